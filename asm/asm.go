@@ -0,0 +1,294 @@
+// Package asm assembles 6502 source code into memory images.
+//
+// The syntax is the one of the MOS Technology manuals:
+//
+//	        .org $0200
+//	start:  ldx #0
+//	loop:   lda msg,x      ; comments run to the end of the line
+//	        beq done
+//	        sta $F001
+//	        inx
+//	        bne loop
+//	done:   jmp done
+//	msg:    .byte "hello", $0A, 0
+//	        .word start
+//
+// Macros are defined between .macro name, params and .endmacro, and invoked
+// like instructions. Code can be assembled conditionally between .if, .else
+// and .endif, and repeated between .repeat count and .endrepeat.
+//
+// Numbers are decimal, hexadecimal with a $ prefix, binary with a % prefix
+// or a character between single quotes. Operands are expressions over
+// numbers and symbols, such as msg+1, <vector or >vector, and constants are
+// defined with name = expression. Values are unsigned 16-bit words: -1 is
+// $FFFF, which is not a byte, so a negative immediate operand is written
+// with its low byte, such as #<-1.
+package asm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/memory"
+	"github.com/leakedmemory/mos6502/symbols"
+)
+
+// Error is a problem found in the source, with the line it was found on.
+type Error struct {
+	Line int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("asm: line %d: %v", e.Line, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// ErrSyntax is returned for lines that can not be parsed.
+	ErrSyntax = errors.New("syntax error")
+	// ErrUndefined is returned for references to symbols never defined.
+	ErrUndefined = errors.New("undefined symbol")
+	// ErrRedefined is returned when a label is defined twice.
+	ErrRedefined = errors.New("symbol redefined")
+	// ErrAddressingMode is returned when an instruction does not support
+	// the addressing mode of its operand.
+	ErrAddressingMode = errors.New("invalid addressing mode")
+	// ErrRange is returned for values too large for their field, including
+	// branches too far from their target.
+	ErrRange = errors.New("value out of range")
+)
+
+// Program is the result of assembling source code.
+type Program struct {
+	// Segments holds the code, one segment per contiguous block started by
+	// an .org directive.
+	Segments []loader.Segment
+	// Symbols holds the labels defined by the source.
+	Symbols *symbols.Table
+	// Listing holds the source lines as they were assembled, with lines in
+	// macros and repeats listed once per expansion.
+	Listing []ListingLine
+}
+
+// Load writes every segment of the program into mem.
+func (p *Program) Load(mem memory.Writer) error {
+	for _, seg := range p.Segments {
+		if err := seg.Load(mem); err != nil {
+			return fmt.Errorf("asm: loading program: %w", err)
+		}
+	}
+	return nil
+}
+
+// Assemble assembles the source code read from r. Code before the first
+// .org directive is placed at $0000.
+func Assemble(r io.Reader) (*Program, error) {
+	var lines []sourceLine
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		lines = append(lines, sourceLine{num: n, text: sc.Text()})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("asm: reading source: %w", err)
+	}
+
+	a := &assembler{
+		syms:  symbols.New(),
+		modes: make(map[int]cpu.AddressingMode),
+	}
+	for a.pass = 1; a.pass <= 2; a.pass++ {
+		a.pc = 0
+		a.full = false
+		a.stmt = 0
+		a.expansions = 0
+		a.segments = nil
+		a.listing = nil
+		a.macros = make(map[string]*macro)
+		if err := a.block(lines); err != nil {
+			return nil, &Error{Line: a.line, Err: err}
+		}
+	}
+	return &Program{Segments: a.segments, Symbols: a.syms, Listing: a.listing}, nil
+}
+
+// AssembleString assembles src.
+func AssembleString(src string) (*Program, error) {
+	return Assemble(strings.NewReader(src))
+}
+
+type assembler struct {
+	pass int
+	line int
+	pc   uint16
+	syms *symbols.Table
+	// stmt counts the instructions assembled in the current pass. Macros and
+	// repeats assemble the same line many times, so instructions are told
+	// apart by their position in the pass instead.
+	stmt int
+	// modes remembers the addressing mode picked for each instruction in the
+	// first pass, so that forward references keep the size they were given.
+	modes map[int]cpu.AddressingMode
+	// full is set once the code fills $FFFF, PC wrapping to $0000, until
+	// the next .org.
+	full       bool
+	macros     map[string]*macro
+	expansions int
+	depth      int
+	segments   []loader.Segment
+	listing    []ListingLine
+}
+
+func (a *assembler) statement(label, mnemonic, operand string) error {
+	if mnemonic == "=" {
+		return a.constant(label, operand)
+	}
+	if label != "" {
+		if err := a.define(label, a.pc); err != nil {
+			return err
+		}
+	}
+	if mnemonic == "" {
+		return nil
+	}
+	if strings.HasPrefix(mnemonic, ".") {
+		return a.directive(mnemonic, operand)
+	}
+	return a.instruction(mnemonic, operand)
+}
+
+func (a *assembler) define(name string, val uint16) error {
+	if !isIdentifier(name) {
+		return fmt.Errorf("%w: invalid symbol name %q", ErrSyntax, name)
+	}
+	if a.pass == 1 {
+		if _, ok := a.syms.Lookup(name); ok {
+			return fmt.Errorf("%w: %s", ErrRedefined, name)
+		}
+		a.syms.Add(name, val)
+	}
+	return nil
+}
+
+// constant defines name as the value of expr. A constant may refer to
+// symbols defined further down; it is then only defined in the second pass.
+func (a *assembler) constant(name, expr string) error {
+	if name == "*" {
+		return a.directive(".org", expr)
+	}
+	v, ok, err := a.eval(expr)
+	if err != nil {
+		return err
+	}
+	switch {
+	case a.pass == 1 && ok:
+		return a.define(name, v)
+	case a.pass == 2 && !ok:
+		return fmt.Errorf("%w: %s", ErrUndefined, expr)
+	case a.pass == 2:
+		a.syms.Add(name, v)
+	}
+	return nil
+}
+
+// emit appends b to the code at PC. Code running past $FFFF is an error
+// rather than wrapping to $0000.
+func (a *assembler) emit(b ...byte) error {
+	if a.full && len(b) != 0 || int(a.pc)+len(b) > 0x10000 {
+		return fmt.Errorf("%w: code runs past $FFFF", ErrRange)
+	}
+	if n := len(a.segments); n == 0 || a.segments[n-1].Addr+uint16(len(a.segments[n-1].Data)) != a.pc {
+		a.segments = append(a.segments, loader.Segment{Addr: a.pc})
+	}
+	seg := &a.segments[len(a.segments)-1]
+	seg.Data = append(seg.Data, b...)
+	if n := len(a.listing); a.pass == 2 && n > 0 {
+		l := &a.listing[n-1]
+		if len(l.Bytes) == 0 {
+			l.Addr = a.pc
+		}
+		l.Bytes = append(l.Bytes, b...)
+	}
+	a.pc += uint16(len(b))
+	a.full = a.full || a.pc == 0 && len(b) != 0
+	return nil
+}
+
+func (a *assembler) directive(name, operand string) error {
+	switch strings.ToLower(name) {
+	case ".org":
+		v, ok, err := a.eval(operand)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: .org needs a value known in advance", ErrUndefined)
+		}
+		a.pc, a.full = v, false
+		return nil
+	case ".byte":
+		return a.data(operand, 1)
+	case ".word":
+		return a.data(operand, 2)
+	default:
+		return fmt.Errorf("%w: unknown directive %s", ErrSyntax, name)
+	}
+}
+
+func (a *assembler) data(operand string, size int) error {
+	items, err := splitList(operand)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("%w: missing data", ErrSyntax)
+	}
+	for _, item := range items {
+		if size == 1 && strings.HasPrefix(item, `"`) {
+			s, err := unquote(item)
+			if err != nil {
+				return err
+			}
+			if err := a.emit([]byte(s)...); err != nil {
+				return err
+			}
+			continue
+		}
+		v, err := a.value(item)
+		if err != nil {
+			return err
+		}
+		if size == 1 {
+			if v > 0xFF {
+				return fmt.Errorf("%w: $%04X is not a byte", ErrRange, v)
+			}
+			err = a.emit(byte(v))
+		} else {
+			err = a.emit(byte(v), byte(v>>8))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// value evaluates expr, which must be known in the second pass.
+func (a *assembler) value(expr string) (uint16, error) {
+	v, ok, err := a.eval(expr)
+	if err != nil {
+		return 0, err
+	}
+	if !ok && a.pass == 2 {
+		return 0, fmt.Errorf("%w: %s", ErrUndefined, expr)
+	}
+	return v, nil
+}
@@ -0,0 +1,248 @@
+package asm
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func assembleTestHelper(t *testing.T, src string) *Program {
+	t.Helper()
+	p, err := AssembleString(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return p
+}
+
+func TestAssembleAddressingModes(t *testing.T) {
+	p := assembleTestHelper(t, `
+		.org $0200
+		nop
+		asl
+		asl a
+		lda #$42
+		lda $10
+		lda $10,x
+		ldx $10,y
+		lda $1234
+		lda $1234,X
+		lda $1234, y
+		jmp ($FFFC)
+		lda ($20,x)
+		lda ($20),y
+	`)
+
+	expected := []byte{
+		0xEA,
+		0x0A,
+		0x0A,
+		0xA9, 0x42,
+		0xA5, 0x10,
+		0xB5, 0x10,
+		0xB6, 0x10,
+		0xAD, 0x34, 0x12,
+		0xBD, 0x34, 0x12,
+		0xB9, 0x34, 0x12,
+		0x6C, 0xFC, 0xFF,
+		0xA1, 0x20,
+		0xB1, 0x20,
+	}
+	if len(p.Segments) != 1 || p.Segments[0].Addr != 0x0200 || !bytes.Equal(p.Segments[0].Data, expected) {
+		t.Errorf("expected % X, actual %+v\n", expected, p.Segments)
+	}
+}
+
+func TestAssembleLabelsAndDirectives(t *testing.T) {
+	p := assembleTestHelper(t, `
+		.org $0200
+start:  ldx #0
+loop:   lda msg,x      ; forward reference: absolute,X
+		beq done
+		sta $F001
+		inx
+		bne loop
+done:   jmp done
+msg:    .byte "hi;", $0A, 0, 'x'
+		.word start, $FFFF
+		.org $FFFC
+		.word start
+	`)
+
+	expected := []byte{
+		0xA2, 0x00,
+		0xBD, 0x10, 0x02,
+		0xF0, 0x06,
+		0x8D, 0x01, 0xF0,
+		0xE8,
+		0xD0, 0xF5,
+		0x4C, 0x0D, 0x02,
+		'h', 'i', ';', 0x0A, 0x00, 'x',
+		0x00, 0x02, 0xFF, 0xFF,
+	}
+	if len(p.Segments) != 2 || !bytes.Equal(p.Segments[0].Data, expected) {
+		t.Fatalf("expected % X, actual %+v\n", expected, p.Segments)
+	}
+	if addr, _ := p.Symbols.Lookup("msg"); addr != 0x0210 {
+		t.Errorf("expected msg at $0210, actual $%04X\n", addr)
+	}
+
+	mem := memory.Memory{}
+	if err := p.Load(&mem); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if memory.ReadWord(&mem, 0xFFFC) != 0x0200 {
+		t.Errorf("expected reset vector to point to start")
+	}
+}
+
+func TestAssembleForwardZeroPageStaysAbsolute(t *testing.T) {
+	p := assembleTestHelper(t, `
+		lda ptr
+ptr:	.byte 0
+	`)
+
+	expected := []byte{0xAD, 0x03, 0x00, 0x00}
+	if !bytes.Equal(p.Segments[0].Data, expected) {
+		t.Errorf("expected % X, actual % X\n", expected, p.Segments[0].Data)
+	}
+}
+
+func TestAssembleConstantsAndExpressions(t *testing.T) {
+	p := assembleTestHelper(t, `
+SCREEN = $0400
+ZP = $10
+COLS = 40
+		* = $0200
+start:	lda #<vector
+		ldx #>vector
+		sta SCREEN+COLS*2+1
+		sta ZP+1
+		lda (ZP+2),y
+		jmp (vector)
+		lda #(1+2)*3
+		lda #[1+2]*3
+		lda #%1010 & ~%10 | 1 << 4
+		.word * - start, END
+vector:	.word start
+END = vector + 2
+	`)
+
+	expected := []byte{
+		0xA9, 0x18,
+		0xA2, 0x02,
+		0x8D, 0x51, 0x04,
+		0x85, 0x11,
+		0xB1, 0x12,
+		0x6C, 0x18, 0x02,
+		0xA9, 0x09,
+		0xA9, 0x09,
+		0xA9, 0x18,
+		0x14, 0x00, 0x1A, 0x02,
+		0x00, 0x02,
+	}
+	if !bytes.Equal(p.Segments[0].Data, expected) {
+		t.Errorf("expected % X, actual % X\n", expected, p.Segments[0].Data)
+	}
+	if v, ok := p.Symbols.Lookup("END"); !ok || v != 0x021A {
+		t.Errorf("expected END = $021A, actual $%04X (%t)\n", v, ok)
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		err  error
+		line int
+	}{
+		{"\n\tldz #1", ErrSyntax, 2},
+		{"\tjmp nowhere", ErrUndefined, 1},
+		{"a:\n\tnop\na:\n", ErrRedefined, 3},
+		{"\tstx $10,x", ErrAddressingMode, 1},
+		{"\tsta #1", ErrAddressingMode, 1},
+		{"\tlda #$100", ErrRange, 1},
+		{"\t.org $0200\nhere:\n\t.org $0300\n\tbne here", ErrRange, 4},
+		{"\t.bogus 1", ErrSyntax, 1},
+		{"\tlda #1/0", ErrRange, 1},
+		{"\t.org $FFFE\n\t.byte 1,2,3", ErrRange, 2},
+		{"\t.org $FFFE\n\tjmp $0200", ErrRange, 2},
+		{"\tlda #-1", ErrRange, 1},
+		{"\tlda (1+2", ErrSyntax, 1},
+		{"n = 1\nn = 2", ErrRedefined, 2},
+		{"n = later", ErrUndefined, 1},
+		{"\t.if 1\n\tnop", ErrSyntax, 1},
+		{"\tnop\n\t.endif", ErrSyntax, 2},
+		{"\t.if later\n\t.endif\nlater:", ErrUndefined, 1},
+		{"\t.macro m a\n\tnop\n\t.endmacro\n\tm", ErrSyntax, 4},
+		{"\t.macro m\n\tm\n\t.endmacro\n\tm", ErrSyntax, 2},
+	} {
+		_, err := AssembleString(tc.src)
+		var asmErr *Error
+		if !errors.Is(err, tc.err) || !errors.As(err, &asmErr) || asmErr.Line != tc.line {
+			t.Errorf("%q: expected %v on line %d, actual %v\n", tc.src, tc.err, tc.line, err)
+		}
+	}
+}
+
+func TestAssembleMacrosAndConditionals(t *testing.T) {
+	p := assembleTestHelper(t, `
+DEBUG = 0
+		.macro store value, addr
+		lda #value
+		sta addr
+		.endmacro
+		.macro wait count
+		ldx #count
+loop\@:	dex
+		bne loop\@
+		.endmacro
+
+		.org $0200
+		store $F, $D020
+		wait 2
+		wait 3
+		.if DEBUG
+		brk
+		.else
+		nop
+		.if DEBUG = 0
+		.byte 1
+		.endif
+		.endif
+		.repeat 3, i
+		.byte i * 2
+		.endrepeat
+		.byte "F"
+	`)
+
+	expected := []byte{
+		0xA9, 0x0F, 0x8D, 0x20, 0xD0,
+		0xA2, 0x02, 0xCA, 0xD0, 0xFD,
+		0xA2, 0x03, 0xCA, 0xD0, 0xFD,
+		0xEA,
+		0x01,
+		0x00, 0x02, 0x04,
+		'F',
+	}
+	if !bytes.Equal(p.Segments[0].Data, expected) {
+		t.Errorf("expected % X, actual % X\n", expected, p.Segments[0].Data)
+	}
+	if _, ok := p.Symbols.Lookup("loop2"); !ok {
+		t.Errorf("expected the label of the second expansion to be defined\n")
+	}
+}
+
+func TestAssembleEndOfMemory(t *testing.T) {
+	p := assembleTestHelper(t, "\t.org $0200\n\tlda #<-1\n\t.org $FFFA\n\t.word 1, 2, 3")
+	if len(p.Segments) != 2 {
+		t.Fatalf("expected 2 segments, actual %d", len(p.Segments))
+	}
+	if data := p.Segments[0].Data; string(data) != "\xA9\xFF" {
+		t.Errorf("expected LDA #$FF, actual % X\n", data)
+	}
+	if seg := p.Segments[1]; seg.Addr != 0xFFFA || len(seg.Data) != 6 {
+		t.Errorf("expected 6 bytes at $FFFA, actual %d at $%04X\n", len(seg.Data), seg.Addr)
+	}
+}
@@ -0,0 +1,219 @@
+package asm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expressions are evaluated with the usual precedence, from lowest to
+// highest:
+//
+//	= == <> != < > <= >= (1 when true, 0 when false)
+//	|
+//	^
+//	&
+//	<< >>
+//	+ -
+//	* / %
+//	unary - ~ < (low byte) > (high byte)
+//
+// Terms are numbers, symbols, * for the address of the current line, and
+// expressions grouped with parentheses or square brackets.
+type exprParser struct {
+	a   *assembler
+	s   string
+	pos int
+	// known is cleared when the expression refers to an undefined symbol.
+	known bool
+}
+
+var binaryLevels = [][]string{
+	{"<=", ">=", "<>", "!=", "==", "=", "<", ">"},
+	{"|"},
+	{"^"},
+	{"&"},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (a *assembler) evalExpr(s string) (uint16, bool, error) {
+	p := &exprParser{a: a, s: s, known: true}
+	v, err := p.binary(0)
+	if err != nil {
+		return 0, false, err
+	}
+	p.skipSpaces()
+	if p.pos != len(p.s) {
+		return 0, false, fmt.Errorf("%w: unexpected %q in expression", ErrSyntax, p.s[p.pos:])
+	}
+	return uint16(v), p.known, nil
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// operator consumes and returns one of ops if it comes next.
+func (p *exprParser) operator(ops []string) (string, bool) {
+	p.skipSpaces()
+	for _, op := range ops {
+		if strings.HasPrefix(p.s[p.pos:], op) {
+			// Keep << and >> from being read as < or >.
+			if len(op) == 1 && p.pos+1 < len(p.s) && (op == "<" || op == ">") && p.s[p.pos+1] == op[0] {
+				continue
+			}
+			p.pos += len(op)
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) binary(level int) (int, error) {
+	if level == len(binaryLevels) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op, ok := p.operator(binaryLevels[level])
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return 0, err
+		}
+		if left, err = apply(op, left, right); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func apply(op string, l, r int) (int, error) {
+	switch op {
+	case "=", "==":
+		return boolValue(l == r), nil
+	case "<>", "!=":
+		return boolValue(l != r), nil
+	case "<":
+		return boolValue(l < r), nil
+	case ">":
+		return boolValue(l > r), nil
+	case "<=":
+		return boolValue(l <= r), nil
+	case ">=":
+		return boolValue(l >= r), nil
+	case "|":
+		return l | r, nil
+	case "^":
+		return l ^ r, nil
+	case "&":
+		return l & r, nil
+	case "<<":
+		return l << (r & 0xF), nil
+	case ">>":
+		return l >> (r & 0xF), nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	}
+	if r == 0 {
+		return 0, fmt.Errorf("%w: division by zero", ErrRange)
+	}
+	if op == "/" {
+		return l / r, nil
+	}
+	return l % r, nil
+}
+
+func boolValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (p *exprParser) unary() (int, error) {
+	op, ok := p.operator([]string{"-", "~", "<", ">"})
+	if !ok {
+		return p.term()
+	}
+	v, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	switch op {
+	case "-":
+		return -v, nil
+	case "~":
+		return ^v & 0xFFFF, nil
+	case "<":
+		return v & 0xFF, nil
+	default:
+		return v >> 8 & 0xFF, nil
+	}
+}
+
+func (p *exprParser) term() (int, error) {
+	p.skipSpaces()
+	if p.pos == len(p.s) {
+		return 0, fmt.Errorf("%w: missing value", ErrSyntax)
+	}
+
+	switch c := p.s[p.pos]; {
+	case c == '(' || c == '[':
+		closing := map[byte]byte{'(': ')', '[': ']'}[c]
+		p.pos++
+		v, err := p.binary(0)
+		if err != nil {
+			return 0, err
+		}
+		p.skipSpaces()
+		if p.pos == len(p.s) || p.s[p.pos] != closing {
+			return 0, fmt.Errorf("%w: missing %c", ErrSyntax, closing)
+		}
+		p.pos++
+		return v, nil
+	case c == '*':
+		p.pos++
+		return int(p.a.pc), nil
+	case c == '\'':
+		if p.pos+2 >= len(p.s) || p.s[p.pos+2] != '\'' {
+			return 0, fmt.Errorf("%w: invalid character literal", ErrSyntax)
+		}
+		p.pos += 3
+		return int(p.s[p.pos-2]), nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && isTermChar(p.s[p.pos]) {
+		p.pos++
+	}
+	tok := p.s[start:p.pos]
+	if tok == "" {
+		return 0, fmt.Errorf("%w: unexpected %q in expression", ErrSyntax, p.s[start:])
+	}
+	if isIdentifier(tok) {
+		v, ok := p.a.syms.Lookup(tok)
+		if !ok {
+			p.known = false
+		}
+		return int(v), nil
+	}
+	v, err := parseNumber(tok)
+	return int(v), err
+}
+
+func isTermChar(c byte) bool {
+	return c == '_' || c == '.' || c == '$' || c == '%' ||
+		c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
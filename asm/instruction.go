@@ -0,0 +1,184 @@
+package asm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// opcodes maps each mnemonic and addressing mode to its opcode, derived from
+// the opcode table of the CPU.
+var opcodes = func() map[string]map[cpu.AddressingMode]byte {
+	m := make(map[string]map[cpu.AddressingMode]byte)
+	for op := range 256 {
+		info := cpu.Opcode(byte(op))
+		if !info.Documented() {
+			continue
+		}
+		if m[info.Mnemonic] == nil {
+			m[info.Mnemonic] = make(map[cpu.AddressingMode]byte)
+		}
+		m[info.Mnemonic][info.Mode] = byte(op)
+	}
+	return m
+}()
+
+// operandKind is the syntactic shape of an operand, before the addressing
+// mode is chosen from it.
+type operandKind byte
+
+const (
+	kindNone operandKind = iota
+	kindAccumulator
+	kindImmediate
+	kindDirect
+	kindDirectX
+	kindDirectY
+	kindIndirect
+	kindIndirectX
+	kindIndirectY
+)
+
+func parseOperand(s string) (operandKind, string) {
+	upper := strings.ToUpper(s)
+	switch {
+	case s == "":
+		return kindNone, ""
+	case upper == "A":
+		return kindAccumulator, ""
+	case strings.HasPrefix(s, "#"):
+		return kindImmediate, s[1:]
+	case strings.HasPrefix(s, "(") && strings.HasSuffix(trimSpaces(upper), ",X)"):
+		t := trimSpaces(s)
+		return kindIndirectX, t[1 : len(t)-3]
+	case strings.HasPrefix(s, "(") && strings.HasSuffix(trimSpaces(upper), "),Y"):
+		t := trimSpaces(s)
+		return kindIndirectY, t[1 : len(t)-3]
+	case strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")"):
+		return kindIndirect, s[1 : len(s)-1]
+	case strings.HasSuffix(trimSpaces(upper), ",X"):
+		t := trimSpaces(s)
+		return kindDirectX, t[:len(t)-2]
+	case strings.HasSuffix(trimSpaces(upper), ",Y"):
+		t := trimSpaces(s)
+		return kindDirectY, t[:len(t)-2]
+	default:
+		return kindDirect, s
+	}
+}
+
+func trimSpaces(s string) string {
+	return strings.ReplaceAll(s, " ", "")
+}
+
+func (a *assembler) instruction(mnemonic, operand string) error {
+	mnemonic = strings.ToUpper(mnemonic)
+	modes, ok := opcodes[mnemonic]
+	if !ok {
+		return fmt.Errorf("%w: unknown mnemonic %s", ErrSyntax, mnemonic)
+	}
+	a.stmt++
+	kind, expr := parseOperand(operand)
+	if _, ok := modes[cpu.Indirect]; kind == kindIndirect && !ok {
+		// Only JMP has an indirect mode; for anything else the parentheses
+		// group an expression.
+		kind, expr = kindDirect, operand
+	}
+	mode, err := a.addressingMode(modes, kind, expr)
+	if err != nil {
+		return err
+	}
+	op := modes[mode]
+
+	switch {
+	case mode.Bytes() == 1:
+		return a.emit(op)
+	case mode == cpu.Relative:
+		target, err := a.value(expr)
+		if err != nil {
+			return err
+		}
+		offset := int(target) - int(a.pc+2)
+		if a.pass == 2 && (offset < -128 || offset > 127) {
+			return fmt.Errorf("%w: branch to $%04X is %d bytes away", ErrRange, target, offset)
+		}
+		return a.emit(op, byte(offset))
+	case mode.Bytes() == 2:
+		v, err := a.value(expr)
+		if err != nil {
+			return err
+		}
+		if a.pass == 2 && v > 0xFF {
+			return fmt.Errorf("%w: $%04X is not a byte", ErrRange, v)
+		}
+		return a.emit(op, byte(v))
+	default:
+		v, err := a.value(expr)
+		if err != nil {
+			return err
+		}
+		return a.emit(op, byte(v), byte(v>>8))
+	}
+}
+
+// addressingMode picks the addressing mode for an operand. Between zero page
+// and absolute forms, zero page is picked in the first pass when the operand
+// is already known to fit in it; the second pass repeats that choice so that
+// every instruction keeps its size.
+func (a *assembler) addressingMode(modes map[cpu.AddressingMode]byte, kind operandKind, expr string) (cpu.AddressingMode, error) {
+	var candidates []cpu.AddressingMode
+	switch kind {
+	case kindNone:
+		candidates = []cpu.AddressingMode{cpu.Implied, cpu.Accumulator}
+	case kindAccumulator:
+		candidates = []cpu.AddressingMode{cpu.Accumulator}
+	case kindImmediate:
+		candidates = []cpu.AddressingMode{cpu.Immediate}
+	case kindIndirect:
+		candidates = []cpu.AddressingMode{cpu.Indirect}
+	case kindIndirectX:
+		candidates = []cpu.AddressingMode{cpu.IndexedIndirect}
+	case kindIndirectY:
+		candidates = []cpu.AddressingMode{cpu.IndirectIndexed}
+	case kindDirect:
+		if _, ok := modes[cpu.Relative]; ok {
+			return cpu.Relative, nil
+		}
+		return a.directMode(modes, expr, cpu.ZeroPage, cpu.Absolute)
+	case kindDirectX:
+		return a.directMode(modes, expr, cpu.ZeroPageX, cpu.AbsoluteX)
+	case kindDirectY:
+		return a.directMode(modes, expr, cpu.ZeroPageY, cpu.AbsoluteY)
+	}
+	for _, m := range candidates {
+		if _, ok := modes[m]; ok {
+			return m, nil
+		}
+	}
+	return 0, ErrAddressingMode
+}
+
+func (a *assembler) directMode(modes map[cpu.AddressingMode]byte, expr string, zp, abs cpu.AddressingMode) (cpu.AddressingMode, error) {
+	if a.pass == 2 {
+		return a.modes[a.stmt], nil
+	}
+	_, hasZP := modes[zp]
+	_, hasAbs := modes[abs]
+	v, known, err := a.eval(expr)
+	if err != nil {
+		return 0, err
+	}
+
+	var mode cpu.AddressingMode
+	switch {
+	case hasZP && (known && v <= 0xFF || !hasAbs):
+		mode = zp
+	case hasAbs:
+		mode = abs
+	default:
+		return 0, ErrAddressingMode
+	}
+	a.modes[a.stmt] = mode
+	return mode, nil
+}
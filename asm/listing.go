@@ -0,0 +1,71 @@
+package asm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// listingRowBytes is the number of bytes shown on each row of a listing.
+// Lines producing more continue on rows of their own.
+const listingRowBytes = 3
+
+// ListingLine is a source line with the address it was assembled at and the
+// bytes it produced.
+type ListingLine struct {
+	Line   int
+	Addr   uint16
+	Bytes  []byte
+	Source string
+}
+
+// WriteListing writes the listing of the program to w, one row per source
+// line with its number, address, bytes and text:
+//
+//	3  0200  A2 00      start:  ldx #0
+func (p *Program) WriteListing(w io.Writer) error {
+	lw := &listingWriter{w: w}
+	for _, l := range p.Listing {
+		first := min(len(l.Bytes), listingRowBytes)
+		lw.printf("%5d  %04X  %-9s  %s\n", l.Line, l.Addr, hexBytes(l.Bytes[:first]), l.Source)
+		for i := first; i < len(l.Bytes); i += listingRowBytes {
+			row := l.Bytes[i:min(i+listingRowBytes, len(l.Bytes))]
+			lw.printf("       %04X  %s\n", l.Addr+uint16(i), hexBytes(row))
+		}
+	}
+	return lw.err
+}
+
+// WriteSymbols writes the symbol table of the program to w, ordered by
+// address, as constant definitions the assembler accepts:
+//
+//	start = $0200
+func (p *Program) WriteSymbols(w io.Writer) error {
+	lw := &listingWriter{w: w}
+	for name, addr := range p.Symbols.All() {
+		lw.printf("%s = $%04X\n", name, addr)
+	}
+	return lw.err
+}
+
+func hexBytes(b []byte) string {
+	s := make([]string, len(b))
+	for i, v := range b {
+		s[i] = fmt.Sprintf("%02X", v)
+	}
+	return strings.Join(s, " ")
+}
+
+type listingWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (lw *listingWriter) printf(format string, args ...any) {
+	if lw.err != nil {
+		return
+	}
+	if _, err := fmt.Fprintf(lw.w, format, args...); err != nil {
+		lw.err = fmt.Errorf("asm: writing listing: %w", err)
+	}
+}
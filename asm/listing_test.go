@@ -0,0 +1,37 @@
+package asm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteListing(t *testing.T) {
+	p := assembleTestHelper(t, "\t.org $0200\nstart:\tldx #0\n\t.byte 1, 2, 3, 4\n\tjmp start")
+
+	var b strings.Builder
+	if err := p.WriteListing(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "" +
+		"    1  0000             \t.org $0200\n" +
+		"    2  0200  A2 00      start:\tldx #0\n" +
+		"    3  0202  01 02 03   \t.byte 1, 2, 3, 4\n" +
+		"       0205  04\n" +
+		"    4  0206  4C 00 02   \tjmp start\n"
+	if b.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, b.String())
+	}
+}
+
+func TestWriteSymbols(t *testing.T) {
+	p := assembleTestHelper(t, "\t.org $0200\nloop:\tjmp loop\nIO = $D000\nend:")
+
+	var b strings.Builder
+	if err := p.WriteSymbols(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "loop = $0200\nend = $0203\nIO = $D000\n"
+	if b.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, b.String())
+	}
+}
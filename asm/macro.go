@@ -0,0 +1,274 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxExpansionDepth bounds how deeply macros may expand into each other, so
+// that a macro invoking itself fails instead of exhausting the stack.
+const maxExpansionDepth = 64
+
+type sourceLine struct {
+	num  int
+	text string
+}
+
+type macro struct {
+	params []string
+	body   []sourceLine
+}
+
+// block assembles lines, handling the directives that span several lines:
+// .macro/.endmacro, .if/.else/.endif and .repeat/.endrepeat.
+func (a *assembler) block(lines []sourceLine) error {
+	for i := 0; i < len(lines); i++ {
+		a.line = lines[i].num
+		if a.pass == 2 {
+			a.listing = append(a.listing, ListingLine{Line: a.line, Addr: a.pc, Source: lines[i].text})
+		}
+		label, mnemonic, operand, err := splitLine(lines[i].text)
+		if err != nil {
+			return err
+		}
+
+		switch directive := strings.ToLower(mnemonic); directive {
+		case ".macro", ".if", ".repeat":
+			end, err := a.matching(lines, i, directive)
+			if err != nil {
+				return err
+			}
+			if err := a.statement(label, "", ""); err != nil {
+				return err
+			}
+			body := lines[i+1 : end]
+			switch directive {
+			case ".macro":
+				err = a.defineMacro(operand, body)
+			case ".if":
+				err = a.conditional(operand, body)
+			default:
+				err = a.repeat(operand, body)
+			}
+			if err != nil {
+				return err
+			}
+			i = end
+		case ".endmacro", ".else", ".endif", ".endrepeat":
+			return fmt.Errorf("%w: %s without an opening directive", ErrSyntax, mnemonic)
+		default:
+			m, ok := a.macros[mnemonic]
+			if !ok {
+				if err := a.statement(label, mnemonic, operand); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := a.statement(label, "", ""); err != nil {
+				return err
+			}
+			if err := a.expand(mnemonic, m, operand); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var closingDirectives = map[string]string{
+	".macro":  ".endmacro",
+	".if":     ".endif",
+	".repeat": ".endrepeat",
+}
+
+// matching returns the index of the line closing the directive opened on
+// lines[start], skipping over nested directives of the same kind.
+func (a *assembler) matching(lines []sourceLine, start int, open string) (int, error) {
+	closing := closingDirectives[open]
+	depth := 0
+	for i := start + 1; i < len(lines); i++ {
+		_, mnemonic, _, _ := splitLine(lines[i].text)
+		switch strings.ToLower(mnemonic) {
+		case open:
+			depth++
+		case closing:
+			if depth == 0 {
+				return i, nil
+			}
+			depth--
+		}
+	}
+	return 0, fmt.Errorf("%w: %s without %s", ErrSyntax, open, closing)
+}
+
+func (a *assembler) defineMacro(operand string, body []sourceLine) error {
+	name, rest, _ := strings.Cut(strings.TrimSpace(operand), " ")
+	if !isIdentifier(name) {
+		return fmt.Errorf("%w: invalid macro name %q", ErrSyntax, name)
+	}
+	if _, ok := a.macros[name]; ok {
+		return fmt.Errorf("%w: macro %s", ErrRedefined, name)
+	}
+	if _, ok := opcodes[strings.ToUpper(name)]; ok {
+		return fmt.Errorf("%w: macro %s would hide an instruction", ErrRedefined, name)
+	}
+	params, err := splitList(rest)
+	if err != nil {
+		return err
+	}
+	for _, p := range params {
+		if !isIdentifier(p) {
+			return fmt.Errorf("%w: invalid macro parameter %q", ErrSyntax, p)
+		}
+	}
+	a.macros[name] = &macro{params: params, body: body}
+	return nil
+}
+
+// expand assembles the body of a macro with its parameters replaced by the
+// arguments of the invocation. \@ is replaced by a number unique to the
+// expansion, for labels local to it.
+func (a *assembler) expand(name string, m *macro, operand string) error {
+	args, err := splitList(operand)
+	if err != nil {
+		return err
+	}
+	if len(args) != len(m.params) {
+		return fmt.Errorf("%w: macro %s takes %d arguments, got %d", ErrSyntax, name, len(m.params), len(args))
+	}
+	if a.depth == maxExpansionDepth {
+		return fmt.Errorf("%w: macros nested too deeply", ErrSyntax)
+	}
+
+	a.expansions++
+	unique := strconv.Itoa(a.expansions)
+	subs := make(map[string]string, len(args))
+	for i, p := range m.params {
+		subs[p] = args[i]
+	}
+	lines := make([]sourceLine, len(m.body))
+	for i, l := range m.body {
+		text := strings.ReplaceAll(l.text, `\@`, unique)
+		lines[i] = sourceLine{num: l.num, text: substitute(text, subs)}
+	}
+
+	a.depth++
+	defer func() { a.depth-- }()
+	return a.block(lines)
+}
+
+func (a *assembler) conditional(operand string, body []sourceLine) error {
+	cond, ok, err := a.eval(operand)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: .if needs a value known in advance", ErrUndefined)
+	}
+
+	then, otherwise := splitElse(body)
+	if cond != 0 {
+		return a.block(then)
+	}
+	return a.block(otherwise)
+}
+
+// splitElse splits the body of an .if at its .else, if it has one.
+func splitElse(body []sourceLine) (then, otherwise []sourceLine) {
+	depth := 0
+	for i, l := range body {
+		_, mnemonic, _, _ := splitLine(l.text)
+		switch strings.ToLower(mnemonic) {
+		case ".if":
+			depth++
+		case ".endif":
+			depth--
+		case ".else":
+			if depth == 0 {
+				return body[:i], body[i+1:]
+			}
+		}
+	}
+	return body, nil
+}
+
+// repeat assembles body count times. With a second operand, that name is
+// replaced in the body by the number of the repetition, counting from 0.
+func (a *assembler) repeat(operand string, body []sourceLine) error {
+	items, err := splitList(operand)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 || len(items) > 2 {
+		return fmt.Errorf("%w: .repeat takes a count and an optional name", ErrSyntax)
+	}
+	count, ok, err := a.eval(items[0])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: .repeat needs a value known in advance", ErrUndefined)
+	}
+	var name string
+	if len(items) == 2 {
+		if name = items[1]; !isIdentifier(name) {
+			return fmt.Errorf("%w: invalid symbol name %q", ErrSyntax, name)
+		}
+	}
+
+	for n := range int(count) {
+		lines := make([]sourceLine, len(body))
+		for i, l := range body {
+			lines[i] = sourceLine{num: l.num, text: l.text}
+			if name != "" {
+				lines[i].text = substitute(l.text, map[string]string{name: strconv.Itoa(n)})
+			}
+		}
+		if err := a.block(lines); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// substitute replaces the identifiers of text found in subs, leaving strings
+// and character literals alone.
+func substitute(text string, subs map[string]string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(text) && text[j] != '"' {
+				j++
+			}
+			if j < len(text) {
+				j++
+			}
+			b.WriteString(text[i:j])
+			i = j
+		case c == '\'' && i+2 < len(text):
+			b.WriteString(text[i : i+3])
+			i += 3
+		case isTermChar(c):
+			// Numbers such as $FF are copied whole, so that their digits are
+			// not taken for identifiers.
+			j := i + 1
+			for j < len(text) && isTermChar(text[j]) && text[j] != '$' && text[j] != '%' {
+				j++
+			}
+			word := text[i:j]
+			if s, ok := subs[word]; ok && isIdentifier(word) {
+				word = s
+			}
+			b.WriteString(word)
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// splitLine splits a source line into its label, mnemonic or directive, and
+// operand, dropping the comment.
+func splitLine(text string) (label, mnemonic, operand string, err error) {
+	text = strings.TrimSpace(stripComment(text))
+	if text == "" {
+		return "", "", "", nil
+	}
+
+	if i := strings.IndexByte(text, ':'); i > 0 && isIdentifier(text[:i]) {
+		label, text = text[:i], strings.TrimSpace(text[i+1:])
+	}
+	if name, expr, ok := strings.Cut(text, "="); ok && label == "" {
+		// Constant definitions, and * = addr as a synonym for .org.
+		if name = strings.TrimSpace(name); name == "*" || isIdentifier(name) {
+			return name, "=", strings.TrimSpace(expr), nil
+		}
+	}
+	if text == "" {
+		return label, "", "", nil
+	}
+	mnemonic, operand, _ = strings.Cut(text, " ")
+	if m, o, ok := strings.Cut(mnemonic, "\t"); ok {
+		mnemonic, operand = m, o+" "+operand
+	}
+	return label, mnemonic, strings.TrimSpace(operand), nil
+}
+
+// stripComment removes everything after a ; that is not inside a string or
+// character literal.
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"':
+			quote = c
+		case c == '\'':
+			// A character literal is always three characters long.
+			i += 2
+		case c == ';':
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// splitList splits a comma separated list, keeping strings whole.
+func splitList(s string) ([]string, error) {
+	var items []string
+	var quote bool
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quote = !quote
+		case s[i] == '\'' && !quote:
+			i += 2
+		case s[i] == ',' && !quote:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if quote {
+		return nil, fmt.Errorf("%w: unterminated string", ErrSyntax)
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(items) > 0 {
+		items = append(items, last)
+	}
+	for _, item := range items {
+		if item == "" {
+			return nil, fmt.Errorf("%w: empty list item", ErrSyntax)
+		}
+	}
+	return items, nil
+}
+
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("%w: unterminated string", ErrSyntax)
+	}
+	return s[1 : len(s)-1], nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || r == '.' && i == 0 || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// eval evaluates an expression. ok is false when it refers to a symbol not
+// defined yet.
+func (a *assembler) eval(expr string) (val uint16, ok bool, err error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return 0, false, fmt.Errorf("%w: missing value", ErrSyntax)
+	}
+	return a.evalExpr(expr)
+}
+
+func parseNumber(s string) (uint16, error) {
+	var (
+		n   uint64
+		err error
+	)
+	switch {
+	case strings.HasPrefix(s, "$"):
+		n, err = strconv.ParseUint(s[1:], 16, 16)
+	case strings.HasPrefix(s, "%"):
+		n, err = strconv.ParseUint(s[1:], 2, 16)
+	case len(s) == 3 && s[0] == '\'' && s[2] == '\'':
+		n = uint64(s[1])
+	default:
+		n, err = strconv.ParseUint(s, 10, 16)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid number %q", ErrSyntax, s)
+	}
+	return uint16(n), nil
+}
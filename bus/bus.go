@@ -0,0 +1,248 @@
+// Package bus provides the pieces that sit between the CPU and the memory it
+// addresses.
+package bus
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+const addressSpaceSize = 0x10000
+
+// ErrOverlap is returned when mapping a device over addresses that are
+// already mapped.
+var ErrOverlap = errors.New("bus: region overlaps an existing mapping")
+
+// Device is a component mapped into a region of the address space. The
+// addresses it receives are relative to the start of its region.
+type Device interface {
+	Read(addr uint16) byte
+	Write(val byte, addr uint16)
+}
+
+// Peeker is implemented by devices whose reads have side effects, such as
+// clearing status flags or advancing a FIFO. Peek returns what Read would
+// return without changing the state of the device.
+type Peeker interface {
+	Peek(addr uint16) byte
+}
+
+// Poker is implemented by devices whose writes have side effects or are
+// ignored, such as ROM. Poke changes the content the device returns for addr
+// without triggering any side effect.
+type Poker interface {
+	Poke(val byte, addr uint16)
+}
+
+type region struct {
+	addrRange
+	dev Device
+}
+
+// Bus routes accesses to the devices mapped into the 64 KiB address space.
+//
+// A Bus created with NewWithBackend sends accesses to addresses without a
+// device to its backend. Otherwise reads from those addresses return the last
+// value driven on the data bus, like the floating bus of a real system,
+// unless SetOpenBusValue was called, and writes to them are dropped.
+type Bus struct {
+	backend memory.ReadWriter
+	regions []region
+	// lookup holds, for every address, the index of its region plus one, or
+	// zero if it is unmapped.
+	lookup [addressSpaceSize]uint16
+
+	last     byte
+	fixed    bool
+	fixedVal byte
+
+	stall    func(cycles uint)
+	watchers watchers
+}
+
+// New returns a Bus with nothing mapped.
+func New() *Bus {
+	return &Bus{}
+}
+
+// NewWithBackend returns a Bus whose whole address space is backed by mem,
+// with devices mapped later taking precedence over it. The backend can be
+// any memory.ReadWriter: a flat memory.Memory is the fastest, while
+// memory.Paged and memory.Sparse use less host memory.
+func NewWithBackend(mem memory.ReadWriter) *Bus {
+	return &Bus{backend: mem}
+}
+
+// Map maps d to the addresses from start to end, inclusive.
+func (b *Bus) Map(start, end uint16, d Device) error {
+	if end < start {
+		return fmt.Errorf("bus: invalid region $%04X-$%04X", start, end)
+	}
+	for addr := int(start); addr <= int(end); addr++ {
+		if b.lookup[addr] != 0 {
+			return fmt.Errorf("%w: $%04X", ErrOverlap, addr)
+		}
+	}
+	b.regions = append(b.regions, region{addrRange: addrRange{start: start, end: end}, dev: d})
+	idx := uint16(len(b.regions))
+	for addr := int(start); addr <= int(end); addr++ {
+		b.lookup[addr] = idx
+	}
+	return nil
+}
+
+// SetOpenBusValue makes reads from unmapped addresses return val instead of
+// the last value driven on the data bus.
+func (b *Bus) SetOpenBusValue(val byte) {
+	b.fixed = true
+	b.fixedVal = val
+}
+
+// Read returns the content from addr in the device mapped there.
+func (b *Bus) Read(addr uint16) byte {
+	r := b.region(addr)
+	if r == nil {
+		if b.backend != nil {
+			b.last = b.backend.Read(addr)
+			return b.last
+		}
+		if b.fixed {
+			return b.fixedVal
+		}
+		return b.last
+	}
+	b.last = r.dev.Read(addr - r.start)
+	return b.last
+}
+
+// Write changes the content of addr in the device mapped there to val.
+func (b *Bus) Write(val byte, addr uint16) {
+	b.notify(val, addr)
+	b.last = val
+	if r := b.region(addr); r != nil {
+		r.dev.Write(val, addr-r.start)
+	} else if b.backend != nil {
+		b.backend.Write(val, addr)
+	}
+}
+
+// Peek returns the content from addr without side effects, for debuggers. It
+// uses the Peek method of the device mapped there if it has one, falling back
+// to Read otherwise, and leaves the open-bus value untouched.
+func (b *Bus) Peek(addr uint16) byte {
+	r := b.region(addr)
+	if r == nil {
+		if b.backend != nil {
+			return b.backend.Read(addr)
+		}
+		if b.fixed {
+			return b.fixedVal
+		}
+		return b.last
+	}
+	if p, ok := r.dev.(Peeker); ok {
+		return p.Peek(addr - r.start)
+	}
+	return r.dev.Read(addr - r.start)
+}
+
+// Poke changes the content of addr to val without side effects, for
+// debuggers. It uses the Poke method of the device mapped there if it has
+// one, falling back to Write otherwise.
+func (b *Bus) Poke(val byte, addr uint16) {
+	b.notify(val, addr)
+	r := b.region(addr)
+	if r == nil {
+		if b.backend != nil {
+			b.backend.Write(val, addr)
+		}
+		return
+	}
+	if p, ok := r.dev.(Poker); ok {
+		p.Poke(val, addr-r.start)
+		return
+	}
+	r.dev.Write(val, addr-r.start)
+}
+
+// DebugView returns a memory.ReadWriter whose reads and writes go through
+// Peek and Poke, so helpers such as memory.NewSnapshot can be used on the bus
+// without disturbing devices.
+func (b *Bus) DebugView() memory.ReadWriter {
+	return debugView{b}
+}
+
+type debugView struct {
+	b *Bus
+}
+
+func (v debugView) Read(addr uint16) byte {
+	return v.b.Peek(addr)
+}
+
+func (v debugView) Write(val byte, addr uint16) {
+	v.b.Poke(val, addr)
+}
+
+// ReadWord returns the little-endian word at addr and addr+1.
+func (b *Bus) ReadWord(addr uint16) uint16 {
+	return memory.ReadWord(b, addr)
+}
+
+// ReadWordPageWrap returns the little-endian word at addr and the next
+// address in the same page, as used by JMP indirect and zero page indirect
+// addressing.
+func (b *Bus) ReadWordPageWrap(addr uint16) uint16 {
+	return memory.ReadWordPageWrap(b, addr)
+}
+
+// WriteWord stores val at addr and addr+1, low byte first.
+func (b *Bus) WriteWord(val uint16, addr uint16) {
+	memory.WriteWord(b, val, addr)
+}
+
+func (b *Bus) region(addr uint16) *region {
+	idx := b.lookup[addr]
+	if idx == 0 {
+		return nil
+	}
+	return &b.regions[idx-1]
+}
+
+// RAM is a block of read/write memory that can be mapped on a Bus. Mapped on
+// a region larger than itself, it is mirrored to fill the region.
+type RAM []byte
+
+// NewRAM returns size bytes of zeroed RAM.
+func NewRAM(size int) RAM {
+	return make(RAM, size)
+}
+
+// Read returns the content from addr in the RAM.
+func (r RAM) Read(addr uint16) byte {
+	return r[int(addr)%len(r)]
+}
+
+// Write changes the content of addr in the RAM to val.
+func (r RAM) Write(val byte, addr uint16) {
+	r[int(addr)%len(r)] = val
+}
+
+// ROM is a block of read-only memory that can be mapped on a Bus. Mapped on a
+// region larger than itself, it is mirrored to fill the region.
+type ROM []byte
+
+// Read returns the content from addr in the ROM.
+func (r ROM) Read(addr uint16) byte {
+	return r[int(addr)%len(r)]
+}
+
+// Write does nothing.
+func (r ROM) Write(val byte, addr uint16) {}
+
+// Poke patches the content of addr in the ROM to val.
+func (r ROM) Poke(val byte, addr uint16) {
+	r[int(addr)%len(r)] = val
+}
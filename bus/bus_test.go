@@ -0,0 +1,168 @@
+package bus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestBusRoutesToDevices(t *testing.T) {
+	b := New()
+	ram := NewRAM(0x0800)
+	rom := ROM{0xEA, 0x4C}
+	if err := b.Map(0x0000, 0x1FFF, ram); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.Map(0xFFFE, 0xFFFF, rom); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b.Write(0x42, 0x0801)
+	b.Write(0x00, 0xFFFE)
+
+	if ram[0x0001] != 0x42 {
+		t.Errorf("expected write mirrored to $0001")
+	}
+	if b.Read(0x1801) != 0x42 {
+		t.Errorf("expected mirrored read from $1801")
+	}
+	if b.Read(0xFFFE) != 0xEA {
+		t.Errorf("expected ROM to ignore writes")
+	}
+}
+
+func TestBusMapOverlap(t *testing.T) {
+	b := New()
+	if err := b.Map(0x0000, 0x00FF, NewRAM(0x100)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := b.Map(0x00FF, 0x01FF, NewRAM(0x100))
+	if !errors.Is(err, ErrOverlap) {
+		t.Errorf("expected %v, actual %v\n", ErrOverlap, err)
+	}
+	if b.region(0x0100) != nil {
+		t.Errorf("failed mapping left addresses mapped")
+	}
+}
+
+func TestBusOpenBusReturnsLastValue(t *testing.T) {
+	b := New()
+	if err := b.Map(0x0000, 0x00FF, RAM{0x10: 0x5A, 0xFF: 0x00}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if b.Read(0x0010) != 0x5A || b.Read(0x4000) != 0x5A {
+		t.Errorf("expected unmapped read to return last value read")
+	}
+	b.Write(0x33, 0x5000)
+	if b.Read(0x6000) != 0x33 {
+		t.Errorf("expected unmapped read to return last value written")
+	}
+}
+
+func TestBusOpenBusValue(t *testing.T) {
+	b := New()
+	if err := b.Map(0x0000, 0x00FF, RAM{0x10: 0x5A, 0xFF: 0x00}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b.SetOpenBusValue(0xFF)
+
+	b.Read(0x0010)
+	if b.Read(0x4000) != 0xFF {
+		t.Errorf("expected unmapped read to return configured value")
+	}
+}
+
+// fifo is a device whose reads pop values off a queue.
+type fifo struct {
+	queue []byte
+}
+
+func (f *fifo) Read(addr uint16) byte {
+	if len(f.queue) == 0 {
+		return 0
+	}
+	val := f.queue[0]
+	f.queue = f.queue[1:]
+	return val
+}
+
+func (f *fifo) Write(val byte, addr uint16) {
+	f.queue = append(f.queue, val)
+}
+
+func (f *fifo) Peek(addr uint16) byte {
+	if len(f.queue) == 0 {
+		return 0
+	}
+	return f.queue[0]
+}
+
+func TestBusPeekHasNoSideEffects(t *testing.T) {
+	b := New()
+	dev := &fifo{queue: []byte{1, 2}}
+	if err := b.Map(0xD000, 0xD000, dev); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if b.Peek(0xD000) != 1 || b.Peek(0xD000) != 1 {
+		t.Errorf("expected peek to leave the queue alone")
+	}
+	if b.Read(0xD000) != 1 || b.Read(0xD000) != 2 {
+		t.Errorf("expected read to pop the queue")
+	}
+}
+
+func TestBusPeekKeepsOpenBus(t *testing.T) {
+	b := New()
+	if err := b.Map(0x0000, 0x00FF, RAM{0x10: 0x5A, 0xFF: 0x00}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b.Read(0x0000)
+	b.Peek(0x0010)
+	if b.Read(0x4000) != 0x00 {
+		t.Errorf("expected peek not to drive the data bus")
+	}
+}
+
+func TestBusPokePatchesROM(t *testing.T) {
+	b := New()
+	rom := ROM{0xEA}
+	if err := b.Map(0xFF00, 0xFF00, rom); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b.Write(0x00, 0xFF00)
+	if b.Read(0xFF00) != 0xEA {
+		t.Errorf("expected write to ROM to be ignored")
+	}
+	b.DebugView().Write(0x60, 0xFF00)
+	if b.Read(0xFF00) != 0x60 {
+		t.Errorf("expected poke to patch ROM")
+	}
+}
+
+func TestBusBackend(t *testing.T) {
+	for _, mem := range []memory.ReadWriter{&memory.Memory{}, &memory.Paged{}, &memory.Sparse{}} {
+		b := NewWithBackend(mem)
+		if err := b.Map(0xE000, 0xE000, ROM{0xEA}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		b.Write(0x42, 0x1000)
+		b.Write(0x00, 0xE000)
+
+		if mem.Read(0x1000) != 0x42 || b.Read(0x1000) != 0x42 {
+			t.Errorf("%T: expected write to reach the backend", mem)
+		}
+		if b.Read(0xE000) != 0xEA || mem.Read(0xE000) != 0x00 {
+			t.Errorf("%T: expected device to take precedence", mem)
+		}
+		if b.View(0x0FFF, 0x1000)[1] != 0x42 {
+			t.Errorf("%T: expected range to read the backend", mem)
+		}
+	}
+}
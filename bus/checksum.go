@@ -0,0 +1,30 @@
+package bus
+
+import (
+	"crypto/sha256"
+	"hash"
+	"hash/crc32"
+)
+
+// Checksum returns the CRC-32 (IEEE) of the content of the addresses from
+// start to end, inclusive, read without side effects.
+func (b *Bus) Checksum(start, end uint16) uint32 {
+	h := crc32.NewIEEE()
+	b.hash(h, start, end)
+	return h.Sum32()
+}
+
+// SHA256 returns the SHA-256 digest of the content of the addresses from
+// start to end, inclusive, read without side effects.
+func (b *Bus) SHA256(start, end uint16) [sha256.Size]byte {
+	h := sha256.New()
+	b.hash(h, start, end)
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func (b *Bus) hash(h hash.Hash, start, end uint16) {
+	// hash.Hash writes never fail.
+	_, _ = h.Write(b.View(start, end))
+}
@@ -0,0 +1,39 @@
+package bus
+
+import (
+	"crypto/sha256"
+	"hash/crc32"
+	"testing"
+)
+
+func TestBusChecksum(t *testing.T) {
+	b := New()
+	rom := ROM("hello, world")
+	if err := b.Map(0xE000, 0xE00B, rom); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if actual, expected := b.Checksum(0xE000, 0xE00B), crc32.ChecksumIEEE(rom); actual != expected {
+		t.Errorf("expected %08X, actual %08X\n", expected, actual)
+	}
+	if actual, expected := b.SHA256(0xE000, 0xE00B), sha256.Sum256(rom); actual != expected {
+		t.Errorf("expected %X, actual %X\n", expected, actual)
+	}
+}
+
+func TestBusChecksumDetectsChanges(t *testing.T) {
+	b := New()
+	if err := b.Map(0x0000, 0x00FF, NewRAM(0x100)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	before := b.Checksum(0x0000, 0x00FF)
+
+	b.Write(0x01, 0x0080)
+
+	if b.Checksum(0x0000, 0x00FF) == before {
+		t.Errorf("expected checksum to change")
+	}
+	if b.Checksum(0x0000, 0x007F) != crc32.ChecksumIEEE(make([]byte, 0x80)) {
+		t.Errorf("expected untouched range to keep its checksum")
+	}
+}
@@ -0,0 +1,228 @@
+package bus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// Region types understood by a Config.
+const (
+	RegionRAM     = "ram"
+	RegionROM     = "rom"
+	RegionFileRAM = "file-ram"
+	RegionDevice  = "device"
+	RegionMirror  = "mirror"
+)
+
+// Backends understood by a Config.
+const (
+	BackendFlat   = "flat"
+	BackendPaged  = "paged"
+	BackendSparse = "sparse"
+)
+
+// ErrConfig is returned when a memory map description is inconsistent.
+var ErrConfig = errors.New("bus: invalid config")
+
+// Addr is an address in a Config. In JSON it can be written as a number or
+// as a hexadecimal string such as "$C000" or "0xC000".
+type Addr uint16
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Addr) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n uint16
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("%w: address %s", ErrConfig, data)
+		}
+		*a = Addr(n)
+		return nil
+	}
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(s, "$"), "0x"), "0X")
+	n, err := strconv.ParseUint(s, 16, 16)
+	if err != nil {
+		return fmt.Errorf("%w: address %q", ErrConfig, s)
+	}
+	*a = Addr(n)
+	return nil
+}
+
+// Config is a declarative description of a memory map, usually read from a
+// JSON file with LoadConfig:
+//
+//	{
+//	  "backend": "flat",
+//	  "regions": [
+//	    {"name": "ram", "type": "ram", "start": "$0000", "end": "$07FF"},
+//	    {"type": "mirror", "of": "ram", "start": "$0800", "end": "$1FFF"},
+//	    {"name": "via", "type": "device", "device": "via", "start": "$6000", "end": "$600F"},
+//	    {"name": "rom", "type": "rom", "file": "rom.bin", "start": "$8000", "end": "$FFFF"}
+//	  ]
+//	}
+type Config struct {
+	// Backend, if set, backs every address not covered by a region.
+	Backend string `json:"backend,omitempty"`
+	// OpenBus, if set, is the value read from unmapped addresses.
+	OpenBus *byte          `json:"open_bus,omitempty"`
+	Regions []RegionConfig `json:"regions"`
+	// Dir is the directory relative file paths are resolved from.
+	Dir string `json:"-"`
+}
+
+// RegionConfig describes one region of a Config.
+type RegionConfig struct {
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type"`
+	Start Addr   `json:"start"`
+	End   Addr   `json:"end"`
+	// Size is the size of RAM, ROM or file RAM, when smaller than the region
+	// and mirrored across it.
+	Size int `json:"size,omitempty"`
+	// File is the image of a ROM or the host file of a file RAM.
+	File string `json:"file,omitempty"`
+	// Device is the name of the device, provided to Build, mapped by a
+	// device region.
+	Device string `json:"device,omitempty"`
+	// Of is the name of the region a mirror repeats.
+	Of string `json:"of,omitempty"`
+}
+
+// LoadConfig reads a JSON memory map description from r.
+func LoadConfig(r io.Reader) (*Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("bus: decoding config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// LoadConfigFile reads a JSON memory map description from the file at path.
+// File paths inside it are resolved relative to the directory of path.
+func LoadConfigFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("bus: opening config: %w", err)
+	}
+	defer f.Close()
+
+	cfg, err := LoadConfig(f)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Dir = filepath.Dir(path)
+	return cfg, nil
+}
+
+// Build creates a Bus laid out as described, looking up the devices named by
+// device regions in devices. File RAM regions keep their files open until
+// the returned io.Closer is closed.
+func (cfg *Config) Build(devices map[string]Device) (*Bus, io.Closer, error) {
+	var b *Bus
+	switch cfg.Backend {
+	case "":
+		b = New()
+	case BackendFlat:
+		b = NewWithBackend(&memory.Memory{})
+	case BackendPaged:
+		b = NewWithBackend(&memory.Paged{})
+	case BackendSparse:
+		b = NewWithBackend(&memory.Sparse{})
+	default:
+		return nil, nil, fmt.Errorf("%w: unknown backend %q", ErrConfig, cfg.Backend)
+	}
+	if cfg.OpenBus != nil {
+		b.SetOpenBusValue(*cfg.OpenBus)
+	}
+
+	var closers closers
+	named := make(map[string]Device)
+	for _, rc := range cfg.Regions {
+		d, err := cfg.device(rc, devices, named, &closers)
+		if err != nil {
+			return nil, nil, errors.Join(err, closers.Close())
+		}
+		if err := b.Map(uint16(rc.Start), uint16(rc.End), d); err != nil {
+			return nil, nil, errors.Join(err, closers.Close())
+		}
+		if rc.Name != "" {
+			named[rc.Name] = d
+		}
+	}
+	return b, closers, nil
+}
+
+func (cfg *Config) device(rc RegionConfig, devices, named map[string]Device, cl *closers) (Device, error) {
+	length := int(rc.End) - int(rc.Start) + 1
+	size := rc.Size
+	if size == 0 {
+		size = length
+	}
+	if size <= 0 || size > length {
+		return nil, fmt.Errorf("%w: region %q of %d bytes with size %d", ErrConfig, rc.Name, length, size)
+	}
+
+	switch rc.Type {
+	case RegionRAM:
+		return NewRAM(size), nil
+	case RegionROM:
+		data, err := os.ReadFile(cfg.path(rc.File))
+		if err != nil {
+			return nil, fmt.Errorf("bus: reading rom %q: %w", rc.Name, err)
+		}
+		if len(data) > size {
+			return nil, fmt.Errorf("%w: rom %q is %d bytes, larger than %d", ErrConfig, rc.Name, len(data), size)
+		}
+		rom := make(ROM, size)
+		copy(rom, data)
+		return rom, nil
+	case RegionFileRAM:
+		ram, err := OpenFileRAM(cfg.path(rc.File), size)
+		if err != nil {
+			return nil, err
+		}
+		*cl = append(*cl, ram)
+		return ram, nil
+	case RegionDevice:
+		d, ok := devices[rc.Device]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown device %q", ErrConfig, rc.Device)
+		}
+		return d, nil
+	case RegionMirror:
+		d, ok := named[rc.Of]
+		if !ok {
+			return nil, fmt.Errorf("%w: mirror of unknown region %q", ErrConfig, rc.Of)
+		}
+		return d, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown region type %q", ErrConfig, rc.Type)
+	}
+}
+
+func (cfg *Config) path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(cfg.Dir, name)
+}
+
+type closers []io.Closer
+
+func (cl closers) Close() error {
+	var errs []error
+	for _, c := range cl {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
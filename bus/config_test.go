@@ -0,0 +1,103 @@
+package bus
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `{
+  "open_bus": 255,
+  "regions": [
+    {"name": "ram", "type": "ram", "start": "$0000", "end": "$07FF"},
+    {"type": "mirror", "of": "ram", "start": "$0800", "end": "0x0FFF"},
+    {"name": "port", "type": "device", "device": "fifo", "start": "$6000", "end": "$6000"},
+    {"name": "nvram", "type": "file-ram", "file": "nvram.bin", "start": 28672, "end": "$7FFF"},
+    {"name": "rom", "type": "rom", "file": "rom.bin", "size": 4, "start": "$F000", "end": "$FFFF"}
+  ]
+}`
+
+func TestConfigBuild(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "rom.bin"), []byte{0xEA, 0x60}, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(dir, "machine.json")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	port := &fifo{queue: []byte{7}}
+	b, cl, err := cfg.Build(map[string]Device{"fifo": port})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cl.Close()
+
+	b.Write(0x42, 0x0801)
+	if b.Read(0x0001) != 0x42 {
+		t.Errorf("expected mirror to share the ram")
+	}
+	if b.Read(0x6000) != 7 {
+		t.Errorf("expected named device at $6000")
+	}
+	if b.Read(0xF000) != 0xEA || b.Read(0xFFFD) != 0x60 || b.Read(0xFFFE) != 0x00 {
+		t.Errorf("expected rom image mirrored across $F000-$FFFF")
+	}
+	b.Write(0x01, 0x7000)
+	if b.Read(0x7000) != 0x01 {
+		t.Errorf("expected file ram at $7000")
+	}
+	if b.Read(0x5000) != 0xFF {
+		t.Errorf("expected open bus value")
+	}
+}
+
+func TestConfigBackend(t *testing.T) {
+	cfg, err := LoadConfig(strings.NewReader(`{"backend": "sparse", "regions": []}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _, err := cfg.Build(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b.Write(0x42, 0x1234)
+	if b.Read(0x1234) != 0x42 {
+		t.Errorf("expected backend to store writes")
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	for _, src := range []string{
+		`{"regions": [{"type": "device", "device": "missing", "start": 0, "end": 0}]}`,
+		`{"regions": [{"type": "mirror", "of": "missing", "start": 0, "end": 0}]}`,
+		`{"regions": [{"type": "eprom", "start": 0, "end": 0}]}`,
+		`{"backend": "tape", "regions": []}`,
+		`{"regions": [{"type": "ram", "size": -1, "start": 0, "end": 255}]}`,
+		`{"regions": [{"type": "ram", "size": 512, "start": 0, "end": 255}]}`,
+		`{"regions": [{"type": "ram", "start": 255, "end": 0}]}`,
+	} {
+		cfg, err := LoadConfig(strings.NewReader(src))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, _, err := cfg.Build(nil); !errors.Is(err, ErrConfig) {
+			t.Errorf("%s: expected %v, actual %v\n", src, ErrConfig, err)
+		}
+	}
+}
+
+func TestConfigInvalidAddress(t *testing.T) {
+	_, err := LoadConfig(strings.NewReader(`{"regions": [{"type": "ram", "start": "$10000", "end": 0}]}`))
+	if !errors.Is(err, ErrConfig) {
+		t.Errorf("expected %v, actual %v\n", ErrConfig, err)
+	}
+}
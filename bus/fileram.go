@@ -0,0 +1,80 @@
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// FileRAM is RAM backed by a host file, so its content survives emulator
+// restarts, like battery-backed RAM. On Unix systems the file is memory
+// mapped and every write lands in it directly; elsewhere the content is
+// written back by Sync and Close.
+type FileRAM struct {
+	data []byte
+	f    *os.File
+}
+
+// OpenFileRAM opens the file at path as size bytes of RAM, creating it if
+// needed. A shorter file is extended with zeros.
+func OpenFileRAM(path string, size int) (*FileRAM, error) {
+	if size <= 0 || size > addressSpaceSize {
+		return nil, fmt.Errorf("bus: invalid file RAM size %d", size)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("bus: opening file RAM: %w", err)
+	}
+	data, err := mapFileRAM(f, size)
+	if err != nil {
+		return nil, errors.Join(err, f.Close())
+	}
+	return &FileRAM{data: data, f: f}, nil
+}
+
+func mapFileRAM(f *os.File, size int) ([]byte, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("bus: opening file RAM: %w", err)
+	}
+	if info.Size() < int64(size) {
+		if err := f.Truncate(int64(size)); err != nil {
+			return nil, fmt.Errorf("bus: extending file RAM: %w", err)
+		}
+	}
+	return mapFile(f, size)
+}
+
+// Read returns the content from addr in the RAM.
+func (r *FileRAM) Read(addr uint16) byte {
+	return r.data[int(addr)%len(r.data)]
+}
+
+// Write changes the content of addr in the RAM to val.
+func (r *FileRAM) Write(val byte, addr uint16) {
+	r.data[int(addr)%len(r.data)] = val
+}
+
+// Sync commits the content of the RAM to the host file.
+func (r *FileRAM) Sync() error {
+	if err := syncFile(r.f, r.data); err != nil {
+		return err
+	}
+	if err := r.f.Sync(); err != nil {
+		return fmt.Errorf("bus: syncing file RAM: %w", err)
+	}
+	return nil
+}
+
+// Close syncs and releases the RAM. It must not be accessed afterwards.
+func (r *FileRAM) Close() error {
+	err := r.Sync()
+	if uerr := unmapFile(r.data); err == nil {
+		err = uerr
+	}
+	r.data = nil
+	if cerr := r.f.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("bus: closing file RAM: %w", cerr)
+	}
+	return err
+}
@@ -0,0 +1,29 @@
+//go:build !unix
+
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+func mapFile(f *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := f.ReadAt(data, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("bus: reading file RAM: %w", err)
+	}
+	return data, nil
+}
+
+func syncFile(f *os.File, data []byte) error {
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("bus: writing file RAM: %w", err)
+	}
+	return nil
+}
+
+func unmapFile([]byte) error {
+	return nil
+}
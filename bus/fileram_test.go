@@ -0,0 +1,49 @@
+package bus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileRAMPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nvram.bin")
+
+	ram, err := OpenFileRAM(path, 0x2000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := New()
+	if err := b.Map(0x6000, 0x7FFF, ram); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b.Write(0x42, 0x6001)
+	b.Write(0x99, 0x7FFF)
+	if err := ram.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(content) != 0x2000 || content[0x0001] != 0x42 || content[0x1FFF] != 0x99 {
+		t.Errorf("unexpected file content")
+	}
+
+	ram, err = OpenFileRAM(path, 0x2000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ram.Close()
+	if ram.Read(0x0001) != 0x42 {
+		t.Errorf("expected content to survive reopening")
+	}
+}
+
+func TestFileRAMInvalidSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nvram.bin")
+	if _, err := OpenFileRAM(path, 0); err == nil {
+		t.Errorf("expected error for empty RAM")
+	}
+}
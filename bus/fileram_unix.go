@@ -0,0 +1,30 @@
+//go:build unix
+
+package bus
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func mapFile(f *os.File, size int) ([]byte, error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("bus: mapping file RAM: %w", err)
+	}
+	return data, nil
+}
+
+// syncFile has nothing to do: the mapping is shared with the file, so the
+// fsync done by the caller is enough.
+func syncFile(*os.File, []byte) error {
+	return nil
+}
+
+func unmapFile(data []byte) error {
+	if err := syscall.Munmap(data); err != nil {
+		return fmt.Errorf("bus: unmapping file RAM: %w", err)
+	}
+	return nil
+}
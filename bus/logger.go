@@ -0,0 +1,111 @@
+package bus
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+type addrRange struct {
+	start uint16
+	end   uint16
+}
+
+func (r addrRange) contains(addr uint16) bool {
+	return addr >= r.start && addr <= r.end
+}
+
+// Logger is a memory.ReadWriter that writes a line to an io.Writer for every
+// access it forwards to the wrapped memory.
+type Logger struct {
+	next   memory.ReadWriter
+	w      io.Writer
+	pc     func() uint16
+	ranges []addrRange
+	err    error
+}
+
+// NewLogger returns a Logger forwarding accesses to next and logging them to
+// w.
+func NewLogger(next memory.ReadWriter, w io.Writer) *Logger {
+	return &Logger{next: next, w: w}
+}
+
+// SetPCFunc sets the function used to find the PC of the instruction behind
+// each access, usually the InstructionPC method of the CPU.
+func (l *Logger) SetPCFunc(fn func() uint16) {
+	l.pc = fn
+}
+
+// Filter restricts logging to accesses between start and end, inclusive.
+// It can be called several times to log more than one range. Without any
+// filter every access is logged.
+func (l *Logger) Filter(start, end uint16) {
+	l.ranges = append(l.ranges, addrRange{start: start, end: end})
+}
+
+// Err returns the first error returned by the underlying io.Writer.
+func (l *Logger) Err() error {
+	return l.err
+}
+
+// Read returns the content from addr in the wrapped memory and logs it.
+func (l *Logger) Read(addr uint16) byte {
+	val := l.next.Read(addr)
+	l.log('R', addr, val)
+	return val
+}
+
+// Write logs the access and changes the content of addr in the wrapped memory
+// to val.
+func (l *Logger) Write(val byte, addr uint16) {
+	l.log('W', addr, val)
+	l.next.Write(val, addr)
+}
+
+// Peek returns the content from addr in the wrapped memory without logging
+// it, using its Peek method if it has one.
+func (l *Logger) Peek(addr uint16) byte {
+	if p, ok := l.next.(Peeker); ok {
+		return p.Peek(addr)
+	}
+	return l.next.Read(addr)
+}
+
+// Poke changes the content of addr in the wrapped memory without logging it,
+// using its Poke method if it has one.
+func (l *Logger) Poke(val byte, addr uint16) {
+	if p, ok := l.next.(Poker); ok {
+		p.Poke(val, addr)
+		return
+	}
+	l.next.Write(val, addr)
+}
+
+func (l *Logger) log(rw byte, addr uint16, val byte) {
+	if l.err != nil || !l.matches(addr) {
+		return
+	}
+	var err error
+	if l.pc != nil {
+		_, err = fmt.Fprintf(l.w, "%04X %c %04X %02X\n", l.pc(), rw, addr, val)
+	} else {
+		_, err = fmt.Fprintf(l.w, "---- %c %04X %02X\n", rw, addr, val)
+	}
+	if err != nil {
+		l.err = fmt.Errorf("bus: writing log: %w", err)
+	}
+}
+
+func (l *Logger) matches(addr uint16) bool {
+	if len(l.ranges) == 0 {
+		return true
+	}
+	for _, r := range l.ranges {
+		if r.contains(addr) {
+			return true
+		}
+	}
+	return false
+}
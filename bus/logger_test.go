@@ -0,0 +1,44 @@
+package bus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestLoggerLogsAccesses(t *testing.T) {
+	mem := memory.Memory{}
+	var out strings.Builder
+	l := NewLogger(&mem, &out)
+	l.SetPCFunc(func() uint16 { return 0x0200 })
+
+	l.Write(0x42, 0x1000)
+	val := l.Read(0x1000)
+
+	if val != 0x42 || mem.Read(0x1000) != 0x42 {
+		t.Errorf("accesses not forwarded")
+	}
+	expected := "0200 W 1000 42\n0200 R 1000 42\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+}
+
+func TestLoggerFilter(t *testing.T) {
+	mem := memory.Memory{}
+	var out strings.Builder
+	l := NewLogger(&mem, &out)
+	l.Filter(0xD000, 0xDFFF)
+	l.Filter(0x0001, 0x0001)
+
+	l.Read(0x0000)
+	l.Read(0x0001)
+	l.Write(0x07, 0xD020)
+	l.Read(0xE000)
+
+	expected := "---- R 0001 00\n---- W D020 07\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+}
@@ -0,0 +1,78 @@
+package bus
+
+import "iter"
+
+// Slicer is implemented by devices backed by a plain byte slice, letting the
+// bus scan them in bulk instead of address by address.
+type Slicer interface {
+	Bytes() []byte
+}
+
+// Bytes returns the memory backing the RAM.
+func (r RAM) Bytes() []byte {
+	return r
+}
+
+// Bytes returns the memory backing the ROM.
+func (r ROM) Bytes() []byte {
+	return r
+}
+
+// Bytes returns the memory backing the RAM.
+func (r *FileRAM) Bytes() []byte {
+	return r.data
+}
+
+// Range returns an iterator over the addresses from start to end, inclusive,
+// and their content. Like Peek it has no side effects; regions backed by a
+// Slicer are read straight from their backing slice.
+func (b *Bus) Range(start, end uint16) iter.Seq2[uint16, byte] {
+	return func(yield func(uint16, byte) bool) {
+		addr := int(start)
+		for addr <= int(end) {
+			r := b.region(uint16(addr))
+			chunkEnd := int(end)
+			if r != nil {
+				chunkEnd = min(chunkEnd, int(r.end))
+			} else {
+				chunkEnd = b.unmappedEnd(addr, chunkEnd)
+			}
+
+			var data []byte
+			if r != nil {
+				if s, ok := r.dev.(Slicer); ok {
+					data = s.Bytes()
+				}
+			}
+			for ; addr <= chunkEnd; addr++ {
+				var val byte
+				if data != nil {
+					val = data[(addr-int(r.start))%len(data)]
+				} else {
+					val = b.Peek(uint16(addr))
+				}
+				if !yield(uint16(addr), val) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// View returns a copy of the content of the addresses from start to end,
+// inclusive, read as with Range.
+func (b *Bus) View(start, end uint16) []byte {
+	buf := make([]byte, 0, int(end)-int(start)+1)
+	for _, val := range b.Range(start, end) {
+		buf = append(buf, val)
+	}
+	return buf
+}
+
+// unmappedEnd returns the last unmapped address from addr up to limit.
+func (b *Bus) unmappedEnd(addr, limit int) int {
+	for addr < limit && b.lookup[addr+1] == 0 {
+		addr++
+	}
+	return addr
+}
@@ -0,0 +1,70 @@
+package bus
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBusRangeAcrossRegions(t *testing.T) {
+	b := New()
+	if err := b.Map(0x0000, 0x0003, RAM{1, 2, 3, 4}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.Map(0x0006, 0x0007, &fifo{queue: []byte{9}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b.SetOpenBusValue(0xFF)
+
+	var addrs []uint16
+	var vals []byte
+	for addr, val := range b.Range(0x0002, 0x0007) {
+		addrs = append(addrs, addr)
+		vals = append(vals, val)
+	}
+
+	expected := []byte{3, 4, 0xFF, 0xFF, 9, 9}
+	if !bytes.Equal(vals, expected) {
+		t.Errorf("expected % X, actual % X\n", expected, vals)
+	}
+	if len(addrs) != 6 || addrs[0] != 0x0002 || addrs[5] != 0x0007 {
+		t.Errorf("unexpected addresses %04X\n", addrs)
+	}
+}
+
+func TestBusRangeMirrorsSlicers(t *testing.T) {
+	b := New()
+	if err := b.Map(0x0000, 0x1FFF, NewRAM(0x0800)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b.Write(0x42, 0x0001)
+
+	view := b.View(0x0800, 0x0801)
+	if !bytes.Equal(view, []byte{0x00, 0x42}) {
+		t.Errorf("expected mirrored content, actual % X\n", view)
+	}
+}
+
+func TestBusRangeWholeAddressSpace(t *testing.T) {
+	b := New()
+	if err := b.Map(0x0000, 0xFFFF, NewRAM(0x10000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := len(b.View(0x0000, 0xFFFF)); n != 0x10000 {
+		t.Errorf("expected 65536 bytes, actual %d\n", n)
+	}
+}
+
+func TestBusRangeStopsEarly(t *testing.T) {
+	b := New()
+	n := 0
+	for range b.Range(0x0000, 0xFFFF) {
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	if n != 3 {
+		t.Errorf("expected 3 iterations, actual %d\n", n)
+	}
+}
@@ -0,0 +1,16 @@
+package bus
+
+// SetStallFunc sets the function StealCycles uses to hold the CPU, usually
+// the Stall method of the CPU, which drives its RDY line.
+func (b *Bus) SetStallFunc(fn func(cycles uint)) {
+	b.stall = fn
+}
+
+// StealCycles lets a bus participant, such as a video chip fetching
+// character data or a DMA controller, claim n cycles during which the CPU is
+// halted. It does nothing if no stall function was set.
+func (b *Bus) StealCycles(n uint) {
+	if b.stall != nil {
+		b.stall(n)
+	}
+}
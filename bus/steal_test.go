@@ -0,0 +1,32 @@
+package bus
+
+import "testing"
+
+// dmaPort steals cycles from the CPU when written, like the NES OAM DMA
+// register.
+type dmaPort struct {
+	b *Bus
+}
+
+func (d dmaPort) Read(addr uint16) byte {
+	return 0
+}
+
+func (d dmaPort) Write(val byte, addr uint16) {
+	d.b.StealCycles(513)
+}
+
+func TestBusStealCycles(t *testing.T) {
+	b := New()
+	if err := b.Map(0x4014, 0x4014, dmaPort{b}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var stalled uint
+	b.SetStallFunc(func(n uint) { stalled += n })
+
+	b.Write(0x02, 0x4014)
+
+	if stalled != 513 {
+		t.Errorf("expected 513 stolen cycles, actual %d\n", stalled)
+	}
+}
@@ -0,0 +1,74 @@
+package bus
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// watchBuffer is the number of events a subscriber can fall behind before
+// events are dropped.
+const watchBuffer = 256
+
+// MemoryEvent is a write delivered to WatchRange subscribers.
+type MemoryEvent struct {
+	Addr uint16
+	Val  byte
+}
+
+type subscriber struct {
+	addrRange
+	ch chan MemoryEvent
+}
+
+type watchers struct {
+	mu   sync.Mutex
+	subs []subscriber
+	// n mirrors len(subs) so writes can skip the lock when nobody watches.
+	n atomic.Int32
+}
+
+// WatchRange returns a channel receiving every write made through Write or
+// Poke to the addresses from start to end, inclusive. The bus never blocks on
+// a slow subscriber: events that do not fit in the channel buffer are
+// dropped. WatchRange and Unwatch are safe to call from other goroutines
+// while the bus is in use.
+func (b *Bus) WatchRange(start, end uint16) <-chan MemoryEvent {
+	ch := make(chan MemoryEvent, watchBuffer)
+	b.watchers.mu.Lock()
+	defer b.watchers.mu.Unlock()
+	b.watchers.subs = append(b.watchers.subs, subscriber{addrRange: addrRange{start: start, end: end}, ch: ch})
+	b.watchers.n.Store(int32(len(b.watchers.subs)))
+	return ch
+}
+
+// Unwatch stops the delivery of events to ch, a channel returned by
+// WatchRange, and closes it.
+func (b *Bus) Unwatch(ch <-chan MemoryEvent) {
+	b.watchers.mu.Lock()
+	defer b.watchers.mu.Unlock()
+	for i, s := range b.watchers.subs {
+		if s.ch == ch {
+			close(s.ch)
+			b.watchers.subs = append(b.watchers.subs[:i], b.watchers.subs[i+1:]...)
+			b.watchers.n.Store(int32(len(b.watchers.subs)))
+			return
+		}
+	}
+}
+
+func (b *Bus) notify(val byte, addr uint16) {
+	if b.watchers.n.Load() == 0 {
+		return
+	}
+	b.watchers.mu.Lock()
+	defer b.watchers.mu.Unlock()
+	for _, s := range b.watchers.subs {
+		if !s.contains(addr) {
+			continue
+		}
+		select {
+		case s.ch <- MemoryEvent{Addr: addr, Val: val}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package bus
+
+import "testing"
+
+func TestWatchRange(t *testing.T) {
+	b := NewWithBackend(NewRAM(0x10000))
+	ch := b.WatchRange(0x0200, 0x05FF)
+
+	b.Write(0x01, 0x01FF)
+	b.Write(0x02, 0x0200)
+	b.Poke(0x03, 0x05FF)
+	b.Write(0x04, 0x0600)
+
+	expected := []MemoryEvent{{Addr: 0x0200, Val: 0x02}, {Addr: 0x05FF, Val: 0x03}}
+	for _, e := range expected {
+		if actual := <-ch; actual != e {
+			t.Errorf("expected %+v, actual %+v\n", e, actual)
+		}
+	}
+	select {
+	case e := <-ch:
+		t.Errorf("unexpected event %+v\n", e)
+	default:
+	}
+}
+
+func TestWatchRangeDropsWhenFull(t *testing.T) {
+	b := NewWithBackend(NewRAM(0x10000))
+	ch := b.WatchRange(0x0000, 0xFFFF)
+
+	for i := range watchBuffer + 10 {
+		b.Write(byte(i), 0x1000)
+	}
+
+	if len(ch) != watchBuffer {
+		t.Errorf("expected %d buffered events, actual %d\n", watchBuffer, len(ch))
+	}
+}
+
+func TestUnwatchClosesChannel(t *testing.T) {
+	b := NewWithBackend(NewRAM(0x10000))
+	ch := b.WatchRange(0x0000, 0xFFFF)
+
+	b.Unwatch(ch)
+	b.Write(0x01, 0x0000)
+
+	if _, ok := <-ch; ok {
+		t.Errorf("expected closed channel")
+	}
+}
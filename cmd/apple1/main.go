@@ -0,0 +1,82 @@
+// Command apple1 runs an Apple 1 in the terminal, from the images of its
+// ROMs.
+//
+// Usage:
+//
+//	apple1 -monitor wozmon.bin [-basic basic.bin] [-ram 4096]
+//
+// The terminal is put in raw mode for the keys to be read as typed; Ctrl-C
+// quits. Lower case is typed as upper case, Return as a carriage return, and
+// Backspace as the underscore the Woz Monitor rubs out with. Integer BASIC,
+// given to -basic, is started from the monitor with E000R.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/devices/keyboard"
+	"github.com/leakedmemory/mos6502/machines/apple1"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "apple1:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	monitor := flag.String("monitor", "", "read the Woz Monitor ROM from `file`")
+	basic := flag.String("basic", "", "load Integer BASIC from `file`")
+	ram := flag.Int("ram", apple1.DefaultRAMSize, "size of the RAM at $0000 in `bytes`")
+	flag.Parse()
+
+	if *monitor == "" {
+		return errors.New("-monitor is required")
+	}
+	cfg := apple1.Config{RAMSize: *ram, Keyboard: os.Stdin, Display: os.Stdout}
+	var err error
+	if cfg.Monitor, err = os.ReadFile(*monitor); err != nil {
+		return err
+	}
+	if *basic != "" {
+		if cfg.BASIC, err = os.ReadFile(*basic); err != nil {
+			return err
+		}
+	}
+	m, err := apple1.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Input that is not a terminal, such as a pipe, is read as is.
+	if restore, err := keyboard.MakeRaw(os.Stdin); err == nil {
+		defer restore()
+	}
+	var interrupted atomic.Bool
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		<-interrupts
+		interrupted.Store(true)
+	}()
+	m.CPU.AddInstructionHook(func(cpu.InstructionEvent) {
+		if interrupted.Load() {
+			m.CPU.Stop()
+		}
+	})
+
+	m.Reset()
+	if _, err := m.CPU.Run(); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,103 @@
+// Command beneater runs a ROM written for the 6502 breadboard computer of
+// Ben Eater, showing its LCD in the terminal.
+//
+// Usage:
+//
+//	beneater [-4bit] rom.bin
+//
+// The ROM is the 32 KiB image written to the EEPROM. With -4bit, the LCD is
+// wired as in the later videos, in 4-bit mode on port B. The LCD is drawn
+// again whenever its text changes, until the program crashes or Ctrl-C is
+// pressed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/machines/beneater"
+)
+
+// refreshCycles is the number of cycles between checks of the text of the
+// LCD.
+const refreshCycles = 10_000
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "beneater:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	fourBit := flag.Bool("4bit", false, "wire the LCD in 4-bit mode on port B")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	rom, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	cfg := beneater.Config{ROM: rom}
+	if *fourBit {
+		cfg.Wiring = beneater.Wiring4Bit
+	}
+	m, err := beneater.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	var interrupted atomic.Bool
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		<-interrupts
+		interrupted.Store(true)
+	}()
+
+	var shown []string
+	var next uint
+	m.CPU.AddInstructionHook(func(cpu.InstructionEvent) {
+		if m.CPU.Cycles() < next {
+			return
+		}
+		next = m.CPU.Cycles() + refreshCycles
+		if lines := m.LCD.Lines(); !slices.Equal(lines, shown) {
+			draw(os.Stdout, lines, shown != nil)
+			shown = lines
+		}
+		if interrupted.Load() {
+			m.CPU.Stop()
+		}
+	})
+
+	m.Reset()
+	_, err = m.CPU.Run()
+	return err
+}
+
+// draw draws the lines of the LCD in a frame, over those drawn before if
+// redraw is set.
+func draw(w io.Writer, lines []string, redraw bool) {
+	if redraw {
+		fmt.Fprintf(w, "\x1b[%dA", len(lines)+2)
+	}
+	border := "+" + strings.Repeat("-", utf8.RuneCountInString(lines[0])) + "+"
+	fmt.Fprintln(w, border)
+	for _, line := range lines {
+		fmt.Fprintf(w, "|%s|\n", line)
+	}
+	fmt.Fprintln(w, border)
+}
@@ -0,0 +1,182 @@
+// Command debug runs a program on an emulated 6502 under a full-screen
+// terminal debugger.
+//
+// Usage:
+//
+//	debug [-config bus.json] [-addr 0200] [-pc 0200] [-symbols file] file
+//
+// Without -config, the whole address space is RAM. The file is read as a PRG
+// file if its name ends with .prg, as Intel HEX if it ends with .hex or .ihx,
+// as a paper tape of the Woz Monitor if it ends with .woz, as an iNES image
+// if it ends with .nes, and as raw bytes placed at -addr otherwise. Execution
+// starts at -pc, or at the start of the loaded file. The disassembly names addresses with the
+// symbols of the VICE label file or ld65 debug info file given to -symbols.
+//
+// Stepping back is limited to the last few hundred thousand instructions,
+// and does not restore the state of the devices on the bus.
+//
+// The terminal is put in raw mode with keyboard.MakeRaw, so the command
+// needs Linux.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/devices/keyboard"
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/memory"
+	"github.com/leakedmemory/mos6502/rewind"
+	"github.com/leakedmemory/mos6502/savestate"
+	"github.com/leakedmemory/mos6502/symbols"
+	"github.com/leakedmemory/mos6502/tui"
+)
+
+// refresh is how often the screen is redrawn while the program runs.
+const refresh = 100 * time.Millisecond
+
+// keyCtrlC is the key Ctrl-C, which the terminal turns into an interrupt.
+const keyCtrlC = 0x03
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "debug:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	config := flag.String("config", "", "bus configuration `file`")
+	addr := flag.String("addr", "0200", "load `address` of raw binaries, in hex")
+	pc := flag.String("pc", "", "start `address`, in hex")
+	symbolFile := flag.String("symbols", "", "load symbols from a VICE label or ld65 debug info `file`")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	b, closer, err := buildBus(*config)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closer.Close() }()
+
+	c := cpu.New(b)
+	c.Reset()
+	a, err := loader.ParseAddr(*addr)
+	if err != nil {
+		return err
+	}
+	start, err := loader.LoadFile(b.DebugView(), flag.Arg(0), a)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", flag.Arg(0), err)
+	}
+	if *pc != "" {
+		if start, err = loader.ParseAddr(*pc); err != nil {
+			return err
+		}
+	}
+	r := c.Registers()
+	r.PC = start
+	c.SetRegisters(r)
+
+	restore, err := keyboard.MakeRaw(os.Stdin)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = restore()
+		fmt.Print("\x1b[2J\x1b[H")
+	}()
+	d := tui.New(c, b.DebugView())
+	if *symbolFile != "" {
+		syms := symbols.New()
+		if err := syms.LoadFile(*symbolFile); err != nil {
+			return err
+		}
+		d.SetSymbols(syms)
+	}
+	m := &savestate.Machine{CPU: c, Memory: b.DebugView()}
+	rw, err := rewind.New(m, nil, rewind.DefaultInterval, rewind.DefaultHistory)
+	if err != nil {
+		return err
+	}
+	d.SetRewinder(rw)
+	return loop(d)
+}
+
+// loop draws the debugger and feeds it the keys read from the terminal until
+// it quits. The interrupts of Ctrl-C are fed as the key.
+func loop(d *tui.Debugger) error {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := os.Stdin.Read(buf); err != nil {
+				close(keys)
+				return
+			}
+			keys <- buf[0]
+		}
+	}()
+
+	last := time.Time{}
+	for {
+		if !d.Running() || time.Since(last) >= refresh {
+			if err := d.Render(os.Stdout); err != nil {
+				return err
+			}
+			last = time.Now()
+		}
+
+		if d.Running() {
+			select {
+			case k, ok := <-keys:
+				if !ok {
+					return nil
+				}
+				d.Key(k)
+			case <-interrupts:
+				d.Key(keyCtrlC)
+			default:
+				d.Tick()
+			}
+			continue
+		}
+
+		var k byte
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			k = key
+		case <-interrupts:
+			k = keyCtrlC
+		}
+		if d.Key(k) {
+			return nil
+		}
+	}
+}
+
+func buildBus(config string) (*bus.Bus, io.Closer, error) {
+	if config == "" {
+		return bus.NewWithBackend(&memory.Memory{}), io.NopCloser(nil), nil
+	}
+	cfg, err := bus.LoadConfigFile(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg.Build(nil)
+}
@@ -0,0 +1,115 @@
+// Command easy6502 runs a program written for the easy6502 web simulator in
+// the terminal, drawing its display with 24-bit colors.
+//
+// Usage:
+//
+//	easy6502 [-cycles 20000] [-seed n] program.bin
+//
+// The program is the raw binary assembled for $0600. The keys typed are
+// stored at $FF, as the simulator does; Escape or Ctrl-C quits. Once the
+// program reaches a BRK, the display is left shown until a key is typed.
+// The CPU runs -cycles cycles by frame, 60 frames a second.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/devices/keyboard"
+	"github.com/leakedmemory/mos6502/machines/easy6502"
+)
+
+const frame = time.Second / 60
+
+// Keys with a special meaning.
+const (
+	keyCtrlC  = 0x03
+	keyEscape = 0x1B
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "easy6502:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cycles := flag.Uint("cycles", 20_000, "run `n` cycles by frame")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed of the random bytes at $FE")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	program, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	m, err := easy6502.New(program, rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		return err
+	}
+	// The simulator stops at BRK.
+	m.CPU.AddInterruptBreakpoint(cpu.InterruptBRK)
+
+	restore, err := keyboard.MakeRaw(os.Stdin)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = restore()
+		fmt.Print("\x1b[2J\x1b[H")
+	}()
+	fmt.Print("\x1b[2J")
+	return loop(m, *cycles)
+}
+
+// loop runs the program a frame at a time, drawing the display and storing
+// the keys read from the terminal until it quits or the program reaches a
+// BRK.
+func loop(m *easy6502.Machine, cycles uint) error {
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := os.Stdin.Read(buf); err != nil {
+				close(keys)
+				return
+			}
+			keys <- buf[0]
+		}
+	}()
+
+	next := time.Now()
+	for {
+		select {
+		case k, ok := <-keys:
+			if !ok || k == keyCtrlC || k == keyEscape {
+				return nil
+			}
+			m.SetKey(k)
+		default:
+		}
+
+		reason, err := m.CPU.RunFor(cycles)
+		if rerr := m.Render(os.Stdout); rerr != nil {
+			return rerr
+		}
+		if reason == cpu.StopInterrupt {
+			// The display is left shown until a key is typed.
+			<-keys
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		next = next.Add(frame)
+		time.Sleep(time.Until(next))
+	}
+}
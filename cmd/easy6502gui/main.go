@@ -0,0 +1,131 @@
+// Command easy6502gui runs a program written for the easy6502 web simulator
+// in a window, drawing its display with Ebiten. It is a module of its own,
+// so that the emulator does not depend on Ebiten and its graphics drivers.
+//
+// Usage:
+//
+//	easy6502gui [-cycles 20000] [-seed n] [-scale 16] program.bin
+//
+// The program is the raw binary assembled for $0600. The characters typed
+// are stored at $FF, as the simulator does, and the arrow keys as w, a, s
+// and d, the keys of most of its games; Escape quits. Once the program
+// reaches a BRK, the display is left shown until the window is closed. The
+// CPU runs -cycles cycles by frame, 60 frames a second.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/machines/easy6502"
+)
+
+// arrows are the keys stored for the arrow keys.
+var arrows = map[ebiten.Key]byte{
+	ebiten.KeyArrowUp:    'w',
+	ebiten.KeyArrowLeft:  'a',
+	ebiten.KeyArrowDown:  's',
+	ebiten.KeyArrowRight: 'd',
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "easy6502gui:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cycles := flag.Uint("cycles", 20_000, "run `n` cycles by frame")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed of the random bytes at $FE")
+	scale := flag.Int("scale", 16, "size of the pixels, in pixels of the screen")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	program, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	m, err := easy6502.New(program, rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		return err
+	}
+	// The simulator stops at BRK.
+	m.CPU.AddInterruptBreakpoint(cpu.InterruptBRK)
+
+	ebiten.SetWindowTitle("easy6502 - " + flag.Arg(0))
+	ebiten.SetWindowSize(easy6502.ScreenSize**scale, easy6502.ScreenSize**scale)
+	err = ebiten.RunGame(newGame(m, *cycles))
+	if errors.Is(err, ebiten.Termination) {
+		return nil
+	}
+	return err
+}
+
+// game runs the machine a frame at a time.
+type game struct {
+	m      *easy6502.Machine
+	cycles uint
+	halted bool
+	pixels []byte
+	chars  []rune
+}
+
+func newGame(m *easy6502.Machine, cycles uint) *game {
+	return &game{m: m, cycles: cycles, pixels: make([]byte, 4*easy6502.ScreenSize*easy6502.ScreenSize)}
+}
+
+// Update stores the keys typed and runs the CPU for a frame.
+func (g *game) Update() error {
+	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
+		return ebiten.Termination
+	}
+	if g.halted {
+		return nil
+	}
+	g.chars = ebiten.AppendInputChars(g.chars[:0])
+	for _, r := range g.chars {
+		if r < 0x80 {
+			g.m.SetKey(byte(r))
+		}
+	}
+	for k, b := range arrows {
+		if ebiten.IsKeyPressed(k) {
+			g.m.SetKey(b)
+		}
+	}
+
+	reason, err := g.m.CPU.RunFor(g.cycles)
+	if reason == cpu.StopInterrupt {
+		// The display is left shown.
+		g.halted = true
+	}
+	return err
+}
+
+// Draw draws the display, a pixel of the screen by pixel of the display,
+// scaled up to the window.
+func (g *game) Draw(screen *ebiten.Image) {
+	img := g.m.Screen()
+	for i, c := range img.Pix {
+		r, gr, b, a := img.Palette[c].RGBA()
+		p := g.pixels[4*i : 4*i+4]
+		p[0], p[1], p[2], p[3] = byte(r>>8), byte(gr>>8), byte(b>>8), byte(a>>8)
+	}
+	screen.WritePixels(g.pixels)
+}
+
+// Layout makes the screen the size of the display.
+func (g *game) Layout(int, int) (int, int) {
+	return easy6502.ScreenSize, easy6502.ScreenSize
+}
@@ -0,0 +1,129 @@
+// Command kim1 runs a KIM-1 in the terminal, from the images of the ROMs of
+// its 6530s, showing its display and reading its keypad from the keyboard.
+//
+// Usage:
+//
+//	kim1 -monitor 6530-002.bin [-tape 6530-003.bin]
+//
+// The keypad is typed as on a numeric keypad: the hex digits, '/' for AD,
+// '*' for DA, '+' for +, 'g' for GO and 'p' for PC, with 's' for ST and
+// 'r' for RS. Escape or Ctrl-C quits. The CPU runs at the 1 MHz of the
+// KIM-1.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/leakedmemory/mos6502/devices/keyboard"
+	"github.com/leakedmemory/mos6502/machines/kim1"
+)
+
+const (
+	// slice is how long the CPU runs between redraws of the display, and
+	// sliceCycles the number of cycles it runs in that time.
+	slice       = 20 * time.Millisecond
+	sliceCycles = 20_000
+	help        = "0-9 A-F hex  / AD  * DA  + +  g GO  p PC  s ST  r RS  Esc quit"
+)
+
+// Keys with a special meaning.
+const (
+	keyCtrlC  = 0x03
+	keyEscape = 0x1B
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "kim1:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	monitor := flag.String("monitor", "", "read the ROM of the 6530-002 from `file`")
+	tape := flag.String("tape", "", "read the ROM of the 6530-003 from `file`")
+	flag.Parse()
+
+	if *monitor == "" {
+		return errors.New("-monitor is required")
+	}
+	var cfg kim1.Config
+	var err error
+	if cfg.Monitor, err = os.ReadFile(*monitor); err != nil {
+		return err
+	}
+	if *tape != "" {
+		if cfg.Tape, err = os.ReadFile(*tape); err != nil {
+			return err
+		}
+	}
+	m, err := kim1.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	restore, err := keyboard.MakeRaw(os.Stdin)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = restore()
+		fmt.Print("\x1b[2J\x1b[H")
+	}()
+	fmt.Print("\x1b[2J")
+	m.Reset()
+	return loop(m)
+}
+
+// loop runs the KIM-1 in real time, drawing its display and feeding it the
+// keys read from the terminal until it quits.
+func loop(m *kim1.Machine) error {
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := os.Stdin.Read(buf); err != nil {
+				close(keys)
+				return
+			}
+			keys <- buf[0]
+		}
+	}()
+
+	next := time.Now()
+	for {
+		select {
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			switch k {
+			case keyCtrlC, keyEscape:
+				return nil
+			case 's', 'S':
+				m.Stop()
+			case 'r', 'R':
+				m.Reset()
+			default:
+				if key, ok := kim1.KeyFor(k); ok {
+					m.Panel.Tap(key)
+				}
+			}
+		default:
+		}
+
+		if _, err := m.CPU.RunFor(sliceCycles); err != nil {
+			return err
+		}
+		if err := m.Panel.Render(os.Stdout); err != nil {
+			return err
+		}
+		fmt.Print("\r\n" + help + "\r\n")
+		next = next.Add(slice)
+		time.Sleep(time.Until(next))
+	}
+}
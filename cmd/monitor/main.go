@@ -0,0 +1,324 @@
+// Command monitor starts a machine-language monitor on an emulated 6502.
+//
+// Usage:
+//
+//	monitor [-config bus.json] [-load file] [-addr 0200] [-pc 0200]
+//		[-symbols file] [-cycles] [-script file]
+//		[-trace 16] [-traceformat format] [-crash file]
+//		[-profile file] [-coverage file] [-heatmap file] [-vcd file]
+//		[-jsontrace file [-jsonbus]]
+//		[-gdb :1234] [-web :8080]
+//
+// Without -config, the whole address space is RAM. The file given to -load is
+// read as a PRG file if its name ends with .prg, as Intel HEX if it ends with
+// .hex or .ihx, as a paper tape of the Woz Monitor if it ends with .woz, as
+// an iNES image if it ends with .nes, and as raw bytes placed at -addr
+// otherwise. Execution starts at -pc, or at the start of the loaded file.
+// Ctrl-C interrupts a running program. Addresses can be given by the names
+// of a VICE label file or ld65 debug info file passed to -symbols. With
+// -cycles, disassembly shows the cycles of the instructions, such as "4+"
+// for those taking one more when crossing a page and "2/3" for branches.
+//
+// When the program crashes on an invalid opcode or a bus fault, the last
+// instructions it executed are shown, as many as -trace, in the format of
+// -traceformat, such as "{PC} {DISASM:16} A:{A} CYC:{CYCLES}" (see
+// disasm.TraceFormat), and with -crash a full crash report is written to the
+// file.
+// The handlers of the debugging script given to -script react to the
+// execution of addresses and to memory accesses, as with the source command.
+//
+// With -profile, a report of where the program spent its cycles is written
+// to the file on exit. With -coverage, a map of the addresses executed as
+// code is written to the file on exit, as HTML if its name ends with .html
+// and as text otherwise. With -heatmap, the reads, writes and opcode fetches
+// of every address are counted and written to the file on exit, as a PNG
+// image if its name ends with .png and as CSV otherwise. With -vcd, the bus
+// activity is dumped to the file as a Value Change Dump, for waveform
+// viewers such as GTKWave. With -jsontrace, every instruction executed is
+// written to the file as a JSON object on its own line, and with -jsonbus
+// every bus access too (see package jsontrace).
+//
+// With -gdb, no prompt is shown: the program is served instead to gdb, or
+// another debugger speaking its remote protocol, connecting to the address.
+// With -web, it is served to browsers instead, as a web page at the address
+// driving the debugger over a WebSocket.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/coverage"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/crash"
+	"github.com/leakedmemory/mos6502/disasm"
+	"github.com/leakedmemory/mos6502/gdb"
+	"github.com/leakedmemory/mos6502/heatmap"
+	"github.com/leakedmemory/mos6502/jsontrace"
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/memory"
+	"github.com/leakedmemory/mos6502/monitor"
+	"github.com/leakedmemory/mos6502/profile"
+	"github.com/leakedmemory/mos6502/savestate"
+	"github.com/leakedmemory/mos6502/symbols"
+	"github.com/leakedmemory/mos6502/vcd"
+	"github.com/leakedmemory/mos6502/web"
+)
+
+// defaultTrace is the number of instructions shown by default when a program
+// crashes.
+const defaultTrace = 16
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "monitor:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	config := flag.String("config", "", "bus configuration `file`")
+	load := flag.String("load", "", "program `file` to load")
+	addr := flag.String("addr", "0200", "load `address` of raw binaries, in hex")
+	pc := flag.String("pc", "", "start `address`, in hex")
+	symbolFile := flag.String("symbols", "", "load symbols from a VICE label or ld65 debug info `file`")
+	cycles := flag.Bool("cycles", false, "show the cycles of the instructions in disassembly")
+	trace := flag.Int("trace", defaultTrace, "keep the last `n` instructions to show when the program crashes")
+	traceFormat := flag.String("traceformat", disasm.DefaultTraceFormat, "`format` of the lines of traces")
+	scriptFile := flag.String("script", "", "run the handlers of the debugging script `file`")
+	crashFile := flag.String("crash", "", "write a crash report to `file` when the program crashes")
+	profileFile := flag.String("profile", "", "write a profile of the hot spots to `file` on exit")
+	coverageFile := flag.String("coverage", "", "write a map of the executed addresses to `file` on exit")
+	heatmapFile := flag.String("heatmap", "", "write the memory accesses of every address to `file` on exit")
+	vcdFile := flag.String("vcd", "", "dump the bus activity to `file` as a Value Change Dump")
+	jsonFile := flag.String("jsontrace", "", "write the instructions executed to `file` as JSON lines")
+	jsonBus := flag.Bool("jsonbus", false, "also write the bus accesses to the -jsontrace file")
+	gdbAddr := flag.String("gdb", "", "serve debuggers speaking the GDB remote protocol on `address`")
+	webAddr := flag.String("web", "", "serve a debugger to browsers on `address`")
+	flag.Parse()
+
+	b, closer, err := buildBus(*config)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closer.Close() }()
+
+	c := cpu.New(b)
+	c.Reset()
+	c.SetTraceSize(*trace)
+
+	start := c.Registers().PC
+	if *load != "" {
+		a, err := loader.ParseAddr(*addr)
+		if err != nil {
+			return err
+		}
+		if start, err = loader.LoadFile(b.DebugView(), *load, a); err != nil {
+			return fmt.Errorf("loading %s: %w", *load, err)
+		}
+	}
+	if *pc != "" {
+		if start, err = loader.ParseAddr(*pc); err != nil {
+			return err
+		}
+	}
+	r := c.Registers()
+	r.PC = start
+	c.SetRegisters(r)
+
+	if *profileFile != "" {
+		p := profile.New()
+		c.AddInstructionHook(p.Record)
+		defer func() {
+			if err := writeProfile(p, *profileFile); err != nil {
+				fmt.Fprintln(os.Stderr, "monitor:", err)
+			}
+		}()
+	}
+
+	if *coverageFile != "" {
+		cov := coverage.New()
+		c.AddInstructionHook(cov.Record)
+		defer func() {
+			if err := writeCoverage(cov, *coverageFile); err != nil {
+				fmt.Fprintln(os.Stderr, "monitor:", err)
+			}
+		}()
+	}
+
+	if *heatmapFile != "" {
+		h := heatmap.New()
+		c.AddWatchpoint(0x0000, 0xFFFF, cpu.AccessAny, h.Record)
+		defer func() {
+			if err := writeHeatmap(h, *heatmapFile); err != nil {
+				fmt.Fprintln(os.Stderr, "monitor:", err)
+			}
+		}()
+	}
+
+	if *vcdFile != "" {
+		f, err := os.Create(*vcdFile)
+		if err != nil {
+			return fmt.Errorf("writing bus dump: %w", err)
+		}
+		dump := vcd.NewWriter(f, c)
+		c.AddWatchpoint(0x0000, 0xFFFF, cpu.AccessAny, dump.Record)
+		defer func() {
+			err := dump.Flush()
+			if closeErr := f.Close(); err == nil && closeErr != nil {
+				err = fmt.Errorf("writing bus dump: %w", closeErr)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "monitor:", err)
+			}
+		}()
+	}
+
+	if *jsonFile != "" {
+		f, err := os.Create(*jsonFile)
+		if err != nil {
+			return fmt.Errorf("writing JSON trace: %w", err)
+		}
+		trace := jsontrace.NewWriter(f, c, b.DebugView())
+		if *jsonBus {
+			c.AddWatchpoint(0x0000, 0xFFFF, cpu.AccessAny, trace.Access)
+		}
+		c.AddInstructionHook(trace.Instruction)
+		defer func() {
+			err := trace.Flush()
+			if closeErr := f.Close(); err == nil && closeErr != nil {
+				err = fmt.Errorf("writing JSON trace: %w", closeErr)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "monitor:", err)
+			}
+		}()
+	}
+
+	if *gdbAddr != "" {
+		return gdb.NewServer(c, b.DebugView()).ListenAndServe(*gdbAddr)
+	}
+	if *webAddr != "" {
+		return web.NewServer(c, b.DebugView()).ListenAndServe(*webAddr)
+	}
+
+	m := monitor.New(c, b.DebugView(), os.Stdout)
+	format, err := disasm.ParseTraceFormat(*traceFormat)
+	if err != nil {
+		return err
+	}
+	m.SetTraceFormat(format)
+	m.ShowCycles(*cycles)
+	report := &crash.Report{Machine: &savestate.Machine{CPU: c, Memory: b.DebugView()}}
+	if *symbolFile != "" {
+		syms := symbols.New()
+		if err := syms.LoadFile(*symbolFile); err != nil {
+			return err
+		}
+		m.SetSymbols(syms)
+		report.Symbols = syms
+	}
+	if *scriptFile != "" {
+		if err := m.Source(*scriptFile); err != nil {
+			return err
+		}
+	}
+	if *crashFile != "" {
+		m.SetFaultHandler(func(err error) {
+			report.Err = err
+			if err := report.WriteFile(*crashFile); err != nil {
+				fmt.Fprintln(os.Stderr, "monitor:", err)
+				return
+			}
+			fmt.Printf("crash report written to %s\n", *crashFile)
+		})
+	}
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		for range interrupts {
+			m.Interrupt()
+		}
+	}()
+	return m.Run(os.Stdin)
+}
+
+func writeProfile(p *profile.Profiler, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("writing profile: %w", err)
+	}
+	if err := p.WriteReport(f, profile.ByAddress, 0); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing profile: %w", err)
+	}
+	return nil
+}
+
+// writeCoverage writes a map of the pages where code was executed.
+func writeCoverage(cov *coverage.Coverage, path string) error {
+	ranges := cov.Ranges()
+	if len(ranges) == 0 {
+		return nil
+	}
+	start := ranges[0].Start &^ 0xFF
+	end := ranges[len(ranges)-1].End | 0xFF
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("writing coverage: %w", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".html") {
+		err = cov.WriteHTML(f, start, end)
+	} else {
+		err = cov.WriteText(f, start, end)
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing coverage: %w", err)
+	}
+	return nil
+}
+
+func writeHeatmap(h *heatmap.Heatmap, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("writing heatmap: %w", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".png") {
+		err = h.WritePNG(f)
+	} else {
+		err = h.WriteCSV(f)
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing heatmap: %w", err)
+	}
+	return nil
+}
+
+func buildBus(config string) (*bus.Bus, io.Closer, error) {
+	if config == "" {
+		return bus.NewWithBackend(&memory.Memory{}), io.NopCloser(nil), nil
+	}
+	cfg, err := bus.LoadConfigFile(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg.Build(nil)
+}
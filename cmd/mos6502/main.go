@@ -0,0 +1,428 @@
+// Command mos6502 runs a program on an emulated 6502 until it halts, and
+// prints the registers it ends with.
+//
+// Usage:
+//
+//	mos6502 [-config bus.json | -machine name [-image name=file]...
+//		[-option key=value]...] [-load-addr 0200] [-reset-vector addr]
+//		[-pc addr] [-trace[=file]] [-max-cycles n] [-break-at addr,...]
+//		[-result addr] [-metrics host:port] [file]
+//
+// Without -config, the whole address space is RAM; with it, the RAM, the
+// ROMs and the devices of the bus configuration are mapped. With -machine,
+// the program runs on a machine of the machines package instead, created
+// with the images and the options given, and reset; -machine list lists
+// them. The file, which only a machine makes optional, is read as a PRG
+// file if its name ends with .prg, as Intel HEX if it ends with .hex or
+// .ihx, as a paper tape of the Woz Monitor if it ends with .woz, as an iNES
+// image if it ends with .nes, and as raw bytes placed at -load-addr
+// otherwise. -reset-vector stores an address in the reset vector at $FFFC.
+// Execution starts at -pc, or at -reset-vector, or at the start of the
+// loaded file, or where the reset of the machine leaves it.
+//
+// The program halts at a BRK, at an instruction jumping to itself, the usual
+// end of test programs, or at an address of -break-at. It is stopped after
+// -max-cycles cycles, or when an instruction fails. With -trace, every
+// instruction executed is written to the standard error, or to the file
+// given, with the registers before it.
+//
+// The exit status is 0 once the program halts, or, with -result, the byte at
+// that address, such as where a test ROM stores the number of the failed
+// test. It is 1 if an instruction fails, 2 for invalid arguments, and 124 if
+// the program is stopped after -max-cycles cycles.
+//
+// With -metrics, the counters of the metrics package are served while the
+// program runs, at /metrics in the text format of Prometheus and at
+// /debug/vars with expvar.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/disasm"
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/machines"
+	_ "github.com/leakedmemory/mos6502/machines/all"
+	"github.com/leakedmemory/mos6502/memory"
+	"github.com/leakedmemory/mos6502/metrics"
+)
+
+// Exit statuses other than the result.
+const (
+	exitFault      = 1
+	exitUsage      = 2
+	exitCycleLimit = 124
+)
+
+// defaultMaxCycles is the number of cycles the program is stopped after by
+// default.
+const defaultMaxCycles = 100_000_000
+
+// errCycleLimit is returned when the program runs for -max-cycles cycles
+// without halting.
+var errCycleLimit = errors.New("cycle limit reached")
+
+func main() {
+	status, err := run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mos6502:", err)
+	}
+	os.Exit(status)
+}
+
+func run() (int, error) {
+	config := flag.String("config", "", "bus configuration `file`")
+	machine := flag.String("machine", "", "run on the machine `name`, or list them with list")
+	images := pairsFlag{}
+	flag.Var(images, "image", "load the image `name=file` of the machine; can be repeated")
+	machineOpts := pairsFlag{}
+	flag.Var(machineOpts, "option", "set the option `key=value` of the machine; can be repeated")
+	loadAddr := flag.String("load-addr", "0200", "load `address` of raw binaries, in hex")
+	resetVector := flag.String("reset-vector", "", "store `address` in the reset vector, in hex")
+	pc := flag.String("pc", "", "start `address`, in hex")
+	var trace traceFlag
+	flag.Var(&trace, "trace", "write the instructions executed to the standard error, or to `file` with -trace=file")
+	maxCycles := flag.Uint("max-cycles", defaultMaxCycles, "stop after `n` cycles")
+	breakAt := flag.String("break-at", "", "halt at the `addresses`, in hex, separated by commas")
+	result := flag.String("result", "", "exit with the byte at `address`, in hex, once halted")
+	metricsAddr := flag.String("metrics", "", "serve the metrics at `host:port`")
+	flag.Parse()
+	if *machine == "list" {
+		listMachines(os.Stdout)
+		return 0, nil
+	}
+	if flag.NArg() > 1 || flag.NArg() == 0 && *machine == "" {
+		flag.Usage()
+		return exitUsage, nil
+	}
+	if *machine != "" && *config != "" {
+		return exitUsage, errors.New("-config and -machine are exclusive")
+	}
+
+	opts, err := parseOptions(*loadAddr, *resetVector, *pc, *breakAt, *result)
+	if err != nil {
+		return exitUsage, err
+	}
+	var b *bus.Bus
+	var c *cpu.CPU
+	if *machine != "" {
+		s, err := newMachine(*machine, images, machineOpts)
+		if err != nil {
+			return exitFault, err
+		}
+		b, c = s.Bus, s.CPU
+		s.Reset()
+	} else {
+		var closer io.Closer
+		if b, closer, err = buildBus(*config); err != nil {
+			return exitFault, err
+		}
+		defer func() { _ = closer.Close() }()
+		c = cpu.New(b)
+		c.Reset()
+	}
+	start := c.Registers().PC
+	if flag.NArg() == 1 {
+		if start, err = loader.LoadFile(b.DebugView(), flag.Arg(0), opts.loadAddr); err != nil {
+			return exitFault, fmt.Errorf("loading %s: %w", flag.Arg(0), err)
+		}
+	}
+	if opts.hasResetVector {
+		memory.WriteWord(b.DebugView(), opts.resetVector, resetVectorAddr)
+		start = opts.resetVector
+	}
+	if opts.hasPC {
+		start = opts.pc
+	}
+	r := c.Registers()
+	r.PC = start
+	c.SetRegisters(r)
+
+	var tw io.Writer
+	if trace.set {
+		f, err := trace.open()
+		if err != nil {
+			return exitFault, err
+		}
+		defer func() { _ = f.Close() }()
+		bw := bufio.NewWriter(f)
+		defer func() { _ = bw.Flush() }()
+		tw = bw
+	}
+
+	var m *metrics.Collector
+	if *metricsAddr != "" {
+		if m, err = serveMetrics(c, *metricsAddr); err != nil {
+			return exitUsage, err
+		}
+	}
+
+	halt, err := execute(c, *maxCycles, opts.breakAt, tw)
+	printState(os.Stdout, c, halt)
+	switch {
+	case errors.Is(err, errCycleLimit):
+		return exitCycleLimit, err
+	case err != nil:
+		if m != nil {
+			m.Observe(err)
+		}
+		return exitFault, err
+	case opts.hasResult:
+		return int(b.Peek(opts.result)), nil
+	default:
+		return 0, nil
+	}
+}
+
+// resetVectorAddr is the address of the reset vector.
+const resetVectorAddr = 0xFFFC
+
+// options are the addresses given by the flags.
+type options struct {
+	loadAddr       uint16
+	resetVector    uint16
+	hasResetVector bool
+	pc             uint16
+	hasPC          bool
+	breakAt        []uint16
+	result         uint16
+	hasResult      bool
+}
+
+func parseOptions(loadAddr, resetVector, pc, breakAt, result string) (*options, error) {
+	opts := &options{}
+	var err error
+	if opts.loadAddr, err = loader.ParseAddr(loadAddr); err != nil {
+		return nil, err
+	}
+	if resetVector != "" {
+		if opts.resetVector, err = loader.ParseAddr(resetVector); err != nil {
+			return nil, err
+		}
+		opts.hasResetVector = true
+	}
+	if pc != "" {
+		if opts.pc, err = loader.ParseAddr(pc); err != nil {
+			return nil, err
+		}
+		opts.hasPC = true
+	}
+	if result != "" {
+		if opts.result, err = loader.ParseAddr(result); err != nil {
+			return nil, err
+		}
+		opts.hasResult = true
+	}
+	if breakAt != "" {
+		for _, s := range strings.Split(breakAt, ",") {
+			addr, err := loader.ParseAddr(strings.TrimSpace(s))
+			if err != nil {
+				return nil, err
+			}
+			opts.breakAt = append(opts.breakAt, addr)
+		}
+	}
+	return opts, nil
+}
+
+// traceFlag is the -trace flag, which can be given alone or with a file.
+type traceFlag struct {
+	set  bool
+	path string
+}
+
+func (f *traceFlag) String() string {
+	return f.path
+}
+
+func (f *traceFlag) Set(s string) error {
+	f.set = true
+	if s != "true" {
+		f.path = s
+	}
+	return nil
+}
+
+func (f *traceFlag) IsBoolFlag() bool {
+	return true
+}
+
+// open returns the file to write the trace to.
+func (f *traceFlag) open() (io.WriteCloser, error) {
+	if f.path == "" {
+		return nopCloser{os.Stderr}, nil
+	}
+	return os.Create(f.path)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// pairsFlag is a flag of key=value pairs, which can be repeated.
+type pairsFlag map[string]string
+
+func (f pairsFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for k, v := range f {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f pairsFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("%q is not key=value", s)
+	}
+	f[k] = v
+	return nil
+}
+
+// newMachine creates the machine name with the images read from the files
+// of images, wired to the terminal.
+func newMachine(name string, images, opts pairsFlag) (*machines.System, error) {
+	cfg := machines.Config{
+		Images:  map[string][]byte{},
+		Input:   os.Stdin,
+		Output:  os.Stdout,
+		Options: opts,
+	}
+	for key, path := range images {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Images[key] = data
+	}
+	return machines.New(name, cfg)
+}
+
+// listMachines writes the machines registered, with their images and their
+// options, to w.
+func listMachines(w io.Writer) {
+	for _, name := range machines.Registered() {
+		info, _ := machines.Lookup(name)
+		fmt.Fprintf(w, "%s\t%s\n", name, info.Description)
+		for _, key := range slices.Sorted(maps.Keys(info.Images)) {
+			fmt.Fprintf(w, "\t-image %s=file\t%s\n", key, info.Images[key])
+		}
+		for _, key := range slices.Sorted(maps.Keys(info.Options)) {
+			fmt.Fprintf(w, "\t-option %s=value\t%s\n", key, info.Options[key])
+		}
+	}
+}
+
+// execute runs c until it halts, returning why, or until it has run limit
+// cycles or an instruction fails. It halts before the addresses of breakAt
+// and at a BRK, and writes every instruction executed to trace, if not nil.
+func execute(c *cpu.CPU, limit uint, breakAt []uint16, trace io.Writer) (string, error) {
+	for _, addr := range breakAt {
+		c.AddBreakpoint(addr)
+	}
+	c.AddInterruptBreakpoint(cpu.InterruptBRK)
+	var t *tracer
+	if trace != nil {
+		c.SetTraceSize(1)
+		t = &tracer{w: trace, c: c}
+	}
+	trapped := false
+	hook := c.AddInstructionHook(func(e cpu.InstructionEvent) {
+		if t != nil && t.write() != nil {
+			c.Stop()
+		}
+		if c.Registers().PC == e.PC {
+			trapped = true
+			c.Stop()
+		}
+	})
+	defer c.RemoveInstructionHook(hook)
+
+	reason, err := c.RunFor(limit)
+	if t != nil {
+		// The instruction failing runs no hook.
+		if werr := t.write(); werr != nil {
+			return "trace", werr
+		}
+	}
+	pc := c.Registers().PC
+	switch {
+	case reason == cpu.StopFault:
+		return "fault", err
+	case reason == cpu.StopBreakpoint:
+		return fmt.Sprintf("break at $%04X", pc), nil
+	case reason == cpu.StopInterrupt:
+		return fmt.Sprintf("BRK at $%04X", pc), nil
+	case trapped:
+		return fmt.Sprintf("trapped at $%04X", pc), nil
+	}
+	return "cycle limit", errCycleLimit
+}
+
+// tracer writes the instructions executed, as kept by the trace of the CPU.
+type tracer struct {
+	w   io.Writer
+	c   *cpu.CPU
+	err error
+	// last is the cycle count of the last instruction written, if written:
+	// a step entering an interrupt records no instruction.
+	last    uint
+	written bool
+}
+
+// write writes the last instruction executed, unless already written, and
+// returns the first error met.
+func (t *tracer) write() error {
+	if e := t.c.Trace(); t.err == nil && len(e) != 0 && (!t.written || e[0].Cycles != t.last) {
+		t.err = disasm.WriteTrace(t.w, e)
+		t.written, t.last = true, e[0].Cycles
+	}
+	return t.err
+}
+
+// serveMetrics serves the metrics of c at addr, in the background.
+func serveMetrics(c *cpu.CPU, addr string) (*metrics.Collector, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	m := metrics.New(c, "mos6502")
+	m.Publish()
+	http.Handle("/metrics", metrics.Handler(m))
+	go func() { _ = http.Serve(ln, nil) }()
+	return m, nil
+}
+
+func printState(w io.Writer, c *cpu.CPU, halt string) {
+	r := c.Registers()
+	fmt.Fprintf(w, "halted: %s after %d cycles\n", halt, c.Cycles())
+	fmt.Fprintf(w, "  PC  SR AC XR YR SP  NV-BDIZC\n")
+	fmt.Fprintf(w, " %04X %02X %02X %02X %02X %02X  %08b\n", r.PC, r.SR, r.A, r.X, r.Y, r.SP, r.SR)
+}
+
+func buildBus(config string) (*bus.Bus, io.Closer, error) {
+	if config == "" {
+		return bus.NewWithBackend(&memory.Memory{}), io.NopCloser(nil), nil
+	}
+	cfg, err := bus.LoadConfigFile(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg.Build(nil)
+}
@@ -0,0 +1,53 @@
+// Command truthtable writes the results and flags the ALU instructions of
+// the emulated 6502 produce over their inputs, for diffing against published
+// tables and other emulators.
+//
+// Usage:
+//
+//	truthtable [-sample n] [-seed n] [opcode ...]
+//
+// The opcodes are in hex, and default to every ALU instruction implemented,
+// of which there are none yet: the tables are written as the CPU comes to
+// execute them. Every input is run unless -sample is given, in which case n
+// inputs are drawn at random from the seed given to -seed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/leakedmemory/mos6502/truthtable"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "truthtable:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	sample := flag.Int("sample", 0, "run `n` inputs drawn at random instead of all of them")
+	seed := flag.Int64("seed", 1, "seed of the sampled inputs")
+	flag.Parse()
+
+	ops := truthtable.Opcodes()
+	if flag.NArg() != 0 {
+		ops = ops[:0]
+		for _, arg := range flag.Args() {
+			op, err := strconv.ParseUint(arg, 16, 8)
+			if err != nil {
+				return fmt.Errorf("invalid opcode %q", arg)
+			}
+			ops = append(ops, byte(op))
+		}
+	}
+	for _, op := range ops {
+		if err := truthtable.Write(os.Stdout, op, truthtable.Options{Sample: *sample, Seed: *seed}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
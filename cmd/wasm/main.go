@@ -0,0 +1,15 @@
+//go:build js && wasm
+
+// Command wasm is the WebAssembly module of the emulator, publishing the
+// JavaScript API of package wasm as the global object mos6502:
+//
+//	GOOS=js GOARCH=wasm go build -o mos6502.wasm ./cmd/wasm
+package main
+
+import "github.com/leakedmemory/mos6502/wasm"
+
+func main() {
+	wasm.Register(wasm.New())
+	// The functions published live as long as the program.
+	select {}
+}
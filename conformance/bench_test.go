@@ -0,0 +1,46 @@
+package conformance
+
+import (
+	"os"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// BenchmarkKlausFunctional runs the whole functional test once per iteration.
+func BenchmarkKlausFunctional(b *testing.B) {
+	path := os.Getenv(klausFunctionalEnv)
+	if path == "" {
+		b.Skipf("%s is not set", klausFunctionalEnv)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+	image := loader.Segment{Addr: 0x0000, Data: data}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	instructions, cycles := 0, uint(0)
+	for range b.N {
+		b.StopTimer()
+		mem := &memory.Memory{}
+		if err := image.Load(mem); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		c := cpu.New(mem)
+		c.Reset()
+		c.AddInstructionHook(func(cpu.InstructionEvent) { instructions++ })
+		start := c.Cycles()
+		b.StartTimer()
+
+		if err := FunctionalTest.Run(c, mem); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		cycles += c.Cycles() - start
+	}
+	b.ReportMetric(float64(instructions)/b.Elapsed().Seconds(), "instructions/s")
+	b.ReportMetric(float64(cycles)/b.Elapsed().Seconds(), "cycles/s")
+}
@@ -0,0 +1,125 @@
+package conformance
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// Values of the status byte of the test ROMs of blargg.
+const (
+	blarggRunning      byte = 0x80
+	blarggResetRequest byte = 0x81
+)
+
+// blarggSignature follows the status byte once the ROM reports through it.
+var blarggSignature = [3]byte{0xDE, 0xB0, 0x61}
+
+// blarggSlice is the number of cycles run between checks of the status byte.
+const blarggSlice = 10_000
+
+// blarggResetDelay is the number of cycles waited before a reset requested by
+// a ROM, at least the 100 ms it asks for on an NTSC NES.
+const blarggResetDelay = 180_000
+
+// resetVector is where the CPU reads the address it starts at on a reset.
+const resetVector uint16 = 0xFFFC
+
+// blarggTextMax bounds the length of the text of a ROM.
+const blarggTextMax = 0x1000
+
+// Blargg describes a test ROM of blargg, such as cpu_timing_test6 or the
+// ROMs of instr_timing, reporting through a status byte followed by the
+// signature $DE $B0 $61 and a text ending with a zero. The ROM is loaded with
+// its reset vector, where it starts.
+type Blargg struct {
+	// Status is the address of the status byte, $6000 in the NES builds.
+	Status uint16
+	// MaxCycles is the number of cycles the ROM may run for.
+	MaxCycles uint
+}
+
+// TimingTest is the NES build of the timing ROMs.
+var TimingTest = Blargg{Status: 0x6000, MaxCycles: 200_000_000}
+
+// BlarggError is returned when a test ROM of blargg reports a failure.
+type BlarggError struct {
+	// Result is the status byte, the number of the test that failed for
+	// most ROMs.
+	Result byte
+	// Text is what the ROM printed.
+	Text string
+}
+
+func (e *BlarggError) Error() string {
+	return fmt.Sprintf("conformance: failed with result %d: %s", e.Result, strings.TrimSpace(e.Text))
+}
+
+// Run resets c to the reset vector of the ROM loaded in its memory and runs
+// it until it reports its result, resetting c again when the ROM asks for it.
+// mem is used to read the status: it should be free of side effects, such as
+// the DebugView of a bus. Run returns the text printed by the ROM, with a
+// *BlarggError when it reports a failure, ErrTimeout when it runs for longer
+// than MaxCycles, and the error of the instruction that failed otherwise.
+func (b Blargg) Run(c *cpu.CPU, mem memory.Reader) (string, error) {
+	resetToVector(c, mem)
+	for elapsed := uint(0); ; elapsed += blarggSlice {
+		if elapsed >= b.MaxCycles {
+			return b.text(mem), fmt.Errorf("%w after %d cycles", ErrTimeout, elapsed)
+		}
+		if _, err := c.RunFor(blarggSlice); err != nil {
+			return b.text(mem), fmt.Errorf("conformance: %w", err)
+		}
+		if !b.reporting(mem) {
+			continue
+		}
+		switch status := mem.Read(b.Status); status {
+		case blarggRunning:
+		case blarggResetRequest:
+			if _, err := c.RunFor(blarggResetDelay); err != nil {
+				return b.text(mem), fmt.Errorf("conformance: %w", err)
+			}
+			resetToVector(c, mem)
+		case 0:
+			return b.text(mem), nil
+		default:
+			return b.text(mem), &BlarggError{Result: status, Text: b.text(mem)}
+		}
+	}
+}
+
+// reporting reports whether the signature follows the status byte.
+func (b Blargg) reporting(mem memory.Reader) bool {
+	for i, s := range blarggSignature {
+		if mem.Read(b.Status+1+uint16(i)) != s {
+			return false
+		}
+	}
+	return true
+}
+
+// text returns the text printed by the ROM after the signature, if any.
+func (b Blargg) text(mem memory.Reader) string {
+	if !b.reporting(mem) {
+		return ""
+	}
+	var s strings.Builder
+	for addr := b.Status + 4; s.Len() < blarggTextMax; addr++ {
+		ch := mem.Read(addr)
+		if ch == 0 {
+			break
+		}
+		s.WriteByte(ch)
+	}
+	return s.String()
+}
+
+// resetToVector resets c and sends it to the address of the reset vector.
+func resetToVector(c *cpu.CPU, mem memory.Reader) {
+	c.Reset()
+	r := c.Registers()
+	r.PC = memory.ReadWord(mem, resetVector)
+	c.SetRegisters(r)
+}
@@ -0,0 +1,119 @@
+package conformance
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// blarggEnv names the environment variable pointing at a test ROM of blargg
+// using mapper 0 and only the CPU, such as cpu_timing_test6. The ROMs of
+// instr_timing time instructions with the APU, which is not emulated.
+const blarggEnv = "MOS6502_BLARGG"
+
+var testBlargg = Blargg{Status: 0x6000, MaxCycles: 100_000}
+
+// newBlarggTest returns a CPU trapped at $0400, the reset vector, which
+// reports status and text after a few instructions.
+func newBlarggTest(status byte, text string) (*cpu.CPU, *memory.Memory) {
+	// 0400  JSR $0400
+	c, mem := newConformanceTest(0x20, 0x00, 0x04)
+	memory.WriteWord(mem, 0x0400, resetVector)
+	instructions := 0
+	c.AddInstructionHook(func(cpu.InstructionEvent) {
+		if instructions++; instructions == 10 {
+			writeBlarggReport(mem, status, text)
+		}
+	})
+	return c, mem
+}
+
+func writeBlarggReport(mem *memory.Memory, status byte, text string) {
+	mem.Write(status, testBlargg.Status)
+	for i, b := range append(blarggSignature[:], text...) {
+		mem.Write(b, testBlargg.Status+1+uint16(i))
+	}
+	mem.Write(0x00, testBlargg.Status+4+uint16(len(text)))
+}
+
+func TestBlarggPass(t *testing.T) {
+	c, mem := newBlarggTest(0x00, "\nPassed\n")
+
+	text, err := testBlargg.Run(c, mem)
+
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if text != "\nPassed\n" {
+		t.Errorf("expected %q, actual %q\n", "\nPassed\n", text)
+	}
+}
+
+func TestBlarggFailure(t *testing.T) {
+	c, mem := newBlarggTest(0x03, "BRK timing\nFailed #3\n")
+
+	_, err := testBlargg.Run(c, mem)
+
+	var failure *BlarggError
+	if !errors.As(err, &failure) {
+		t.Fatalf("expected a failure, actual %v", err)
+	}
+	if expected := (BlarggError{Result: 3, Text: "BRK timing\nFailed #3\n"}); *failure != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, *failure)
+	}
+}
+
+func TestBlarggRunning(t *testing.T) {
+	c, mem := newBlarggTest(blarggRunning, "")
+
+	if _, err := testBlargg.Run(c, mem); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected %v, actual %v\n", ErrTimeout, err)
+	}
+}
+
+func TestBlarggResetRequest(t *testing.T) {
+	c, mem := newBlarggTest(blarggResetRequest, "")
+	resets := 0
+	last := c.Cycles()
+	c.AddInstructionHook(func(cpu.InstructionEvent) {
+		if c.Cycles() < last {
+			resets++
+			writeBlarggReport(mem, 0x00, "Passed")
+		}
+		last = c.Cycles()
+	})
+
+	if _, err := (Blargg{Status: 0x6000, MaxCycles: 1_000_000}).Run(c, mem); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if resets != 1 {
+		t.Errorf("expected 1 reset, actual %d\n", resets)
+	}
+}
+
+func TestBlarggROM(t *testing.T) {
+	path := os.Getenv(blarggEnv)
+	if path == "" {
+		t.Skipf("%s is not set", blarggEnv)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	mem := &memory.Memory{}
+	if _, err := loader.LoadINES(mem, f); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := cpu.New(mem)
+
+	text, err := TimingTest.Run(c, mem)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	t.Log(text)
+}
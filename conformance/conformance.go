@@ -0,0 +1,99 @@
+// Package conformance runs the test programs published for the 6502 against
+// the emulator, such as the functional and interrupt tests of Klaus Dormann,
+// nestest and the timing ROMs of blargg, and compares it instruction by
+// instruction with other emulators.
+//
+// The test programs themselves are not part of the repository. The tests of
+// the package run them when environment variables point at them, such as
+//
+//	MOS6502_KLAUS_FUNCTIONAL=6502_functional_test.bin go test ./conformance
+package conformance
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// runSlice is the number of cycles run between checks for a timeout.
+const runSlice = 100_000
+
+// ErrTimeout is returned when a test program runs for longer than allowed.
+var ErrTimeout = errors.New("conformance: timeout")
+
+// Klaus describes a build of one of the tests of Klaus Dormann, loaded as a
+// 64 KiB image at $0000. The tests end by trapping, that is by jumping or
+// branching to themselves, at Success when all the tests pass, and elsewhere
+// when one fails.
+type Klaus struct {
+	// Start is where the test starts, $0400 in the default builds.
+	Start uint16
+	// Success is where the test traps when it passes.
+	Success uint16
+	// TestCase is the address of the byte holding the number of the test
+	// running, $0200 in the default builds.
+	TestCase uint16
+	// MaxCycles is the number of cycles the test may run for.
+	MaxCycles uint
+}
+
+// FunctionalTest is the default build of 6502_functional_test.bin, with
+// decimal mode tested.
+var FunctionalTest = Klaus{Start: 0x0400, Success: 0x3469, TestCase: 0x0200, MaxCycles: 100_000_000}
+
+// InterruptTest is the default build of 6502_interrupt_test.bin. It needs a
+// FeedbackRegister mapped at $BFFC.
+var InterruptTest = Klaus{Start: 0x0400, Success: 0x06F5, TestCase: 0x0200, MaxCycles: 10_000_000}
+
+// FeedbackAddr is the address of the FeedbackRegister in the default build of
+// the interrupt test.
+const FeedbackAddr uint16 = 0xBFFC
+
+// TrapError is returned when a test traps elsewhere than at its success
+// address, that is when one of its tests fails.
+type TrapError struct {
+	PC uint16
+	// TestCase is the number of the test that failed.
+	TestCase byte
+}
+
+func (e *TrapError) Error() string {
+	return fmt.Sprintf("conformance: test $%02X failed, trapped at $%04X", e.TestCase, e.PC)
+}
+
+// Run runs the test loaded in the memory of c from its start until it traps.
+// mem is used to read the number of the test running: it should be free of
+// side effects, such as the DebugView of a bus. Run returns nil when the
+// test passes, a *TrapError when one of its tests fails, ErrTimeout when it
+// runs for longer than MaxCycles, and the error of the instruction that
+// failed otherwise, wrapped with the number of the test running.
+func (k Klaus) Run(c *cpu.CPU, mem memory.Reader) error {
+	r := c.Registers()
+	r.PC = k.Start
+	c.SetRegisters(r)
+
+	trapped := false
+	id := c.AddInstructionHook(func(e cpu.InstructionEvent) {
+		if c.Registers().PC == e.PC {
+			trapped = true
+			c.Stop()
+		}
+	})
+	defer c.RemoveInstructionHook(id)
+
+	start := c.Cycles()
+	for !trapped {
+		if c.Cycles()-start >= k.MaxCycles {
+			return fmt.Errorf("%w after %d cycles in test $%02X", ErrTimeout, c.Cycles()-start, mem.Read(k.TestCase))
+		}
+		if _, err := c.RunFor(runSlice); err != nil {
+			return fmt.Errorf("conformance: test $%02X: %w", mem.Read(k.TestCase), err)
+		}
+	}
+	if pc := c.Registers().PC; pc != k.Success {
+		return &TrapError{PC: pc, TestCase: mem.Read(k.TestCase)}
+	}
+	return nil
+}
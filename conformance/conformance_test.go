@@ -0,0 +1,122 @@
+package conformance
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// Environment variables pointing at the default builds of
+// 6502_functional_test.bin and 6502_interrupt_test.bin.
+const (
+	klausFunctionalEnv = "MOS6502_KLAUS_FUNCTIONAL"
+	klausInterruptEnv  = "MOS6502_KLAUS_INTERRUPT"
+)
+
+var testKlaus = Klaus{Start: 0x0400, Success: 0x0400, TestCase: 0x0200, MaxCycles: 1000}
+
+func newConformanceTest(code ...byte) (*cpu.CPU, *memory.Memory) {
+	mem := &memory.Memory{}
+	for i, b := range code {
+		mem.Write(b, testKlaus.Start+uint16(i))
+	}
+	c := cpu.New(mem)
+	c.Reset()
+	return c, mem
+}
+
+func TestKlausSuccess(t *testing.T) {
+	// 0400  JSR $0400
+	c, mem := newConformanceTest(0x20, 0x00, 0x04)
+
+	if err := testKlaus.Run(c, mem); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestKlausTrap(t *testing.T) {
+	// 0400  LDA #$01
+	// 0402  JSR $0402
+	c, mem := newConformanceTest(0xA9, 0x01, 0x20, 0x02, 0x04)
+	mem.Write(0x05, testKlaus.TestCase)
+
+	err := testKlaus.Run(c, mem)
+
+	var trap *TrapError
+	if !errors.As(err, &trap) {
+		t.Fatalf("expected a trap, actual %v", err)
+	}
+	if expected := (TrapError{PC: 0x0402, TestCase: 0x05}); *trap != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, *trap)
+	}
+}
+
+func TestKlausTimeout(t *testing.T) {
+	// 0400  LDA #$01
+	// 0402  JSR $0400
+	c, mem := newConformanceTest(0xA9, 0x01, 0x20, 0x00, 0x04)
+
+	if err := testKlaus.Run(c, mem); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected %v, actual %v\n", ErrTimeout, err)
+	}
+}
+
+func TestKlausFault(t *testing.T) {
+	c, mem := newConformanceTest(0xA9, 0x01)
+
+	var invalid *cpu.InvalidOpcodeError
+	if err := testKlaus.Run(c, mem); !errors.As(err, &invalid) {
+		t.Errorf("expected an invalid opcode, actual %v\n", err)
+	}
+}
+
+func TestKlausFunctional(t *testing.T) {
+	path := os.Getenv(klausFunctionalEnv)
+	if path == "" {
+		t.Skipf("%s is not set", klausFunctionalEnv)
+	}
+	mem := loadKlausTest(t, path)
+	c := cpu.New(mem)
+	c.Reset()
+
+	if err := FunctionalTest.Run(c, mem); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestKlausInterrupt(t *testing.T) {
+	path := os.Getenv(klausInterruptEnv)
+	if path == "" {
+		t.Skipf("%s is not set", klausInterruptEnv)
+	}
+	b := bus.NewWithBackend(loadKlausTest(t, path))
+	c := cpu.New(b)
+	c.Reset()
+	if err := b.Map(FeedbackAddr, FeedbackAddr, NewFeedbackRegister(c)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := InterruptTest.Run(c, b.DebugView()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// loadKlausTest loads the image of a test of Klaus Dormann at $0000.
+func loadKlausTest(t *testing.T, path string) *memory.Memory {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	mem := &memory.Memory{}
+	if _, err := loader.LoadBinary(mem, f, 0x0000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return mem
+}
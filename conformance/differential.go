@@ -0,0 +1,176 @@
+package conformance
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// ErrReference is returned when a reference emulator fails or answers
+// something that does not follow the protocol.
+var ErrReference = errors.New("conformance: reference emulator")
+
+// State is the state of a CPU after an instruction.
+type State struct {
+	cpu.Registers
+	// Cycles is the number of cycles the instruction took.
+	Cycles uint
+}
+
+func (s State) String() string {
+	return fmt.Sprintf("A:%02X X:%02X Y:%02X SP:%02X PC:%04X SR:%02X cycles:%d", s.A, s.X, s.Y, s.SP, s.PC, s.SR, s.Cycles)
+}
+
+// Reference is another 6502 implementation the CPU is compared with.
+type Reference interface {
+	// Load sets the 64 KiB of memory and the registers of the reference.
+	Load(image []byte, r cpu.Registers) error
+	// Step executes an instruction and returns the state it leaves.
+	Step() (State, error)
+}
+
+// MismatchError is returned when the CPU leaves another state than the
+// reference after an instruction.
+type MismatchError struct {
+	// Step is the number of the instruction, from 1.
+	Step int
+	// PC is the address of the instruction.
+	PC       uint16
+	Expected State
+	Actual   State
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("conformance: instruction %d at $%04X diverges from the reference:\n- %v\n+ %v", e.Step, e.PC, e.Expected, e.Actual)
+}
+
+// Differential runs c and ref side by side for steps instructions, starting
+// from the memory of c and its registers, and returns a *MismatchError at
+// the first instruction after which their states differ. mem is used to read
+// the memory of c: it should be free of side effects, such as the DebugView
+// of a bus. The error of an instruction that failed in c or ref is returned
+// as well.
+func Differential(c *cpu.CPU, mem memory.Reader, ref Reference, steps int) error {
+	image := make([]byte, 0x10000)
+	for addr := range image {
+		image[addr] = mem.Read(uint16(addr))
+	}
+	if err := ref.Load(image, c.Registers()); err != nil {
+		return err
+	}
+	for n := 1; n <= steps; n++ {
+		pc, start := c.Registers().PC, c.Cycles()
+		if err := c.Step(); err != nil {
+			return fmt.Errorf("conformance: instruction %d at $%04X: %w", n, pc, err)
+		}
+		actual := State{Registers: c.Registers(), Cycles: c.Cycles() - start}
+		expected, err := ref.Step()
+		if err != nil {
+			return fmt.Errorf("conformance: instruction %d at $%04X: %w", n, pc, err)
+		}
+		if actual != expected {
+			return &MismatchError{Step: n, PC: pc, Expected: expected, Actual: actual}
+		}
+	}
+	return nil
+}
+
+// Process is a Reference running as a subprocess, such as a wrapper around an
+// established emulator, which reads requests on its standard input and
+// answers each with a line on its standard output. Numbers are in
+// hexadecimal, without prefix. The requests are
+//
+//	mem AAAA XXXX...   store the bytes XX from AAAA, answering "ok"
+//	regs A X Y SP PC SR
+//	                   set the registers, answering "ok"
+//	step               execute an instruction, answering
+//	                   "A X Y SP PC SR CYCLES" with the state it leaves
+//
+// Any other answer is taken as an error message.
+type Process struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	w   *bufio.Writer
+	out *bufio.Scanner
+}
+
+// StartProcess starts cmd, whose standard input and output must not be set,
+// as a reference emulator.
+func StartProcess(cmd *exec.Cmd) (*Process, error) {
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("conformance: %w", err)
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("conformance: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("conformance: %w", err)
+	}
+	return &Process{cmd: cmd, in: in, w: bufio.NewWriter(in), out: bufio.NewScanner(out)}, nil
+}
+
+// Load sends the pages of image holding anything but zeros, then r.
+func (p *Process) Load(image []byte, r cpu.Registers) error {
+	for page := 0; page < len(image); page += 0x100 {
+		data := image[page:min(page+0x100, len(image))]
+		if strings.Trim(string(data), "\x00") == "" {
+			continue
+		}
+		if _, err := p.request("mem %04X %X", page, data); err != nil {
+			return err
+		}
+	}
+	_, err := p.request("regs %02X %02X %02X %02X %04X %02X", r.A, r.X, r.Y, r.SP, r.PC, r.SR)
+	return err
+}
+
+func (p *Process) Step() (State, error) {
+	answer, err := p.request("step")
+	if err != nil {
+		return State{}, err
+	}
+	var s State
+	if _, err := fmt.Sscanf(answer, "%x %x %x %x %x %x %x", &s.A, &s.X, &s.Y, &s.SP, &s.PC, &s.SR, &s.Cycles); err != nil {
+		return State{}, fmt.Errorf("%w: %s", ErrReference, answer)
+	}
+	return s, nil
+}
+
+// request sends a request and returns its answer, which must be "ok" for all
+// but step.
+func (p *Process) request(format string, args ...any) (string, error) {
+	fmt.Fprintf(p.w, format+"\n", args...)
+	if err := p.w.Flush(); err != nil {
+		return "", fmt.Errorf("conformance: %w", err)
+	}
+	if !p.out.Scan() {
+		if err := p.out.Err(); err != nil {
+			return "", fmt.Errorf("conformance: %w", err)
+		}
+		return "", fmt.Errorf("%w: unexpected end of output", ErrReference)
+	}
+	answer := p.out.Text()
+	if format != "step" && answer != "ok" {
+		return "", fmt.Errorf("%w: %s", ErrReference, answer)
+	}
+	return answer, nil
+}
+
+// Close closes the standard input of the process and waits for it to exit.
+func (p *Process) Close() error {
+	if err := p.in.Close(); err != nil {
+		return fmt.Errorf("conformance: %w", err)
+	}
+	if err := p.cmd.Wait(); err != nil {
+		return fmt.Errorf("conformance: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package conformance
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// referenceHelperEnv makes the test binary serve as a reference emulator,
+// running this CPU, when set to "1", and a reference taking a cycle too many
+// per instruction when set to "skew".
+const referenceHelperEnv = "MOS6502_REFERENCE_HELPER"
+
+func TestReferenceHelperProcess(t *testing.T) {
+	mode := os.Getenv(referenceHelperEnv)
+	if mode == "" {
+		t.Skip("not a reference emulator")
+	}
+	serveReference(mode == "skew")
+	os.Exit(0)
+}
+
+// serveReference serves the protocol of Process on the standard input and
+// output.
+func serveReference(skew bool) {
+	mem := &memory.Memory{}
+	c := cpu.New(mem)
+	c.Reset()
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		switch fields[0] {
+		case "mem":
+			var addr uint16
+			_, _ = fmt.Sscanf(fields[1], "%x", &addr)
+			data, _ := hex.DecodeString(fields[2])
+			for i, b := range data {
+				mem.Write(b, addr+uint16(i))
+			}
+			fmt.Println("ok")
+		case "regs":
+			var r cpu.Registers
+			_, _ = fmt.Sscanf(sc.Text(), "regs %x %x %x %x %x %x", &r.A, &r.X, &r.Y, &r.SP, &r.PC, &r.SR)
+			c.SetRegisters(r)
+			fmt.Println("ok")
+		case "step":
+			start := c.Cycles()
+			if err := c.Step(); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			r, cycles := c.Registers(), c.Cycles()-start
+			if skew {
+				cycles++
+			}
+			fmt.Printf("%02X %02X %02X %02X %04X %02X %X\n", r.A, r.X, r.Y, r.SP, r.PC, r.SR, cycles)
+		default:
+			fmt.Println("unknown request")
+		}
+	}
+}
+
+func startReferenceHelper(t *testing.T, mode string) *Process {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestReferenceHelperProcess$")
+	cmd.Env = append(os.Environ(), referenceHelperEnv+"="+mode)
+	p, err := StartProcess(cmd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+// newDifferentialTest returns a CPU running a subroutine call from $0400.
+func newDifferentialTest() (*cpu.CPU, *memory.Memory) {
+	// 0400  LDA #$80
+	// 0402  JSR $0500
+	// 0405  SEI
+	// 0500  LDA #$00
+	// 0502  RTS
+	c, mem := newConformanceTest(0xA9, 0x80, 0x20, 0x00, 0x05, 0x78)
+	for i, b := range []byte{0xA9, 0x00, 0x60} {
+		mem.Write(b, 0x0500+uint16(i))
+	}
+	r := c.Registers()
+	r.PC = 0x0400
+	c.SetRegisters(r)
+	return c, mem
+}
+
+func TestDifferentialMatch(t *testing.T) {
+	c, mem := newDifferentialTest()
+	p := startReferenceHelper(t, "1")
+
+	if err := Differential(c, mem, p, 5); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if pc := c.Registers().PC; pc != 0x0406 {
+		t.Errorf("expected pc $0406, actual $%04X\n", pc)
+	}
+}
+
+func TestDifferentialMismatch(t *testing.T) {
+	c, mem := newDifferentialTest()
+	p := startReferenceHelper(t, "skew")
+
+	err := Differential(c, mem, p, 5)
+
+	var mismatch *MismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected a mismatch, actual %v", err)
+	}
+	if mismatch.Step != 1 || mismatch.PC != 0x0400 {
+		t.Errorf("expected a mismatch at instruction 1 at $0400, actual %+v\n", mismatch)
+	}
+	if mismatch.Expected.Cycles != 3 || mismatch.Actual.Cycles != 2 {
+		t.Errorf("expected 3 and 2 cycles, actual %d and %d\n", mismatch.Expected.Cycles, mismatch.Actual.Cycles)
+	}
+}
+
+func TestDifferentialReferenceError(t *testing.T) {
+	// The reference sees a BRK, which it can not execute, at $0400.
+	c, mem := newDifferentialTest()
+	p := startReferenceHelper(t, "1")
+	ref := &rewritingReference{Reference: p, addr: 0x0400}
+
+	if err := Differential(c, mem, ref, 1); !errors.Is(err, ErrReference) {
+		t.Errorf("expected %v, actual %v\n", ErrReference, err)
+	}
+}
+
+// rewritingReference clears the byte at addr of the image it loads.
+type rewritingReference struct {
+	Reference
+	addr uint16
+}
+
+func (r *rewritingReference) Load(image []byte, regs cpu.Registers) error {
+	image[r.addr] = 0x00
+	return r.Reference.Load(image, regs)
+}
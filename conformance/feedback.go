@@ -0,0 +1,44 @@
+package conformance
+
+// Bits of the feedback register in the default build of the interrupt test.
+const (
+	feedbackIRQ byte = 1 << 0
+	feedbackNMI byte = 1 << 1
+)
+
+// InterruptLines are the interrupt inputs of a CPU, such as *cpu.CPU.
+type InterruptLines interface {
+	SetIRQ(asserted bool)
+	NMI()
+}
+
+// FeedbackRegister is the port through which the interrupt test of Klaus
+// Dormann triggers interrupts, as wired in its default build: setting bit 0
+// asserts IRQ for as long as it stays set, and setting bit 1 signals an NMI.
+// It is mapped at a single address, $BFFC in the default build, and reads
+// back what was written.
+type FeedbackRegister struct {
+	lines InterruptLines
+	value byte
+}
+
+// NewFeedbackRegister returns a FeedbackRegister driving lines.
+func NewFeedbackRegister(lines InterruptLines) *FeedbackRegister {
+	return &FeedbackRegister{lines: lines}
+}
+
+// Read returns the value last written.
+func (f *FeedbackRegister) Read(uint16) byte {
+	return f.value
+}
+
+// Write sets the interrupt lines from val. The NMI input is edge-triggered:
+// only setting bit 1 while it is clear signals an NMI.
+func (f *FeedbackRegister) Write(val byte, _ uint16) {
+	rising := val &^ f.value
+	f.value = val
+	f.lines.SetIRQ(val&feedbackIRQ != 0)
+	if rising&feedbackNMI != 0 {
+		f.lines.NMI()
+	}
+}
@@ -0,0 +1,25 @@
+package conformance
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestFeedbackRegister(t *testing.T) {
+	c := cpu.New(&memory.Memory{})
+	c.Reset()
+	f := NewFeedbackRegister(c)
+
+	f.Write(feedbackIRQ|feedbackNMI, 0)
+	if !c.IRQ() || !c.NMIPending() || f.Read(0) != feedbackIRQ|feedbackNMI {
+		t.Errorf("expected IRQ and NMI, actual %v and %v\n", c.IRQ(), c.NMIPending())
+	}
+
+	c.Reset()
+	f.Write(feedbackNMI, 0)
+	if c.IRQ() || c.NMIPending() {
+		t.Errorf("expected no interrupt while bit 1 stays set, actual %v and %v\n", c.IRQ(), c.NMIPending())
+	}
+}
@@ -0,0 +1,99 @@
+package conformance
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/disasm"
+)
+
+// nestestFormat is the trace format of nestest.log, without the disassembly
+// and the position of the PPU, which is not emulated.
+var nestestFormat = disasm.MustParseTraceFormat("{PC}  {BYTES:8}  A:{A} X:{X} Y:{Y} P:{SR} SP:{SP} CYC:{CYCLES}")
+
+// Where nestest.log puts its fields.
+const (
+	nestestBytesEnd = 14
+	nestestRegs     = "A:00 X:00 Y:00 P:00 SP:00"
+)
+
+// nestestContext is the number of matching lines shown before a divergence.
+const nestestContext = 5
+
+// DivergenceError is returned when the trace of the CPU differs from a golden
+// log.
+type DivergenceError struct {
+	// Line is the number of the line of the log that differs, from 1.
+	Line     int
+	Expected string
+	Actual   string
+	// Context holds the lines before it, which matched.
+	Context []string
+}
+
+func (e *DivergenceError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "conformance: trace diverges at line %d:\n", e.Line)
+	for _, l := range e.Context {
+		fmt.Fprintf(&b, "  %s\n", l)
+	}
+	fmt.Fprintf(&b, "- %s\n+ %s", e.Expected, e.Actual)
+	return b.String()
+}
+
+// Nestest runs nestest.nes, loaded in the memory of c, in its automation
+// mode from $C000, and compares the trace of the CPU with log, the canonical
+// nestest.log, line by line. The disassembly and the position of the PPU in
+// the log are not compared. Nestest returns a *DivergenceError at the first
+// line that differs, and the error of an instruction that failed where the
+// trace still matched.
+func Nestest(c *cpu.CPU, log io.Reader) error {
+	c.Reset()
+	c.SetRegisters(cpu.Registers{PC: 0xC000, SP: 0xFD, SR: 0x24})
+	c.SetTraceSize(1)
+	defer c.SetTraceSize(0)
+	p := disasm.Printer{TraceFormat: nestestFormat}
+
+	var context []string
+	sc := bufio.NewScanner(log)
+	for n := 1; sc.Scan(); n++ {
+		expected, err := parseNestestLine(sc.Text())
+		if err != nil {
+			return fmt.Errorf("conformance: nestest.log line %d: %w", n, err)
+		}
+		stepErr := c.Step()
+		var b strings.Builder
+		if err := p.Trace(&b, c.Trace()); err != nil {
+			return err
+		}
+		actual := strings.TrimSuffix(b.String(), "\n")
+		if actual != expected {
+			return &DivergenceError{Line: n, Expected: expected, Actual: actual, Context: context}
+		}
+		if stepErr != nil {
+			return fmt.Errorf("conformance: nestest.log line %d: %w", n, stepErr)
+		}
+		if len(context) == nestestContext {
+			context = context[1:]
+		}
+		context = append(context, actual)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("conformance: reading nestest.log: %w", err)
+	}
+	return nil
+}
+
+// parseNestestLine returns a line of nestest.log in the trace format of
+// nestestFormat.
+func parseNestestLine(line string) (string, error) {
+	regs := strings.Index(line, "A:")
+	_, cycles, ok := strings.Cut(line, "CYC:")
+	if len(line) < nestestBytesEnd || regs < 0 || regs+len(nestestRegs) > len(line) || !ok {
+		return "", fmt.Errorf("invalid line %q", line)
+	}
+	return line[:nestestBytesEnd] + "  " + line[regs:regs+len(nestestRegs)] + " CYC:" + strings.TrimSpace(cycles), nil
+}
@@ -0,0 +1,98 @@
+package conformance
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// nestestEnv names the environment variable pointing at a directory holding
+// nestest.nes and nestest.log.
+const nestestEnv = "MOS6502_NESTEST"
+
+// newNestestTest returns a CPU with
+//
+//	C000  LDA #$01
+//	C002  JSR $C100
+//	C100  LDA #$02
+func newNestestTest() *cpu.CPU {
+	mem := &memory.Memory{}
+	for addr, b := range map[uint16][]byte{
+		0xC000: {0xA9, 0x01, 0x20, 0x00, 0xC1},
+		0xC100: {0xA9, 0x02},
+	} {
+		for i, v := range b {
+			mem.Write(v, addr+uint16(i))
+		}
+	}
+	return cpu.New(mem)
+}
+
+const testNestestLog = "" +
+	"C000  A9 01     LDA #$01                        A:00 X:00 Y:00 P:24 SP:FD PPU:  0, 21 CYC:7\n" +
+	"C002  20 00 C1  JSR $C100                       A:01 X:00 Y:00 P:24 SP:FD PPU:  0, 27 CYC:9\n" +
+	"C100  A9 02     LDA #$02                        A:01 X:00 Y:00 P:24 SP:FB PPU:  0, 45 CYC:15\n"
+
+func TestNestest(t *testing.T) {
+	c := newNestestTest()
+
+	if err := Nestest(c, strings.NewReader(testNestestLog)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNestestDivergence(t *testing.T) {
+	c := newNestestTest()
+	log := strings.Replace(testNestestLog, "SP:FB", "SP:FA", 1)
+
+	err := Nestest(c, strings.NewReader(log))
+
+	var div *DivergenceError
+	if !errors.As(err, &div) {
+		t.Fatalf("expected a divergence, actual %v", err)
+	}
+	expected := DivergenceError{
+		Line:     3,
+		Expected: "C100  A9 02     A:01 X:00 Y:00 P:24 SP:FA CYC:15",
+		Actual:   "C100  A9 02     A:01 X:00 Y:00 P:24 SP:FB CYC:15",
+		Context: []string{
+			"C000  A9 01     A:00 X:00 Y:00 P:24 SP:FD CYC:7",
+			"C002  20 00 C1  A:01 X:00 Y:00 P:24 SP:FD CYC:9",
+		},
+	}
+	if div.Line != expected.Line || div.Expected != expected.Expected || div.Actual != expected.Actual ||
+		strings.Join(div.Context, "\n") != strings.Join(expected.Context, "\n") {
+		t.Errorf("expected %+v, actual %+v\n", expected, *div)
+	}
+}
+
+func TestNestestROM(t *testing.T) {
+	dir := os.Getenv(nestestEnv)
+	if dir == "" {
+		t.Skipf("%s is not set", nestestEnv)
+	}
+	rom, err := os.Open(filepath.Join(dir, "nestest.nes"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = rom.Close() }()
+	mem := &memory.Memory{}
+	if _, err := loader.LoadINES(mem, rom); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	log, err := os.Open(filepath.Join(dir, "nestest.log"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = log.Close() }()
+
+	if err := Nestest(cpu.New(mem), log); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+// Package coverage records which addresses a 6502 program executed as code,
+// and writes coverage maps of them as text or HTML.
+//
+//	cov := coverage.New()
+//	c.AddInstructionHook(cov.Record)
+//	c.Run()
+//	cov.WriteText(os.Stdout, 0xC000, 0xFFFF)
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// rowSize is the number of addresses in a row of the coverage maps.
+const rowSize = 64
+
+// Coverage is the set of addresses executed as code: the opcodes and the
+// operands of the instructions run.
+type Coverage struct {
+	executed [0x10000]bool
+}
+
+// New returns an empty Coverage.
+func New() *Coverage {
+	return &Coverage{}
+}
+
+// Record marks the bytes of an executed instruction. It is meant to be passed
+// to the AddInstructionHook method of a CPU.
+func (c *Coverage) Record(e cpu.InstructionEvent) {
+	n := uint16(1)
+	if info := cpu.Opcode(e.Opcode); info.Documented() {
+		n = info.Bytes
+	}
+	for i := range n {
+		c.executed[e.PC+i] = true
+	}
+}
+
+// Reset forgets everything recorded so far.
+func (c *Coverage) Reset() {
+	*c = Coverage{}
+}
+
+// Executed reports whether addr was executed as code.
+func (c *Coverage) Executed(addr uint16) bool {
+	return c.executed[addr]
+}
+
+// Count returns how many addresses from start to end, inclusive, were
+// executed.
+func (c *Coverage) Count(start, end uint16) int {
+	n := 0
+	for addr := int(start); addr <= int(end); addr++ {
+		if c.executed[addr] {
+			n++
+		}
+	}
+	return n
+}
+
+// Range is a run of consecutive executed addresses, from Start to End
+// inclusive.
+type Range struct {
+	Start uint16
+	End   uint16
+}
+
+// Ranges returns the runs of executed addresses, in increasing order.
+func (c *Coverage) Ranges() []Range {
+	var ranges []Range
+	for addr := 0; addr < len(c.executed); addr++ {
+		if !c.executed[addr] {
+			continue
+		}
+		start := addr
+		for addr+1 < len(c.executed) && c.executed[addr+1] {
+			addr++
+		}
+		ranges = append(ranges, Range{Start: uint16(start), End: uint16(addr)})
+	}
+	return ranges
+}
+
+// summary describes the coverage of start to end in a sentence.
+func (c *Coverage) summary(start, end uint16) string {
+	total := int(end) - int(start) + 1
+	n := c.Count(start, end)
+	return fmt.Sprintf("$%04X-$%04X: %d of %d bytes executed (%.2f%%)",
+		start, end, n, total, 100*float64(n)/float64(total))
+}
+
+// rows splits the addresses from start to end in the rows of the coverage
+// maps.
+func (c *Coverage) rows(start, end uint16) []row {
+	var rows []row
+	for addr := int(start); addr <= int(end); addr += rowSize {
+		r := row{Addr: uint16(addr)}
+		for a := addr; a < addr+rowSize && a <= int(end); a++ {
+			r.Cells = append(r.Cells, cell{Addr: uint16(a), Executed: c.executed[a]})
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+type row struct {
+	Addr  uint16
+	Cells []cell
+}
+
+type cell struct {
+	Addr     uint16
+	Executed bool
+}
+
+// WriteText writes a coverage map of the addresses from start to end to w, a
+// row of 64 addresses per line, with # for the executed ones:
+//
+//	$0200-$023F: 5 of 64 bytes executed (7.81%)
+//	0200 #####...........................................................
+func (c *Coverage) WriteText(w io.Writer, start, end uint16) error {
+	var b strings.Builder
+	b.WriteString(c.summary(start, end) + "\n")
+	for _, r := range c.rows(start, end) {
+		fmt.Fprintf(&b, "%04X ", r.Addr)
+		for _, cell := range r.Cells {
+			if cell.Executed {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("coverage: writing map: %w", err)
+	}
+	return nil
+}
+
+var htmlTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage {{.Summary}}</title>
+<style>
+body { font-family: monospace; }
+table { border-collapse: collapse; }
+td { width: 8px; height: 12px; padding: 0; background: #ddd; border: 1px solid #fff; }
+td.addr { width: auto; padding-right: 8px; background: none; }
+td.x { background: #2a2; }
+</style>
+</head>
+<body>
+<p>{{.Summary}}</p>
+<table>
+{{- range .Rows}}
+<tr><td class="addr">{{printf "%04X" .Addr}}</td>
+{{- range .Cells}}<td{{if .Executed}} class="x"{{end}} title="{{printf "%04X" .Addr}}"></td>{{end}}</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes a coverage map of the addresses from start to end to w as
+// an HTML page, with the executed addresses in green.
+func (c *Coverage) WriteHTML(w io.Writer, start, end uint16) error {
+	data := struct {
+		Summary string
+		Rows    []row
+	}{c.summary(start, end), c.rows(start, end)}
+	if err := htmlTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("coverage: writing map: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func newCoverageTest(t *testing.T) *Coverage {
+	t.Helper()
+	mem := &memory.Memory{}
+	// JSR $0210; invalid opcode. At $0210: LDA #$01; RTS.
+	for i, b := range []byte{0x20, 0x10, 0x02, 0x02} {
+		mem.Write(b, 0x0200+uint16(i))
+	}
+	for i, b := range []byte{0xA9, 0x01, 0x60} {
+		mem.Write(b, 0x0210+uint16(i))
+	}
+	c := cpu.New(mem)
+	c.Reset()
+	cov := New()
+	c.AddInstructionHook(cov.Record)
+	if _, err := c.Run(); err == nil {
+		t.Fatalf("expected invalid opcode error")
+	}
+	return cov
+}
+
+func TestRanges(t *testing.T) {
+	cov := newCoverageTest(t)
+
+	expected := []Range{{0x0200, 0x0202}, {0x0210, 0x0212}}
+	actual := cov.Ranges()
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %+v, actual %+v\n", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %+v, actual %+v\n", expected[i], actual[i])
+		}
+	}
+	if !cov.Executed(0x0211) || cov.Executed(0x0203) {
+		t.Errorf("expected $0211 executed and $0203 not\n")
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	cov := newCoverageTest(t)
+
+	var out strings.Builder
+	if err := cov.WriteText(&out, 0x0200, 0x0217); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "" +
+		"$0200-$0217: 6 of 24 bytes executed (25.00%)\n" +
+		"0200 ###.............###.....\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, out.String())
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	cov := newCoverageTest(t)
+
+	var out strings.Builder
+	if err := cov.WriteHTML(&out, 0x0200, 0x0203); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"<p>$0200-$0203: 3 of 4 bytes executed (75.00%)</p>",
+		`<td class="x" title="0202"></td><td title="0203"></td></tr>`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in\n%s\n", expected, out.String())
+		}
+	}
+}
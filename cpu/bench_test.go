@@ -0,0 +1,125 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// mixProgram is a loop body mixing the instructions implemented, with a call
+// to mixSubroutine. It is repeated from defaultPC up to mixEnd.
+var mixProgram = []byte{
+	0xA9, 0x01, // LDA #$01
+	0x78,             // SEI
+	0x58,             // CLI
+	0x20, 0x00, 0xF0, // JSR $F000
+	0xA9, 0x00, // LDA #$00
+}
+
+const (
+	mixSubroutine uint16 = 0xF000
+	mixEnd        uint16 = 0xE000
+)
+
+// newMixBenchmark returns a CPU running mixProgram, and the address where it
+// must be sent back to defaultPC.
+func newMixBenchmark() (*CPU, uint16) {
+	mem := &memory.Memory{}
+	addr := defaultPC
+	for addr+uint16(len(mixProgram)) <= mixEnd {
+		for _, b := range mixProgram {
+			mem.Write(b, addr)
+			addr++
+		}
+	}
+	// F000  LDA #$80
+	// F002  RTS
+	for i, b := range []byte{0xA9, 0x80, 0x60} {
+		mem.Write(b, mixSubroutine+uint16(i))
+	}
+	c := New(mem)
+	c.Reset()
+	return c, addr
+}
+
+// reportInstructionRate reports the number of instructions run per second,
+// n being the number of instructions run.
+func reportInstructionRate(b *testing.B, n int) {
+	b.ReportMetric(float64(n)/b.Elapsed().Seconds(), "instructions/s")
+}
+
+// BenchmarkStep steps through memory filled with LDA #$A9.
+func BenchmarkStep(b *testing.B) {
+	mem := &memory.Memory{}
+	for addr := range 0x10000 {
+		mem.Write(byte(ldaImmediateOpcode), uint16(addr))
+	}
+	c := New(mem)
+	c.Reset()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if err := c.Step(); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+	reportInstructionRate(b, b.N)
+}
+
+func BenchmarkStepMix(b *testing.B) {
+	c, end := newMixBenchmark()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if err := c.Step(); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		if c.pc == end {
+			c.pc = defaultPC
+		}
+	}
+	reportInstructionRate(b, b.N)
+}
+
+// BenchmarkStepMixWatched runs the mix with a watchpoint on the stack, to
+// measure the cost of watching the bus.
+func BenchmarkStepMixWatched(b *testing.B) {
+	c, end := newMixBenchmark()
+	c.AddWatchpoint(stackPage, stackPage|0xFF, AccessAny, func(WatchEvent) {})
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if err := c.Step(); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		if c.pc == end {
+			c.pc = defaultPC
+		}
+	}
+	reportInstructionRate(b, b.N)
+}
+
+// BenchmarkRunFor runs the mix through RunFor, a cycle per iteration, with a
+// breakpoint that is never reached, to measure the cost of the run loop.
+func BenchmarkRunFor(b *testing.B) {
+	c, end := newMixBenchmark()
+	c.AddBreakpoint(0xFFFF)
+	instructions := 0
+	c.AddInstructionHook(func(InstructionEvent) {
+		instructions++
+		if c.pc == end {
+			c.pc = defaultPC
+		}
+	})
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	if _, err := c.RunFor(uint(b.N)); err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "cycles/s")
+	reportInstructionRate(b, instructions)
+}
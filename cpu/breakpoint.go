@@ -0,0 +1,147 @@
+package cpu
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leakedmemory/mos6502/expr"
+)
+
+// StopReason tells why Run or RunFor returned.
+type StopReason byte
+
+const (
+	// StopRequested means Stop was called.
+	StopRequested StopReason = iota
+	// StopBreakpoint means the CPU reached a breakpoint. PC holds its
+	// address, and the instruction there is not executed yet.
+	StopBreakpoint
+	// StopCycles means RunFor ran for the cycles it was given.
+	StopCycles
+	// StopFault means an instruction failed, with the returned error.
+	StopFault
+	// StopStepped means StepOver or StepOut completed.
+	StopStepped
+	// StopStackLimit means an instruction moved SP out of the limits of a
+	// stack breakpoint.
+	StopStackLimit
+	// StopInterrupt means the CPU took an interrupt matching an interrupt
+	// breakpoint, or is about to execute a BRK matching one.
+	StopInterrupt
+)
+
+var stopReasonNames = [...]string{
+	StopRequested:  "stop requested",
+	StopBreakpoint: "breakpoint",
+	StopCycles:     "cycles elapsed",
+	StopFault:      "fault",
+	StopStepped:    "stepped",
+	StopStackLimit: "stack limit",
+	StopInterrupt:  "interrupt",
+}
+
+func (r StopReason) String() string {
+	if int(r) < len(stopReasonNames) {
+		return stopReasonNames[r]
+	}
+	return "unknown"
+}
+
+type breakpoint struct {
+	id   int
+	addr uint16
+	// cond is nil for breakpoints without a condition.
+	cond *expr.Expr
+}
+
+// AddBreakpoint makes Run and RunFor stop when the CPU is about to execute
+// the instruction at addr. It returns an id that can be passed to
+// RemoveBreakpoint.
+func (c *CPU) AddBreakpoint(addr uint16) int {
+	return c.addBreakpoint(addr, nil)
+}
+
+// AddConditionalBreakpoint is like AddBreakpoint, but the CPU only stops if
+// cond is true, that is not zero, when the instruction at addr is reached.
+// cond is an expression of package expr over the registers, the flags and
+// the memory, as described by Env:
+//
+//	A == $3F && mem[$10] != 0
+//
+// A condition that fails to evaluate, such as by dividing by zero, stops the
+// CPU.
+func (c *CPU) AddConditionalBreakpoint(addr uint16, cond string) (int, error) {
+	e, err := expr.Parse(cond)
+	if err != nil {
+		return 0, fmt.Errorf("cpu: breakpoint condition: %w", err)
+	}
+	return c.AddExprBreakpoint(addr, e)
+}
+
+// AddExprBreakpoint is like AddConditionalBreakpoint with a parsed condition,
+// such as one bound to the symbols of the program.
+func (c *CPU) AddExprBreakpoint(addr uint16, cond *expr.Expr) (int, error) {
+	// Catch names that are not registers now rather than at the breakpoint.
+	if _, err := cond.Eval(c.Env()); err != nil && !errors.Is(err, expr.ErrDivisionByZero) {
+		return 0, fmt.Errorf("cpu: breakpoint condition: %w", err)
+	}
+	return c.addBreakpoint(addr, cond), nil
+}
+
+func (c *CPU) addBreakpoint(addr uint16, cond *expr.Expr) int {
+	id := c.nextBreakpointID()
+	c.breakpoints = append(c.breakpoints, breakpoint{id: id, addr: addr, cond: cond})
+	return id
+}
+
+// nextBreakpointID returns an id not used by breakpoints of any kind.
+func (c *CPU) nextBreakpointID() int {
+	id := 0
+	for _, b := range c.breakpoints {
+		id = max(id, b.id+1)
+	}
+	for _, b := range c.stackBreakpoints {
+		id = max(id, b.id+1)
+	}
+	for _, b := range c.interruptBreakpoints {
+		id = max(id, b.id+1)
+	}
+	return id
+}
+
+// RemoveBreakpoint removes the breakpoint, of any kind, with the given id.
+func (c *CPU) RemoveBreakpoint(id int) {
+	for i, b := range c.breakpoints {
+		if b.id == id {
+			c.breakpoints = append(c.breakpoints[:i], c.breakpoints[i+1:]...)
+			return
+		}
+	}
+	for i, b := range c.stackBreakpoints {
+		if b.id == id {
+			c.stackBreakpoints = append(c.stackBreakpoints[:i], c.stackBreakpoints[i+1:]...)
+			return
+		}
+	}
+	for i, b := range c.interruptBreakpoints {
+		if b.id == id {
+			c.interruptBreakpoints = append(c.interruptBreakpoints[:i], c.interruptBreakpoints[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *CPU) atBreakpoint() bool {
+	for _, b := range c.breakpoints {
+		if b.addr != c.pc {
+			continue
+		}
+		if b.cond == nil {
+			return true
+		}
+		if v, err := b.cond.Eval(c.Env()); err != nil || v != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newBreakpointTestCPU returns a CPU about to run count LDA #imm
+// instructions.
+func newBreakpointTestCPU(count int) *CPU {
+	mem := memory.Memory{}
+	for i := range count {
+		addr := defaultPC + uint16(i)*ldaImmediateBytes
+		mem.Write(byte(ldaImmediateOpcode), addr)
+		mem.Write(byte(i), addr+1)
+	}
+	c := New(&mem)
+	c.Reset()
+	return c
+}
+
+func TestRunStopsAtBreakpoint(t *testing.T) {
+	c := newBreakpointTestCPU(8)
+	c.AddBreakpoint(0x0206)
+
+	reason, err := c.Run()
+
+	if reason != StopBreakpoint || err != nil {
+		t.Fatalf("expected %v, actual %v (%v)", StopBreakpoint, reason, err)
+	}
+	if c.pc != 0x0206 || c.acc != 2 {
+		t.Errorf("expected to stop before $0206 with A=2, actual pc %04X A=%d\n", c.pc, c.acc)
+	}
+}
+
+func TestRunResumesFromBreakpoint(t *testing.T) {
+	c := newBreakpointTestCPU(8)
+	c.AddBreakpoint(0x0202)
+	c.AddBreakpoint(0x0206)
+
+	_, _ = c.Run()
+	reason, _ := c.Run()
+
+	if reason != StopBreakpoint || c.pc != 0x0206 {
+		t.Errorf("expected to stop at the next breakpoint, actual %v at %04X\n", reason, c.pc)
+	}
+}
+
+func TestRemoveBreakpoint(t *testing.T) {
+	c := newBreakpointTestCPU(8)
+	id := c.AddBreakpoint(0x0202)
+	c.RemoveBreakpoint(id)
+
+	reason, _ := c.Run()
+
+	if reason != StopFault {
+		t.Errorf("expected to run into the end of the program, actual %v\n", reason)
+	}
+}
+
+func TestRunFor(t *testing.T) {
+	c := newBreakpointTestCPU(8)
+	start := c.cycles
+
+	reason, err := c.RunFor(5)
+
+	if reason != StopCycles || err != nil {
+		t.Fatalf("expected %v, actual %v (%v)", StopCycles, reason, err)
+	}
+	// Instructions are not interrupted: the third one ends past the budget.
+	if elapsed := c.cycles - start; elapsed != 3*ldaImmediateCycles {
+		t.Errorf("expected %d cycles, actual %d\n", 3*ldaImmediateCycles, elapsed)
+	}
+}
+
+func TestRunForStopsAtBreakpointAfterBudget(t *testing.T) {
+	c := newBreakpointTestCPU(8)
+	c.AddBreakpoint(0x0204)
+
+	_, _ = c.RunFor(2 * ldaImmediateCycles)
+	reason, _ := c.RunFor(100)
+
+	if reason != StopBreakpoint || c.pc != 0x0204 {
+		t.Errorf("expected to stop at the breakpoint, actual %v at %04X\n", reason, c.pc)
+	}
+}
+
+func TestConditionalBreakpoint(t *testing.T) {
+	c := newBreakpointTestCPU(8)
+	if _, err := c.AddConditionalBreakpoint(0x0204, "A == 5"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.AddConditionalBreakpoint(0x0208, "A == 3 && mem[$0209] != 0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reason, _ := c.Run()
+
+	if reason != StopBreakpoint || c.pc != 0x0208 {
+		t.Errorf("expected to stop at $0208, actual %v at %04X\n", reason, c.pc)
+	}
+}
+
+func TestConditionalBreakpointErrors(t *testing.T) {
+	c := newBreakpointTestCPU(1)
+	for _, cond := range []string{"A ==", "Q == 1"} {
+		if _, err := c.AddConditionalBreakpoint(0x0200, cond); err == nil {
+			t.Errorf("%q: expected an error\n", cond)
+		}
+	}
+	if len(c.breakpoints) != 0 {
+		t.Errorf("expected no breakpoint, actual %d\n", len(c.breakpoints))
+	}
+}
@@ -0,0 +1,151 @@
+package cpu
+
+import "fmt"
+
+// FrameKind tells how a Frame of the call stack was entered.
+type FrameKind byte
+
+const (
+	// FrameCall is a subroutine called by JSR.
+	FrameCall FrameKind = iota
+	// FrameIRQ is the handler of an IRQ.
+	FrameIRQ
+	// FrameNMI is the handler of an NMI.
+	FrameNMI
+)
+
+func (k FrameKind) String() string {
+	switch k {
+	case FrameIRQ:
+		return "IRQ"
+	case FrameNMI:
+		return "NMI"
+	default:
+		return "JSR"
+	}
+}
+
+// Frame is an entry of the call stack.
+type Frame struct {
+	Kind FrameKind
+	// Site is the address of the JSR for calls, and of the instruction
+	// interrupted for interrupts, where execution resumes.
+	Site   uint16
+	Target uint16
+	// SP is the stack pointer before the return address was pushed, which it
+	// is back to once the frame returns.
+	SP byte
+}
+
+// CallAnomalyKind tells what is wrong with a return.
+type CallAnomalyKind byte
+
+const (
+	// UnmatchedReturn is an RTS or RTI with no frame on the call stack, such
+	// as an RTS used as an indirect jump.
+	UnmatchedReturn CallAnomalyKind = iota
+	// MismatchedReturn is an RTS returning from an interrupt, or an RTI
+	// returning from a subroutine.
+	MismatchedReturn
+	// StackImbalance is a return leaving SP elsewhere than before the call,
+	// because the frame pushed more than it pulled, or the reverse.
+	StackImbalance
+)
+
+func (k CallAnomalyKind) String() string {
+	switch k {
+	case MismatchedReturn:
+		return "mismatched return"
+	case StackImbalance:
+		return "stack imbalance"
+	default:
+		return "return without call"
+	}
+}
+
+// CallAnomaly describes a return that does not match the call stack.
+type CallAnomaly struct {
+	Kind CallAnomalyKind
+	// PC is the address of the RTS or RTI.
+	PC uint16
+	// Frame is the frame returned from, the zero Frame for an
+	// UnmatchedReturn.
+	Frame Frame
+	// SP is the stack pointer after the return.
+	SP byte
+}
+
+func (a CallAnomaly) String() string {
+	if a.Kind == UnmatchedReturn {
+		return fmt.Sprintf("%v at $%04X", a.Kind, a.PC)
+	}
+	return fmt.Sprintf("%v at $%04X, returning from %v $%04X to $%04X with SP $%02X instead of $%02X",
+		a.Kind, a.PC, a.Frame.Kind, a.Frame.Target, a.Frame.Site, a.SP, a.Frame.SP)
+}
+
+// CallAnomalyFunc is called when a return does not match the call stack. It
+// may call Stop to end the current Run.
+type CallAnomalyFunc func(CallAnomaly)
+
+// TrackCalls makes the CPU keep a shadow call stack of the subroutines and
+// interrupt handlers entered, for CallStack, starting empty. Returns that do
+// not match it are reported to fn, which may be nil. It is a tool for
+// debuggers: unlike the stack in memory, the call stack knows what pushed its
+// frames.
+func (c *CPU) TrackCalls(fn CallAnomalyFunc) {
+	c.tracking = true
+	c.onAnomaly = fn
+	c.calls = c.calls[:0]
+}
+
+// StopTrackingCalls turns call tracking off and empties the call stack.
+func (c *CPU) StopTrackingCalls() {
+	c.tracking = false
+	c.onAnomaly = nil
+	c.calls = nil
+}
+
+// CallStack returns the frames of the call stack, the outermost first. It is
+// empty unless calls are tracked.
+func (c *CPU) CallStack() []Frame {
+	return append([]Frame(nil), c.calls...)
+}
+
+// trackCall updates the call stack after the instruction op ran, with SP at
+// sp before it.
+func (c *CPU) trackCall(op opcode, sp byte) {
+	switch op {
+	case jsrAbsoluteOpcode:
+		c.calls = append(c.calls, Frame{Kind: FrameCall, Site: c.instPC, Target: c.pc, SP: sp})
+	case rtsImpliedOpcode:
+		c.trackReturn(false)
+	case rtiImpliedOpcode:
+		c.trackReturn(true)
+	}
+}
+
+func (c *CPU) trackReturn(interrupt bool) {
+	a := CallAnomaly{PC: c.instPC, SP: c.sp}
+	if len(c.calls) == 0 {
+		a.Kind = UnmatchedReturn
+		c.anomaly(a)
+		return
+	}
+	a.Frame = c.calls[len(c.calls)-1]
+	c.calls = c.calls[:len(c.calls)-1]
+	switch {
+	case (a.Frame.Kind != FrameCall) != interrupt:
+		a.Kind = MismatchedReturn
+	case a.Frame.SP != c.sp:
+		a.Kind = StackImbalance
+	default:
+		return
+	}
+	c.anomaly(a)
+}
+
+func (c *CPU) anomaly(a CallAnomaly) {
+	if c.onAnomaly != nil {
+		c.onAnomaly(a)
+	}
+}
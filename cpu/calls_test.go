@@ -0,0 +1,148 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newCallsTestCPU returns a CPU running JSR $0300 at $0200, with the
+// subroutine at $0300 calling $0400, which returns with RTS.
+func newCallsTestCPU(mem *memory.Memory) *CPU {
+	mem.Write(byte(jsrAbsoluteOpcode), defaultPC)
+	memory.WriteWord(mem, 0x0300, defaultPC+1)
+	mem.Write(byte(jsrAbsoluteOpcode), 0x0300)
+	memory.WriteWord(mem, 0x0400, 0x0301)
+	mem.Write(byte(rtsImpliedOpcode), 0x0303)
+	mem.Write(byte(rtsImpliedOpcode), 0x0400)
+	c := New(mem)
+	c.Reset()
+	return c
+}
+
+func stepTestHelper(t *testing.T, c *CPU, n int) {
+	t.Helper()
+	for range n {
+		if err := c.Step(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestCallStack(t *testing.T) {
+	mem := memory.Memory{}
+	c := newCallsTestCPU(&mem)
+	var anomalies []CallAnomaly
+	c.TrackCalls(func(a CallAnomaly) { anomalies = append(anomalies, a) })
+
+	stepTestHelper(t, c, 2)
+	expected := []Frame{
+		{Kind: FrameCall, Site: defaultPC, Target: 0x0300, SP: defaultSP},
+		{Kind: FrameCall, Site: 0x0300, Target: 0x0400, SP: defaultSP - 2},
+	}
+	frames := c.CallStack()
+	if len(frames) != len(expected) {
+		t.Fatalf("expected %+v, actual %+v\n", expected, frames)
+	}
+	for i := range expected {
+		if frames[i] != expected[i] {
+			t.Errorf("expected %+v, actual %+v\n", expected[i], frames[i])
+		}
+	}
+
+	stepTestHelper(t, c, 2)
+	if frames := c.CallStack(); len(frames) != 0 {
+		t.Errorf("expected empty call stack, actual %+v\n", frames)
+	}
+	if len(anomalies) != 0 {
+		t.Errorf("expected no anomaly, actual %+v\n", anomalies)
+	}
+}
+
+func TestCallStackInterrupt(t *testing.T) {
+	mem := memory.Memory{}
+	c := newCallsTestCPU(&mem)
+	memory.WriteWord(&mem, 0x0500, nmiVector)
+	mem.Write(byte(rtiImpliedOpcode), 0x0500)
+	c.TrackCalls(nil)
+
+	stepTestHelper(t, c, 1)
+	c.NMI()
+	stepTestHelper(t, c, 1)
+	expected := Frame{Kind: FrameNMI, Site: 0x0300, Target: 0x0500, SP: defaultSP - 2}
+	frames := c.CallStack()
+	if len(frames) != 2 || frames[1] != expected {
+		t.Fatalf("expected %+v on top, actual %+v\n", expected, frames)
+	}
+
+	stepTestHelper(t, c, 1)
+	if frames := c.CallStack(); len(frames) != 1 {
+		t.Errorf("expected a single frame, actual %+v\n", frames)
+	}
+}
+
+func TestCallAnomalies(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(mem *memory.Memory, c *CPU)
+		kind  CallAnomalyKind
+	}{
+		{
+			name: "return without call",
+			setup: func(mem *memory.Memory, c *CPU) {
+				c.pc = 0x0400
+			},
+			kind: UnmatchedReturn,
+		},
+		{
+			name: "rti from subroutine",
+			setup: func(mem *memory.Memory, c *CPU) {
+				mem.Write(byte(rtiImpliedOpcode), 0x0300)
+			},
+			kind: MismatchedReturn,
+		},
+		{
+			name: "imbalance",
+			setup: func(mem *memory.Memory, c *CPU) {
+				// The subroutine, called with SP at $FD, pulled its return
+				// address and returns to the caller of its caller.
+				c.sp = defaultSP - 2
+				memory.WriteWord(mem, 0x0230, stackPage|uint16(c.sp+1))
+				c.calls = append(c.calls, Frame{Kind: FrameCall, Site: 0x0220, Target: 0x0300, SP: defaultSP - 2})
+				c.pc = 0x0303
+			},
+			kind: StackImbalance,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := memory.Memory{}
+			c := newCallsTestCPU(&mem)
+			var anomalies []CallAnomaly
+			c.TrackCalls(func(a CallAnomaly) { anomalies = append(anomalies, a) })
+			tt.setup(&mem, c)
+			if tt.kind == MismatchedReturn {
+				stepTestHelper(t, c, 1)
+			}
+			stepTestHelper(t, c, 1)
+
+			if len(anomalies) != 1 || anomalies[0].Kind != tt.kind {
+				t.Errorf("expected a %v, actual %+v\n", tt.kind, anomalies)
+			}
+		})
+	}
+}
+
+func TestStopTrackingCalls(t *testing.T) {
+	mem := memory.Memory{}
+	c := newCallsTestCPU(&mem)
+	c.TrackCalls(nil)
+	stepTestHelper(t, c, 1)
+	c.StopTrackingCalls()
+	stepTestHelper(t, c, 1)
+
+	if frames := c.CallStack(); len(frames) != 0 {
+		t.Errorf("expected empty call stack, actual %+v\n", frames)
+	}
+}
@@ -0,0 +1,19 @@
+package cpu
+
+const (
+	cliImpliedBytes  uint16 = 1
+	cliImpliedCycles uint   = 2
+)
+
+// cliImplied clears the interrupt disable flag, allowing IRQs.
+//
+// Attributes:
+//
+//	Bytes: 1
+//	Cycles: 2
+//	Flags affected: I
+func cliImplied(cpu *CPU) {
+	// Dummy read of the next byte.
+	cpu.cycles++
+	cpu.sr &^= interruptSF
+}
@@ -0,0 +1,10 @@
+package cpu
+
+import "testing"
+
+func TestCLIImplied(t *testing.T) {
+	runInstructionTests(t, opcodeSpec{op: cliImpliedOpcode, flags: interruptSF}, []instructionCase{
+		{name: "set", setup: func(c *CPU) { c.sr |= interruptSF }},
+		{name: "clear"},
+	})
+}
@@ -1,6 +1,8 @@
 package cpu
 
 import (
+	"fmt"
+
 	"github.com/leakedmemory/mos6502/memory"
 )
 
@@ -13,8 +15,14 @@ const (
 )
 
 const (
-	zeroSF     byte = 0x02
-	negativeSF byte = 0x80
+	carrySF     byte = 0x01
+	zeroSF      byte = 0x02
+	interruptSF byte = 0x04
+	decimalSF   byte = 0x08
+	breakSF     byte = 0x10
+	unusedSF    byte = 0x20
+	overflowSF  byte = 0x40
+	negativeSF  byte = 0x80
 )
 
 type (
@@ -31,7 +39,54 @@ type CPU struct {
 	// N, V, 1, B, D, I, Z, C
 	sr     byte
 	cycles uint
-	mem    *memory.Memory
+	stall  uint
+	mem    memory.ReadWriter
+
+	// instPC is the address of the instruction being executed.
+	instPC      uint16
+	stopped     bool
+	fault       error
+	watchpoints []watchpoint
+	protections []protection
+	breakpoints []breakpoint
+	// stackBreakpoints and interruptBreakpoints share the ids of
+	// breakpoints.
+	stackBreakpoints     []stackBreakpoint
+	interruptBreakpoints []interruptBreakpoint
+	// atBreak is set when Run stopped at a breakpoint, so that running again
+	// executes the instruction there instead of stopping at once.
+	atBreak bool
+	// trace is a ring of the last instructions executed, the oldest at
+	// traceNext once it is full.
+	trace     []TraceEntry
+	traceNext int
+	hooks     []instructionHook
+	// interruptHooks are called after the CPU enters an interrupt.
+	interruptHooks []interruptHook
+	// irq is the level of the IRQ line, and nmi is set when an NMI is
+	// pending.
+	irq bool
+	nmi bool
+	// taken is the interrupt entered by the last step, if any.
+	taken InterruptKind
+	// calls is the shadow call stack kept while tracking is set.
+	calls     []Frame
+	tracking  bool
+	onAnomaly CallAnomalyFunc
+	// pushed marks the stack slots holding pushed bytes while the stack is
+	// checked, and op is the opcode of the instruction being executed.
+	// misused is set once a misuse of the current step is reported.
+	pushed        [256]bool
+	op            opcode
+	misused       bool
+	onStackMisuse StackMisuseFunc
+
+	unstable Unstable
+}
+
+// New returns a CPU connected to mem. The CPU must be Reset before use.
+func New(mem memory.ReadWriter) *CPU {
+	return &CPU{mem: mem}
 }
 
 // Resets the CPU.
@@ -43,33 +98,170 @@ func (c *CPU) Reset() {
 	c.pc = defaultPC
 	c.sr = defaultSR
 	c.cycles = 7
+	c.stall = 0
+	c.nmi = false
+	c.calls = c.calls[:0]
+	c.resetPushed()
 }
 
-// Runs the CPU.
-func (c *CPU) Run() {
+// Runs the CPU until Stop is called, a breakpoint is reached, SP leaves the
+// limits of a stack breakpoint, an interrupt breakpoint is hit or an
+// instruction fails, in which case the error is returned.
+func (c *CPU) Run() (StopReason, error) {
+	return c.run(nil, StopRequested)
+}
+
+// RunFor runs the CPU like Run, stopping as well once at least cycles cycles
+// have elapsed.
+func (c *CPU) RunFor(cycles uint) (StopReason, error) {
+	until := c.cycles + cycles
+	return c.run(func() bool { return c.cycles >= until }, StopCycles)
+}
+
+// run runs the CPU like Run, stopping as well with reason when done, if not
+// nil, returns true before an instruction.
+func (c *CPU) run(done func() bool, reason StopReason) (StopReason, error) {
+	c.stopped = false
 	for {
-		c.step()
+		switch {
+		case c.stopped:
+			return StopRequested, nil
+		case done != nil && done():
+			return reason, nil
+		case len(c.breakpoints) != 0 && !c.atBreak && c.atBreakpoint():
+			c.atBreak = true
+			return StopBreakpoint, nil
+		case len(c.interruptBreakpoints) != 0 && !c.atBreak && c.atBRK():
+			c.atBreak = true
+			return StopInterrupt, nil
+		}
+		sp := c.sp
+		if err := c.Step(); err != nil {
+			return StopFault, err
+		}
+		if len(c.stackBreakpoints) != 0 && c.leftStackLimits(sp) {
+			return StopStackLimit, nil
+		}
+		if len(c.interruptBreakpoints) != 0 && c.tookInterrupt() {
+			return StopInterrupt, nil
+		}
 	}
 }
 
+// Step executes a single instruction, or enters the handler of a pending
+// interrupt instead. If the instruction made an access denied by Protect, the
+// *BusFault is returned, and if its opcode can not be executed, an
+// *InvalidOpcodeError.
+func (c *CPU) Step() error {
+	c.atBreak = false
+	c.step()
+	err := c.fault
+	c.fault = nil
+	return err
+}
+
+// Stop makes Run return once the current instruction completes.
+func (c *CPU) Stop() {
+	c.stopped = true
+}
+
+// InstructionPC returns the address of the instruction being executed, or of
+// the last one executed between steps.
+func (c *CPU) InstructionPC() uint16 {
+	return c.instPC
+}
+
 func (c *CPU) step() {
-	op := opcode(c.fetchByte())
+	if c.stall != 0 {
+		c.applyStall()
+	}
+	c.misused = false
+	c.taken = 0
+	if c.interruptPending() {
+		c.interrupt()
+		return
+	}
+	if cap(c.trace) != 0 {
+		c.record()
+	}
+	c.instPC = c.pc
+	start, sp := c.cycles, c.sp
+	op := opcode(c.fetch(AccessExecute))
+	if c.fault != nil {
+		// The opcode could not be fetched: leave PC at the instruction.
+		c.pc = c.instPC
+		return
+	}
+	c.op = op
 	inst := c.decodeInstruction(op)
+	if inst == nil {
+		c.pc = c.instPC
+		c.fault = &InvalidOpcodeError{Opcode: byte(op), PC: c.instPC}
+		return
+	}
 	inst(c)
+	if c.tracking && c.fault == nil {
+		c.trackCall(op, sp)
+	}
+	if len(c.hooks) != 0 && c.fault == nil {
+		c.runHooks(op, start)
+	}
+}
+
+// InvalidOpcodeError is the error returned by Step and Run when the CPU
+// fetches an opcode it can not execute. PC is left at the opcode.
+type InvalidOpcodeError struct {
+	Opcode byte
+	PC     uint16
+}
+
+func (e *InvalidOpcodeError) Error() string {
+	return fmt.Sprintf("invalid opcode $%02X at $%04X", e.Opcode, e.PC)
 }
 
 func (c *CPU) fetchByte() byte {
-	b := c.mem.Read(c.pc)
+	return c.fetch(AccessRead)
+}
+
+func (c *CPU) fetch(access Access) byte {
+	b := c.access(access, c.pc)
 	c.cycles++
 	c.pc++
 	return b
 }
 
-func (c *CPU) decodeInstruction(op opcode) instruction {
-	switch op {
-	case ldaImmediateOpcode:
-		return ldaImmediate
-	default:
-		panic("invalid opcode")
+func (c *CPU) access(access Access, addr uint16) byte {
+	if len(c.protections) != 0 && !c.allowed(access, addr) {
+		return 0
+	}
+	val := c.mem.Read(addr)
+	if len(c.watchpoints) != 0 {
+		c.checkWatchpoints(access, addr, val, val)
 	}
+	return val
+}
+
+func (c *CPU) write(val byte, addr uint16) {
+	if len(c.protections) != 0 && !c.allowed(AccessWrite, addr) {
+		return
+	}
+	if len(c.watchpoints) == 0 {
+		c.mem.Write(val, addr)
+		return
+	}
+	old := c.peek(addr)
+	c.mem.Write(val, addr)
+	c.checkWatchpoints(AccessWrite, addr, old, val)
+}
+
+// peek reads addr without side effects when the memory supports it.
+func (c *CPU) peek(addr uint16) byte {
+	if p, ok := c.mem.(interface{ Peek(uint16) byte }); ok {
+		return p.Peek(addr)
+	}
+	return c.mem.Read(addr)
+}
+
+func (c *CPU) decodeInstruction(op opcode) instruction {
+	return opcodeTable[op].exec
 }
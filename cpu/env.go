@@ -0,0 +1,62 @@
+package cpu
+
+import (
+	"strings"
+
+	"github.com/leakedmemory/mos6502/expr"
+)
+
+// flagBits maps the names of the flags to their bit in the status register.
+var flagBits = map[string]byte{
+	"N": negativeSF,
+	"V": overflowSF,
+	"B": breakSF,
+	"D": decimalSF,
+	"I": interruptSF,
+	"Z": zeroSF,
+	"C": carrySF,
+}
+
+// Env returns the environment expressions of package expr are evaluated in
+// for debuggers. It names the registers A, X, Y, SP, PC and SR, the flags N,
+// V, B, D, I, Z and C, which are 0 or 1, and CYCLES, in any case. Memory is
+// read without side effects where the memory allows it.
+func (c *CPU) Env() expr.Env {
+	return cpuEnv{c}
+}
+
+// cpuEnv gives expressions access to the registers and memory of a CPU.
+type cpuEnv struct {
+	c *CPU
+}
+
+func (e cpuEnv) Value(name string) (int, bool) {
+	name = strings.ToUpper(name)
+	switch name {
+	case "A":
+		return int(e.c.acc), true
+	case "X":
+		return int(e.c.x), true
+	case "Y":
+		return int(e.c.y), true
+	case "SP":
+		return int(e.c.sp), true
+	case "PC":
+		return int(e.c.pc), true
+	case "SR":
+		return int(e.c.sr), true
+	case "CYCLES":
+		return int(e.c.cycles), true
+	}
+	if bit, ok := flagBits[name]; ok {
+		if e.c.sr&bit != 0 {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func (e cpuEnv) Read(addr uint16) byte {
+	return e.c.peek(addr)
+}
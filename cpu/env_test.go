@@ -0,0 +1,37 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/expr"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestEnv(t *testing.T) {
+	mem := memory.Memory{}
+	mem.Write(0x80, 0x12)
+	c := New(&mem)
+	c.Reset()
+	c.SetRegisters(Registers{A: 0x3F, X: 2, SP: 0xFD, PC: 0x0300, SR: negativeSF | carrySF})
+
+	for src, expected := range map[string]int{
+		"mem[$10 + x] & $80": 0x80,
+		"a + X + y":          0x41,
+		"N && C && !Z":       1,
+		"V | D | I | B":      0,
+		"PC - SP + SR":       0x0300 - 0xFD + 0xA1,
+		"cycles":             7,
+	} {
+		e, err := expr.Parse(src)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", src, err)
+		}
+		actual, err := e.Eval(c.Env())
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", src, err)
+		}
+		if actual != expected {
+			t.Errorf("%q: expected %d, actual %d\n", src, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package cpu
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// propertySteps is the number of instructions run from each random state.
+const propertySteps = 32
+
+// TestLDAFlagsProperty checks that LDA sets Z when it loads zero and N when it
+// loads a byte with bit 7 set, and leaves the other flags alone.
+func TestLDAFlagsProperty(t *testing.T) {
+	property := func(value, sr byte) bool {
+		mem := memory.Memory{}
+		mem.Write(byte(ldaImmediateOpcode), defaultPC)
+		mem.Write(value, defaultPC+1)
+		c := New(&mem)
+		c.Reset()
+		c.sr = sr | unusedSF
+
+		if err := c.Step(); err != nil {
+			return false
+		}
+		return c.acc == value &&
+			(c.sr&zeroSF != 0) == (value == 0) &&
+			(c.sr&negativeSF != 0) == (value&0x80 != 0) &&
+			c.sr&^(zeroSF|negativeSF) == (sr|unusedSF)&^(zeroSF|negativeSF)
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestUnusedFlagProperty checks that bit 5 of the status register reads 1
+// whatever the CPU runs, including RTI pulling a status with it clear, and
+// whatever it is set to.
+func TestUnusedFlagProperty(t *testing.T) {
+	property := func(seed int64, r Registers) bool {
+		c := newRandomProgram(rand.New(rand.NewSource(seed)))
+		c.SetRegisters(r)
+		if c.Registers().SR&unusedSF == 0 {
+			return false
+		}
+		r.PC = defaultPC
+		c.SetRegisters(r)
+		for range propertySteps {
+			if c.Step() != nil {
+				break
+			}
+			if c.Registers().SR&unusedSF == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// newRandomProgram returns a CPU whose memory is random, but for a sequence
+// of implemented instructions with random operands at defaultPC. The CPU runs
+// it until an instruction jumps or returns elsewhere, where it most likely
+// meets an opcode that is not implemented.
+func newRandomProgram(rng *rand.Rand) *CPU {
+	var ops []opcode
+	for op := range 256 {
+		if Opcode(byte(op)).Implemented() {
+			ops = append(ops, opcode(op))
+		}
+	}
+
+	mem := &memory.Memory{}
+	for addr := range 0x10000 {
+		mem.Write(byte(rng.Intn(256)), uint16(addr))
+	}
+	addr := defaultPC
+	for range propertySteps {
+		op := ops[rng.Intn(len(ops))]
+		mem.Write(byte(op), addr)
+		addr += Opcode(byte(op)).Bytes
+	}
+	c := New(mem)
+	c.Reset()
+	return c
+}
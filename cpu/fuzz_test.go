@@ -0,0 +1,101 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// fuzzSteps is the number of instructions a fuzzed program runs for.
+const fuzzSteps = 64
+
+// recordingMemory counts the accesses that reach memory. Peeks, which
+// watchpoints use for the values that writes replace, are not counted.
+type recordingMemory struct {
+	memory.Memory
+	reads, writes int
+}
+
+func (m *recordingMemory) Peek(addr uint16) byte {
+	return m.Memory.Read(addr)
+}
+
+func (m *recordingMemory) Read(addr uint16) byte {
+	m.reads++
+	return m.Memory.Read(addr)
+}
+
+func (m *recordingMemory) Write(val byte, addr uint16) {
+	m.writes++
+	m.Memory.Write(val, addr)
+}
+
+// FuzzStep runs random bytes as code, from random registers, with the rest
+// of memory filled with the bytes repeated. It checks that every access
+// reaches memory through the watchpoints, and that each step advances PC and
+// the cycles as the opcode table says.
+func FuzzStep(f *testing.F) {
+	f.Add([]byte{0xA9, 0x01, 0x20, 0x00, 0x02}, byte(0), byte(0), byte(0), byte(0xFF), byte(0x24), false)
+	f.Add([]byte{0x60, 0x40, 0x58, 0x78}, byte(1), byte(2), byte(3), byte(0x00), byte(0x00), true)
+	f.Fuzz(func(t *testing.T, code []byte, a, x, y, sp, sr byte, irq bool) {
+		if len(code) == 0 {
+			return
+		}
+		mem := &recordingMemory{}
+		for addr := range 0x10000 {
+			mem.Memory.Write(code[addr%len(code)], uint16(addr))
+		}
+		c := New(mem)
+		c.Reset()
+		c.SetRegisters(Registers{A: a, X: x, Y: y, SP: sp, PC: defaultPC, SR: sr})
+		c.SetIRQ(irq)
+		watched := 0
+		c.AddWatchpoint(0x0000, 0xFFFF, AccessAny, func(WatchEvent) { watched++ })
+
+		for range fuzzSteps {
+			pc, cycles := c.pc, c.cycles
+			info := Opcode(mem.Memory.Read(pc))
+			interrupt := c.interruptPending()
+
+			err := c.Step()
+
+			if mem.reads+mem.writes != watched {
+				t.Fatalf("%d reads and %d writes reached memory, %d were watched", mem.reads, mem.writes, watched)
+			}
+			var invalid *InvalidOpcodeError
+			if errors.As(err, &invalid) {
+				if c.pc != pc {
+					t.Fatalf("expected PC to stay at $%04X, actual $%04X", pc, c.pc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkFuzzedStep(t, c, info, interrupt, pc, cycles)
+		}
+	})
+}
+
+func checkFuzzedStep(t *testing.T, c *CPU, info OpcodeInfo, interrupt bool, pc uint16, cycles uint) {
+	t.Helper()
+	elapsed := c.cycles - cycles
+	if interrupt {
+		if elapsed != interruptCycles {
+			t.Fatalf("expected an interrupt to take %d cycles, actual %d", interruptCycles, elapsed)
+		}
+		return
+	}
+	// Crossing a page and taking a branch cost up to two more cycles.
+	if elapsed < info.Cycles || elapsed > info.Cycles+2 {
+		t.Fatalf("%s at $%04X: expected %d cycles, actual %d", info.Mnemonic, pc, info.Cycles, elapsed)
+	}
+	switch info.Mnemonic {
+	case "JMP", "JSR", "RTS", "RTI", "BRK":
+		return
+	}
+	if info.Mode != Relative && c.pc != pc+info.Bytes {
+		t.Fatalf("%s at $%04X: expected PC $%04X, actual $%04X", info.Mnemonic, pc, pc+info.Bytes, c.pc)
+	}
+}
@@ -0,0 +1,96 @@
+package cpu
+
+// InstructionEvent describes an instruction the CPU executed.
+type InstructionEvent struct {
+	PC     uint16
+	Opcode byte
+	// Cycles is the number of cycles the instruction took, without the
+	// cycles the CPU was stalled before it.
+	Cycles uint
+}
+
+// InstructionFunc is called after each instruction the CPU executes. It may
+// call Stop to end the current Run.
+type InstructionFunc func(InstructionEvent)
+
+type instructionHook struct {
+	id int
+	fn InstructionFunc
+}
+
+// AddInstructionHook registers fn to be called after every instruction
+// executed, for tools such as profilers. Instructions that fault are not
+// reported. It returns an id that can be passed to RemoveInstructionHook.
+func (c *CPU) AddInstructionHook(fn InstructionFunc) int {
+	id := 0
+	for _, h := range c.hooks {
+		id = max(id, h.id+1)
+	}
+	c.hooks = append(c.hooks, instructionHook{id: id, fn: fn})
+	return id
+}
+
+// RemoveInstructionHook removes the hook with the given id.
+func (c *CPU) RemoveInstructionHook(id int) {
+	for i, h := range c.hooks {
+		if h.id == id {
+			c.hooks = append(c.hooks[:i], c.hooks[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *CPU) runHooks(op opcode, start uint) {
+	e := InstructionEvent{PC: c.instPC, Opcode: byte(op), Cycles: c.cycles - start}
+	for _, h := range c.hooks {
+		h.fn(e)
+	}
+}
+
+// InterruptEvent describes an interrupt the CPU entered.
+type InterruptEvent struct {
+	// Kind is InterruptIRQ or InterruptNMI.
+	Kind InterruptKind
+	// PC is the address of the instruction interrupted, and Handler that of
+	// the handler entered.
+	PC      uint16
+	Handler uint16
+}
+
+// InterruptFunc is called after the CPU enters the handler of an IRQ or an
+// NMI.
+type InterruptFunc func(InterruptEvent)
+
+type interruptHook struct {
+	id int
+	fn InterruptFunc
+}
+
+// AddInterruptHook registers fn to be called after every interrupt the CPU
+// enters, for tools such as metrics. BRK, which the CPU does not execute, is
+// not reported. It returns an id that can be passed to RemoveInterruptHook.
+func (c *CPU) AddInterruptHook(fn InterruptFunc) int {
+	id := 0
+	for _, h := range c.interruptHooks {
+		id = max(id, h.id+1)
+	}
+	c.interruptHooks = append(c.interruptHooks, interruptHook{id: id, fn: fn})
+	return id
+}
+
+// RemoveInterruptHook removes the hook with the given id.
+func (c *CPU) RemoveInterruptHook(id int) {
+	for i, h := range c.interruptHooks {
+		if h.id == id {
+			c.interruptHooks = append(c.interruptHooks[:i], c.interruptHooks[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *CPU) runInterruptHooks() {
+	e := InterruptEvent{Kind: c.taken, PC: c.instPC, Handler: c.pc}
+	for _, h := range c.interruptHooks {
+		h.fn(e)
+	}
+}
@@ -0,0 +1,69 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestInstructionHook(t *testing.T) {
+	mem := memory.Memory{}
+	mem.Write(byte(jsrAbsoluteOpcode), defaultPC)
+	mem.Write(0x00, defaultPC+1)
+	mem.Write(0x03, defaultPC+2)
+	mem.Write(byte(ldaImmediateOpcode), 0x0300)
+	c := New(&mem)
+	c.Reset()
+	c.Stall(3)
+
+	var events []InstructionEvent
+	id := c.AddInstructionHook(func(e InstructionEvent) {
+		events = append(events, e)
+	})
+	for range 2 {
+		if err := c.Step(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	c.RemoveInstructionHook(id)
+	if err := c.Step(); err == nil {
+		t.Fatalf("expected invalid opcode error")
+	}
+
+	expected := []InstructionEvent{
+		{PC: defaultPC, Opcode: byte(jsrAbsoluteOpcode), Cycles: jsrAbsoluteCycles},
+		{PC: 0x0300, Opcode: byte(ldaImmediateOpcode), Cycles: ldaImmediateCycles},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %+v, actual %+v\n", expected, events)
+	}
+	for i := range expected {
+		if expected[i] != events[i] {
+			t.Errorf("expected %+v, actual %+v\n", expected[i], events[i])
+		}
+	}
+}
+
+func TestInterruptHook(t *testing.T) {
+	mem := memory.Memory{}
+	c := newInterruptTestCPU(&mem)
+
+	var events []InterruptEvent
+	id := c.AddInterruptHook(func(e InterruptEvent) {
+		events = append(events, e)
+	})
+	c.NMI()
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.RemoveInterruptHook(id)
+	c.NMI()
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []InterruptEvent{{Kind: InterruptNMI, PC: defaultPC, Handler: 0x0500}}
+	if len(events) != len(expected) || events[0] != expected[0] {
+		t.Errorf("expected %+v, actual %+v\n", expected, events)
+	}
+}
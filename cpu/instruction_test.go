@@ -0,0 +1,186 @@
+package cpu
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// regMask is a set of registers, those an instruction changes.
+type regMask byte
+
+const (
+	regA regMask = 1 << iota
+	regX
+	regY
+	regSP
+	regPC
+)
+
+// opcodeSpec is what an opcode may change: the registers in regs and the
+// flags in flags. The others must be left alone, and PC must point to the
+// instruction after it unless regPC is in regs. Its length, cycles and
+// addressing mode come from the opcode table.
+type opcodeSpec struct {
+	op    opcode
+	regs  regMask
+	flags byte
+}
+
+// instructionCase is a run of an instruction, placed at defaultPC and
+// followed by its operand.
+type instructionCase struct {
+	name    string
+	operand []byte
+	// setup prepares the CPU, after a Reset, and its memory.
+	setup func(c *CPU)
+	// out holds the expected registers and flags that the spec changes.
+	out Registers
+	// mem holds bytes expected in memory afterwards.
+	mem map[uint16]byte
+	// penalty is the number of cycles taken above the base cycles.
+	penalty uint
+}
+
+// runInstructionTests runs the cases of the opcode of spec, each as a
+// subtest, checking the registers, the memory and the cycles.
+func runInstructionTests(t *testing.T, spec opcodeSpec, cases []instructionCase) {
+	t.Helper()
+	info := Opcode(byte(spec.op))
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mem := memory.Memory{}
+			mem.Write(byte(spec.op), defaultPC)
+			for i, b := range tc.operand {
+				mem.Write(b, defaultPC+1+uint16(i))
+			}
+			c := New(&mem)
+			c.Reset()
+			if tc.setup != nil {
+				tc.setup(c)
+			}
+			in := c.Registers()
+			cyclesInit := c.cycles
+
+			if err := c.Step(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := spec.expected(in, tc.out, info)
+			if actual := c.Registers(); actual != expected {
+				t.Errorf("expected %+v, actual %+v\n", expected, actual)
+			}
+			if cycles := c.cycles - cyclesInit; cycles != info.Cycles+tc.penalty {
+				t.Errorf("expected %d cycles, actual %d\n", info.Cycles+tc.penalty, cycles)
+			}
+			for addr, v := range tc.mem {
+				if actual := mem.Read(addr); actual != v {
+					t.Errorf("expected $%02X at $%04X, actual $%02X\n", v, addr, actual)
+				}
+			}
+		})
+	}
+}
+
+// expected returns the registers expected after an instruction run from in:
+// those of out for what the spec changes, and those of in otherwise.
+func (s opcodeSpec) expected(in, out Registers, info OpcodeInfo) Registers {
+	r := in
+	r.PC = in.PC + info.Bytes
+	for _, reg := range []struct {
+		mask     regMask
+		dst, src *byte
+	}{
+		{regA, &r.A, &out.A},
+		{regX, &r.X, &out.X},
+		{regY, &r.Y, &out.Y},
+		{regSP, &r.SP, &out.SP},
+	} {
+		if s.regs&reg.mask != 0 {
+			*reg.dst = *reg.src
+		}
+	}
+	if s.regs&regPC != 0 {
+		r.PC = out.PC
+	}
+	r.SR = in.SR&^s.flags | out.SR&s.flags
+	return r
+}
+
+// readCases returns a case for every way the addressing mode of the opcode
+// of s can reach value, such as with and without crossing a page, expecting
+// out.
+func (s opcodeSpec) readCases(value byte, out Registers) []instructionCase {
+	info := Opcode(byte(s.op))
+	name := func(variant string) string {
+		return fmt.Sprintf("%v%s $%02X", info.Mode, variant, value)
+	}
+	at := func(addr uint16, regs func(c *CPU)) func(c *CPU) {
+		return func(c *CPU) {
+			c.mem.Write(value, addr)
+			if regs != nil {
+				regs(c)
+			}
+		}
+	}
+	// pointer stores base at ptr, in the zero page, and value at addr.
+	pointer := func(ptr byte, base, addr uint16, regs func(c *CPU)) func(c *CPU) {
+		return func(c *CPU) {
+			c.mem.Write(byte(base), uint16(ptr))
+			c.mem.Write(byte(base>>8), uint16(ptr+1))
+			at(addr, regs)(c)
+		}
+	}
+	setX := func(x byte) func(c *CPU) { return func(c *CPU) { c.x = x } }
+	setY := func(y byte) func(c *CPU) { return func(c *CPU) { c.y = y } }
+	var penalty uint
+	if info.PageCrossPenalty() {
+		penalty = 1
+	}
+
+	switch info.Mode {
+	case Immediate:
+		return []instructionCase{{name: name(""), operand: []byte{value}, out: out}}
+	case ZeroPage:
+		return []instructionCase{{name: name(""), operand: []byte{0x10}, setup: at(0x0010, nil), out: out}}
+	case ZeroPageX:
+		return []instructionCase{
+			{name: name(""), operand: []byte{0x10}, setup: at(0x0012, setX(0x02)), out: out},
+			{name: name(" wrapping"), operand: []byte{0xFF}, setup: at(0x0001, setX(0x02)), out: out},
+		}
+	case ZeroPageY:
+		return []instructionCase{
+			{name: name(""), operand: []byte{0x10}, setup: at(0x0012, setY(0x02)), out: out},
+			{name: name(" wrapping"), operand: []byte{0xFF}, setup: at(0x0001, setY(0x02)), out: out},
+		}
+	case Absolute:
+		return []instructionCase{{name: name(""), operand: []byte{0x34, 0x12}, setup: at(0x1234, nil), out: out}}
+	case AbsoluteX:
+		return []instructionCase{
+			{name: name(""), operand: []byte{0x34, 0x12}, setup: at(0x1235, setX(0x01)), out: out},
+			{name: name(" crossing a page"), operand: []byte{0xF0, 0x12}, setup: at(0x1300, setX(0x10)), out: out, penalty: penalty},
+			{name: name(" wrapping"), operand: []byte{0xF0, 0xFF}, setup: at(0x0010, setX(0x20)), out: out, penalty: penalty},
+		}
+	case AbsoluteY:
+		return []instructionCase{
+			{name: name(""), operand: []byte{0x34, 0x12}, setup: at(0x1235, setY(0x01)), out: out},
+			{name: name(" crossing a page"), operand: []byte{0xF0, 0x12}, setup: at(0x1300, setY(0x10)), out: out, penalty: penalty},
+			{name: name(" wrapping"), operand: []byte{0xF0, 0xFF}, setup: at(0x0010, setY(0x20)), out: out, penalty: penalty},
+		}
+	case IndexedIndirect:
+		return []instructionCase{
+			{name: name(""), operand: []byte{0x10}, setup: pointer(0x14, 0x1234, 0x1234, setX(0x04)), out: out},
+			// The pointer is read from $FF and $00, not $0100.
+			{name: name(" pointer wrapping"), operand: []byte{0xFE}, setup: pointer(0xFF, 0x1234, 0x1234, setX(0x01)), out: out},
+		}
+	case IndirectIndexed:
+		return []instructionCase{
+			{name: name(""), operand: []byte{0x10}, setup: pointer(0x10, 0x1234, 0x1235, setY(0x01)), out: out},
+			{name: name(" crossing a page"), operand: []byte{0x10}, setup: pointer(0x10, 0x12F0, 0x1300, setY(0x10)), out: out, penalty: penalty},
+			{name: name(" pointer wrapping"), operand: []byte{0xFF}, setup: pointer(0xFF, 0x1234, 0x1235, setY(0x01)), out: out},
+		}
+	default:
+		panic(fmt.Sprintf("no read cases for %v", info.Mode))
+	}
+}
@@ -0,0 +1,77 @@
+package cpu
+
+// Interrupt vectors.
+const (
+	nmiVector uint16 = 0xFFFA
+	irqVector uint16 = 0xFFFE
+)
+
+// interruptCycles is the number of cycles taken to enter an interrupt
+// handler.
+const interruptCycles uint = 7
+
+// SetIRQ sets the level of the IRQ line. While it is asserted and the I flag
+// is clear, the CPU enters the handler at the IRQ/BRK vector before its next
+// instruction.
+func (c *CPU) SetIRQ(asserted bool) {
+	c.irq = asserted
+}
+
+// NMI signals a non-maskable interrupt: the CPU enters the handler at the NMI
+// vector before its next instruction, whatever the I flag.
+func (c *CPU) NMI() {
+	c.nmi = true
+}
+
+// IRQ reports whether the IRQ line is asserted.
+func (c *CPU) IRQ() bool {
+	return c.irq
+}
+
+// NMIPending reports whether an NMI was signaled and not taken yet.
+func (c *CPU) NMIPending() bool {
+	return c.nmi
+}
+
+// interruptPending reports whether an interrupt is to be taken before the
+// next instruction.
+func (c *CPU) interruptPending() bool {
+	return c.nmi || c.irq && c.sr&interruptSF == 0
+}
+
+// interrupt enters the handler of the pending interrupt, NMI taking
+// precedence over IRQ.
+func (c *CPU) interrupt() {
+	vector, kind := irqVector, FrameIRQ
+	c.taken = InterruptIRQ
+	if c.nmi {
+		vector, kind = nmiVector, FrameNMI
+		c.taken = InterruptNMI
+		c.nmi = false
+	}
+	c.instPC = c.pc
+	sp := c.sp
+	// Two internal cycles, in which the CPU reads the opcode it will not
+	// execute.
+	c.cycles += 2
+	c.enter(vector, c.sr&^breakSF|unusedSF)
+	if c.tracking {
+		c.calls = append(c.calls, Frame{Kind: kind, Site: c.instPC, Target: c.pc, SP: sp})
+	}
+	if len(c.interruptHooks) != 0 {
+		c.runInterruptHooks()
+	}
+}
+
+// enter pushes PC and the status register sr and jumps to the handler at
+// vector, as interrupts and BRK do.
+func (c *CPU) enter(vector uint16, sr byte) {
+	c.push(byte(c.pc >> 8))
+	c.push(byte(c.pc))
+	c.push(sr)
+	c.sr |= interruptSF
+	lo := c.access(AccessRead, vector)
+	hi := c.access(AccessRead, vector+1)
+	c.cycles += 2
+	c.pc = uint16(hi)<<8 | uint16(lo)
+}
@@ -0,0 +1,101 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func newInterruptTestCPU(mem *memory.Memory) *CPU {
+	memory.WriteWord(mem, 0x0400, irqVector)
+	memory.WriteWord(mem, 0x0500, nmiVector)
+	mem.Write(byte(ldaImmediateOpcode), defaultPC)
+	c := New(mem)
+	c.Reset()
+	return c
+}
+
+func TestIRQ(t *testing.T) {
+	mem := memory.Memory{}
+	c := newInterruptTestCPU(&mem)
+	c.sr |= carrySF | breakSF
+	cyclesInit := c.cycles
+
+	c.SetIRQ(true)
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c.pc != 0x0400 {
+		t.Errorf("expected pc 0400, actual %04X\n", c.pc)
+	}
+	if c.sr&interruptSF == 0 {
+		t.Errorf("expected I set, actual sr %08b\n", c.sr)
+	}
+	if ret := memory.ReadWord(&mem, stackPage|uint16(c.sp+2)); ret != defaultPC {
+		t.Errorf("expected return address %04X, actual %04X\n", defaultPC, ret)
+	}
+	// The status register is pushed with B clear.
+	if sr := mem.Read(stackPage | uint16(c.sp+1)); sr != unusedSF|carrySF {
+		t.Errorf("expected pushed sr %08b, actual %08b\n", unusedSF|carrySF, sr)
+	}
+	if cycles := c.cycles - cyclesInit; cycles != interruptCycles {
+		t.Errorf("expected %d cycles, actual %d\n", interruptCycles, cycles)
+	}
+}
+
+func TestIRQMasked(t *testing.T) {
+	mem := memory.Memory{}
+	c := newInterruptTestCPU(&mem)
+	c.sr |= interruptSF
+
+	c.SetIRQ(true)
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c.pc != defaultPC+ldaImmediateBytes {
+		t.Errorf("expected pc %04X, actual %04X\n", defaultPC+ldaImmediateBytes, c.pc)
+	}
+}
+
+func TestNMI(t *testing.T) {
+	mem := memory.Memory{}
+	c := newInterruptTestCPU(&mem)
+	c.sr |= interruptSF
+	mem.Write(byte(ldaImmediateOpcode), 0x0500)
+
+	c.SetIRQ(true)
+	c.NMI()
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.pc != 0x0500 {
+		t.Errorf("expected pc 0500, actual %04X\n", c.pc)
+	}
+
+	// The NMI is taken once, and the IRQ stays masked.
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.pc != 0x0500+ldaImmediateBytes {
+		t.Errorf("expected pc %04X, actual %04X\n", 0x0500+ldaImmediateBytes, c.pc)
+	}
+}
+
+func TestInterruptLines(t *testing.T) {
+	mem := memory.Memory{}
+	c := newInterruptTestCPU(&mem)
+
+	c.SetIRQ(true)
+	c.NMI()
+	if !c.IRQ() || !c.NMIPending() {
+		t.Errorf("expected IRQ and NMI, actual %v %v\n", c.IRQ(), c.NMIPending())
+	}
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !c.IRQ() || c.NMIPending() {
+		t.Errorf("expected IRQ only, actual %v %v\n", c.IRQ(), c.NMIPending())
+	}
+}
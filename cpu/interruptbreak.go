@@ -0,0 +1,71 @@
+package cpu
+
+// InterruptKind is a set of kinds of interrupts, for interrupt breakpoints.
+type InterruptKind byte
+
+const (
+	// InterruptIRQ is an IRQ taken by the CPU.
+	InterruptIRQ InterruptKind = 1 << iota
+	// InterruptNMI is an NMI.
+	InterruptNMI
+	// InterruptBRK is a BRK instruction.
+	InterruptBRK
+
+	// InterruptAny matches every kind of interrupt.
+	InterruptAny = InterruptIRQ | InterruptNMI | InterruptBRK
+)
+
+type interruptBreakpoint struct {
+	id    int
+	kinds InterruptKind
+	// handler is the address the vector must point to, if filtered.
+	handler  uint16
+	filtered bool
+}
+
+// AddInterruptBreakpoint makes Run and RunFor stop, with StopInterrupt, when
+// the CPU takes an interrupt of the given kinds: PC is then the first
+// instruction of the handler, not executed yet. The CPU does not execute BRK,
+// which fails as an invalid opcode: BRK stops the CPU at the instruction
+// instead, before it fails. It returns an id that can be passed to
+// RemoveBreakpoint.
+func (c *CPU) AddInterruptBreakpoint(kinds InterruptKind) int {
+	return c.addInterruptBreakpoint(interruptBreakpoint{kinds: kinds})
+}
+
+// AddHandlerBreakpoint is like AddInterruptBreakpoint, but only stops for
+// interrupts whose vector points to handler.
+func (c *CPU) AddHandlerBreakpoint(kinds InterruptKind, handler uint16) int {
+	return c.addInterruptBreakpoint(interruptBreakpoint{kinds: kinds, handler: handler, filtered: true})
+}
+
+func (c *CPU) addInterruptBreakpoint(b interruptBreakpoint) int {
+	b.id = c.nextBreakpointID()
+	c.interruptBreakpoints = append(c.interruptBreakpoints, b)
+	return b.id
+}
+
+// tookInterrupt reports whether the last step entered an interrupt handler
+// matching an interrupt breakpoint.
+func (c *CPU) tookInterrupt() bool {
+	return c.taken != 0 && c.matchesInterrupt(c.taken, c.pc)
+}
+
+// atBRK reports whether the CPU is about to execute a BRK matching an
+// interrupt breakpoint.
+func (c *CPU) atBRK() bool {
+	if opcode(c.peek(c.pc)) != brkImpliedOpcode {
+		return false
+	}
+	handler := uint16(c.peek(irqVector+1))<<8 | uint16(c.peek(irqVector))
+	return c.matchesInterrupt(InterruptBRK, handler)
+}
+
+func (c *CPU) matchesInterrupt(kind InterruptKind, handler uint16) bool {
+	for _, b := range c.interruptBreakpoints {
+		if b.kinds&kind != 0 && (!b.filtered || b.handler == handler) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newInterruptBreakpointTestCPU returns a CPU about to run LDA #imm and a
+// BRK, with an LDA #imm and a BRK in the IRQ handler at $0400 and the NMI
+// handler at $0500.
+func newInterruptBreakpointTestCPU() *CPU {
+	mem := memory.Memory{}
+	mem.Write(byte(ldaImmediateOpcode), 0x0400)
+	mem.Write(byte(ldaImmediateOpcode), 0x0500)
+	return newInterruptTestCPU(&mem)
+}
+
+func TestRunStopsAtIRQ(t *testing.T) {
+	c := newInterruptBreakpointTestCPU()
+	c.AddInterruptBreakpoint(InterruptIRQ | InterruptNMI)
+	c.SetIRQ(true)
+
+	reason, err := c.Run()
+
+	if reason != StopInterrupt || err != nil {
+		t.Fatalf("expected %v, actual %v (%v)", StopInterrupt, reason, err)
+	}
+	if c.pc != 0x0400 {
+		t.Errorf("expected to stop at the handler, actual pc %04X\n", c.pc)
+	}
+}
+
+func TestRunStopsAtHandler(t *testing.T) {
+	c := newInterruptBreakpointTestCPU()
+	c.AddHandlerBreakpoint(InterruptAny, 0x0500)
+	c.SetIRQ(true)
+
+	// The IRQ handler is not filtered in, and runs into its BRK.
+	reason, _ := c.Run()
+	if reason != StopFault || c.pc != 0x0402 {
+		t.Fatalf("expected %v at $0402, actual %v at $%04X", StopFault, reason, c.pc)
+	}
+
+	c.NMI()
+	reason, err := c.Run()
+
+	if reason != StopInterrupt || err != nil {
+		t.Fatalf("expected %v, actual %v (%v)", StopInterrupt, reason, err)
+	}
+	if c.pc != 0x0500 {
+		t.Errorf("expected to stop at the handler, actual pc %04X\n", c.pc)
+	}
+}
+
+func TestRunStopsAtBRK(t *testing.T) {
+	c := newInterruptBreakpointTestCPU()
+	id := c.AddInterruptBreakpoint(InterruptBRK)
+
+	reason, err := c.Run()
+	if reason != StopInterrupt || err != nil || c.pc != defaultPC+ldaImmediateBytes {
+		t.Fatalf("expected %v at the BRK, actual %v at $%04X (%v)", StopInterrupt, reason, c.pc, err)
+	}
+
+	// Running again executes the BRK, which the CPU does not implement.
+	var invalid *InvalidOpcodeError
+	if reason, err := c.Run(); reason != StopFault || !errors.As(err, &invalid) {
+		t.Errorf("expected an invalid opcode, actual %v (%v)\n", reason, err)
+	}
+
+	c.RemoveBreakpoint(id)
+	c.pc = defaultPC
+	if reason, _ := c.Run(); reason != StopFault {
+		t.Errorf("expected %v, actual %v\n", StopFault, reason)
+	}
+}
@@ -0,0 +1,25 @@
+package cpu
+
+const (
+	jsrAbsoluteBytes  uint16 = 3
+	jsrAbsoluteCycles uint   = 6
+)
+
+// jsrAbsolute pushes the address of its last byte on the stack and jumps to
+// a subroutine.
+//
+// Attributes:
+//
+//	Bytes: 3
+//	Cycles: 6
+//	Flags affected: none
+func jsrAbsolute(cpu *CPU) {
+	lo := cpu.fetchByte()
+	// Internal cycle, storing the low byte of the target while the stack
+	// pointer is read.
+	cpu.cycles++
+	cpu.push(byte(cpu.pc >> 8))
+	cpu.push(byte(cpu.pc))
+	hi := cpu.fetchByte()
+	cpu.pc = uint16(hi)<<8 | uint16(lo)
+}
@@ -0,0 +1,33 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestJSRAbsolute(t *testing.T) {
+	mem := memory.Memory{}
+	mem.Write(byte(jsrAbsoluteOpcode), defaultPC)
+	memory.WriteWord(&mem, 0x1234, defaultPC+1)
+
+	c := New(&mem)
+	c.Reset()
+	cyclesInit := c.cycles
+
+	c.step()
+
+	if c.pc != 0x1234 {
+		t.Errorf("expected pc 1234, actual %04X\n", c.pc)
+	}
+	if c.sp != defaultSP-2 {
+		t.Errorf("expected sp %02X, actual %02X\n", defaultSP-2, c.sp)
+	}
+	// The address of the last byte of the JSR is pushed, high byte first.
+	if ret := memory.ReadWord(&mem, stackPage|uint16(c.sp+1)); ret != defaultPC+jsrAbsoluteBytes-1 {
+		t.Errorf("expected return address %04X, actual %04X\n", defaultPC+jsrAbsoluteBytes-1, ret)
+	}
+	if cycles := c.cycles - cyclesInit; cycles != jsrAbsoluteCycles {
+		t.Errorf("expected %d cycles, actual %d\n", jsrAbsoluteCycles, cycles)
+	}
+}
@@ -0,0 +1,157 @@
+package cpu
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestOpcodeTableDocumentedCount(t *testing.T) {
+	n := 0
+	for op := range 256 {
+		if Opcode(byte(op)).Documented() {
+			n++
+		}
+	}
+	if n != 151 {
+		t.Errorf("expected 151 documented opcodes, actual %d\n", n)
+	}
+}
+
+func TestOpcodeTableMatchesImplementation(t *testing.T) {
+	info := Opcode(byte(ldaImmediateOpcode))
+	if !info.Implemented() || info.Bytes != ldaImmediateBytes || info.Cycles != ldaImmediateCycles {
+		t.Errorf("unexpected LDA immediate entry %+v\n", info)
+	}
+}
+
+func TestOpcodePageCrossPenalty(t *testing.T) {
+	n := 0
+	for op := range 256 {
+		if Opcode(byte(op)).PageCrossPenalty() {
+			n++
+		}
+	}
+	// ADC, AND, CMP, EOR, LDA, ORA and SBC with abs,X, abs,Y and (zp),Y,
+	// LDX abs,Y and LDY abs,X.
+	if n != 23 {
+		t.Errorf("expected 23 opcodes with a page-cross penalty, actual %d\n", n)
+	}
+	if Opcode(0x9D).PageCrossPenalty() {
+		t.Errorf("expected STA abs,X to take no penalty\n")
+	}
+}
+
+// referenceModes maps the addressing modes of testdata/opcodes.txt to those
+// of the opcode table.
+var referenceModes = map[string]AddressingMode{
+	"imp": Implied, "acc": Accumulator, "imm": Immediate,
+	"zp": ZeroPage, "zpx": ZeroPageX, "zpy": ZeroPageY, "rel": Relative,
+	"abs": Absolute, "abx": AbsoluteX, "aby": AbsoluteY, "ind": Indirect,
+	"izx": IndexedIndirect, "izy": IndirectIndexed,
+}
+
+// referenceOpcode is an opcode of testdata/opcodes.txt.
+type referenceOpcode struct {
+	info OpcodeInfo
+	// flags are the flags the instruction may change.
+	flags byte
+}
+
+func loadReferenceOpcodes(t *testing.T) map[byte]referenceOpcode {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "opcodes.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	ops := map[byte]referenceOpcode{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		var (
+			op                        byte
+			mnemonic, mode, flagNames string
+			ref                       referenceOpcode
+		)
+		if _, err := fmt.Sscanf(line, "%x %s %s %d %d %s", &op, &mnemonic, &mode, &ref.info.Bytes, &ref.info.Cycles, &flagNames); err != nil {
+			t.Fatalf("invalid line %q: %v", line, err)
+		}
+		ref.info.Mnemonic = mnemonic
+		ref.info.Mode = referenceModes[mode]
+		for _, name := range strings.TrimPrefix(flagNames, "-") {
+			ref.flags |= flagBits[string(name)]
+		}
+		ops[op] = ref
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return ops
+}
+
+func TestOpcodeTableMatchesReference(t *testing.T) {
+	ref := loadReferenceOpcodes(t)
+	for op := range 256 {
+		info := Opcode(byte(op))
+		expected, ok := ref[byte(op)]
+		if !ok {
+			if info.Documented() {
+				t.Errorf("unexpected documented opcode %02X %+v\n", op, info)
+			}
+			continue
+		}
+		e := expected.info
+		if info.Mnemonic != e.Mnemonic || info.Mode != e.Mode || info.Bytes != e.Bytes || info.Cycles != e.Cycles {
+			t.Errorf("opcode %02X: expected %s %v %d %d, actual %s %v %d %d\n", op,
+				e.Mnemonic, e.Mode, e.Bytes, e.Cycles, info.Mnemonic, info.Mode, info.Bytes, info.Cycles)
+		}
+	}
+}
+
+// TestOpcodeFlagsMatchReference runs the implemented opcodes from random
+// states and checks that they change no flag but those of the reference. B
+// is not a flag of the status register, and is left out.
+func TestOpcodeFlagsMatchReference(t *testing.T) {
+	ref := loadReferenceOpcodes(t)
+	rng := rand.New(rand.NewSource(1))
+	for op := range 256 {
+		if !Opcode(byte(op)).Implemented() {
+			continue
+		}
+		allowed := ref[byte(op)].flags | breakSF
+		for range 100 {
+			mem := &memory.Memory{}
+			for addr := range 0x10000 {
+				mem.Write(byte(rng.Intn(256)), uint16(addr))
+			}
+			mem.Write(byte(op), defaultPC)
+			c := New(mem)
+			c.Reset()
+			c.SetRegisters(Registers{
+				A: byte(rng.Intn(256)), X: byte(rng.Intn(256)), Y: byte(rng.Intn(256)),
+				SP: byte(rng.Intn(256)), PC: defaultPC, SR: byte(rng.Intn(256)),
+			})
+			in := c.sr
+
+			if err := c.Step(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if changed := (in ^ c.sr) &^ allowed; changed != 0 {
+				t.Errorf("opcode %02X: unexpected change of flags %08b, sr %08b to %08b\n", op, changed, in, c.sr)
+				break
+			}
+		}
+	}
+}
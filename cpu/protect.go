@@ -0,0 +1,71 @@
+package cpu
+
+import "fmt"
+
+// Permissions commonly given to Protect.
+const (
+	PermNone      Access = 0
+	PermReadOnly         = AccessRead | AccessExecute
+	PermNoExecute        = AccessRead | AccessWrite
+)
+
+// BusFault is the error returned by Step and Run when an instruction makes an
+// access denied by Protect.
+//
+//nolint:errname
+type BusFault struct {
+	Access Access
+	Addr   uint16
+	// PC is the address of the instruction that made the access.
+	PC uint16
+}
+
+func (f *BusFault) Error() string {
+	var kind string
+	switch f.Access {
+	case AccessRead:
+		kind = "read from"
+	case AccessWrite:
+		kind = "write to"
+	default:
+		kind = "execute at"
+	}
+	return fmt.Sprintf("bus fault: %s $%04X by instruction at $%04X", kind, f.Addr, f.PC)
+}
+
+type protection struct {
+	start uint16
+	end   uint16
+	allow Access
+}
+
+// Protect restricts the accesses allowed on the addresses from start to end,
+// inclusive, to the kinds in allow. A denied access is not performed, reads
+// returning zero instead, and makes Step report a *BusFault once the
+// instruction completes. When regions overlap, the most recently added one
+// wins.
+func (c *CPU) Protect(start, end uint16, allow Access) {
+	c.protections = append(c.protections, protection{start: start, end: end, allow: allow})
+}
+
+// Unprotect removes every restriction added with Protect.
+func (c *CPU) Unprotect() {
+	c.protections = nil
+}
+
+func (c *CPU) allowed(access Access, addr uint16) bool {
+	for i := len(c.protections) - 1; i >= 0; i-- {
+		p := c.protections[i]
+		if addr < p.start || addr > p.end {
+			continue
+		}
+		if p.allow&access != 0 {
+			return true
+		}
+		if c.fault == nil {
+			c.fault = &BusFault{Access: access, Addr: addr, PC: c.instPC}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func newProtectTestCPU() *CPU {
+	offset := unreservedMemoryAddressStart
+	mem := memory.Memory{}
+	mem.Write(byte(ldaImmediateOpcode), offset)
+	mem.Write(0x42, offset+1)
+
+	c := New(&mem)
+	c.Reset()
+	return c
+}
+
+func TestProtectNoExecute(t *testing.T) {
+	c := newProtectTestCPU()
+	c.Protect(0x0200, 0x02FF, PermNoExecute)
+
+	err := c.Step()
+
+	var fault *BusFault
+	if !errors.As(err, &fault) {
+		t.Fatalf("expected bus fault, actual %v", err)
+	}
+	expected := BusFault{Access: AccessExecute, Addr: defaultPC, PC: defaultPC}
+	if *fault != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, *fault)
+	}
+}
+
+func TestProtectReadOnlyDropsWrites(t *testing.T) {
+	c := newProtectTestCPU()
+	c.Protect(0x8000, 0xFFFF, PermReadOnly)
+
+	c.write(0x01, 0x8000)
+
+	if c.mem.Read(0x8000) != 0x00 {
+		t.Errorf("expected write to be dropped")
+	}
+	if err := c.Step(); !errors.As(err, new(*BusFault)) {
+		t.Errorf("expected bus fault, actual %v", err)
+	}
+}
+
+func TestProtectInaccessible(t *testing.T) {
+	c := newProtectTestCPU()
+	c.Protect(defaultPC+1, defaultPC+1, PermNone)
+
+	err := c.Step()
+
+	var fault *BusFault
+	if !errors.As(err, &fault) || fault.Access != AccessRead {
+		t.Fatalf("expected read fault, actual %v", err)
+	}
+	if c.acc != 0x00 {
+		t.Errorf("expected denied read to return 0, actual %02X\n", c.acc)
+	}
+}
+
+func TestProtectLatestRegionWins(t *testing.T) {
+	c := newProtectTestCPU()
+	c.Protect(0x0000, 0xFFFF, PermNone)
+	c.Protect(0x0200, 0x0201, AccessAny)
+
+	if err := c.Step(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRunStopsOnBusFault(t *testing.T) {
+	c := newProtectTestCPU()
+	c.Protect(0x0202, 0xFFFF, PermNone)
+
+	reason, err := c.Run()
+
+	var fault *BusFault
+	if reason != StopFault || !errors.As(err, &fault) || fault.Addr != 0x0202 {
+		t.Errorf("expected fault at $0202, actual %v", err)
+	}
+}
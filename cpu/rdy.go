@@ -0,0 +1,18 @@
+package cpu
+
+// Stall holds the RDY line low for n cycles, as done by DMA controllers and
+// video chips sharing the bus. The CPU sits idle for those cycles before
+// starting its next instruction.
+func (c *CPU) Stall(n uint) {
+	c.stall += n
+}
+
+// Cycles returns the number of cycles elapsed since power on.
+func (c *CPU) Cycles() uint {
+	return c.cycles
+}
+
+func (c *CPU) applyStall() {
+	c.cycles += c.stall
+	c.stall = 0
+}
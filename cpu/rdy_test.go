@@ -0,0 +1,29 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestStallDelaysNextInstruction(t *testing.T) {
+	offset := unreservedMemoryAddressStart
+	mem := memory.Memory{}
+	mem.Write(byte(ldaImmediateOpcode), offset)
+	mem.Write(0x42, offset+1)
+
+	c := New(&mem)
+	c.Reset()
+	cyclesInit := c.Cycles()
+
+	c.Stall(513)
+	c.Stall(1)
+	c.step()
+
+	if actual, expected := c.Cycles()-cyclesInit, 514+ldaImmediateCycles; actual != expected {
+		t.Errorf("expected %d cycles, actual %d\n", expected, actual)
+	}
+	if c.acc != 0x42 {
+		t.Errorf("expected the instruction to run after the stall")
+	}
+}
@@ -0,0 +1,33 @@
+package cpu
+
+// Registers holds the registers of the CPU, for debuggers and tests.
+type Registers struct {
+	A  byte
+	X  byte
+	Y  byte
+	SP byte
+	PC uint16
+	// SR holds the flags N, V, 1, B, D, I, Z and C, from bit 7 to bit 0.
+	SR byte
+}
+
+// Registers returns the current content of the registers.
+func (c *CPU) Registers() Registers {
+	return Registers{A: c.acc, X: c.x, Y: c.y, SP: c.sp, PC: c.pc, SR: c.sr}
+}
+
+// SetRegisters changes the content of the registers. Bit 5 of the status
+// register is not wired to anything and always reads 1.
+func (c *CPU) SetRegisters(r Registers) {
+	c.acc = r.A
+	c.x = r.X
+	c.y = r.Y
+	if c.sp != r.SP {
+		// The stack is moved: its history is lost.
+		c.sp = r.SP
+		c.resetPushed()
+	}
+	c.pc = r.PC
+	c.sr = r.SR | unusedSF
+	c.atBreak = false
+}
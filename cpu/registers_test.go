@@ -0,0 +1,55 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestSetRegisters(t *testing.T) {
+	c := New(&memory.Memory{})
+	c.Reset()
+
+	c.SetRegisters(Registers{A: 1, X: 2, Y: 3, SP: 0xF0, PC: 0x1234, SR: negativeSF})
+
+	expected := Registers{A: 1, X: 2, Y: 3, SP: 0xF0, PC: 0x1234, SR: negativeSF | unusedSF}
+	if actual := c.Registers(); actual != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+}
+
+func TestStepInvalidOpcode(t *testing.T) {
+	mem := memory.Memory{}
+	mem.Write(0x02, defaultPC)
+	c := New(&mem)
+	c.Reset()
+
+	err := c.Step()
+
+	var invalid *InvalidOpcodeError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected invalid opcode error, actual %v", err)
+	}
+	expected := InvalidOpcodeError{Opcode: 0x02, PC: defaultPC}
+	if *invalid != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, *invalid)
+	}
+	if c.pc != defaultPC {
+		t.Errorf("expected PC to stay at $%04X, actual $%04X\n", defaultPC, c.pc)
+	}
+}
+
+func TestSetState(t *testing.T) {
+	c := New(&memory.Memory{})
+	c.Reset()
+	c.Stall(4)
+	expected := c.State()
+
+	other := New(&memory.Memory{})
+	other.SetState(expected)
+
+	if actual := other.State(); actual != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+}
@@ -0,0 +1,24 @@
+package cpu
+
+const (
+	rtiImpliedBytes  uint16 = 1
+	rtiImpliedCycles uint   = 6
+)
+
+// rtiImplied returns from an interrupt handler, pulling the status register
+// and PC from the stack.
+//
+// Attributes:
+//
+//	Bytes: 1
+//	Cycles: 6
+//	Flags affected: all, from the stack
+func rtiImplied(cpu *CPU) {
+	// Dummy read of the next byte, then an internal cycle incrementing the
+	// stack pointer.
+	cpu.cycles += 2
+	cpu.sr = cpu.pull()&^breakSF | unusedSF
+	lo := cpu.pull()
+	hi := cpu.pull()
+	cpu.pc = uint16(hi)<<8 | uint16(lo)
+}
@@ -0,0 +1,34 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestRTIImplied(t *testing.T) {
+	mem := memory.Memory{}
+	mem.Write(byte(rtiImpliedOpcode), defaultPC)
+	memory.WriteWord(&mem, 0x1234, stackPage|uint16(defaultSP-1))
+	mem.Write(breakSF|carrySF, stackPage|uint16(defaultSP-2))
+
+	c := New(&mem)
+	c.Reset()
+	c.sp = defaultSP - 3
+	cyclesInit := c.cycles
+
+	c.step()
+
+	if c.pc != 0x1234 {
+		t.Errorf("expected pc 1234, actual %04X\n", c.pc)
+	}
+	if c.sp != defaultSP {
+		t.Errorf("expected sp %02X, actual %02X\n", defaultSP, c.sp)
+	}
+	if c.sr != unusedSF|carrySF {
+		t.Errorf("expected sr %08b, actual %08b\n", unusedSF|carrySF, c.sr)
+	}
+	if cycles := c.cycles - cyclesInit; cycles != rtiImpliedCycles {
+		t.Errorf("expected %d cycles, actual %d\n", rtiImpliedCycles, cycles)
+	}
+}
@@ -0,0 +1,26 @@
+package cpu
+
+const (
+	rtsImpliedBytes  uint16 = 1
+	rtsImpliedCycles uint   = 6
+)
+
+// rtsImplied returns from a subroutine, pulling from the stack the address
+// pushed by JSR and continuing after it.
+//
+// Attributes:
+//
+//	Bytes: 1
+//	Cycles: 6
+//	Flags affected: none
+func rtsImplied(cpu *CPU) {
+	// Dummy read of the next byte, then an internal cycle incrementing the
+	// stack pointer.
+	cpu.cycles += 2
+	lo := cpu.pull()
+	hi := cpu.pull()
+	cpu.pc = uint16(hi)<<8 | uint16(lo)
+	// Internal cycle incrementing PC past the last byte of the JSR.
+	cpu.pc++
+	cpu.cycles++
+}
@@ -0,0 +1,30 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestRTSImplied(t *testing.T) {
+	mem := memory.Memory{}
+	mem.Write(byte(rtsImpliedOpcode), defaultPC)
+	memory.WriteWord(&mem, 0x1233, stackPage|0xFE)
+
+	c := New(&mem)
+	c.Reset()
+	c.sp = 0xFD
+	cyclesInit := c.cycles
+
+	c.step()
+
+	if c.pc != 0x1234 {
+		t.Errorf("expected pc 1234, actual %04X\n", c.pc)
+	}
+	if c.sp != defaultSP {
+		t.Errorf("expected sp %02X, actual %02X\n", defaultSP, c.sp)
+	}
+	if cycles := c.cycles - cyclesInit; cycles != rtsImpliedCycles {
+		t.Errorf("expected %d cycles, actual %d\n", rtsImpliedCycles, cycles)
+	}
+}
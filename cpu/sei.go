@@ -0,0 +1,19 @@
+package cpu
+
+const (
+	seiImpliedBytes  uint16 = 1
+	seiImpliedCycles uint   = 2
+)
+
+// seiImplied sets the interrupt disable flag, masking IRQs.
+//
+// Attributes:
+//
+//	Bytes: 1
+//	Cycles: 2
+//	Flags affected: I
+func seiImplied(cpu *CPU) {
+	// Dummy read of the next byte.
+	cpu.cycles++
+	cpu.sr |= interruptSF
+}
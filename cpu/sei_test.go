@@ -0,0 +1,10 @@
+package cpu
+
+import "testing"
+
+func TestSEIImplied(t *testing.T) {
+	runInstructionTests(t, opcodeSpec{op: seiImpliedOpcode, flags: interruptSF}, []instructionCase{
+		{name: "clear", setup: func(c *CPU) { c.sr &^= interruptSF }, out: Registers{SR: interruptSF}},
+		{name: "set", out: Registers{SR: interruptSF}},
+	})
+}
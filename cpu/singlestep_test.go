@@ -0,0 +1,157 @@
+package cpu
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// singleStepEnv names the environment variable pointing at the directory of
+// the 6502 vectors of Tom Harte's SingleStepTests (ProcessorTests), one file
+// per opcode such as a9.json. The tests are skipped without it.
+const singleStepEnv = "MOS6502_SINGLESTEP"
+
+// maxSingleStepFailures is the number of failing cases reported per opcode.
+const maxSingleStepFailures = 5
+
+type singleStepState struct {
+	PC  uint16     `json:"pc"`
+	S   byte       `json:"s"`
+	A   byte       `json:"a"`
+	X   byte       `json:"x"`
+	Y   byte       `json:"y"`
+	P   byte       `json:"p"`
+	RAM [][2]int32 `json:"ram"`
+}
+
+// singleStepCycle is a bus cycle of a vector: [addr, value, "read"|"write"].
+type singleStepCycle struct {
+	addr  uint16
+	value byte
+	write bool
+}
+
+func (c *singleStepCycle) UnmarshalJSON(data []byte) error {
+	var raw [3]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	addr, ok1 := raw[0].(float64)
+	value, ok2 := raw[1].(float64)
+	kind, ok3 := raw[2].(string)
+	if !ok1 || !ok2 || !ok3 {
+		return fmt.Errorf("invalid bus cycle %s", data)
+	}
+	*c = singleStepCycle{addr: uint16(addr), value: byte(value), write: kind == "write"}
+	return nil
+}
+
+type singleStepCase struct {
+	Name    string            `json:"name"`
+	Initial singleStepState   `json:"initial"`
+	Final   singleStepState   `json:"final"`
+	Cycles  []singleStepCycle `json:"cycles"`
+}
+
+func TestSingleStep(t *testing.T) {
+	dir := os.Getenv(singleStepEnv)
+	if dir == "" {
+		t.Skipf("%s is not set", singleStepEnv)
+	}
+	for op := range 256 {
+		info := Opcode(byte(op))
+		if !info.Implemented() {
+			continue
+		}
+		t.Run(fmt.Sprintf("%02X %s", op, info.Mnemonic), func(t *testing.T) {
+			singleStepOpcode(t, filepath.Join(dir, fmt.Sprintf("%02x.json", op)))
+		})
+	}
+}
+
+func singleStepOpcode(t *testing.T, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var cases []singleStepCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	failures := 0
+	for _, tc := range cases {
+		if err := runSingleStepCase(tc); err != nil {
+			t.Errorf("%s: %v\n", tc.Name, err)
+			if failures++; failures == maxSingleStepFailures {
+				t.Fatalf("too many failures, stopping")
+			}
+		}
+	}
+}
+
+// runSingleStepCase runs the instruction of tc and compares the state it
+// leaves with the final state. The CPU does not make the dummy reads of the
+// real one: its accesses must be the cycles of the vector in order, with only
+// reads left out, and it must take as many cycles. B and bit 5 of the status
+// register do not exist in the real CPU, and are not compared.
+func runSingleStepCase(tc singleStepCase) error {
+	mem := &memory.Memory{}
+	for _, r := range tc.Initial.RAM {
+		mem.Write(byte(r[1]), uint16(r[0]))
+	}
+	c := New(mem)
+	c.Reset()
+	in := tc.Initial
+	c.SetRegisters(Registers{A: in.A, X: in.X, Y: in.Y, SP: in.S, PC: in.PC, SR: in.P})
+	var accesses []singleStepCycle
+	c.AddWatchpoint(0x0000, 0xFFFF, AccessAny, func(e WatchEvent) {
+		accesses = append(accesses, singleStepCycle{addr: e.Addr, value: e.New, write: e.Access == AccessWrite})
+	})
+
+	start := c.Cycles()
+	if err := c.Step(); err != nil {
+		return err
+	}
+
+	out := tc.Final
+	expected := Registers{A: out.A, X: out.X, Y: out.Y, SP: out.S, PC: out.PC, SR: out.P | unusedSF}
+	actual := c.Registers()
+	const flags = ^(breakSF | unusedSF)
+	if actual.SR&flags == expected.SR&flags {
+		actual.SR = expected.SR
+	}
+	if actual != expected {
+		return fmt.Errorf("expected registers %+v, actual %+v", expected, actual)
+	}
+	for _, r := range out.RAM {
+		if v := mem.Read(uint16(r[0])); v != byte(r[1]) {
+			return fmt.Errorf("expected $%02X at $%04X, actual $%02X", r[1], r[0], v)
+		}
+	}
+	if cycles := c.Cycles() - start; cycles != uint(len(tc.Cycles)) {
+		return fmt.Errorf("expected %d cycles, actual %d", len(tc.Cycles), cycles)
+	}
+	return compareBusCycles(tc.Cycles, accesses)
+}
+
+func compareBusCycles(expected, actual []singleStepCycle) error {
+	i := 0
+	for _, e := range expected {
+		if i < len(actual) && actual[i] == e {
+			i++
+			continue
+		}
+		if e.write {
+			return fmt.Errorf("expected write of $%02X at $%04X, actual accesses %+v", e.value, e.addr, actual)
+		}
+	}
+	if i != len(actual) {
+		return fmt.Errorf("unexpected access %+v, expected cycles %+v", actual[i], expected)
+	}
+	return nil
+}
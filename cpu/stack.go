@@ -0,0 +1,24 @@
+package cpu
+
+// stackPage is the page holding the stack, indexed by SP.
+const stackPage uint16 = 0x0100
+
+// push stores val on the stack, taking a cycle.
+func (c *CPU) push(val byte) {
+	if c.onStackMisuse != nil {
+		c.checkPush()
+	}
+	c.write(val, stackPage|uint16(c.sp))
+	c.sp--
+	c.cycles++
+}
+
+// pull removes the byte on top of the stack and returns it, taking a cycle.
+func (c *CPU) pull() byte {
+	if c.onStackMisuse != nil {
+		c.checkPull()
+	}
+	c.sp++
+	c.cycles++
+	return c.access(AccessRead, stackPage|uint16(c.sp))
+}
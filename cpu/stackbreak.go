@@ -0,0 +1,34 @@
+package cpu
+
+type stackBreakpoint struct {
+	id        int
+	low, high byte
+}
+
+// AddStackBreakpoint makes Run and RunFor stop when an instruction, or the
+// entry of an interrupt handler, moves SP from within low to high, inclusive,
+// to outside of them, such as to catch runaway recursion with a low limit or
+// a program pulling more than it pushed with a high one. The stack grows
+// down: the deeper the stack, the lower SP. Run stops after the instruction,
+// with StopStackLimit. It returns an id that can be passed to
+// RemoveBreakpoint.
+func (c *CPU) AddStackBreakpoint(low, high byte) int {
+	id := c.nextBreakpointID()
+	c.stackBreakpoints = append(c.stackBreakpoints, stackBreakpoint{id: id, low: low, high: high})
+	return id
+}
+
+// leftStackLimits reports whether the last step moved SP out of the limits of
+// a stack breakpoint, from sp.
+func (c *CPU) leftStackLimits(sp byte) bool {
+	for _, b := range c.stackBreakpoints {
+		if b.within(sp) && !b.within(c.sp) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b stackBreakpoint) within(sp byte) bool {
+	return sp >= b.low && sp <= b.high
+}
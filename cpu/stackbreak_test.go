@@ -0,0 +1,65 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newStackBreakpointTestCPU returns a CPU about to run a subroutine calling
+// itself forever:
+//
+//	0200  JSR $0200
+func newStackBreakpointTestCPU() *CPU {
+	mem := memory.Memory{}
+	mem.Write(byte(jsrAbsoluteOpcode), 0x0200)
+	mem.Write(0x00, 0x0201)
+	mem.Write(0x02, 0x0202)
+	c := New(&mem)
+	c.Reset()
+	return c
+}
+
+func TestRunStopsAtStackLimit(t *testing.T) {
+	c := newStackBreakpointTestCPU()
+	c.AddStackBreakpoint(0xF0, 0xFF)
+
+	reason, err := c.Run()
+
+	if reason != StopStackLimit || err != nil {
+		t.Fatalf("expected %v, actual %v (%v)", StopStackLimit, reason, err)
+	}
+	if c.sp != 0xEF {
+		t.Errorf("expected SP $EF, actual $%02X\n", c.sp)
+	}
+}
+
+func TestRunResumesOutsideStackLimit(t *testing.T) {
+	c := newStackBreakpointTestCPU()
+	c.AddStackBreakpoint(0xF0, 0xFF)
+	_, _ = c.Run()
+
+	// Running below the limit does not stop again until SP wraps around
+	// the page and comes back within it.
+	reason, _ := c.RunFor(100 * jsrAbsoluteCycles)
+
+	if reason != StopCycles || c.sp != 0x27 {
+		t.Errorf("expected to run below the limit, actual %v with SP $%02X\n", reason, c.sp)
+	}
+}
+
+func TestRemoveStackBreakpoint(t *testing.T) {
+	c := newStackBreakpointTestCPU()
+	addr := c.AddBreakpoint(0x0300)
+	stack := c.AddStackBreakpoint(0xF0, 0xFF)
+	if addr == stack {
+		t.Fatalf("expected distinct ids, actual %d and %d", addr, stack)
+	}
+	c.RemoveBreakpoint(stack)
+
+	reason, _ := c.RunFor(20 * jsrAbsoluteCycles)
+
+	if reason != StopCycles {
+		t.Errorf("expected %v, actual %v\n", StopCycles, reason)
+	}
+}
@@ -0,0 +1,113 @@
+package cpu
+
+import "fmt"
+
+// StackMisuseKind tells what is wrong with a stack access.
+type StackMisuseKind byte
+
+const (
+	// StackOverflow is a push with SP at $00, wrapping it to $FF and
+	// overwriting the bottom of the stack.
+	StackOverflow StackMisuseKind = iota
+	// StackUnderflow is a pull with SP at $FF, wrapping it to $00.
+	StackUnderflow
+	// StackUnpushed is a pull of a byte that was never pushed or, with
+	// calls tracked, that was not pushed in the current frame, such as a
+	// subroutine pulling its own return address.
+	StackUnpushed
+)
+
+func (k StackMisuseKind) String() string {
+	switch k {
+	case StackUnderflow:
+		return "stack underflow"
+	case StackUnpushed:
+		return "pull of a byte never pushed"
+	default:
+		return "stack overflow"
+	}
+}
+
+// StackMisuse describes a bad stack access.
+type StackMisuse struct {
+	Kind StackMisuseKind
+	// PC is the address of the instruction accessing the stack.
+	PC uint16
+	// SP is the stack pointer before the access.
+	SP byte
+}
+
+func (m StackMisuse) String() string {
+	return fmt.Sprintf("%v at $%04X, SP $%02X", m.Kind, m.PC, m.SP)
+}
+
+// StackMisuseFunc is called on the first bad stack access of an instruction,
+// before it is made. It may call Stop to end the current Run once the
+// instruction completes.
+type StackMisuseFunc func(StackMisuse)
+
+// CheckStack makes the CPU report bad stack accesses to fn. The bytes above
+// SP are taken as pushed.
+func (c *CPU) CheckStack(fn StackMisuseFunc) {
+	c.onStackMisuse = fn
+	c.resetPushed()
+}
+
+// StopCheckingStack turns stack checking off.
+func (c *CPU) StopCheckingStack() {
+	c.onStackMisuse = nil
+}
+
+// resetPushed marks the bytes above SP as pushed, and the others as not, for
+// a stack whose history is unknown.
+func (c *CPU) resetPushed() {
+	for i := range c.pushed {
+		c.pushed[i] = i > int(c.sp)
+	}
+}
+
+func (c *CPU) checkPush() {
+	if c.sp == 0x00 {
+		c.misuse(StackMisuse{Kind: StackOverflow, PC: c.instPC, SP: c.sp})
+	}
+	c.pushed[c.sp] = true
+}
+
+func (c *CPU) checkPull() {
+	m := StackMisuse{PC: c.instPC, SP: c.sp}
+	slot := c.sp + 1
+	switch {
+	case c.sp == 0xFF:
+		m.Kind = StackUnderflow
+	case !c.pushed[slot] || c.outsideFrame(slot):
+		m.Kind = StackUnpushed
+	default:
+		c.pushed[slot] = false
+		return
+	}
+	c.pushed[slot] = false
+	c.misuse(m)
+}
+
+func (c *CPU) misuse(m StackMisuse) {
+	if !c.misused {
+		c.misused = true
+		c.onStackMisuse(m)
+	}
+}
+
+// outsideFrame reports whether the stack slot belongs to the callers of the
+// innermost tracked frame, which only returning from it may pull.
+func (c *CPU) outsideFrame(slot byte) bool {
+	if !c.tracking || len(c.calls) == 0 || c.op == rtsImpliedOpcode || c.op == rtiImpliedOpcode {
+		return false
+	}
+	f := c.calls[len(c.calls)-1]
+	// The frame starts below the return address, and the status register
+	// of interrupts.
+	top := f.SP - 2
+	if f.Kind != FrameCall {
+		top--
+	}
+	return slot > top
+}
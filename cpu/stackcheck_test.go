@@ -0,0 +1,92 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestCheckStack(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(mem *memory.Memory, c *CPU)
+		kind  StackMisuseKind
+		sp    byte
+	}{
+		{
+			name: "overflow",
+			setup: func(mem *memory.Memory, c *CPU) {
+				mem.Write(byte(jsrAbsoluteOpcode), defaultPC)
+				c.SetRegisters(Registers{PC: defaultPC, SP: 0x01})
+			},
+			kind: StackOverflow,
+			sp:   0x00,
+		},
+		{
+			name: "underflow",
+			setup: func(mem *memory.Memory, c *CPU) {
+				mem.Write(byte(rtsImpliedOpcode), defaultPC)
+			},
+			kind: StackUnderflow,
+			sp:   0xFF,
+		},
+		{
+			name: "never pushed",
+			setup: func(mem *memory.Memory, c *CPU) {
+				mem.Write(byte(rtsImpliedOpcode), defaultPC)
+				c.sp = defaultSP - 2
+			},
+			kind: StackUnpushed,
+			sp:   defaultSP - 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := memory.Memory{}
+			c := New(&mem)
+			c.Reset()
+			var misuses []StackMisuse
+			c.CheckStack(func(m StackMisuse) { misuses = append(misuses, m) })
+			tt.setup(&mem, c)
+			stepTestHelper(t, c, 1)
+
+			expected := StackMisuse{Kind: tt.kind, PC: defaultPC, SP: tt.sp}
+			if len(misuses) != 1 || misuses[0] != expected {
+				t.Errorf("expected %+v, actual %+v\n", expected, misuses)
+			}
+		})
+	}
+}
+
+func TestCheckStackBalanced(t *testing.T) {
+	mem := memory.Memory{}
+	c := newCallsTestCPU(&mem)
+	c.TrackCalls(nil)
+	var misuses []StackMisuse
+	c.CheckStack(func(m StackMisuse) { misuses = append(misuses, m) })
+
+	stepTestHelper(t, c, 4)
+
+	if len(misuses) != 0 {
+		t.Errorf("expected no misuse, actual %+v\n", misuses)
+	}
+}
+
+func TestCheckStackOutsideFrame(t *testing.T) {
+	mem := memory.Memory{}
+	c := newCallsTestCPU(&mem)
+	c.TrackCalls(nil)
+	var misuses []StackMisuse
+	c.CheckStack(func(m StackMisuse) { misuses = append(misuses, m) })
+	stepTestHelper(t, c, 1)
+
+	// A pull other than a return takes the return address of the frame.
+	c.op = ldaImmediateOpcode
+	c.pull()
+
+	expected := StackMisuse{Kind: StackUnpushed, PC: defaultPC, SP: defaultSP - 2}
+	if len(misuses) != 1 || misuses[0] != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, misuses)
+	}
+}
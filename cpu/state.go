@@ -0,0 +1,27 @@
+package cpu
+
+// State is the complete state of the CPU between instructions, for
+// savestates.
+type State struct {
+	Registers
+	Cycles uint
+	// Stall is the number of cycles the CPU still has to sit idle, see
+	// Stall.
+	Stall uint
+}
+
+// State returns the current state of the CPU.
+func (c *CPU) State() State {
+	return State{Registers: c.Registers(), Cycles: c.cycles, Stall: c.stall}
+}
+
+// SetState restores a state returned by State. The call stack is emptied and
+// the bytes above SP are taken as pushed, as the history of the stack is not
+// part of the state.
+func (c *CPU) SetState(s State) {
+	c.SetRegisters(s.Registers)
+	c.cycles = s.Cycles
+	c.stall = s.Stall
+	c.calls = c.calls[:0]
+	c.resetPushed()
+}
@@ -0,0 +1,34 @@
+package cpu
+
+// StepOver executes the instruction at PC like Step, except that a JSR runs
+// at full speed until the subroutine returns to the instruction after it,
+// with SP back where it was. Breakpoints, Stop and errors end the subroutine
+// early, as they end Run.
+func (c *CPU) StepOver() (StopReason, error) {
+	call := c.peek(c.pc) == byte(jsrAbsoluteOpcode)
+	ret, sp := c.pc+jsrAbsoluteBytes, c.sp
+	if err := c.Step(); err != nil {
+		return StopFault, err
+	}
+	if !call {
+		return StopStepped, nil
+	}
+	return c.run(func() bool { return c.pc == ret && c.sp == sp }, StopStepped)
+}
+
+// StepOut runs the CPU at full speed until the current subroutine returns,
+// that is until an RTS pulls its return address from above the stack frame
+// the CPU is in. Breakpoints, Stop and errors end the run early, as they end
+// Run.
+func (c *CPU) StepOut() (StopReason, error) {
+	sp := c.sp
+	returned := func() bool {
+		// The stack grows down: the return address is above SP, and pulling
+		// it leaves SP above where it was, modulo the page.
+		return c.peek(c.instPC) == byte(rtsImpliedOpcode) && int8(c.sp-sp) > 0
+	}
+	if err := c.Step(); err != nil {
+		return StopFault, err
+	}
+	return c.run(returned, StopStepped)
+}
@@ -0,0 +1,82 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newStepTestCPU returns a CPU about to run:
+//
+//	0200  JSR $0210
+//	0203  LDA #$01
+//	0205  .byte $02
+//	0210  LDA #$05
+//	0212  JSR $0220
+//	0215  RTS
+//	0220  LDA #$07
+//	0222  RTS
+func newStepTestCPU() *CPU {
+	mem := memory.Memory{}
+	for addr, b := range map[uint16][]byte{
+		0x0200: {0x20, 0x10, 0x02, 0xA9, 0x01, 0x02},
+		0x0210: {0xA9, 0x05, 0x20, 0x20, 0x02, 0x60},
+		0x0220: {0xA9, 0x07, 0x60},
+	} {
+		for i, v := range b {
+			mem.Write(v, addr+uint16(i))
+		}
+	}
+	c := New(&mem)
+	c.Reset()
+	return c
+}
+
+func TestStepOverRunsSubroutine(t *testing.T) {
+	c := newStepTestCPU()
+
+	reason, err := c.StepOver()
+
+	if reason != StopStepped || err != nil {
+		t.Fatalf("expected %v, actual %v (%v)", StopStepped, reason, err)
+	}
+	if c.pc != 0x0203 || c.sp != defaultSP || c.acc != 0x07 {
+		t.Errorf("expected to return to 0203, actual pc %04X sp %02X A %02X\n", c.pc, c.sp, c.acc)
+	}
+}
+
+func TestStepOverSingleInstruction(t *testing.T) {
+	c := newStepTestCPU()
+	c.pc = 0x0203
+
+	reason, err := c.StepOver()
+
+	if reason != StopStepped || err != nil || c.pc != 0x0205 {
+		t.Errorf("expected a single step to 0205, actual %v (%v) at %04X\n", reason, err, c.pc)
+	}
+}
+
+func TestStepOverStopsAtBreakpoint(t *testing.T) {
+	c := newStepTestCPU()
+	c.AddBreakpoint(0x0220)
+
+	reason, _ := c.StepOver()
+
+	if reason != StopBreakpoint || c.pc != 0x0220 {
+		t.Errorf("expected to stop at the breakpoint, actual %v at %04X\n", reason, c.pc)
+	}
+}
+
+func TestStepOutSkipsNestedReturns(t *testing.T) {
+	c := newStepTestCPU()
+	c.step()
+
+	reason, err := c.StepOut()
+
+	if reason != StopStepped || err != nil {
+		t.Fatalf("expected %v, actual %v (%v)", StopStepped, reason, err)
+	}
+	if c.pc != 0x0203 || c.sp != defaultSP {
+		t.Errorf("expected to return to 0203, actual pc %04X sp %02X\n", c.pc, c.sp)
+	}
+}
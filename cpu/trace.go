@@ -0,0 +1,56 @@
+package cpu
+
+// TraceEntry is an instruction executed by the CPU, with the registers as
+// they were before it ran: Registers.PC is the address of the instruction.
+type TraceEntry struct {
+	Registers
+	Opcode byte
+	// Operand is the raw operand, as read before the instruction ran: a
+	// byte, a word or a branch offset depending on the addressing mode.
+	Operand uint16
+	// Cycles is the cycle count of the CPU when the instruction started.
+	Cycles uint
+}
+
+// SetTraceSize makes the CPU keep the last n instructions it executes, so
+// that Trace can show how it got where it is, such as at an invalid opcode or
+// a bus fault. The instructions kept so far are dropped. A size of zero, the
+// default, turns tracing off.
+func (c *CPU) SetTraceSize(n int) {
+	c.trace = nil
+	c.traceNext = 0
+	if n > 0 {
+		c.trace = make([]TraceEntry, 0, n)
+	}
+}
+
+// Trace returns the instructions kept by tracing, the oldest first. The last
+// one is the instruction executed by the last step, including one that
+// faulted.
+func (c *CPU) Trace() []TraceEntry {
+	entries := make([]TraceEntry, 0, len(c.trace))
+	entries = append(entries, c.trace[c.traceNext:]...)
+	return append(entries, c.trace[:c.traceNext]...)
+}
+
+// record adds the instruction at PC to the trace. The instruction bytes are
+// peeked, so that recording does not disturb devices.
+func (c *CPU) record() {
+	e := TraceEntry{Registers: c.Registers(), Opcode: c.peek(c.pc), Cycles: c.cycles}
+	info := Opcode(e.Opcode)
+	if info.Documented() {
+		switch info.Bytes {
+		case 2:
+			e.Operand = uint16(c.peek(c.pc + 1))
+		case 3:
+			e.Operand = uint16(c.peek(c.pc+1)) | uint16(c.peek(c.pc+2))<<8
+		}
+	}
+
+	if len(c.trace) < cap(c.trace) {
+		c.trace = append(c.trace, e)
+		return
+	}
+	c.trace[c.traceNext] = e
+	c.traceNext = (c.traceNext + 1) % len(c.trace)
+}
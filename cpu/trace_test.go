@@ -0,0 +1,57 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func newTraceTestCPU(code ...byte) *CPU {
+	mem := memory.Memory{}
+	for i, b := range code {
+		mem.Write(b, defaultPC+uint16(i))
+	}
+	c := New(&mem)
+	c.Reset()
+	return c
+}
+
+func TestTraceKeepsLastInstructions(t *testing.T) {
+	c := newTraceTestCPU(0xA9, 0x01, 0xA9, 0x02, 0xA9, 0x03, 0x02)
+	c.SetTraceSize(2)
+
+	for range 3 {
+		if err := c.Step(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := c.Step(); err == nil {
+		t.Fatalf("expected invalid opcode error")
+	}
+
+	expected := []TraceEntry{
+		{Registers: Registers{A: 0x02, SP: defaultSP, PC: 0x0204, SR: defaultSR}, Opcode: 0xA9, Operand: 0x03, Cycles: 11},
+		{Registers: Registers{A: 0x03, SP: defaultSP, PC: 0x0206, SR: defaultSR}, Opcode: 0x02, Cycles: 13},
+	}
+	actual := c.Trace()
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d entries, actual %d", len(expected), len(actual))
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %+v, actual %+v\n", expected[i], actual[i])
+		}
+	}
+}
+
+func TestTraceOffByDefault(t *testing.T) {
+	c := newTraceTestCPU(0xA9, 0x01)
+
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if actual := c.Trace(); len(actual) != 0 {
+		t.Errorf("expected no entries, actual %+v\n", actual)
+	}
+}
@@ -0,0 +1,42 @@
+package cpu
+
+import "math/rand"
+
+// Unstable pins what differs between chips, or between power-ons of a chip,
+// so that traces can be reproduced. The zero value pins everything: A, X, Y
+// and the flags are zero at power on, as after Reset.
+type Unstable struct {
+	// Rand, if set, provides A, X, Y, SP and the flags at power on, undefined
+	// on a real chip.
+	Rand *rand.Rand
+	// Magic is the constant the unstable opcodes ANE ($8B) and LXA ($AB) OR
+	// into A, which varies between chips and with temperature. The opcodes
+	// are not implemented yet.
+	Magic byte
+}
+
+// Deterministic is the Unstable for tests comparing traces, with the magic
+// constant most chips use.
+var Deterministic = Unstable{Magic: 0xEE}
+
+// SetUnstable changes the values of what differs between chips.
+func (c *CPU) SetUnstable(u Unstable) {
+	c.unstable = u
+}
+
+// PowerOn puts the CPU in the state it is in after a power on: that of
+// Reset, with A, X, Y, SP and the flags but B and I taken from the Rand of its
+// Unstable, if any.
+func (c *CPU) PowerOn() {
+	c.Reset()
+	rng := c.unstable.Rand
+	if rng == nil {
+		return
+	}
+	c.acc = byte(rng.Intn(256))
+	c.x = byte(rng.Intn(256))
+	c.y = byte(rng.Intn(256))
+	c.sp = byte(rng.Intn(256))
+	c.sr = byte(rng.Intn(256))&^(breakSF|interruptSF) | c.sr
+	c.resetPushed()
+}
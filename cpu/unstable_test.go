@@ -0,0 +1,40 @@
+package cpu
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestPowerOnDeterministic(t *testing.T) {
+	c := New(&memory.Memory{})
+	c.SetUnstable(Deterministic)
+
+	c.PowerOn()
+
+	expected := Registers{SP: defaultSP, PC: defaultPC, SR: defaultSR}
+	if actual := c.Registers(); actual != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+}
+
+func TestPowerOnSeeded(t *testing.T) {
+	powerOn := func(seed int64) Registers {
+		c := New(&memory.Memory{})
+		c.SetUnstable(Unstable{Rand: rand.New(rand.NewSource(seed))})
+		c.PowerOn()
+		return c.Registers()
+	}
+
+	a, b := powerOn(1), powerOn(1)
+	if a != b {
+		t.Errorf("expected %+v, actual %+v\n", a, b)
+	}
+	if a.PC != defaultPC || a.SR&(unusedSF|breakSF|interruptSF) != unusedSF {
+		t.Errorf("unexpected power-on state %+v\n", a)
+	}
+	if a == powerOn(2) {
+		t.Errorf("expected different seeds to give different states, actual %+v\n", a)
+	}
+}
@@ -0,0 +1,84 @@
+package cpu
+
+// Access is a kind of memory access performed by the CPU.
+type Access byte
+
+const (
+	// AccessRead is a data read, including operand fetches.
+	AccessRead Access = 1 << iota
+	// AccessWrite is a data write.
+	AccessWrite
+	// AccessExecute is an opcode fetch.
+	AccessExecute
+
+	// AccessReadWrite matches any data access.
+	AccessReadWrite = AccessRead | AccessWrite
+	// AccessAny matches every access.
+	AccessAny = AccessRead | AccessWrite | AccessExecute
+)
+
+// WatchEvent describes an access that hit a watchpoint. For reads and opcode
+// fetches Old and New are both the value read.
+type WatchEvent struct {
+	Access Access
+	Addr   uint16
+	Old    byte
+	New    byte
+	// PC is the address of the instruction that performed the access.
+	PC uint16
+}
+
+// WatchFunc is called when a watchpoint is hit. It may call Stop to end the
+// current Run.
+type WatchFunc func(WatchEvent)
+
+type watchpoint struct {
+	id     int
+	start  uint16
+	end    uint16
+	access Access
+	fn     WatchFunc
+}
+
+// AddWatchpoint registers fn to be called on every access of the given kinds
+// to the addresses from start to end, inclusive. It returns an id that can be
+// passed to RemoveWatchpoint.
+func (c *CPU) AddWatchpoint(start, end uint16, access Access, fn WatchFunc) int {
+	id := 0
+	for _, w := range c.watchpoints {
+		id = max(id, w.id+1)
+	}
+	c.watchpoints = append(c.watchpoints, watchpoint{
+		id:     id,
+		start:  start,
+		end:    end,
+		access: access,
+		fn:     fn,
+	})
+	return id
+}
+
+// RemoveWatchpoint removes the watchpoint with the given id.
+func (c *CPU) RemoveWatchpoint(id int) {
+	for i, w := range c.watchpoints {
+		if w.id == id {
+			c.watchpoints = append(c.watchpoints[:i], c.watchpoints[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *CPU) checkWatchpoints(access Access, addr uint16, old, val byte) {
+	for _, w := range c.watchpoints {
+		if w.access&access == 0 || addr < w.start || addr > w.end {
+			continue
+		}
+		w.fn(WatchEvent{
+			Access: access,
+			Addr:   addr,
+			Old:    old,
+			New:    val,
+			PC:     c.instPC,
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func newWatchTestCPU() *CPU {
+	offset := unreservedMemoryAddressStart
+	mem := memory.Memory{}
+	mem.Write(byte(ldaImmediateOpcode), offset)
+	mem.Write(0x42, offset+1)
+	mem.Write(0x99, 0x10)
+
+	c := New(&mem)
+	c.Reset()
+	return c
+}
+
+func TestWatchpointOnExecuteAndRead(t *testing.T) {
+	c := newWatchTestCPU()
+	var events []WatchEvent
+	c.AddWatchpoint(defaultPC, defaultPC+1, AccessAny, func(e WatchEvent) {
+		events = append(events, e)
+	})
+
+	c.step()
+
+	expected := []WatchEvent{
+		{Access: AccessExecute, Addr: defaultPC, Old: 0xA9, New: 0xA9, PC: defaultPC},
+		{Access: AccessRead, Addr: defaultPC + 1, Old: 0x42, New: 0x42, PC: defaultPC},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %+v, actual %+v\n", expected, events)
+	}
+	for i := range expected {
+		if expected[i] != events[i] {
+			t.Errorf("expected %+v, actual %+v\n", expected[i], events[i])
+		}
+	}
+}
+
+func TestWatchpointOnWriteReportsOldValue(t *testing.T) {
+	c := newWatchTestCPU()
+	var actual WatchEvent
+	c.AddWatchpoint(0x10, 0x10, AccessWrite, func(e WatchEvent) {
+		actual = e
+	})
+
+	c.instPC = 0x1234
+	c.write(0x01, 0x10)
+
+	expected := WatchEvent{Access: AccessWrite, Addr: 0x10, Old: 0x99, New: 0x01, PC: 0x1234}
+	if expected != actual {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+}
+
+func TestWatchpointFiltersAccess(t *testing.T) {
+	c := newWatchTestCPU()
+	hits := 0
+	c.AddWatchpoint(0x0000, 0xFFFF, AccessWrite, func(WatchEvent) {
+		hits++
+	})
+
+	c.step()
+
+	if hits != 0 {
+		t.Errorf("expected 0 hits, actual %d\n", hits)
+	}
+}
+
+func TestRemoveWatchpoint(t *testing.T) {
+	c := newWatchTestCPU()
+	hits := 0
+	id := c.AddWatchpoint(0x0000, 0xFFFF, AccessAny, func(WatchEvent) {
+		hits++
+	})
+	c.RemoveWatchpoint(id)
+
+	c.step()
+
+	if hits != 0 {
+		t.Errorf("expected 0 hits, actual %d\n", hits)
+	}
+}
+
+func TestWatchpointStopsRun(t *testing.T) {
+	c := newWatchTestCPU()
+	c.AddWatchpoint(defaultPC+1, defaultPC+1, AccessRead, func(WatchEvent) {
+		c.Stop()
+	})
+
+	if reason, err := c.Run(); reason != StopRequested || err != nil {
+		t.Errorf("expected %v, actual %v (%v)\n", StopRequested, reason, err)
+	}
+	if c.pc != defaultPC+ldaImmediateBytes {
+		t.Errorf("expected pc %04X, actual %04X\n", defaultPC+ldaImmediateBytes, c.pc)
+	}
+}
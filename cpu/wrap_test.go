@@ -0,0 +1,87 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newWrapTest returns a CPU at pc, with code there, wrapping past $FFFF.
+func newWrapTest(pc uint16, code ...byte) (*CPU, *memory.Memory) {
+	mem := &memory.Memory{}
+	for i, b := range code {
+		mem.Write(b, pc+uint16(i))
+	}
+	c := New(mem)
+	c.Reset()
+	c.pc = pc
+	return c, mem
+}
+
+func wrapTestHelper(t *testing.T, c *CPU, expected Registers) {
+	t.Helper()
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual := c.Registers(); actual != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+}
+
+func TestPCWrapsAfterFFFF(t *testing.T) {
+	c, _ := newWrapTest(0xFFFF, byte(seiImpliedOpcode))
+
+	wrapTestHelper(t, c, Registers{SP: defaultSP, PC: 0x0000, SR: defaultSR | interruptSF})
+}
+
+func TestOperandWrapsToZero(t *testing.T) {
+	c, _ := newWrapTest(0xFFFF, byte(ldaImmediateOpcode), 0x42)
+
+	wrapTestHelper(t, c, Registers{A: 0x42, SP: defaultSP, PC: 0x0001, SR: defaultSR})
+}
+
+func TestJSROperandWrapsToZero(t *testing.T) {
+	c, mem := newWrapTest(0xFFFE, byte(jsrAbsoluteOpcode), 0x00, 0x03)
+
+	wrapTestHelper(t, c, Registers{SP: defaultSP - 2, PC: 0x0300, SR: defaultSR})
+	// The return address is that of the last byte of JSR, $0000.
+	if ret := memory.ReadWord(mem, 0x01FE); ret != 0x0000 {
+		t.Errorf("expected return address $0000, actual $%04X\n", ret)
+	}
+}
+
+func TestRTSWrapsPC(t *testing.T) {
+	c, mem := newWrapTest(defaultPC, byte(rtsImpliedOpcode))
+	memory.WriteWord(mem, 0xFFFF, 0x01FE)
+	c.sp = 0xFD
+
+	wrapTestHelper(t, c, Registers{SP: defaultSP, PC: 0x0000, SR: defaultSR})
+}
+
+func TestJSRWrapsStack(t *testing.T) {
+	c, mem := newWrapTest(defaultPC, byte(jsrAbsoluteOpcode), 0x00, 0x03)
+	c.sp = 0x00
+
+	wrapTestHelper(t, c, Registers{SP: 0xFE, PC: 0x0300, SR: defaultSR})
+	if hi, lo := mem.Read(0x0100), mem.Read(0x01FF); hi != 0x02 || lo != 0x02 {
+		t.Errorf("expected $02 at $0100 and $01FF, actual $%02X and $%02X\n", hi, lo)
+	}
+}
+
+func TestRTSWrapsStack(t *testing.T) {
+	c, mem := newWrapTest(defaultPC, byte(rtsImpliedOpcode))
+	mem.Write(0xFF, 0x0100)
+	mem.Write(0x02, 0x0101)
+	c.sp = 0xFF
+
+	wrapTestHelper(t, c, Registers{SP: 0x01, PC: 0x0300, SR: defaultSR})
+}
+
+func TestRTIWrapsStack(t *testing.T) {
+	c, mem := newWrapTest(defaultPC, byte(rtiImpliedOpcode))
+	mem.Write(carrySF, 0x01FF)
+	memory.WriteWord(mem, 0x0300, 0x0100)
+	c.sp = 0xFE
+
+	wrapTestHelper(t, c, Registers{SP: 0x01, PC: 0x0300, SR: unusedSF | carrySF})
+}
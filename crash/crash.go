@@ -0,0 +1,154 @@
+// Package crash writes crash reports: everything known about a machine
+// whose program failed, in a single text file to attach to bug reports.
+//
+// A report holds the error, the registers and flags, the disassembly from
+// PC, the stack, the call stack and the trace the CPU keeps, if any, and the
+// state of the devices.
+package crash
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/leakedmemory/mos6502/disasm"
+	"github.com/leakedmemory/mos6502/memory"
+	"github.com/leakedmemory/mos6502/savestate"
+)
+
+const (
+	disasmLines  = 8
+	stackPage    = 0x0100
+	stackTop     = 0x01FF
+	stackRowSize = 8
+	stateRowSize = 16
+)
+
+// Report is the crash of the program of a machine.
+type Report struct {
+	// Err is the error the program stopped with.
+	Err     error
+	Machine *savestate.Machine
+	// Symbols, if not nil, names the addresses in the disassembly.
+	Symbols memory.Labeler
+}
+
+// Write writes the report to w.
+func (r *Report) Write(w io.Writer) error {
+	var b bytes.Buffer
+	p := &disasm.Printer{Symbols: r.Symbols}
+	c := r.Machine.CPU
+	regs := c.Registers()
+
+	b.WriteString("mos6502 crash report\n\n")
+	fmt.Fprintf(&b, "error: %v\n", r.Err)
+
+	b.WriteString("\nregisters\n")
+	fmt.Fprintf(&b, "  PC $%04X  SP $%02X  A $%02X  X $%02X  Y $%02X  SR $%02X\n",
+		regs.PC, regs.SP, regs.A, regs.X, regs.Y, regs.SR)
+	fmt.Fprintf(&b, "  NV-BDIZC\n  %08b\n", regs.SR)
+	fmt.Fprintf(&b, "  cycles %d\n", c.Cycles())
+
+	b.WriteString("\ndisassembly\n")
+	addr := regs.PC
+	for i := range disasmLines {
+		inst := disasm.Decode(r.Machine.Memory, addr)
+		marker := "  "
+		if i == 0 {
+			marker = "> "
+		}
+		b.WriteString(marker + p.Line(inst) + "\n")
+		addr += inst.Len()
+	}
+
+	b.WriteString("\nstack\n")
+	r.writeStack(&b, regs.SP)
+
+	b.WriteString("\ncall stack\n")
+	frames := c.CallStack()
+	for i := len(frames) - 1; i >= 0; i-- {
+		f := frames[i]
+		fmt.Fprintf(&b, "  %v $%04X from $%04X, SP $%02X\n", f.Kind, f.Target, f.Site, f.SP)
+	}
+	if len(frames) == 0 {
+		b.WriteString("  (not tracked or empty)\n")
+	}
+
+	b.WriteString("\ntrace\n")
+	if trace := c.Trace(); len(trace) != 0 {
+		// Writing to a buffer does not fail.
+		_ = p.Trace(&b, trace)
+	} else {
+		b.WriteString("  (not kept)\n")
+	}
+
+	b.WriteString("\ndevices\n")
+	if err := r.writeDevices(&b); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("crash: writing report: %w", err)
+	}
+	return nil
+}
+
+// writeStack dumps the bytes above SP, the most recently pushed first.
+func (r *Report) writeStack(b *bytes.Buffer, sp byte) {
+	if sp == 0xFF {
+		b.WriteString("  (empty)\n")
+		return
+	}
+	for addr := stackPage + int(sp) + 1; addr <= stackTop; addr += stackRowSize {
+		fmt.Fprintf(b, "  %04X:", addr)
+		for a := addr; a < min(addr+stackRowSize, stackTop+1); a++ {
+			fmt.Fprintf(b, " %02X", r.Machine.Memory.Read(uint16(a)))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// writeDevices writes the state of the devices, as their String method
+// shows it or as a hex dump of their saved state.
+func (r *Report) writeDevices(b *bytes.Buffer) error {
+	if len(r.Machine.Devices) == 0 {
+		b.WriteString("  (none)\n")
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(r.Machine.Devices)) {
+		d := r.Machine.Devices[name]
+		if s, ok := d.(fmt.Stringer); ok {
+			fmt.Fprintf(b, "  %s: %s\n", name, s)
+			continue
+		}
+		state, err := d.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("crash: saving device %s: %w", name, err)
+		}
+		fmt.Fprintf(b, "  %s: %d bytes\n", name, len(state))
+		for off := 0; off < len(state); off += stateRowSize {
+			row := state[off:min(off+stateRowSize, len(state))]
+			fmt.Fprintf(b, "    %04X: % X\n", off, row)
+		}
+	}
+	return nil
+}
+
+// WriteFile writes the report to the file at path.
+func (r *Report) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("crash: %w", err)
+	}
+	if err := r.Write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("crash: %w", err)
+	}
+	return nil
+}
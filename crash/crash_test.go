@@ -0,0 +1,121 @@
+package crash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+	"github.com/leakedmemory/mos6502/savestate"
+)
+
+type testDevice struct {
+	state []byte
+}
+
+func (d *testDevice) MarshalBinary() ([]byte, error) {
+	return d.state, nil
+}
+
+func (d *testDevice) UnmarshalBinary(data []byte) error {
+	d.state = data
+	return nil
+}
+
+type stringDevice struct {
+	testDevice
+}
+
+func (d *stringDevice) String() string {
+	return "timer 1234"
+}
+
+// newCrashTestMachine returns a machine whose program crashed on the invalid
+// opcode at $0212, in a subroutine called from $0200.
+func newCrashTestMachine(t *testing.T) (*savestate.Machine, error) {
+	t.Helper()
+	mem := &memory.Memory{}
+	for addr, b := range map[uint16][]byte{
+		0x0200: {0x20, 0x10, 0x02},
+		0x0210: {0xA9, 0x07, 0x02},
+	} {
+		for i, v := range b {
+			mem.Write(v, addr+uint16(i))
+		}
+	}
+	c := cpu.New(mem)
+	c.Reset()
+	c.SetTraceSize(4)
+	c.TrackCalls(nil)
+	_, err := c.Run()
+	if err == nil {
+		t.Fatalf("expected the program to crash")
+	}
+	return &savestate.Machine{
+		CPU:    c,
+		Memory: mem,
+		Devices: map[string]savestate.Device{
+			"ram":   &testDevice{state: []byte{1, 2, 3}},
+			"timer": &stringDevice{},
+		},
+	}, err
+}
+
+func TestWrite(t *testing.T) {
+	m, err := newCrashTestMachine(t)
+	r := &Report{Err: err, Machine: m}
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"error: " + err.Error(),
+		"PC $0212  SP $FD  A $07",
+		"> 0212  02        .byte $02",
+		"01FE: 02 02",
+		"JSR $0210 from $0200, SP $FF",
+		"0210  A9 07     LDA #$07         A=00",
+		"ram: 3 bytes\n    0000: 01 02 03",
+		"timer: timer 1234",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in the report\n%s\n", expected, b.String())
+		}
+	}
+}
+
+type failingDevice struct {
+	testDevice
+}
+
+var errTestDevice = errors.New("device failed")
+
+func (d *failingDevice) MarshalBinary() ([]byte, error) {
+	return nil, errTestDevice
+}
+
+func TestWriteFile(t *testing.T) {
+	m, err := newCrashTestMachine(t)
+	path := filepath.Join(t.TempDir(), "crash.txt")
+
+	if err := (&Report{Err: err, Machine: m}).WriteFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		t.Fatalf("unexpected error: %v", readErr)
+	}
+	if !strings.HasPrefix(string(data), "mos6502 crash report\n") {
+		t.Errorf("expected a report, actual %q\n", data)
+	}
+
+	m.Devices["bad"] = &failingDevice{}
+	if err := (&Report{Err: err, Machine: m}).WriteFile(path); !errors.Is(err, errTestDevice) {
+		t.Errorf("expected %v, actual %v\n", errTestDevice, err)
+	}
+}
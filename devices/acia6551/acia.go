@@ -0,0 +1,280 @@
+// Package acia6551 emulates the 6551 Asynchronous Communications Interface
+// Adapter: its data, status, command and control registers, with the serial
+// line connected to the host, such as its terminal or a pseudo-terminal.
+//
+// The ACIA occupies 4 addresses, mirrored across larger regions, and moves
+// characters when the cycles of the CPU are given to Tick, by a
+// devices.Clock:
+//
+//	a := acia6551.New(c, os.Stdin, os.Stdout, acia6551.Options{})
+//	b.Map(0x5000, 0x5003, a)
+//	devices.NewClock(c, a)
+package acia6551
+
+import (
+	"io"
+
+	"github.com/leakedmemory/mos6502/devices"
+)
+
+// Registers, by their offset.
+const (
+	regData = iota
+	regStatus
+	regCommand
+	regControl
+)
+
+// Bits of the status register.
+const (
+	StatusParity  byte = 0x01
+	StatusFraming byte = 0x02
+	StatusOverrun byte = 0x04
+	// StatusRDRF is set while the receive data register holds a character.
+	StatusRDRF byte = 0x08
+	// StatusTDRE is set while the transmit data register is empty.
+	StatusTDRE byte = 0x10
+	StatusDCD  byte = 0x20
+	StatusDSR  byte = 0x40
+	StatusIRQ  byte = 0x80
+)
+
+// Bits of the command register.
+const (
+	cmdDTR       byte = 0x01
+	cmdRxIRQOff  byte = 0x02
+	cmdTxMask    byte = 0x0C
+	cmdTxIRQ     byte = 0x04
+	cmdEcho      byte = 0x10
+	cmdParity    byte = 0x20
+	cmdResetMask byte = 0x1F
+)
+
+// Bits of the control register.
+const (
+	ctrlBaudMask byte = 0x0F
+	ctrlWordMask byte = 0x60
+	ctrlTwoStops byte = 0x80
+)
+
+const (
+	defaultClockHz = 1_000_000
+	// inputBuffer is the number of characters read from the host ahead of
+	// the program.
+	inputBuffer = 256
+)
+
+// baudRates are the rates selected by the low bits of the control register.
+// Rate 0, the external clock, is taken as 115200 bauds.
+var baudRates = [16]float64{115200, 50, 75, 109.92, 134.58, 150, 300, 600, 1200, 1800, 2400, 3600, 4800, 7200, 9600, 19200}
+
+// Options configures the pacing of the ACIA.
+type Options struct {
+	// Paced makes characters take as long as at the baud rate of the control
+	// register to be sent and received. Otherwise they move at once.
+	Paced bool
+	// ClockHz is the clock rate of the CPU, 1 MHz if zero, which paces the
+	// characters.
+	ClockHz uint
+}
+
+// ACIA is a 6551. Its zero value is not usable: use New.
+type ACIA struct {
+	irq      devices.IRQLine
+	asserted bool
+	opts     Options
+
+	in  chan byte
+	out io.Writer
+	err error
+
+	rx, tx                   byte
+	status, command, control byte
+	// txLeft is the number of cycles until tx is sent, while the transmit
+	// data register is full, and rxLeft until another character can be
+	// received.
+	txLeft, rxLeft uint
+}
+
+// New returns an ACIA asserting irq, if not nil, receiving what is read from
+// r, if not nil, and sending to w, if not nil. It reads r in a goroutine
+// until it fails, such as at the end of a file. Characters are received only
+// once the program has read the previous one, so none is lost.
+func New(irq devices.IRQLine, r io.Reader, w io.Writer, opts Options) *ACIA {
+	if opts.ClockHz == 0 {
+		opts.ClockHz = defaultClockHz
+	}
+	a := &ACIA{irq: irq, opts: opts, out: w}
+	if r != nil {
+		a.in = make(chan byte, inputBuffer)
+		go a.read(r)
+	}
+	a.Reset()
+	return a
+}
+
+func (a *ACIA) read(r io.Reader) {
+	defer close(a.in)
+	buf := make([]byte, inputBuffer)
+	for {
+		n, err := r.Read(buf)
+		for _, b := range buf[:n] {
+			a.in <- b
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Reset clears the registers, like a low level on the RES pin.
+func (a *ACIA) Reset() {
+	a.status = StatusTDRE
+	a.command = cmdRxIRQOff
+	a.control = 0
+	a.txLeft, a.rxLeft = 0, 0
+	a.updateIRQ()
+}
+
+// Err returns the first error writing to the host, if any.
+func (a *ACIA) Err() error {
+	return a.err
+}
+
+// Read returns the register at addr. Reading the data clears StatusRDRF and
+// the errors, and reading the status clears StatusIRQ.
+func (a *ACIA) Read(addr uint16) byte {
+	val := a.Peek(addr)
+	switch addr & 0x03 {
+	case regData:
+		a.status &^= StatusRDRF | StatusOverrun | StatusFraming | StatusParity
+	case regStatus:
+		a.status &^= StatusIRQ
+		a.updateIRQ()
+	}
+	return val
+}
+
+// Peek returns the register at addr without side effects.
+func (a *ACIA) Peek(addr uint16) byte {
+	switch addr & 0x03 {
+	case regData:
+		return a.rx
+	case regStatus:
+		return a.status
+	case regCommand:
+		return a.command
+	default:
+		return a.control
+	}
+}
+
+// Write changes the register at addr to val. Writing the status register
+// makes a programmed reset.
+func (a *ACIA) Write(val byte, addr uint16) {
+	switch addr & 0x03 {
+	case regData:
+		a.tx = val
+		a.status &^= StatusTDRE
+		a.txLeft = a.characterCycles()
+		if a.txLeft == 0 {
+			a.transmit()
+		}
+	case regStatus:
+		a.command = a.command&^cmdResetMask | cmdRxIRQOff
+		a.status &^= StatusOverrun
+	case regCommand:
+		a.command = val
+	case regControl:
+		a.control = val
+	}
+	a.updateIRQ()
+}
+
+// Tick counts cycles of the CPU, sending and receiving characters.
+func (a *ACIA) Tick(cycles uint) {
+	if a.status&StatusTDRE == 0 {
+		a.txLeft -= min(cycles, a.txLeft)
+		if a.txLeft == 0 {
+			a.transmit()
+		}
+	}
+	a.rxLeft -= min(cycles, a.rxLeft)
+	if a.rxLeft == 0 && a.status&StatusRDRF == 0 && a.command&cmdDTR != 0 {
+		a.receive()
+	}
+	a.updateIRQ()
+}
+
+// transmit sends the transmit data register, once the transmitter is on.
+// Until then, it stays full.
+func (a *ACIA) transmit() {
+	if a.command&cmdTxMask == 0 {
+		return
+	}
+	a.send(a.tx)
+	a.status |= StatusTDRE
+	if a.command&cmdTxMask == cmdTxIRQ {
+		a.status |= StatusIRQ
+	}
+}
+
+// receive takes the next character from the host, if any.
+func (a *ACIA) receive() {
+	if a.in == nil {
+		return
+	}
+	select {
+	case b, ok := <-a.in:
+		if !ok {
+			a.in = nil
+			return
+		}
+		a.rx = b
+		a.status |= StatusRDRF
+		a.rxLeft = a.characterCycles()
+		if a.command&cmdRxIRQOff == 0 {
+			a.status |= StatusIRQ
+		}
+		if a.command&cmdEcho != 0 {
+			a.send(b)
+		}
+	default:
+	}
+}
+
+func (a *ACIA) send(b byte) {
+	if a.out == nil || a.err != nil {
+		return
+	}
+	if _, err := a.out.Write([]byte{b}); err != nil {
+		a.err = err
+	}
+}
+
+// characterCycles returns the number of cycles a character takes at the
+// baud rate, with its start, parity and stop bits, or 0 if not paced.
+func (a *ACIA) characterCycles() uint {
+	if !a.opts.Paced {
+		return 0
+	}
+	bits := 1 + 8 - int(a.control&ctrlWordMask>>5) + 1
+	if a.command&cmdParity != 0 {
+		bits++
+	}
+	if a.control&ctrlTwoStops != 0 {
+		bits++
+	}
+	return uint(float64(bits) * float64(a.opts.ClockHz) / baudRates[a.control&ctrlBaudMask])
+}
+
+// updateIRQ drives the IRQ output from StatusIRQ.
+func (a *ACIA) updateIRQ() {
+	asserted := a.status&StatusIRQ != 0
+	if asserted != a.asserted {
+		a.asserted = asserted
+		if a.irq != nil {
+			a.irq.SetIRQ(asserted)
+		}
+	}
+}
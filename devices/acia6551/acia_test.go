@@ -0,0 +1,241 @@
+package acia6551
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type testIRQ struct {
+	asserted bool
+}
+
+func (l *testIRQ) SetIRQ(asserted bool) {
+	l.asserted = asserted
+}
+
+// waitRDRF ticks a until it receives a character from its goroutine reading
+// the host.
+func waitRDRF(t *testing.T, a *ACIA) {
+	t.Helper()
+	for range 1_000_000 {
+		a.Tick(1)
+		if a.Peek(regStatus)&StatusRDRF != 0 {
+			return
+		}
+		runtime.Gosched()
+	}
+	t.Fatalf("no character received")
+}
+
+func TestACIATransmit(t *testing.T) {
+	var out bytes.Buffer
+	a := New(nil, nil, &out, Options{})
+	a.Write(0x0B, regCommand)
+	a.Write(0x1F, regControl)
+
+	for _, c := range []byte("Hi") {
+		a.Write(c, regData)
+	}
+
+	if out.String() != "Hi" {
+		t.Errorf("expected %q, actual %q\n", "Hi", out.String())
+	}
+	if status := a.Read(regStatus); status&StatusTDRE == 0 {
+		t.Errorf("expected the transmit data register empty, actual status $%02X\n", status)
+	}
+}
+
+func TestACIATransmitterOff(t *testing.T) {
+	var out bytes.Buffer
+	a := New(nil, nil, &out, Options{})
+
+	a.Write('X', regData)
+
+	if out.Len() != 0 {
+		t.Errorf("expected nothing sent, actual %q\n", out.String())
+	}
+	if status := a.Read(regStatus); status&StatusTDRE != 0 {
+		t.Errorf("expected the transmit data register full, actual status $%02X\n", status)
+	}
+
+	a.Write(0x0B, regCommand)
+	a.Tick(1)
+	if out.String() != "X" {
+		t.Errorf("expected %q, actual %q\n", "X", out.String())
+	}
+}
+
+func TestACIATransmitIRQ(t *testing.T) {
+	irq := &testIRQ{}
+	a := New(irq, nil, &bytes.Buffer{}, Options{})
+	a.Write(0x07, regCommand)
+
+	a.Write('X', regData)
+	if !irq.asserted {
+		t.Fatalf("expected the IRQ asserted once the character is sent")
+	}
+	if status := a.Read(regStatus); status&StatusIRQ == 0 {
+		t.Errorf("expected the IRQ flag, actual status $%02X\n", status)
+	}
+	if irq.asserted {
+		t.Errorf("expected the IRQ released by reading the status")
+	}
+}
+
+func TestACIAReceive(t *testing.T) {
+	irq := &testIRQ{}
+	a := New(irq, strings.NewReader("AB"), nil, Options{})
+	a.Write(0x09, regCommand)
+
+	for _, expected := range []byte("AB") {
+		waitRDRF(t, a)
+		if !irq.asserted {
+			t.Errorf("expected the IRQ asserted on receiving %q", expected)
+		}
+		a.Read(regStatus)
+		if irq.asserted {
+			t.Errorf("expected the IRQ released by reading the status")
+		}
+		if actual := a.Read(regData); actual != expected {
+			t.Errorf("expected %q, actual %q\n", expected, actual)
+		}
+		if status := a.Peek(regStatus); status&StatusRDRF != 0 {
+			t.Errorf("expected the receive data register empty, actual status $%02X\n", status)
+		}
+	}
+}
+
+func TestACIAReceiveNeedsDTR(t *testing.T) {
+	a := New(nil, strings.NewReader("A"), nil, Options{})
+	a.Write(0x02, regCommand)
+
+	for range 1000 {
+		a.Tick(1)
+		runtime.Gosched()
+	}
+	if status := a.Peek(regStatus); status&StatusRDRF != 0 {
+		t.Errorf("expected nothing received with DTR off, actual status $%02X\n", status)
+	}
+
+	a.Write(0x0B, regCommand)
+	waitRDRF(t, a)
+	if actual := a.Read(regData); actual != 'A' {
+		t.Errorf("expected %q, actual %q\n", 'A', actual)
+	}
+}
+
+func TestACIAEcho(t *testing.T) {
+	var out bytes.Buffer
+	a := New(nil, strings.NewReader("e"), &out, Options{})
+	a.Write(0x1B, regCommand)
+
+	waitRDRF(t, a)
+
+	if out.String() != "e" {
+		t.Errorf("expected %q echoed, actual %q\n", "e", out.String())
+	}
+}
+
+// TestACIAPaced sends a character at 9600 bauds, 8 bits and 1 stop bit: 10
+// bits at 1 MHz take 1041 cycles.
+func TestACIAPaced(t *testing.T) {
+	var out bytes.Buffer
+	a := New(nil, nil, &out, Options{Paced: true, ClockHz: 1_000_000})
+	a.Write(0x0B, regCommand)
+	a.Write(0x1E, regControl)
+
+	a.Write('P', regData)
+	a.Tick(1040)
+	if out.Len() != 0 || a.Peek(regStatus)&StatusTDRE != 0 {
+		t.Fatalf("expected the character still being sent, actual %q\n", out.String())
+	}
+	a.Tick(1)
+	if out.String() != "P" || a.Peek(regStatus)&StatusTDRE == 0 {
+		t.Errorf("expected the character sent, actual %q\n", out.String())
+	}
+}
+
+func TestACIACharacterCycles(t *testing.T) {
+	tests := []struct {
+		control, command byte
+		expected         uint
+	}{
+		{0x1E, 0x0B, 1041},
+		{0x1F, 0x0B, 520},
+		{0x16, 0x0B, 33333},
+		{0x9E, 0x0B, 1145},
+		{0x9E, 0x2B, 1250},
+		{0x7E, 0x0B, 729},
+	}
+
+	for _, test := range tests {
+		a := New(nil, nil, nil, Options{Paced: true})
+		a.Write(test.command, regCommand)
+		a.Write(test.control, regControl)
+		if actual := a.characterCycles(); actual != test.expected {
+			t.Errorf("control $%02X command $%02X: expected %d, actual %d\n", test.control, test.command, test.expected, actual)
+		}
+	}
+}
+
+func TestACIAProgrammedReset(t *testing.T) {
+	a := New(nil, nil, nil, Options{})
+	a.Write(0xFF, regCommand)
+	a.Write(0x1E, regControl)
+
+	a.Write(0x00, regStatus)
+
+	if actual := a.Read(regCommand); actual != 0xE2 {
+		t.Errorf("expected command $E2, actual $%02X\n", actual)
+	}
+	if actual := a.Read(regControl); actual != 0x1E {
+		t.Errorf("expected control $1E, actual $%02X\n", actual)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, os.ErrClosed
+}
+
+func TestACIAWriteError(t *testing.T) {
+	a := New(nil, nil, failingWriter{}, Options{})
+	a.Write(0x0B, regCommand)
+
+	a.Write('X', regData)
+
+	if !errors.Is(a.Err(), os.ErrClosed) {
+		t.Errorf("expected %v, actual %v\n", os.ErrClosed, a.Err())
+	}
+}
+
+func TestACIAPTY(t *testing.T) {
+	m, name, err := OpenPTY()
+	if errors.Is(err, ErrNoPTY) {
+		t.Skipf("%v", err)
+	}
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	defer m.Close()
+	s, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	a := New(nil, m, m, Options{})
+	a.Write(0x0B, regCommand)
+	if _, err := s.Write([]byte("k")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitRDRF(t, a)
+	if actual := a.Read(regData); actual != 'k' {
+		t.Errorf("expected %q, actual %q\n", 'k', actual)
+	}
+}
@@ -0,0 +1,21 @@
+package acia6551
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrNoPTY is returned by OpenPTY where pseudo-terminals are not supported.
+var ErrNoPTY = errors.New("acia6551: pseudo-terminals are not supported")
+
+// OpenPTY opens a pseudo-terminal, returning its master, to give to New as
+// the serial line, and the name of its slave, such as /dev/pts/3, for a
+// terminal program to open:
+//
+//	m, name, err := acia6551.OpenPTY()
+//	...
+//	a := acia6551.New(c, m, m, acia6551.Options{Paced: true})
+//	fmt.Println("screen", name)
+func OpenPTY() (*os.File, string, error) {
+	return openPTY()
+}
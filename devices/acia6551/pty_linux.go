@@ -0,0 +1,30 @@
+//go:build linux
+
+package acia6551
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/leakedmemory/mos6502/internal/term"
+)
+
+func openPTY() (*os.File, string, error) {
+	m, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, "", fmt.Errorf("acia6551: opening pseudo-terminal: %w", err)
+	}
+	var unlock int32
+	if err := term.Ioctl(m, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		m.Close()
+		return nil, "", fmt.Errorf("acia6551: unlocking pseudo-terminal: %w", err)
+	}
+	var n uint32
+	if err := term.Ioctl(m, syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		m.Close()
+		return nil, "", fmt.Errorf("acia6551: naming pseudo-terminal: %w", err)
+	}
+	return m, fmt.Sprintf("/dev/pts/%d", n), nil
+}
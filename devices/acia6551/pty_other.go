@@ -0,0 +1,9 @@
+//go:build !linux
+
+package acia6551
+
+import "os"
+
+func openPTY() (*os.File, string, error) {
+	return nil, "", ErrNoPTY
+}
@@ -0,0 +1,197 @@
+// Package blockdev emulates a simple block storage device, not modeled on
+// any chip: sectors of a file of the host, such as a disk image, read into
+// and written from a buffer in the address space, so that programs have
+// persistent storage.
+//
+// The device occupies 8 addresses, mirrored across larger regions, and
+// moves the sectors over the bus as a command is written, like a DMA
+// controller, without holding the CPU:
+//
+//	f, err := os.OpenFile("disk.img", os.O_RDWR|os.O_CREATE, 0o644)
+//	...
+//	d := blockdev.New(b, f)
+//	b.Map(0xD200, 0xD207, d)
+//
+// A program reads a sector by writing its number and the address of the
+// buffer, then the command:
+//
+//	LDA #$05
+//	STA $D200   ; sector 5
+//	LDA #$00
+//	STA $D201
+//	STA $D202   ; buffer $0400
+//	LDA #$04
+//	STA $D203
+//	LDA #$01    ; read
+//	STA $D204
+//	LDA $D205   ; status
+package blockdev
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/leakedmemory/mos6502/bus"
+)
+
+// SectorSize is the size of a sector, and of the buffer it is moved to or
+// from.
+const SectorSize = 512
+
+// Registers, by their offset.
+const (
+	// RegSectorLow and RegSectorHigh hold the number of the sector.
+	RegSectorLow = iota
+	RegSectorHigh
+	// RegBufferLow and RegBufferHigh hold the address of the buffer.
+	RegBufferLow
+	RegBufferHigh
+	// RegCommand runs the command written to it.
+	RegCommand
+	// RegStatus reads the Status bits of the last command.
+	RegStatus
+)
+
+// Commands.
+const (
+	// CmdRead reads the sector into the buffer.
+	CmdRead byte = 0x01
+	// CmdWrite writes the buffer to the sector.
+	CmdWrite byte = 0x02
+	// CmdSync commits the sectors written to the storage of the host, if it
+	// supports it.
+	CmdSync byte = 0x03
+)
+
+// Bits of the status register.
+const (
+	// StatusError is set if the last command failed, such as for an unknown
+	// command or an error of the host.
+	StatusError byte = 0x01
+	// StatusReadOnly is set, with StatusError, if the last command was a
+	// write to storage that can not be written.
+	StatusReadOnly byte = 0x02
+	// StatusDone is set once a command was run.
+	StatusDone byte = 0x80
+)
+
+var (
+	// ErrCommand is returned by Err for an unknown command.
+	ErrCommand = errors.New("blockdev: unknown command")
+	// ErrReadOnly is returned by Err for a write to read-only storage.
+	ErrReadOnly = errors.New("blockdev: read-only storage")
+)
+
+// Storage is what the sectors are stored in, such as an *os.File. If it
+// does not implement io.WriterAt, or its writes fail for lack of permission,
+// such as those to a file opened read-only, it is read-only. Reading past
+// its end reads zeros, and writing past it extends it.
+type Storage interface {
+	io.ReaderAt
+}
+
+// BlockDev is a block storage device. Its zero value is not usable: use New.
+type BlockDev struct {
+	bus     *bus.Bus
+	storage Storage
+	err     error
+
+	sector, buffer uint16
+	status         byte
+}
+
+// New returns a BlockDev moving the sectors of s over b.
+func New(b *bus.Bus, s Storage) *BlockDev {
+	return &BlockDev{bus: b, storage: s}
+}
+
+// Reset clears the registers.
+func (d *BlockDev) Reset() {
+	d.sector, d.buffer, d.status = 0, 0, 0
+}
+
+// Err returns the error of the last command that failed, if any.
+func (d *BlockDev) Err() error {
+	return d.err
+}
+
+// Read returns the register at addr.
+func (d *BlockDev) Read(addr uint16) byte {
+	switch addr & 0x07 {
+	case RegSectorLow:
+		return byte(d.sector)
+	case RegSectorHigh:
+		return byte(d.sector >> 8)
+	case RegBufferLow:
+		return byte(d.buffer)
+	case RegBufferHigh:
+		return byte(d.buffer >> 8)
+	case RegStatus:
+		return d.status
+	default:
+		return 0
+	}
+}
+
+// Write changes the register at addr to val. Writing RegCommand runs the
+// command val.
+func (d *BlockDev) Write(val byte, addr uint16) {
+	switch addr & 0x07 {
+	case RegSectorLow:
+		d.sector = d.sector&0xFF00 | uint16(val)
+	case RegSectorHigh:
+		d.sector = d.sector&0x00FF | uint16(val)<<8
+	case RegBufferLow:
+		d.buffer = d.buffer&0xFF00 | uint16(val)
+	case RegBufferHigh:
+		d.buffer = d.buffer&0x00FF | uint16(val)<<8
+	case RegCommand:
+		d.status = StatusDone
+		if err := d.run(val); err != nil {
+			d.err = err
+			d.status |= StatusError
+		}
+	}
+}
+
+func (d *BlockDev) run(cmd byte) error {
+	off := int64(d.sector) * SectorSize
+	buf := make([]byte, SectorSize)
+	switch cmd {
+	case CmdRead:
+		n, err := d.storage.ReadAt(buf, off)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		clear(buf[n:])
+		for i, b := range buf {
+			d.bus.Write(b, d.buffer+uint16(i))
+		}
+	case CmdWrite:
+		w, ok := d.storage.(io.WriterAt)
+		if !ok {
+			d.status |= StatusReadOnly
+			return ErrReadOnly
+		}
+		for i := range buf {
+			buf[i] = d.bus.Read(d.buffer + uint16(i))
+		}
+		if _, err := w.WriteAt(buf, off); err != nil {
+			if errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EBADF) {
+				d.status |= StatusReadOnly
+				return fmt.Errorf("%w: %w", ErrReadOnly, err)
+			}
+			return err
+		}
+	case CmdSync:
+		if s, ok := d.storage.(interface{ Sync() error }); ok {
+			return s.Sync()
+		}
+	default:
+		return ErrCommand
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package blockdev
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/bus"
+)
+
+func newBlockDevTest(t *testing.T, s Storage) (*BlockDev, *bus.Bus) {
+	t.Helper()
+	b := bus.New()
+	if err := b.Map(0x0000, 0x7FFF, bus.NewRAM(0x8000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d := New(b, s)
+	if err := b.Map(0xD200, 0xD207, d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return d, b
+}
+
+func command(b *bus.Bus, cmd byte, sector, buffer uint16) byte {
+	b.Write(byte(sector), 0xD200+RegSectorLow)
+	b.Write(byte(sector>>8), 0xD200+RegSectorHigh)
+	b.Write(byte(buffer), 0xD200+RegBufferLow)
+	b.Write(byte(buffer>>8), 0xD200+RegBufferHigh)
+	b.Write(cmd, 0xD200+RegCommand)
+	return b.Read(0xD200 + RegStatus)
+}
+
+func TestBlockDevWriteRead(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "disk.img"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+	_, b := newBlockDevTest(t, f)
+	for i := range uint16(SectorSize) {
+		b.Write(byte(i*7), 0x0400+i)
+	}
+
+	if status := command(b, CmdWrite, 3, 0x0400); status != StatusDone {
+		t.Fatalf("expected status $%02X, actual $%02X", StatusDone, status)
+	}
+	if status := command(b, CmdSync, 0, 0); status != StatusDone {
+		t.Fatalf("expected status $%02X, actual $%02X", StatusDone, status)
+	}
+	if status := command(b, CmdRead, 3, 0x1000); status != StatusDone {
+		t.Fatalf("expected status $%02X, actual $%02X", StatusDone, status)
+	}
+
+	for i := range uint16(SectorSize) {
+		if actual := b.Read(0x1000 + i); actual != byte(i*7) {
+			t.Fatalf("expected $%02X at $%04X, actual $%02X", byte(i*7), 0x1000+i, actual)
+		}
+	}
+	if info, err := f.Stat(); err != nil || info.Size() != 4*SectorSize {
+		t.Errorf("expected the file extended to 4 sectors, actual %v %v\n", info.Size(), err)
+	}
+}
+
+func TestBlockDevReadPastEnd(t *testing.T) {
+	_, b := newBlockDevTest(t, bytes.NewReader([]byte{1, 2, 3}))
+	for i := range uint16(SectorSize) {
+		b.Write(0xFF, 0x0400+i)
+	}
+
+	if status := command(b, CmdRead, 0, 0x0400); status != StatusDone {
+		t.Fatalf("expected status $%02X, actual $%02X", StatusDone, status)
+	}
+
+	if actual := [4]byte{b.Read(0x0400), b.Read(0x0401), b.Read(0x0402), b.Read(0x0403)}; actual != [4]byte{1, 2, 3, 0} {
+		t.Errorf("expected the sector padded with zeros, actual %+v\n", actual)
+	}
+}
+
+func TestBlockDevErrors(t *testing.T) {
+	d, b := newBlockDevTest(t, bytes.NewReader(nil))
+
+	if status := command(b, CmdWrite, 0, 0x0400); status != StatusDone|StatusError|StatusReadOnly {
+		t.Errorf("expected status $%02X, actual $%02X\n", StatusDone|StatusError|StatusReadOnly, status)
+	}
+	if d.Err() != ErrReadOnly {
+		t.Errorf("expected %v, actual %v\n", ErrReadOnly, d.Err())
+	}
+	if status := command(b, 0x7F, 0, 0x0400); status != StatusDone|StatusError {
+		t.Errorf("expected status $%02X, actual $%02X\n", StatusDone|StatusError, status)
+	}
+	if d.Err() != ErrCommand {
+		t.Errorf("expected %v, actual %v\n", ErrCommand, d.Err())
+	}
+}
+
+func TestBlockDevReadOnlyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disk.img")
+	if err := os.WriteFile(path, make([]byte, SectorSize), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+	d, b := newBlockDevTest(t, f)
+
+	if status := command(b, CmdWrite, 0, 0x0400); status != StatusDone|StatusError|StatusReadOnly {
+		t.Errorf("expected status $%02X, actual $%02X\n", StatusDone|StatusError|StatusReadOnly, status)
+	}
+	if !errors.Is(d.Err(), ErrReadOnly) {
+		t.Errorf("expected %v, actual %v\n", ErrReadOnly, d.Err())
+	}
+}
@@ -0,0 +1,46 @@
+// Package charout emulates the character output port of simulators and test
+// environments: a single address where each byte written is printed, so
+// programs can print without a ROM or a terminal chip.
+//
+// It is mapped at DefaultAddr, or wherever the program expects it:
+//
+//	b.Map(charout.DefaultAddr, charout.DefaultAddr, charout.New(os.Stdout))
+package charout
+
+import "io"
+
+// DefaultAddr is the address of the port in several simulators, such as the
+// monitor of py65.
+const DefaultAddr uint16 = 0xF001
+
+// CharOut is a character output port. It reads as 0. Its zero value is not
+// usable: use New.
+type CharOut struct {
+	w   io.Writer
+	err error
+}
+
+// New returns a CharOut writing to w.
+func New(w io.Writer) *CharOut {
+	return &CharOut{w: w}
+}
+
+// Err returns the first error writing to w, if any. The bytes written after
+// it are dropped.
+func (c *CharOut) Err() error {
+	return c.err
+}
+
+func (c *CharOut) Read(uint16) byte {
+	return 0
+}
+
+// Write writes val to w, wherever it is written in the region of the port.
+func (c *CharOut) Write(val byte, _ uint16) {
+	if c.err != nil {
+		return
+	}
+	if _, err := c.w.Write([]byte{val}); err != nil {
+		c.err = err
+	}
+}
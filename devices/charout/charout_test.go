@@ -0,0 +1,54 @@
+package charout
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/bus"
+)
+
+func TestCharOutPrints(t *testing.T) {
+	var out bytes.Buffer
+	b := bus.New()
+	if err := b.Map(DefaultAddr, DefaultAddr, New(&out)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, c := range []byte("hello, world\n") {
+		b.Write(c, DefaultAddr)
+	}
+	b.Write('!', DefaultAddr+1)
+
+	if out.String() != "hello, world\n" {
+		t.Errorf("expected %q, actual %q\n", "hello, world\n", out.String())
+	}
+	if actual := b.Read(DefaultAddr); actual != 0 {
+		t.Errorf("expected 0, actual $%02X\n", actual)
+	}
+}
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write([]byte) (int, error) {
+	w.writes++
+	return 0, os.ErrClosed
+}
+
+func TestCharOutError(t *testing.T) {
+	w := &failingWriter{}
+	c := New(w)
+
+	c.Write('a', 0)
+	c.Write('b', 0)
+
+	if !errors.Is(c.Err(), os.ErrClosed) {
+		t.Errorf("expected %v, actual %v\n", os.ErrClosed, c.Err())
+	}
+	if w.writes != 1 {
+		t.Errorf("expected 1 write, actual %d\n", w.writes)
+	}
+}
@@ -0,0 +1,128 @@
+// Package devices holds what the emulated peripheral chips share: the clock
+// that drives them with the cycles of the CPU, or the scheduler that runs
+// events posted for later cycles as well, and the interrupt line they
+// assert. The chips themselves are in its subpackages, such as via6522.
+package devices
+
+import "github.com/leakedmemory/mos6502/cpu"
+
+// Clocked is implemented by devices counting the cycles of the CPU, such as
+// timers.
+type Clocked interface {
+	Tick(cycles uint)
+}
+
+// ClockedFunc adapts a function to a Clocked, such as for the glue logic of
+// a machine.
+type ClockedFunc func(cycles uint)
+
+// Tick calls f(cycles).
+func (f ClockedFunc) Tick(cycles uint) {
+	f(cycles)
+}
+
+// IRQLine is an interrupt request input, such as that of *cpu.CPU.
+type IRQLine interface {
+	SetIRQ(asserted bool)
+}
+
+// Port is what is wired to an 8-bit port of a chip, each pin of which is an
+// input or an output as set by a data direction register.
+type Port interface {
+	// Input returns the levels driven on the pins of the port. Those of the
+	// pins the chip drives are ignored.
+	Input() byte
+	// Output is called when the chip changes the levels it drives: those of
+	// val on the pins set in ddr.
+	Output(val, ddr byte)
+}
+
+// Clock ticks devices with the cycles the CPU runs, after each instruction.
+// The devices see the cycles of an instruction once it completes, and those
+// of an interrupt entry or a stall along with the next instruction.
+type Clock struct {
+	cpu     *cpu.CPU
+	devices []Clocked
+	last    uint
+	hook    int
+}
+
+// NewClock returns a Clock ticking devs with the cycles c runs from now on.
+func NewClock(c *cpu.CPU, devs ...Clocked) *Clock {
+	k := &Clock{cpu: c, devices: devs, last: c.Cycles()}
+	k.hook = c.AddInstructionHook(func(cpu.InstructionEvent) { k.Sync() })
+	return k
+}
+
+// Add adds d to the devices ticked.
+func (k *Clock) Add(d Clocked) {
+	k.devices = append(k.devices, d)
+}
+
+// Sync ticks the devices with the cycles run since the last tick. It is
+// called after every instruction, and can be called between instructions to
+// bring the devices up to date, such as after an interrupt entry.
+func (k *Clock) Sync() {
+	now := k.cpu.Cycles()
+	if now < k.last {
+		// The CPU was reset.
+		k.last = now
+	}
+	if elapsed := now - k.last; elapsed != 0 {
+		for _, d := range k.devices {
+			d.Tick(elapsed)
+		}
+	}
+	k.last = now
+}
+
+// Stop stops ticking the devices.
+func (k *Clock) Stop() {
+	k.cpu.RemoveInstructionHook(k.hook)
+}
+
+// SharedIRQ is an open-drain interrupt line shared by several devices, such
+// as the IRQ input of the CPU wired to several chips: it is asserted while
+// any of its inputs is.
+type SharedIRQ struct {
+	line     IRQLine
+	inputs   []bool
+	asserted bool
+}
+
+// NewSharedIRQ returns a SharedIRQ driving line.
+func NewSharedIRQ(line IRQLine) *SharedIRQ {
+	return &SharedIRQ{line: line}
+}
+
+// Input returns a new input of the line, for a device to assert.
+func (s *SharedIRQ) Input() IRQLine {
+	s.inputs = append(s.inputs, false)
+	return sharedInput{s: s, i: len(s.inputs) - 1}
+}
+
+// Asserted reports whether any input asserts the line.
+func (s *SharedIRQ) Asserted() bool {
+	return s.asserted
+}
+
+func (s *SharedIRQ) set(i int, asserted bool) {
+	s.inputs[i] = asserted
+	on := false
+	for _, a := range s.inputs {
+		on = on || a
+	}
+	if on != s.asserted {
+		s.asserted = on
+		s.line.SetIRQ(on)
+	}
+}
+
+type sharedInput struct {
+	s *SharedIRQ
+	i int
+}
+
+func (in sharedInput) SetIRQ(asserted bool) {
+	in.s.set(in.i, asserted)
+}
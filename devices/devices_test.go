@@ -0,0 +1,205 @@
+package devices
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+type testDevice struct {
+	cycles uint
+}
+
+func (d *testDevice) Tick(cycles uint) {
+	d.cycles += cycles
+}
+
+type testIRQ struct {
+	asserted bool
+	changes  int
+}
+
+func (l *testIRQ) SetIRQ(asserted bool) {
+	l.asserted = asserted
+	l.changes++
+}
+
+func TestClockTicksDevices(t *testing.T) {
+	mem := &memory.Memory{}
+	// 0200  SEI
+	// 0201  LDA #$01
+	// 0203  JSR $0200
+	for i, b := range []byte{0x78, 0xA9, 0x01, 0x20, 0x00, 0x02} {
+		mem.Write(b, 0x0200+uint16(i))
+	}
+	c := cpu.New(mem)
+	c.Reset()
+	d := &testDevice{}
+	k := NewClock(c, d)
+
+	for range 3 {
+		if err := c.Step(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if d.cycles != 10 {
+		t.Errorf("expected 10 cycles, actual %d\n", d.cycles)
+	}
+
+	c.Reset()
+	k.Stop()
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.cycles != 10 {
+		t.Errorf("expected no tick once stopped, actual %d cycles\n", d.cycles)
+	}
+}
+
+func TestSharedIRQ(t *testing.T) {
+	line := &testIRQ{}
+	s := NewSharedIRQ(line)
+	a, b := s.Input(), s.Input()
+
+	a.SetIRQ(true)
+	b.SetIRQ(true)
+	a.SetIRQ(false)
+	if !line.asserted {
+		t.Errorf("expected the line asserted while an input is\n")
+	}
+	b.SetIRQ(false)
+	if line.asserted || s.Asserted() {
+		t.Errorf("expected the line released\n")
+	}
+	if line.changes != 2 {
+		t.Errorf("expected 2 changes of the line, actual %d\n", line.changes)
+	}
+}
+
+func TestSchedulerRunsEventsInOrder(t *testing.T) {
+	mem := &memory.Memory{}
+	// 0200  LDA #$01
+	// 0202  LDA #$02
+	// 0204  LDA #$03
+	for i, b := range []byte{0xA9, 0x01, 0xA9, 0x02, 0xA9, 0x03} {
+		mem.Write(b, 0x0200+uint16(i))
+	}
+	c := cpu.New(mem)
+	c.Reset()
+	d := &testDevice{}
+	s := NewScheduler(c, d)
+
+	var fired []uint
+	s.At(3, func() { fired = append(fired, d.cycles) })
+	s.After(1, func() { fired = append(fired, d.cycles) })
+	cancelled := s.At(2, func() { t.Errorf("expected a cancelled event not to run\n") })
+	s.Cancel(cancelled)
+	s.At(10, func() { t.Errorf("expected an event not due not to run\n") })
+
+	for range 2 {
+		if err := c.Step(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if expected := []uint{1, 3}; len(fired) != 2 || fired[0] != expected[0] || fired[1] != expected[1] {
+		t.Errorf("expected events run at %v cycles, actual %v\n", expected, fired)
+	}
+	if d.cycles != 4 || s.Now() != 4 {
+		t.Errorf("expected 4 cycles, actual %d ticked and %d counted\n", d.cycles, s.Now())
+	}
+	if at, ok := s.Next(); !ok || at != 10 {
+		t.Errorf("expected the next event at 10, actual %d %v\n", at, ok)
+	}
+}
+
+func TestSchedulerPostsFromEvents(t *testing.T) {
+	mem := &memory.Memory{}
+	// 0200  JSR $0200
+	for i, b := range []byte{0x20, 0x00, 0x02} {
+		mem.Write(b, 0x0200+uint16(i))
+	}
+	c := cpu.New(mem)
+	c.Reset()
+	s := NewScheduler(c)
+
+	// A periodic event, such as a timer reposting its expiry.
+	var ticks []uint
+	var expire func()
+	expire = func() {
+		ticks = append(ticks, s.Now())
+		s.After(100, expire)
+	}
+	s.After(100, expire)
+
+	if _, err := s.RunFor(350); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []uint{100, 200, 300}; len(ticks) != 3 || ticks[2] != expected[2] {
+		t.Errorf("expected events at %v, actual %v\n", expected, ticks)
+	}
+	c.Reset()
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Now() < 350 {
+		t.Errorf("expected the count to go on across a reset, actual %d\n", s.Now())
+	}
+}
+
+type testChip struct {
+	testDevice
+	irq  IRQLine
+	regs [2]byte
+}
+
+func (c *testChip) Read(addr uint16) byte {
+	return c.regs[addr%2]
+}
+
+func (c *testChip) Write(val byte, addr uint16) {
+	c.regs[addr%2] = val
+}
+
+func (c *testChip) Reset() {
+	c.regs = [2]byte{}
+}
+
+func TestRegistry(t *testing.T) {
+	Register(Info{
+		Name: "test-chip",
+		Size: 2,
+		New: func(cfg Config) (Device, error) {
+			return &testChip{irq: cfg.IRQ}, nil
+		},
+	})
+
+	irq := &testIRQ{}
+	d, err := New("test-chip", Config{IRQ: irq})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if chip, ok := d.(*testChip); !ok || chip.irq != irq {
+		t.Errorf("expected a test chip wired to the IRQ, actual %+v\n", d)
+	}
+	if info, ok := Lookup("test-chip"); !ok || info.Size != 2 {
+		t.Errorf("expected the test chip registered, actual %+v\n", info)
+	}
+	if names := Registered(); !slices.Contains(names, "test-chip") {
+		t.Errorf("expected the test chip among %v\n", names)
+	}
+
+	if _, err := New("missing", Config{}); !errors.Is(err, ErrUnknownDevice) {
+		t.Errorf("expected %v, actual %v\n", ErrUnknownDevice, err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a name twice to panic\n")
+		}
+	}()
+	Register(Info{Name: "test-chip", New: func(Config) (Device, error) { return nil, nil }})
+}
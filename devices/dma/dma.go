@@ -0,0 +1,245 @@
+// Package dma emulates a simple DMA controller, not modeled on any chip: it
+// copies blocks of memory over the bus, such as for a blitter or a disk
+// controller, while the CPU is held by its RDY line for the cycles the copy
+// takes.
+//
+// The controller occupies 8 addresses, mirrored across larger regions. It
+// holds the CPU through the stall function of the bus, and counts the
+// cycles of the copy given to Tick, by a devices.Clock, to signal its end:
+//
+//	b.SetStallFunc(c.Stall)
+//	d := dma.New(b, c)
+//	b.Map(0xD100, 0xD107, d)
+//	devices.NewClock(c, d)
+//
+// A program sets the source, the destination and the length, then starts
+// the copy by writing the control register:
+//
+//	LDA #$00
+//	STA $D100   ; source $2000
+//	LDA #$20
+//	STA $D101
+//	...
+//	LDA #$03    ; started, interrupting at its end
+//	STA $D106
+package dma
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/devices"
+)
+
+// ErrState is returned by UnmarshalBinary for a state it can not restore.
+var ErrState = errors.New("dma: invalid state")
+
+// Registers, by their offset.
+const (
+	// RegSourceLow and RegSourceHigh hold the address copied from.
+	RegSourceLow = iota
+	RegSourceHigh
+	// RegDestLow and RegDestHigh hold the address copied to.
+	RegDestLow
+	RegDestHigh
+	// RegLengthLow and RegLengthHigh hold the number of bytes copied, 65536
+	// if 0.
+	RegLengthLow
+	RegLengthHigh
+	// RegControl holds the Control bits.
+	RegControl
+	// RegStatus reads the Status bits, and clears StatusDone.
+	RegStatus
+)
+
+// Bits of the control register.
+const (
+	// ControlStart starts a copy as it is written. It is cleared as the copy
+	// ends.
+	ControlStart byte = 0x01
+	// ControlIRQ asserts the IRQ while StatusDone is set.
+	ControlIRQ byte = 0x02
+	// ControlFixedSource keeps the source address, such as the data register
+	// of a disk controller, instead of incrementing it after each byte.
+	ControlFixedSource byte = 0x04
+	// ControlFixedDest keeps the destination address, such as to fill a
+	// block from a fixed source, instead of incrementing it after each byte.
+	ControlFixedDest byte = 0x08
+)
+
+// Bits of the status register.
+const (
+	// StatusBusy is set while a copy holds the CPU.
+	StatusBusy byte = 0x40
+	// StatusDone is set as a copy ends.
+	StatusDone byte = 0x80
+)
+
+// CyclesPerByte is the number of cycles the CPU is held for each byte
+// copied: one to read it and one to write it.
+const CyclesPerByte = 2
+
+// DMA is a DMA controller. Its zero value is not usable: use New.
+type DMA struct {
+	bus      *bus.Bus
+	irq      devices.IRQLine
+	asserted bool
+
+	source, dest, length uint16
+	control, status      byte
+	// left is the number of cycles until the copy ends.
+	left uint
+}
+
+// New returns a DMA copying over b and asserting irq, if not nil.
+func New(b *bus.Bus, irq devices.IRQLine) *DMA {
+	d := &DMA{bus: b, irq: irq}
+	d.Reset()
+	return d
+}
+
+// Reset stops the copy in progress and clears the registers.
+func (d *DMA) Reset() {
+	d.source, d.dest, d.length = 0, 0, 0
+	d.control, d.status = 0, 0
+	d.left = 0
+	d.updateIRQ()
+}
+
+// Read returns the register at addr. Reading the status clears StatusDone.
+func (d *DMA) Read(addr uint16) byte {
+	val := d.Peek(addr)
+	if addr&0x07 == RegStatus {
+		d.status &^= StatusDone
+		d.updateIRQ()
+	}
+	return val
+}
+
+// Peek returns the register at addr without side effects. The address
+// registers read the addresses the copy reached.
+func (d *DMA) Peek(addr uint16) byte {
+	switch addr & 0x07 {
+	case RegSourceLow:
+		return byte(d.source)
+	case RegSourceHigh:
+		return byte(d.source >> 8)
+	case RegDestLow:
+		return byte(d.dest)
+	case RegDestHigh:
+		return byte(d.dest >> 8)
+	case RegLengthLow:
+		return byte(d.length)
+	case RegLengthHigh:
+		return byte(d.length >> 8)
+	case RegControl:
+		return d.control
+	default:
+		return d.status
+	}
+}
+
+// Write changes the register at addr to val. Writing RegControl with
+// ControlStart set, while no copy is in progress, starts one.
+func (d *DMA) Write(val byte, addr uint16) {
+	switch addr & 0x07 {
+	case RegSourceLow:
+		d.source = d.source&0xFF00 | uint16(val)
+	case RegSourceHigh:
+		d.source = d.source&0x00FF | uint16(val)<<8
+	case RegDestLow:
+		d.dest = d.dest&0xFF00 | uint16(val)
+	case RegDestHigh:
+		d.dest = d.dest&0x00FF | uint16(val)<<8
+	case RegLengthLow:
+		d.length = d.length&0xFF00 | uint16(val)
+	case RegLengthHigh:
+		d.length = d.length&0x00FF | uint16(val)<<8
+	case RegControl:
+		if d.status&StatusBusy != 0 {
+			return
+		}
+		d.control = val & (ControlStart | ControlIRQ | ControlFixedSource | ControlFixedDest)
+		if d.control&ControlStart != 0 {
+			d.start()
+		}
+	case RegStatus:
+		d.status &^= StatusDone
+	}
+	d.updateIRQ()
+}
+
+// Tick counts cycles of the CPU, ending the copy in progress once the CPU
+// was held for its cycles.
+func (d *DMA) Tick(cycles uint) {
+	if d.status&StatusBusy == 0 {
+		return
+	}
+	d.left -= min(cycles, d.left)
+	if d.left == 0 {
+		d.status = d.status&^StatusBusy | StatusDone
+		d.control &^= ControlStart
+		d.updateIRQ()
+	}
+}
+
+// start copies the block and holds the CPU for the cycles it takes. The
+// bytes are all copied at once, as the CPU cannot see them in between.
+func (d *DMA) start() {
+	n := uint(d.length)
+	if n == 0 {
+		n = 0x10000
+	}
+	for range n {
+		d.bus.Write(d.bus.Read(d.source), d.dest)
+		if d.control&ControlFixedSource == 0 {
+			d.source++
+		}
+		if d.control&ControlFixedDest == 0 {
+			d.dest++
+		}
+	}
+	d.status = d.status&^StatusDone | StatusBusy
+	d.left = n * CyclesPerByte
+	d.bus.StealCycles(d.left)
+}
+
+// updateIRQ drives the IRQ output from the status and its enable.
+func (d *DMA) updateIRQ() {
+	asserted := d.control&ControlIRQ != 0 && d.status&StatusDone != 0
+	if asserted != d.asserted {
+		d.asserted = asserted
+		if d.irq != nil {
+			d.irq.SetIRQ(asserted)
+		}
+	}
+}
+
+// stateSize is the size of the state saved by MarshalBinary.
+const stateSize = 12
+
+// MarshalBinary returns the state of the controller, for a savestate.
+func (d *DMA) MarshalBinary() ([]byte, error) {
+	b := binary.LittleEndian.AppendUint16(nil, d.source)
+	b = binary.LittleEndian.AppendUint16(b, d.dest)
+	b = binary.LittleEndian.AppendUint16(b, d.length)
+	b = append(b, d.control, d.status)
+	return binary.LittleEndian.AppendUint32(b, uint32(d.left)), nil
+}
+
+// UnmarshalBinary restores the state of the controller returned by
+// MarshalBinary. The CPU is not held again for a copy in progress: its
+// stall is restored with the state of the CPU.
+func (d *DMA) UnmarshalBinary(data []byte) error {
+	if len(data) != stateSize {
+		return ErrState
+	}
+	d.source = binary.LittleEndian.Uint16(data)
+	d.dest = binary.LittleEndian.Uint16(data[2:])
+	d.length = binary.LittleEndian.Uint16(data[4:])
+	d.control, d.status = data[6], data[7]
+	d.left = uint(binary.LittleEndian.Uint32(data[8:]))
+	d.updateIRQ()
+	return nil
+}
@@ -0,0 +1,153 @@
+package dma
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/devices"
+)
+
+type testIRQ struct {
+	asserted bool
+}
+
+func (l *testIRQ) SetIRQ(asserted bool) {
+	l.asserted = asserted
+}
+
+func newDMATest(t *testing.T) (*DMA, *bus.Bus, *testIRQ, *uint) {
+	t.Helper()
+	b := bus.New()
+	if err := b.Map(0x0000, 0x7FFF, bus.NewRAM(0x8000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stalled := new(uint)
+	b.SetStallFunc(func(n uint) { *stalled += n })
+	irq := &testIRQ{}
+	d := New(b, irq)
+	if err := b.Map(0xD100, 0xD107, d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return d, b, irq, stalled
+}
+
+func setup(b *bus.Bus, source, dest, length uint16) {
+	b.Write(byte(source), 0xD100+RegSourceLow)
+	b.Write(byte(source>>8), 0xD100+RegSourceHigh)
+	b.Write(byte(dest), 0xD100+RegDestLow)
+	b.Write(byte(dest>>8), 0xD100+RegDestHigh)
+	b.Write(byte(length), 0xD100+RegLengthLow)
+	b.Write(byte(length>>8), 0xD100+RegLengthHigh)
+}
+
+func TestDMACopy(t *testing.T) {
+	d, b, irq, stalled := newDMATest(t)
+	for i := range uint16(0x100) {
+		b.Write(byte(i), 0x2000+i)
+	}
+	setup(b, 0x2000, 0x4000, 0x100)
+
+	b.Write(ControlStart|ControlIRQ, 0xD100+RegControl)
+
+	for i := range uint16(0x100) {
+		if actual := b.Read(0x4000 + i); actual != byte(i) {
+			t.Fatalf("expected $%02X at $%04X, actual $%02X", byte(i), 0x4000+i, actual)
+		}
+	}
+	if expected := uint(0x100 * CyclesPerByte); *stalled != expected {
+		t.Errorf("expected %d cycles stolen, actual %d\n", expected, *stalled)
+	}
+	if status := d.Peek(RegStatus); status != StatusBusy {
+		t.Errorf("expected status $%02X, actual $%02X\n", StatusBusy, status)
+	}
+
+	d.Tick(*stalled - 1)
+	if irq.asserted {
+		t.Fatalf("expected no interrupt before the copy ends")
+	}
+	d.Tick(1)
+	if !irq.asserted {
+		t.Errorf("expected the IRQ asserted as the copy ends\n")
+	}
+	if control := d.Peek(RegControl); control&ControlStart != 0 {
+		t.Errorf("expected ControlStart cleared, actual control $%02X\n", control)
+	}
+	if status := d.Read(RegStatus); status != StatusDone {
+		t.Errorf("expected status $%02X, actual $%02X\n", StatusDone, status)
+	}
+	if irq.asserted {
+		t.Errorf("expected the IRQ released by reading the status\n")
+	}
+	if source, dest := d.Peek(RegSourceHigh), d.Peek(RegDestHigh); source != 0x21 || dest != 0x41 {
+		t.Errorf("expected the addresses reached $21xx and $41xx, actual $%02Xxx and $%02Xxx\n", source, dest)
+	}
+}
+
+func TestDMAFill(t *testing.T) {
+	_, b, _, _ := newDMATest(t)
+	b.Write(0xAA, 0x0010)
+	setup(b, 0x0010, 0x3000, 0x20)
+
+	b.Write(ControlStart|ControlFixedSource, 0xD100+RegControl)
+
+	for i := range uint16(0x20) {
+		if actual := b.Read(0x3000 + i); actual != 0xAA {
+			t.Fatalf("expected $AA at $%04X, actual $%02X", 0x3000+i, actual)
+		}
+	}
+	if actual := b.Read(0x3020); actual != 0x00 {
+		t.Errorf("expected the copy to stop at its length, actual $%02X\n", actual)
+	}
+}
+
+func TestDMAIgnoresStartWhileBusy(t *testing.T) {
+	d, b, _, stalled := newDMATest(t)
+	setup(b, 0x2000, 0x4000, 0x10)
+	b.Write(ControlStart, 0xD100+RegControl)
+
+	b.Write(ControlStart, 0xD100+RegControl)
+
+	if expected := uint(0x10 * CyclesPerByte); *stalled != expected {
+		t.Errorf("expected %d cycles stolen, actual %d\n", expected, *stalled)
+	}
+	d.Tick(*stalled)
+	if status := d.Peek(RegStatus); status != StatusDone {
+		t.Errorf("expected status $%02X, actual $%02X\n", StatusDone, status)
+	}
+}
+
+func TestDMAState(t *testing.T) {
+	d, b, _, _ := newDMATest(t)
+	setup(b, 0x2000, 0x4000, 0x10)
+	b.Write(ControlStart|ControlIRQ, 0xD100+RegControl)
+
+	data, err := d.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restored, _, irq, stalled := newDMATest(t)
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *stalled != 0 {
+		t.Errorf("expected no cycles stolen by a restore, actual %d\n", *stalled)
+	}
+	restored.Tick(0x10 * CyclesPerByte)
+
+	if !irq.asserted || restored.Peek(RegSourceLow) != 0x10 {
+		t.Errorf("expected the restored copy to end, actual status $%02X\n", restored.Peek(RegStatus))
+	}
+	if err := restored.UnmarshalBinary(nil); err != ErrState {
+		t.Errorf("expected %v, actual %v\n", ErrState, err)
+	}
+}
+
+func TestDMARegistered(t *testing.T) {
+	if _, err := devices.New("dma", devices.Config{}); err == nil {
+		t.Errorf("expected an error without a bus\n")
+	}
+	b := bus.New()
+	if _, err := devices.New("dma", devices.Config{Bus: b}); err != nil {
+		t.Errorf("unexpected error: %v\n", err)
+	}
+}
@@ -0,0 +1,21 @@
+package dma
+
+import (
+	"errors"
+
+	"github.com/leakedmemory/mos6502/devices"
+)
+
+func init() {
+	devices.Register(devices.Info{
+		Name:        "dma",
+		Description: "DMA controller copying blocks over the bus",
+		Size:        8,
+		New: func(cfg devices.Config) (devices.Device, error) {
+			if cfg.Bus == nil {
+				return nil, errors.New("dma: no bus to copy over")
+			}
+			return New(cfg.Bus, cfg.IRQ), nil
+		},
+	})
+}
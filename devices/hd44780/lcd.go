@@ -0,0 +1,333 @@
+// Package hd44780 emulates the HD44780 dot-matrix LCD controller, with its
+// display data and character generator RAMs, as found on the character LCD
+// modules of 16x2 and similar sizes.
+//
+// The controller is either wired to the ports of a chip such as a VIA,
+// through its pins set by SetControl and SetData, or mapped as a device of
+// 2 addresses, the instruction register and the data register:
+//
+//	l := hd44780.New(16, 2)
+//	b.Map(0x7000, 0x7001, l)
+//
+// It executes instructions at once, so its busy flag is never set.
+package hd44780
+
+import "strings"
+
+// Instructions, with the bits of their arguments.
+const (
+	InstClear        byte = 0x01
+	InstHome         byte = 0x02
+	InstEntryMode    byte = 0x04
+	EntryIncrement   byte = 0x02
+	EntryShift       byte = 0x01
+	InstDisplay      byte = 0x08
+	DisplayOn        byte = 0x04
+	DisplayCursor    byte = 0x02
+	DisplayBlink     byte = 0x01
+	InstShift        byte = 0x10
+	ShiftDisplay     byte = 0x08
+	ShiftRight       byte = 0x04
+	InstFunction     byte = 0x20
+	Function8Bit     byte = 0x10
+	FunctionTwoLine  byte = 0x08
+	FunctionFont5x10 byte = 0x04
+	InstCGRAMAddr    byte = 0x40
+	InstDDRAMAddr    byte = 0x80
+)
+
+const (
+	ddramSize = 80
+	cgramSize = 64
+	// lineSize is the size of a line of the display data RAM in two-line
+	// mode, the second line starting at lineAddr.
+	lineSize = 40
+	lineAddr = 0x40
+)
+
+// LCD is an HD44780. Its zero value is not usable: use New.
+type LCD struct {
+	cols, rows int
+
+	ddram [ddramSize]byte
+	cgram [cgramSize]byte
+	// ac is the address counter, in the character generator RAM if cg is
+	// set.
+	ac byte
+	cg bool
+
+	entry    byte
+	display  byte
+	function byte
+	// offset is the number of characters the display is shifted left by.
+	offset int
+
+	// pending holds the high nibble of a transfer in 4-bit mode, while half
+	// is set, and readHalf is set once the high nibble of a read is out.
+	pending  byte
+	half     bool
+	read     byte
+	readHalf bool
+
+	rs, rw, e bool
+	data, out byte
+}
+
+// New returns an LCD module of cols columns and rows rows, such as 16x2, in
+// the state of the reset at power on: 8-bit interface, one line, display
+// off and cleared.
+func New(cols, rows int) *LCD {
+	l := &LCD{cols: cols, rows: rows}
+	l.Reset()
+	return l
+}
+
+// Reset puts the controller in the state of the reset at power on.
+func (l *LCD) Reset() {
+	l.Instruction(InstFunction | Function8Bit)
+	l.Instruction(InstDisplay)
+	l.Instruction(InstEntryMode | EntryIncrement)
+	l.Instruction(InstClear)
+}
+
+// Read returns the register at addr: the busy flag and the address counter
+// at even addresses, and the data at the address counter at odd ones,
+// advancing it.
+func (l *LCD) Read(addr uint16) byte {
+	return l.transferOut(addr&0x01 != 0)
+}
+
+// Peek returns the register at addr without side effects.
+func (l *LCD) Peek(addr uint16) byte {
+	if addr&0x01 == 0 {
+		return l.ac
+	}
+	return l.memory()
+}
+
+// Write writes val to the instruction register at even addresses, and to
+// the data register at odd ones.
+func (l *LCD) Write(val byte, addr uint16) {
+	l.transferIn(addr&0x01 != 0, val)
+}
+
+// SetControl sets the levels of the pins RS, R/W and E. Data is written on
+// the falling edge of E, and driven while E is high when reading.
+func (l *LCD) SetControl(rs, rw, e bool) {
+	rising, falling := e && !l.e, !e && l.e
+	l.rs, l.rw, l.e = rs, rw, e
+	switch {
+	case rising && rw:
+		l.out = l.transferOut(rs)
+	case falling && !rw:
+		l.transferIn(rs, l.data)
+	}
+}
+
+// SetData sets the levels of the pins D7-D0. In 4-bit mode, only D7-D4 are
+// used.
+func (l *LCD) SetData(val byte) {
+	l.data = val
+}
+
+// Output returns the levels the controller drives on D7-D0, while reading
+// with E high, or 0.
+func (l *LCD) Output() byte {
+	if l.e && l.rw {
+		return l.out
+	}
+	return 0
+}
+
+// Instruction executes the instruction inst, whatever the interface.
+func (l *LCD) Instruction(inst byte) {
+	switch {
+	case inst&InstDDRAMAddr != 0:
+		l.ac, l.cg = inst&^InstDDRAMAddr, false
+	case inst&InstCGRAMAddr != 0:
+		l.ac, l.cg = inst&(cgramSize-1), true
+	case inst&InstFunction != 0:
+		if (inst^l.function)&Function8Bit != 0 {
+			l.half, l.readHalf = false, false
+		}
+		l.function = inst
+	case inst&InstShift != 0:
+		step := -1
+		if inst&ShiftRight != 0 {
+			step = 1
+		}
+		if inst&ShiftDisplay != 0 {
+			l.offset -= step
+		} else {
+			l.advance(step)
+		}
+	case inst&InstDisplay != 0:
+		l.display = inst
+	case inst&InstEntryMode != 0:
+		l.entry = inst
+	case inst&InstHome != 0:
+		l.ac, l.cg, l.offset = 0, false, 0
+	case inst&InstClear != 0:
+		for i := range l.ddram {
+			l.ddram[i] = ' '
+		}
+		l.ac, l.cg, l.offset = 0, false, 0
+		l.entry |= EntryIncrement
+	}
+}
+
+// WriteData writes val at the address counter, advancing it, whatever the
+// interface.
+func (l *LCD) WriteData(val byte) {
+	if l.cg {
+		l.cgram[l.ac] = val
+		l.advance(l.step())
+		return
+	}
+	l.ddram[l.index(l.ac)] = val
+	l.advance(l.step())
+	if l.entry&EntryShift != 0 {
+		l.offset += l.step()
+	}
+}
+
+// ReadData returns the data at the address counter, advancing it, whatever
+// the interface.
+func (l *LCD) ReadData() byte {
+	val := l.memory()
+	l.advance(l.step())
+	return val
+}
+
+// On reports whether the display is on.
+func (l *LCD) On() bool {
+	return l.display&DisplayOn != 0
+}
+
+// Cursor returns the address counter in the display data RAM, and whether
+// the cursor is shown there.
+func (l *LCD) Cursor() (addr byte, shown bool) {
+	return l.ac, !l.cg && l.display&DisplayCursor != 0
+}
+
+// Row returns the character codes shown on row r, whether the display is
+// on or not. In one-line mode, the rows past the first are blank.
+func (l *LCD) Row(r int) []byte {
+	row := make([]byte, l.cols)
+	size, base := ddramSize, 0
+	if l.twoLines() {
+		size, base = lineSize, r*lineSize
+	}
+	for i := range row {
+		if r > 0 && !l.twoLines() {
+			row[i] = ' '
+			continue
+		}
+		row[i] = l.ddram[base+((l.offset+i)%size+size)%size]
+	}
+	return row
+}
+
+// Lines returns the text shown on every row, in the characters of the ROM
+// A00, with those not in ASCII, and those of the character generator RAM,
+// as '?'.
+func (l *LCD) Lines() []string {
+	lines := make([]string, l.rows)
+	for r := range lines {
+		var sb strings.Builder
+		for _, c := range l.Row(r) {
+			switch {
+			case c == 0x5C:
+				sb.WriteRune('¥')
+			case c >= 0x20 && c < 0x7E:
+				sb.WriteByte(c)
+			default:
+				sb.WriteByte('?')
+			}
+		}
+		lines[r] = sb.String()
+	}
+	return lines
+}
+
+// CGRAM returns the character generator RAM, the patterns of the characters
+// 0-7.
+func (l *LCD) CGRAM() [cgramSize]byte {
+	return l.cgram
+}
+
+// transferIn writes val to the instruction register, or to the data one if
+// rs is set, as a nibble in 4-bit mode.
+func (l *LCD) transferIn(rs bool, val byte) {
+	if l.function&Function8Bit == 0 {
+		if !l.half {
+			l.pending, l.half = val&0xF0, true
+			return
+		}
+		val, l.half = l.pending|val>>4, false
+	}
+	if rs {
+		l.WriteData(val)
+	} else {
+		l.Instruction(val)
+	}
+}
+
+// transferOut reads the busy flag and the address counter, or the data if rs
+// is set, as a nibble in 4-bit mode.
+func (l *LCD) transferOut(rs bool) byte {
+	if l.function&Function8Bit == 0 && l.readHalf {
+		l.readHalf = false
+		return l.read << 4
+	}
+	val := l.ac
+	if rs {
+		val = l.ReadData()
+	}
+	if l.function&Function8Bit == 0 {
+		l.read, l.readHalf = val, true
+		return val & 0xF0
+	}
+	return val
+}
+
+func (l *LCD) memory() byte {
+	if l.cg {
+		return l.cgram[l.ac]
+	}
+	return l.ddram[l.index(l.ac)]
+}
+
+func (l *LCD) step() int {
+	if l.entry&EntryIncrement != 0 {
+		return 1
+	}
+	return -1
+}
+
+func (l *LCD) twoLines() bool {
+	return l.function&FunctionTwoLine != 0
+}
+
+// index returns the index in the display data RAM of addr.
+func (l *LCD) index(addr byte) int {
+	if l.twoLines() {
+		return int(addr>>6&0x01)*lineSize + int(addr&0x3F)%lineSize
+	}
+	return int(addr) % ddramSize
+}
+
+// advance moves the address counter by step, wrapping around the RAM it
+// addresses, from the end of a line to the start of the other in two-line
+// mode.
+func (l *LCD) advance(step int) {
+	switch {
+	case l.cg:
+		l.ac = byte(int(l.ac)+step) & (cgramSize - 1)
+	case l.twoLines():
+		i := (l.index(l.ac) + step + ddramSize) % ddramSize
+		l.ac = byte(i/lineSize*lineAddr + i%lineSize)
+	default:
+		l.ac = byte((int(l.ac) + step + ddramSize) % ddramSize)
+	}
+}
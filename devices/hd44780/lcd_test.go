@@ -0,0 +1,166 @@
+package hd44780
+
+import "testing"
+
+func writeString(l *LCD, s string) {
+	for _, c := range []byte(s) {
+		l.Write(c, 1)
+	}
+}
+
+func TestLCDHelloWorld(t *testing.T) {
+	l := New(16, 2)
+	l.Write(InstFunction|Function8Bit|FunctionTwoLine, 0)
+	l.Write(InstDisplay|DisplayOn|DisplayCursor, 0)
+	l.Write(InstEntryMode|EntryIncrement, 0)
+	l.Write(InstClear, 0)
+
+	writeString(l, "Hello,")
+	l.Write(InstDDRAMAddr|0x40, 0)
+	writeString(l, "world!")
+
+	expected := []string{"Hello,          ", "world!          "}
+	for i, line := range l.Lines() {
+		if line != expected[i] {
+			t.Errorf("expected %q, actual %q\n", expected[i], line)
+		}
+	}
+	if !l.On() {
+		t.Errorf("expected the display on")
+	}
+	if addr, shown := l.Cursor(); addr != 0x46 || !shown {
+		t.Errorf("expected the cursor shown at $46, actual $%02X %v\n", addr, shown)
+	}
+}
+
+func TestLCDPowerOn(t *testing.T) {
+	l := New(16, 2)
+
+	if l.On() {
+		t.Errorf("expected the display off")
+	}
+	if lines := l.Lines(); lines[0] != "                " || lines[1] != "                " {
+		t.Errorf("expected the display cleared, actual %q\n", lines)
+	}
+}
+
+func TestLCDLineWrap(t *testing.T) {
+	l := New(16, 2)
+	l.Instruction(InstFunction | Function8Bit | FunctionTwoLine)
+	l.Instruction(InstDDRAMAddr | 0x27)
+
+	l.WriteData('a')
+	if l.Peek(0) != 0x40 {
+		t.Errorf("expected the address counter at $40, actual $%02X\n", l.Peek(0))
+	}
+	l.Instruction(InstDDRAMAddr | 0x67)
+	l.WriteData('b')
+	if l.Peek(0) != 0x00 {
+		t.Errorf("expected the address counter at $00, actual $%02X\n", l.Peek(0))
+	}
+
+	l.Instruction(InstEntryMode)
+	l.WriteData('c')
+	if l.Peek(0) != 0x67 {
+		t.Errorf("expected the address counter at $67 decrementing, actual $%02X\n", l.Peek(0))
+	}
+}
+
+func TestLCDShift(t *testing.T) {
+	l := New(4, 1)
+	l.Instruction(InstFunction | Function8Bit)
+	writeString(l, "abcdef")
+
+	l.Instruction(InstShift | ShiftDisplay)
+	if line := l.Lines()[0]; line != "bcde" {
+		t.Errorf("expected %q, actual %q\n", "bcde", line)
+	}
+	l.Instruction(InstShift | ShiftDisplay | ShiftRight)
+	l.Instruction(InstShift | ShiftDisplay | ShiftRight)
+	if row := l.Row(0); row[0] != ' ' || row[1] != 'a' {
+		t.Errorf("expected the display shifted right, actual %q\n", row)
+	}
+
+	l.Instruction(InstHome)
+	l.Instruction(InstEntryMode | EntryIncrement | EntryShift)
+	writeString(l, "xy")
+	if line := l.Lines()[0]; line != "cdef" {
+		t.Errorf("expected %q, actual %q\n", "cdef", line)
+	}
+}
+
+func TestLCDReadBack(t *testing.T) {
+	l := New(16, 2)
+	l.Instruction(InstCGRAMAddr | 0x08)
+	l.WriteData(0x1F)
+	l.WriteData(0x11)
+
+	l.Instruction(InstCGRAMAddr | 0x08)
+	if a, b := l.Read(1), l.Read(1); a != 0x1F || b != 0x11 {
+		t.Errorf("expected $1F $11, actual $%02X $%02X\n", a, b)
+	}
+	if cg := l.CGRAM(); cg[8] != 0x1F {
+		t.Errorf("expected the pattern in the character generator RAM, actual $%02X\n", cg[8])
+	}
+	if ac := l.Read(0); ac != 0x0A {
+		t.Errorf("expected the address counter at $0A, actual $%02X\n", ac)
+	}
+}
+
+// pulse writes val through the pins, as a program driving them from the
+// ports of a VIA.
+func pulse(l *LCD, rs bool, val byte) {
+	l.SetData(val)
+	l.SetControl(rs, false, true)
+	l.SetControl(rs, false, false)
+}
+
+// pulse4 writes val through the pins in 4-bit mode, high nibble first.
+func pulse4(l *LCD, rs bool, val byte) {
+	pulse(l, rs, val&0xF0)
+	pulse(l, rs, val<<4)
+}
+
+func readPulse(l *LCD, rs bool) byte {
+	l.SetControl(rs, true, true)
+	val := l.Output()
+	l.SetControl(rs, true, false)
+	return val
+}
+
+func TestLCDPins(t *testing.T) {
+	l := New(16, 2)
+	pulse(l, false, InstFunction|Function8Bit|FunctionTwoLine)
+	pulse(l, true, 'A')
+
+	if row := l.Row(0); row[0] != 'A' {
+		t.Errorf("expected %q, actual %q\n", 'A', row[0])
+	}
+	if status := readPulse(l, false); status != 0x01 {
+		t.Errorf("expected status $01, actual $%02X\n", status)
+	}
+	if l.Output() != 0 {
+		t.Errorf("expected the pins released with E low")
+	}
+}
+
+func TestLCD4Bit(t *testing.T) {
+	l := New(16, 2)
+	// The function set switching to 4-bit mode is a single 8-bit transfer.
+	pulse(l, false, InstFunction)
+	pulse4(l, false, InstFunction|FunctionTwoLine)
+	pulse4(l, true, 'H')
+	pulse4(l, true, 'i')
+
+	if line := l.Lines()[0]; line[:2] != "Hi" {
+		t.Errorf("expected %q, actual %q\n", "Hi", line)
+	}
+	if !l.twoLines() {
+		t.Errorf("expected two lines")
+	}
+
+	high, low := readPulse(l, false), readPulse(l, false)
+	if high != 0x00 || low != 0x20 {
+		t.Errorf("expected the nibbles $00 $20, actual $%02X $%02X\n", high, low)
+	}
+}
@@ -0,0 +1,108 @@
+// Package keyboard emulates a keyboard port: a status register telling
+// whether a key is available, and a data register reading it, fed from the
+// host, such as its terminal put in raw mode with MakeRaw. Programs poll it
+// without blocking, like monitors and BASIC interpreters poll a keyboard.
+//
+// It occupies 2 addresses, mirrored across larger regions:
+//
+//	restore, err := keyboard.MakeRaw(os.Stdin)
+//	...
+//	defer restore()
+//	b.Map(0xF004, 0xF005, keyboard.New(os.Stdin))
+package keyboard
+
+import "io"
+
+// Registers, by their offset.
+const (
+	// RegStatus has StatusReady set while a key is available.
+	RegStatus = iota
+	// RegData reads the key available, or 0, and clears StatusReady.
+	RegData
+)
+
+// StatusReady is set in the status register while a key is available.
+const StatusReady byte = 0x80
+
+// inputBuffer is the number of keys read from the host ahead of the program.
+const inputBuffer = 256
+
+// Keyboard is a keyboard port. Its zero value is not usable: use New.
+type Keyboard struct {
+	in    chan byte
+	key   byte
+	ready bool
+}
+
+// New returns a Keyboard reading keys from r, in a goroutine, until it
+// fails, such as at the end of a file.
+func New(r io.Reader) *Keyboard {
+	k := &Keyboard{in: make(chan byte, inputBuffer)}
+	go k.read(r)
+	return k
+}
+
+func (k *Keyboard) read(r io.Reader) {
+	defer close(k.in)
+	buf := make([]byte, inputBuffer)
+	for {
+		n, err := r.Read(buf)
+		for _, b := range buf[:n] {
+			k.in <- b
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Read returns the register at addr. Reading the status takes the next key
+// from the host, if none is available yet, and reading the data consumes
+// the key.
+func (k *Keyboard) Read(addr uint16) byte {
+	if addr&0x01 == RegStatus {
+		k.poll()
+		return k.Peek(addr)
+	}
+	val := k.Peek(addr)
+	k.key, k.ready = 0, false
+	return val
+}
+
+// Peek returns the register at addr without side effects.
+func (k *Keyboard) Peek(addr uint16) byte {
+	if addr&0x01 == RegStatus {
+		if k.ready {
+			return StatusReady
+		}
+		return 0
+	}
+	return k.key
+}
+
+// Write ignores val: the registers are read-only.
+func (k *Keyboard) Write(byte, uint16) {}
+
+// Pending reports whether a key is available, or can be taken from the host
+// without blocking.
+func (k *Keyboard) Pending() bool {
+	k.poll()
+	return k.ready
+}
+
+// poll takes the next key from the host, if none is available and it has
+// one.
+func (k *Keyboard) poll() {
+	if k.ready || k.in == nil {
+		return
+	}
+	select {
+	case b, ok := <-k.in:
+		if !ok {
+			k.in = nil
+			return
+		}
+		k.key, k.ready = b, true
+	default:
+	}
+}
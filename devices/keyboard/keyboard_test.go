@@ -0,0 +1,99 @@
+package keyboard
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// waitReady polls k until a key is available from its goroutine reading the
+// host.
+func waitReady(t *testing.T, k *Keyboard) {
+	t.Helper()
+	for range 1_000_000 {
+		if k.Read(RegStatus)&StatusReady != 0 {
+			return
+		}
+		runtime.Gosched()
+	}
+	t.Fatalf("no key available")
+}
+
+func TestKeyboardReadsKeys(t *testing.T) {
+	k := New(strings.NewReader("RUN\r"))
+
+	for _, expected := range []byte("RUN\r") {
+		waitReady(t, k)
+		if actual := k.Peek(RegData); actual != expected {
+			t.Errorf("expected %q peeked, actual %q\n", expected, actual)
+		}
+		if actual := k.Read(RegData); actual != expected {
+			t.Errorf("expected %q, actual %q\n", expected, actual)
+		}
+		if status := k.Peek(RegStatus); status != 0 {
+			t.Errorf("expected no key available, actual status $%02X\n", status)
+		}
+	}
+	if actual := k.Read(RegData); actual != 0 {
+		t.Errorf("expected 0 without a key, actual %q\n", actual)
+	}
+}
+
+func TestKeyboardEndOfInput(t *testing.T) {
+	k := New(strings.NewReader(""))
+
+	for range 1000 {
+		if k.Pending() {
+			t.Fatalf("expected no key at the end of the input")
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestKeyboardMirrored(t *testing.T) {
+	k := New(strings.NewReader("x"))
+
+	for range 1_000_000 {
+		if k.Read(0xF006)&StatusReady != 0 {
+			break
+		}
+		runtime.Gosched()
+	}
+	if actual := k.Read(0xF007); actual != 'x' {
+		t.Errorf("expected %q, actual %q\n", 'x', actual)
+	}
+}
+
+func TestMakeRawNotTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "keys"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := MakeRaw(f); err == nil {
+		t.Errorf("expected an error making a file raw")
+	}
+}
+
+func TestMakeRawRestores(t *testing.T) {
+	f, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	defer f.Close()
+
+	restore, err := MakeRaw(f)
+	if errors.Is(err, ErrNoRawMode) {
+		t.Skipf("%v", err)
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := restore(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+package keyboard
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrNoRawMode is returned by MakeRaw where terminals cannot be put in raw
+// mode.
+var ErrNoRawMode = errors.New("keyboard: raw mode is not supported")
+
+// MakeRaw puts the terminal f in raw mode, for each key to be read as it is
+// typed, without being echoed, with the Return key read as a carriage
+// return. Output processing and the keys sending signals, such as Ctrl-C,
+// are left alone. It returns the function restoring the previous mode.
+func MakeRaw(f *os.File) (restore func() error, err error) {
+	return makeRaw(f)
+}
@@ -0,0 +1,34 @@
+//go:build linux
+
+package keyboard
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/leakedmemory/mos6502/internal/term"
+)
+
+func makeRaw(f *os.File) (func() error, error) {
+	var old syscall.Termios
+	if err := term.Ioctl(f, syscall.TCGETS, unsafe.Pointer(&old)); err != nil {
+		return nil, fmt.Errorf("keyboard: reading terminal mode: %w", err)
+	}
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.IEXTEN
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := term.Ioctl(f, syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+		return nil, fmt.Errorf("keyboard: setting raw mode: %w", err)
+	}
+	return func() error {
+		if err := term.Ioctl(f, syscall.TCSETS, unsafe.Pointer(&old)); err != nil {
+			return fmt.Errorf("keyboard: restoring terminal mode: %w", err)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package keyboard
+
+import "os"
+
+func makeRaw(*os.File) (func() error, error) {
+	return nil, ErrNoRawMode
+}
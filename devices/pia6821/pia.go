@@ -0,0 +1,280 @@
+// Package pia6821 emulates the 6821 Peripheral Interface Adapter, and the
+// 6520 it is compatible with: two 8-bit ports with their data direction
+// registers, and the control registers of their handshake lines CA1, CA2,
+// CB1 and CB2.
+//
+// The PIA occupies 4 addresses, mirrored across larger regions. Its pulse
+// outputs last a cycle of the CPU given to Tick, by a devices.Clock:
+//
+//	p := pia6821.New(nil, nil, keyboard, display)
+//	b.Map(0xD010, 0xD013, p)
+//	devices.NewClock(c, p)
+package pia6821
+
+import "github.com/leakedmemory/mos6502/devices"
+
+// Registers, by their offset.
+const (
+	regA = iota
+	regCRA
+	regB
+	regCRB
+)
+
+// Bits of the control registers.
+const (
+	// CRC1IRQ enables the interrupt on an active edge of C1.
+	CRC1IRQ byte = 0x01
+	// CRC1Rising makes the rising edge of C1 active, instead of the falling
+	// one.
+	CRC1Rising byte = 0x02
+	// CRPort selects the peripheral register, instead of the data direction
+	// register.
+	CRPort byte = 0x04
+	// CRC2IRQ enables the interrupt on an active edge of C2 as an input. As
+	// an output, it selects the pulse mode or the level of C2.
+	CRC2IRQ byte = 0x08
+	// CRC2Rising makes the rising edge of C2 as an input active. As an
+	// output, it selects the manual mode.
+	CRC2Rising byte = 0x10
+	// CRC2Output makes C2 an output.
+	CRC2Output byte = 0x20
+	// CRFlagC2 is set by an active edge of C2 as an input.
+	CRFlagC2 byte = 0x40
+	// CRFlagC1 is set by an active edge of C1.
+	CRFlagC1 byte = 0x80
+)
+
+// side is a port of the PIA with its control register and lines.
+type side struct {
+	irq      devices.IRQLine
+	asserted bool
+	port     devices.Port
+
+	or, ddr, cr byte
+	// c1 and c2 are the levels of the control lines, and pulse is set while
+	// C2 is low for a pulse.
+	c1, c2 bool
+	pulse  bool
+}
+
+// PIA is a 6821. Its zero value is not usable: use New.
+type PIA struct {
+	a, b side
+}
+
+// New returns a PIA asserting irqA and irqB, if not nil, wired to portA and
+// portB. A nil port reads as all ones, like pins left floating.
+func New(irqA, irqB devices.IRQLine, portA, portB devices.Port) *PIA {
+	p := &PIA{
+		a: side{irq: irqA, port: portA, c1: true, c2: true},
+		b: side{irq: irqB, port: portB, c1: true, c2: true},
+	}
+	p.Reset()
+	return p
+}
+
+// Reset clears the registers, like a low level on the RES pin.
+func (p *PIA) Reset() {
+	for _, s := range []*side{&p.a, &p.b} {
+		s.or, s.ddr, s.cr = 0, 0, 0
+		s.pulse = false
+		s.output()
+		s.updateIRQ()
+	}
+}
+
+// Read returns the register at addr. Reading a peripheral register clears
+// the flags of its control register, and lowers CA2 as a handshake or pulse
+// output.
+func (p *PIA) Read(addr uint16) byte {
+	val := p.Peek(addr)
+	switch addr & 0x03 {
+	case regA:
+		if p.a.cr&CRPort != 0 {
+			p.a.clearFlags()
+			p.a.handshake()
+		}
+	case regB:
+		if p.b.cr&CRPort != 0 {
+			p.b.clearFlags()
+		}
+	}
+	return val
+}
+
+// Peek returns the register at addr without side effects.
+func (p *PIA) Peek(addr uint16) byte {
+	switch addr & 0x03 {
+	case regA:
+		return p.a.data()
+	case regCRA:
+		return p.a.cr
+	case regB:
+		return p.b.data()
+	default:
+		return p.b.cr
+	}
+}
+
+// Write changes the register at addr to val. Writing port B lowers CB2 as a
+// handshake or pulse output.
+func (p *PIA) Write(val byte, addr uint16) {
+	switch addr & 0x03 {
+	case regA:
+		p.a.writeData(val)
+	case regCRA:
+		p.a.writeControl(val)
+	case regB:
+		p.b.writeData(val)
+		if p.b.cr&CRPort != 0 {
+			p.b.handshake()
+		}
+	default:
+		p.b.writeControl(val)
+	}
+}
+
+// Tick counts cycles of the CPU, ending the pulses on CA2 and CB2.
+func (p *PIA) Tick(cycles uint) {
+	if cycles == 0 {
+		return
+	}
+	for _, s := range []*side{&p.a, &p.b} {
+		if s.pulse {
+			s.pulse, s.c2 = false, true
+		}
+	}
+}
+
+// SetCA1 sets the level of CA1.
+func (p *PIA) SetCA1(level bool) {
+	p.a.setC1(level)
+}
+
+// SetCA2 sets the level of CA2, when it is an input.
+func (p *PIA) SetCA2(level bool) {
+	p.a.setC2(level)
+}
+
+// SetCB1 sets the level of CB1.
+func (p *PIA) SetCB1(level bool) {
+	p.b.setC1(level)
+}
+
+// SetCB2 sets the level of CB2, when it is an input.
+func (p *PIA) SetCB2(level bool) {
+	p.b.setC2(level)
+}
+
+// CA2 returns the level of CA2.
+func (p *PIA) CA2() bool {
+	return p.a.c2
+}
+
+// CB2 returns the level of CB2.
+func (p *PIA) CB2() bool {
+	return p.b.c2
+}
+
+// data returns the peripheral or the data direction register, as selected.
+func (s *side) data() byte {
+	if s.cr&CRPort == 0 {
+		return s.ddr
+	}
+	in := byte(0xFF)
+	if s.port != nil {
+		in = s.port.Input()
+	}
+	return s.or&s.ddr | in&^s.ddr
+}
+
+func (s *side) writeData(val byte) {
+	if s.cr&CRPort == 0 {
+		s.ddr = val
+	} else {
+		s.or = val
+	}
+	s.output()
+}
+
+// writeControl sets the control register but its flags, which are read-only.
+func (s *side) writeControl(val byte) {
+	s.cr = s.cr&(CRFlagC1|CRFlagC2) | val&^(CRFlagC1|CRFlagC2)
+	if s.cr&CRC2Output != 0 {
+		s.pulse = false
+		switch {
+		case s.cr&CRC2Rising != 0:
+			s.c2 = s.cr&CRC2IRQ != 0
+		default:
+			s.c2 = true
+		}
+		s.cr &^= CRFlagC2
+	}
+	s.updateIRQ()
+}
+
+// handshake lowers C2 when it is a handshake or pulse output.
+func (s *side) handshake() {
+	if s.cr&(CRC2Output|CRC2Rising) != CRC2Output {
+		return
+	}
+	s.c2 = false
+	s.pulse = s.cr&CRC2IRQ != 0
+}
+
+func (s *side) setC1(level bool) {
+	from := s.c1
+	s.c1 = level
+	if !activeEdge(from, level, s.cr&CRC1Rising != 0) {
+		return
+	}
+	s.cr |= CRFlagC1
+	if s.cr&(CRC2Output|CRC2Rising|CRC2IRQ) == CRC2Output {
+		// Handshake mode: the active edge of C1 raises C2.
+		s.c2 = true
+	}
+	s.updateIRQ()
+}
+
+func (s *side) setC2(level bool) {
+	if s.cr&CRC2Output != 0 {
+		return
+	}
+	from := s.c2
+	s.c2 = level
+	if activeEdge(from, level, s.cr&CRC2Rising != 0) {
+		s.cr |= CRFlagC2
+		s.updateIRQ()
+	}
+}
+
+func activeEdge(from, to, rising bool) bool {
+	if rising {
+		return !from && to
+	}
+	return from && !to
+}
+
+func (s *side) clearFlags() {
+	s.cr &^= CRFlagC1 | CRFlagC2
+	s.updateIRQ()
+}
+
+func (s *side) output() {
+	if s.port != nil {
+		s.port.Output(s.or, s.ddr)
+	}
+}
+
+// updateIRQ drives the IRQ output of the side from its flags and enables.
+func (s *side) updateIRQ() {
+	asserted := s.cr&CRFlagC1 != 0 && s.cr&CRC1IRQ != 0 ||
+		s.cr&CRFlagC2 != 0 && s.cr&CRC2IRQ != 0 && s.cr&CRC2Output == 0
+	if asserted != s.asserted {
+		s.asserted = asserted
+		if s.irq != nil {
+			s.irq.SetIRQ(asserted)
+		}
+	}
+}
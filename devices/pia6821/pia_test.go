@@ -0,0 +1,149 @@
+package pia6821
+
+import "testing"
+
+type testIRQ struct {
+	asserted bool
+}
+
+func (l *testIRQ) SetIRQ(asserted bool) {
+	l.asserted = asserted
+}
+
+type testPort struct {
+	in       byte
+	out, ddr byte
+}
+
+func (p *testPort) Input() byte {
+	return p.in
+}
+
+func (p *testPort) Output(val, ddr byte) {
+	p.out, p.ddr = val, ddr
+}
+
+func newPIATest() (*PIA, *testIRQ, *testPort, *testPort) {
+	irq := &testIRQ{}
+	a, b := &testPort{in: 0xFF}, &testPort{in: 0xFF}
+	return New(irq, nil, a, b), irq, a, b
+}
+
+func TestPIADataDirection(t *testing.T) {
+	p, _, a, _ := newPIATest()
+	a.in = 0x3C
+
+	p.Write(0xF0, regA)
+	p.Write(CRPort, regCRA)
+	p.Write(0xAA, regA)
+
+	if actual := p.Read(regA); actual != 0xAC {
+		t.Errorf("expected $AC, actual $%02X\n", actual)
+	}
+	if a.out != 0xAA || a.ddr != 0xF0 {
+		t.Errorf("unexpected output %+v\n", *a)
+	}
+	p.Write(0x00, regCRA)
+	if actual := p.Read(regA); actual != 0xF0 {
+		t.Errorf("expected the data direction register $F0, actual $%02X\n", actual)
+	}
+}
+
+// TestPIAKeyboard follows the keyboard of the Apple 1: a key strobes CA1 on
+// its rising edge, setting bit 7 of the control register until the key is
+// read.
+func TestPIAKeyboard(t *testing.T) {
+	p, irq, a, _ := newPIATest()
+	p.Write(CRPort|CRC1Rising, regCRA)
+
+	a.in = 'A' | 0x80
+	p.SetCA1(false)
+	p.SetCA1(true)
+
+	if p.Read(regCRA)&CRFlagC1 == 0 {
+		t.Fatalf("expected the flag of CA1 set")
+	}
+	if irq.asserted {
+		t.Errorf("expected no interrupt while it is disabled\n")
+	}
+	if actual := p.Read(regA); actual != 'A'|0x80 {
+		t.Errorf("expected $%02X, actual $%02X\n", 'A'|0x80, actual)
+	}
+	if p.Read(regCRA)&CRFlagC1 != 0 {
+		t.Errorf("expected reading the port to clear the flag\n")
+	}
+}
+
+func TestPIAInterrupts(t *testing.T) {
+	p, irq, _, _ := newPIATest()
+	p.Write(CRPort|CRC1IRQ|CRC2IRQ, regCRA)
+
+	p.SetCA2(false)
+	if !irq.asserted || p.Peek(regCRA)&CRFlagC2 == 0 {
+		t.Fatalf("expected an interrupt on the falling edge of CA2")
+	}
+	p.Read(regA)
+	if irq.asserted {
+		t.Errorf("expected reading the port to clear the interrupt\n")
+	}
+	p.SetCA1(false)
+	if !irq.asserted {
+		t.Errorf("expected an interrupt on the falling edge of CA1\n")
+	}
+}
+
+func TestPIAFlagsReadOnly(t *testing.T) {
+	p, _, _, _ := newPIATest()
+	p.SetCA1(false)
+
+	p.Write(0x00, regCRA)
+
+	if p.Peek(regCRA)&CRFlagC1 == 0 {
+		t.Errorf("expected writing the control register to leave the flags\n")
+	}
+}
+
+func TestPIACA2Handshake(t *testing.T) {
+	p, _, _, _ := newPIATest()
+	p.Write(CRPort|CRC2Output, regCRA)
+
+	p.Read(regA)
+	if p.CA2() {
+		t.Fatalf("expected CA2 low after reading port A")
+	}
+	p.Tick(10)
+	if p.CA2() {
+		t.Fatalf("expected CA2 to stay low until CA1")
+	}
+	p.SetCA1(false)
+	if !p.CA2() {
+		t.Errorf("expected CA2 high on the active edge of CA1\n")
+	}
+}
+
+func TestPIACB2Pulse(t *testing.T) {
+	p, _, _, _ := newPIATest()
+	p.Write(CRPort|CRC2Output|CRC2IRQ, regCRB)
+
+	p.Write(0x55, regB)
+	if p.CB2() {
+		t.Fatalf("expected CB2 low after writing port B")
+	}
+	p.Tick(1)
+	if !p.CB2() {
+		t.Errorf("expected CB2 high after a cycle\n")
+	}
+}
+
+func TestPIAManualC2(t *testing.T) {
+	p, _, _, _ := newPIATest()
+
+	p.Write(CRC2Output|CRC2Rising, regCRB)
+	if p.CB2() {
+		t.Errorf("expected CB2 low\n")
+	}
+	p.Write(CRC2Output|CRC2Rising|CRC2IRQ, regCRB)
+	if !p.CB2() {
+		t.Errorf("expected CB2 high\n")
+	}
+}
@@ -0,0 +1,18 @@
+package pia6821
+
+import "github.com/leakedmemory/mos6502/devices"
+
+func init() {
+	devices.Register(devices.Info{
+		Name:        "6821",
+		Description: "6821 Peripheral Interface Adapter, with IRQA and IRQB wired together",
+		Size:        4,
+		New: func(cfg devices.Config) (devices.Device, error) {
+			if cfg.IRQ == nil {
+				return New(nil, nil, cfg.PortA, cfg.PortB), nil
+			}
+			irq := devices.NewSharedIRQ(cfg.IRQ)
+			return New(irq.Input(), irq.Input(), cfg.PortA, cfg.PortB), nil
+		},
+	})
+}
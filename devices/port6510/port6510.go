@@ -0,0 +1,196 @@
+// Package port6510 emulates the on-chip I/O port of the 6510, at $00 and
+// $01, and the banking it controls in the Commodore 64: which of the RAM,
+// the BASIC and KERNAL ROMs, the character ROM and the I/O chips the CPU
+// sees at $A000-$BFFF, $D000-$DFFF and $E000-$FFFF.
+//
+// Banks is mapped over the whole address space, taking the place of the
+// RAM:
+//
+//	banks := port6510.NewBanks(basic, chargen, kernal, io)
+//	b.Map(0x0000, 0xFFFF, banks)
+//
+// The lines of the expansion port, GAME and EXROM, are left high, as
+// without a cartridge.
+package port6510
+
+import (
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/devices"
+)
+
+// Registers of the port.
+const (
+	RegDDR  uint16 = 0x0000
+	RegData uint16 = 0x0001
+)
+
+// Lines of the port, bits of its registers.
+const (
+	// LineLORAM and LineHIRAM select the BASIC and KERNAL ROMs, and
+	// LineCHAREN the I/O chips instead of the character ROM.
+	LineLORAM  byte = 0x01
+	LineHIRAM  byte = 0x02
+	LineCHAREN byte = 0x04
+	// LineCassetteOut, LineCassetteSense and LineCassetteMotor are wired
+	// to the cassette port.
+	LineCassetteOut   byte = 0x08
+	LineCassetteSense byte = 0x10
+	LineCassetteMotor byte = 0x20
+)
+
+// Port is the I/O port of the 6510: its data direction register and its
+// data register. Its zero value is a port with nothing wired to it, the
+// lines of which read high as inputs, as pulled up in the Commodore 64, so
+// that after a reset, with every line an input, the ROMs and the I/O chips
+// are seen.
+type Port struct {
+	// Pins is wired to the lines, such as the cassette port, if not nil.
+	Pins devices.Port
+
+	ddr, data byte
+}
+
+// Reset clears the registers, making every line an input.
+func (p *Port) Reset() {
+	p.ddr, p.data = 0, 0
+	p.output()
+}
+
+// Lines returns the levels of the lines of the port.
+func (p *Port) Lines() byte {
+	in := byte(0xFF)
+	if p.Pins != nil {
+		in = p.Pins.Input()
+	}
+	return p.data&p.ddr | in&^p.ddr
+}
+
+func (p *Port) Read(addr uint16) byte {
+	if addr == RegDDR {
+		return p.ddr
+	}
+	return p.Lines()
+}
+
+func (p *Port) Write(val byte, addr uint16) {
+	if addr == RegDDR {
+		p.ddr = val
+	} else {
+		p.data = val
+	}
+	p.output()
+}
+
+func (p *Port) output() {
+	if p.Pins != nil {
+		p.Pins.Output(p.data, p.ddr)
+	}
+}
+
+// Regions of the address space banked.
+const (
+	basicAddr  uint16 = 0xA000
+	basicEnd   uint16 = 0xBFFF
+	ioAddr     uint16 = 0xD000
+	ioEnd      uint16 = 0xDFFF
+	kernalAddr uint16 = 0xE000
+	romSize           = 0x2000
+	ioSize            = 0x1000
+)
+
+// Banks is the 64 KiB of RAM of the Commodore 64 with the port of the 6510
+// at $00 and $01, and the ROMs and I/O chips the port banks in. Writes to a
+// ROM go to the RAM below it. Its zero value is not usable: use NewBanks.
+type Banks struct {
+	Port Port
+	RAM  [0x10000]byte
+
+	basic, chargen, kernal []byte
+	io                     bus.Device
+}
+
+// NewBanks returns Banks with the ROMs basic, chargen and kernal, of 8, 4
+// and 8 KiB, and the I/O chips io, mapped on $D000-$DFFF, at their offsets
+// from $D000. A nil ROM or io leaves the RAM seen instead.
+func NewBanks(basic, chargen, kernal []byte, io bus.Device) *Banks {
+	return &Banks{basic: basic, chargen: chargen, kernal: kernal, io: io}
+}
+
+// Reset resets the port, banking the ROMs and the I/O chips in.
+func (b *Banks) Reset() {
+	b.Port.Reset()
+}
+
+// Read returns the content of addr as seen by the CPU.
+func (b *Banks) Read(addr uint16) byte {
+	if addr <= RegData {
+		return b.Port.Read(addr)
+	}
+	switch rom, off, io := b.bank(addr); {
+	case io:
+		return b.io.Read(off)
+	case rom != nil:
+		return rom[off]
+	default:
+		return b.RAM[addr]
+	}
+}
+
+// Peek returns the content of addr as seen by the CPU, without side effects
+// when the I/O chips implement Peek.
+func (b *Banks) Peek(addr uint16) byte {
+	if _, off, io := b.bank(addr); io {
+		if p, ok := b.io.(bus.Peeker); ok {
+			return p.Peek(off)
+		}
+	}
+	return b.Read(addr)
+}
+
+// Write changes the content of addr to val: the register of the port, or
+// the I/O chips when they are banked in, and the RAM otherwise.
+func (b *Banks) Write(val byte, addr uint16) {
+	if addr <= RegData {
+		// The RAM below is written as well, as seen by the video chip.
+		b.Port.Write(val, addr)
+	}
+	if _, off, io := b.bank(addr); io {
+		b.io.Write(val, off)
+		return
+	}
+	b.RAM[addr] = val
+}
+
+// Poke changes the RAM at addr to val, even below a ROM or the I/O chips,
+// such as to load a program.
+func (b *Banks) Poke(val byte, addr uint16) {
+	b.RAM[addr] = val
+}
+
+// bank returns the ROM seen at addr and the offset in it, or whether the I/O
+// chips are seen, at that offset, or neither if the RAM is.
+func (b *Banks) bank(addr uint16) (rom []byte, off uint16, io bool) {
+	lines := b.Port.Lines()
+	loram, hiram := lines&LineLORAM != 0, lines&LineHIRAM != 0
+	switch {
+	case addr >= basicAddr && addr <= basicEnd:
+		if loram && hiram && b.basic != nil {
+			return b.basic, addr - basicAddr, false
+		}
+	case addr >= ioAddr && addr <= ioEnd:
+		switch {
+		case !loram && !hiram:
+		case lines&LineCHAREN == 0:
+			if b.chargen != nil {
+				return b.chargen, addr - ioAddr, false
+			}
+		case b.io != nil:
+			return nil, addr - ioAddr, true
+		}
+	case addr >= kernalAddr:
+		if hiram && b.kernal != nil {
+			return b.kernal, addr - kernalAddr, false
+		}
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,147 @@
+package port6510
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/bus"
+)
+
+type testPort struct {
+	in       byte
+	out, ddr byte
+}
+
+func (p *testPort) Input() byte {
+	return p.in
+}
+
+func (p *testPort) Output(val, ddr byte) {
+	p.out, p.ddr = val, ddr
+}
+
+func newROM(size int, val byte) []byte {
+	rom := make([]byte, size)
+	for i := range rom {
+		rom[i] = val
+	}
+	return rom
+}
+
+func newBanksTest() *Banks {
+	io := bus.NewRAM(ioSize)
+	for i := range io {
+		io[i] = 0x10
+	}
+	return NewBanks(newROM(romSize, 0xBA), newROM(ioSize, 0xC4), newROM(romSize, 0xE4), io)
+}
+
+func TestBanksConfigurations(t *testing.T) {
+	tests := []struct {
+		lines             byte
+		basic, io, kernal byte
+	}{
+		{0x07, 0xBA, 0x10, 0xE4},
+		{0x06, 0x00, 0x10, 0xE4},
+		{0x05, 0x00, 0x10, 0x00},
+		{0x04, 0x00, 0x00, 0x00},
+		{0x03, 0xBA, 0xC4, 0xE4},
+		{0x02, 0x00, 0xC4, 0xE4},
+		{0x01, 0x00, 0xC4, 0x00},
+		{0x00, 0x00, 0x00, 0x00},
+	}
+	for _, tt := range tests {
+		b := newBanksTest()
+		b.Write(0x07, RegDDR)
+		b.Write(tt.lines, RegData)
+
+		actual := [3]byte{b.Read(0xA000), b.Read(0xD000), b.Read(0xE000)}
+		if expected := [3]byte{tt.basic, tt.io, tt.kernal}; actual != expected {
+			t.Errorf("lines %03b: expected %+v, actual %+v\n", tt.lines, expected, actual)
+		}
+	}
+}
+
+// TestBanksReset checks that the ROMs and the I/O chips are seen after a
+// reset, with every line of the port an input pulled high.
+func TestBanksReset(t *testing.T) {
+	b := newBanksTest()
+	b.Write(0x2F, RegDDR)
+	b.Write(0x30, RegData)
+	b.Reset()
+
+	if actual := b.Read(RegData); actual != 0xFF {
+		t.Errorf("expected $FF, actual $%02X\n", actual)
+	}
+	if actual := b.Read(0xE000); actual != 0xE4 {
+		t.Errorf("expected the KERNAL $E4, actual $%02X\n", actual)
+	}
+}
+
+func TestBanksWriteBelowROM(t *testing.T) {
+	b := newBanksTest()
+	b.Write(0x2F, RegDDR)
+	b.Write(0x37, RegData)
+
+	b.Write(0x42, 0xA000)
+	if actual := b.Read(0xA000); actual != 0xBA {
+		t.Fatalf("expected the BASIC ROM $BA, actual $%02X", actual)
+	}
+	b.Write(0x36, RegData)
+	if actual := b.Read(0xA000); actual != 0x42 {
+		t.Errorf("expected the RAM below $42, actual $%02X\n", actual)
+	}
+}
+
+func TestBanksWriteIO(t *testing.T) {
+	b := newBanksTest()
+	b.Write(0x2F, RegDDR)
+	b.Write(0x37, RegData)
+
+	b.Write(0x55, 0xD020)
+	if actual := b.io.Read(0x0020); actual != 0x55 {
+		t.Errorf("expected the I/O chips written $55, actual $%02X\n", actual)
+	}
+	if actual := b.RAM[0xD020]; actual != 0x00 {
+		t.Errorf("expected the RAM below left $00, actual $%02X\n", actual)
+	}
+}
+
+func TestBanksPort(t *testing.T) {
+	b := newBanksTest()
+	pins := &testPort{in: 0xEF}
+	b.Port.Pins = pins
+
+	b.Write(0x2F, RegDDR)
+	b.Write(0x27, RegData)
+
+	if actual := b.Read(RegData); actual != 0xE7 {
+		t.Errorf("expected $E7, actual $%02X\n", actual)
+	}
+	if actual := b.Read(RegDDR); actual != 0x2F {
+		t.Errorf("expected $2F, actual $%02X\n", actual)
+	}
+	if pins.out != 0x27 || pins.ddr != 0x2F {
+		t.Errorf("unexpected output %+v\n", *pins)
+	}
+	if b.RAM[RegData] != 0x27 {
+		t.Errorf("expected the RAM below written $27, actual $%02X\n", b.RAM[RegData])
+	}
+}
+
+func TestBanksOnBus(t *testing.T) {
+	b := bus.New()
+	banks := newBanksTest()
+	if err := b.Map(0x0000, 0xFFFF, banks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b.Poke(0x99, 0xE000)
+	if actual := b.Read(0xE000); actual != 0xE4 {
+		t.Fatalf("expected the KERNAL $E4, actual $%02X", actual)
+	}
+	b.Write(0x2F, 0x0000)
+	b.Write(0x35, 0x0001)
+	if actual := b.Read(0xE000); actual != 0x99 {
+		t.Errorf("expected the RAM poked $99, actual $%02X\n", actual)
+	}
+}
@@ -0,0 +1,89 @@
+package devices
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/internal/registry"
+)
+
+// ErrUnknownDevice is returned by New for a name no device was registered
+// with.
+var ErrUnknownDevice = errors.New("devices: unknown device")
+
+// Device is a chip mapped on the bus and clocked by the CPU, such as those
+// of the subpackages, or chips of other packages contributed with Register.
+// Its interrupt output is the IRQLine of its Config.
+type Device interface {
+	bus.Device
+	Clocked
+	// Reset resets the chip, like a low level on its RES pin.
+	Reset()
+}
+
+// StatefulDevice is a Device whose state can be saved and restored, beyond
+// what it shows in the address space, such as by a savestate.
+type StatefulDevice interface {
+	Device
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// Config is what a registered device is created with.
+type Config struct {
+	// Bus is the bus the device is mapped on, for those mastering it, such
+	// as DMA controllers.
+	Bus *bus.Bus
+	// IRQ is the line the device asserts, if not nil.
+	IRQ IRQLine
+	// PortA and PortB are wired to the ports of the device, if not nil.
+	PortA, PortB Port
+	// Options are settings specific to the device.
+	Options map[string]string
+}
+
+// Info describes a registered device.
+type Info struct {
+	// Name is the name the device is created by, such as "6522".
+	Name        string
+	Description string
+	// Size is the number of addresses the device occupies, mirrored across
+	// larger regions.
+	Size int
+	// New returns a device configured by cfg.
+	New func(cfg Config) (Device, error)
+}
+
+var registered = registry.New[Info]("devices")
+
+// Register makes a device available by its name, usually from the init
+// function of the package emulating it. It panics if the name was already
+// registered or New is nil.
+func Register(info Info) {
+	registered.Register(info.Name, info, info.New != nil)
+}
+
+// Lookup returns the device registered with name, if any.
+func Lookup(name string) (Info, bool) {
+	return registered.Lookup(name)
+}
+
+// Registered returns the names of the devices registered, sorted.
+func Registered() []string {
+	return registered.Names()
+}
+
+// New returns the device registered with name, configured by cfg.
+func New(name string, cfg Config) (Device, error) {
+	info, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("devices: %q: %w", name, ErrUnknownDevice)
+	}
+	d, err := info.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("devices: %s: %w", name, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,14 @@
+package riot6532
+
+import "github.com/leakedmemory/mos6502/devices"
+
+func init() {
+	devices.Register(devices.Info{
+		Name:        "6532",
+		Description: "6532 RAM-I/O-Timer, its I/O registers and timer",
+		Size:        32,
+		New: func(cfg devices.Config) (devices.Device, error) {
+			return New(cfg.IRQ, cfg.PortA, cfg.PortB), nil
+		},
+	})
+}
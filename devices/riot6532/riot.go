@@ -0,0 +1,231 @@
+// Package riot6532 emulates the 6532 RAM-I/O-Timer: 128 bytes of RAM, two
+// 8-bit ports with their data direction registers, an interval timer with a
+// selectable prescaler, and an interrupt on an edge of PA7.
+//
+// The RAM and the I/O registers are selected by the RS pin, and are mapped as
+// two devices, such as at $0080 and $0280 in the Atari 2600:
+//
+//	r := riot6532.New(c, nil, nil)
+//	b.Map(0x0080, 0x00FF, r.RAM())
+//	b.Map(0x0280, 0x029F, r)
+//	devices.NewClock(c, r)
+package riot6532
+
+import "github.com/leakedmemory/mos6502/devices"
+
+// RAMSize is the size of the RAM.
+const RAMSize = 128
+
+// Bits of the I/O addresses.
+const (
+	addrTimer     uint16 = 0x04
+	addrWriteTime uint16 = 0x10
+	addrTimerIRQ  uint16 = 0x08
+	addrFlags     uint16 = 0x01
+	addrEdgeIRQ   uint16 = 0x02
+	addrEdgeRise  uint16 = 0x01
+)
+
+// Bits of the interrupt flag register.
+const (
+	FlagPA7   byte = 0x40
+	FlagTimer byte = 0x80
+)
+
+// prescalers are the intervals selected by the low bits of the address a
+// timer value is written to.
+var prescalers = [4]uint{1, 8, 64, 1024}
+
+// RIOT is a 6532. It is the device of the I/O registers and the timer. Its
+// zero value is not usable: use New.
+type RIOT struct {
+	irq          devices.IRQLine
+	asserted     bool
+	portA, portB devices.Port
+	ram          RAM
+
+	ora, orb, ddra, ddrb byte
+
+	timer byte
+	// interval is the number of cycles between decrements of the timer, and
+	// left the number of cycles until the next one. The timer decrements
+	// every cycle once it expires.
+	interval, left uint
+	flags          byte
+	timerIRQ       bool
+
+	edgeIRQ, edgeRise bool
+	pa7               bool
+}
+
+// New returns a RIOT asserting irq, if not nil, wired to portA and portB. A
+// nil port reads as all ones, like pins left floating.
+func New(irq devices.IRQLine, portA, portB devices.Port) *RIOT {
+	r := &RIOT{irq: irq, portA: portA, portB: portB, interval: prescalers[3], left: prescalers[3]}
+	r.Reset()
+	return r
+}
+
+// Reset clears the ports, their data direction registers and the interrupt
+// enables, like a low level on the RES pin. The RAM and the timer are left
+// alone.
+func (r *RIOT) Reset() {
+	r.ora, r.orb, r.ddra, r.ddrb = 0, 0, 0, 0
+	r.timerIRQ, r.edgeIRQ, r.edgeRise = false, false, false
+	r.pa7 = r.portAValue()&0x80 != 0
+	r.updateIRQ()
+}
+
+// RAM returns the device of the RAM, 128 bytes mirrored across its region.
+func (r *RIOT) RAM() *RAM {
+	return &r.ram
+}
+
+// Read returns the register at addr, with the side effects of reading it:
+// reading the timer clears its flag and sets its interrupt enable from bit 3
+// of addr, and reading the flags clears that of PA7.
+func (r *RIOT) Read(addr uint16) byte {
+	val := r.Peek(addr)
+	if addr&addrTimer != 0 {
+		if addr&addrFlags == 0 {
+			r.flags &^= FlagTimer
+			r.timerIRQ = addr&addrTimerIRQ != 0
+		} else {
+			r.flags &^= FlagPA7
+		}
+		r.updateIRQ()
+	}
+	return val
+}
+
+// Peek returns the register at addr without side effects.
+func (r *RIOT) Peek(addr uint16) byte {
+	if addr&addrTimer != 0 {
+		if addr&addrFlags == 0 {
+			return r.timer
+		}
+		return r.flags
+	}
+	switch addr & 0x03 {
+	case 0:
+		return r.portAValue()
+	case 1:
+		return r.ddra
+	case 2:
+		return r.orb&r.ddrb | r.portInput(r.portB)&^r.ddrb
+	default:
+		return r.ddrb
+	}
+}
+
+// Write changes the register at addr to val. With bit 2 of addr set, bit 4
+// selects between starting the timer from val, with the prescaler selected
+// by bits 1-0 and its interrupt enabled by bit 3, and setting the edge of
+// PA7 that interrupts, rising with bit 0 set, with its interrupt enabled by
+// bit 1.
+func (r *RIOT) Write(val byte, addr uint16) {
+	if addr&addrTimer != 0 {
+		if addr&addrWriteTime != 0 {
+			r.timer = val
+			r.interval = prescalers[addr&0x03]
+			r.left = 1
+			r.flags &^= FlagTimer
+			r.timerIRQ = addr&addrTimerIRQ != 0
+		} else {
+			r.edgeRise = addr&addrEdgeRise != 0
+			r.edgeIRQ = addr&addrEdgeIRQ != 0
+		}
+		r.updateIRQ()
+		return
+	}
+	switch addr & 0x03 {
+	case 0:
+		r.ora = val
+		r.outputA()
+	case 1:
+		r.ddra = val
+		r.outputA()
+	case 2:
+		r.orb = val
+		r.outputB()
+	default:
+		r.ddrb = val
+		r.outputB()
+	}
+}
+
+// Tick counts cycles of the CPU, which clocks the RIOT, and samples PA7.
+func (r *RIOT) Tick(cycles uint) {
+	for cycles != 0 {
+		if cycles < r.left {
+			r.left -= cycles
+			break
+		}
+		cycles -= r.left
+		r.timer--
+		r.left = r.interval
+		if r.timer == 0xFF && r.flags&FlagTimer == 0 {
+			r.flags |= FlagTimer
+			r.interval = 1
+			r.left = 1
+		}
+	}
+	r.SamplePA7()
+}
+
+// SamplePA7 samples the level of PA7, setting the flag of PA7 on the edge
+// selected. Tick samples it, and peripherals can call it after changing the
+// input for the edge to be seen at once.
+func (r *RIOT) SamplePA7() {
+	pa7 := r.portAValue()&0x80 != 0
+	if pa7 != r.pa7 && pa7 == r.edgeRise {
+		r.flags |= FlagPA7
+	}
+	r.pa7 = pa7
+	r.updateIRQ()
+}
+
+func (r *RIOT) portInput(p devices.Port) byte {
+	if p == nil {
+		return 0xFF
+	}
+	return p.Input()
+}
+
+func (r *RIOT) portAValue() byte {
+	return r.ora&r.ddra | r.portInput(r.portA)&^r.ddra
+}
+
+func (r *RIOT) outputA() {
+	if r.portA != nil {
+		r.portA.Output(r.ora, r.ddra)
+	}
+}
+
+func (r *RIOT) outputB() {
+	if r.portB != nil {
+		r.portB.Output(r.orb, r.ddrb)
+	}
+}
+
+// updateIRQ drives the IRQ output from the flags and their enables.
+func (r *RIOT) updateIRQ() {
+	asserted := r.timerIRQ && r.flags&FlagTimer != 0 || r.edgeIRQ && r.flags&FlagPA7 != 0
+	if asserted != r.asserted {
+		r.asserted = asserted
+		if r.irq != nil {
+			r.irq.SetIRQ(asserted)
+		}
+	}
+}
+
+// RAM is the RAM of a RIOT, mirrored every 128 bytes.
+type RAM [RAMSize]byte
+
+func (m *RAM) Read(addr uint16) byte {
+	return m[addr%RAMSize]
+}
+
+func (m *RAM) Write(val byte, addr uint16) {
+	m[addr%RAMSize] = val
+}
@@ -0,0 +1,136 @@
+package riot6532
+
+import "testing"
+
+type testIRQ struct {
+	asserted bool
+}
+
+func (l *testIRQ) SetIRQ(asserted bool) {
+	l.asserted = asserted
+}
+
+type testPort struct {
+	in       byte
+	out, ddr byte
+}
+
+func (p *testPort) Input() byte {
+	return p.in
+}
+
+func (p *testPort) Output(val, ddr byte) {
+	p.out, p.ddr = val, ddr
+}
+
+// Addresses of the I/O registers, as on the Atari 2600.
+const (
+	swcha  uint16 = 0x00
+	swacnt uint16 = 0x01
+	swchb  uint16 = 0x02
+	swbcnt uint16 = 0x03
+	intim  uint16 = 0x04
+	instat uint16 = 0x05
+	tim1t  uint16 = 0x14
+	tim64t uint16 = 0x16
+	// tim8tIRQ writes the timer with an interval of 8 and its interrupt
+	// enabled.
+	tim8tIRQ uint16 = 0x1D
+)
+
+func newRIOTTest() (*RIOT, *testIRQ, *testPort, *testPort) {
+	irq := &testIRQ{}
+	a, b := &testPort{in: 0xFF}, &testPort{in: 0xFF}
+	return New(irq, a, b), irq, a, b
+}
+
+func TestRIOTRAMMirrored(t *testing.T) {
+	r, _, _, _ := newRIOTTest()
+
+	r.RAM().Write(0x42, 0x0005)
+
+	if actual := r.RAM().Read(0x0085); actual != 0x42 {
+		t.Errorf("expected $42, actual $%02X\n", actual)
+	}
+}
+
+func TestRIOTPorts(t *testing.T) {
+	r, _, a, b := newRIOTTest()
+	a.in, b.in = 0x0F, 0xF0
+
+	r.Write(0xF0, swacnt)
+	r.Write(0xA0, swcha)
+	r.Write(0xFF, swbcnt)
+	r.Write(0x12, swchb)
+
+	if actual := r.Read(swcha); actual != 0xAF {
+		t.Errorf("expected $AF, actual $%02X\n", actual)
+	}
+	if actual := r.Read(swchb); actual != 0x12 {
+		t.Errorf("expected $12, actual $%02X\n", actual)
+	}
+	if a.out != 0xA0 || a.ddr != 0xF0 || b.out != 0x12 || b.ddr != 0xFF {
+		t.Errorf("unexpected outputs %+v and %+v\n", *a, *b)
+	}
+}
+
+func TestRIOTTimer(t *testing.T) {
+	r, _, _, _ := newRIOTTest()
+
+	r.Write(0x03, tim64t)
+	r.Tick(1)
+	if actual := r.Read(intim); actual != 0x02 {
+		t.Fatalf("expected $02 after a cycle, actual $%02X", actual)
+	}
+	r.Tick(64)
+	if actual := r.Read(intim); actual != 0x01 {
+		t.Fatalf("expected $01 after 65 cycles, actual $%02X", actual)
+	}
+	r.Tick(128)
+	if actual := r.Peek(intim); actual != 0xFF || r.Peek(instat)&FlagTimer == 0 {
+		t.Fatalf("expected the timer to expire, actual $%02X, flags $%02X", actual, r.Peek(instat))
+	}
+	r.Tick(5)
+	if actual := r.Peek(intim); actual != 0xFA {
+		t.Errorf("expected the timer to count every cycle once expired, actual $%02X\n", actual)
+	}
+	r.Read(intim)
+	if r.Peek(instat)&FlagTimer != 0 {
+		t.Errorf("expected reading the timer to clear its flag\n")
+	}
+}
+
+func TestRIOTTimerIRQ(t *testing.T) {
+	r, irq, _, _ := newRIOTTest()
+
+	r.Write(0x01, tim8tIRQ)
+	r.Tick(1 + 8)
+	if !irq.asserted {
+		t.Fatalf("expected an interrupt once the timer expires")
+	}
+	r.Write(0x10, tim1t)
+	if irq.asserted {
+		t.Errorf("expected writing the timer without bit 3 to clear the interrupt\n")
+	}
+}
+
+func TestRIOTPA7Edge(t *testing.T) {
+	r, irq, a, _ := newRIOTTest()
+	// Interrupt on a rising edge.
+	r.Write(0x00, 0x07)
+
+	a.in = 0x7F
+	r.SamplePA7()
+	if r.Peek(instat)&FlagPA7 != 0 {
+		t.Fatalf("expected no flag on a falling edge")
+	}
+	a.in = 0xFF
+	r.Tick(1)
+	if r.Peek(instat)&FlagPA7 == 0 || !irq.asserted {
+		t.Fatalf("expected a flag and an interrupt on a rising edge, actual flags $%02X", r.Peek(instat))
+	}
+	r.Read(instat)
+	if r.Peek(instat)&FlagPA7 != 0 || irq.asserted {
+		t.Errorf("expected reading the flags to clear that of PA7\n")
+	}
+}
@@ -0,0 +1,45 @@
+// Package rng emulates a random number generator, not modeled on any chip:
+// an address reading as a new random byte at every read, such as $FE in the
+// easy6502 simulator.
+//
+// The generator occupies an address, mirrored across larger regions:
+//
+//	r := rng.New(rand.New(rand.NewSource(1)))
+//	b.Map(rng.DefaultAddr, rng.DefaultAddr, r)
+package rng
+
+import (
+	"math/rand"
+	"time"
+)
+
+// DefaultAddr is the address of the generator in the easy6502 simulator.
+const DefaultAddr uint16 = 0x00FE
+
+// RNG is a random number generator. Its zero value is not usable: use New.
+type RNG struct {
+	rng *rand.Rand
+}
+
+// New returns an RNG drawing the bytes from r, such as seeded for the
+// program to run the same every time, or from a generator seeded with the
+// time if r is nil.
+func New(r *rand.Rand) *RNG {
+	if r == nil {
+		r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return &RNG{rng: r}
+}
+
+// Read returns a new random byte.
+func (r *RNG) Read(uint16) byte {
+	return byte(r.rng.Intn(0x100))
+}
+
+// Peek returns 0, as only reads draw random bytes.
+func (r *RNG) Peek(uint16) byte {
+	return 0
+}
+
+// Write ignores val.
+func (r *RNG) Write(byte, uint16) {}
@@ -0,0 +1,37 @@
+package rng
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRNGSeeded(t *testing.T) {
+	a, b := New(rand.New(rand.NewSource(42))), New(rand.New(rand.NewSource(42)))
+
+	for i := range uint16(32) {
+		if x, y := a.Read(i), b.Read(DefaultAddr); x != y {
+			t.Fatalf("expected generators with the same seed to draw the same bytes, actual $%02X and $%02X", x, y)
+		}
+	}
+	if actual := a.Peek(0); actual != 0 {
+		t.Errorf("expected peeking to draw nothing, actual $%02X\n", actual)
+	}
+}
+
+func TestRNGUnseeded(t *testing.T) {
+	r := New(nil)
+	var seen [0x100]bool
+	for range 4096 {
+		seen[r.Read(0)] = true
+	}
+
+	n := 0
+	for _, s := range seen {
+		if s {
+			n++
+		}
+	}
+	if n < 0xF0 {
+		t.Errorf("expected nearly every byte drawn, actual %d distinct\n", n)
+	}
+}
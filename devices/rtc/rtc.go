@@ -0,0 +1,102 @@
+// Package rtc emulates a real-time clock, not modeled on any chip: the time
+// and the date of the host, or of a fixed time for tests, in BCD registers a
+// program reads to display or log them.
+//
+// The clock occupies 8 addresses, mirrored across larger regions:
+//
+//	r := rtc.New(nil, rtc.Options{Latched: true})
+//	b.Map(0xD300, 0xD307, r)
+package rtc
+
+import "time"
+
+// Registers, by their offset, in BCD.
+const (
+	RegSeconds = iota
+	RegMinutes
+	RegHours
+	// RegWeekday reads the day of the week, 0 for Sunday.
+	RegWeekday
+	RegDay
+	RegMonth
+	// RegYear reads the year in the century, and RegCentury the century,
+	// 20 for 2024.
+	RegYear
+	RegCentury
+)
+
+// Options configures the reads of the clock.
+type Options struct {
+	// Latched makes reading RegSeconds latch the time, the other registers
+	// reading the time it was read at, so that a program reading the seconds
+	// first reads a consistent time, not one changing between reads. Until
+	// the first read of the seconds, they read the time the clock was reset.
+	// Otherwise every register reads the current time.
+	Latched bool
+}
+
+// RTC is a real-time clock. Its zero value is not usable: use New.
+type RTC struct {
+	now   func() time.Time
+	opts  Options
+	latch time.Time
+}
+
+// New returns an RTC reading the time now returns, such as a fixed time for
+// tests, or that of the host if now is nil.
+func New(now func() time.Time, opts Options) *RTC {
+	if now == nil {
+		now = time.Now
+	}
+	r := &RTC{now: now, opts: opts}
+	r.Reset()
+	return r
+}
+
+// Reset latches the current time.
+func (r *RTC) Reset() {
+	r.latch = r.now()
+}
+
+// Read returns the register at addr. Reading RegSeconds latches the time,
+// if the clock is latched.
+func (r *RTC) Read(addr uint16) byte {
+	if r.opts.Latched && addr&0x07 == RegSeconds {
+		r.latch = r.now()
+	}
+	return r.Peek(addr)
+}
+
+// Peek returns the register at addr without side effects.
+func (r *RTC) Peek(addr uint16) byte {
+	t := r.latch
+	if !r.opts.Latched {
+		t = r.now()
+	}
+	switch addr & 0x07 {
+	case RegSeconds:
+		return bcd(t.Second())
+	case RegMinutes:
+		return bcd(t.Minute())
+	case RegHours:
+		return bcd(t.Hour())
+	case RegWeekday:
+		return bcd(int(t.Weekday()))
+	case RegDay:
+		return bcd(t.Day())
+	case RegMonth:
+		return bcd(int(t.Month()))
+	case RegYear:
+		return bcd(t.Year() % 100)
+	default:
+		return bcd(t.Year() / 100 % 100)
+	}
+}
+
+// Write ignores val: the time is that of the host.
+func (r *RTC) Write(byte, uint16) {}
+
+// bcd returns n, from 0 to 99, in BCD.
+func bcd(n int) byte {
+	return byte(n/10<<4 | n%10)
+}
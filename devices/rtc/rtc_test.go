@@ -0,0 +1,39 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRTCRegisters(t *testing.T) {
+	now := time.Date(2024, time.March, 9, 23, 59, 58, 0, time.UTC)
+	r := New(func() time.Time { return now }, Options{})
+
+	expected := [8]byte{0x58, 0x59, 0x23, 0x06, 0x09, 0x03, 0x24, 0x20}
+	var actual [8]byte
+	for i := range actual {
+		actual[i] = r.Read(0xD300 + uint16(i))
+	}
+	if actual != expected {
+		t.Errorf("expected % X, actual % X\n", expected, actual)
+	}
+}
+
+func TestRTCLatched(t *testing.T) {
+	now := time.Date(2024, time.December, 31, 23, 59, 59, 0, time.UTC)
+	r := New(func() time.Time { return now }, Options{Latched: true})
+
+	if actual := r.Read(RegSeconds); actual != 0x59 {
+		t.Fatalf("expected $59, actual $%02X", actual)
+	}
+	now = now.Add(time.Second)
+	if year, century := r.Read(RegYear), r.Read(RegCentury); year != 0x24 || century != 0x20 {
+		t.Errorf("expected the year latched $2024, actual $%02X%02X\n", century, year)
+	}
+	if actual := r.Read(RegSeconds); actual != 0x00 {
+		t.Errorf("expected reading the seconds to latch the time, actual $%02X\n", actual)
+	}
+	if actual := r.Read(RegYear); actual != 0x25 {
+		t.Errorf("expected $25, actual $%02X\n", actual)
+	}
+}
@@ -0,0 +1,164 @@
+package devices
+
+import (
+	"container/heap"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// EventFunc is run by a Scheduler at the cycle it was posted for.
+type EventFunc func()
+
+// Scheduler is a Clock with a queue of events stamped with cycles: it ticks
+// the devices with the cycles the CPU runs, on a counter of its own shared by
+// the devices, and runs the events posted for a cycle once the devices were
+// ticked up to it, such as the expiry of a timer asserting an interrupt. The
+// events run between instructions, as soon as the CPU has run up to their
+// cycle.
+type Scheduler struct {
+	cpu     *cpu.CPU
+	devices []Clocked
+	hook    int
+
+	// now is the count of cycles ticked, and last the cycles of the CPU at
+	// the last tick.
+	now, last uint
+	events    eventQueue
+	seq       uint64
+}
+
+// NewScheduler returns a Scheduler ticking devs with the cycles c runs from
+// now on.
+func NewScheduler(c *cpu.CPU, devs ...Clocked) *Scheduler {
+	s := &Scheduler{cpu: c, devices: devs, last: c.Cycles()}
+	s.hook = c.AddInstructionHook(func(cpu.InstructionEvent) { s.Sync() })
+	return s
+}
+
+// Add adds d to the devices ticked.
+func (s *Scheduler) Add(d Clocked) {
+	s.devices = append(s.devices, d)
+}
+
+// Now returns the number of cycles ticked since the Scheduler was created,
+// as of the last tick. It keeps counting across resets of the CPU.
+func (s *Scheduler) Now() uint {
+	return s.now
+}
+
+// At posts fn to run once the devices were ticked up to the cycle at, as
+// returned by Now, or at the next Sync if it has passed. Events posted for
+// the same cycle run in the order they were posted. The event can be
+// cancelled with the ID returned.
+func (s *Scheduler) At(at uint, fn EventFunc) int {
+	s.seq++
+	heap.Push(&s.events, &event{at: at, seq: s.seq, fn: fn})
+	return int(s.seq)
+}
+
+// After posts fn to run delay cycles after Now, like At.
+func (s *Scheduler) After(delay uint, fn EventFunc) int {
+	return s.At(s.now+delay, fn)
+}
+
+// Cancel removes the event with the ID id, if it has not run yet.
+func (s *Scheduler) Cancel(id int) {
+	for i, e := range s.events {
+		if e.seq == uint64(id) {
+			heap.Remove(&s.events, i)
+			return
+		}
+	}
+}
+
+// Next returns the cycle of the next event, if any.
+func (s *Scheduler) Next() (at uint, ok bool) {
+	if len(s.events) == 0 {
+		return 0, false
+	}
+	return s.events[0].at, true
+}
+
+// Sync ticks the devices with the cycles run since the last tick, stopping
+// at the cycle of each event due to run it. It is called after every
+// instruction, and can be called between instructions to bring the devices
+// up to date, such as after an interrupt entry.
+func (s *Scheduler) Sync() {
+	now := s.cpu.Cycles()
+	if now < s.last {
+		// The CPU was reset.
+		s.last = now
+	}
+	until := s.now + now - s.last
+	s.last = now
+	for len(s.events) != 0 && s.events[0].at <= until {
+		e := heap.Pop(&s.events).(*event)
+		if e.at > s.now {
+			s.tick(e.at - s.now)
+		}
+		e.fn()
+	}
+	if until > s.now {
+		s.tick(until - s.now)
+	}
+}
+
+// RunFor runs the CPU like cpu.CPU.RunFor, and brings the devices up to date
+// before returning, with the cycles of a stall or an interrupt entry at the
+// end. Use it to run the machine for a frame or a time slice.
+func (s *Scheduler) RunFor(cycles uint) (cpu.StopReason, error) {
+	s.Sync()
+	reason, err := s.cpu.RunFor(cycles)
+	s.Sync()
+	return reason, err
+}
+
+// Stop stops ticking the devices and running the events.
+func (s *Scheduler) Stop() {
+	s.cpu.RemoveInstructionHook(s.hook)
+}
+
+func (s *Scheduler) tick(cycles uint) {
+	s.now += cycles
+	for _, d := range s.devices {
+		d.Tick(cycles)
+	}
+}
+
+// event is an EventFunc posted for the cycle at, seq ordering those posted
+// for the same cycle.
+type event struct {
+	at  uint
+	seq uint64
+	fn  EventFunc
+}
+
+// eventQueue is a heap of events, the earliest first.
+type eventQueue []*event
+
+func (q eventQueue) Len() int {
+	return len(q)
+}
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q eventQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *eventQueue) Push(x any) {
+	*q = append(*q, x.(*event))
+}
+
+func (q *eventQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,180 @@
+// Package textscreen emulates a text-mode display, not modeled on any video
+// chip: a matrix of characters in video memory, drawn on the terminal of
+// the host with ANSI escape codes, so that text-mode machines have a screen
+// without a graphical interface.
+//
+// The video memory is mapped as a device, a byte by character, row by row.
+// The screen is redrawn at its refresh rate, if the memory changed, from the
+// cycles of the CPU given to Tick, by a devices.Clock:
+//
+//	s := textscreen.New(os.Stdout, textscreen.Options{})
+//	b.Map(0x0400, 0x0400+uint16(s.Size())-1, s)
+//	devices.NewClock(c, s)
+package textscreen
+
+import (
+	"io"
+	"strings"
+)
+
+// Defaults of Options.
+const (
+	DefaultCols      = 40
+	DefaultRows      = 25
+	DefaultClockHz   = 1_000_000
+	DefaultRefreshHz = 30
+)
+
+// ReverseBit shows the character of the low 7 bits in reverse video.
+const ReverseBit byte = 0x80
+
+// Options configures the size and the refresh rate of the screen.
+type Options struct {
+	// Cols and Rows are the size of the screen, in characters,
+	// DefaultCols and DefaultRows if zero.
+	Cols, Rows int
+	// ClockHz is the clock rate of the CPU, DefaultClockHz if zero, and
+	// RefreshHz the number of times the screen is redrawn a second,
+	// DefaultRefreshHz if zero.
+	ClockHz, RefreshHz uint
+}
+
+// Screen is a text-mode display. Its zero value is not usable: use New.
+type Screen struct {
+	w    io.Writer
+	err  error
+	opts Options
+
+	mem   []byte
+	dirty bool
+	// left is the number of cycles until the next refresh.
+	left uint
+}
+
+// New returns a Screen drawn on w.
+func New(w io.Writer, opts Options) *Screen {
+	if opts.Cols <= 0 {
+		opts.Cols = DefaultCols
+	}
+	if opts.Rows <= 0 {
+		opts.Rows = DefaultRows
+	}
+	if opts.ClockHz == 0 {
+		opts.ClockHz = DefaultClockHz
+	}
+	if opts.RefreshHz == 0 {
+		opts.RefreshHz = DefaultRefreshHz
+	}
+	s := &Screen{w: w, opts: opts, mem: make([]byte, opts.Cols*opts.Rows)}
+	s.Reset()
+	return s
+}
+
+// Size returns the size of the video memory.
+func (s *Screen) Size() int {
+	return len(s.mem)
+}
+
+// Reset fills the screen with spaces, to be drawn at the next refresh.
+func (s *Screen) Reset() {
+	for i := range s.mem {
+		s.mem[i] = ' '
+	}
+	s.dirty = true
+	s.left = s.refreshCycles()
+}
+
+// Err returns the first error drawing the screen, if any. The screen is
+// not drawn after it.
+func (s *Screen) Err() error {
+	return s.err
+}
+
+// Read returns the character at addr, mirrored across larger regions.
+func (s *Screen) Read(addr uint16) byte {
+	return s.mem[int(addr)%len(s.mem)]
+}
+
+// Write changes the character at addr to val.
+func (s *Screen) Write(val byte, addr uint16) {
+	i := int(addr) % len(s.mem)
+	if s.mem[i] != val {
+		s.mem[i] = val
+		s.dirty = true
+	}
+}
+
+// Tick counts cycles of the CPU, drawing the screen at each refresh if the
+// video memory changed.
+func (s *Screen) Tick(cycles uint) {
+	if cycles < s.left {
+		s.left -= cycles
+		return
+	}
+	s.left = s.refreshCycles()
+	if s.dirty {
+		s.Draw()
+	}
+}
+
+// Draw draws the screen now, from the top left corner of the terminal.
+func (s *Screen) Draw() {
+	if s.err != nil {
+		return
+	}
+	s.dirty = false
+	if _, err := io.WriteString(s.w, s.render()); err != nil {
+		s.err = err
+	}
+}
+
+// Lines returns the text of the rows, with the characters in reverse video
+// as their low 7 bits and the control characters as spaces.
+func (s *Screen) Lines() []string {
+	lines := make([]string, s.opts.Rows)
+	for y := range lines {
+		row := make([]byte, s.opts.Cols)
+		for x, c := range s.mem[y*s.opts.Cols : (y+1)*s.opts.Cols] {
+			row[x] = printable(c &^ ReverseBit)
+		}
+		lines[y] = string(row)
+	}
+	return lines
+}
+
+// render returns the escape codes and the text drawing the screen.
+func (s *Screen) render() string {
+	var sb strings.Builder
+	sb.WriteString("\x1b[H")
+	for y := range s.opts.Rows {
+		reverse := false
+		for _, c := range s.mem[y*s.opts.Cols : (y+1)*s.opts.Cols] {
+			if r := c&ReverseBit != 0; r != reverse {
+				reverse = r
+				if r {
+					sb.WriteString("\x1b[7m")
+				} else {
+					sb.WriteString("\x1b[27m")
+				}
+			}
+			sb.WriteByte(printable(c &^ ReverseBit))
+		}
+		if reverse {
+			sb.WriteString("\x1b[27m")
+		}
+		sb.WriteString("\r\n")
+	}
+	return sb.String()
+}
+
+func (s *Screen) refreshCycles() uint {
+	return max(s.opts.ClockHz/s.opts.RefreshHz, 1)
+}
+
+// printable returns c, or a space for a control character.
+func printable(c byte) byte {
+	if c < ' ' || c == 0x7F {
+		return ' '
+	}
+	return c
+}
@@ -0,0 +1,47 @@
+package textscreen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestScreenRefresh(t *testing.T) {
+	var out bytes.Buffer
+	s := New(&out, Options{Cols: 4, Rows: 2, ClockHz: 1000, RefreshHz: 10})
+	for i, c := range []byte("Hi") {
+		s.Write(c, 0x0400+uint16(i))
+	}
+	s.Write('!'|ReverseBit, 0x0405)
+
+	s.Tick(99)
+	if out.Len() != 0 {
+		t.Fatalf("expected nothing drawn before the refresh, actual %q", out.String())
+	}
+	s.Tick(1)
+
+	expected := "\x1b[HHi  \r\n \x1b[7m!\x1b[27m  \r\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+	if lines := s.Lines(); strings.Join(lines, "|") != "Hi  | !  " {
+		t.Errorf("unexpected lines %q\n", lines)
+	}
+
+	out.Reset()
+	s.Tick(100)
+	if out.Len() != 0 {
+		t.Errorf("expected nothing drawn without changes, actual %q\n", out.String())
+	}
+}
+
+func TestScreenDefaults(t *testing.T) {
+	s := New(nil, Options{})
+
+	if s.Size() != DefaultCols*DefaultRows {
+		t.Errorf("expected %d bytes of video memory, actual %d\n", DefaultCols*DefaultRows, s.Size())
+	}
+	if actual := s.Read(uint16(s.Size())); actual != ' ' {
+		t.Errorf("expected the memory mirrored and cleared to spaces, actual %q\n", actual)
+	}
+}
@@ -0,0 +1,14 @@
+package timer
+
+import "github.com/leakedmemory/mos6502/devices"
+
+func init() {
+	devices.Register(devices.Info{
+		Name:        "timer",
+		Description: "programmable 16-bit interval timer",
+		Size:        4,
+		New: func(cfg devices.Config) (devices.Device, error) {
+			return New(cfg.IRQ), nil
+		},
+	})
+}
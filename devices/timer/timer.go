@@ -0,0 +1,186 @@
+// Package timer emulates a simple programmable timer, not modeled on any
+// chip: a 16-bit counter decremented every cycle of the CPU, interrupting as
+// it expires, once or periodically. It gives periodic interrupts to programs
+// without the registers of a VIA.
+//
+// The timer occupies 4 addresses, mirrored across larger regions, and counts
+// the cycles of the CPU given to Tick, by a devices.Clock:
+//
+//	t := timer.New(c)
+//	b.Map(0xD000, 0xD003, t)
+//	devices.NewClock(c, t)
+//
+// A program starts it by writing the period, then the control register:
+//
+//	LDA #<10000
+//	STA $D000
+//	LDA #>10000
+//	STA $D001
+//	LDA #$07    ; enabled, periodic, interrupting
+//	STA $D002
+package timer
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/leakedmemory/mos6502/devices"
+)
+
+// ErrState is returned by UnmarshalBinary for a state it can not restore.
+var ErrState = errors.New("timer: invalid state")
+
+// Registers, by their offset.
+const (
+	// RegLow and RegHigh read the counter, and write the period, the number
+	// of cycles from a start to the expiry, 65536 if 0. Writing RegHigh
+	// restarts the counter from the period.
+	RegLow = iota
+	RegHigh
+	// RegControl holds the Control bits.
+	RegControl
+	// RegStatus reads StatusExpired, and clears it.
+	RegStatus
+)
+
+// Bits of the control register.
+const (
+	// ControlEnable makes the counter count. It is cleared as a one-shot
+	// timer expires.
+	ControlEnable byte = 0x01
+	// ControlRepeat restarts the counter from the period as it expires.
+	ControlRepeat byte = 0x02
+	// ControlIRQ asserts the IRQ while StatusExpired is set.
+	ControlIRQ byte = 0x04
+)
+
+// StatusExpired is set in the status register as the counter expires.
+const StatusExpired byte = 0x80
+
+// Timer is a programmable timer. Its zero value is not usable: use New.
+type Timer struct {
+	irq      devices.IRQLine
+	asserted bool
+
+	period  uint16
+	count   uint
+	control byte
+	status  byte
+}
+
+// New returns a Timer asserting irq, if not nil.
+func New(irq devices.IRQLine) *Timer {
+	t := &Timer{irq: irq}
+	t.Reset()
+	return t
+}
+
+// Reset stops the timer and clears its registers.
+func (t *Timer) Reset() {
+	t.period, t.count = 0, 0
+	t.control, t.status = 0, 0
+	t.updateIRQ()
+}
+
+// Read returns the register at addr. Reading the status clears it.
+func (t *Timer) Read(addr uint16) byte {
+	val := t.Peek(addr)
+	if addr&0x03 == RegStatus {
+		t.status = 0
+		t.updateIRQ()
+	}
+	return val
+}
+
+// Peek returns the register at addr without side effects.
+func (t *Timer) Peek(addr uint16) byte {
+	switch addr & 0x03 {
+	case RegLow:
+		return byte(t.count)
+	case RegHigh:
+		return byte(t.count >> 8)
+	case RegControl:
+		return t.control
+	default:
+		return t.status
+	}
+}
+
+// Write changes the register at addr to val. Writing RegHigh or RegControl
+// restarts the counter from the period.
+func (t *Timer) Write(val byte, addr uint16) {
+	switch addr & 0x03 {
+	case RegLow:
+		t.period = t.period&0xFF00 | uint16(val)
+	case RegHigh:
+		t.period = t.period&0x00FF | uint16(val)<<8
+		t.count = t.cycles()
+	case RegControl:
+		t.control = val & (ControlEnable | ControlRepeat | ControlIRQ)
+		t.count = t.cycles()
+	case RegStatus:
+		t.status = 0
+	}
+	t.updateIRQ()
+}
+
+// Tick counts cycles of the CPU.
+func (t *Timer) Tick(cycles uint) {
+	for t.control&ControlEnable != 0 && cycles != 0 {
+		if cycles < t.count {
+			t.count -= cycles
+			break
+		}
+		cycles -= t.count
+		t.status |= StatusExpired
+		if t.control&ControlRepeat != 0 {
+			t.count = t.cycles()
+		} else {
+			t.count = 0
+			t.control &^= ControlEnable
+		}
+	}
+	t.updateIRQ()
+}
+
+// cycles returns the number of cycles of the period.
+func (t *Timer) cycles() uint {
+	if t.period == 0 {
+		return 0x10000
+	}
+	return uint(t.period)
+}
+
+// updateIRQ drives the IRQ output from the status and its enable.
+func (t *Timer) updateIRQ() {
+	asserted := t.control&ControlIRQ != 0 && t.status&StatusExpired != 0
+	if asserted != t.asserted {
+		t.asserted = asserted
+		if t.irq != nil {
+			t.irq.SetIRQ(asserted)
+		}
+	}
+}
+
+// stateSize is the size of the state saved by MarshalBinary.
+const stateSize = 8
+
+// MarshalBinary returns the state of the timer, for a savestate.
+func (t *Timer) MarshalBinary() ([]byte, error) {
+	b := binary.LittleEndian.AppendUint16(nil, t.period)
+	b = binary.LittleEndian.AppendUint32(b, uint32(t.count))
+	return append(b, t.control, t.status), nil
+}
+
+// UnmarshalBinary restores the state of the timer returned by
+// MarshalBinary.
+func (t *Timer) UnmarshalBinary(data []byte) error {
+	if len(data) != stateSize {
+		return ErrState
+	}
+	t.period = binary.LittleEndian.Uint16(data)
+	t.count = uint(binary.LittleEndian.Uint32(data[2:]))
+	t.control, t.status = data[6], data[7]
+	t.updateIRQ()
+	return nil
+}
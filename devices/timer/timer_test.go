@@ -0,0 +1,164 @@
+package timer
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/devices"
+)
+
+type testIRQ struct {
+	asserted bool
+}
+
+func (l *testIRQ) SetIRQ(asserted bool) {
+	l.asserted = asserted
+}
+
+func start(t *Timer, period uint16, control byte) {
+	t.Write(byte(period), RegLow)
+	t.Write(byte(period>>8), RegHigh)
+	t.Write(control, RegControl)
+}
+
+func TestTimerOneShot(t *testing.T) {
+	irq := &testIRQ{}
+	tm := New(irq)
+	start(tm, 0x0100, ControlEnable|ControlIRQ)
+
+	tm.Tick(0xFF)
+	if irq.asserted || tm.Peek(RegStatus) != 0 {
+		t.Fatalf("expected the timer running, actual status $%02X\n", tm.Peek(RegStatus))
+	}
+	if low, high := tm.Read(RegLow), tm.Read(RegHigh); low != 0x01 || high != 0x00 {
+		t.Errorf("expected count $0001, actual $%02X%02X\n", high, low)
+	}
+
+	tm.Tick(1)
+	if !irq.asserted {
+		t.Errorf("expected the IRQ asserted on expiry")
+	}
+	if control := tm.Read(RegControl); control&ControlEnable != 0 {
+		t.Errorf("expected the timer stopped, actual control $%02X\n", control)
+	}
+	if status := tm.Read(RegStatus); status != StatusExpired {
+		t.Errorf("expected status $%02X, actual $%02X\n", StatusExpired, status)
+	}
+	if irq.asserted {
+		t.Errorf("expected the IRQ released by reading the status")
+	}
+
+	tm.Tick(0x1000)
+	if tm.Peek(RegStatus) != 0 {
+		t.Errorf("expected a one-shot timer to expire once")
+	}
+}
+
+func TestTimerPeriodic(t *testing.T) {
+	tm := New(nil)
+	start(tm, 100, ControlEnable|ControlRepeat)
+
+	tm.Tick(250)
+
+	if status := tm.Read(RegStatus); status != StatusExpired {
+		t.Errorf("expected status $%02X, actual $%02X\n", StatusExpired, status)
+	}
+	if actual := tm.Peek(RegLow); actual != 50 {
+		t.Errorf("expected count 50, actual %d\n", actual)
+	}
+	tm.Tick(50)
+	if status := tm.Read(RegStatus); status != StatusExpired {
+		t.Errorf("expected status $%02X, actual $%02X\n", StatusExpired, status)
+	}
+	if actual := tm.Peek(RegLow); actual != 100 {
+		t.Errorf("expected the count reloaded to 100, actual %d\n", actual)
+	}
+}
+
+func TestTimerPeriodZero(t *testing.T) {
+	tm := New(nil)
+	start(tm, 0, ControlEnable)
+
+	tm.Tick(0xFFFF)
+	if tm.Peek(RegStatus) != 0 {
+		t.Fatalf("expected a period of 0 to last 65536 cycles")
+	}
+	tm.Tick(1)
+	if tm.Peek(RegStatus) != StatusExpired {
+		t.Errorf("expected the timer expired after 65536 cycles")
+	}
+}
+
+func TestTimerIRQDisabled(t *testing.T) {
+	irq := &testIRQ{}
+	tm := New(irq)
+	start(tm, 10, ControlEnable)
+
+	tm.Tick(10)
+	if irq.asserted {
+		t.Errorf("expected no IRQ with ControlIRQ clear")
+	}
+
+	tm.Write(ControlIRQ, RegControl)
+	if !irq.asserted {
+		t.Errorf("expected the IRQ asserted once enabled with the status set")
+	}
+	tm.Write(0, RegStatus)
+	if irq.asserted {
+		t.Errorf("expected the IRQ released by writing the status")
+	}
+}
+
+func TestTimerStopped(t *testing.T) {
+	tm := New(nil)
+	start(tm, 10, 0)
+
+	tm.Tick(100)
+
+	if tm.Peek(RegStatus) != 0 || tm.Peek(RegLow) != 10 {
+		t.Errorf("expected a stopped timer not to count, actual count %d\n", tm.Peek(RegLow))
+	}
+}
+
+func TestTimerMirrored(t *testing.T) {
+	tm := New(nil)
+	tm.Write(0x34, 0xD004)
+	tm.Write(0x12, 0xD005)
+
+	if low, high := tm.Peek(0xD000), tm.Peek(0xD001); low != 0x34 || high != 0x12 {
+		t.Errorf("expected count $1234, actual $%02X%02X\n", high, low)
+	}
+}
+
+func TestTimerState(t *testing.T) {
+	tm := New(nil)
+	start(tm, 1000, ControlEnable|ControlRepeat)
+	tm.Tick(300)
+
+	data, err := tm.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restored := New(nil)
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tm.Tick(800)
+	restored.Tick(800)
+
+	if restored.count != tm.count || restored.status != tm.status || restored.control != tm.control {
+		t.Errorf("expected %+v, actual %+v\n", *tm, *restored)
+	}
+	if err := restored.UnmarshalBinary(data[1:]); err != ErrState {
+		t.Errorf("expected %v, actual %v\n", ErrState, err)
+	}
+}
+
+func TestTimerRegistered(t *testing.T) {
+	d, err := devices.New("timer", devices.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := d.(devices.StatefulDevice); !ok {
+		t.Errorf("expected the timer to save its state\n")
+	}
+}
@@ -0,0 +1,14 @@
+package via6522
+
+import "github.com/leakedmemory/mos6502/devices"
+
+func init() {
+	devices.Register(devices.Info{
+		Name:        "6522",
+		Description: "6522 Versatile Interface Adapter",
+		Size:        16,
+		New: func(cfg devices.Config) (devices.Device, error) {
+			return New(cfg.IRQ, cfg.PortA, cfg.PortB), nil
+		},
+	})
+}
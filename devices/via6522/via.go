@@ -0,0 +1,566 @@
+// Package via6522 emulates the 6522 Versatile Interface Adapter: two 8-bit
+// ports with their data direction registers and handshake lines, the timers
+// T1 and T2, the shift register and the interrupt flag and enable registers.
+//
+// The VIA occupies 16 addresses, mirrored across larger regions, and counts
+// the cycles of the CPU given to Tick, by a devices.Clock:
+//
+//	v := via6522.New(c, nil, nil)
+//	b.Map(0x6000, 0x600F, v)
+//	devices.NewClock(c, v)
+package via6522
+
+import "github.com/leakedmemory/mos6502/devices"
+
+// Registers, by their offset.
+const (
+	regORB = iota
+	regORA
+	regDDRB
+	regDDRA
+	regT1CL
+	regT1CH
+	regT1LL
+	regT1LH
+	regT2CL
+	regT2CH
+	regSR
+	regACR
+	regPCR
+	regIFR
+	regIER
+	regORANoHandshake
+)
+
+// Bits of the interrupt flag and enable registers.
+const (
+	IntCA2 byte = 1 << iota
+	IntCA1
+	IntSR
+	IntCB2
+	IntCB1
+	IntT2
+	IntT1
+	// IntIRQ is set in IFR when any flag enabled in IER is set.
+	IntIRQ
+)
+
+// Bits of the auxiliary control register.
+const (
+	acrPALatch   byte = 0x01
+	acrPBLatch   byte = 0x02
+	acrSRMask    byte = 0x1C
+	acrT2Count   byte = 0x20
+	acrT1FreeRun byte = 0x40
+	acrT1PB7     byte = 0x80
+)
+
+// Modes of the shift register, bits 4-2 of ACR.
+const (
+	srDisabled = iota
+	srInT2
+	srInPhi2
+	srInCB1
+	srOutFree
+	srOutT2
+	srOutPhi2
+	srOutCB1
+)
+
+// Modes of CA2 and CB2, bits 3-1 and 7-5 of PCR.
+const (
+	c2InputNegative byte = iota << 1
+	c2IndependentNegative
+	c2InputPositive
+	c2IndependentPositive
+	c2Handshake
+	c2Pulse
+	c2Low
+	c2High
+)
+
+// VIA is a 6522. Its zero value is not usable: use New.
+type VIA struct {
+	irq          devices.IRQLine
+	asserted     bool
+	portA, portB devices.Port
+
+	ora, orb, ddra, ddrb byte
+	// ira and irb hold the inputs latched on an active edge of CA1 and CB1.
+	ira, irb byte
+
+	t1, t1Latch uint16
+	// t1Armed is set while T1 interrupts on its next time-out, and t1Reload
+	// when it reloads from its latch on the next cycle.
+	t1Armed, t1Reload bool
+	// pb7 is the level T1 drives on PB7 when ACR enables it.
+	pb7 bool
+
+	t2        uint16
+	t2LatchLo byte
+	t2Armed   bool
+
+	sr byte
+	// srActive is set while the shift register shifts, srBits counts the
+	// bits shifted and srTimer the cycles until the next shift under T2.
+	srActive bool
+	srBits   int
+	srTimer  int
+
+	acr, pcr, ifr, ier byte
+
+	// ca1, ca2, cb1 and cb2 are the levels of the control lines, driven by
+	// the peripherals or, for CA2 and CB2 as outputs, by the VIA.
+	ca1, ca2, cb1, cb2 bool
+	// ca2Pulse and cb2Pulse are set while a pulse output is low.
+	ca2Pulse, cb2Pulse bool
+}
+
+// New returns a VIA asserting irq, if not nil, wired to portA and portB. A
+// nil port reads as all ones, like pins left floating.
+func New(irq devices.IRQLine, portA, portB devices.Port) *VIA {
+	v := &VIA{irq: irq, portA: portA, portB: portB, ca1: true, ca2: true, cb1: true, cb2: true}
+	v.Reset()
+	return v
+}
+
+// Reset clears the registers but the timers, their latches and the shift
+// register, like a low level on the RES pin.
+func (v *VIA) Reset() {
+	v.ora, v.orb, v.ddra, v.ddrb = 0, 0, 0, 0
+	v.acr, v.pcr, v.ifr, v.ier = 0, 0, 0, 0
+	v.t1Armed, v.t1Reload, v.t2Armed = false, false, false
+	v.srActive, v.srBits = false, 0
+	v.ca2Pulse, v.cb2Pulse = false, false
+	v.updateIRQ()
+}
+
+// Read returns the register at addr, with the side effects of reading it,
+// such as clearing interrupt flags.
+func (v *VIA) Read(addr uint16) byte {
+	val := v.Peek(addr)
+	switch addr & 0x0F {
+	case regORB:
+		v.clearFlags(IntCB1 | v.c2Flag(v.pcr>>4, IntCB2))
+	case regORA:
+		v.clearFlags(IntCA1 | v.c2Flag(v.pcr, IntCA2))
+		v.handshakeCA2()
+	case regT1CL:
+		v.clearFlags(IntT1)
+	case regT2CL:
+		v.clearFlags(IntT2)
+	case regSR:
+		v.clearFlags(IntSR)
+		v.startShift()
+	}
+	return val
+}
+
+// Peek returns the register at addr without side effects.
+func (v *VIA) Peek(addr uint16) byte {
+	switch addr & 0x0F {
+	case regORB:
+		return v.portBValue()
+	case regORA, regORANoHandshake:
+		return v.portAValue()
+	case regDDRB:
+		return v.ddrb
+	case regDDRA:
+		return v.ddra
+	case regT1CL:
+		return byte(v.t1)
+	case regT1CH:
+		return byte(v.t1 >> 8)
+	case regT1LL:
+		return byte(v.t1Latch)
+	case regT1LH:
+		return byte(v.t1Latch >> 8)
+	case regT2CL:
+		return byte(v.t2)
+	case regT2CH:
+		return byte(v.t2 >> 8)
+	case regSR:
+		return v.sr
+	case regACR:
+		return v.acr
+	case regPCR:
+		return v.pcr
+	case regIFR:
+		if v.ifr&v.ier != 0 {
+			return v.ifr | IntIRQ
+		}
+		return v.ifr
+	default: // regIER
+		return v.ier | 0x80
+	}
+}
+
+// Write changes the register at addr to val.
+func (v *VIA) Write(val byte, addr uint16) {
+	switch addr & 0x0F {
+	case regORB:
+		v.orb = val
+		v.clearFlags(IntCB1 | v.c2Flag(v.pcr>>4, IntCB2))
+		v.outputB()
+		v.handshakeCB2()
+	case regORA:
+		v.ora = val
+		v.clearFlags(IntCA1 | v.c2Flag(v.pcr, IntCA2))
+		v.outputA()
+		v.handshakeCA2()
+	case regORANoHandshake:
+		v.ora = val
+		v.outputA()
+	case regDDRB:
+		v.ddrb = val
+		v.outputB()
+	case regDDRA:
+		v.ddra = val
+		v.outputA()
+	case regT1CL, regT1LL:
+		v.t1Latch = v.t1Latch&0xFF00 | uint16(val)
+	case regT1CH:
+		v.t1Latch = uint16(val)<<8 | v.t1Latch&0x00FF
+		v.t1 = v.t1Latch
+		v.t1Armed, v.t1Reload = true, false
+		v.clearFlags(IntT1)
+		if v.acr&acrT1PB7 != 0 {
+			v.pb7 = false
+			v.outputB()
+		}
+	case regT1LH:
+		v.t1Latch = uint16(val)<<8 | v.t1Latch&0x00FF
+		v.clearFlags(IntT1)
+	case regT2CL:
+		v.t2LatchLo = val
+	case regT2CH:
+		v.t2 = uint16(val)<<8 | uint16(v.t2LatchLo)
+		v.t2Armed = true
+		v.clearFlags(IntT2)
+	case regSR:
+		v.sr = val
+		v.clearFlags(IntSR)
+		v.startShift()
+	case regACR:
+		v.acr = val
+		v.outputB()
+	case regPCR:
+		v.pcr = val
+		v.ca2 = v.c2Level(v.pcr, v.ca2)
+		v.cb2 = v.c2Level(v.pcr>>4, v.cb2)
+	case regIFR:
+		v.clearFlags(val)
+	case regIER:
+		if val&0x80 != 0 {
+			v.ier |= val & 0x7F
+		} else {
+			v.ier &^= val
+		}
+		v.updateIRQ()
+	}
+}
+
+// Tick counts cycles of the CPU, which clocks the VIA.
+func (v *VIA) Tick(cycles uint) {
+	for range cycles {
+		v.tickT1()
+		if v.acr&acrT2Count == 0 {
+			v.decrementT2()
+		}
+		v.tickShift()
+		if v.ca2Pulse {
+			v.ca2Pulse, v.ca2 = false, true
+		}
+		if v.cb2Pulse {
+			v.cb2Pulse, v.cb2 = false, true
+		}
+	}
+	v.updateIRQ()
+}
+
+// tickT1 counts a cycle of T1, which times out when it passes zero, N + 1.5
+// cycles after being loaded with N, and then every N + 2 cycles when free
+// running.
+func (v *VIA) tickT1() {
+	if v.t1Reload {
+		v.t1, v.t1Reload = v.t1Latch, false
+		return
+	}
+	v.t1--
+	if v.t1 != 0xFFFF {
+		return
+	}
+	freeRun := v.acr&acrT1FreeRun != 0
+	if v.t1Armed {
+		v.ifr |= IntT1
+		v.t1Armed = freeRun
+		if v.acr&acrT1PB7 != 0 {
+			v.pb7 = !v.pb7 || !freeRun
+			v.outputB()
+		}
+	}
+	v.t1Reload = freeRun
+}
+
+// decrementT2 counts a cycle, or a pulse on PB6, of T2, which interrupts once
+// when it passes zero after being loaded.
+func (v *VIA) decrementT2() {
+	v.t2--
+	if v.t2 == 0xFFFF && v.t2Armed {
+		v.ifr |= IntT2
+		v.t2Armed = false
+	}
+}
+
+// PulsePB6 counts a negative edge on PB6, which T2 counts instead of cycles
+// when ACR sets it to count pulses.
+func (v *VIA) PulsePB6() {
+	if v.acr&acrT2Count != 0 {
+		v.decrementT2()
+		v.updateIRQ()
+	}
+}
+
+func (v *VIA) srMode() byte {
+	return (v.acr & acrSRMask) >> 2
+}
+
+// startShift starts shifting 8 bits, on an access to the shift register.
+func (v *VIA) startShift() {
+	v.srActive = v.srMode() != srDisabled
+	v.srBits = 0
+	v.srTimer = int(v.t2LatchLo) + 1
+}
+
+// tickShift counts a cycle of the shift register, which shifts every cycle
+// under phi2, and every N + 2 cycles under T2, N being the low latch of T2.
+func (v *VIA) tickShift() {
+	switch v.srMode() {
+	case srInPhi2, srOutPhi2:
+		v.shift()
+	case srInT2, srOutT2, srOutFree:
+		if v.srTimer == 0 {
+			v.srTimer = int(v.t2LatchLo) + 1
+			v.shift()
+		} else {
+			v.srTimer--
+		}
+	}
+}
+
+// shift shifts a bit in from CB2, or out to CB2, most significant first. The
+// bits shifted out are shifted back in at the bottom.
+func (v *VIA) shift() {
+	mode := v.srMode()
+	if !v.srActive {
+		return
+	}
+	if mode >= srOutFree {
+		bit := v.sr >> 7
+		v.sr = v.sr<<1 | bit
+		v.cb2 = bit != 0
+	} else {
+		v.sr <<= 1
+		if v.cb2 {
+			v.sr |= 1
+		}
+	}
+	if v.srBits++; v.srBits == 8 {
+		v.srBits = 0
+		if mode != srOutFree {
+			v.srActive = false
+			v.ifr |= IntSR
+		}
+	}
+}
+
+// SetCA1 sets the level of CA1, which interrupts on the edge PCR selects and
+// latches port A if ACR enables it.
+func (v *VIA) SetCA1(level bool) {
+	if !v.activeEdge(v.ca1, level, v.pcr&0x01 != 0) {
+		v.ca1 = level
+		return
+	}
+	v.ca1 = level
+	v.ifr |= IntCA1
+	if v.acr&acrPALatch != 0 {
+		v.ira = v.portInput(v.portA)
+	}
+	if v.pcr&0x0E == c2Handshake {
+		v.ca2 = true
+	}
+	v.updateIRQ()
+}
+
+// SetCB1 sets the level of CB1, which interrupts on the edge PCR selects,
+// latches port B if ACR enables it and clocks the shift register on a rising
+// edge when ACR sets it to shift under CB1.
+func (v *VIA) SetCB1(level bool) {
+	rising := level && !v.cb1
+	active := v.activeEdge(v.cb1, level, v.pcr&0x10 != 0)
+	v.cb1 = level
+	if rising && (v.srMode() == srInCB1 || v.srMode() == srOutCB1) {
+		v.shift()
+	}
+	if active {
+		v.ifr |= IntCB1
+		if v.acr&acrPBLatch != 0 {
+			v.irb = v.portInput(v.portB)
+		}
+		if (v.pcr>>4)&0x0E == c2Handshake {
+			v.cb2 = true
+		}
+	}
+	v.updateIRQ()
+}
+
+// SetCA2 sets the level of CA2, which interrupts on the edge PCR selects when
+// it is an input.
+func (v *VIA) SetCA2(level bool) {
+	if v.pcr&0x08 == 0 && v.activeEdge(v.ca2, level, v.pcr&0x04 != 0) {
+		v.ifr |= IntCA2
+	}
+	if v.pcr&0x08 == 0 {
+		v.ca2 = level
+	}
+	v.updateIRQ()
+}
+
+// SetCB2 sets the level of CB2, which interrupts on the edge PCR selects when
+// it is an input, and is shifted in by the shift register.
+func (v *VIA) SetCB2(level bool) {
+	if v.pcr&0x80 == 0 && v.activeEdge(v.cb2, level, v.pcr&0x40 != 0) {
+		v.ifr |= IntCB2
+	}
+	if v.pcr&0x80 == 0 || v.srMode() < srOutFree && v.srMode() != srDisabled {
+		v.cb2 = level
+	}
+	v.updateIRQ()
+}
+
+// CA2 returns the level of CA2.
+func (v *VIA) CA2() bool {
+	return v.ca2
+}
+
+// CB2 returns the level of CB2.
+func (v *VIA) CB2() bool {
+	return v.cb2
+}
+
+func (v *VIA) activeEdge(from, to, positive bool) bool {
+	if positive {
+		return !from && to
+	}
+	return from && !to
+}
+
+// c2Flag returns flag, the flag of CA2 or CB2 of the mode of ctrl, unless
+// the mode is independent, in which case accessing the port leaves it alone.
+func (v *VIA) c2Flag(ctrl, flag byte) byte {
+	switch ctrl & 0x0E {
+	case c2IndependentNegative, c2IndependentPositive:
+		return 0
+	}
+	return flag
+}
+
+// c2Level returns the level of CA2 or CB2 once ctrl sets its mode, level
+// being its current one.
+func (v *VIA) c2Level(ctrl byte, level bool) bool {
+	switch ctrl & 0x0E {
+	case c2Low:
+		return false
+	case c2High, c2Handshake, c2Pulse:
+		return true
+	}
+	return level
+}
+
+// handshakeCA2 lowers CA2 on an access to port A when it is a handshake or
+// pulse output.
+func (v *VIA) handshakeCA2() {
+	switch v.pcr & 0x0E {
+	case c2Handshake:
+		v.ca2 = false
+	case c2Pulse:
+		v.ca2, v.ca2Pulse = false, true
+	}
+}
+
+// handshakeCB2 lowers CB2 on a write to port B when it is a handshake or
+// pulse output.
+func (v *VIA) handshakeCB2() {
+	switch (v.pcr >> 4) & 0x0E {
+	case c2Handshake:
+		v.cb2 = false
+	case c2Pulse:
+		v.cb2, v.cb2Pulse = false, true
+	}
+}
+
+func (v *VIA) portInput(p devices.Port) byte {
+	if p == nil {
+		return 0xFF
+	}
+	return p.Input()
+}
+
+func (v *VIA) portAValue() byte {
+	in := v.portInput(v.portA)
+	if v.acr&acrPALatch != 0 {
+		in = v.ira
+	}
+	return v.ora&v.ddra | in&^v.ddra
+}
+
+func (v *VIA) portBValue() byte {
+	in := v.portInput(v.portB)
+	if v.acr&acrPBLatch != 0 {
+		in = v.irb
+	}
+	val, ddr := v.portBOutput()
+	return val&ddr | in&^ddr
+}
+
+// portBOutput returns the levels the VIA drives on port B, and the pins it
+// drives, PB7 being driven by T1 when ACR enables it.
+func (v *VIA) portBOutput() (val, ddr byte) {
+	val, ddr = v.orb, v.ddrb
+	if v.acr&acrT1PB7 != 0 {
+		val &^= 0x80
+		if v.pb7 {
+			val |= 0x80
+		}
+		ddr |= 0x80
+	}
+	return val, ddr
+}
+
+func (v *VIA) outputA() {
+	if v.portA != nil {
+		v.portA.Output(v.ora, v.ddra)
+	}
+}
+
+func (v *VIA) outputB() {
+	if v.portB != nil {
+		v.portB.Output(v.portBOutput())
+	}
+}
+
+func (v *VIA) clearFlags(flags byte) {
+	v.ifr &^= flags & 0x7F
+	v.updateIRQ()
+}
+
+// updateIRQ drives the IRQ output from the enabled flags.
+func (v *VIA) updateIRQ() {
+	asserted := v.ifr&v.ier != 0
+	if asserted != v.asserted {
+		v.asserted = asserted
+		if v.irq != nil {
+			v.irq.SetIRQ(asserted)
+		}
+	}
+}
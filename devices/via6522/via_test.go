@@ -0,0 +1,255 @@
+package via6522
+
+import "testing"
+
+type testIRQ struct {
+	asserted bool
+}
+
+func (l *testIRQ) SetIRQ(asserted bool) {
+	l.asserted = asserted
+}
+
+type testPort struct {
+	in       byte
+	out, ddr byte
+}
+
+func (p *testPort) Input() byte {
+	return p.in
+}
+
+func (p *testPort) Output(val, ddr byte) {
+	p.out, p.ddr = val, ddr
+}
+
+func newVIATest() (*VIA, *testIRQ, *testPort, *testPort) {
+	irq := &testIRQ{}
+	a, b := &testPort{}, &testPort{}
+	return New(irq, a, b), irq, a, b
+}
+
+func TestVIAPorts(t *testing.T) {
+	v, _, a, b := newVIATest()
+	a.in, b.in = 0x5A, 0xA5
+
+	v.Write(0x0F, regDDRA)
+	v.Write(0xFF, regORA)
+	v.Write(0xF0, regDDRB)
+	v.Write(0x3C, regORB)
+
+	if actual := v.Read(regORA); actual != 0x5F {
+		t.Errorf("expected $5F, actual $%02X\n", actual)
+	}
+	if actual := v.Read(regORB); actual != 0x35 {
+		t.Errorf("expected $35, actual $%02X\n", actual)
+	}
+	if a.out != 0xFF || a.ddr != 0x0F || b.out != 0x3C || b.ddr != 0xF0 {
+		t.Errorf("unexpected outputs %+v and %+v\n", *a, *b)
+	}
+}
+
+func TestVIANilPortsReadHigh(t *testing.T) {
+	v := New(nil, nil, nil)
+
+	if actual := v.Read(regORA); actual != 0xFF {
+		t.Errorf("expected $FF, actual $%02X\n", actual)
+	}
+}
+
+func TestVIAT1OneShot(t *testing.T) {
+	v, irq, _, _ := newVIATest()
+	v.Write(IntIRQ|IntT1, regIER)
+	v.Write(0x10, regT1CL)
+	v.Write(0x00, regT1CH)
+
+	v.Tick(0x10)
+	if v.Peek(regIFR)&IntT1 != 0 || irq.asserted {
+		t.Fatalf("expected no time-out after $10 cycles")
+	}
+	v.Tick(1)
+	if v.Peek(regIFR) != IntIRQ|IntT1 || !irq.asserted {
+		t.Fatalf("expected a time-out after $11 cycles, actual IFR $%02X", v.Peek(regIFR))
+	}
+
+	v.Read(regT1CL)
+	if v.Peek(regIFR) != 0 || irq.asserted {
+		t.Errorf("expected reading T1C-L to clear the interrupt, actual IFR $%02X\n", v.Peek(regIFR))
+	}
+	v.Tick(0x20000)
+	if v.Peek(regIFR) != 0 {
+		t.Errorf("expected a single time-out, actual IFR $%02X\n", v.Peek(regIFR))
+	}
+}
+
+func TestVIAT1FreeRun(t *testing.T) {
+	v, _, _, b := newVIATest()
+	v.Write(acrT1FreeRun|acrT1PB7, regACR)
+	v.Write(0x04, regT1CL)
+	v.Write(0x00, regT1CH)
+	if b.out&0x80 != 0 || b.ddr&0x80 == 0 {
+		t.Fatalf("expected PB7 driven low, actual %+v", *b)
+	}
+
+	timeouts := 0
+	for cycle := 1; cycle <= 5+6*3; cycle++ {
+		v.Tick(1)
+		if v.Peek(regIFR)&IntT1 != 0 {
+			timeouts++
+			if cycle != 5+6*(timeouts-1) {
+				t.Errorf("expected a time-out every 6 cycles from 5, actual one at %d\n", cycle)
+			}
+			v.Read(regT1CL)
+		}
+	}
+	if timeouts != 4 {
+		t.Errorf("expected 4 time-outs, actual %d\n", timeouts)
+	}
+	if b.out&0x80 != 0 {
+		t.Errorf("expected PB7 low after 4 toggles, actual %+v\n", *b)
+	}
+}
+
+func TestVIAT2(t *testing.T) {
+	v, _, _, _ := newVIATest()
+	v.Write(0x02, regT2CL)
+	v.Write(0x01, regT2CH)
+
+	v.Tick(0x0103)
+	if v.Peek(regIFR)&IntT2 == 0 {
+		t.Errorf("expected T2 to time out, actual IFR $%02X\n", v.Peek(regIFR))
+	}
+	if actual := v.Peek(regT2CH); actual != 0xFF {
+		t.Errorf("expected T2 to keep counting, actual T2C-H $%02X\n", actual)
+	}
+}
+
+func TestVIAT2CountsPulses(t *testing.T) {
+	v, _, _, _ := newVIATest()
+	v.Write(acrT2Count, regACR)
+	v.Write(0x01, regT2CL)
+	v.Write(0x00, regT2CH)
+
+	v.Tick(100)
+	v.PulsePB6()
+	if v.Peek(regIFR)&IntT2 != 0 {
+		t.Fatalf("expected no time-out after a pulse")
+	}
+	v.PulsePB6()
+	if v.Peek(regIFR)&IntT2 == 0 {
+		t.Errorf("expected a time-out after 2 pulses, actual IFR $%02X\n", v.Peek(regIFR))
+	}
+}
+
+func TestVIAShiftOut(t *testing.T) {
+	v, _, _, _ := newVIATest()
+	v.Write(srOutPhi2<<2, regACR)
+	v.Write(0xA5, regSR)
+
+	var bits byte
+	for range 8 {
+		v.Tick(1)
+		bits <<= 1
+		if v.CB2() {
+			bits |= 1
+		}
+	}
+	if bits != 0xA5 {
+		t.Errorf("expected $A5 shifted out, actual $%02X\n", bits)
+	}
+	if v.Peek(regIFR)&IntSR == 0 {
+		t.Errorf("expected the shift register to interrupt, actual IFR $%02X\n", v.Peek(regIFR))
+	}
+	if v.Peek(regSR) != 0xA5 {
+		t.Errorf("expected the bits to recirculate, actual SR $%02X\n", v.Peek(regSR))
+	}
+}
+
+func TestVIAShiftInUnderCB1(t *testing.T) {
+	v, _, _, _ := newVIATest()
+	v.Write(srInCB1<<2, regACR)
+	v.Read(regSR)
+
+	for i := range 8 {
+		v.SetCB2((0x3C>>(7-i))&1 != 0)
+		v.SetCB1(false)
+		v.SetCB1(true)
+	}
+	if actual := v.Read(regSR); actual != 0x3C {
+		t.Errorf("expected $3C, actual $%02X\n", actual)
+	}
+}
+
+func TestVIACA1(t *testing.T) {
+	v, irq, a, _ := newVIATest()
+	v.Write(IntIRQ|IntCA1, regIER)
+	// CA1 on the rising edge, CA2 a handshake output, port A latched.
+	v.Write(0x01|c2Handshake, regPCR)
+	v.Write(acrPALatch, regACR)
+	v.SetCA1(false)
+	v.Read(regORA)
+	if v.CA2() {
+		t.Fatalf("expected CA2 low after reading port A")
+	}
+
+	a.in = 0x42
+	v.SetCA1(true)
+	a.in = 0x00
+
+	if !irq.asserted || !v.CA2() {
+		t.Errorf("expected an interrupt and CA2 high on the rising edge\n")
+	}
+	if actual := v.Read(regORA); actual != 0x42 {
+		t.Errorf("expected the latched $42, actual $%02X\n", actual)
+	}
+	if irq.asserted {
+		t.Errorf("expected reading port A to clear the interrupt\n")
+	}
+}
+
+func TestVIACA2Independent(t *testing.T) {
+	v, _, _, _ := newVIATest()
+	v.Write(c2IndependentNegative, regPCR)
+
+	v.SetCA2(false)
+	v.Read(regORA)
+
+	if v.Peek(regIFR)&IntCA2 == 0 {
+		t.Errorf("expected reading port A to leave an independent CA2 flag set\n")
+	}
+}
+
+func TestVIAIER(t *testing.T) {
+	v, _, _, _ := newVIATest()
+
+	v.Write(IntIRQ|IntT1|IntT2|IntCA1, regIER)
+	v.Write(IntT2, regIER)
+
+	if actual := v.Read(regIER); actual != 0x80|IntT1|IntCA1 {
+		t.Errorf("expected $%02X, actual $%02X\n", 0x80|IntT1|IntCA1, actual)
+	}
+}
+
+func TestVIAReset(t *testing.T) {
+	v, irq, _, _ := newVIATest()
+	v.Write(IntIRQ|IntT1, regIER)
+	v.Write(0x00, regT1CL)
+	v.Write(0x00, regT1CH)
+	v.Tick(2)
+
+	v.Reset()
+
+	if irq.asserted || v.Peek(regIER) != 0x80 || v.Peek(regIFR) != 0 {
+		t.Errorf("expected reset to clear the interrupts\n")
+	}
+}
+
+func TestVIAMirrored(t *testing.T) {
+	v, _, _, _ := newVIATest()
+
+	v.Write(0x55, 0x0010|regDDRA)
+
+	if actual := v.Read(regDDRA); actual != 0x55 {
+		t.Errorf("expected $55, actual $%02X\n", actual)
+	}
+}
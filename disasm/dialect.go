@@ -0,0 +1,317 @@
+package disasm
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// Dialect is the syntax of an assembler that disassembly is written in.
+type Dialect struct {
+	Name      string
+	Lowercase bool
+	// HexByte and HexWord format 8 and 16-bit operands.
+	HexByte string
+	HexWord string
+	// Accumulator is the operand written for accumulator addressing, empty
+	// if the assembler takes none.
+	Accumulator string
+	// AbsolutePrefix and AbsoluteSuffix force absolute addressing for
+	// operands that fit in the zero page, which would otherwise be
+	// reassembled as zero page instructions: the prefix goes before the
+	// operand and the suffix after the mnemonic.
+	AbsolutePrefix string
+	AbsoluteSuffix string
+	// LabelSuffix follows label definitions.
+	LabelSuffix string
+	// Org and Byte are formats for the origin and data directives.
+	Org  string
+	Byte string
+}
+
+// Dialects available out of the box.
+var (
+	// MOS is the syntax of the MOS Technology manuals, used by String.
+	MOS = &Dialect{
+		Name:        "mos",
+		HexByte:     "$%02X",
+		HexWord:     "$%04X",
+		Accumulator: "A",
+		LabelSuffix: ":",
+		Org:         "*=$%04X",
+		Byte:        ".byte $%02X",
+	}
+	CA65 = &Dialect{
+		Name:           "ca65",
+		Lowercase:      true,
+		HexByte:        "$%02X",
+		HexWord:        "$%04X",
+		Accumulator:    "a",
+		AbsolutePrefix: "a:",
+		LabelSuffix:    ":",
+		Org:            ".org $%04X",
+		Byte:           ".byte $%02X",
+	}
+	ACME = &Dialect{
+		Name:           "acme",
+		Lowercase:      true,
+		HexByte:        "$%02x",
+		HexWord:        "$%04x",
+		AbsoluteSuffix: "+2",
+		Org:            "* = $%04x",
+		Byte:           "!byte $%02x",
+	}
+	VASM = &Dialect{
+		Name:        "vasm",
+		Lowercase:   true,
+		HexByte:     "$%02x",
+		HexWord:     "$%04x",
+		Accumulator: "a",
+		// vasm selects absolute addressing for "!" like the WDC syntax.
+		AbsolutePrefix: "!",
+		LabelSuffix:    ":",
+		Org:            "\torg $%04x",
+		Byte:           "dc.b $%02x",
+	}
+)
+
+// Dialects lists the built-in dialects by name.
+var Dialects = map[string]*Dialect{
+	MOS.Name:  MOS,
+	CA65.Name: CA65,
+	ACME.Name: ACME,
+	VASM.Name: VASM,
+}
+
+// Format returns the instruction in the syntax of d.
+func (i Instruction) Format(d *Dialect) string {
+	return i.format(d, "")
+}
+
+// format is Format using label for the address operand, if not empty.
+func (i Instruction) format(d *Dialect, label string) string {
+	if !i.Info.Documented() {
+		return fmt.Sprintf(d.Byte, i.Opcode)
+	}
+	mnemonic := i.Info.Mnemonic
+	if d.Lowercase {
+		mnemonic = strings.ToLower(mnemonic)
+	}
+	operand := i.formatOperand(d, label)
+	if i.forcedAbsolute() {
+		mnemonic += d.AbsoluteSuffix
+		operand = d.AbsolutePrefix + operand
+	}
+	if operand == "" {
+		return mnemonic
+	}
+	return mnemonic + " " + operand
+}
+
+// forcedAbsolute reports whether the instruction uses absolute addressing
+// with an operand an assembler would encode as zero page.
+func (i Instruction) forcedAbsolute() bool {
+	switch i.Info.Mode {
+	case cpu.Absolute, cpu.AbsoluteX, cpu.AbsoluteY:
+		return i.Operand < 0x100 && i.Info.Mnemonic != "JMP" && i.Info.Mnemonic != "JSR"
+	default:
+		return false
+	}
+}
+
+// formatOperand formats the operand, using label instead of the address for
+// modes that take one when it is not empty.
+func (i Instruction) formatOperand(d *Dialect, label string) string {
+	b := func() string { return fmt.Sprintf(d.HexByte, i.Operand) }
+	w := func() string {
+		if label != "" {
+			return label
+		}
+		return fmt.Sprintf(d.HexWord, i.Operand)
+	}
+	x, y := ",X", ",Y"
+	if d.Lowercase {
+		x, y = ",x", ",y"
+	}
+
+	switch i.Info.Mode {
+	case cpu.Accumulator:
+		return d.Accumulator
+	case cpu.Immediate:
+		return "#" + b()
+	case cpu.ZeroPage:
+		return b()
+	case cpu.ZeroPageX:
+		return b() + x
+	case cpu.ZeroPageY:
+		return b() + y
+	case cpu.Relative:
+		if label != "" {
+			return label
+		}
+		target, _ := i.Target()
+		return fmt.Sprintf(d.HexWord, target)
+	case cpu.Absolute:
+		return w()
+	case cpu.AbsoluteX:
+		return w() + x
+	case cpu.AbsoluteY:
+		return w() + y
+	case cpu.Indirect:
+		return "(" + w() + ")"
+	case cpu.IndexedIndirect:
+		return "(" + b() + x + ")"
+	case cpu.IndirectIndexed:
+		return "(" + b() + ")" + y
+	default:
+		return ""
+	}
+}
+
+// Printer writes disassembly in a chosen dialect.
+type Printer struct {
+	// Dialect defaults to MOS.
+	Dialect *Dialect
+	// AutoLabels replaces the targets of branches, jumps and subroutine
+	// calls with generated labels, as returned by Labels.
+	AutoLabels bool
+	// Symbols, if not nil, names the instructions and the addresses
+	// operands refer to, taking precedence over AutoLabels.
+	Symbols memory.Labeler
+	// Cycles adds the cycles of the instructions to listing lines, as
+	// returned by Instruction.Cycles, in a comment.
+	Cycles bool
+	// TraceFormat is the format of the lines of traces, defaulting to
+	// DefaultTraceFormat.
+	TraceFormat *TraceFormat
+}
+
+func (p *Printer) dialect() *Dialect {
+	if p.Dialect == nil {
+		return MOS
+	}
+	return p.Dialect
+}
+
+// Listing writes a listing of the instructions from start to end, with their
+// address and encoding, to w.
+func (p *Printer) Listing(w io.Writer, r memory.Reader, start, end uint16) error {
+	d := p.dialect()
+	insts := Range(r, start, end)
+	labels := p.labels(insts)
+	lw := &lineWriter{w: w}
+	for _, inst := range insts {
+		if label, ok := labels[inst.Addr]; ok {
+			lw.printf("%s%s\n", label, d.LabelSuffix)
+		}
+		lw.printf("%s\n", p.listingLine(inst, operandLabel(inst, labels)))
+	}
+	return lw.err
+}
+
+// Source writes the instructions from start to end to w as source code the
+// assembler of the dialect reassembles into the same bytes.
+func (p *Printer) Source(w io.Writer, r memory.Reader, start, end uint16) error {
+	d := p.dialect()
+	insts := Range(r, start, end)
+	labels := p.labels(insts)
+	lw := &lineWriter{w: w}
+
+	// Labels that are not at the start of an instruction in the range are
+	// defined as constants.
+	placed := make(map[uint16]bool, len(insts))
+	for _, inst := range insts {
+		placed[inst.Addr] = true
+	}
+	for _, addr := range slices.Sorted(maps.Keys(labels)) {
+		if !placed[addr] {
+			lw.printf("%s = "+d.HexWord+"\n", labels[addr], addr)
+		}
+	}
+
+	lw.printf(d.Org+"\n", start)
+	for _, inst := range insts {
+		if label, ok := labels[inst.Addr]; ok {
+			lw.printf("%s%s\n", label, d.LabelSuffix)
+		}
+		lw.printf("\t%s\n", inst.format(d, operandLabel(inst, labels)))
+	}
+	return lw.err
+}
+
+func (p *Printer) labels(insts []Instruction) map[uint16]string {
+	labels := make(map[uint16]string)
+	if p.AutoLabels {
+		labels = Labels(insts)
+	}
+	if p.Symbols == nil {
+		return labels
+	}
+	name := func(addr uint16) {
+		if label, ok := p.Symbols.Label(addr); ok {
+			labels[addr] = label
+		}
+	}
+	for _, inst := range insts {
+		name(inst.Addr)
+		if target, ok := inst.Target(); ok {
+			name(target)
+		} else if inst.hasAddressOperand() {
+			name(inst.Operand)
+		}
+	}
+	return labels
+}
+
+// Labels names the addresses that the branches, jumps and subroutine calls
+// among insts go to, as L_ followed by the address in hex.
+func Labels(insts []Instruction) map[uint16]string {
+	labels := make(map[uint16]string)
+	for _, inst := range insts {
+		if target, ok := inst.Target(); ok {
+			labels[target] = fmt.Sprintf("L_%04X", target)
+		}
+	}
+	return labels
+}
+
+// operandLabel returns the label of the address the operand of inst refers
+// to, if it has one.
+func operandLabel(inst Instruction, labels map[uint16]string) string {
+	if target, ok := inst.Target(); ok {
+		return labels[target]
+	}
+	if inst.hasAddressOperand() {
+		return labels[inst.Operand]
+	}
+	return ""
+}
+
+// hasAddressOperand reports whether the operand of i is a 16-bit address.
+func (i Instruction) hasAddressOperand() bool {
+	switch i.Info.Mode {
+	case cpu.Absolute, cpu.AbsoluteX, cpu.AbsoluteY, cpu.Indirect:
+		return true
+	default:
+		return false
+	}
+}
+
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (lw *lineWriter) printf(format string, args ...any) {
+	if lw.err != nil {
+		return
+	}
+	if _, err := fmt.Fprintf(lw.w, format, args...); err != nil {
+		lw.err = fmt.Errorf("disasm: writing output: %w", err)
+	}
+}
@@ -0,0 +1,115 @@
+package disasm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/symbols"
+)
+
+func TestDialectFormat(t *testing.T) {
+	mem := newDisasmTestMemory(0x0200,
+		0xA9, 0x42,
+		0x0A,
+		0xAD, 0x10, 0x00,
+		0xB1, 0x20,
+		0xD0, 0xF6,
+		0x02,
+	)
+	insts := Range(mem, 0x0200, 0x020A)
+
+	for _, tc := range []struct {
+		dialect  *Dialect
+		expected []string
+	}{
+		{MOS, []string{"LDA #$42", "ASL A", "LDA $0010", "LDA ($20),Y", "BNE $0200", ".byte $02"}},
+		{CA65, []string{"lda #$42", "asl a", "lda a:$0010", "lda ($20),y", "bne $0200", ".byte $02"}},
+		{ACME, []string{"lda #$42", "asl", "lda+2 $0010", "lda ($20),y", "bne $0200", "!byte $02"}},
+		{VASM, []string{"lda #$42", "asl a", "lda !$0010", "lda ($20),y", "bne $0200", "dc.b $02"}},
+	} {
+		for i, inst := range insts {
+			if actual := inst.Format(tc.dialect); actual != tc.expected[i] {
+				t.Errorf("%s: expected %q, actual %q\n", tc.dialect.Name, tc.expected[i], actual)
+			}
+		}
+	}
+}
+
+func TestPrinterSource(t *testing.T) {
+	mem := newDisasmTestMemory(0xC000, 0x4C, 0x00, 0xC0)
+
+	var out strings.Builder
+	p := Printer{Dialect: Dialects["acme"]}
+	if err := p.Source(&out, mem, 0xC000, 0xC002); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "* = $c000\n\tjmp $c000\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+}
+
+func TestPrinterAutoLabels(t *testing.T) {
+	mem := newDisasmTestMemory(0x0200,
+		0xA2, 0x00, // ldx #$00
+		0xE8,       // L_0202: inx
+		0xD0, 0xFD, // bne L_0202
+		0x20, 0xD2, 0xFF, // jsr L_FFD2
+		0x4C, 0x02, 0x02, // jmp L_0202
+	)
+	p := Printer{Dialect: CA65, AutoLabels: true}
+
+	var source strings.Builder
+	if err := p.Source(&source, mem, 0x0200, 0x020A); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "" +
+		"L_FFD2 = $FFD2\n" +
+		".org $0200\n" +
+		"\tldx #$00\n" +
+		"L_0202:\n" +
+		"\tinx\n" +
+		"\tbne L_0202\n" +
+		"\tjsr L_FFD2\n" +
+		"\tjmp L_0202\n"
+	if source.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, source.String())
+	}
+
+	var listing strings.Builder
+	if err := p.Listing(&listing, mem, 0x0200, 0x020A); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(listing.String(), "L_0202:\n0202  E8        inx\n0203  D0 FD     bne L_0202\n") {
+		t.Errorf("unexpected listing\n%s\n", listing.String())
+	}
+}
+
+func TestPrinterSymbols(t *testing.T) {
+	mem := newDisasmTestMemory(0x0200,
+		0xAD, 0x10, 0x00, // lda a:ptr
+		0x20, 0xD2, 0xFF, // jsr chrout
+		0xD0, 0xF8, // bne start
+	)
+	syms := symbols.New()
+	syms.Add("start", 0x0200)
+	syms.Add("ptr", 0x0010)
+	syms.Add("chrout", 0xFFD2)
+	p := Printer{Dialect: CA65, Symbols: syms}
+
+	var out strings.Builder
+	if err := p.Listing(&out, mem, 0x0200, 0x0207); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "" +
+		"start:\n" +
+		"0200  AD 10 00  lda a:ptr\n" +
+		"0203  20 D2 FF  jsr chrout\n" +
+		"0206  D0 F8     bne start\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, out.String())
+	}
+}
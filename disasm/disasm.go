@@ -0,0 +1,150 @@
+// Package disasm turns 6502 machine code back into assembly, using the opcode
+// table of the cpu package.
+package disasm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// Instruction is a decoded instruction.
+type Instruction struct {
+	Addr   uint16
+	Opcode byte
+	// Operand is the raw operand: a byte, a word or a branch offset
+	// depending on the addressing mode.
+	Operand uint16
+	Info    cpu.OpcodeInfo
+}
+
+// Len returns the length of the instruction in bytes. Opcodes outside the
+// documented instruction set are one byte long.
+func (i Instruction) Len() uint16 {
+	if !i.Info.Documented() {
+		return 1
+	}
+	return i.Info.Bytes
+}
+
+// Bytes returns the encoding of the instruction.
+func (i Instruction) Bytes() []byte {
+	b := []byte{i.Opcode}
+	switch i.Len() {
+	case 2:
+		b = append(b, byte(i.Operand))
+	case 3:
+		b = append(b, byte(i.Operand), byte(i.Operand>>8))
+	}
+	return b
+}
+
+// Target returns the address a branch, jump or subroutine call goes to.
+// JMP indirect has no static target.
+func (i Instruction) Target() (uint16, bool) {
+	switch {
+	case i.Info.Mode == cpu.Relative:
+		return i.Addr + 2 + uint16(int8(i.Operand)), true
+	case i.Info.Mnemonic == "JMP" && i.Info.Mode == cpu.Absolute, i.Info.Mnemonic == "JSR":
+		return i.Operand, true
+	default:
+		return 0, false
+	}
+}
+
+// Cycles returns the number of cycles the instruction takes, such as "4",
+// followed by a + when crossing a page takes one more. For branches, it
+// returns the cycles when the branch is not taken and when it is, such as
+// "2/3", or "2/4" when the target is in another page. Opcodes outside the
+// documented instruction set have no cycles.
+func (i Instruction) Cycles() string {
+	if !i.Info.Documented() {
+		return ""
+	}
+	n := i.Info.Cycles
+	if i.Info.Mode == cpu.Relative {
+		target, _ := i.Target()
+		taken := n + 1
+		if target&0xFF00 != (i.Addr+2)&0xFF00 {
+			taken++
+		}
+		return fmt.Sprintf("%d/%d", n, taken)
+	}
+	if i.Info.PageCrossPenalty() {
+		return fmt.Sprintf("%d+", n)
+	}
+	return fmt.Sprint(n)
+}
+
+// String returns the instruction in standard MOS syntax, such as "LDA #$42".
+func (i Instruction) String() string {
+	return i.Format(MOS)
+}
+
+// Decode decodes the instruction at addr. Reading through r must not have
+// side effects: pass the DebugView of a bus rather than the bus itself.
+func Decode(r memory.Reader, addr uint16) Instruction {
+	op := r.Read(addr)
+	inst := Instruction{Addr: addr, Opcode: op, Info: cpu.Opcode(op)}
+	switch inst.Len() {
+	case 2:
+		inst.Operand = uint16(r.Read(addr + 1))
+	case 3:
+		inst.Operand = memory.ReadWord(r, addr+1)
+	}
+	return inst
+}
+
+// Range decodes the instructions starting from start up to the one that
+// includes end.
+func Range(r memory.Reader, start, end uint16) []Instruction {
+	var insts []Instruction
+	for addr := int(start); addr <= int(end); {
+		inst := Decode(r, uint16(addr))
+		insts = append(insts, inst)
+		addr += int(inst.Len())
+	}
+	return insts
+}
+
+// Disassemble writes a listing of the instructions from start to end, with
+// their address and encoding, to w in MOS syntax.
+func Disassemble(w io.Writer, r memory.Reader, start, end uint16) error {
+	return (&Printer{}).Listing(w, r, start, end)
+}
+
+// Line formats inst as a listing line: "0200  A9 42     LDA #$42".
+func Line(inst Instruction) string {
+	return listingPrefix(inst) + "  " + inst.String()
+}
+
+// Line formats inst as a listing line like the package-level Line, in the
+// dialect of the printer and naming its operand with the symbols.
+func (p *Printer) Line(inst Instruction) string {
+	labels := p.labels([]Instruction{inst})
+	return p.listingLine(inst, operandLabel(inst, labels))
+}
+
+// listingLine formats inst as a listing line, with its cycles if the printer
+// shows them.
+func (p *Printer) listingLine(inst Instruction, label string) string {
+	line := listingPrefix(inst) + "  " + inst.format(p.dialect(), label)
+	if p.Cycles && inst.Info.Documented() {
+		line = fmt.Sprintf("%-*s; %s", cyclesColumn, line, inst.Cycles())
+	}
+	return line
+}
+
+// cyclesColumn is where the cycles start in listing lines.
+const cyclesColumn = 40
+
+func listingPrefix(inst Instruction) string {
+	hex := make([]string, 0, 3)
+	for _, b := range inst.Bytes() {
+		hex = append(hex, fmt.Sprintf("%02X", b))
+	}
+	return fmt.Sprintf("%04X  %-8s", inst.Addr, strings.Join(hex, " "))
+}
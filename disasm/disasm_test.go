@@ -0,0 +1,106 @@
+package disasm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func newDisasmTestMemory(addr uint16, code ...byte) *memory.Memory {
+	mem := memory.Memory{}
+	for i, b := range code {
+		mem.Write(b, addr+uint16(i))
+	}
+	return &mem
+}
+
+func TestDecodeAddressingModes(t *testing.T) {
+	for _, tc := range []struct {
+		code     []byte
+		expected string
+		len      uint16
+	}{
+		{[]byte{0xEA}, "NOP", 1},
+		{[]byte{0x0A}, "ASL A", 1},
+		{[]byte{0xA9, 0x42}, "LDA #$42", 2},
+		{[]byte{0xA5, 0x10}, "LDA $10", 2},
+		{[]byte{0xB5, 0x10}, "LDA $10,X", 2},
+		{[]byte{0xB6, 0x10}, "LDX $10,Y", 2},
+		{[]byte{0xAD, 0x34, 0x12}, "LDA $1234", 3},
+		{[]byte{0xBD, 0x34, 0x12}, "LDA $1234,X", 3},
+		{[]byte{0xB9, 0x34, 0x12}, "LDA $1234,Y", 3},
+		{[]byte{0x6C, 0xFC, 0xFF}, "JMP ($FFFC)", 3},
+		{[]byte{0xA1, 0x20}, "LDA ($20,X)", 2},
+		{[]byte{0xB1, 0x20}, "LDA ($20),Y", 2},
+		{[]byte{0xD0, 0xFE}, "BNE $0200", 2},
+		{[]byte{0x10, 0x10}, "BPL $0212", 2},
+		{[]byte{0x02}, ".byte $02", 1},
+	} {
+		inst := Decode(newDisasmTestMemory(0x0200, tc.code...), 0x0200)
+		if inst.String() != tc.expected || inst.Len() != tc.len {
+			t.Errorf("expected %q (%d bytes), actual %q (%d bytes)\n", tc.expected, tc.len, inst.String(), inst.Len())
+		}
+	}
+}
+
+func TestDecodeTarget(t *testing.T) {
+	mem := newDisasmTestMemory(0x0200, 0x20, 0x00, 0xC0, 0x4C, 0x00, 0x02, 0x6C, 0x00, 0x03, 0xEA)
+	insts := Range(mem, 0x0200, 0x0209)
+
+	expected := []struct {
+		target uint16
+		ok     bool
+	}{{0xC000, true}, {0x0200, true}, {0, false}, {0, false}}
+	if len(insts) != len(expected) {
+		t.Fatalf("expected %d instructions, actual %d", len(expected), len(insts))
+	}
+	for i, e := range expected {
+		if target, ok := insts[i].Target(); target != e.target || ok != e.ok {
+			t.Errorf("%s: expected %04X %v, actual %04X %v\n", insts[i], e.target, e.ok, target, ok)
+		}
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	mem := newDisasmTestMemory(0x0200, 0xA9, 0x42, 0x8D, 0x00, 0xD0, 0x60)
+
+	var out strings.Builder
+	if err := Disassemble(&out, mem, 0x0200, 0x0205); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "" +
+		"0200  A9 42     LDA #$42\n" +
+		"0202  8D 00 D0  STA $D000\n" +
+		"0205  60        RTS\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, out.String())
+	}
+}
+
+func TestPrinterCycles(t *testing.T) {
+	mem := newDisasmTestMemory(0x02F8,
+		0xBD, 0x00, 0x10, // lda $1000,x
+		0x9D, 0x00, 0x10, // sta $1000,x
+		0xD0, 0xF8, // bne $02F8
+		0xF0, 0x02, // beq $0304
+		0x02,
+	)
+
+	var out strings.Builder
+	p := Printer{Cycles: true}
+	if err := p.Listing(&out, mem, 0x02F8, 0x0302); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "" +
+		"02F8  BD 00 10  LDA $1000,X             ; 4+\n" +
+		"02FB  9D 00 10  STA $1000,X             ; 5\n" +
+		"02FE  D0 F8     BNE $02F8               ; 2/4\n" +
+		"0300  F0 02     BEQ $0304               ; 2/3\n" +
+		"0302  02        .byte $02\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, out.String())
+	}
+}
@@ -0,0 +1,40 @@
+package disasm
+
+import (
+	"strings"
+	"testing"
+)
+
+// FuzzDecode decodes random bytes in every dialect, checking that the
+// instructions are one to three bytes long and that their encoding is what
+// was decoded.
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte{0xA9, 0x42}, uint16(0x0200))
+	f.Add([]byte{0xD0, 0x80}, uint16(0xFFFE))
+	f.Add([]byte{0x6C, 0xFF, 0x10}, uint16(0xFFFF))
+	f.Fuzz(func(t *testing.T, code []byte, addr uint16) {
+		mem := newDisasmTestMemory(addr, code...)
+		inst := Decode(mem, addr)
+
+		if n := inst.Len(); n < 1 || n > 3 {
+			t.Fatalf("%s: unexpected length %d", inst, n)
+		}
+		b := inst.Bytes()
+		if len(b) != int(inst.Len()) {
+			t.Fatalf("%s: expected %d bytes, actual %d", inst, inst.Len(), len(b))
+		}
+		for i, v := range b {
+			if actual := mem.Read(addr + uint16(i)); actual != v {
+				t.Fatalf("%s: expected $%02X at offset %d, actual $%02X", inst, actual, i, v)
+			}
+		}
+		for _, d := range Dialects {
+			if s := inst.Format(d); s == "" || strings.ContainsRune(s, '\n') {
+				t.Fatalf("%s: unexpected %s syntax %q", inst, d.Name, s)
+			}
+		}
+		if !strings.HasPrefix((&Printer{Cycles: true}).Line(inst), Line(inst)) {
+			t.Fatalf("%s: unexpected listing line %q", inst, Line(inst))
+		}
+	})
+}
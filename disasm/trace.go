@@ -0,0 +1,52 @@
+package disasm
+
+import (
+	"io"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// Traced returns the instruction of a trace entry, decoded from the bytes
+// the CPU ran rather than from what memory holds now.
+func Traced(e cpu.TraceEntry) Instruction {
+	return Instruction{Addr: e.PC, Opcode: e.Opcode, Operand: e.Operand, Info: cpu.Opcode(e.Opcode)}
+}
+
+// WriteTrace writes the instructions of a CPU trace to w in MOS syntax, each
+// with the registers as they were before it ran:
+//
+//	0200  A9 42     LDA #$42         A=00 X=00 Y=00 SP=FF SR=00100000
+func WriteTrace(w io.Writer, entries []cpu.TraceEntry) error {
+	return (&Printer{}).Trace(w, entries)
+}
+
+// Trace writes the instructions of a CPU trace to w like WriteTrace, naming
+// addresses with the symbols of the printer, in its trace format.
+func (p *Printer) Trace(w io.Writer, entries []cpu.TraceEntry) error {
+	d := p.dialect()
+	format := p.TraceFormat
+	if format == nil {
+		format = defaultTraceFormat
+	}
+	insts := make([]Instruction, len(entries))
+	for i, e := range entries {
+		insts[i] = Traced(e)
+	}
+	var labels map[uint16]string
+	if p.AutoLabels || p.Symbols != nil {
+		labels = p.labels(insts)
+	}
+	lw := &lineWriter{w: w}
+	var line []byte
+	for i, e := range entries {
+		inst := insts[i]
+		if label, ok := labels[inst.Addr]; ok {
+			lw.printf("%s%s\n", label, d.LabelSuffix)
+		}
+		line = format.append(line[:0], e, inst, func() string {
+			return inst.format(d, operandLabel(inst, labels))
+		})
+		lw.printf("%s\n", line)
+	}
+	return lw.err
+}
@@ -0,0 +1,57 @@
+package disasm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/symbols"
+)
+
+func TestWriteTrace(t *testing.T) {
+	mem := newDisasmTestMemory(0x0200, 0xA9, 0x42, 0x02)
+	c := cpu.New(mem)
+	c.Reset()
+	c.SetTraceSize(4)
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The LDA is overwritten: the trace still shows what ran.
+	mem.Write(0xEA, 0x0200)
+	if err := c.Step(); err == nil {
+		t.Fatalf("expected invalid opcode error")
+	}
+
+	var out strings.Builder
+	if err := WriteTrace(&out, c.Trace()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "" +
+		"0200  A9 42     LDA #$42         A=00 X=00 Y=00 SP=FF SR=00100000\n" +
+		"0202  02        .byte $02        A=42 X=00 Y=00 SP=FF SR=00100000\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, out.String())
+	}
+}
+
+func TestPrinterTraceSymbols(t *testing.T) {
+	syms := symbols.New()
+	syms.Add("main", 0x0200)
+	syms.Add("sub", 0x0300)
+	entries := []cpu.TraceEntry{
+		{Registers: cpu.Registers{SP: 0xFF, PC: 0x0200, SR: 0x20}, Opcode: 0x20, Operand: 0x0300},
+	}
+
+	var out strings.Builder
+	if err := (&Printer{Symbols: syms}).Trace(&out, entries); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "" +
+		"main:\n" +
+		"0200  20 00 03  JSR sub          A=00 X=00 Y=00 SP=FF SR=00100000\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, out.String())
+	}
+}
@@ -0,0 +1,193 @@
+package disasm
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// DefaultTraceFormat is the format of the lines written by WriteTrace.
+const DefaultTraceFormat = "{PC}  {BYTES:8}  {DISASM:16} A={A} X={X} Y={Y} SP={SP} SR={SRBITS}"
+
+// ErrTraceFormat is returned for trace formats that can not be parsed.
+var ErrTraceFormat = errors.New("disasm: invalid trace format")
+
+var defaultTraceFormat = MustParseTraceFormat(DefaultTraceFormat)
+
+// Fields of trace formats.
+const (
+	fieldLiteral traceField = iota
+	fieldPC
+	fieldBytes
+	fieldDisasm
+	fieldA
+	fieldX
+	fieldY
+	fieldSP
+	fieldSR
+	fieldSRBits
+	fieldCycles
+)
+
+type traceField byte
+
+var traceFields = map[string]traceField{
+	"PC":     fieldPC,
+	"BYTES":  fieldBytes,
+	"DISASM": fieldDisasm,
+	"A":      fieldA,
+	"X":      fieldX,
+	"Y":      fieldY,
+	"SP":     fieldSP,
+	"SR":     fieldSR,
+	"SRBITS": fieldSRBits,
+	"CYCLES": fieldCycles,
+}
+
+// TraceFormat is the format of the lines of traces, so that they can match
+// the traces of other emulators for diffing, or hold only what is needed.
+//
+// A format is text with fields in braces, such as "{PC} {DISASM:16} A:{A}":
+//
+//	{PC}      address of the instruction, in hex
+//	{BYTES}   bytes of the instruction, in hex
+//	{DISASM}  instruction, in the dialect of the printer
+//	{A} {X} {Y} {SP} {SR}
+//	          registers before the instruction, in hex
+//	{SRBITS}  status register in binary
+//	{CYCLES}  cycle count when the instruction started, in decimal
+//
+// A field followed by a colon and a width, such as {DISASM:16}, is padded
+// with spaces to that width. {{ and }} stand for braces.
+type TraceFormat struct {
+	src   string
+	parts []tracePart
+}
+
+type tracePart struct {
+	field   traceField
+	literal string
+	width   int
+}
+
+// ParseTraceFormat parses a trace format.
+func ParseTraceFormat(s string) (*TraceFormat, error) {
+	f := &TraceFormat{src: s}
+	var lit strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "{{") || strings.HasPrefix(s[i:], "}}"):
+			lit.WriteByte(s[i])
+			i++
+		case s[i] == '}':
+			return nil, fmt.Errorf("%w: unmatched } in %q", ErrTraceFormat, s)
+		case s[i] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unmatched { in %q", ErrTraceFormat, s)
+			}
+			part, err := parseTraceField(s[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+			if lit.Len() != 0 {
+				f.parts = append(f.parts, tracePart{literal: lit.String()})
+				lit.Reset()
+			}
+			f.parts = append(f.parts, part)
+			i += end
+		default:
+			lit.WriteByte(s[i])
+		}
+	}
+	if lit.Len() != 0 {
+		f.parts = append(f.parts, tracePart{literal: lit.String()})
+	}
+	return f, nil
+}
+
+func parseTraceField(s string) (tracePart, error) {
+	name, width, hasWidth := strings.Cut(s, ":")
+	field, ok := traceFields[strings.ToUpper(name)]
+	if !ok {
+		return tracePart{}, fmt.Errorf("%w: unknown field %q", ErrTraceFormat, name)
+	}
+	part := tracePart{field: field}
+	if hasWidth {
+		w, err := strconv.Atoi(width)
+		if err != nil || w < 0 {
+			return tracePart{}, fmt.Errorf("%w: invalid width %q", ErrTraceFormat, width)
+		}
+		part.width = w
+	}
+	return part, nil
+}
+
+// MustParseTraceFormat is like ParseTraceFormat but panics if the format is
+// invalid, for formats known in advance such as those of variables.
+func MustParseTraceFormat(s string) *TraceFormat {
+	f, err := ParseTraceFormat(s)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// String returns the source of the format.
+func (f *TraceFormat) String() string {
+	return f.src
+}
+
+// append appends the line of a trace entry to b. The disassembly, the
+// costliest field, is only formatted when the format has it.
+func (f *TraceFormat) append(b []byte, e cpu.TraceEntry, inst Instruction, disasm func() string) []byte {
+	for _, part := range f.parts {
+		start := len(b)
+		switch part.field {
+		case fieldLiteral:
+			b = append(b, part.literal...)
+		case fieldPC:
+			b = appendHex(b, uint(e.PC), 4)
+		case fieldBytes:
+			for i, v := range inst.Bytes() {
+				if i != 0 {
+					b = append(b, ' ')
+				}
+				b = appendHex(b, uint(v), 2)
+			}
+		case fieldDisasm:
+			b = append(b, disasm()...)
+		case fieldA:
+			b = appendHex(b, uint(e.A), 2)
+		case fieldX:
+			b = appendHex(b, uint(e.X), 2)
+		case fieldY:
+			b = appendHex(b, uint(e.Y), 2)
+		case fieldSP:
+			b = appendHex(b, uint(e.SP), 2)
+		case fieldSR:
+			b = appendHex(b, uint(e.SR), 2)
+		case fieldSRBits:
+			for bit := 7; bit >= 0; bit-- {
+				b = append(b, '0'+e.SR>>bit&1)
+			}
+		case fieldCycles:
+			b = strconv.AppendUint(b, uint64(e.Cycles), 10)
+		}
+		for len(b)-start < part.width {
+			b = append(b, ' ')
+		}
+	}
+	return b
+}
+
+func appendHex(b []byte, v uint, digits int) []byte {
+	const hexDigits = "0123456789ABCDEF"
+	for shift := (digits - 1) * 4; shift >= 0; shift -= 4 {
+		b = append(b, hexDigits[v>>shift&0xF])
+	}
+	return b
+}
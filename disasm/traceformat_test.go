@@ -0,0 +1,64 @@
+package disasm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+func TestTraceFormat(t *testing.T) {
+	entries := []cpu.TraceEntry{
+		{Registers: cpu.Registers{A: 0x01, SP: 0xFD, PC: 0xC000, SR: 0x24}, Opcode: 0x20, Operand: 0xC010, Cycles: 7},
+		{Registers: cpu.Registers{A: 0x01, SP: 0xFB, PC: 0xC010, SR: 0x24}, Opcode: 0x60, Cycles: 13},
+	}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: "{PC}  {BYTES:9} {DISASM:10}A:{A} X:{X} Y:{Y} P:{SR} SP:{SP} CYC:{CYCLES}",
+			expected: "C000  20 10 C0  JSR $C010 A:01 X:00 Y:00 P:24 SP:FD CYC:7\n" +
+				"C010  60        RTS       A:01 X:00 Y:00 P:24 SP:FB CYC:13\n",
+		},
+		{
+			format:   "{pc} {{{srbits}}}",
+			expected: "C000 {00100100}\nC010 {00100100}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		f, err := ParseTraceFormat(tt.format)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var out strings.Builder
+		if err := (&Printer{TraceFormat: f}).Trace(&out, entries); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("expected\n%s\nactual\n%s\n", tt.expected, out.String())
+		}
+	}
+}
+
+func TestParseTraceFormatErrors(t *testing.T) {
+	for _, s := range []string{"{PC", "PC}", "{FOO}", "{PC:x}", "{PC:-1}"} {
+		if _, err := ParseTraceFormat(s); !errors.Is(err, ErrTraceFormat) {
+			t.Errorf("expected %v for %q, actual %v\n", ErrTraceFormat, s, err)
+		}
+	}
+}
+
+func TestMustParseTraceFormat(t *testing.T) {
+	if f := MustParseTraceFormat("{PC}"); f.String() != "{PC}" {
+		t.Errorf("expected {PC}, actual %q\n", f.String())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic\n")
+		}
+	}()
+	MustParseTraceFormat("{FOO}")
+}
@@ -0,0 +1,213 @@
+// Package expr evaluates the expressions debuggers use to inspect a machine,
+// such as
+//
+//	A == $3F && mem[$10] != 0
+//
+// Values are integers. Numbers are decimal, hexadecimal with a $ or 0x
+// prefix, or binary with a % prefix. Names, such as registers, are resolved
+// by the Env the expression is evaluated in, and mem[addr] is the byte at
+// addr. The operators are those of C, with the same precedence; comparisons
+// and logical operators give 1 for true and 0 for false.
+package expr
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSyntax is returned for expressions that can not be parsed.
+	ErrSyntax = errors.New("expr: syntax error")
+	// ErrUnknownName is returned when evaluating a name the Env does not
+	// know.
+	ErrUnknownName = errors.New("expr: unknown name")
+	// ErrDivisionByZero is returned when dividing by zero.
+	ErrDivisionByZero = errors.New("expr: division by zero")
+)
+
+// Env gives expressions access to the machine they inspect.
+type Env interface {
+	// Value returns the value of name, such as a register.
+	Value(name string) (int, bool)
+	// Read returns the content of addr. It must not have side effects.
+	Read(addr uint16) byte
+}
+
+// Expr is a parsed expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses s.
+func Parse(s string) (*Expr, error) {
+	p := &parser{s: s}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{src: s, root: root}, nil
+}
+
+// Eval evaluates the expression in env.
+func (e *Expr) Eval(env Env) (int, error) {
+	return e.root.eval(env)
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Bind returns the expression with the names lookup knows replaced by their
+// value, such as the symbols of a program, which do not change while it
+// runs. The other names are left to the Env.
+func (e *Expr) Bind(lookup func(name string) (int, bool)) *Expr {
+	return &Expr{src: e.src, root: e.root.bind(lookup)}
+}
+
+type node interface {
+	eval(env Env) (int, error)
+	bind(lookup func(string) (int, bool)) node
+}
+
+type number int
+
+func (n number) eval(Env) (int, error) {
+	return int(n), nil
+}
+
+func (n number) bind(func(string) (int, bool)) node {
+	return n
+}
+
+type name string
+
+func (n name) eval(env Env) (int, error) {
+	v, ok := env.Value(string(n))
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownName, string(n))
+	}
+	return v, nil
+}
+
+func (n name) bind(lookup func(string) (int, bool)) node {
+	if v, ok := lookup(string(n)); ok {
+		return number(v)
+	}
+	return n
+}
+
+type deref struct {
+	addr node
+}
+
+func (d deref) eval(env Env) (int, error) {
+	addr, err := d.addr.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	return int(env.Read(uint16(addr))), nil
+}
+
+func (d deref) bind(lookup func(string) (int, bool)) node {
+	return deref{addr: d.addr.bind(lookup)}
+}
+
+type unary struct {
+	op string
+	x  node
+}
+
+func (u unary) bind(lookup func(string) (int, bool)) node {
+	return unary{op: u.op, x: u.x.bind(lookup)}
+}
+
+func (u unary) eval(env Env) (int, error) {
+	x, err := u.x.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	switch u.op {
+	case "!":
+		return boolValue(x == 0), nil
+	case "~":
+		return ^x, nil
+	default:
+		return -x, nil
+	}
+}
+
+type binary struct {
+	op   string
+	l, r node
+}
+
+func (b binary) bind(lookup func(string) (int, bool)) node {
+	return binary{op: b.op, l: b.l.bind(lookup), r: b.r.bind(lookup)}
+}
+
+func (b binary) eval(env Env) (int, error) {
+	l, err := b.l.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	// The logical operators only evaluate their right side when needed.
+	switch {
+	case b.op == "&&" && l == 0:
+		return 0, nil
+	case b.op == "||" && l != 0:
+		return 1, nil
+	}
+	r, err := b.r.eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	switch b.op {
+	case "&&", "||":
+		return boolValue(r != 0), nil
+	case "|":
+		return l | r, nil
+	case "^":
+		return l ^ r, nil
+	case "&":
+		return l & r, nil
+	case "==":
+		return boolValue(l == r), nil
+	case "!=":
+		return boolValue(l != r), nil
+	case "<":
+		return boolValue(l < r), nil
+	case "<=":
+		return boolValue(l <= r), nil
+	case ">":
+		return boolValue(l > r), nil
+	case ">=":
+		return boolValue(l >= r), nil
+	case "<<":
+		return l << (r & 0x1F), nil
+	case ">>":
+		return l >> (r & 0x1F), nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	}
+	if r == 0 {
+		return 0, ErrDivisionByZero
+	}
+	if b.op == "/" {
+		return l / r, nil
+	}
+	return l % r, nil
+}
+
+func boolValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
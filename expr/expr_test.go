@@ -0,0 +1,112 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+type testEnv struct {
+	values map[string]int
+	mem    [0x10000]byte
+}
+
+func (e *testEnv) Value(name string) (int, bool) {
+	v, ok := e.values[name]
+	return v, ok
+}
+
+func (e *testEnv) Read(addr uint16) byte {
+	return e.mem[addr]
+}
+
+func newTestEnv() *testEnv {
+	env := &testEnv{values: map[string]int{"A": 0x3F, "X": 2}}
+	env.mem[0x10] = 0x80
+	env.mem[0x12] = 0x07
+	return env
+}
+
+func TestEval(t *testing.T) {
+	env := newTestEnv()
+	for _, tc := range []struct {
+		src      string
+		expected int
+	}{
+		{"42", 42},
+		{"$FF + 0x10 + %101", 0x114},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"A == $3F && mem[$10] != 0", 1},
+		{"A == $3F && mem[$11] != 0", 0},
+		{"mem[$10 + X] & $80", 0},
+		{"mem[$10] & $80", 0x80},
+		{"A % 2 | 1 << 4", 0x11},
+		{"!X || -1 < 0", 1},
+		{"~0 & $FF", 0xFF},
+		{"X >= 2 && X <= 2 && X > 1 && X < 3", 1},
+		{"7 / 2 - 7 % 2 ^ 1", 3},
+	} {
+		e, err := Parse(tc.src)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.src, err)
+		}
+		actual, err := e.Eval(env)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.src, err)
+		}
+		if actual != tc.expected {
+			t.Errorf("%q: expected %d, actual %d\n", tc.src, tc.expected, actual)
+		}
+	}
+}
+
+func TestEvalShortCircuits(t *testing.T) {
+	e, err := Parse("A != $3F && Q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, err := e.Eval(newTestEnv()); v != 0 || err != nil {
+		t.Errorf("expected 0, actual %d (%v)\n", v, err)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for src, expected := range map[string]error{
+		"Q + 1": ErrUnknownName,
+		"1 / 0": ErrDivisionByZero,
+		"A % 0": ErrDivisionByZero,
+	} {
+		e, err := Parse(src)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", src, err)
+		}
+		if _, err := e.Eval(newTestEnv()); !errors.Is(err, expected) {
+			t.Errorf("%q: expected %v, actual %v\n", src, expected, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{"", "1 +", "(1", "mem[1", "mem 1", "$G", "1 2", "A ! B"} {
+		if _, err := Parse(src); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: expected %v, actual %v\n", src, ErrSyntax, err)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	e, err := Parse("mem[ptr + X] + ptr")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bound := e.Bind(func(name string) (int, bool) {
+		return 0x10, name == "ptr"
+	})
+
+	if v, err := bound.Eval(newTestEnv()); v != 0x17 || err != nil {
+		t.Errorf("expected $17, actual $%X (%v)\n", v, err)
+	}
+	if _, err := e.Eval(newTestEnv()); !errors.Is(err, ErrUnknownName) {
+		t.Errorf("expected the original to be unchanged, actual %v\n", err)
+	}
+}
@@ -0,0 +1,186 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// binaryLevels lists the binary operators from the lowest precedence to the
+// highest. Within a level, longer operators come first so that they are not
+// taken for their prefixes.
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"|"},
+	{"^"},
+	{"&"},
+	{"==", "!="},
+	{"<=", ">=", "<", ">"},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) parse() (node, error) {
+	n, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return n, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrSyntax, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// operator consumes and returns one of ops if it comes next.
+func (p *parser) operator(ops []string) (string, bool) {
+	p.skipSpaces()
+	rest := p.s[p.pos:]
+	for _, op := range ops {
+		if !strings.HasPrefix(rest, op) {
+			continue
+		}
+		// A single & or | is not the start of && or ||, nor < or > the
+		// start of a shift.
+		if len(op) == 1 && len(rest) > 1 && rest[1] == op[0] && strings.Contains("&|<>", op) {
+			continue
+		}
+		p.pos += len(op)
+		return op, true
+	}
+	return "", false
+}
+
+func (p *parser) binary(level int) (node, error) {
+	if level == len(binaryLevels) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.operator(binaryLevels[level])
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, l: left, r: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	op, ok := p.operator([]string{"!", "~", "-"})
+	if !ok {
+		return p.primary()
+	}
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return unary{op: op, x: x}, nil
+}
+
+func (p *parser) primary() (node, error) {
+	p.skipSpaces()
+	if p.pos == len(p.s) {
+		return nil, p.errorf("missing value")
+	}
+
+	if p.s[p.pos] == '(' {
+		p.pos++
+		n, err := p.binary(0)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+
+	start := p.pos
+	// $ and % are prefixes of numbers, and operators anywhere else.
+	if c := p.s[p.pos]; c == '$' || c == '%' {
+		p.pos++
+	}
+	for p.pos < len(p.s) && isWordChar(p.s[p.pos]) {
+		p.pos++
+	}
+	word := p.s[start:p.pos]
+	switch {
+	case word == "":
+		return nil, p.errorf("unexpected %q", p.s[start:])
+	case strings.EqualFold(word, "mem"):
+		if err := p.expect('['); err != nil {
+			return nil, err
+		}
+		addr, err := p.binary(0)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(']'); err != nil {
+			return nil, err
+		}
+		return deref{addr: addr}, nil
+	case isName(word):
+		return name(word), nil
+	}
+	v, err := parseNumber(word)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", word)
+	}
+	return number(v), nil
+}
+
+func (p *parser) expect(c byte) error {
+	p.skipSpaces()
+	if p.pos == len(p.s) || p.s[p.pos] != c {
+		return p.errorf("missing %c", c)
+	}
+	p.pos++
+	return nil
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' ||
+		c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isName(s string) bool {
+	c := s[0]
+	return c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func parseNumber(s string) (int64, error) {
+	switch {
+	case strings.HasPrefix(s, "$"):
+		return strconv.ParseInt(s[1:], 16, 64)
+	case strings.HasPrefix(s, "0x"), strings.HasPrefix(s, "0X"):
+		return strconv.ParseInt(s[2:], 16, 64)
+	case strings.HasPrefix(s, "%"):
+		return strconv.ParseInt(s[1:], 2, 64)
+	default:
+		return strconv.ParseInt(s, 10, 64)
+	}
+}
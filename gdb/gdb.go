@@ -0,0 +1,267 @@
+// Package gdb implements a stub of the GDB remote serial protocol, so that
+// 6502 programs running on the emulator can be debugged with gdb and the
+// front-ends and IDEs speaking its protocol.
+//
+// The stub describes the registers to the debugger with a target description:
+// a, x, y, p (the status register) and sp of 8 bits, and pc of 16 bits, in
+// that order. It supports reading and writing registers and memory, software
+// and hardware breakpoints, continuing, single stepping, and interrupting a
+// running program.
+package gdb
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// runSlice is the number of cycles a continue runs between checks for an
+// interrupt from the debugger.
+const runSlice = 100_000
+
+// Signals reported to the debugger when the program stops.
+const (
+	sigint  = 2
+	sigill  = 4
+	sigtrap = 5
+	sigsegv = 11
+)
+
+// Server serves a CPU to debuggers.
+type Server struct {
+	cpu *cpu.CPU
+	mem memory.ReadWriter
+	// breakpoints maps the addresses of the breakpoints set by the debugger
+	// to their CPU ids.
+	breakpoints map[uint16]int
+}
+
+// NewServer returns a Server debugging c. mem is used to read and write
+// memory for the debugger: it should be free of side effects, such as the
+// DebugView of a bus.
+func NewServer(c *cpu.CPU, mem memory.ReadWriter) *Server {
+	return &Server{cpu: c, mem: mem, breakpoints: make(map[uint16]int)}
+}
+
+// ListenAndServe listens on the TCP address addr and serves the debuggers
+// connecting to it, one at a time.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("gdb: %w", err)
+	}
+	defer func() { _ = l.Close() }()
+	return s.Serve(l)
+}
+
+// Serve serves the debuggers connecting to l, one at a time, until l fails.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return fmt.Errorf("gdb: %w", err)
+		}
+		err = s.ServeConn(conn)
+		_ = conn.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ServeConn serves a single debugger on conn until it detaches, kills the
+// program or closes the connection.
+func (s *Server) ServeConn(conn io.ReadWriter) error {
+	sess := &session{
+		server: s,
+		w:      conn,
+		events: make(chan event),
+		done:   make(chan struct{}),
+		ack:    true,
+	}
+	defer close(sess.done)
+	go sess.read(bufio.NewReader(conn))
+	return sess.serve()
+}
+
+type eventKind byte
+
+const (
+	eventNone eventKind = iota
+	eventPacket
+	eventBadPacket
+	eventNak
+	eventInterrupt
+	eventError
+)
+
+type event struct {
+	kind   eventKind
+	packet string
+	err    error
+}
+
+type session struct {
+	server *Server
+	w      io.Writer
+	events chan event
+	// done is closed when the session ends, so that read stops.
+	done chan struct{}
+	// pending holds the packets received while the program ran.
+	pending []event
+	ack     bool
+	last    string
+}
+
+// errDetached ends a session normally.
+var errDetached = errors.New("gdb: detached")
+
+func (sess *session) serve() error {
+	for {
+		var ev event
+		if len(sess.pending) != 0 {
+			ev, sess.pending = sess.pending[0], sess.pending[1:]
+		} else {
+			ev = <-sess.events
+		}
+
+		var err error
+		switch ev.kind {
+		case eventError:
+			if errors.Is(ev.err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("gdb: reading from debugger: %w", ev.err)
+		case eventNak:
+			err = sess.send(sess.last)
+		case eventBadPacket:
+			if sess.ack {
+				err = sess.write("-")
+			}
+		case eventInterrupt:
+			err = sess.send(stopReply(sigint))
+		case eventPacket:
+			err = sess.packet(ev.packet)
+		}
+		if errors.Is(err, errDetached) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (sess *session) packet(p string) error {
+	if sess.ack {
+		if err := sess.write("+"); err != nil {
+			return err
+		}
+	}
+	reply, err := sess.handle(p)
+	if p == "k" {
+		// Killing the program gets no reply.
+		return err
+	}
+	if sendErr := sess.send(reply); sendErr != nil {
+		return sendErr
+	}
+	return err
+}
+
+// read turns what the debugger sends into events, until the connection
+// fails or the session ends.
+func (sess *session) read(r *bufio.Reader) {
+	for {
+		ev, err := readEvent(r)
+		if err != nil {
+			ev = event{kind: eventError, err: err}
+		}
+		if ev.kind == eventNone {
+			continue
+		}
+		select {
+		case sess.events <- ev:
+		case <-sess.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func readEvent(r *bufio.Reader) (event, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return event{}, err
+	}
+	switch c {
+	case '-':
+		return event{kind: eventNak}, nil
+	case 0x03:
+		return event{kind: eventInterrupt}, nil
+	case '$':
+		data, err := r.ReadString('#')
+		if err != nil {
+			return event{}, err
+		}
+		var sum [2]byte
+		if _, err := io.ReadFull(r, sum[:]); err != nil {
+			return event{}, err
+		}
+		data = data[:len(data)-1]
+		if fmt.Sprintf("%02x", checksum(data)) != strings.ToLower(string(sum[:])) {
+			return event{kind: eventBadPacket}, nil
+		}
+		return event{kind: eventPacket, packet: data}, nil
+	}
+	// Acknowledgements and stray bytes need no answer.
+	return event{kind: eventNone}, nil
+}
+
+func (sess *session) send(data string) error {
+	sess.last = data
+	return sess.write(fmt.Sprintf("$%s#%02x", data, checksum(data)))
+}
+
+func (sess *session) write(s string) error {
+	if _, err := io.WriteString(sess.w, s); err != nil {
+		return fmt.Errorf("gdb: writing to debugger: %w", err)
+	}
+	return nil
+}
+
+func checksum(data string) byte {
+	var sum byte
+	for i := range len(data) {
+		sum += data[i]
+	}
+	return sum
+}
+
+// interrupted reports whether the debugger asked to interrupt the program,
+// keeping any other event for later.
+func (sess *session) interrupted() bool {
+	for {
+		select {
+		case ev := <-sess.events:
+			if ev.kind == eventInterrupt {
+				return true
+			}
+			sess.pending = append(sess.pending, ev)
+		default:
+			return false
+		}
+	}
+}
+
+func stopReply(signal int) string {
+	return fmt.Sprintf("S%02x", signal)
+}
@@ -0,0 +1,154 @@
+package gdb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+	ack  bool
+}
+
+// newGDBTest serves a CPU about to run LDA #$01, LDA #$02, LDA #$03 and an
+// invalid opcode to a client on the other end of a pipe.
+func newGDBTest(t *testing.T) (*testClient, *cpu.CPU, *memory.Memory, chan error) {
+	mem := &memory.Memory{}
+	for i, b := range []byte{0xA9, 0x01, 0xA9, 0x02, 0xA9, 0x03, 0x02} {
+		mem.Write(b, 0x0200+uint16(i))
+	}
+	c := cpu.New(mem)
+	c.Reset()
+
+	server, client := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(c, mem).ServeConn(server)
+		server.Close()
+	}()
+	t.Cleanup(func() { client.Close() })
+	return &testClient{t: t, conn: client, r: bufio.NewReader(client), ack: true}, c, mem, done
+}
+
+// exchange sends packet and returns the reply.
+func (tc *testClient) exchange(packet string) string {
+	tc.t.Helper()
+	fmt.Fprintf(tc.conn, "$%s#%02x", packet, checksum(packet))
+	if tc.ack {
+		if b, err := tc.r.ReadByte(); err != nil || b != '+' {
+			tc.t.Fatalf("expected +, actual %q (%v)", b, err)
+		}
+	}
+	return tc.reply()
+}
+
+func (tc *testClient) reply() string {
+	tc.t.Helper()
+	if b, err := tc.r.ReadByte(); err != nil || b != '$' {
+		tc.t.Fatalf("expected $, actual %q (%v)", b, err)
+	}
+	data, err := tc.r.ReadString('#')
+	if err != nil {
+		tc.t.Fatalf("unexpected error: %v", err)
+	}
+	var sum [2]byte
+	if _, err := io.ReadFull(tc.r, sum[:]); err != nil {
+		tc.t.Fatalf("unexpected error: %v", err)
+	}
+	data = data[:len(data)-1]
+	if fmt.Sprintf("%02x", checksum(data)) != string(sum[:]) {
+		tc.t.Fatalf("bad checksum in %q", data)
+	}
+	if !tc.ack {
+		return data
+	}
+	fmt.Fprint(tc.conn, "+")
+	return data
+}
+
+func expectReply(t *testing.T, tc *testClient, packet, expected string) {
+	t.Helper()
+	if actual := tc.exchange(packet); actual != expected {
+		t.Errorf("%s: expected %q, actual %q\n", packet, expected, actual)
+	}
+}
+
+func TestRegistersAndMemory(t *testing.T) {
+	tc, c, mem, _ := newGDBTest(t)
+
+	expectReply(t, tc, "g", "00000020ff0002")
+	expectReply(t, tc, "P5=3412", "OK")
+	expectReply(t, tc, "P0=7f", "OK")
+	expectReply(t, tc, "p5", "3412")
+	expectReply(t, tc, "G0102032140ff00", "OK")
+	expected := cpu.Registers{A: 1, X: 2, Y: 3, SR: 0x21, SP: 0x40, PC: 0x00FF}
+	if r := c.Registers(); r != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, r)
+	}
+
+	expectReply(t, tc, "m200,4", "a901a902")
+	expectReply(t, tc, "M300,2:beef", "OK")
+	if mem.Read(0x0301) != 0xEF {
+		t.Errorf("expected $EF at $0301, actual $%02X\n", mem.Read(0x0301))
+	}
+	expectReply(t, tc, "m200", "E01")
+}
+
+func TestBreakpointsAndStepping(t *testing.T) {
+	tc, c, _, _ := newGDBTest(t)
+
+	expectReply(t, tc, "Z0,204,1", "OK")
+	expectReply(t, tc, "c", "S05")
+	if pc := c.Registers().PC; pc != 0x0204 {
+		t.Errorf("expected to stop at $0204, actual $%04X\n", pc)
+	}
+	expectReply(t, tc, "z0,204,1", "OK")
+	expectReply(t, tc, "s", "S05")
+	expectReply(t, tc, "c", "S04")
+	expectReply(t, tc, "Z2,300,1", "")
+}
+
+func TestTargetDescription(t *testing.T) {
+	tc, _, _, _ := newGDBTest(t)
+
+	expectReply(t, tc, "qSupported:xmlRegisters=i386", "PacketSize=4000;qXfer:features:read+;QStartNoAckMode+")
+	first := tc.exchange("qXfer:features:read:target.xml:0,10")
+	if first != "m"+targetXML[:0x10] {
+		t.Errorf("unexpected first chunk %q\n", first)
+	}
+	last := tc.exchange(fmt.Sprintf("qXfer:features:read:target.xml:10,%x", len(targetXML)))
+	if last != "l"+targetXML[0x10:] {
+		t.Errorf("unexpected last chunk %q\n", last)
+	}
+}
+
+func TestNoAckModeAndDetach(t *testing.T) {
+	tc, _, _, done := newGDBTest(t)
+
+	expectReply(t, tc, "QStartNoAckMode", "OK")
+	tc.ack = false
+	expectReply(t, tc, "?", "S05")
+	expectReply(t, tc, "D", "OK")
+
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBadChecksumIsRejected(t *testing.T) {
+	tc, _, _, _ := newGDBTest(t)
+
+	fmt.Fprint(tc.conn, "$g#00")
+	if b, err := tc.r.ReadByte(); err != nil || b != '-' {
+		t.Fatalf("expected -, actual %q (%v)", b, err)
+	}
+	expectReply(t, tc, "g", "00000020ff0002")
+}
@@ -0,0 +1,296 @@
+package gdb
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// Replies to packets.
+const (
+	replyOK          = "OK"
+	replyError       = "E01"
+	replyUnsupported = ""
+)
+
+const (
+	// registerCount is the number of registers in the target description.
+	registerCount = 6
+	// maxMemoryRead is the most bytes a single m packet can read, half the
+	// packet size advertised.
+	maxMemoryRead = 0x2000
+)
+
+const targetXML = `<?xml version="1.0"?>
+<!DOCTYPE target SYSTEM "gdb-target.dtd">
+<target version="1.0">
+  <feature name="org.leakedmemory.mos6502.core">
+    <reg name="a" bitsize="8" type="uint8" regnum="0"/>
+    <reg name="x" bitsize="8" type="uint8"/>
+    <reg name="y" bitsize="8" type="uint8"/>
+    <reg name="p" bitsize="8" type="uint8"/>
+    <reg name="sp" bitsize="8" type="uint8"/>
+    <reg name="pc" bitsize="16" type="code_ptr"/>
+  </feature>
+</target>
+`
+
+// handle returns the reply to the packet p.
+func (sess *session) handle(p string) (string, error) {
+	if p == "" {
+		return replyUnsupported, nil
+	}
+	args := p[1:]
+	switch p[0] {
+	case '?':
+		return stopReply(sigtrap), nil
+	case 'g':
+		return sess.readRegisters(), nil
+	case 'G':
+		return sess.writeRegisters(args), nil
+	case 'p':
+		return sess.readRegister(args), nil
+	case 'P':
+		return sess.writeRegister(args), nil
+	case 'm':
+		return sess.readMemory(args), nil
+	case 'M':
+		return sess.writeMemory(args), nil
+	case 'Z', 'z':
+		return sess.breakpoint(p[0] == 'Z', args), nil
+	case 'c':
+		return sess.resume(args, false), nil
+	case 's':
+		return sess.resume(args, true), nil
+	case 'H':
+		// There is a single thread.
+		return replyOK, nil
+	case 'k', 'D':
+		return replyOK, errDetached
+	case 'q', 'Q':
+		return sess.query(p), nil
+	}
+	return replyUnsupported, nil
+}
+
+func (sess *session) query(p string) string {
+	switch {
+	case strings.HasPrefix(p, "qSupported"):
+		return "PacketSize=4000;qXfer:features:read+;QStartNoAckMode+"
+	case p == "QStartNoAckMode":
+		// The reply is still acknowledged by the debugger.
+		sess.ack = false
+		return replyOK
+	case p == "qAttached":
+		return "1"
+	case p == "qC":
+		return "QC1"
+	case p == "qfThreadInfo":
+		return "m1"
+	case p == "qsThreadInfo":
+		return "l"
+	case strings.HasPrefix(p, "qXfer:features:read:target.xml:"):
+		return xferTarget(strings.TrimPrefix(p, "qXfer:features:read:target.xml:"))
+	}
+	return replyUnsupported
+}
+
+// xferTarget returns the part of the target description asked for by the
+// offset,length argument of a qXfer packet.
+func xferTarget(args string) string {
+	off, length, ok := parsePair(args)
+	if !ok {
+		return replyError
+	}
+	if off >= len(targetXML) {
+		return "l"
+	}
+	end := min(off+length, len(targetXML))
+	if end == len(targetXML) {
+		return "l" + targetXML[off:end]
+	}
+	return "m" + targetXML[off:end]
+}
+
+func registerBytes(r cpu.Registers) []byte {
+	return []byte{r.A, r.X, r.Y, r.SR, r.SP, byte(r.PC), byte(r.PC >> 8)}
+}
+
+func (sess *session) readRegisters() string {
+	return hex.EncodeToString(registerBytes(sess.server.cpu.Registers()))
+}
+
+func (sess *session) writeRegisters(args string) string {
+	b, err := hex.DecodeString(args)
+	if err != nil || len(b) != len(registerBytes(cpu.Registers{})) {
+		return replyError
+	}
+	sess.server.cpu.SetRegisters(cpu.Registers{
+		A:  b[0],
+		X:  b[1],
+		Y:  b[2],
+		SR: b[3],
+		SP: b[4],
+		PC: uint16(b[5]) | uint16(b[6])<<8,
+	})
+	return replyOK
+}
+
+func (sess *session) readRegister(args string) string {
+	n, err := strconv.ParseUint(args, 16, 8)
+	if err != nil || n >= registerCount {
+		return replyError
+	}
+	b := registerBytes(sess.server.cpu.Registers())
+	if n == registerCount-1 {
+		return hex.EncodeToString(b[n:])
+	}
+	return hex.EncodeToString(b[n : n+1])
+}
+
+func (sess *session) writeRegister(args string) string {
+	num, val, ok := strings.Cut(args, "=")
+	n, err := strconv.ParseUint(num, 16, 8)
+	if !ok || err != nil || n >= registerCount {
+		return replyError
+	}
+	v, err := hex.DecodeString(val)
+	if err != nil || len(v) == 0 {
+		return replyError
+	}
+	r := sess.server.cpu.Registers()
+	switch n {
+	case 0:
+		r.A = v[0]
+	case 1:
+		r.X = v[0]
+	case 2:
+		r.Y = v[0]
+	case 3:
+		r.SR = v[0]
+	case 4:
+		r.SP = v[0]
+	default:
+		if len(v) < 2 {
+			return replyError
+		}
+		r.PC = uint16(v[0]) | uint16(v[1])<<8
+	}
+	sess.server.cpu.SetRegisters(r)
+	return replyOK
+}
+
+func (sess *session) readMemory(args string) string {
+	addr, length, ok := parsePair(args)
+	if !ok || length > maxMemoryRead {
+		return replyError
+	}
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = sess.server.mem.Read(uint16(addr + i))
+	}
+	return hex.EncodeToString(b)
+}
+
+func (sess *session) writeMemory(args string) string {
+	pair, data, ok := strings.Cut(args, ":")
+	if !ok {
+		return replyError
+	}
+	addr, length, ok := parsePair(pair)
+	b, err := hex.DecodeString(data)
+	if !ok || err != nil || len(b) != length {
+		return replyError
+	}
+	for i, v := range b {
+		sess.server.mem.Write(v, uint16(addr+i))
+	}
+	return replyOK
+}
+
+// breakpoint handles Z and z packets. Software and hardware breakpoints are
+// the same to the emulator; watchpoints are not supported.
+func (sess *session) breakpoint(insert bool, args string) string {
+	parts := strings.Split(args, ",")
+	if len(parts) < 2 {
+		return replyError
+	}
+	if parts[0] != "0" && parts[0] != "1" {
+		return replyUnsupported
+	}
+	a, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return replyError
+	}
+
+	s := sess.server
+	addr := uint16(a)
+	id, set := s.breakpoints[addr]
+	switch {
+	case insert && !set:
+		s.breakpoints[addr] = s.cpu.AddBreakpoint(addr)
+	case !insert && set:
+		s.cpu.RemoveBreakpoint(id)
+		delete(s.breakpoints, addr)
+	}
+	return replyOK
+}
+
+// resume handles the c and s packets, returning the stop reply once the
+// program stops.
+func (sess *session) resume(args string, step bool) string {
+	c := sess.server.cpu
+	if args != "" {
+		addr, err := strconv.ParseUint(args, 16, 16)
+		if err != nil {
+			return replyError
+		}
+		r := c.Registers()
+		r.PC = uint16(addr)
+		c.SetRegisters(r)
+	}
+
+	if step {
+		return faultReply(c.Step())
+	}
+	for {
+		reason, err := c.RunFor(runSlice)
+		if reason != cpu.StopCycles {
+			return faultReply(err)
+		}
+		if sess.interrupted() {
+			return stopReply(sigint)
+		}
+	}
+}
+
+// faultReply returns the stop reply for a program stopped by err, which is
+// nil for breakpoints and steps.
+func faultReply(err error) string {
+	var fault *cpu.BusFault
+	var invalid *cpu.InvalidOpcodeError
+	switch {
+	case errors.As(err, &fault):
+		return stopReply(sigsegv)
+	case errors.As(err, &invalid):
+		return stopReply(sigill)
+	}
+	return stopReply(sigtrap)
+}
+
+// parsePair parses the hexadecimal "addr,length" arguments of packets.
+func parsePair(s string) (int, int, bool) {
+	a, l, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, 0, false
+	}
+	addr, err1 := strconv.ParseUint(a, 16, 32)
+	length, err2 := strconv.ParseUint(l, 16, 32)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return int(addr), int(length), true
+}
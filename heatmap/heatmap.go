@@ -0,0 +1,137 @@
+// Package heatmap counts the reads, writes and opcode fetches of a 6502
+// program at every address, and exports them as CSV or as a PNG image,
+// showing how the program uses the zero page, the stack and its buffers.
+//
+//	h := heatmap.New()
+//	c.AddWatchpoint(0x0000, 0xFFFF, cpu.AccessAny, h.Record)
+//	c.Run()
+//	h.WritePNG(f)
+package heatmap
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+const (
+	addressSpaceSize = 0x10000
+	pageSize         = 0x100
+	// scale is the size in pixels of an address in the images.
+	scale = 2
+)
+
+// Counts is the number of accesses of each kind to an address.
+type Counts struct {
+	Reads    uint64
+	Writes   uint64
+	Executes uint64
+}
+
+// Heatmap holds the access counts of the whole address space.
+type Heatmap struct {
+	counts [addressSpaceSize]Counts
+}
+
+// New returns an empty Heatmap.
+func New() *Heatmap {
+	return &Heatmap{}
+}
+
+// Record counts an access. It is meant to be passed to the AddWatchpoint
+// method of a CPU, for the whole address space and every kind of access.
+func (h *Heatmap) Record(e cpu.WatchEvent) {
+	c := &h.counts[e.Addr]
+	switch e.Access {
+	case cpu.AccessRead:
+		c.Reads++
+	case cpu.AccessWrite:
+		c.Writes++
+	case cpu.AccessExecute:
+		c.Executes++
+	}
+}
+
+// Reset forgets everything counted so far.
+func (h *Heatmap) Reset() {
+	*h = Heatmap{}
+}
+
+// Counts returns the accesses to addr.
+func (h *Heatmap) Counts(addr uint16) Counts {
+	return h.counts[addr]
+}
+
+// WriteCSV writes a line for every address accessed, with its counts:
+//
+//	addr,reads,writes,executes
+//	0010,12,3,0
+func (h *Heatmap) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"addr", "reads", "writes", "executes"})
+	for addr, c := range h.counts {
+		if c == (Counts{}) {
+			continue
+		}
+		_ = cw.Write([]string{
+			fmt.Sprintf("%04X", addr),
+			strconv.FormatUint(c.Reads, 10),
+			strconv.FormatUint(c.Writes, 10),
+			strconv.FormatUint(c.Executes, 10),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("heatmap: %w", err)
+	}
+	return nil
+}
+
+// WritePNG writes the heatmap as a PNG image with a row for each page and a
+// column for each address in a page: the zero page is the top row and the
+// stack the next. Writes are red, reads green and opcode fetches blue, on a
+// logarithmic scale, so that addresses accessed once still show.
+func (h *Heatmap) WritePNG(w io.Writer) error {
+	var maxCounts Counts
+	for _, c := range h.counts {
+		maxCounts.Reads = max(maxCounts.Reads, c.Reads)
+		maxCounts.Writes = max(maxCounts.Writes, c.Writes)
+		maxCounts.Executes = max(maxCounts.Executes, c.Executes)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, pageSize*scale, addressSpaceSize/pageSize*scale))
+	for addr, c := range h.counts {
+		col := color.RGBA{
+			R: intensity(c.Writes, maxCounts.Writes),
+			G: intensity(c.Reads, maxCounts.Reads),
+			B: intensity(c.Executes, maxCounts.Executes),
+			A: 0xFF,
+		}
+		x, y := addr%pageSize*scale, addr/pageSize*scale
+		for dy := range scale {
+			for dx := range scale {
+				img.SetRGBA(x+dx, y+dy, col)
+			}
+		}
+	}
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("heatmap: %w", err)
+	}
+	return nil
+}
+
+// intensity maps n from 0 to maxN to a color component on a logarithmic
+// scale.
+func intensity(n, maxN uint64) uint8 {
+	if n == 0 {
+		return 0
+	}
+	return uint8(math.Round(0xFF * math.Log1p(float64(n)) / math.Log1p(float64(maxN))))
+}
@@ -0,0 +1,105 @@
+package heatmap
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newHeatmapTest returns the heatmap of a run of
+//
+//	0200  JSR $0210
+//	0210  LDA #$07
+//	0212  RTS
+func newHeatmapTest(t *testing.T) *Heatmap {
+	t.Helper()
+	mem := &memory.Memory{}
+	for addr, b := range map[uint16][]byte{
+		0x0200: {0x20, 0x10, 0x02},
+		0x0210: {0xA9, 0x07, 0x60},
+	} {
+		for i, v := range b {
+			mem.Write(v, addr+uint16(i))
+		}
+	}
+	c := cpu.New(mem)
+	c.Reset()
+	h := New()
+	c.AddWatchpoint(0x0000, 0xFFFF, cpu.AccessAny, h.Record)
+	for range 3 {
+		if err := c.Step(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return h
+}
+
+func TestRecord(t *testing.T) {
+	h := newHeatmapTest(t)
+
+	tests := []struct {
+		addr     uint16
+		expected Counts
+	}{
+		{0x0200, Counts{Executes: 1}},
+		{0x0201, Counts{Reads: 1}},
+		{0x01FF, Counts{Reads: 1, Writes: 1}},
+		{0x0210, Counts{Executes: 1}},
+		{0x0300, Counts{}},
+	}
+	for _, tt := range tests {
+		if c := h.Counts(tt.addr); c != tt.expected {
+			t.Errorf("expected %+v at $%04X, actual %+v\n", tt.expected, tt.addr, c)
+		}
+	}
+
+	h.Reset()
+	if c := h.Counts(0x0200); c != (Counts{}) {
+		t.Errorf("expected no access after Reset, actual %+v\n", c)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	h := newHeatmapTest(t)
+
+	var b strings.Builder
+	if err := h.WriteCSV(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	if lines[0] != "addr,reads,writes,executes" || lines[1] != "01FE,1,1,0" {
+		t.Errorf("expected a header and $01FE first, actual %q\n", b.String())
+	}
+	if !strings.Contains(b.String(), "\n0200,0,0,1\n") {
+		t.Errorf("expected $0200 executed, actual %q\n", b.String())
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	h := newHeatmapTest(t)
+
+	var b bytes.Buffer
+	if err := h.WritePNG(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if size := img.Bounds().Size(); size.X != 256*scale || size.Y != 256*scale {
+		t.Errorf("expected %dx%d, actual %v\n", 256*scale, 256*scale, size)
+	}
+	// $0200 is the first address of the third row.
+	if r, g, b, _ := img.At(0, 2*scale).RGBA(); r != 0 || g != 0 || b != 0xFFFF {
+		t.Errorf("expected blue at $0200, actual %04X %04X %04X\n", r, g, b)
+	}
+	if r, g, b, _ := img.At(0x40*scale, 0).RGBA(); r != 0 || g != 0 || b != 0 {
+		t.Errorf("expected black at $0040, actual %04X %04X %04X\n", r, g, b)
+	}
+}
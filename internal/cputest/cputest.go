@@ -0,0 +1,138 @@
+// Package cputest gives the tests of the packages built on the CPU, such as
+// machines and devices, controlled access to its registers, flags and
+// counters through its exported API, without the fields of the CPU being
+// exported.
+package cputest
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// The flags of the status register.
+const (
+	FlagC      byte = 0x01
+	FlagZ      byte = 0x02
+	FlagI      byte = 0x04
+	FlagD      byte = 0x08
+	FlagB      byte = 0x10
+	FlagUnused byte = 0x20
+	FlagV      byte = 0x40
+	FlagN      byte = 0x80
+)
+
+// CodeStart is where Load puts code by default, and where the CPU starts
+// after a reset.
+const CodeStart uint16 = 0x0200
+
+// Harness runs a CPU in a test, failing the test when an instruction fails.
+type Harness struct {
+	t   testing.TB
+	CPU *cpu.CPU
+	Mem memory.ReadWriter
+
+	instructions int
+}
+
+// New returns a harness running a CPU connected to mem, or to a zeroed
+// memory.Memory if mem is nil, powered on with cpu.Deterministic.
+func New(t testing.TB, mem memory.ReadWriter) *Harness {
+	t.Helper()
+	if mem == nil {
+		mem = &memory.Memory{}
+	}
+	h := &Harness{t: t, CPU: cpu.New(mem), Mem: mem}
+	h.CPU.SetUnstable(cpu.Deterministic)
+	h.CPU.PowerOn()
+	h.CPU.AddInstructionHook(func(cpu.InstructionEvent) { h.instructions++ })
+	return h
+}
+
+// Load writes code at addr.
+func (h *Harness) Load(addr uint16, code ...byte) {
+	for i, b := range code {
+		h.Mem.Write(b, addr+uint16(i))
+	}
+}
+
+// SetPC sends the CPU to pc.
+func (h *Harness) SetPC(pc uint16) {
+	r := h.CPU.Registers()
+	r.PC = pc
+	h.CPU.SetRegisters(r)
+}
+
+// SetFlags sets the flags in mask, or clears them if set is false.
+func (h *Harness) SetFlags(mask byte, set bool) {
+	r := h.CPU.Registers()
+	if set {
+		r.SR |= mask
+	} else {
+		r.SR &^= mask
+	}
+	h.CPU.SetRegisters(r)
+}
+
+// Flag reports whether the flag in mask is set.
+func (h *Harness) Flag(mask byte) bool {
+	return h.CPU.Registers().SR&mask != 0
+}
+
+// Step executes an instruction and returns the number of cycles it took,
+// failing the test if it fails.
+func (h *Harness) Step() uint {
+	h.t.Helper()
+	start := h.CPU.Cycles()
+	if err := h.CPU.Step(); err != nil {
+		h.t.Fatalf("unexpected error: %v", err)
+	}
+	return h.CPU.Cycles() - start
+}
+
+// StepN executes n instructions and returns the number of cycles they took.
+func (h *Harness) StepN(n int) uint {
+	h.t.Helper()
+	var cycles uint
+	for range n {
+		cycles += h.Step()
+	}
+	return cycles
+}
+
+// Instructions returns the number of instructions executed since New,
+// whether by Step or by the Run methods of the CPU.
+func (h *Harness) Instructions() int {
+	return h.instructions
+}
+
+// Cycles returns the number of cycles elapsed since the last reset.
+func (h *Harness) Cycles() uint {
+	return h.CPU.Cycles()
+}
+
+// ExpectRegisters reports an error unless the registers are expected.
+func (h *Harness) ExpectRegisters(expected cpu.Registers) {
+	h.t.Helper()
+	if actual := h.CPU.Registers(); actual != expected {
+		h.t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+}
+
+// ExpectFlags reports an error unless the flags in mask are those of
+// expected.
+func (h *Harness) ExpectFlags(mask, expected byte) {
+	h.t.Helper()
+	if actual := h.CPU.Registers().SR & mask; actual != expected&mask {
+		h.t.Errorf("expected flags %08b, actual %08b\n", expected&mask, actual)
+	}
+}
+
+// ExpectMemory reports an error unless the byte at addr is expected.
+func (h *Harness) ExpectMemory(addr uint16, expected byte) {
+	h.t.Helper()
+	if actual := h.Mem.Read(addr); actual != expected {
+		h.t.Errorf("expected $%02X at $%04X, actual $%02X\n", expected, addr, actual)
+	}
+}
@@ -0,0 +1,50 @@
+package cputest
+
+import (
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+func TestHarnessSteps(t *testing.T) {
+	h := New(t, nil)
+	// 0200  SEI
+	// 0201  LDA #$80
+	// 0203  CLI
+	h.Load(CodeStart, 0x78, 0xA9, 0x80, 0x58)
+
+	if cycles := h.StepN(3); cycles != 6 {
+		t.Errorf("expected 6 cycles, actual %d\n", cycles)
+	}
+	if h.Instructions() != 3 {
+		t.Errorf("expected 3 instructions, actual %d\n", h.Instructions())
+	}
+	h.ExpectRegisters(cpu.Registers{A: 0x80, SP: 0xFF, PC: 0x0204, SR: FlagUnused | FlagN})
+	h.ExpectFlags(FlagN|FlagZ|FlagI, FlagN)
+}
+
+func TestHarnessFlags(t *testing.T) {
+	h := New(t, nil)
+
+	h.SetFlags(FlagC|FlagD, true)
+	h.SetFlags(FlagD, false)
+
+	if !h.Flag(FlagC) || h.Flag(FlagD) {
+		t.Errorf("expected C set and D clear, actual sr %08b\n", h.CPU.Registers().SR)
+	}
+}
+
+func TestHarnessSetPC(t *testing.T) {
+	h := New(t, nil)
+	// 0300  JSR $0400
+	h.Load(0x0300, 0x20, 0x00, 0x04)
+	h.SetPC(0x0300)
+
+	h.Step()
+
+	h.ExpectMemory(0x01FF, 0x03)
+	h.ExpectMemory(0x01FE, 0x02)
+	if pc := h.CPU.Registers().PC; pc != 0x0400 {
+		t.Errorf("expected pc $0400, actual $%04X\n", pc)
+	}
+}
@@ -0,0 +1,58 @@
+// Package registry is the registry of named factories shared by packages
+// such as devices and machines, whose implementations register themselves
+// when imported.
+package registry
+
+import (
+	"slices"
+	"sync"
+)
+
+// Registry maps names to entries, such as the descriptions of devices. It is
+// safe for concurrent use.
+type Registry[T any] struct {
+	// kind prefixes the panics of Register, such as "devices".
+	kind    string
+	mu      sync.RWMutex
+	entries map[string]T
+}
+
+// New returns an empty Registry whose panics are prefixed with kind.
+func New[T any](kind string) *Registry[T] {
+	return &Registry[T]{kind: kind, entries: map[string]T{}}
+}
+
+// Register adds entry under name. It panics if the name was already
+// registered, or if hasNew is false, for an entry without the function
+// creating what it describes.
+func (r *Registry[T]) Register(name string, entry T, hasNew bool) {
+	if !hasNew {
+		panic(r.kind + ": Register of " + name + " with a nil New")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.entries[name]; dup {
+		panic(r.kind + ": Register called twice for " + name)
+	}
+	r.entries[name] = entry
+}
+
+// Lookup returns the entry registered with name, if any.
+func (r *Registry[T]) Lookup(name string) (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.entries[name]
+	return entry, ok
+}
+
+// Names returns the names registered, sorted.
+func (r *Registry[T]) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,35 @@
+package registry
+
+import (
+	"slices"
+	"testing"
+)
+
+func expectPanic(t *testing.T, expected string, f func()) {
+	t.Helper()
+	defer func() {
+		if actual := recover(); actual != expected {
+			t.Errorf("expected panic %q, actual %v\n", expected, actual)
+		}
+	}()
+	f()
+}
+
+func TestRegistry(t *testing.T) {
+	r := New[int]("test")
+	r.Register("b", 2, true)
+	r.Register("a", 1, true)
+
+	if v, ok := r.Lookup("b"); !ok || v != 2 {
+		t.Errorf("expected 2, actual %d, %t\n", v, ok)
+	}
+	if _, ok := r.Lookup("c"); ok {
+		t.Errorf("expected c not to be registered\n")
+	}
+	if names := r.Names(); !slices.Equal(names, []string{"a", "b"}) {
+		t.Errorf("expected [a b], actual %v\n", names)
+	}
+
+	expectPanic(t, "test: Register called twice for a", func() { r.Register("a", 3, true) })
+	expectPanic(t, "test: Register of c with a nil New", func() { r.Register("c", 3, false) })
+}
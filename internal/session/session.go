@@ -0,0 +1,264 @@
+// Package session controls a CPU for the remote debuggers, package web and
+// the gRPC server of the proto module, which translate their requests to
+// its methods: it runs the program in slices for clients to look at the CPU
+// in between, keeps the breakpoints set by address, and tells the watchers
+// whenever the CPU stops or its state is changed.
+package session
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// runSlice is the number of cycles run between checks for a stop.
+const runSlice = 100_000
+
+// ErrRunning is returned by the methods the running program prevents.
+var ErrRunning = errors.New("the program is running")
+
+// ReasonInterrupted is the reason of the CPU stopped by Stop.
+const ReasonInterrupted = "interrupted"
+
+// State is the state of the CPU.
+type State struct {
+	Registers   cpu.Registers
+	Cycles      uint
+	Running     bool
+	Breakpoints []uint16
+}
+
+// Event is sent to the watchers when the CPU stops or its state is changed.
+type Event struct {
+	// Stopped tells the CPU stopped, for Reason, rather than its state
+	// being changed.
+	Stopped bool
+	Reason  string
+	// Err is the error of a fault, for which Reason is "fault".
+	Err   error
+	State State
+}
+
+// Session controls a CPU. Its methods may be called from any goroutine.
+type Session struct {
+	// mu guards the CPU, which runs in slices while the program runs so
+	// that clients can look at it in between.
+	mu          sync.Mutex
+	cpu         *cpu.CPU
+	mem         memory.ReadWriter
+	breakpoints map[uint16]int
+	running     bool
+	watchers    map[int]func(Event)
+	nextWatcher int
+}
+
+// New returns a Session controlling c. mem is used to read and write memory
+// for the clients: it should be free of side effects, such as the DebugView
+// of a bus.
+func New(c *cpu.CPU, mem memory.ReadWriter) *Session {
+	return &Session{
+		cpu:         c,
+		mem:         mem,
+		breakpoints: make(map[uint16]int),
+		watchers:    make(map[int]func(Event)),
+	}
+}
+
+// Watch calls fn with every event from now on, without the lock held, and
+// returns the current state and the function to stop watching.
+func (s *Session) Watch(fn func(Event)) (State, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextWatcher
+	s.nextWatcher++
+	s.watchers[id] = fn
+	return s.state(), func() {
+		s.mu.Lock()
+		delete(s.watchers, id)
+		s.mu.Unlock()
+	}
+}
+
+// Do calls fn with the lock held, for what the methods of s do not cover,
+// such as adding hooks or disassembling. Hooks of the CPU run with the lock
+// held as well, and must not call the methods of s.
+func (s *Session) Do(fn func(c *cpu.CPU, mem memory.ReadWriter)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.cpu, s.mem)
+}
+
+// State returns the state of the CPU.
+func (s *Session) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state()
+}
+
+// Read reads n bytes of memory from addr.
+func (s *Session) Read(addr uint16, n int) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := make([]byte, max(n, 0))
+	for i := range data {
+		data[i] = s.mem.Read(addr + uint16(i))
+	}
+	return data
+}
+
+// Step executes an instruction, or enters the handler of a pending
+// interrupt. A fault is reported by the event, not as an error.
+func (s *Session) Step() (Event, error) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return Event{}, ErrRunning
+	}
+	ev := s.stopped(cpu.StopStepped.String(), s.cpu.Step())
+	s.mu.Unlock()
+	s.notify(ev)
+	return ev, nil
+}
+
+// Continue runs the program until a breakpoint, a fault or Stop.
+func (s *Session) Continue() (State, error) {
+	return s.change(func() {
+		s.running = true
+		go s.run()
+	})
+}
+
+// Stop stops the running program. If it is not running, the event is not
+// one of stopping and is not sent to the watchers.
+func (s *Session) Stop() Event {
+	s.mu.Lock()
+	if !s.running {
+		defer s.mu.Unlock()
+		return Event{State: s.state()}
+	}
+	s.running = false
+	ev := s.stopped(ReasonInterrupted, nil)
+	s.mu.Unlock()
+	s.notify(ev)
+	return ev
+}
+
+// Write writes data to memory from addr.
+func (s *Session) Write(addr uint16, data []byte) (State, error) {
+	return s.change(func() {
+		s.write(addr, data)
+	})
+}
+
+// Load writes a program to memory from addr and starts the CPU there.
+func (s *Session) Load(addr uint16, program []byte) (State, error) {
+	return s.change(func() {
+		s.write(addr, program)
+		r := s.cpu.Registers()
+		r.PC = addr
+		s.cpu.SetRegisters(r)
+	})
+}
+
+// SetRegisters changes the registers.
+func (s *Session) SetRegisters(r cpu.Registers) (State, error) {
+	return s.change(func() {
+		s.cpu.SetRegisters(r)
+	})
+}
+
+// AddBreakpoint stops the program before the instruction at addr. A second
+// breakpoint at the same address is ignored.
+func (s *Session) AddBreakpoint(addr uint16) (State, error) {
+	return s.change(func() {
+		if _, ok := s.breakpoints[addr]; !ok {
+			s.breakpoints[addr] = s.cpu.AddBreakpoint(addr)
+		}
+	})
+}
+
+// RemoveBreakpoint removes the breakpoint at addr, if any.
+func (s *Session) RemoveBreakpoint(addr uint16) (State, error) {
+	return s.change(func() {
+		if id, ok := s.breakpoints[addr]; ok {
+			s.cpu.RemoveBreakpoint(id)
+			delete(s.breakpoints, addr)
+		}
+	})
+}
+
+// change runs fn with the lock held unless the program runs and, once the
+// lock is released, tells the watchers of the new state.
+func (s *Session) change(fn func()) (State, error) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return State{}, ErrRunning
+	}
+	fn()
+	st := s.state()
+	s.mu.Unlock()
+	s.notify(Event{State: st})
+	return st, nil
+}
+
+// notify calls the watchers with ev. It is called without the lock held.
+func (s *Session) notify(ev Event) {
+	s.mu.Lock()
+	watchers := slices.Collect(maps.Values(s.watchers))
+	s.mu.Unlock()
+	for _, fn := range watchers {
+		fn(ev)
+	}
+}
+
+func (s *Session) state() State {
+	return State{
+		Registers:   s.cpu.Registers(),
+		Cycles:      s.cpu.Cycles(),
+		Running:     s.running,
+		Breakpoints: slices.Sorted(maps.Keys(s.breakpoints)),
+	}
+}
+
+// stopped returns the event of the CPU stopping for reason, or at the fault
+// err if not nil.
+func (s *Session) stopped(reason string, err error) Event {
+	ev := Event{Stopped: true, Reason: reason, State: s.state()}
+	if err != nil {
+		ev.Reason = cpu.StopFault.String()
+		ev.Err = err
+	}
+	return ev
+}
+
+// run runs the program in slices until it stops or Stop is called.
+func (s *Session) run() {
+	for {
+		s.mu.Lock()
+		if !s.running {
+			s.mu.Unlock()
+			return
+		}
+		reason, err := s.cpu.RunFor(runSlice)
+		if reason == cpu.StopCycles {
+			s.mu.Unlock()
+			continue
+		}
+		s.running = false
+		ev := s.stopped(reason.String(), err)
+		s.mu.Unlock()
+		s.notify(ev)
+		return
+	}
+}
+
+func (s *Session) write(addr uint16, data []byte) {
+	for i, b := range data {
+		s.mem.Write(b, addr+uint16(i))
+	}
+}
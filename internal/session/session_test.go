@@ -0,0 +1,105 @@
+package session
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newSessionTest controls a CPU about to run
+//
+//	0200  JSR $0210
+//	0203  .byte $02
+//	0210  LDA #$07
+//	0212  RTS
+func newSessionTest(t *testing.T) *Session {
+	t.Helper()
+	mem := &memory.Memory{}
+	for addr, b := range map[uint16][]byte{
+		0x0200: {0x20, 0x10, 0x02, 0x02},
+		0x0210: {0xA9, 0x07, 0x60},
+	} {
+		for i, v := range b {
+			mem.Write(v, addr+uint16(i))
+		}
+	}
+	c := cpu.New(mem)
+	c.Reset()
+	r := c.Registers()
+	r.PC = 0x0200
+	c.SetRegisters(r)
+	return New(c, mem)
+}
+
+func TestStepAndContinue(t *testing.T) {
+	s := newSessionTest(t)
+	events := make(chan Event, 16)
+	st, stop := s.Watch(func(ev Event) { events <- ev })
+	defer stop()
+	if st.Registers.PC != 0x0200 {
+		t.Errorf("expected the state at $0200, actual %+v\n", st)
+	}
+
+	ev, err := s.Step()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ev.Stopped || ev.Reason != "stepped" || ev.State.Registers.PC != 0x0210 {
+		t.Errorf("expected to stop at $0210, actual %+v\n", ev)
+	}
+	if actual := <-events; actual.State.Registers.PC != 0x0210 {
+		t.Errorf("expected the watcher to see $0210, actual %+v\n", actual)
+	}
+
+	if st, _ = s.AddBreakpoint(0x0212); len(st.Breakpoints) != 1 || st.Breakpoints[0] != 0x0212 {
+		t.Errorf("expected a breakpoint at $0212, actual %+v\n", st)
+	}
+	<-events
+	if _, err := s.Continue(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for ev = <-events; !ev.Stopped; ev = <-events {
+	}
+	if ev.Reason != "breakpoint" || ev.State.Registers.A != 0x07 || ev.State.Running {
+		t.Errorf("expected to stop at the breakpoint with A $07, actual %+v\n", ev)
+	}
+
+	s.RemoveBreakpoint(0x0212)
+	s.Step()
+	if ev, _ = s.Step(); ev.Reason != "fault" || ev.Err == nil {
+		t.Errorf("expected a fault, actual %+v\n", ev)
+	}
+}
+
+func TestRunning(t *testing.T) {
+	s := newSessionTest(t)
+	// 0300  JSR $0300
+	if st, _ := s.Load(0x0300, []byte{0x20, 0x00, 0x03}); st.Registers.PC != 0x0300 {
+		t.Errorf("expected PC $0300, actual %+v\n", st)
+	}
+	if ev := s.Stop(); ev.Stopped {
+		t.Errorf("expected no stop before running, actual %+v\n", ev)
+	}
+	if st, _ := s.Continue(); !st.Running {
+		t.Errorf("expected the program to run, actual %+v\n", st)
+	}
+
+	if _, err := s.Step(); !errors.Is(err, ErrRunning) {
+		t.Errorf("expected %v, actual %v\n", ErrRunning, err)
+	}
+	if _, err := s.Write(0x0400, []byte{1}); !errors.Is(err, ErrRunning) {
+		t.Errorf("expected %v, actual %v\n", ErrRunning, err)
+	}
+	if ev := s.Stop(); !ev.Stopped || ev.Reason != ReasonInterrupted || ev.State.Running {
+		t.Errorf("expected to be interrupted, actual %+v\n", ev)
+	}
+
+	if _, err := s.Write(0x0400, []byte{1, 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data := s.Read(0x0400, 3); string(data) != "\x01\x02\x00" {
+		t.Errorf("expected the bytes written, actual % X\n", data)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build linux
+
+package term
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Ioctl makes the ioctl system call req on f, with arg.
+func Ioctl(f *os.File, req uint, arg unsafe.Pointer) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(req), uintptr(arg))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,4 @@
+// Package term holds the system calls on terminals shared by the devices
+// wired to the terminal of the host, such as keyboard and acia6551. They are
+// only implemented on Linux.
+package term
@@ -0,0 +1,132 @@
+// Package jsontrace streams the execution of a 6502 as JSON lines, one object
+// per line, for analysis tools that should not have to parse text traces.
+//
+// Every instruction executed is written as an object such as
+//
+//	{"type":"instruction","pc":512,"opcode":169,"bytes":"A907","disasm":"LDA #$07","cycles":2,"total":9,"a":7,"x":0,"y":0,"sp":255,"sr":36}
+//
+// where cycles is the number of cycles the instruction took, total the cycle
+// count of the CPU after it, and the registers are those after it ran.
+// Optionally, every bus access is written as well, before the instruction
+// making it:
+//
+//	{"type":"read","addr":513,"data":7,"pc":512,"cycle":8}
+//
+// The type of an access is "read", "write" or "fetch", for opcode fetches.
+//
+//	w := jsontrace.NewWriter(f, c, bus.DebugView())
+//	c.AddInstructionHook(w.Instruction)
+//	c.AddWatchpoint(0x0000, 0xFFFF, cpu.AccessAny, w.Access)
+//	c.Run()
+//	w.Flush()
+package jsontrace
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/disasm"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// Instruction is the object written for an executed instruction.
+type Instruction struct {
+	Type   string `json:"type"`
+	PC     uint16 `json:"pc"`
+	Opcode byte   `json:"opcode"`
+	// Bytes is the encoding of the instruction in hexadecimal.
+	Bytes  string `json:"bytes"`
+	Disasm string `json:"disasm"`
+	Cycles uint   `json:"cycles"`
+	Total  uint   `json:"total"`
+	A      byte   `json:"a"`
+	X      byte   `json:"x"`
+	Y      byte   `json:"y"`
+	SP     byte   `json:"sp"`
+	SR     byte   `json:"sr"`
+}
+
+// Access is the object written for a bus access.
+type Access struct {
+	Type string `json:"type"`
+	Addr uint16 `json:"addr"`
+	// Data is the value read or written.
+	Data byte `json:"data"`
+	// PC is the address of the instruction making the access.
+	PC    uint16 `json:"pc"`
+	Cycle uint   `json:"cycle"`
+}
+
+// Writer writes the execution of a CPU as JSON lines.
+type Writer struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+	cpu *cpu.CPU
+	mem memory.Reader
+	err error
+}
+
+// NewWriter returns a Writer tracing c to w. mem is used to read the bytes of
+// the instructions: it should be free of side effects, such as the DebugView
+// of a bus.
+func NewWriter(w io.Writer, c *cpu.CPU, mem memory.Reader) *Writer {
+	bw := bufio.NewWriter(w)
+	return &Writer{w: bw, enc: json.NewEncoder(bw), cpu: c, mem: mem}
+}
+
+// Instruction writes an executed instruction. It is meant to be passed to the
+// AddInstructionHook method of the CPU.
+func (t *Writer) Instruction(e cpu.InstructionEvent) {
+	inst := disasm.Decode(t.mem, e.PC)
+	r := t.cpu.Registers()
+	t.encode(Instruction{
+		Type:   "instruction",
+		PC:     e.PC,
+		Opcode: e.Opcode,
+		Bytes:  strings.ToUpper(hex.EncodeToString(inst.Bytes())),
+		Disasm: inst.String(),
+		Cycles: e.Cycles,
+		Total:  t.cpu.Cycles(),
+		A:      r.A,
+		X:      r.X,
+		Y:      r.Y,
+		SP:     r.SP,
+		SR:     r.SR,
+	})
+}
+
+// Access writes a bus access. It is meant to be passed to the AddWatchpoint
+// method of the CPU, for the addresses and kinds of accesses to trace.
+func (t *Writer) Access(e cpu.WatchEvent) {
+	typ := "read"
+	switch e.Access {
+	case cpu.AccessWrite:
+		typ = "write"
+	case cpu.AccessExecute:
+		typ = "fetch"
+	}
+	t.encode(Access{Type: typ, Addr: e.Addr, Data: e.New, PC: e.PC, Cycle: t.cpu.Cycles()})
+}
+
+func (t *Writer) encode(v any) {
+	if t.err == nil {
+		t.err = t.enc.Encode(v)
+	}
+}
+
+// Flush writes what is buffered and returns the first error met writing the
+// trace.
+func (t *Writer) Flush() error {
+	if t.err == nil {
+		t.err = t.w.Flush()
+	}
+	if t.err != nil {
+		return fmt.Errorf("jsontrace: %w", t.err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package jsontrace
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newJSONTraceTest returns a CPU about to run LDA #$07 at $0200, and a Writer
+// tracing it to b.
+func newJSONTraceTest(b *strings.Builder) (*cpu.CPU, *Writer) {
+	mem := &memory.Memory{}
+	mem.Write(0xA9, 0x0200)
+	mem.Write(0x07, 0x0201)
+	c := cpu.New(mem)
+	c.Reset()
+	c.SetRegisters(cpu.Registers{PC: 0x0200, SP: 0xFF, SR: 0x24})
+	return c, NewWriter(b, c, mem)
+}
+
+func TestWriterInstruction(t *testing.T) {
+	var b strings.Builder
+	c, w := newJSONTraceTest(&b)
+	c.AddInstructionHook(w.Instruction)
+
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"type":"instruction","pc":512,"opcode":169,"bytes":"A907","disasm":"LDA #$07",` +
+		`"cycles":2,"total":9,"a":7,"x":0,"y":0,"sp":255,"sr":36}` + "\n"
+	if b.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, b.String())
+	}
+}
+
+func TestWriterAccesses(t *testing.T) {
+	var b strings.Builder
+	c, w := newJSONTraceTest(&b)
+	c.AddWatchpoint(0x0000, 0xFFFF, cpu.AccessAny, w.Access)
+	c.AddInstructionHook(w.Instruction)
+
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, actual %q\n", b.String())
+	}
+	expected := []Access{
+		{Type: "fetch", Addr: 0x0200, Data: 0xA9, PC: 0x0200, Cycle: 7},
+		{Type: "read", Addr: 0x0201, Data: 0x07, PC: 0x0200, Cycle: 8},
+	}
+	for i, e := range expected {
+		var actual Access
+		if err := json.Unmarshal([]byte(lines[i]), &actual); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if actual != e {
+			t.Errorf("expected %+v, actual %+v\n", e, actual)
+		}
+	}
+	if !strings.Contains(lines[2], `"type":"instruction"`) {
+		t.Errorf("expected an instruction, actual %q\n", lines[2])
+	}
+}
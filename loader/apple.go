@@ -0,0 +1,59 @@
+package loader
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+const dos33HeaderSize = 4
+
+// ReadBinary reads a raw binary, as saved by the Apple 1 and Apple II
+// monitors, that belongs at addr.
+func ReadBinary(r io.Reader, addr uint16) (Segment, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return Segment{}, fmt.Errorf("loader: reading binary: %w", err)
+	}
+	return Segment{Addr: addr, Data: buf}, nil
+}
+
+// LoadBinary reads a raw binary from r and writes it into mem at addr.
+func LoadBinary(mem memory.Writer, r io.Reader, addr uint16) (Segment, error) {
+	seg, err := ReadBinary(r, addr)
+	if err != nil {
+		return Segment{}, err
+	}
+	return seg, seg.Load(mem)
+}
+
+// ReadDOS33Binary parses an Apple DOS 3.3 "B" file, whose data is preceded by
+// its little-endian load address and length. Bytes past the announced length,
+// such as the padding to the end of the last sector, are ignored.
+func ReadDOS33Binary(r io.Reader) (Segment, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return Segment{}, fmt.Errorf("loader: reading dos 3.3 binary: %w", err)
+	}
+	if len(buf) < dos33HeaderSize {
+		return Segment{}, ErrTruncated
+	}
+	addr := littleEndian(buf[0], buf[1])
+	length := int(littleEndian(buf[2], buf[3]))
+	data := buf[dos33HeaderSize:]
+	if len(data) < length {
+		return Segment{}, ErrTruncated
+	}
+	return Segment{Addr: addr, Data: data[:length]}, nil
+}
+
+// LoadDOS33Binary reads an Apple DOS 3.3 "B" file from r and writes it into
+// mem at the address given by its header, like BLOAD does.
+func LoadDOS33Binary(mem memory.Writer, r io.Reader) (Segment, error) {
+	seg, err := ReadDOS33Binary(r)
+	if err != nil {
+		return Segment{}, err
+	}
+	return seg, seg.Load(mem)
+}
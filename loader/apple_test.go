@@ -0,0 +1,49 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestLoadBinary(t *testing.T) {
+	mem := memory.Memory{}
+	seg, err := LoadBinary(&mem, bytes.NewReader([]byte{0xD8, 0x58, 0xA0}), 0xFF00)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if seg.Addr != 0xFF00 || seg.End() != 0xFF02 {
+		t.Errorf("expected $FF00-$FF02, actual $%04X-$%04X\n", seg.Addr, seg.End())
+	}
+	if mem.Read(0xFF01) != 0x58 {
+		t.Errorf("expected 58, actual %02X\n", mem.Read(0xFF01))
+	}
+}
+
+func TestLoadDOS33BinaryIgnoresPadding(t *testing.T) {
+	file := []byte{0x00, 0x03, 0x02, 0x00, 0xA9, 0x01, 0xFF, 0xFF}
+	mem := memory.Memory{}
+
+	seg, err := LoadDOS33Binary(&mem, bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if seg.Addr != 0x0300 || len(seg.Data) != 2 {
+		t.Errorf("expected 2 bytes at $0300, actual %d bytes at $%04X\n", len(seg.Data), seg.Addr)
+	}
+	if mem.Read(0x0302) != 0x00 {
+		t.Errorf("padding written to memory")
+	}
+}
+
+func TestLoadDOS33BinaryTruncated(t *testing.T) {
+	mem := memory.Memory{}
+	_, err := LoadDOS33Binary(&mem, bytes.NewReader([]byte{0x00, 0x03, 0x10, 0x00, 0xEA}))
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected %v, actual %v\n", ErrTruncated, err)
+	}
+}
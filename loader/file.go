@@ -0,0 +1,75 @@
+package loader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// LoadFile loads the program in path into mem, in the format its extension
+// names, and returns where it starts: a PRG file for .prg, Intel HEX for
+// .hex or .ihx, a paper tape of the Woz Monitor for .woz, an iNES image for
+// .nes, and raw bytes placed at addr otherwise. A file with a start address
+// starts there, an iNES image at its reset vector, and other files at their
+// first byte.
+func LoadFile(mem memory.Writer, path string, addr uint16) (uint16, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("loader: opening program: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".prg":
+		seg, err := LoadPRG(mem, f)
+		return seg.Addr, err
+	case ".hex", ".ihx":
+		h, err := LoadIntelHex(mem, f)
+		if err != nil {
+			return 0, err
+		}
+		return startOf(h.Start, h.HasStart, h.Segments, addr), nil
+	case ".woz":
+		t, err := LoadWozTape(mem, f)
+		if err != nil {
+			return 0, err
+		}
+		return startOf(t.Start, t.HasStart, t.Segments, addr), nil
+	case ".nes":
+		rom, err := LoadINES(mem, f)
+		if err != nil {
+			return 0, err
+		}
+		return rom.ResetVector(), nil
+	default:
+		seg, err := LoadBinary(mem, f, addr)
+		return seg.Addr, err
+	}
+}
+
+// startOf returns start if hasStart, or else the address of the first of
+// segs, or else addr.
+func startOf(start uint16, hasStart bool, segs []Segment, addr uint16) uint16 {
+	switch {
+	case hasStart:
+		return start
+	case len(segs) != 0:
+		return segs[0].Addr
+	default:
+		return addr
+	}
+}
+
+// ParseAddr parses an address in hex, optionally prefixed with $, such as a
+// load address given on a command line.
+func ParseAddr(s string) (uint16, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "$"), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address %q", s)
+	}
+	return uint16(v), nil
+}
@@ -0,0 +1,53 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name     string
+		data     string
+		at       uint16
+		expected uint16
+	}{
+		{"raw.bin", "\xA9\x42", 0x0400, 0x0400},
+		{"prg.PRG", "\x00\x10\xA9\x42", 0x1000, 0x1000},
+		{"ihex.hex", ":02030000A94210\n:00000001FF\n", 0x0300, 0x0300},
+		{"woz.woz", "0280: A9 42\n0281R\n", 0x0280, 0x0281},
+	} {
+		path := filepath.Join(dir, tc.name)
+		if err := os.WriteFile(path, []byte(tc.data), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		mem := memory.Memory{}
+
+		start, err := LoadFile(&mem, path, 0x0400)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+
+		if start != tc.expected {
+			t.Errorf("%s: expected start $%04X, actual $%04X\n", tc.name, tc.expected, start)
+		}
+		if actual := mem.Read(tc.at); actual != 0xA9 {
+			t.Errorf("%s: expected $A9 at $%04X, actual $%02X\n", tc.name, tc.at, actual)
+		}
+	}
+}
+
+func TestParseAddr(t *testing.T) {
+	for s, expected := range map[string]uint16{"0200": 0x0200, "$FFFC": 0xFFFC, "a": 0x000A} {
+		if actual, err := ParseAddr(s); err != nil || actual != expected {
+			t.Errorf("%s: expected $%04X, actual $%04X, %v\n", s, expected, actual, err)
+		}
+	}
+	if _, err := ParseAddr("10000"); err == nil {
+		t.Errorf("expected an error")
+	}
+}
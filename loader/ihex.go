@@ -0,0 +1,125 @@
+package loader
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// ErrInvalidRecord is returned when a record of a text format, such as
+// Intel HEX, is malformed or its checksum does not match.
+var ErrInvalidRecord = errors.New("loader: invalid record")
+
+// Intel HEX record types.
+const (
+	ihexData          = 0x00
+	ihexEOF           = 0x01
+	ihexSegmentAddr   = 0x02
+	ihexSegmentStart  = 0x03
+	ihexExtendedAddr  = 0x04
+	ihexLinearStart   = 0x05
+	ihexMinRecordSize = 5
+)
+
+// IntelHex is the content of an Intel HEX file.
+type IntelHex struct {
+	// Segments are the data records, merged when contiguous, in the order
+	// of the file.
+	Segments []Segment
+	// Start is the start address of the start address record, if HasStart.
+	Start    uint16
+	HasStart bool
+}
+
+// ReadIntelHex parses an Intel HEX file, as written by most assemblers and
+// EPROM programmers. The address records must keep the data within the 64
+// KiB of the address space.
+func ReadIntelHex(r io.Reader) (*IntelHex, error) {
+	h := &IntelHex{}
+	var base uint32
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		rec, err := parseIHexRecord(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidRecord, n, err)
+		}
+		addr := uint32(rec[1])<<8 | uint32(rec[2])
+		data := rec[4 : len(rec)-1]
+		switch rec[3] {
+		case ihexData:
+			if base+addr+uint32(len(data)) > addressSpaceSize {
+				return nil, fmt.Errorf("%w: line %d", ErrOverflow, n)
+			}
+			h.Segments = appendSegment(h.Segments, uint16(base+addr), data)
+		case ihexEOF:
+			return h, nil
+		case ihexSegmentAddr, ihexExtendedAddr:
+			if len(data) != 2 {
+				return nil, fmt.Errorf("%w: line %d: address of %d bytes", ErrInvalidRecord, n, len(data))
+			}
+			base = uint32(data[0])<<8 | uint32(data[1])
+			if rec[3] == ihexSegmentAddr {
+				base <<= 4
+			} else {
+				base <<= 16
+			}
+		case ihexSegmentStart, ihexLinearStart:
+			if len(data) != 4 {
+				return nil, fmt.Errorf("%w: line %d: start address of %d bytes", ErrInvalidRecord, n, len(data))
+			}
+			h.Start, h.HasStart = uint16(data[2])<<8|uint16(data[3]), true
+		default:
+			return nil, fmt.Errorf("%w: line %d: record type $%02X", ErrInvalidRecord, n, rec[3])
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("loader: reading intel hex: %w", err)
+	}
+	return nil, ErrTruncated
+}
+
+// LoadIntelHex reads an Intel HEX file from r and writes its data into mem.
+func LoadIntelHex(mem memory.Writer, r io.Reader) (*IntelHex, error) {
+	h, err := ReadIntelHex(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, seg := range h.Segments {
+		if err := seg.Load(mem); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// parseIHexRecord decodes the record in line, checking its length and its
+// checksum.
+func parseIHexRecord(line string) ([]byte, error) {
+	if line[0] != ':' {
+		return nil, errors.New("missing start code")
+	}
+	rec, err := hex.DecodeString(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(rec) < ihexMinRecordSize || len(rec) != ihexMinRecordSize+int(rec[0]) {
+		return nil, fmt.Errorf("record of %d bytes", len(rec))
+	}
+	var sum byte
+	for _, b := range rec {
+		sum += b
+	}
+	if sum != 0 {
+		return nil, errors.New("checksum mismatch")
+	}
+	return rec, nil
+}
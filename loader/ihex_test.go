@@ -0,0 +1,57 @@
+package loader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestLoadIntelHex(t *testing.T) {
+	file := `:03020000A94260B0
+:0202030060EAAF
+:02FFFC00000201
+:0400000500000200F5
+:00000001FF
+`
+	mem := memory.Memory{}
+
+	h, err := LoadIntelHex(&mem, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(h.Segments) != 2 || h.Segments[0].Addr != 0x0200 || h.Segments[0].End() != 0x0204 {
+		t.Fatalf("expected the records at $0200 merged, actual %+v", h.Segments)
+	}
+	for i, expected := range []byte{0xA9, 0x42, 0x60, 0x60, 0xEA} {
+		if actual := mem.Read(0x0200 + uint16(i)); actual != expected {
+			t.Errorf("expected %02X, actual %02X\n", expected, actual)
+		}
+	}
+	if actual := memory.ReadWord(&mem, 0xFFFC); actual != 0x0200 {
+		t.Errorf("expected the reset vector $0200, actual $%04X\n", actual)
+	}
+	if !h.HasStart || h.Start != 0x0200 {
+		t.Errorf("expected the start address $0200, actual %+v\n", h)
+	}
+}
+
+func TestReadIntelHexErrors(t *testing.T) {
+	tests := []struct {
+		file     string
+		expected error
+	}{
+		{":03020000A9426001\n:00000001FF\n", ErrInvalidRecord},
+		{"03020000A9426000\n", ErrInvalidRecord},
+		{":0402000600000000F4\n", ErrInvalidRecord},
+		{":03020000A94260B0\n", ErrTruncated},
+		{":020000040001F9\n:0100000000FF\n:00000001FF\n", ErrOverflow},
+	}
+	for _, tt := range tests {
+		if _, err := ReadIntelHex(strings.NewReader(tt.file)); !errors.Is(err, tt.expected) {
+			t.Errorf("%q: expected %v, actual %v\n", tt.file, tt.expected, err)
+		}
+	}
+}
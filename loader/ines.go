@@ -0,0 +1,161 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+const (
+	inesHeaderSize  = 16
+	inesTrainerSize = 512
+	inesPRGUnit     = 16 * 1024
+	inesCHRUnit     = 8 * 1024
+)
+
+const (
+	inesMirroringFlag byte = 0x01
+	inesBatteryFlag   byte = 0x02
+	inesTrainerFlag   byte = 0x04
+	inesFourScreen    byte = 0x08
+	inesNES2Mask      byte = 0x0C
+	inesNES2ID        byte = 0x08
+)
+
+// PRG-ROM is mapped at the top of the address space.
+const inesPRGStart uint16 = 0x8000
+
+var inesMagic = []byte{'N', 'E', 'S', 0x1A}
+
+var (
+	// ErrInvalidHeader is returned when a file does not start with the
+	// header of the expected format.
+	ErrInvalidHeader = errors.New("loader: invalid header")
+	// ErrUnsupportedMapper is returned when an iNES image needs bank
+	// switching hardware that can not be expressed as a flat PRG-ROM mapping.
+	ErrUnsupportedMapper = errors.New("loader: unsupported mapper")
+)
+
+// Mirroring is the nametable arrangement wired on an NES cartridge.
+type Mirroring byte
+
+const (
+	MirroringHorizontal Mirroring = iota
+	MirroringVertical
+	MirroringFourScreen
+)
+
+// INESHeader is the metadata stored in the 16-byte header of an .nes file.
+type INESHeader struct {
+	// PRGROMSize and CHRROMSize are in bytes.
+	PRGROMSize int
+	CHRROMSize int
+	Mapper     byte
+	Mirroring  Mirroring
+	Battery    bool
+	Trainer    bool
+	// NES2 reports whether the header uses the NES 2.0 extensions. Only the
+	// fields shared with iNES are decoded.
+	NES2 bool
+}
+
+// INES is a cartridge image read from an .nes file.
+type INES struct {
+	Header  INESHeader
+	Trainer []byte
+	PRGROM  []byte
+	CHRROM  []byte
+}
+
+// ReadINES parses an iNES (.nes) file.
+func ReadINES(r io.Reader) (*INES, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("loader: reading ines: %w", err)
+	}
+	if len(buf) < inesHeaderSize {
+		return nil, ErrTruncated
+	}
+	if !bytes.Equal(buf[:len(inesMagic)], inesMagic) {
+		return nil, ErrInvalidHeader
+	}
+
+	flags6, flags7 := buf[6], buf[7]
+	h := INESHeader{
+		PRGROMSize: int(buf[4]) * inesPRGUnit,
+		CHRROMSize: int(buf[5]) * inesCHRUnit,
+		Mapper:     flags7&0xF0 | flags6>>4,
+		Battery:    flags6&inesBatteryFlag != 0,
+		Trainer:    flags6&inesTrainerFlag != 0,
+		NES2:       flags7&inesNES2Mask == inesNES2ID,
+	}
+	switch {
+	case flags6&inesFourScreen != 0:
+		h.Mirroring = MirroringFourScreen
+	case flags6&inesMirroringFlag != 0:
+		h.Mirroring = MirroringVertical
+	default:
+		h.Mirroring = MirroringHorizontal
+	}
+
+	rest := buf[inesHeaderSize:]
+	img := &INES{Header: h}
+	if h.Trainer {
+		if len(rest) < inesTrainerSize {
+			return nil, ErrTruncated
+		}
+		img.Trainer, rest = rest[:inesTrainerSize], rest[inesTrainerSize:]
+	}
+	if len(rest) < h.PRGROMSize+h.CHRROMSize {
+		return nil, ErrTruncated
+	}
+	img.PRGROM = rest[:h.PRGROMSize]
+	img.CHRROM = rest[h.PRGROMSize : h.PRGROMSize+h.CHRROMSize]
+	return img, nil
+}
+
+// LoadINES reads an .nes file from r and maps its PRG-ROM into mem.
+func LoadINES(mem memory.Writer, r io.Reader) (*INES, error) {
+	img, err := ReadINES(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := img.Load(mem); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// Load maps the PRG-ROM into $8000-$FFFF the way an NROM board does: a
+// 32 KiB image fills the whole window and a 16 KiB image is mirrored into
+// both halves.
+func (n *INES) Load(mem memory.Writer) error {
+	if n.Header.Mapper != 0 {
+		return fmt.Errorf("%w: %d", ErrUnsupportedMapper, n.Header.Mapper)
+	}
+	switch len(n.PRGROM) {
+	case inesPRGUnit:
+		if err := (Segment{Addr: inesPRGStart, Data: n.PRGROM}).Load(mem); err != nil {
+			return err
+		}
+		return Segment{Addr: inesPRGStart + inesPRGUnit, Data: n.PRGROM}.Load(mem)
+	case 2 * inesPRGUnit:
+		return Segment{Addr: inesPRGStart, Data: n.PRGROM}.Load(mem)
+	default:
+		return fmt.Errorf("%w: NROM with %d bytes of PRG-ROM", ErrUnsupportedMapper, len(n.PRGROM))
+	}
+}
+
+// ResetVector returns the address stored at $FFFC-$FFFD once the PRG-ROM is
+// mapped.
+func (n *INES) ResetVector() uint16 {
+	end := len(n.PRGROM)
+	if end < inesPRGUnit {
+		return 0
+	}
+	// $FFFC is 4 bytes from the end of the window for both NROM sizes.
+	return littleEndian(n.PRGROM[end-4], n.PRGROM[end-3])
+}
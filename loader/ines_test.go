@@ -0,0 +1,94 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func newINESFile(prgBanks byte, flags6 byte) []byte {
+	file := make([]byte, inesHeaderSize, inesHeaderSize+int(prgBanks)*inesPRGUnit)
+	copy(file, inesMagic)
+	file[4] = prgBanks
+	file[6] = flags6
+	prg := make([]byte, int(prgBanks)*inesPRGUnit)
+	for i := range prg {
+		prg[i] = byte(i / inesPRGUnit)
+	}
+	// Reset vector pointing to $C000.
+	prg[len(prg)-4] = 0x00
+	prg[len(prg)-3] = 0xC0
+	return append(file, prg...)
+}
+
+func TestLoadINESMirrors16KiBPRG(t *testing.T) {
+	mem := memory.Memory{}
+	img, err := LoadINES(&mem, bytes.NewReader(newINESFile(1, inesMirroringFlag)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if img.Header.PRGROMSize != inesPRGUnit || img.Header.Mirroring != MirroringVertical {
+		t.Errorf("unexpected header %+v\n", img.Header)
+	}
+	if mem.Read(0x8000) != mem.Read(0xC000) || mem.Read(0xBFFC) != mem.Read(0xFFFC) {
+		t.Errorf("16 KiB PRG-ROM not mirrored")
+	}
+	if v := img.ResetVector(); v != 0xC000 {
+		t.Errorf("expected $C000, actual $%04X\n", v)
+	}
+}
+
+func TestLoadINESMaps32KiBPRG(t *testing.T) {
+	mem := memory.Memory{}
+	if _, err := LoadINES(&mem, bytes.NewReader(newINESFile(2, 0))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mem.Read(0x8000) != 0 || mem.Read(0xC000) != 1 {
+		t.Errorf("expected banks 0 and 1, actual %d and %d\n", mem.Read(0x8000), mem.Read(0xC000))
+	}
+}
+
+func TestReadINESHeader(t *testing.T) {
+	file := newINESFile(1, 0x12|inesBatteryFlag)
+	file[5] = 1
+	file[7] = 0x40 | inesNES2ID
+	file = append(file, make([]byte, inesCHRUnit)...)
+
+	img, err := ReadINES(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := INESHeader{
+		PRGROMSize: inesPRGUnit,
+		CHRROMSize: inesCHRUnit,
+		Mapper:     0x41,
+		Mirroring:  MirroringHorizontal,
+		Battery:    true,
+		NES2:       true,
+	}
+	if img.Header != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, img.Header)
+	}
+}
+
+func TestLoadINESRejectsMappers(t *testing.T) {
+	mem := memory.Memory{}
+	_, err := LoadINES(&mem, bytes.NewReader(newINESFile(1, 0x10)))
+	if !errors.Is(err, ErrUnsupportedMapper) {
+		t.Errorf("expected %v, actual %v\n", ErrUnsupportedMapper, err)
+	}
+}
+
+func TestReadINESInvalidMagic(t *testing.T) {
+	file := newINESFile(1, 0)
+	file[0] = 'X'
+	_, err := ReadINES(bytes.NewReader(file))
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("expected %v, actual %v\n", ErrInvalidHeader, err)
+	}
+}
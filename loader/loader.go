@@ -0,0 +1,62 @@
+// Package loader places program images stored in common 6502 file formats
+// into memory.
+package loader
+
+import (
+	"errors"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+const addressSpaceSize = 0x10000
+
+var (
+	// ErrTruncated is returned when a file ends before its header or the
+	// data announced by the header.
+	ErrTruncated = errors.New("loader: truncated file")
+	// ErrOverflow is returned when an image does not fit between its load
+	// address and the end of the address space.
+	ErrOverflow = errors.New("loader: image overflows address space")
+)
+
+// Segment is a contiguous block of bytes that belongs at Addr.
+type Segment struct {
+	Addr uint16
+	Data []byte
+}
+
+// End returns the address of the last byte of the segment.
+func (s Segment) End() uint16 {
+	if len(s.Data) == 0 {
+		return s.Addr
+	}
+	return s.Addr + uint16(len(s.Data)-1)
+}
+
+// Load writes the segment into mem.
+func (s Segment) Load(mem memory.Writer) error {
+	if int(s.Addr)+len(s.Data) > addressSpaceSize {
+		return ErrOverflow
+	}
+	for i, b := range s.Data {
+		mem.Write(b, s.Addr+uint16(i))
+	}
+	return nil
+}
+
+// appendSegment appends data at addr to segs, extending the last segment if
+// data follows it.
+func appendSegment(segs []Segment, addr uint16, data []byte) []Segment {
+	if n := len(segs); n != 0 {
+		last := &segs[n-1]
+		if int(last.Addr)+len(last.Data) == int(addr) {
+			last.Data = append(last.Data, data...)
+			return segs
+		}
+	}
+	return append(segs, Segment{Addr: addr, Data: append([]byte(nil), data...)})
+}
+
+func littleEndian(lo, hi byte) uint16 {
+	return uint16(hi)<<8 | uint16(lo)
+}
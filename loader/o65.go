@@ -0,0 +1,378 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// Mode bits of an o65 header.
+const (
+	O65Mode65816    uint16 = 0x8000
+	O65ModePagewise uint16 = 0x4000
+	O65ModeSize32   uint16 = 0x2000
+	O65ModeObject   uint16 = 0x1000
+	O65ModeSimple   uint16 = 0x0800
+	O65ModeChain    uint16 = 0x0400
+	O65ModeBSSZero  uint16 = 0x0200
+)
+
+// Segment IDs used by o65 relocation entries and exported globals.
+const (
+	O65SegUndefined byte = iota
+	O65SegAbsolute
+	O65SegText
+	O65SegData
+	O65SegBSS
+	O65SegZero
+)
+
+const (
+	o65RelocWord   byte = 0x80
+	o65RelocHigh   byte = 0x40
+	o65RelocLow    byte = 0x20
+	o65RelocTypes  byte = 0xE0
+	o65RelocSegs   byte = 0x1F
+	o65RelocSkip   byte = 0xFF
+	o65RelocStride      = 254
+)
+
+var o65Magic = []byte{0x01, 0x00, 'o', '6', '5', 0x00}
+
+var (
+	// ErrUnsupportedFormat is returned for valid files using features of
+	// their format that this package does not implement.
+	ErrUnsupportedFormat = errors.New("loader: unsupported format variant")
+	// ErrUndefinedSymbol is returned when an o65 file references a symbol
+	// that was not provided when relocating it.
+	ErrUndefinedSymbol = errors.New("loader: undefined symbol")
+)
+
+// O65Header is the fixed part and the options of an o65 file header.
+type O65Header struct {
+	Mode    uint16
+	TBase   uint16
+	TLen    uint16
+	DBase   uint16
+	DLen    uint16
+	BBase   uint16
+	BLen    uint16
+	ZBase   uint16
+	ZLen    uint16
+	Stack   uint16
+	Options []O65Option
+}
+
+// O65Option is a typed header option, such as the file name or the name of
+// the assembler that produced the file.
+type O65Option struct {
+	Type byte
+	Data []byte
+}
+
+// O65Global is a symbol exported by an o65 file.
+type O65Global struct {
+	Name    string
+	Segment byte
+	Value   uint16
+}
+
+// O65Layout is the set of addresses the segments of an o65 file are
+// relocated to.
+type O65Layout struct {
+	Text uint16
+	Data uint16
+	BSS  uint16
+	Zero uint16
+}
+
+// O65 is a relocatable program read from an o65 file. Only 16-bit 6502 files
+// are supported; for chained files only the first file is read.
+type O65 struct {
+	Header    O65Header
+	Text      []byte
+	Data      []byte
+	Undefined []string
+	Globals   []O65Global
+
+	textRelocs []o65Reloc
+	dataRelocs []o65Reloc
+}
+
+type o65Reloc struct {
+	offset int
+	typ    byte
+	seg    byte
+	undef  int
+	// low is the low byte of the full address patched by a HIGH entry,
+	// needed to carry into the high byte.
+	low byte
+}
+
+type o65Reader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *o65Reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 {
+		r.err = ErrInvalidHeader
+		return nil
+	}
+	if n > len(r.buf)-r.pos {
+		r.err = ErrTruncated
+		return nil
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *o65Reader) byte() byte {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *o65Reader) word() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return littleEndian(b[0], b[1])
+}
+
+func (r *o65Reader) cstring() string {
+	if r.err != nil {
+		return ""
+	}
+	end := bytes.IndexByte(r.buf[r.pos:], 0)
+	if end < 0 {
+		r.err = ErrTruncated
+		return ""
+	}
+	s := string(r.buf[r.pos : r.pos+end])
+	r.pos += end + 1
+	return s
+}
+
+// ReadO65 parses an o65 relocatable binary.
+func ReadO65(r io.Reader) (*O65, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("loader: reading o65: %w", err)
+	}
+	if len(buf) < len(o65Magic) {
+		return nil, ErrTruncated
+	}
+	if !bytes.Equal(buf[:len(o65Magic)], o65Magic) {
+		return nil, ErrInvalidHeader
+	}
+
+	rd := &o65Reader{buf: buf, pos: len(o65Magic)}
+	o := &O65{}
+	h := &o.Header
+	h.Mode = rd.word()
+	if h.Mode&(O65ModeSize32|O65Mode65816) != 0 {
+		return nil, fmt.Errorf("%w: o65 mode $%04X", ErrUnsupportedFormat, h.Mode)
+	}
+	for _, field := range []*uint16{
+		&h.TBase, &h.TLen, &h.DBase, &h.DLen, &h.BBase, &h.BLen, &h.ZBase, &h.ZLen, &h.Stack,
+	} {
+		*field = rd.word()
+	}
+	for {
+		n := rd.byte()
+		if n == 0 {
+			break
+		}
+		if n < 2 {
+			return nil, fmt.Errorf("%w: o65 option of %d bytes", ErrInvalidHeader, n)
+		}
+		typ := rd.byte()
+		o.Header.Options = append(o.Header.Options, O65Option{Type: typ, Data: rd.bytes(int(n) - 2)})
+		if rd.err != nil {
+			return nil, rd.err
+		}
+	}
+
+	o.Text = bytes.Clone(rd.bytes(int(h.TLen)))
+	o.Data = bytes.Clone(rd.bytes(int(h.DLen)))
+	for range rd.word() {
+		o.Undefined = append(o.Undefined, rd.cstring())
+	}
+	if o.textRelocs, err = o.readRelocs(rd); err != nil {
+		return nil, err
+	}
+	if o.dataRelocs, err = o.readRelocs(rd); err != nil {
+		return nil, err
+	}
+	for range rd.word() {
+		o.Globals = append(o.Globals, O65Global{Name: rd.cstring(), Segment: rd.byte(), Value: rd.word()})
+	}
+	if rd.err != nil {
+		return nil, rd.err
+	}
+	return o, nil
+}
+
+func (o *O65) readRelocs(rd *o65Reader) ([]o65Reloc, error) {
+	var relocs []o65Reloc
+	offset := -1
+	for {
+		b := rd.byte()
+		if rd.err != nil {
+			return nil, rd.err
+		}
+		if b == 0 {
+			return relocs, nil
+		}
+		if b == o65RelocSkip {
+			offset += o65RelocStride
+			continue
+		}
+		offset += int(b)
+
+		ts := rd.byte()
+		rel := o65Reloc{offset: offset, typ: ts & o65RelocTypes, seg: ts & o65RelocSegs}
+		if rel.seg == O65SegUndefined {
+			rel.undef = int(rd.word())
+			if rd.err == nil && rel.undef >= len(o.Undefined) {
+				return nil, fmt.Errorf("%w: undefined reference %d", ErrInvalidHeader, rel.undef)
+			}
+		}
+		switch rel.typ {
+		case o65RelocWord, o65RelocLow:
+		case o65RelocHigh:
+			if o.Header.Mode&O65ModePagewise == 0 {
+				rel.low = rd.byte()
+			}
+		default:
+			return nil, fmt.Errorf("%w: o65 relocation type $%02X", ErrUnsupportedFormat, rel.typ)
+		}
+		relocs = append(relocs, rel)
+	}
+}
+
+// Relocate moves the segments of the file to the addresses in l, patching
+// every relocated reference in the text and data segments as well as the
+// values of the exported globals. References to undefined symbols are
+// resolved through syms.
+func (o *O65) Relocate(l O65Layout, syms map[string]uint16) error {
+	h := &o.Header
+	deltas := map[byte]uint16{
+		O65SegAbsolute: 0,
+		O65SegText:     l.Text - h.TBase,
+		O65SegData:     l.Data - h.DBase,
+		O65SegBSS:      l.BSS - h.BBase,
+		O65SegZero:     l.Zero - h.ZBase,
+	}
+	delta := func(rel *o65Reloc) (uint16, error) {
+		if rel.seg != O65SegUndefined {
+			d, ok := deltas[rel.seg]
+			if !ok {
+				return 0, fmt.Errorf("%w: o65 segment %d", ErrUnsupportedFormat, rel.seg)
+			}
+			return d, nil
+		}
+		name := o.Undefined[rel.undef]
+		v, ok := syms[name]
+		if !ok {
+			return 0, fmt.Errorf("%w: %s", ErrUndefinedSymbol, name)
+		}
+		return v, nil
+	}
+
+	for _, seg := range []struct {
+		data   []byte
+		relocs []o65Reloc
+	}{{o.Text, o.textRelocs}, {o.Data, o.dataRelocs}} {
+		for i := range seg.relocs {
+			rel := &seg.relocs[i]
+			d, err := delta(rel)
+			if err != nil {
+				return err
+			}
+			if err := rel.apply(seg.data, d); err != nil {
+				return err
+			}
+		}
+	}
+	// Undefined references are patched once; afterwards they behave like
+	// absolute ones.
+	for _, relocs := range [][]o65Reloc{o.textRelocs, o.dataRelocs} {
+		for i := range relocs {
+			if relocs[i].seg == O65SegUndefined {
+				relocs[i].seg = O65SegAbsolute
+			}
+		}
+	}
+
+	for i := range o.Globals {
+		o.Globals[i].Value += deltas[o.Globals[i].Segment]
+	}
+	h.TBase, h.DBase, h.BBase, h.ZBase = l.Text, l.Data, l.BSS, l.Zero
+	return nil
+}
+
+func (rel *o65Reloc) apply(data []byte, delta uint16) error {
+	size := 1
+	if rel.typ == o65RelocWord {
+		size = 2
+	}
+	if rel.offset < 0 || rel.offset+size > len(data) {
+		return fmt.Errorf("%w: relocation at offset %d", ErrTruncated, rel.offset)
+	}
+
+	switch rel.typ {
+	case o65RelocWord:
+		v := littleEndian(data[rel.offset], data[rel.offset+1]) + delta
+		data[rel.offset], data[rel.offset+1] = byte(v), byte(v>>8)
+	case o65RelocHigh:
+		v := littleEndian(rel.low, data[rel.offset]) + delta
+		data[rel.offset], rel.low = byte(v>>8), byte(v)
+	case o65RelocLow:
+		data[rel.offset] += byte(delta)
+	}
+	return nil
+}
+
+// Load writes the text and data segments into mem at their current base
+// addresses, clearing the BSS segment if the file asks for it.
+func (o *O65) Load(mem memory.Writer) error {
+	h := o.Header
+	if err := (Segment{Addr: h.TBase, Data: o.Text}).Load(mem); err != nil {
+		return err
+	}
+	if err := (Segment{Addr: h.DBase, Data: o.Data}).Load(mem); err != nil {
+		return err
+	}
+	if h.Mode&O65ModeBSSZero != 0 {
+		return Segment{Addr: h.BBase, Data: make([]byte, h.BLen)}.Load(mem)
+	}
+	return nil
+}
+
+// LoadO65 reads an o65 file from r, relocates it to l and writes it into mem.
+func LoadO65(mem memory.Writer, r io.Reader, l O65Layout, syms map[string]uint16) (*O65, error) {
+	o, err := ReadO65(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.Relocate(l, syms); err != nil {
+		return nil, err
+	}
+	if err := o.Load(mem); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
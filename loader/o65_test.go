@@ -0,0 +1,157 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newO65File assembles by hand:
+//
+//	$1000  LDA $1005
+//	$1003  JMP $1000
+//	$1006  LDA #>$10F0
+//	$1008  LDA #<$1002
+//	$100A  LDA $10     ; zero page segment based at $10
+//	$100C  JSR print   ; undefined symbol
+func newO65File(mode uint16) []byte {
+	file := append([]byte{}, o65Magic...)
+	file = append(file, byte(mode), byte(mode>>8))
+	file = append(file,
+		0x00, 0x10, 0x0F, 0x00, // text
+		0x00, 0x20, 0x00, 0x00, // data
+		0x00, 0x30, 0x04, 0x00, // bss
+		0x10, 0x00, 0x01, 0x00, // zero page
+		0x00, 0x00, // stack
+		0x06, 0x00, 'a', 'b', 'c', 0x00, // file name option
+		0x00,
+	)
+	file = append(file,
+		0xAD, 0x05, 0x10,
+		0x4C, 0x00, 0x10,
+		0xA9, 0x10,
+		0xA9, 0x02,
+		0xA5, 0x10,
+		0x20, 0x00, 0x00,
+	)
+	file = append(file, 0x01, 0x00, 'p', 'r', 'i', 'n', 't', 0x00)
+	file = append(file,
+		0x02, 0x82,
+		0x03, 0x82,
+		0x03, 0x42, 0xF0,
+		0x02, 0x22,
+		0x02, 0x25,
+		0x02, 0x80, 0x00, 0x00,
+		0x00,
+	)
+	file = append(file, 0x00)
+	file = append(file, 0x01, 0x00, 'm', 'a', 'i', 'n', 0x00, O65SegText, 0x00, 0x10)
+	return file
+}
+
+func TestReadO65(t *testing.T) {
+	o, err := ReadO65(bytes.NewReader(newO65File(0)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if o.Header.TBase != 0x1000 || o.Header.ZLen != 1 || len(o.Text) != 15 {
+		t.Errorf("unexpected header %+v\n", o.Header)
+	}
+	if len(o.Header.Options) != 1 || string(o.Header.Options[0].Data) != "abc\x00" {
+		t.Errorf("unexpected options %+v\n", o.Header.Options)
+	}
+	if len(o.Undefined) != 1 || o.Undefined[0] != "print" {
+		t.Errorf("unexpected undefined references %v\n", o.Undefined)
+	}
+}
+
+func TestLoadO65Relocates(t *testing.T) {
+	mem := memory.Memory{}
+	layout := O65Layout{Text: 0x2010, Data: 0x2100, BSS: 0x2200, Zero: 0x80}
+	syms := map[string]uint16{"print": 0xFFD2}
+
+	o, err := LoadO65(&mem, bytes.NewReader(newO65File(0)), layout, syms)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []byte{
+		0xAD, 0x15, 0x20,
+		0x4C, 0x10, 0x20,
+		0xA9, 0x21,
+		0xA9, 0x12,
+		0xA5, 0x80,
+		0x20, 0xD2, 0xFF,
+	}
+	for i, b := range expected {
+		if actual := mem.Read(0x2010 + uint16(i)); actual != b {
+			t.Errorf("at $%04X: expected %02X, actual %02X\n", 0x2010+i, b, actual)
+		}
+	}
+	if o.Globals[0].Value != 0x2010 {
+		t.Errorf("expected main at $2010, actual $%04X\n", o.Globals[0].Value)
+	}
+}
+
+func TestO65RelocateTwice(t *testing.T) {
+	o, err := ReadO65(bytes.NewReader(newO65File(0)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	syms := map[string]uint16{"print": 0xFFD2}
+
+	if err := o.Relocate(O65Layout{Text: 0x2010, Zero: 0x80}, syms); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := o.Relocate(O65Layout{Text: 0x1000, Zero: 0x10}, syms); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if o.Text[7] != 0x10 || o.Text[11] != 0x10 || o.Text[13] != 0xD2 {
+		t.Errorf("relocation not reversible: % X\n", o.Text)
+	}
+}
+
+func TestLoadO65ClearsBSS(t *testing.T) {
+	mem := memory.Memory{}
+	mem.Write(0xFF, 0x3003)
+	layout := O65Layout{Text: 0x1000, Data: 0x2000, BSS: 0x3000, Zero: 0x10}
+	syms := map[string]uint16{"print": 0xFFD2}
+
+	if _, err := LoadO65(&mem, bytes.NewReader(newO65File(O65ModeBSSZero)), layout, syms); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mem.Read(0x3003) != 0x00 {
+		t.Errorf("bss not cleared")
+	}
+}
+
+func TestLoadO65UndefinedSymbol(t *testing.T) {
+	mem := memory.Memory{}
+	_, err := LoadO65(&mem, bytes.NewReader(newO65File(0)), O65Layout{}, nil)
+	if !errors.Is(err, ErrUndefinedSymbol) {
+		t.Errorf("expected %v, actual %v\n", ErrUndefinedSymbol, err)
+	}
+}
+
+func TestReadO65Rejects32Bit(t *testing.T) {
+	_, err := ReadO65(bytes.NewReader(newO65File(O65ModeSize32)))
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected %v, actual %v\n", ErrUnsupportedFormat, err)
+	}
+}
+
+func TestReadO65RejectsShortOption(t *testing.T) {
+	file := newO65File(0)
+	// The length of the file name option, which counts itself and its type.
+	file[len(o65Magic)+2+18] = 0x01
+
+	_, err := ReadO65(bytes.NewReader(file))
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("expected %v, actual %v\n", ErrInvalidHeader, err)
+	}
+}
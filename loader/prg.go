@@ -0,0 +1,48 @@
+package loader
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+const prgHeaderSize = 2
+
+// ReadPRG parses a Commodore .prg file. The returned segment is addressed at
+// the load address stored in the 2-byte little-endian file header.
+func ReadPRG(r io.Reader) (Segment, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return Segment{}, fmt.Errorf("loader: reading prg: %w", err)
+	}
+	if len(buf) < prgHeaderSize {
+		return Segment{}, ErrTruncated
+	}
+	return Segment{
+		Addr: littleEndian(buf[0], buf[1]),
+		Data: buf[prgHeaderSize:],
+	}, nil
+}
+
+// LoadPRG reads a .prg file from r and writes it into mem at the address
+// given by its header, like LOAD "NAME",8,1 does on a real machine.
+func LoadPRG(mem memory.Writer, r io.Reader) (Segment, error) {
+	seg, err := ReadPRG(r)
+	if err != nil {
+		return Segment{}, err
+	}
+	return seg, seg.Load(mem)
+}
+
+// LoadPRGAt reads a .prg file from r and writes it into mem at addr, ignoring
+// the address in its header, like LOAD "NAME",8 does for BASIC programs.
+// Absolute addresses inside the program are not patched.
+func LoadPRGAt(mem memory.Writer, r io.Reader, addr uint16) (Segment, error) {
+	seg, err := ReadPRG(r)
+	if err != nil {
+		return Segment{}, err
+	}
+	seg.Addr = addr
+	return seg, seg.Load(mem)
+}
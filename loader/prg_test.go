@@ -0,0 +1,64 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestLoadPRGUsesHeaderAddress(t *testing.T) {
+	file := []byte{0x01, 0x08, 0xA9, 0x42, 0x60}
+	mem := memory.Memory{}
+
+	seg, err := LoadPRG(&mem, bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if seg.Addr != 0x0801 || seg.End() != 0x0803 {
+		t.Errorf("expected $0801-$0803, actual $%04X-$%04X\n", seg.Addr, seg.End())
+	}
+	for i, expected := range file[prgHeaderSize:] {
+		if actual := mem.Read(0x0801 + uint16(i)); actual != expected {
+			t.Errorf("expected %02X, actual %02X\n", expected, actual)
+		}
+	}
+}
+
+func TestLoadPRGAtRelocates(t *testing.T) {
+	file := []byte{0x01, 0x08, 0xA9, 0x42}
+	mem := memory.Memory{}
+
+	seg, err := LoadPRGAt(&mem, bytes.NewReader(file), 0xC000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if seg.Addr != 0xC000 {
+		t.Errorf("expected $C000, actual $%04X\n", seg.Addr)
+	}
+	if mem.Read(0xC000) != 0xA9 || mem.Read(0xC001) != 0x42 {
+		t.Errorf("program not found at $C000")
+	}
+	if mem.Read(0x0801) != 0x00 {
+		t.Errorf("program also written at header address")
+	}
+}
+
+func TestLoadPRGTruncated(t *testing.T) {
+	mem := memory.Memory{}
+	_, err := LoadPRG(&mem, bytes.NewReader([]byte{0x01}))
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected %v, actual %v\n", ErrTruncated, err)
+	}
+}
+
+func TestLoadPRGOverflow(t *testing.T) {
+	mem := memory.Memory{}
+	_, err := LoadPRG(&mem, bytes.NewReader([]byte{0xFF, 0xFF, 0x00, 0x00}))
+	if !errors.Is(err, ErrOverflow) {
+		t.Errorf("expected %v, actual %v\n", ErrOverflow, err)
+	}
+}
@@ -0,0 +1,130 @@
+package loader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// wozLineSize is the number of bytes on a line written by WriteWozTape, as
+// the Woz Monitor prints them when examining memory.
+const wozLineSize = 8
+
+// WozTape is the content of a paper tape of the Woz Monitor of the Apple 1:
+// the lines typed to it to deposit a program, such as
+//
+//	0300: A9 00 AA
+//	: 20 EF FF
+//	0300R
+//
+// A line starting with a colon continues from the last byte deposited, and
+// an address followed by R runs the program there.
+type WozTape struct {
+	// Segments are the bytes deposited, merged when contiguous, in the order
+	// of the tape.
+	Segments []Segment
+	// Start is the address of the run command, if HasStart.
+	Start    uint16
+	HasStart bool
+}
+
+// ReadWozTape parses a paper tape of the Woz Monitor, as exchanged by
+// hobbyists and printed by the monitor itself. Addresses and bytes are in
+// hex, of up to 4 and 2 digits.
+func ReadWozTape(r io.Reader) (*WozTape, error) {
+	t := &WozTape{}
+	var next uint32
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		addr, data, deposit := strings.Cut(line, ":")
+		if !deposit {
+			start, ok := strings.CutSuffix(strings.ToUpper(line), "R")
+			v, err := strconv.ParseUint(start, 16, 16)
+			if !ok || err != nil {
+				return nil, fmt.Errorf("%w: line %d: %q", ErrInvalidRecord, n, line)
+			}
+			t.Start, t.HasStart = uint16(v), true
+			continue
+		}
+		if addr = strings.TrimSpace(addr); addr != "" {
+			v, err := strconv.ParseUint(addr, 16, 16)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: address %q", ErrInvalidRecord, n, addr)
+			}
+			next = uint32(v)
+		}
+		fields := strings.Fields(data)
+		buf := make([]byte, len(fields))
+		for i, f := range fields {
+			v, err := strconv.ParseUint(f, 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: byte %q", ErrInvalidRecord, n, f)
+			}
+			buf[i] = byte(v)
+		}
+		if next+uint32(len(buf)) > addressSpaceSize {
+			return nil, fmt.Errorf("%w: line %d", ErrOverflow, n)
+		}
+		t.Segments = appendSegment(t.Segments, uint16(next), buf)
+		next += uint32(len(buf))
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("loader: reading woz tape: %w", err)
+	}
+	return t, nil
+}
+
+// LoadWozTape reads a paper tape of the Woz Monitor from r and writes its
+// bytes into mem, as typing it to the monitor does.
+func LoadWozTape(mem memory.Writer, r io.Reader) (*WozTape, error) {
+	t, err := ReadWozTape(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, seg := range t.Segments {
+		if err := seg.Load(mem); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// WriteWozTape writes t to w as a paper tape of the Woz Monitor, 8 bytes a
+// line at addresses aligned as the monitor prints them, followed by the run
+// command if t has a start address.
+func WriteWozTape(w io.Writer, t *WozTape) error {
+	bw := bufio.NewWriter(w)
+	for _, seg := range t.Segments {
+		if int(seg.Addr)+len(seg.Data) > addressSpaceSize {
+			return ErrOverflow
+		}
+		for i, b := range seg.Data {
+			addr := seg.Addr + uint16(i)
+			if i == 0 || addr%wozLineSize == 0 {
+				if i != 0 {
+					bw.WriteString("\n")
+				}
+				fmt.Fprintf(bw, "%04X:", addr)
+			}
+			fmt.Fprintf(bw, " %02X", b)
+		}
+		if len(seg.Data) != 0 {
+			bw.WriteString("\n")
+		}
+	}
+	if t.HasStart {
+		fmt.Fprintf(bw, "%04XR\n", t.Start)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("loader: writing woz tape: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestLoadWozTape(t *testing.T) {
+	tape := `0300: A9 00 AA
+: 20 EF FF
+FFFC:00 03
+
+300r
+`
+	mem := memory.Memory{}
+
+	tp, err := LoadWozTape(&mem, strings.NewReader(tape))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(tp.Segments) != 2 || tp.Segments[0].Addr != 0x0300 || tp.Segments[0].End() != 0x0305 {
+		t.Fatalf("expected the lines at $0300 merged, actual %+v", tp.Segments)
+	}
+	for i, expected := range []byte{0xA9, 0x00, 0xAA, 0x20, 0xEF, 0xFF} {
+		if actual := mem.Read(0x0300 + uint16(i)); actual != expected {
+			t.Errorf("expected %02X, actual %02X\n", expected, actual)
+		}
+	}
+	if actual := memory.ReadWord(&mem, 0xFFFC); actual != 0x0300 {
+		t.Errorf("expected the reset vector $0300, actual $%04X\n", actual)
+	}
+	if !tp.HasStart || tp.Start != 0x0300 {
+		t.Errorf("expected the start address $0300, actual %+v\n", tp)
+	}
+}
+
+func TestReadWozTapeErrors(t *testing.T) {
+	for _, tc := range []struct {
+		tape     string
+		expected error
+	}{
+		{"0300: A9 G0\n", ErrInvalidRecord},
+		{"03X0: A9\n", ErrInvalidRecord},
+		{"0300.0310\n", ErrInvalidRecord},
+		{"FFFF: 00 00\n", ErrOverflow},
+	} {
+		if _, err := ReadWozTape(strings.NewReader(tc.tape)); !errors.Is(err, tc.expected) {
+			t.Errorf("expected %v for %q, actual %v\n", tc.expected, tc.tape, err)
+		}
+	}
+}
+
+func TestWriteWozTape(t *testing.T) {
+	tp := &WozTape{
+		Segments: []Segment{
+			{Addr: 0x0305, Data: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C}},
+			{Addr: 0xFFFC, Data: []byte{0x05, 0x03}},
+		},
+		Start:    0x0305,
+		HasStart: true,
+	}
+	var out bytes.Buffer
+
+	if err := WriteWozTape(&out, tp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `0305: 01 02 03
+0308: 04 05 06 07 08 09 0A 0B
+0310: 0C
+FFFC: 05 03
+0305R
+`
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+	read, err := ReadWozTape(&out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(read.Segments) != 2 || !bytes.Equal(read.Segments[0].Data, tp.Segments[0].Data) || read.Start != tp.Start {
+		t.Errorf("expected %+v read back, actual %+v\n", tp, read)
+	}
+}
@@ -0,0 +1,14 @@
+// Package all registers every machine of the subpackages of machines, for
+// programs to create any of them by name:
+//
+//	import _ "github.com/leakedmemory/mos6502/machines/all"
+package all
+
+import (
+	_ "github.com/leakedmemory/mos6502/machines/apple1"
+	_ "github.com/leakedmemory/mos6502/machines/atari2600"
+	_ "github.com/leakedmemory/mos6502/machines/beneater"
+	_ "github.com/leakedmemory/mos6502/machines/easy6502"
+	_ "github.com/leakedmemory/mos6502/machines/kim1"
+	_ "github.com/leakedmemory/mos6502/machines/nes"
+)
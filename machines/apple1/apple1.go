@@ -0,0 +1,204 @@
+// Package apple1 composes the Apple 1 out of the CPU and the devices: RAM,
+// the Woz Monitor in ROM, Integer BASIC, and the 6821 PIA wiring the
+// keyboard and the display to the terminal of the host.
+//
+// The ROM images are not included: they are given to New, read from files
+// such as those distributed with other emulators.
+//
+//	m, err := apple1.New(apple1.Config{Monitor: wozmon, Keyboard: os.Stdin, Display: os.Stdout})
+//	...
+//	m.Reset()
+//	m.CPU.Run()
+package apple1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/devices"
+	"github.com/leakedmemory/mos6502/devices/keyboard"
+	"github.com/leakedmemory/mos6502/devices/pia6821"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// The memory map.
+const (
+	// PIAAddr is the address of the PIA: KBD, KBDCR, DSP and DSPCR.
+	PIAAddr uint16 = 0xD010
+	// BASICAddr is where Integer BASIC is loaded, in the second 4 KiB of
+	// RAM.
+	BASICAddr uint16 = 0xE000
+	BASICSize        = 0x1000
+	// MonitorAddr is the address of the Woz Monitor ROM.
+	MonitorAddr uint16 = 0xFF00
+	MonitorSize        = 0x100
+	// DefaultRAMSize is the RAM at $0000, that of the Apple 1 as shipped.
+	DefaultRAMSize = 0x1000
+	maxRAMSize     = int(PIAAddr &^ 0x0FFF)
+)
+
+const (
+	resetVector uint16 = 0xFFFC
+	regKBDCR    uint16 = 0x01
+)
+
+// ErrConfig is returned by New for an invalid Config.
+var ErrConfig = errors.New("apple1: invalid config")
+
+// Config describes an Apple 1.
+type Config struct {
+	// Monitor is the image of the Woz Monitor ROM, of MonitorSize bytes.
+	Monitor []byte
+	// BASIC is the image of Integer BASIC, loaded at BASICAddr, or nil.
+	BASIC []byte
+	// RAMSize is the size of the RAM at $0000, up to 52 KiB, or
+	// DefaultRAMSize if zero.
+	RAMSize int
+	// Keyboard gives the keys typed, such as the terminal put in raw mode
+	// with keyboard.MakeRaw, and Display receives what is printed. Either
+	// can be nil.
+	Keyboard io.Reader
+	Display  io.Writer
+}
+
+// Machine is an Apple 1.
+type Machine struct {
+	CPU *cpu.CPU
+	Bus *bus.Bus
+	PIA *pia6821.PIA
+
+	clock    *devices.Clock
+	keyboard *keyboard.Keyboard
+	kbd      keyPort
+	dsp      displayPort
+}
+
+// New returns an Apple 1 as described by cfg. It is to be reset before
+// running.
+func New(cfg Config) (*Machine, error) {
+	if len(cfg.Monitor) != MonitorSize {
+		return nil, fmt.Errorf("%w: monitor of %d bytes instead of %d", ErrConfig, len(cfg.Monitor), MonitorSize)
+	}
+	if len(cfg.BASIC) > BASICSize {
+		return nil, fmt.Errorf("%w: BASIC of %d bytes, more than %d", ErrConfig, len(cfg.BASIC), BASICSize)
+	}
+	if cfg.RAMSize == 0 {
+		cfg.RAMSize = DefaultRAMSize
+	}
+	if cfg.RAMSize < 0 || cfg.RAMSize > maxRAMSize {
+		return nil, fmt.Errorf("%w: RAM of %d bytes", ErrConfig, cfg.RAMSize)
+	}
+
+	m := &Machine{Bus: bus.New(), dsp: displayPort{w: cfg.Display}}
+	if cfg.Keyboard != nil {
+		m.keyboard = keyboard.New(cfg.Keyboard)
+	}
+	m.PIA = pia6821.New(nil, nil, &m.kbd, &m.dsp)
+	m.dsp.pia = m.PIA
+
+	basic := bus.NewRAM(BASICSize)
+	copy(basic, cfg.BASIC)
+	for _, r := range []struct {
+		start, end uint16
+		dev        bus.Device
+	}{
+		{0x0000, uint16(cfg.RAMSize - 1), bus.NewRAM(cfg.RAMSize)},
+		{PIAAddr, PIAAddr + 3, m.PIA},
+		{BASICAddr, BASICAddr + BASICSize - 1, basic},
+		{MonitorAddr, MonitorAddr + (MonitorSize - 1), bus.ROM(cfg.Monitor)},
+	} {
+		if err := m.Bus.Map(r.start, r.end, r.dev); err != nil {
+			return nil, fmt.Errorf("apple1: mapping memory: %w", err)
+		}
+	}
+
+	m.CPU = cpu.New(m.Bus)
+	m.clock = devices.NewClock(m.CPU, m.PIA, devices.ClockedFunc(m.tick))
+	return m, nil
+}
+
+// Reset resets the CPU and the PIA, like the RESET key, and starts the CPU
+// at the reset vector, in the Woz Monitor.
+func (m *Machine) Reset() {
+	m.CPU.Reset()
+	m.PIA.Reset()
+	r := m.CPU.Registers()
+	r.PC = memory.ReadWord(m.Bus, resetVector)
+	m.CPU.SetRegisters(r)
+	m.clock.Sync()
+}
+
+// tick strobes the next key into the PIA once the previous one is read, and
+// prints the character written to the display.
+func (m *Machine) tick(uint) {
+	if m.keyboard != nil && m.PIA.Peek(regKBDCR)&pia6821.CRFlagC1 == 0 && m.keyboard.Pending() {
+		m.kbd.key = key(m.keyboard.Read(keyboard.RegData))
+		m.PIA.SetCA1(false)
+		m.PIA.SetCA1(true)
+	}
+	if !m.PIA.CB2() {
+		m.dsp.print()
+		m.PIA.SetCB1(false)
+		m.PIA.SetCB1(true)
+	}
+}
+
+// key returns the code the keyboard of the Apple 1, which has no lower case,
+// sends for b, with bit 7 set as wired to PA7.
+func key(b byte) byte {
+	switch {
+	case b >= 'a' && b <= 'z':
+		b -= 'a' - 'A'
+	case b == '\n':
+		b = '\r'
+	case b == 0x7F || b == 0x08:
+		// The Woz Monitor rubs out with an underscore.
+		b = '_'
+	}
+	return b | 0x80
+}
+
+// keyPort is port A, where the keyboard drives the last key.
+type keyPort struct {
+	key byte
+}
+
+func (p *keyPort) Input() byte {
+	return p.key
+}
+
+func (p *keyPort) Output(byte, byte) {}
+
+// displayPort is port B, where the display reads a character, on the
+// falling edge of CB2, and drives PB7 high until it is printed.
+type displayPort struct {
+	pia *pia6821.PIA
+	w   io.Writer
+	val byte
+}
+
+func (p *displayPort) Input() byte {
+	if p.pia != nil && !p.pia.CB2() {
+		return 0x80
+	}
+	return 0
+}
+
+func (p *displayPort) Output(val, ddr byte) {
+	p.val = val & ddr
+}
+
+func (p *displayPort) print() {
+	if p.w == nil {
+		return
+	}
+	switch c := p.val & 0x7F; {
+	case c == '\r':
+		p.w.Write([]byte{'\n'})
+	case c >= 0x20:
+		p.w.Write([]byte{c})
+	}
+}
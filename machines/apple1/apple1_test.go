@@ -0,0 +1,136 @@
+package apple1
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/devices/pia6821"
+)
+
+const (
+	kbd   uint16 = PIAAddr
+	kbdcr uint16 = PIAAddr + 1
+	dsp   uint16 = PIAAddr + 2
+	dspcr uint16 = PIAAddr + 3
+)
+
+// testMonitor returns a monitor image looping on LDA #$00 from $FF00, with
+// the reset vector pointing to it.
+func testMonitor() []byte {
+	rom := make([]byte, MonitorSize)
+	for i := 0; i < 0xEE; i += 2 {
+		rom[i] = 0xA9
+	}
+	copy(rom[0xEE:], []byte{0x20, 0x00, 0xFF})
+	rom[0xFC], rom[0xFD] = 0x00, 0xFF
+	return rom
+}
+
+// initPIA sets the PIA up as the Woz Monitor does.
+func initPIA(m *Machine) {
+	m.Bus.Write(0x7F, dsp)
+	m.Bus.Write(0xA7, kbdcr)
+	m.Bus.Write(0xA7, dspcr)
+}
+
+func TestApple1Reset(t *testing.T) {
+	m, err := New(Config{Monitor: testMonitor()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.Reset()
+
+	if pc := m.CPU.Registers().PC; pc != MonitorAddr {
+		t.Errorf("expected PC $%04X, actual $%04X\n", MonitorAddr, pc)
+	}
+}
+
+func TestApple1Memory(t *testing.T) {
+	basic := []byte{0x4C, 0xB0, 0xE2}
+	m, err := New(Config{Monitor: testMonitor(), BASIC: basic, RAMSize: 0x8000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.Bus.Write(0x42, 0x7FFF)
+	if actual := m.Bus.Read(0x7FFF); actual != 0x42 {
+		t.Errorf("expected RAM to hold $42, actual $%02X\n", actual)
+	}
+	if actual := m.Bus.Read(BASICAddr + 1); actual != 0xB0 {
+		t.Errorf("expected BASIC at $E000, actual $%02X\n", actual)
+	}
+	m.Bus.Write(0x00, MonitorAddr)
+	if actual := m.Bus.Read(MonitorAddr); actual != 0xA9 {
+		t.Errorf("expected the monitor read-only, actual $%02X\n", actual)
+	}
+}
+
+func TestApple1Display(t *testing.T) {
+	var out bytes.Buffer
+	m, err := New(Config{Monitor: testMonitor(), Display: &out})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	initPIA(m)
+
+	for _, c := range []byte("HI\r") {
+		if m.Bus.Read(dsp)&0x80 != 0 {
+			t.Fatalf("expected the display ready before %q", c)
+		}
+		m.Bus.Write(c|0x80, dsp)
+		if m.Bus.Read(dsp)&0x80 == 0 {
+			t.Errorf("expected the display busy printing %q", c)
+		}
+		m.tick(1)
+	}
+
+	if out.String() != "HI\n" {
+		t.Errorf("expected %q, actual %q\n", "HI\n", out.String())
+	}
+}
+
+func TestApple1Keyboard(t *testing.T) {
+	m, err := New(Config{Monitor: testMonitor(), Keyboard: strings.NewReader("e\n\x7F")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.Reset()
+	initPIA(m)
+
+	for _, expected := range []byte{'E' | 0x80, '\r' | 0x80, '_' | 0x80} {
+		for range 1_000_000 {
+			if m.Bus.Read(kbdcr)&pia6821.CRFlagC1 != 0 {
+				break
+			}
+			if err := m.CPU.Step(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			runtime.Gosched()
+		}
+		if actual := m.Bus.Read(kbd); actual != expected {
+			t.Errorf("expected $%02X, actual $%02X\n", expected, actual)
+		}
+		if m.Bus.Read(kbdcr)&pia6821.CRFlagC1 != 0 {
+			t.Errorf("expected the key strobe cleared by reading KBD")
+		}
+	}
+}
+
+func TestApple1Config(t *testing.T) {
+	tests := []Config{
+		{},
+		{Monitor: make([]byte, 0x200)},
+		{Monitor: testMonitor(), BASIC: make([]byte, 0x1001)},
+		{Monitor: testMonitor(), RAMSize: 0xD001},
+	}
+
+	for _, cfg := range tests {
+		if _, err := New(cfg); !errors.Is(err, ErrConfig) {
+			t.Errorf("expected %v, actual %v\n", ErrConfig, err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package apple1
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/leakedmemory/mos6502/machines"
+)
+
+func init() {
+	machines.Register(machines.Info{
+		Name:        "apple1",
+		Description: "Apple 1 with the Woz Monitor",
+		Images: map[string]string{
+			"monitor": "Woz Monitor ROM, of 256 bytes",
+			"basic":   "Integer BASIC, of 4 KiB, loaded at $E000",
+		},
+		Options: map[string]string{
+			"ram": "size of the RAM at $0000, in bytes",
+		},
+		New: func(cfg machines.Config) (*machines.System, error) {
+			c := Config{
+				Monitor:  cfg.Images["monitor"],
+				BASIC:    cfg.Images["basic"],
+				Keyboard: cfg.Input,
+				Display:  cfg.Output,
+			}
+			if s, ok := cfg.Options["ram"]; ok {
+				n, err := strconv.Atoi(s)
+				if err != nil {
+					return nil, fmt.Errorf("%w: RAM size %q", ErrConfig, s)
+				}
+				c.RAMSize = n
+			}
+			m, err := New(c)
+			if err != nil {
+				return nil, err
+			}
+			return &machines.System{CPU: m.CPU, Bus: m.Bus, Machine: m}, nil
+		},
+	})
+}
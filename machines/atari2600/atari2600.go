@@ -0,0 +1,122 @@
+// Package atari2600 composes the CPU side of the Atari 2600, enough to trace
+// and time the kernels of its games: the 6507, a 6502 with 13 address lines,
+// the 6532 RIOT with its RAM at $80 and its I/O at $280, a stub of the TIA
+// stalling the CPU on WSYNC and counting the scanlines, and the cartridge
+// ROM at $1000.
+//
+//	m, err := atari2600.New(rom)
+//	...
+//	m.Reset()
+//	m.CPU.RunFor(60 * atari2600.CyclesPerLine * 262)
+//	fmt.Println(m.TIA.Frames, m.TIA.LastFrameLines)
+package atari2600
+
+import (
+	"fmt"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/devices"
+	"github.com/leakedmemory/mos6502/devices/riot6532"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// Lines of the address bus decoding the chips.
+const (
+	// addrMask is the 13 address lines of the 6507: the 8 KiB it addresses
+	// are mirrored across the address space of the CPU.
+	addrMask uint16 = 0x1FFF
+	// addrCartridge selects the cartridge, and addrRIOT the RIOT otherwise,
+	// its I/O with addrRIOTIO and its RAM without. The TIA is selected by
+	// neither.
+	addrCartridge uint16 = 0x1000
+	addrRIOT      uint16 = 0x0080
+	addrRIOTIO    uint16 = 0x0200
+	riotIOMask    uint16 = 0x001F
+)
+
+// Sizes of the cartridges without bank switching.
+const (
+	ROMSize2K = 0x0800
+	ROMSize4K = 0x1000
+)
+
+const resetVector uint16 = 0xFFFC
+
+// Machine is the CPU side of an Atari 2600.
+type Machine struct {
+	CPU  *cpu.CPU
+	Bus  *bus.Bus
+	RIOT *riot6532.RIOT
+	TIA  *TIA
+
+	rom   bus.ROM
+	clock *devices.Clock
+}
+
+// New returns an Atari 2600 running the cartridge rom, of 2 or 4 KiB, a 2 KiB
+// one being mirrored. It is to be reset before running. The joysticks and
+// the console switches read as released.
+func New(rom []byte) (*Machine, error) {
+	if len(rom) != ROMSize2K && len(rom) != ROMSize4K {
+		return nil, fmt.Errorf("atari2600: ROM of %d bytes, not of 2 or 4 KiB", len(rom))
+	}
+	m := &Machine{Bus: bus.New(), rom: bus.ROM(rom)}
+	m.CPU = cpu.New(m.Bus)
+	m.Bus.SetStallFunc(m.CPU.Stall)
+	m.RIOT = riot6532.New(nil, nil, nil)
+	m.TIA = newTIA(m.CPU, m.Bus)
+	if err := m.Bus.Map(0x0000, 0xFFFF, addressBus{m}); err != nil {
+		return nil, fmt.Errorf("atari2600: mapping memory: %w", err)
+	}
+	m.clock = devices.NewClock(m.CPU, m.RIOT, m.TIA)
+	return m, nil
+}
+
+// Reset resets the CPU and the RIOT, and starts the CPU at the reset vector.
+func (m *Machine) Reset() {
+	m.CPU.Reset()
+	m.RIOT.Reset()
+	r := m.CPU.Registers()
+	r.PC = memory.ReadWord(m.Bus, resetVector)
+	m.CPU.SetRegisters(r)
+	m.clock.Sync()
+}
+
+// addressBus decodes the 13 address lines of the 6507 to the chips.
+type addressBus struct {
+	m *Machine
+}
+
+// device returns the device addr selects, and the address in it.
+func (b addressBus) device(addr uint16) (bus.Device, uint16) {
+	addr &= addrMask
+	switch {
+	case addr&addrCartridge != 0:
+		return b.m.rom, addr &^ addrCartridge
+	case addr&addrRIOT == 0:
+		return b.m.TIA, addr
+	case addr&addrRIOTIO != 0:
+		return b.m.RIOT, addr & riotIOMask
+	default:
+		return b.m.RIOT.RAM(), addr
+	}
+}
+
+func (b addressBus) Read(addr uint16) byte {
+	d, a := b.device(addr)
+	return d.Read(a)
+}
+
+func (b addressBus) Write(val byte, addr uint16) {
+	d, a := b.device(addr)
+	d.Write(val, a)
+}
+
+func (b addressBus) Peek(addr uint16) byte {
+	d, a := b.device(addr)
+	if p, ok := d.(bus.Peeker); ok {
+		return p.Peek(a)
+	}
+	return d.Read(a)
+}
@@ -0,0 +1,92 @@
+package atari2600
+
+import "testing"
+
+func testROM(size int) []byte {
+	rom := make([]byte, size)
+	rom[0] = 0xA9
+	rom[size-4], rom[size-3] = 0x00, 0xF0
+	return rom
+}
+
+func newTestMachine(t *testing.T, size int) *Machine {
+	t.Helper()
+	m, err := New(testROM(size))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.Reset()
+	return m
+}
+
+func TestAtari2600Decoding(t *testing.T) {
+	m := newTestMachine(t, ROMSize2K)
+
+	if pc := m.CPU.Registers().PC; pc != 0xF000 {
+		t.Errorf("expected PC $F000, actual $%04X\n", pc)
+	}
+	for _, addr := range []uint16{0x1000, 0x1800, 0xF000, 0xF800, 0x3000} {
+		if actual := m.Bus.Read(addr); actual != 0xA9 {
+			t.Errorf("expected the ROM at $%04X, actual $%02X\n", addr, actual)
+		}
+	}
+
+	m.Bus.Write(0x42, 0x0080)
+	for _, addr := range []uint16{0x0180, 0x2080, 0xE080} {
+		if actual := m.Bus.Read(addr); actual != 0x42 {
+			t.Errorf("expected the RAM at $%04X, actual $%02X\n", addr, actual)
+		}
+	}
+	if actual := m.Bus.Read(0x000C); actual != 0x80 {
+		t.Errorf("expected INPT4 released, actual $%02X\n", actual)
+	}
+}
+
+func TestAtari2600RIOT(t *testing.T) {
+	m := newTestMachine(t, ROMSize4K)
+
+	// TIM64T.
+	m.Bus.Write(10, 0x0296)
+	m.RIOT.Tick(64)
+	if actual := m.Bus.Read(0x0284); actual != 9 {
+		t.Errorf("expected INTIM 9, actual %d\n", actual)
+	}
+	if actual := m.Bus.Read(0x0280); actual != 0xFF {
+		t.Errorf("expected SWCHA released, actual $%02X\n", actual)
+	}
+}
+
+func TestAtari2600WSYNC(t *testing.T) {
+	m := newTestMachine(t, ROMSize4K)
+
+	// The CPU is on cycle 7 after a reset.
+	m.Bus.Write(0, regWSYNC)
+	if stall := m.CPU.State().Stall; stall != CyclesPerLine-7 {
+		t.Errorf("expected a stall of %d cycles, actual %d\n", CyclesPerLine-7, stall)
+	}
+}
+
+func TestAtari2600Frames(t *testing.T) {
+	m := newTestMachine(t, ROMSize4K)
+	st := m.CPU.State()
+
+	for range 3 {
+		m.Bus.Write(vsyncOn, regVSYNC)
+		m.Bus.Write(0, regVSYNC)
+		st.Cycles += 262 * CyclesPerLine
+		m.CPU.SetState(st)
+	}
+
+	if m.TIA.Frames != 3 || m.TIA.LastFrameLines != 262 {
+		t.Errorf("expected 3 frames of 262 lines, actual %d of %d\n", m.TIA.Frames, m.TIA.LastFrameLines)
+	}
+	if line := m.TIA.Scanline(); line != 262 {
+		t.Errorf("expected scanline 262, actual %d\n", line)
+	}
+}
+
+func TestAtari2600ROMSize(t *testing.T) {
+	if _, err := New(make([]byte, 0x2000)); err == nil {
+		t.Errorf("expected an error for a bank-switched ROM")
+	}
+}
@@ -0,0 +1,20 @@
+package atari2600
+
+import "github.com/leakedmemory/mos6502/machines"
+
+func init() {
+	machines.Register(machines.Info{
+		Name:        "atari2600",
+		Description: "Atari 2600, without the display",
+		Images: map[string]string{
+			"rom": "cartridge ROM, of 2 or 4 KiB",
+		},
+		New: func(cfg machines.Config) (*machines.System, error) {
+			m, err := New(cfg.Images["rom"])
+			if err != nil {
+				return nil, err
+			}
+			return &machines.System{CPU: m.CPU, Bus: m.Bus, Machine: m}, nil
+		},
+	})
+}
@@ -0,0 +1,96 @@
+package atari2600
+
+import (
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// CyclesPerLine is the number of cycles of the CPU by scanline, 228 color
+// clocks of the TIA.
+const CyclesPerLine = 76
+
+// Registers of the TIA, by their address.
+const (
+	regVSYNC = 0x00
+	regWSYNC = 0x02
+	regINPT4 = 0x0C
+	regINPT5 = 0x0D
+	// writeRegs and readRegs are the number of registers, mirrored across
+	// the addresses of the TIA.
+	writeRegs = 0x40
+	readRegs  = 0x10
+)
+
+// vsyncOn is the bit of VSYNC starting the vertical sync.
+const vsyncOn byte = 0x02
+
+// TIA is a stub of the TIA as the CPU sees it: WSYNC halts the CPU until the
+// start of the next scanline, and the scanlines are counted from the start
+// of the vertical sync, without video or sound. The collisions read as
+// none, and the fire buttons as released. It is made by New.
+type TIA struct {
+	cpu *cpu.CPU
+	bus *bus.Bus
+
+	regs [writeRegs]byte
+	// line is the cycle the current scanline started at, and frame the one
+	// the vertical sync started at.
+	line, frame uint
+	// Frames is the number of vertical syncs started since power on, and
+	// LastFrameLines the number of scanlines between the last two.
+	Frames         uint
+	LastFrameLines uint
+}
+
+func newTIA(c *cpu.CPU, b *bus.Bus) *TIA {
+	return &TIA{cpu: c, bus: b}
+}
+
+// Scanline returns the number of scanlines since the start of the vertical
+// sync.
+func (t *TIA) Scanline() uint {
+	return (t.cpu.Cycles() - t.frame) / CyclesPerLine
+}
+
+// Read returns the input register at addr.
+func (t *TIA) Read(addr uint16) byte {
+	switch addr % readRegs {
+	case regINPT4, regINPT5:
+		return 0x80
+	default:
+		return 0
+	}
+}
+
+// Write changes the register at addr to val. Writing WSYNC halts the CPU
+// until the start of the next scanline, and setting VSYNC starts a frame.
+func (t *TIA) Write(val byte, addr uint16) {
+	reg := addr % writeRegs
+	now := t.cpu.Cycles()
+	switch reg {
+	case regWSYNC:
+		t.Tick(0)
+		if left := CyclesPerLine - (now-t.line)%CyclesPerLine; left != CyclesPerLine {
+			t.bus.StealCycles(left)
+		}
+	case regVSYNC:
+		if val&vsyncOn != 0 && t.regs[reg]&vsyncOn == 0 {
+			if t.Frames != 0 {
+				t.LastFrameLines = (now - t.frame) / CyclesPerLine
+			}
+			t.Frames++
+			t.frame = now
+		}
+	}
+	t.regs[reg] = val
+}
+
+// Tick keeps the start of the current scanline.
+func (t *TIA) Tick(uint) {
+	now := t.cpu.Cycles()
+	if now < t.line {
+		// The CPU was reset.
+		t.line = now
+	}
+	t.line += (now - t.line) / CyclesPerLine * CyclesPerLine
+}
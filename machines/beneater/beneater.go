@@ -0,0 +1,169 @@
+// Package beneater composes the 6502 computer built on breadboards in the
+// videos of Ben Eater: a 32 KiB RAM, a 32 KiB ROM, a 6522 VIA and a 16x2
+// character LCD on the ports of the VIA. The ROMs written for it run
+// unmodified.
+//
+// The address decoding selects the RAM at $0000-$3FFF, 16 KiB of the chip,
+// the VIA at $6000-$7FFF, mirrored, and the ROM at $8000-$FFFF:
+//
+//	m, err := beneater.New(beneater.Config{ROM: rom})
+//	...
+//	m.Reset()
+//	m.CPU.Run()
+//	fmt.Println(m.LCD.Lines())
+package beneater
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/devices"
+	"github.com/leakedmemory/mos6502/devices/hd44780"
+	"github.com/leakedmemory/mos6502/devices/via6522"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// The memory map.
+const (
+	RAMSize        = 0x4000
+	VIAAddr uint16 = 0x6000
+	VIAEnd  uint16 = 0x7FFF
+	ROMAddr uint16 = 0x8000
+	ROMSize        = 0x8000
+)
+
+const resetVector uint16 = 0xFFFC
+
+// Wiring is how the LCD is wired to the ports of the VIA.
+type Wiring int
+
+const (
+	// Wiring8Bit is that of the first videos: D7-D0 on port B, and E, RW and
+	// RS on PA7, PA6 and PA5.
+	Wiring8Bit Wiring = iota
+	// Wiring4Bit is that of the later videos, freeing port A: D7-D4 on
+	// PB3-PB0, and E, RW and RS on PB6, PB5 and PB4.
+	Wiring4Bit
+)
+
+// ErrConfig is returned by New for an invalid Config.
+var ErrConfig = errors.New("beneater: invalid config")
+
+// Config describes a breadboard computer.
+type Config struct {
+	// ROM is the image of the ROM, of ROMSize bytes, as written to the
+	// EEPROM.
+	ROM []byte
+	// Wiring is the wiring of the LCD.
+	Wiring Wiring
+}
+
+// Machine is a breadboard computer.
+type Machine struct {
+	CPU *cpu.CPU
+	Bus *bus.Bus
+	VIA *via6522.VIA
+	LCD *hd44780.LCD
+
+	clock *devices.Clock
+}
+
+// New returns a breadboard computer as described by cfg. It is to be reset
+// before running. The IRQ output of the VIA is wired to the CPU.
+func New(cfg Config) (*Machine, error) {
+	if len(cfg.ROM) != ROMSize {
+		return nil, fmt.Errorf("%w: ROM of %d bytes instead of %d", ErrConfig, len(cfg.ROM), ROMSize)
+	}
+	m := &Machine{Bus: bus.New(), LCD: hd44780.New(16, 2)}
+	m.CPU = cpu.New(m.Bus)
+	switch cfg.Wiring {
+	case Wiring8Bit:
+		m.VIA = via6522.New(m.CPU, controlPort{m.LCD}, dataPort{m.LCD})
+	case Wiring4Bit:
+		m.VIA = via6522.New(m.CPU, nil, nibblePort{m.LCD})
+	default:
+		return nil, fmt.Errorf("%w: wiring %d", ErrConfig, cfg.Wiring)
+	}
+
+	for _, r := range []struct {
+		start, end uint16
+		dev        bus.Device
+	}{
+		{0x0000, RAMSize - 1, bus.NewRAM(RAMSize)},
+		{VIAAddr, VIAEnd, m.VIA},
+		{ROMAddr, ROMAddr + (ROMSize - 1), bus.ROM(cfg.ROM)},
+	} {
+		if err := m.Bus.Map(r.start, r.end, r.dev); err != nil {
+			return nil, fmt.Errorf("beneater: mapping memory: %w", err)
+		}
+	}
+	m.clock = devices.NewClock(m.CPU, m.VIA)
+	return m, nil
+}
+
+// Reset resets the CPU, the VIA and the LCD, like the reset button and
+// power on, and starts the CPU at the reset vector.
+func (m *Machine) Reset() {
+	m.CPU.Reset()
+	m.VIA.Reset()
+	m.LCD.Reset()
+	r := m.CPU.Registers()
+	r.PC = memory.ReadWord(m.Bus, resetVector)
+	m.CPU.SetRegisters(r)
+	m.clock.Sync()
+}
+
+// Bits of the control lines of the LCD on port A, in the 8-bit wiring, and
+// on port B, in the 4-bit one.
+const (
+	pinE8  byte = 0x80
+	pinRW8 byte = 0x40
+	pinRS8 byte = 0x20
+	pinE4  byte = 0x40
+	pinRW4 byte = 0x20
+	pinRS4 byte = 0x10
+)
+
+// controlPort is port A in the 8-bit wiring.
+type controlPort struct {
+	lcd *hd44780.LCD
+}
+
+func (p controlPort) Input() byte {
+	return 0
+}
+
+func (p controlPort) Output(val, ddr byte) {
+	pins := val & ddr
+	p.lcd.SetControl(pins&pinRS8 != 0, pins&pinRW8 != 0, pins&pinE8 != 0)
+}
+
+// dataPort is port B in the 8-bit wiring.
+type dataPort struct {
+	lcd *hd44780.LCD
+}
+
+func (p dataPort) Input() byte {
+	return p.lcd.Output()
+}
+
+func (p dataPort) Output(val, ddr byte) {
+	p.lcd.SetData(val & ddr)
+}
+
+// nibblePort is port B in the 4-bit wiring.
+type nibblePort struct {
+	lcd *hd44780.LCD
+}
+
+func (p nibblePort) Input() byte {
+	return p.lcd.Output() >> 4
+}
+
+func (p nibblePort) Output(val, ddr byte) {
+	pins := val & ddr
+	p.lcd.SetData(pins << 4)
+	p.lcd.SetControl(pins&pinRS4 != 0, pins&pinRW4 != 0, pins&pinE4 != 0)
+}
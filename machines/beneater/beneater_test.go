@@ -0,0 +1,140 @@
+package beneater
+
+import (
+	"errors"
+	"testing"
+)
+
+const (
+	portB = VIAAddr
+	portA = VIAAddr + 1
+	ddrB  = VIAAddr + 2
+	ddrA  = VIAAddr + 3
+)
+
+func testROM() []byte {
+	rom := make([]byte, ROMSize)
+	rom[0x7FFC], rom[0x7FFD] = 0x00, 0x80
+	return rom
+}
+
+func newTestMachine(t *testing.T, wiring Wiring) *Machine {
+	t.Helper()
+	m, err := New(Config{ROM: testROM(), Wiring: wiring})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.Reset()
+	return m
+}
+
+// send8 is lcd_instruction and print_char of the 8-bit programs.
+func send8(m *Machine, rs byte, val byte) {
+	m.Bus.Write(val, portB)
+	m.Bus.Write(rs, portA)
+	m.Bus.Write(rs|pinE8, portA)
+	m.Bus.Write(rs, portA)
+}
+
+// send4 is lcd_instruction and print_char of the 4-bit programs.
+func send4(m *Machine, rs byte, val byte) {
+	for _, nibble := range []byte{val >> 4, val & 0x0F} {
+		m.Bus.Write(nibble|rs, portB)
+		m.Bus.Write(nibble|rs|pinE4, portB)
+		m.Bus.Write(nibble|rs, portB)
+	}
+}
+
+func TestBenEaterReset(t *testing.T) {
+	m := newTestMachine(t, Wiring8Bit)
+
+	if pc := m.CPU.Registers().PC; pc != ROMAddr {
+		t.Errorf("expected PC $%04X, actual $%04X\n", ROMAddr, pc)
+	}
+}
+
+func TestBenEaterMemoryMap(t *testing.T) {
+	m := newTestMachine(t, Wiring8Bit)
+
+	m.Bus.Write(0x42, RAMSize-1)
+	if actual := m.Bus.Read(RAMSize - 1); actual != 0x42 {
+		t.Errorf("expected RAM to hold $42, actual $%02X\n", actual)
+	}
+	m.Bus.Write(0xE0, 0x7FF3)
+	if actual := m.Bus.Read(ddrA); actual != 0xE0 {
+		t.Errorf("expected the VIA mirrored at $7FF0, actual $%02X\n", actual)
+	}
+	m.Bus.Write(0xFF, 0xFFFC)
+	if actual := m.Bus.Read(0xFFFC); actual != 0x00 {
+		t.Errorf("expected the ROM read-only, actual $%02X\n", actual)
+	}
+}
+
+func TestBenEaterHelloWorld8Bit(t *testing.T) {
+	m := newTestMachine(t, Wiring8Bit)
+	m.Bus.Write(0xFF, ddrB)
+	m.Bus.Write(0xE0, ddrA)
+
+	for _, inst := range []byte{0x38, 0x0E, 0x06, 0x01} {
+		send8(m, 0, inst)
+	}
+	for _, c := range []byte("Hello, world!") {
+		send8(m, pinRS8, c)
+	}
+
+	if line := m.LCD.Lines()[0]; line != "Hello, world!   " {
+		t.Errorf("expected %q, actual %q\n", "Hello, world!   ", line)
+	}
+}
+
+func TestBenEaterBusyFlag8Bit(t *testing.T) {
+	m := newTestMachine(t, Wiring8Bit)
+	m.Bus.Write(0xFF, ddrB)
+	m.Bus.Write(0xE0, ddrA)
+	send8(m, 0, 0x38)
+	send8(m, pinRS8, 'x')
+
+	// lcd_wait reads the busy flag and the address counter on port B as an
+	// input.
+	m.Bus.Write(0x00, ddrB)
+	m.Bus.Write(pinRW8, portA)
+	m.Bus.Write(pinRW8|pinE8, portA)
+	if status := m.Bus.Read(portB); status != 0x01 {
+		t.Errorf("expected status $01, actual $%02X\n", status)
+	}
+	m.Bus.Write(pinRW8, portA)
+}
+
+func TestBenEaterHelloWorld4Bit(t *testing.T) {
+	m := newTestMachine(t, Wiring4Bit)
+	m.Bus.Write(0xFF, ddrB)
+
+	// lcd_init sets the 4-bit mode with a single transfer.
+	m.Bus.Write(0x02, portB)
+	m.Bus.Write(0x02|pinE4, portB)
+	m.Bus.Write(0x02, portB)
+	for _, inst := range []byte{0x28, 0x0E, 0x06, 0x01} {
+		send4(m, 0, inst)
+	}
+	for _, c := range []byte("Hello") {
+		send4(m, pinRS4, c)
+	}
+
+	if line := m.LCD.Lines()[0]; line != "Hello           " {
+		t.Errorf("expected %q, actual %q\n", "Hello           ", line)
+	}
+}
+
+func TestBenEaterConfig(t *testing.T) {
+	tests := []Config{
+		{},
+		{ROM: make([]byte, 0x2000)},
+		{ROM: testROM(), Wiring: 2},
+	}
+
+	for _, cfg := range tests {
+		if _, err := New(cfg); !errors.Is(err, ErrConfig) {
+			t.Errorf("expected %v, actual %v\n", ErrConfig, err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package beneater
+
+import (
+	"fmt"
+
+	"github.com/leakedmemory/mos6502/machines"
+)
+
+func init() {
+	machines.Register(machines.Info{
+		Name:        "beneater",
+		Description: "Ben Eater's breadboard computer with a 16x2 LCD",
+		Images: map[string]string{
+			"rom": "EEPROM image, of 32 KiB",
+		},
+		Options: map[string]string{
+			"wiring": `wiring of the LCD, "8bit" or "4bit"`,
+		},
+		New: func(cfg machines.Config) (*machines.System, error) {
+			c := Config{ROM: cfg.Images["rom"]}
+			switch w := cfg.Options["wiring"]; w {
+			case "", "8bit":
+				c.Wiring = Wiring8Bit
+			case "4bit":
+				c.Wiring = Wiring4Bit
+			default:
+				return nil, fmt.Errorf("%w: wiring %q", ErrConfig, w)
+			}
+			m, err := New(c)
+			if err != nil {
+				return nil, err
+			}
+			return &machines.System{CPU: m.CPU, Bus: m.Bus, Machine: m}, nil
+		},
+	})
+}
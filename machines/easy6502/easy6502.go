@@ -0,0 +1,126 @@
+// Package easy6502 composes the machine of the easy6502 web simulator, for
+// its tutorials and games, such as snake, to run unmodified: 64 KiB of RAM,
+// a random byte at $FE, the last key pressed at $FF, and a 32x32 display of
+// 16 colors at $0200-$05FF. Programs are loaded and started at $0600.
+//
+//	m, err := easy6502.New(program, rand.New(rand.NewSource(1)))
+//	...
+//	m.SetKey('w')
+//	m.CPU.RunFor(100_000)
+//	png.Encode(f, m.Screen())
+package easy6502
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/rand"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/devices/rng"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// The memory map.
+const (
+	RandomAddr uint16 = 0x00FE
+	KeyAddr    uint16 = 0x00FF
+	ScreenAddr uint16 = 0x0200
+	// ScreenSize is the width and the height of the display, in pixels of a
+	// byte each, row by row.
+	ScreenSize        = 32
+	CodeAddr   uint16 = 0x0600
+)
+
+// Palette is the colors of the pixels, selected by their low 4 bits.
+var Palette = color.Palette{
+	color.RGBA{0x00, 0x00, 0x00, 0xFF},
+	color.RGBA{0xFF, 0xFF, 0xFF, 0xFF},
+	color.RGBA{0x88, 0x00, 0x00, 0xFF},
+	color.RGBA{0xAA, 0xFF, 0xEE, 0xFF},
+	color.RGBA{0xCC, 0x44, 0xCC, 0xFF},
+	color.RGBA{0x00, 0xCC, 0x55, 0xFF},
+	color.RGBA{0x00, 0x00, 0xAA, 0xFF},
+	color.RGBA{0xEE, 0xEE, 0x77, 0xFF},
+	color.RGBA{0xDD, 0x88, 0x55, 0xFF},
+	color.RGBA{0x66, 0x44, 0x00, 0xFF},
+	color.RGBA{0xFF, 0x77, 0x77, 0xFF},
+	color.RGBA{0x33, 0x33, 0x33, 0xFF},
+	color.RGBA{0x77, 0x77, 0x77, 0xFF},
+	color.RGBA{0xAA, 0xFF, 0x66, 0xFF},
+	color.RGBA{0x00, 0x88, 0xFF, 0xFF},
+	color.RGBA{0xBB, 0xBB, 0xBB, 0xFF},
+}
+
+// Machine is an easy6502 machine.
+type Machine struct {
+	CPU *cpu.CPU
+	Bus *bus.Bus
+}
+
+// New returns a machine with program loaded at CodeAddr and the CPU reset
+// to run it, drawing the random bytes from r.
+func New(program []byte, r *rand.Rand) (*Machine, error) {
+	if int(CodeAddr)+len(program) > 0x10000 {
+		return nil, fmt.Errorf("easy6502: program of %d bytes overflows the memory", len(program))
+	}
+	m := &Machine{Bus: bus.NewWithBackend(&memory.Memory{})}
+	if err := m.Bus.Map(RandomAddr, RandomAddr, rng.New(r)); err != nil {
+		return nil, fmt.Errorf("easy6502: mapping memory: %w", err)
+	}
+	for i, b := range program {
+		m.Bus.Write(b, CodeAddr+uint16(i))
+	}
+	m.CPU = cpu.New(m.Bus)
+	m.Reset()
+	return m, nil
+}
+
+// Reset resets the CPU, starting it at CodeAddr.
+func (m *Machine) Reset() {
+	m.CPU.Reset()
+	r := m.CPU.Registers()
+	r.PC = CodeAddr
+	m.CPU.SetRegisters(r)
+}
+
+// SetKey stores the ASCII code of the key pressed at KeyAddr.
+func (m *Machine) SetKey(key byte) {
+	m.Bus.Write(key, KeyAddr)
+}
+
+// Pixel returns the color index of the pixel at x, y.
+func (m *Machine) Pixel(x, y int) byte {
+	return m.Bus.Peek(ScreenAddr+uint16(y*ScreenSize+x)) & 0x0F
+}
+
+// Screen returns an image of the display, a pixel for each.
+func (m *Machine) Screen() *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, ScreenSize, ScreenSize), Palette)
+	for y := range ScreenSize {
+		for x := range ScreenSize {
+			img.SetColorIndex(x, y, m.Pixel(x, y))
+		}
+	}
+	return img
+}
+
+// Render draws the display on a terminal supporting 24-bit colors, from
+// the top left corner, two rows of pixels by line of text.
+func (m *Machine) Render(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("\x1b[H")
+	for y := 0; y < ScreenSize; y += 2 {
+		for x := range ScreenSize {
+			top := Palette[m.Pixel(x, y)].(color.RGBA)
+			bottom := Palette[m.Pixel(x, y+1)].(color.RGBA)
+			fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		sb.WriteString("\x1b[0m\r\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
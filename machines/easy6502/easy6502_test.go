@@ -0,0 +1,107 @@
+package easy6502
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/machines"
+)
+
+func newTestMachine(t *testing.T, program []byte) *Machine {
+	t.Helper()
+	m, err := New(program, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return m
+}
+
+func TestEasy6502Program(t *testing.T) {
+	// 0600  LDA #$07
+	m := newTestMachine(t, []byte{0xA9, 0x07})
+
+	if err := m.CPU.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r := m.CPU.Registers(); r.A != 0x07 || r.PC != CodeAddr+2 {
+		t.Errorf("expected A $07 and PC $0602, actual %+v\n", r)
+	}
+}
+
+func TestEasy6502Random(t *testing.T) {
+	m := newTestMachine(t, nil)
+	rng := rand.New(rand.NewSource(1))
+
+	for range 16 {
+		expected := byte(rng.Intn(0x100))
+		if actual := m.Bus.Read(RandomAddr); actual != expected {
+			t.Errorf("expected $%02X, actual $%02X\n", expected, actual)
+		}
+	}
+	if actual := m.Bus.Peek(RandomAddr); actual != 0 {
+		t.Errorf("expected peeking to draw nothing, actual $%02X\n", actual)
+	}
+}
+
+func TestEasy6502Key(t *testing.T) {
+	m := newTestMachine(t, nil)
+
+	m.SetKey('d')
+
+	if actual := m.Bus.Read(KeyAddr); actual != 'd' {
+		t.Errorf("expected %q, actual %q\n", 'd', actual)
+	}
+}
+
+func TestEasy6502Screen(t *testing.T) {
+	m := newTestMachine(t, nil)
+	m.Bus.Write(0x01, ScreenAddr)
+	m.Bus.Write(0xF5, ScreenAddr+ScreenSize*ScreenSize-1)
+
+	img := m.Screen()
+	if actual := img.ColorIndexAt(0, 0); actual != 1 {
+		t.Errorf("expected white at 0,0, actual %d\n", actual)
+	}
+	if actual := img.ColorIndexAt(31, 31); actual != 5 {
+		t.Errorf("expected green at 31,31 from the low 4 bits, actual %d\n", actual)
+	}
+
+	var out bytes.Buffer
+	if err := m.Render(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "\x1b[H\x1b[38;2;255;255;255m\x1b[48;2;0;0;0m▀") {
+		t.Errorf("unexpected rendering %q\n", out.String()[:40])
+	}
+	if lines := strings.Count(out.String(), "\r\n"); lines != ScreenSize/2 {
+		t.Errorf("expected %d lines, actual %d\n", ScreenSize/2, lines)
+	}
+}
+
+func TestEasy6502ProgramTooLarge(t *testing.T) {
+	if _, err := New(make([]byte, 0xFA01), rand.New(rand.NewSource(1))); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func TestEasy6502Registered(t *testing.T) {
+	s, err := machines.New("easy6502", machines.Config{
+		Images:  map[string][]byte{"program": {0xA9, 0x07}},
+		Options: map[string]string{"seed": "1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.CPU.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r := s.CPU.Registers(); r.A != 0x07 {
+		t.Errorf("expected A $07, actual %+v\n", r)
+	}
+	if _, ok := s.Machine.(*Machine); !ok {
+		t.Errorf("expected an easy6502 machine, actual %T\n", s.Machine)
+	}
+}
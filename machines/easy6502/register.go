@@ -0,0 +1,37 @@
+package easy6502
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/leakedmemory/mos6502/machines"
+)
+
+func init() {
+	machines.Register(machines.Info{
+		Name:        "easy6502",
+		Description: "easy6502 simulator with its 32x32 display",
+		Images: map[string]string{
+			"program": "program assembled for $0600",
+		},
+		Options: map[string]string{
+			"seed": "seed of the random bytes at $FE",
+		},
+		New: func(cfg machines.Config) (*machines.System, error) {
+			seed := time.Now().UnixNano()
+			if s, ok := cfg.Options["seed"]; ok {
+				var err error
+				if seed, err = strconv.ParseInt(s, 0, 64); err != nil {
+					return nil, fmt.Errorf("easy6502: invalid seed %q", s)
+				}
+			}
+			m, err := New(cfg.Images["program"], rand.New(rand.NewSource(seed)))
+			if err != nil {
+				return nil, err
+			}
+			return &machines.System{CPU: m.CPU, Bus: m.Bus, Machine: m}, nil
+		},
+	})
+}
@@ -0,0 +1,181 @@
+// Package kim1 composes the KIM-1 of MOS Technology: 1 KiB of RAM, and the
+// two 6530 RRIOTs, each with a ROM, 64 bytes of RAM, two ports and a timer,
+// wiring the seven-segment display and the keypad to the 6530-002.
+//
+// The 6530s are emulated with the 6532 RIOTs of package riot6532, behind
+// the register layout of the 6530. The ROM images are not included: they
+// are given to New, read from files such as those distributed with other
+// emulators.
+//
+//	m, err := kim1.New(kim1.Config{Monitor: rom002, Tape: rom003})
+//	...
+//	m.Reset()
+//	m.CPU.RunFor(1_000_000)
+//	fmt.Println(m.Panel.Text())
+package kim1
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/devices"
+	"github.com/leakedmemory/mos6502/devices/riot6532"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// The memory map.
+const (
+	RAMSize = 0x0400
+	// IO003Addr and IO002Addr are the addresses of the ports and the timers
+	// of the 6530s, and RAM003Addr and RAM002Addr those of their RAMs.
+	IO003Addr  uint16 = 0x1700
+	IO002Addr  uint16 = 0x1740
+	RAM003Addr uint16 = 0x1780
+	RAM002Addr uint16 = 0x17C0
+	// TapeAddr is the address of the ROM of the 6530-003, with the tape
+	// routines, and MonitorAddr that of the 6530-002, with the monitor.
+	TapeAddr     uint16 = 0x1800
+	MonitorAddr  uint16 = 0x1C00
+	ROMSize             = 0x0400
+	ioSize              = 0x40
+	rriotRAMSize        = 0x40
+	// vectorsAddr is where the monitor is mirrored for the CPU to read its
+	// vectors, A13-A15 being left undecoded.
+	vectorsAddr uint16 = 0xFC00
+)
+
+const resetVector uint16 = 0xFFFC
+
+// ErrConfig is returned by New for an invalid Config.
+var ErrConfig = errors.New("kim1: invalid config")
+
+// Config describes a KIM-1.
+type Config struct {
+	// Monitor is the image of the ROM of the 6530-002, of ROMSize bytes.
+	Monitor []byte
+	// Tape is the image of the ROM of the 6530-003, of ROMSize bytes, or
+	// nil to leave it unmapped.
+	Tape []byte
+}
+
+// region is a device mapped by New.
+type region struct {
+	start, end uint16
+	dev        bus.Device
+}
+
+// Machine is a KIM-1.
+type Machine struct {
+	CPU *cpu.CPU
+	Bus *bus.Bus
+	// RRIOT002 drives the display and the keypad, and RRIOT003 is free for
+	// the applications.
+	RRIOT002, RRIOT003 *riot6532.RIOT
+	Panel              *Panel
+
+	clock *devices.Clock
+}
+
+// New returns a KIM-1 as described by cfg. It is to be reset before
+// running.
+func New(cfg Config) (*Machine, error) {
+	if len(cfg.Monitor) != ROMSize {
+		return nil, fmt.Errorf("%w: monitor of %d bytes instead of %d", ErrConfig, len(cfg.Monitor), ROMSize)
+	}
+	if cfg.Tape != nil && len(cfg.Tape) != ROMSize {
+		return nil, fmt.Errorf("%w: tape ROM of %d bytes instead of %d", ErrConfig, len(cfg.Tape), ROMSize)
+	}
+
+	m := &Machine{Bus: bus.New(), Panel: newPanel()}
+	m.CPU = cpu.New(m.Bus)
+	m.RRIOT002 = riot6532.New(nil, panelSegments{m.Panel}, panelSelect{m.Panel})
+	m.RRIOT003 = riot6532.New(nil, nil, nil)
+
+	monitor := bus.ROM(cfg.Monitor)
+	regions := []region{
+		{0x0000, RAMSize - 1, bus.NewRAM(RAMSize)},
+		{IO003Addr, IO003Addr + ioSize - 1, io6530{m.RRIOT003}},
+		{IO002Addr, IO002Addr + ioSize - 1, io6530{m.RRIOT002}},
+		{RAM003Addr, RAM003Addr + rriotRAMSize - 1, m.RRIOT003.RAM()},
+		{RAM002Addr, RAM002Addr + rriotRAMSize - 1, m.RRIOT002.RAM()},
+		{MonitorAddr, MonitorAddr + ROMSize - 1, monitor},
+		{vectorsAddr, vectorsAddr + (ROMSize - 1), monitor},
+	}
+	if cfg.Tape != nil {
+		regions = append(regions, region{TapeAddr, TapeAddr + ROMSize - 1, bus.ROM(cfg.Tape)})
+	}
+	for _, r := range regions {
+		if err := m.Bus.Map(r.start, r.end, r.dev); err != nil {
+			return nil, fmt.Errorf("kim1: mapping memory: %w", err)
+		}
+	}
+	m.clock = devices.NewClock(m.CPU, m.RRIOT002, m.RRIOT003, m.Panel)
+	return m, nil
+}
+
+// Reset resets the CPU and the 6530s, like the RS key, and starts the CPU
+// at the reset vector, in the monitor.
+func (m *Machine) Reset() {
+	m.CPU.Reset()
+	m.RRIOT002.Reset()
+	m.RRIOT003.Reset()
+	r := m.CPU.Registers()
+	r.PC = memory.ReadWord(m.Bus, resetVector)
+	m.CPU.SetRegisters(r)
+	m.clock.Sync()
+}
+
+// Stop interrupts the CPU with an NMI, like the ST key. The monitor handles
+// it once its vector at $17FA is set to $1C00.
+func (m *Machine) Stop() {
+	m.CPU.NMI()
+}
+
+// Offsets of the registers of a 6530.
+const (
+	io6530Timer   uint16 = 0x04
+	io6530IRQ     uint16 = 0x08
+	io6530Flags   uint16 = 0x01
+	riotWriteTime uint16 = 0x10
+	riotFlags     uint16 = 0x05
+	riotFlagTimer byte   = 0x80
+)
+
+// io6530 is the I/O and timer of a 6530, laid out as in the 6530: the timer
+// is written at offsets 4-7, with the prescaler selected by bits 1-0 and its
+// interrupt enabled by bit 3, and read at even offsets from 4, its flag
+// being bit 7 of odd ones.
+type io6530 struct {
+	riot *riot6532.RIOT
+}
+
+func (d io6530) Read(addr uint16) byte {
+	return d.access(addr, d.riot.Read)
+}
+
+func (d io6530) Peek(addr uint16) byte {
+	return d.access(addr, d.riot.Peek)
+}
+
+func (d io6530) access(addr uint16, read func(uint16) byte) byte {
+	addr &= 0x0F
+	switch {
+	case addr&io6530Timer == 0:
+		return read(addr)
+	case addr&io6530Flags == 0:
+		return read(io6530Timer | addr&io6530IRQ)
+	default:
+		return read(riotFlags) & riotFlagTimer
+	}
+}
+
+func (d io6530) Write(val byte, addr uint16) {
+	addr &= 0x0F
+	if addr&io6530Timer == 0 {
+		d.riot.Write(val, addr)
+		return
+	}
+	d.riot.Write(val, riotWriteTime|io6530Timer|addr&(io6530IRQ|0x03))
+}
@@ -0,0 +1,171 @@
+package kim1
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	sad  = IO002Addr
+	padd = IO002Addr + 1
+	sbd  = IO002Addr + 2
+	pbdd = IO002Addr + 3
+)
+
+// testMonitor returns a monitor image looping on LDA #$00 from $1C00, with
+// the reset vector pointing to it.
+func testMonitor() []byte {
+	rom := make([]byte, ROMSize)
+	for i := 0; i < 0x3EE; i += 2 {
+		rom[i] = 0xA9
+	}
+	copy(rom[0x3EE:], []byte{0x20, 0x00, 0x1C})
+	rom[0x3FC], rom[0x3FD] = 0x00, 0x1C
+	return rom
+}
+
+func newTestMachine(t *testing.T) *Machine {
+	t.Helper()
+	m, err := New(Config{Monitor: testMonitor()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.Reset()
+	return m
+}
+
+func TestKIM1Reset(t *testing.T) {
+	m := newTestMachine(t)
+
+	if pc := m.CPU.Registers().PC; pc != MonitorAddr {
+		t.Errorf("expected PC $%04X, actual $%04X\n", MonitorAddr, pc)
+	}
+	if actual := m.Bus.Read(0xFFFD); actual != 0x1C {
+		t.Errorf("expected the monitor mirrored at $FC00, actual $%02X\n", actual)
+	}
+}
+
+func TestKIM1MemoryMap(t *testing.T) {
+	m := newTestMachine(t)
+
+	for _, addr := range []uint16{0x03FF, RAM003Addr, RAM002Addr + 0x3F} {
+		m.Bus.Write(0x42, addr)
+		if actual := m.Bus.Read(addr); actual != 0x42 {
+			t.Errorf("expected RAM at $%04X, actual $%02X\n", addr, actual)
+		}
+	}
+	m.Bus.Write(0x5A, RAM002Addr+0x3A)
+	if actual := m.RRIOT002.RAM().Read(0x3A); actual != 0x5A {
+		t.Errorf("expected the RAM of the 6530-002, actual $%02X\n", actual)
+	}
+}
+
+func TestKIM1Timer(t *testing.T) {
+	m := newTestMachine(t)
+
+	// Start the timer of the 6530-003 at 4 intervals of 8 cycles.
+	m.Bus.Write(4, IO003Addr+0x05)
+	m.RRIOT003.Tick(8)
+	if actual := m.Bus.Read(IO003Addr + 0x06); actual != 3 {
+		t.Errorf("expected timer 3, actual %d\n", actual)
+	}
+	if actual := m.Bus.Read(IO003Addr + 0x07); actual != 0 {
+		t.Errorf("expected no interrupt flag, actual $%02X\n", actual)
+	}
+	m.RRIOT003.Tick(4 * 8)
+	if actual := m.Bus.Peek(IO003Addr + 0x07); actual != 0x80 {
+		t.Errorf("expected the interrupt flag, actual $%02X\n", actual)
+	}
+}
+
+// scan drives a digit as the monitor does: dark segments, the digit
+// selected, then its segments.
+func scan(m *Machine, digit int, segs byte) {
+	m.Bus.Write(0x00, sad)
+	m.Bus.Write(byte(firstDigit+digit)<<1, sbd)
+	m.Bus.Write(segs, sad)
+	m.Panel.Tick(500)
+}
+
+func TestKIM1Display(t *testing.T) {
+	m := newTestMachine(t)
+	m.Bus.Write(0x7F, padd)
+	m.Bus.Write(0x1E, pbdd)
+
+	for d, segs := range []byte{0x06, 0x39, 0x3F, 0x3F, 0x77, 0x6F} {
+		scan(m, d, segs)
+	}
+
+	if text := m.Panel.Text(); text != "1C00 A9" {
+		t.Errorf("expected %q, actual %q\n", "1C00 A9", text)
+	}
+	var out bytes.Buffer
+	if err := m.Panel.Render(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "|_|") {
+		t.Errorf("expected the segments drawn, actual %q\n", out.String())
+	}
+
+	m.Bus.Write(0x00, sad)
+	m.Panel.Tick(persistCycles)
+	if text := m.Panel.Text(); text != "       " {
+		t.Errorf("expected the display dark once no longer scanned, actual %q\n", text)
+	}
+}
+
+func TestKIM1Keypad(t *testing.T) {
+	m := newTestMachine(t)
+	m.Bus.Write(0x00, padd)
+	m.Bus.Write(0x1E, pbdd)
+
+	m.Panel.Press(Key9)
+	m.Panel.Tap(KeyGO)
+	rows := []byte{0x7F, 0x7F &^ 0x04, 0x7F &^ 0x20, 0x7F}
+	for row, expected := range rows {
+		m.Bus.Write(byte(row)<<1, sbd)
+		if actual := m.Bus.Read(sad) & 0x7F; actual != expected {
+			t.Errorf("row %d: expected $%02X, actual $%02X\n", row, expected, actual)
+		}
+	}
+
+	m.Panel.Tick(TapCycles)
+	m.Bus.Write(2<<1, sbd)
+	if actual := m.Bus.Read(sad) & 0x7F; actual != 0x7F {
+		t.Errorf("expected GO released after a tap, actual $%02X\n", actual)
+	}
+	m.Panel.Release(Key9)
+	m.Bus.Write(1<<1, sbd)
+	if actual := m.Bus.Read(sad) & 0x7F; actual != 0x7F {
+		t.Errorf("expected 9 released, actual $%02X\n", actual)
+	}
+}
+
+func TestKeyFor(t *testing.T) {
+	tests := map[byte]Key{'0': Key0, '9': Key9, 'a': KeyA, 'F': KeyF, '/': KeyAD, '*': KeyDA, '+': KeyPlus, 'g': KeyGO, 'p': KeyPC}
+
+	for b, expected := range tests {
+		if actual, ok := KeyFor(b); !ok || actual != expected {
+			t.Errorf("%q: expected %d, actual %d %v\n", b, expected, actual, ok)
+		}
+	}
+	if _, ok := KeyFor('x'); ok {
+		t.Errorf("expected no key for %q", 'x')
+	}
+}
+
+func TestKIM1Config(t *testing.T) {
+	tests := []Config{
+		{},
+		{Monitor: make([]byte, 0x800)},
+		{Monitor: testMonitor(), Tape: make([]byte, 0x10)},
+	}
+
+	for _, cfg := range tests {
+		if _, err := New(cfg); !errors.Is(err, ErrConfig) {
+			t.Errorf("expected %v, actual %v\n", ErrConfig, err)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package kim1
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Key is a key of the keypad. The keys RS and ST are wired to the reset and
+// the NMI of the CPU instead: see Machine.Reset and Machine.Stop.
+type Key int
+
+// The keys, in the order of the matrix: 7 keys by row, on PA0-PA6.
+const (
+	Key0 Key = iota
+	Key1
+	Key2
+	Key3
+	Key4
+	Key5
+	Key6
+	Key7
+	Key8
+	Key9
+	KeyA
+	KeyB
+	KeyC
+	KeyD
+	KeyE
+	KeyF
+	KeyAD
+	KeyDA
+	KeyPlus
+	KeyGO
+	KeyPC
+	numKeys
+)
+
+const (
+	keysPerRow = 7
+	// Digits is the number of digits of the display: 4 of the address and 2
+	// of the data.
+	Digits = 6
+	// firstDigit is the output of the decoder selecting the leftmost digit,
+	// those from 0 selecting the rows of the keypad.
+	firstDigit = 4
+	// persistCycles is how long a segment stays lit once driven, like the
+	// eye sees a multiplexed display.
+	persistCycles = 20_000
+	// TapCycles is how long Tap holds a key, long enough for the monitor to
+	// debounce it.
+	TapCycles = 100_000
+)
+
+// segmentDigits maps the patterns of the segments, a-g on bits 0-6, to the
+// hex digits the monitor shows.
+var segmentDigits = map[byte]byte{
+	0x3F: '0', 0x06: '1', 0x5B: '2', 0x4F: '3', 0x66: '4', 0x6D: '5', 0x7D: '6', 0x07: '7',
+	0x7F: '8', 0x6F: '9', 0x77: 'A', 0x7C: 'B', 0x39: 'C', 0x5E: 'D', 0x79: 'E', 0x71: 'F',
+}
+
+// Panel is the display and the keypad of the KIM-1, wired to the ports of
+// the 6530-002: the segments of the digits, and the columns of the keys, on
+// port A, and the decoder selecting a digit or a row of keys on PB1-PB4.
+type Panel struct {
+	segments, selected byte
+	held               [numKeys]bool
+	// tapped is the number of cycles left until the keys tapped are
+	// released.
+	tapped [numKeys]uint
+
+	now uint
+	// lit is the cycle after which each segment of each digit was last
+	// driven, plus one.
+	lit [Digits][7]uint
+}
+
+func newPanel() *Panel {
+	return &Panel{}
+}
+
+// Press holds k down until Release.
+func (p *Panel) Press(k Key) {
+	p.held[k] = true
+}
+
+// Release releases k.
+func (p *Panel) Release(k Key) {
+	p.held[k], p.tapped[k] = false, 0
+}
+
+// Tap presses k for TapCycles.
+func (p *Panel) Tap(k Key) {
+	p.held[k], p.tapped[k] = true, TapCycles
+}
+
+// Tick counts cycles of the CPU, lighting the segments driven and releasing
+// the keys tapped.
+func (p *Panel) Tick(cycles uint) {
+	p.now += cycles
+	if d := int(p.selected) - firstDigit; d >= 0 && d < Digits {
+		for s := range p.lit[d] {
+			if p.segments&(1<<s) != 0 {
+				p.lit[d][s] = p.now + 1
+			}
+		}
+	}
+	for k, left := range p.tapped {
+		if left == 0 {
+			continue
+		}
+		p.tapped[k] -= min(cycles, left)
+		if p.tapped[k] == 0 {
+			p.held[k] = false
+		}
+	}
+}
+
+// Segments returns the patterns of the segments lit on the digits, from the
+// left, segments a-g on bits 0-6.
+func (p *Panel) Segments() [Digits]byte {
+	var digits [Digits]byte
+	for d, segs := range p.lit {
+		for s, lit := range segs {
+			if lit != 0 && p.now+1-lit < persistCycles {
+				digits[d] |= 1 << s
+			}
+		}
+	}
+	return digits
+}
+
+// Text returns the digits shown, such as "1C00 A9", with the dark ones as
+// spaces and the patterns not of hex digits as '?'.
+func (p *Panel) Text() string {
+	var sb strings.Builder
+	for d, segs := range p.Segments() {
+		if d == 4 {
+			sb.WriteByte(' ')
+		}
+		switch c, ok := segmentDigits[segs]; {
+		case segs == 0:
+			sb.WriteByte(' ')
+		case ok:
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return sb.String()
+}
+
+// Render draws the display on a terminal, with the segments as bars, from
+// the top left corner.
+func (p *Panel) Render(w io.Writer) error {
+	var rows [3]strings.Builder
+	for d, segs := range p.Segments() {
+		if d == 4 {
+			for r := range rows {
+				rows[r].WriteString("  ")
+			}
+		}
+		bar := func(s int, c string) string {
+			if segs&(1<<s) != 0 {
+				return c
+			}
+			return " "
+		}
+		rows[0].WriteString(" " + bar(0, "_") + "  ")
+		rows[1].WriteString(bar(5, "|") + bar(6, "_") + bar(1, "|") + " ")
+		rows[2].WriteString(bar(4, "|") + bar(3, "_") + bar(2, "|") + " ")
+	}
+	_, err := fmt.Fprintf(w, "\x1b[H%s\r\n%s\r\n%s\r\n", rows[0].String(), rows[1].String(), rows[2].String())
+	return err
+}
+
+// KeyFor returns the key of the keypad typed as b on the keyboard of the
+// host, as on a numeric keypad: the hex digits, '/' for AD, '*' for DA, '+'
+// for +, 'g' for GO and 'p' for PC.
+func KeyFor(b byte) (Key, bool) {
+	switch {
+	case b >= '0' && b <= '9':
+		return Key0 + Key(b-'0'), true
+	case b >= 'a' && b <= 'f':
+		return KeyA + Key(b-'a'), true
+	case b >= 'A' && b <= 'F':
+		return KeyA + Key(b-'A'), true
+	}
+	switch b {
+	case '/':
+		return KeyAD, true
+	case '*':
+		return KeyDA, true
+	case '+':
+		return KeyPlus, true
+	case 'g', 'G':
+		return KeyGO, true
+	case 'p', 'P':
+		return KeyPC, true
+	}
+	return 0, false
+}
+
+// columns returns the levels of PA0-PA6 read on the row of keys selected,
+// low for the keys down. PA0 reads high on row 3, the TTY jumper being open
+// for the keypad, and PA7, the serial input of the TTY, idles high.
+func (p *Panel) columns() byte {
+	in := byte(0xFF)
+	if int(p.selected) < int(numKeys)/keysPerRow {
+		for k, down := range p.held[int(p.selected)*keysPerRow:][:keysPerRow] {
+			if down {
+				in &^= 1 << k
+			}
+		}
+	}
+	return in
+}
+
+// panelSegments is port A of the 6530-002.
+type panelSegments struct {
+	p *Panel
+}
+
+func (s panelSegments) Input() byte {
+	return s.p.columns()
+}
+
+func (s panelSegments) Output(val, ddr byte) {
+	s.p.segments = val & ddr & 0x7F
+}
+
+// panelSelect is port B of the 6530-002.
+type panelSelect struct {
+	p *Panel
+}
+
+func (s panelSelect) Input() byte {
+	return 0xFF
+}
+
+func (s panelSelect) Output(val, ddr byte) {
+	s.p.selected = val & ddr >> 1 & 0x0F
+}
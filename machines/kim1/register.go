@@ -0,0 +1,21 @@
+package kim1
+
+import "github.com/leakedmemory/mos6502/machines"
+
+func init() {
+	machines.Register(machines.Info{
+		Name:        "kim1",
+		Description: "KIM-1 with its keypad and LED display",
+		Images: map[string]string{
+			"monitor": "ROM of the 6530-002, of 1 KiB",
+			"tape":    "ROM of the 6530-003, of 1 KiB",
+		},
+		New: func(cfg machines.Config) (*machines.System, error) {
+			m, err := New(Config{Monitor: cfg.Images["monitor"], Tape: cfg.Images["tape"]})
+			if err != nil {
+				return nil, err
+			}
+			return &machines.System{CPU: m.CPU, Bus: m.Bus, Machine: m}, nil
+		},
+	})
+}
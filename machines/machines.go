@@ -0,0 +1,128 @@
+// Package machines is the registry of the machines composed in its
+// subpackages, such as apple1, for programs to create them by name. A
+// subpackage registers its machine when imported, and package all imports
+// them all:
+//
+//	import _ "github.com/leakedmemory/mos6502/machines/all"
+//	...
+//	s, err := machines.New("apple1", machines.Config{
+//		Images: map[string][]byte{"monitor": monitor},
+//		Input:  os.Stdin,
+//		Output: os.Stdout,
+//	})
+//	...
+//	s.Reset()
+//	s.CPU.Run()
+package machines
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/internal/registry"
+)
+
+// ErrUnknownMachine is returned by New for a name no machine was registered
+// with.
+var ErrUnknownMachine = errors.New("machines: unknown machine")
+
+// ErrUnknownOption is returned by New for an option or an image the machine
+// does not take.
+var ErrUnknownOption = errors.New("machines: unknown option")
+
+// Machine is a machine of a subpackage, such as *apple1.Machine.
+type Machine interface {
+	// Reset resets the machine, like its reset button, starting the CPU at
+	// the program it runs.
+	Reset()
+}
+
+// System is a machine created by New, with its CPU and its bus.
+type System struct {
+	// Name is the name the machine was created by.
+	Name string
+	CPU  *cpu.CPU
+	Bus  *bus.Bus
+	// Machine is the machine of the subpackage, for what is specific to it,
+	// such as its display.
+	Machine Machine
+}
+
+// Reset resets the machine.
+func (s *System) Reset() {
+	s.Machine.Reset()
+}
+
+// Config is what a registered machine is created with.
+type Config struct {
+	// Images are the ROMs and programs the machine runs, by the names its
+	// Info lists, such as "monitor".
+	Images map[string][]byte
+	// Input gives the keys typed, and Output receives what the machine
+	// prints, for the machines with a terminal. Either can be nil.
+	Input  io.Reader
+	Output io.Writer
+	// Options are settings specific to the machine, by the names its Info
+	// lists.
+	Options map[string]string
+}
+
+// Info describes a registered machine.
+type Info struct {
+	// Name is the name the machine is created by, such as "apple1".
+	Name        string
+	Description string
+	// Images are the names of the images the machine takes, and Options
+	// those of its options, each with a description.
+	Images  map[string]string
+	Options map[string]string
+	// New returns a machine configured by cfg.
+	New func(cfg Config) (*System, error)
+}
+
+var registered = registry.New[Info]("machines")
+
+// Register makes a machine available by its name, usually from the init
+// function of the package composing it. It panics if the name was already
+// registered or New is nil.
+func Register(info Info) {
+	registered.Register(info.Name, info, info.New != nil)
+}
+
+// Lookup returns the machine registered with name, if any.
+func Lookup(name string) (Info, bool) {
+	return registered.Lookup(name)
+}
+
+// Registered returns the names of the machines registered, sorted.
+func Registered() []string {
+	return registered.Names()
+}
+
+// New returns the machine registered with name, configured by cfg. The
+// images and options not listed by its Info are rejected.
+func New(name string, cfg Config) (*System, error) {
+	info, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("machines: %q: %w", name, ErrUnknownMachine)
+	}
+	for key := range cfg.Options {
+		if _, ok := info.Options[key]; !ok {
+			return nil, fmt.Errorf("machines: %s: option %q: %w", name, key, ErrUnknownOption)
+		}
+	}
+	for key := range cfg.Images {
+		if _, ok := info.Images[key]; !ok {
+			return nil, fmt.Errorf("machines: %s: image %q: %w", name, key, ErrUnknownOption)
+		}
+	}
+	s, err := info.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("machines: %s: %w", name, err)
+	}
+	s.Name = name
+	return s, nil
+}
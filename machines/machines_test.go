@@ -0,0 +1,71 @@
+package machines
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+type testMachine struct {
+	cfg    Config
+	resets int
+}
+
+func (m *testMachine) Reset() {
+	m.resets++
+}
+
+func TestRegistry(t *testing.T) {
+	Register(Info{
+		Name:    "test-machine",
+		Images:  map[string]string{"rom": "ROM"},
+		Options: map[string]string{"speed": "speed"},
+		New: func(cfg Config) (*System, error) {
+			if cfg.Options["speed"] == "bad" {
+				return nil, errors.New("bad speed")
+			}
+			b := bus.New()
+			return &System{CPU: cpu.New(b), Bus: b, Machine: &testMachine{cfg: cfg}}, nil
+		},
+	})
+
+	s, err := New("test-machine", Config{
+		Images:  map[string][]byte{"rom": {0xEA}},
+		Options: map[string]string{"speed": "fast"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.Reset()
+	m, ok := s.Machine.(*testMachine)
+	if !ok || s.Name != "test-machine" || m.resets != 1 || m.cfg.Images["rom"][0] != 0xEA {
+		t.Errorf("expected a reset test machine with its ROM, actual %+v\n", s)
+	}
+	if names := Registered(); !slices.Contains(names, "test-machine") {
+		t.Errorf("expected the test machine among %v\n", names)
+	}
+
+	for _, cfg := range []Config{
+		{Options: map[string]string{"colour": "red"}},
+		{Images: map[string][]byte{"basic": nil}},
+	} {
+		if _, err := New("test-machine", cfg); !errors.Is(err, ErrUnknownOption) {
+			t.Errorf("expected %v, actual %v\n", ErrUnknownOption, err)
+		}
+	}
+	if _, err := New("test-machine", Config{Options: map[string]string{"speed": "bad"}}); err == nil {
+		t.Errorf("expected an error")
+	}
+	if _, err := New("missing", Config{}); !errors.Is(err, ErrUnknownMachine) {
+		t.Errorf("expected %v, actual %v\n", ErrUnknownMachine, err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a name twice to panic\n")
+		}
+	}()
+	Register(Info{Name: "test-machine", New: func(Config) (*System, error) { return nil, nil }})
+}
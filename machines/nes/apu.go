@@ -0,0 +1,128 @@
+package nes
+
+import (
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// Registers of the 2A03, by their offset from $4000.
+const (
+	regOAMDMA       = 0x14
+	regStatus       = 0x15
+	regFrameCounter = 0x17
+	apuRegs         = 0x20
+)
+
+// Bits of the registers of the 2A03.
+const (
+	statusFrameIRQ  byte = 0x40
+	frameFiveStep   byte = 0x80
+	frameIRQInhibit byte = 0x40
+)
+
+// Timing of the 2A03, in cycles.
+const (
+	oamDMACycles = 513
+	// fourStepCycles and fiveStepCycles are the lengths of the sequences of
+	// the frame counter.
+	fourStepCycles = 29830
+	fiveStepCycles = 37282
+	// frameIRQCycle is the cycle of the sequence of 4 steps the frame
+	// interrupt is raised at.
+	frameIRQCycle = 29829
+)
+
+// APU is the registers of the 2A03 at $4000-$401F as the CPU sees them: the
+// OAM DMA, and the frame counter with its interrupt, without the sound. The
+// controllers read as nothing pressed. It is made by New.
+type APU struct {
+	cpu *cpu.CPU
+	bus *bus.Bus
+	ppu *PPU
+
+	regs [apuRegs]byte
+	// frame is the cycle of the sequence of the frame counter, and irq its
+	// interrupt flag.
+	frame int
+	irq   bool
+}
+
+func newAPU(c *cpu.CPU, b *bus.Bus, p *PPU) *APU {
+	return &APU{cpu: c, bus: b, ppu: p}
+}
+
+// Reset restarts the frame counter and clears its interrupt, like the reset
+// button.
+func (a *APU) Reset() {
+	a.frame = 0
+	a.setIRQ(false)
+}
+
+// Read returns the register at addr. Reading the status clears the frame
+// interrupt.
+func (a *APU) Read(addr uint16) byte {
+	val := a.Peek(addr)
+	if addr%apuRegs == regStatus {
+		a.setIRQ(false)
+	}
+	return val
+}
+
+// Peek returns the register at addr without side effects.
+func (a *APU) Peek(addr uint16) byte {
+	if addr%apuRegs == regStatus && a.irq {
+		return statusFrameIRQ
+	}
+	return 0
+}
+
+// Write changes the register at addr to val. Writing OAMDMA copies the page
+// val to the OAM, stalling the CPU for 513 cycles, or 514 when the write is
+// on an odd cycle. Writing the frame counter restarts it.
+func (a *APU) Write(val byte, addr uint16) {
+	reg := addr % apuRegs
+	a.regs[reg] = val
+	switch reg {
+	case regOAMDMA:
+		page := uint16(val) << 8
+		for i := range uint16(0x100) {
+			a.ppu.writeOAM(a.bus.Read(page | i))
+		}
+		stall := uint(oamDMACycles)
+		if a.cpu.Cycles()%2 == 1 {
+			stall++
+		}
+		a.bus.StealCycles(stall)
+	case regFrameCounter:
+		a.frame = 0
+		if val&frameIRQInhibit != 0 {
+			a.setIRQ(false)
+		}
+	}
+}
+
+// Tick counts cycles of the CPU, raising the frame interrupt at the end of
+// each sequence of 4 steps.
+func (a *APU) Tick(cycles uint) {
+	ctrl := a.regs[regFrameCounter]
+	period := fourStepCycles
+	if ctrl&frameFiveStep != 0 {
+		period = fiveStepCycles
+	}
+	for left := int(cycles); left != 0; {
+		step := min(left, period-a.frame)
+		if a.frame < frameIRQCycle && a.frame+step >= frameIRQCycle &&
+			ctrl&(frameFiveStep|frameIRQInhibit) == 0 {
+			a.setIRQ(true)
+		}
+		a.frame = (a.frame + step) % period
+		left -= step
+	}
+}
+
+func (a *APU) setIRQ(asserted bool) {
+	if asserted != a.irq {
+		a.irq = asserted
+		a.cpu.SetIRQ(asserted)
+	}
+}
@@ -0,0 +1,107 @@
+// Package nes composes the parts of the NES the CPU sees, enough to run the
+// test ROMs exercising the CPU: the 2 KiB of RAM, mirrored, the registers
+// of the 2A03 with the OAM DMA and the frame counter interrupt, a stub of
+// the PPU keeping the timing of the vertical blank, and the cartridge of an
+// NROM board.
+//
+// The 2A03 is a 6502 without the decimal mode. The CPU used is the 6502 of
+// package cpu, as it does not implement the instructions the decimal mode
+// affects yet.
+//
+//	img, err := loader.ReadINES(f)
+//	...
+//	m, err := nes.New(img)
+//	...
+//	m.Reset()
+//	m.CPU.RunFor(nes.FrameCycles * 60)
+package nes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/devices"
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// The memory map.
+const (
+	RAMSize           = 0x0800
+	ramEnd     uint16 = 0x1FFF
+	PPUAddr    uint16 = 0x2000
+	ppuEnd     uint16 = 0x3FFF
+	APUAddr    uint16 = 0x4000
+	apuEnd     uint16 = 0x401F
+	PRGRAMAddr uint16 = 0x6000
+	PRGRAMSize        = 0x2000
+	PRGROMAddr uint16 = 0x8000
+	prgROMEnd  uint16 = 0xFFFF
+)
+
+const resetVector uint16 = 0xFFFC
+
+// ErrNoPRGROM is returned by New for a cartridge without PRG-ROM.
+var ErrNoPRGROM = errors.New("nes: cartridge without PRG-ROM")
+
+// Machine is the CPU side of an NES.
+type Machine struct {
+	CPU *cpu.CPU
+	Bus *bus.Bus
+	PPU *PPU
+	APU *APU
+	// PRGRAM is the RAM of the cartridge at $6000, where test ROMs, such as
+	// those of blargg, report their results.
+	PRGRAM bus.RAM
+
+	clock *devices.Clock
+}
+
+// New returns an NES running cart, which must use mapper 0. It is to be
+// reset before running.
+func New(cart *loader.INES) (*Machine, error) {
+	if cart.Header.Mapper != 0 {
+		return nil, fmt.Errorf("%w: %d", loader.ErrUnsupportedMapper, cart.Header.Mapper)
+	}
+	if len(cart.PRGROM) == 0 {
+		return nil, ErrNoPRGROM
+	}
+
+	m := &Machine{Bus: bus.New(), PRGRAM: bus.NewRAM(PRGRAMSize)}
+	m.CPU = cpu.New(m.Bus)
+	m.Bus.SetStallFunc(m.CPU.Stall)
+	m.PPU = newPPU(m.CPU.NMI)
+	m.APU = newAPU(m.CPU, m.Bus, m.PPU)
+
+	for _, r := range []struct {
+		start, end uint16
+		dev        bus.Device
+	}{
+		{0x0000, ramEnd, bus.NewRAM(RAMSize)},
+		{PPUAddr, ppuEnd, m.PPU},
+		{APUAddr, apuEnd, m.APU},
+		{PRGRAMAddr, PRGRAMAddr + (PRGRAMSize - 1), m.PRGRAM},
+		// A 16 KiB PRG-ROM is mirrored in both halves.
+		{PRGROMAddr, prgROMEnd, bus.ROM(cart.PRGROM)},
+	} {
+		if err := m.Bus.Map(r.start, r.end, r.dev); err != nil {
+			return nil, fmt.Errorf("nes: mapping memory: %w", err)
+		}
+	}
+	m.clock = devices.NewClock(m.CPU, m.PPU, m.APU)
+	return m, nil
+}
+
+// Reset resets the CPU, the PPU and the APU, like the reset button, and
+// starts the CPU at the reset vector.
+func (m *Machine) Reset() {
+	m.CPU.Reset()
+	m.PPU.Reset()
+	m.APU.Reset()
+	r := m.CPU.Registers()
+	r.PC = memory.ReadWord(m.Bus, resetVector)
+	m.CPU.SetRegisters(r)
+	m.clock.Sync()
+}
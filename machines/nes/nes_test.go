@@ -0,0 +1,147 @@
+package nes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/loader"
+)
+
+// resetCycles is the number of cycles of the reset, which the devices see.
+const resetCycles = 7
+
+func testCart(prgSize int) *loader.INES {
+	prg := make([]byte, prgSize)
+	prg[0] = 0xAB
+	// Reset vector to $C000, the start of nestest in automation.
+	prg[prgSize-4], prg[prgSize-3] = 0x00, 0xC0
+	return &loader.INES{Header: loader.INESHeader{PRGROMSize: prgSize}, PRGROM: prg}
+}
+
+func newTestMachine(t *testing.T) *Machine {
+	t.Helper()
+	m, err := New(testCart(0x4000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.Reset()
+	return m
+}
+
+func TestNESMemoryMap(t *testing.T) {
+	m := newTestMachine(t)
+
+	m.Bus.Write(0x42, 0x0001)
+	for _, addr := range []uint16{0x0801, 0x1001, 0x1801} {
+		if actual := m.Bus.Read(addr); actual != 0x42 {
+			t.Errorf("expected RAM mirrored at $%04X, actual $%02X\n", addr, actual)
+		}
+	}
+	for _, addr := range []uint16{0x8000, 0xC000} {
+		if actual := m.Bus.Read(addr); actual != 0xAB {
+			t.Errorf("expected the PRG-ROM mirrored at $%04X, actual $%02X\n", addr, actual)
+		}
+	}
+	m.Bus.Write(0x80, 0x6000)
+	if m.PRGRAM[0] != 0x80 {
+		t.Errorf("expected PRG-RAM at $6000, actual $%02X\n", m.PRGRAM[0])
+	}
+	if pc := m.CPU.Registers().PC; pc != 0xC000 {
+		t.Errorf("expected PC $C000, actual $%04X\n", pc)
+	}
+}
+
+func TestNESOAMDMA(t *testing.T) {
+	m := newTestMachine(t)
+	for i := range 0x100 {
+		m.Bus.Write(byte(i), 0x0200+uint16(i))
+	}
+	m.Bus.Write(0x10, 0x2003)
+
+	// The CPU is on cycle 7 after a reset, an odd one.
+	m.Bus.Write(0x02, 0x4014)
+
+	if m.PPU.OAM[0x10] != 0x00 || m.PPU.OAM[0x0F] != 0xFF {
+		t.Errorf("expected the page copied from OAMADDR $10, actual $%02X $%02X\n", m.PPU.OAM[0x10], m.PPU.OAM[0x0F])
+	}
+	if stall := m.CPU.State().Stall; stall != 514 {
+		t.Errorf("expected a stall of 514 cycles, actual %d\n", stall)
+	}
+
+	if err := m.CPU.Step(); err == nil {
+		t.Fatalf("expected the empty PRG-ROM to fault")
+	}
+	m.Bus.Write(0x02, 0x4014)
+	if stall := m.CPU.State().Stall; stall != 513 {
+		t.Errorf("expected a stall of 513 cycles on an even cycle, actual %d\n", stall)
+	}
+}
+
+func TestNESFrameIRQ(t *testing.T) {
+	m := newTestMachine(t)
+
+	m.APU.Tick(frameIRQCycle - resetCycles - 1)
+	if m.CPU.IRQ() {
+		t.Fatalf("expected no frame interrupt yet")
+	}
+	m.APU.Tick(1)
+	if !m.CPU.IRQ() {
+		t.Fatalf("expected the frame interrupt")
+	}
+	if status := m.Bus.Read(0x4015); status != statusFrameIRQ {
+		t.Errorf("expected status $%02X, actual $%02X\n", statusFrameIRQ, status)
+	}
+	if m.CPU.IRQ() {
+		t.Errorf("expected the frame interrupt cleared by reading the status")
+	}
+
+	m.Bus.Write(frameIRQInhibit, 0x4017)
+	m.APU.Tick(2 * fourStepCycles)
+	if m.CPU.IRQ() {
+		t.Errorf("expected no frame interrupt when inhibited")
+	}
+	m.Bus.Write(frameFiveStep, 0x4017)
+	m.APU.Tick(2 * fiveStepCycles)
+	if m.CPU.IRQ() {
+		t.Errorf("expected no frame interrupt in the sequence of 5 steps")
+	}
+}
+
+func TestNESVBlank(t *testing.T) {
+	m := newTestMachine(t)
+	m.Bus.Write(ctrlNMI, 0x2000)
+
+	m.PPU.Tick(vblankDot/dotsPerCycle - resetCycles - 1)
+	if m.Bus.Peek(0x2002)&statusVBlank != 0 {
+		t.Fatalf("expected no vertical blank yet")
+	}
+	m.PPU.Tick(1)
+	if !m.CPU.NMIPending() {
+		t.Errorf("expected an NMI at the vertical blank")
+	}
+	if status := m.Bus.Read(0x200A); status&statusVBlank == 0 {
+		t.Errorf("expected the vertical blank flag, mirrored, actual $%02X\n", status)
+	}
+	if m.Bus.Peek(0x2002)&statusVBlank != 0 {
+		t.Errorf("expected the flag cleared by reading the status")
+	}
+
+	m.PPU.Tick(FrameCycles + 1)
+	if m.PPU.Frames != 2 {
+		t.Errorf("expected 2 frames, actual %d\n", m.PPU.Frames)
+	}
+}
+
+func TestNESCartridges(t *testing.T) {
+	if _, err := New(testCart(0x8000)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	mapper := testCart(0x4000)
+	mapper.Header.Mapper = 1
+	if _, err := New(mapper); !errors.Is(err, loader.ErrUnsupportedMapper) {
+		t.Errorf("expected %v, actual %v\n", loader.ErrUnsupportedMapper, err)
+	}
+	if _, err := New(&loader.INES{}); !errors.Is(err, ErrNoPRGROM) {
+		t.Errorf("expected %v, actual %v\n", ErrNoPRGROM, err)
+	}
+}
@@ -0,0 +1,136 @@
+package nes
+
+// Timing of the PPU of the NTSC NES, in dots, 3 for each cycle of the CPU.
+const (
+	dotsPerCycle = 3
+	frameDots    = 341 * 262
+	// vblankDot is the dot the vertical blank starts at, the second of
+	// scanline 241, and vblankEndDot the one it ends at, the second of the
+	// pre-render scanline.
+	vblankDot    = 341*241 + 1
+	vblankEndDot = 341*261 + 1
+	// FrameCycles is the number of cycles of the CPU by frame, rounded.
+	FrameCycles = frameDots / dotsPerCycle
+)
+
+// Registers of the PPU, by their offset, mirrored every 8 bytes.
+const (
+	regPPUCtrl   = 0
+	regPPUStatus = 2
+	regOAMAddr   = 3
+	regOAMData   = 4
+	ppuRegs      = 8
+)
+
+// Bits of the registers of the PPU.
+const (
+	ctrlNMI      byte = 0x80
+	statusVBlank byte = 0x80
+)
+
+// PPU is a stub of the 2C02 as the CPU sees it: its registers, the OAM and
+// the vertical blank with its NMI, without rendering. It is made by New.
+type PPU struct {
+	nmi func()
+
+	regs   [ppuRegs]byte
+	status byte
+	// OAM is the object attribute memory, the sprites written by the OAM
+	// DMA and OAMDATA.
+	OAM     [256]byte
+	oamAddr byte
+
+	dot int
+	// Frames is the number of vertical blanks started since power on.
+	Frames uint
+}
+
+func newPPU(nmi func()) *PPU {
+	return &PPU{nmi: nmi}
+}
+
+// Reset clears the control register, like the reset button. The timing of
+// the frames goes on.
+func (p *PPU) Reset() {
+	p.regs[regPPUCtrl] = 0
+}
+
+// Read returns the register at addr. Reading PPUSTATUS clears the flag of
+// the vertical blank.
+func (p *PPU) Read(addr uint16) byte {
+	val := p.Peek(addr)
+	if addr%ppuRegs == regPPUStatus {
+		p.status &^= statusVBlank
+	}
+	return val
+}
+
+// Peek returns the register at addr without side effects.
+func (p *PPU) Peek(addr uint16) byte {
+	switch addr % ppuRegs {
+	case regPPUStatus:
+		return p.status
+	case regOAMData:
+		return p.OAM[p.oamAddr]
+	default:
+		return p.regs[addr%ppuRegs]
+	}
+}
+
+// Write changes the register at addr to val. Enabling the NMI during the
+// vertical blank makes one at once.
+func (p *PPU) Write(val byte, addr uint16) {
+	reg := addr % ppuRegs
+	switch reg {
+	case regPPUCtrl:
+		if val&ctrlNMI != 0 && p.regs[reg]&ctrlNMI == 0 && p.status&statusVBlank != 0 {
+			p.nmi()
+		}
+	case regOAMAddr:
+		p.oamAddr = val
+	case regOAMData:
+		p.OAM[p.oamAddr] = val
+		p.oamAddr++
+	}
+	p.regs[reg] = val
+}
+
+// Tick counts cycles of the CPU, starting and ending the vertical blanks.
+func (p *PPU) Tick(cycles uint) {
+	for left := int(cycles) * dotsPerCycle; left != 0; {
+		next := p.nextEvent()
+		step := min(left, next-p.dot)
+		p.dot += step
+		left -= step
+		switch p.dot {
+		case vblankDot:
+			p.status |= statusVBlank
+			p.Frames++
+			if p.regs[regPPUCtrl]&ctrlNMI != 0 {
+				p.nmi()
+			}
+		case vblankEndDot:
+			p.status &^= statusVBlank
+		case frameDots:
+			p.dot = 0
+		}
+	}
+}
+
+// nextEvent returns the next dot the state of the PPU changes at.
+func (p *PPU) nextEvent() int {
+	switch {
+	case p.dot < vblankDot:
+		return vblankDot
+	case p.dot < vblankEndDot:
+		return vblankEndDot
+	default:
+		return frameDots
+	}
+}
+
+// writeOAM writes val to the OAM at OAMADDR, as the OAM DMA does.
+func (p *PPU) writeOAM(val byte) {
+	p.OAM[p.oamAddr] = val
+	p.oamAddr++
+}
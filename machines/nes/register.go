@@ -0,0 +1,29 @@
+package nes
+
+import (
+	"bytes"
+
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/machines"
+)
+
+func init() {
+	machines.Register(machines.Info{
+		Name:        "nes",
+		Description: "CPU side of the NES, with an NROM cartridge",
+		Images: map[string]string{
+			"cart": "iNES image of the cartridge",
+		},
+		New: func(cfg machines.Config) (*machines.System, error) {
+			cart, err := loader.ReadINES(bytes.NewReader(cfg.Images["cart"]))
+			if err != nil {
+				return nil, err
+			}
+			m, err := New(cart)
+			if err != nil {
+				return nil, err
+			}
+			return &machines.System{CPU: m.CPU, Bus: m.Bus, Machine: m}, nil
+		},
+	})
+}
@@ -0,0 +1,56 @@
+package memory
+
+const pageSize = 0x100
+
+// Paged is 64 KiB of memory allocated one 256-byte page at a time, on the
+// first write to each page. Unwritten pages read as zero. It is slower than
+// Memory but much smaller when a program only touches a few pages.
+type Paged struct {
+	pages [memorySize / pageSize]*[pageSize]byte
+}
+
+// Read returns the content from addr in memory.
+func (p *Paged) Read(addr uint16) byte {
+	page := p.pages[addr/pageSize]
+	if page == nil {
+		return 0
+	}
+	return page[addr%pageSize]
+}
+
+// Write changes the content of addr in memory to val.
+func (p *Paged) Write(val byte, addr uint16) {
+	page := p.pages[addr/pageSize]
+	if page == nil {
+		if val == 0 {
+			return
+		}
+		page = new([pageSize]byte)
+		p.pages[addr/pageSize] = page
+	}
+	page[addr%pageSize] = val
+}
+
+// Sparse is 64 KiB of memory storing only the addresses holding a value other
+// than zero. It is the slowest backend and the smallest for programs that
+// touch few scattered addresses.
+type Sparse struct {
+	cells map[uint16]byte
+}
+
+// Read returns the content from addr in memory.
+func (s *Sparse) Read(addr uint16) byte {
+	return s.cells[addr]
+}
+
+// Write changes the content of addr in memory to val.
+func (s *Sparse) Write(val byte, addr uint16) {
+	if val == 0 {
+		delete(s.cells, addr)
+		return
+	}
+	if s.cells == nil {
+		s.cells = make(map[uint16]byte)
+	}
+	s.cells[addr] = val
+}
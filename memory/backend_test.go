@@ -0,0 +1,47 @@
+package memory
+
+import "testing"
+
+func TestBackends(t *testing.T) {
+	for name, mem := range map[string]ReadWriter{
+		"flat":   &Memory{},
+		"paged":  &Paged{},
+		"sparse": &Sparse{},
+	} {
+		mem.Write(0x42, 0x0000)
+		mem.Write(0x43, 0xFFFF)
+		mem.Write(0x00, 0x8000)
+
+		if mem.Read(0x0000) != 0x42 || mem.Read(0xFFFF) != 0x43 || mem.Read(0x8000) != 0x00 || mem.Read(0x1234) != 0x00 {
+			t.Errorf("%s: unexpected content", name)
+		}
+
+		mem.Write(0x00, 0x0000)
+		if mem.Read(0x0000) != 0x00 {
+			t.Errorf("%s: expected $0000 cleared", name)
+		}
+	}
+}
+
+func TestPagedAllocatesOnWrite(t *testing.T) {
+	p := Paged{}
+	p.Write(0x00, 0x1000)
+	p.Write(0x01, 0x2000)
+
+	if p.pages[0x10] != nil {
+		t.Errorf("expected writing zero to an empty page not to allocate it")
+	}
+	if p.pages[0x20] == nil {
+		t.Errorf("expected page $20 to be allocated")
+	}
+}
+
+func TestSparseDropsZeroes(t *testing.T) {
+	s := Sparse{}
+	s.Write(0x01, 0x1000)
+	s.Write(0x00, 0x1000)
+
+	if len(s.cells) != 0 {
+		t.Errorf("expected no stored cells, actual %d\n", len(s.cells))
+	}
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const dumpRowSize = 16
+
+// Labeler names addresses, such as a symbols.Table does.
+type Labeler interface {
+	Label(addr uint16) (string, bool)
+}
+
+// Areas with a fixed purpose on every 6502 system.
+var knownRegions = []struct {
+	start uint16
+	name  string
+}{
+	{0x0000, "zero page"},
+	{0x0100, "stack"},
+	{0x0200, ""},
+	{0xFFFA, "vectors: NMI $FFFA, RESET $FFFC, IRQ/BRK $FFFE"},
+}
+
+// DumpHex writes the content of the addresses from start to end, inclusive,
+// as a hex dump with 16 bytes and their ASCII rendering per line.
+//
+// If labels is not nil the dump is annotated: a line naming each labelled
+// address precedes the bytes starting at it, and comment lines mark where the
+// zero page, the stack and the interrupt vectors begin.
+func DumpHex(w io.Writer, r Reader, start, end uint16, labels Labeler) error {
+	d := dumper{w: w}
+	row := make([]byte, 0, dumpRowSize)
+	rowStart := start
+	for addr := int(start); addr <= int(end); addr++ {
+		a := uint16(addr)
+		if labels != nil {
+			if header := regionHeader(a, addr == int(start)); header != "" || hasLabel(labels, a) {
+				d.row(rowStart, row)
+				row, rowStart = row[:0], a
+				if header != "" {
+					d.printf("; %s\n", header)
+				}
+				if label, ok := labels.Label(a); ok {
+					d.printf("%s:\n", label)
+				}
+			}
+		}
+		row = append(row, r.Read(a))
+		if len(row) == dumpRowSize || (a+1)%dumpRowSize == 0 {
+			d.row(rowStart, row)
+			row, rowStart = row[:0], a+1
+		}
+	}
+	d.row(rowStart, row)
+	return d.err
+}
+
+func hasLabel(labels Labeler, addr uint16) bool {
+	_, ok := labels.Label(addr)
+	return ok
+}
+
+// regionHeader returns the comment announcing the known region starting at
+// addr, or the one addr is in if it is the first address dumped.
+func regionHeader(addr uint16, first bool) string {
+	header := ""
+	for _, r := range knownRegions {
+		if addr == r.start {
+			return r.name
+		}
+		if first && addr > r.start {
+			header = r.name
+		}
+	}
+	return header
+}
+
+type dumper struct {
+	w   io.Writer
+	err error
+}
+
+func (d *dumper) printf(format string, args ...any) {
+	if d.err != nil {
+		return
+	}
+	if _, err := fmt.Fprintf(d.w, format, args...); err != nil {
+		d.err = fmt.Errorf("memory: writing dump: %w", err)
+	}
+}
+
+func (d *dumper) row(addr uint16, row []byte) {
+	if len(row) == 0 {
+		return
+	}
+	// Rows cut short by a label keep their columns aligned.
+	pad := int(addr % dumpRowSize)
+	var hex, ascii strings.Builder
+	hex.WriteString(strings.Repeat("   ", pad))
+	ascii.WriteString(strings.Repeat(" ", pad))
+	for _, b := range row {
+		fmt.Fprintf(&hex, " %02X", b)
+		if b >= 0x20 && b < 0x7F {
+			ascii.WriteByte(b)
+		} else {
+			ascii.WriteByte('.')
+		}
+	}
+	d.printf("%04X %-48s  |%-16s|\n", addr, hex.String(), ascii.String())
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+)
+
+type labels map[uint16]string
+
+func (l labels) Label(addr uint16) (string, bool) {
+	name, ok := l[addr]
+	return name, ok
+}
+
+func TestDumpHex(t *testing.T) {
+	mem := Memory{}
+	copy(mem[0x1000:], "Hello, 6502!\x00\x01")
+
+	var out strings.Builder
+	if err := DumpHex(&out, &mem, 0x1000, 0x1017, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "" +
+		"1000  48 65 6C 6C 6F 2C 20 36 35 30 32 21 00 01 00 00  |Hello, 6502!....|\n" +
+		"1010  00 00 00 00 00 00 00 00                          |........        |\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, out.String())
+	}
+}
+
+func TestDumpHexAnnotated(t *testing.T) {
+	mem := Memory{}
+
+	var out strings.Builder
+	err := DumpHex(&out, &mem, 0x00F0, 0x0113, labels{0x00F4: "ptr", 0x0110: "buf"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "" +
+		"; zero page\n" +
+		"00F0  00 00 00 00                                      |....            |\n" +
+		"ptr:\n" +
+		"00F4              00 00 00 00 00 00 00 00 00 00 00 00  |    ............|\n" +
+		"; stack\n" +
+		"0100  00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  |................|\n" +
+		"buf:\n" +
+		"0110  00 00 00 00                                      |....            |\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, out.String())
+	}
+}
+
+func TestDumpHexVectors(t *testing.T) {
+	mem := Memory{}
+
+	var out strings.Builder
+	if err := DumpHex(&out, &mem, 0xFFF8, 0xFFFF, labels{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "; vectors") || !strings.HasPrefix(out.String(), "FFF8") {
+		t.Errorf("unexpected dump\n%s\n", out.String())
+	}
+}
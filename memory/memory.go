@@ -10,6 +10,22 @@ const memorySize uint = 64 * 1024
 //nolint:godox
 type Memory [memorySize]byte
 
+// Reader is the interface that wraps the basic Read method.
+type Reader interface {
+	Read(addr uint16) byte
+}
+
+// Writer is the interface that wraps the basic Write method.
+type Writer interface {
+	Write(val byte, addr uint16)
+}
+
+// ReadWriter is the interface that groups the basic Read and Write methods.
+type ReadWriter interface {
+	Reader
+	Writer
+}
+
 // Write changes the content of addr in memory to val.
 func (m *Memory) Write(val byte, addr uint16) {
 	m[addr] = val
@@ -0,0 +1,70 @@
+package memory
+
+import "fmt"
+
+// Snapshot is a copy of a range of memory taken at some point in time.
+type Snapshot struct {
+	Start uint16
+	Data  []byte
+}
+
+// Change is an address whose content differs between two points in time.
+type Change struct {
+	Addr uint16
+	Old  byte
+	New  byte
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("$%04X: %02X -> %02X", c.Addr, c.Old, c.New)
+}
+
+// NewSnapshot copies the content of the addresses from start to end,
+// inclusive, out of r.
+func NewSnapshot(r Reader, start, end uint16) Snapshot {
+	s := Snapshot{Start: start, Data: make([]byte, int(end-start)+1)}
+	for i := range s.Data {
+		s.Data[i] = r.Read(start + uint16(i))
+	}
+	return s
+}
+
+// Contains reports whether addr is covered by the snapshot.
+func (s Snapshot) Contains(addr uint16) bool {
+	return int(addr-s.Start) < len(s.Data)
+}
+
+// At returns the content of addr when the snapshot was taken. It panics if
+// the snapshot does not contain addr.
+func (s Snapshot) At(addr uint16) byte {
+	return s.Data[addr-s.Start]
+}
+
+// Diff lists, in address order, the addresses of the snapshot whose content
+// in r is not the one recorded.
+func (s Snapshot) Diff(r Reader) []Change {
+	var changes []Change
+	for i, old := range s.Data {
+		addr := s.Start + uint16(i)
+		if val := r.Read(addr); val != old {
+			changes = append(changes, Change{Addr: addr, Old: old, New: val})
+		}
+	}
+	return changes
+}
+
+// DiffSnapshot lists the addresses present in both snapshots whose content
+// changed from s to later.
+func (s Snapshot) DiffSnapshot(later Snapshot) []Change {
+	var changes []Change
+	for i, old := range s.Data {
+		addr := s.Start + uint16(i)
+		if !later.Contains(addr) {
+			continue
+		}
+		if val := later.At(addr); val != old {
+			changes = append(changes, Change{Addr: addr, Old: old, New: val})
+		}
+	}
+	return changes
+}
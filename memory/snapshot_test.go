@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSnapshotDiff(t *testing.T) {
+	mem := Memory{}
+	mem.Write(0x11, 0x0200)
+	s := NewSnapshot(&mem, 0x0200, 0x02FF)
+
+	mem.Write(0x22, 0x0200)
+	mem.Write(0x33, 0x02FF)
+	mem.Write(0x44, 0x0300)
+
+	expected := []Change{
+		{Addr: 0x0200, Old: 0x11, New: 0x22},
+		{Addr: 0x02FF, Old: 0x00, New: 0x33},
+	}
+	if actual := s.Diff(&mem); !slices.Equal(actual, expected) {
+		t.Errorf("expected %v, actual %v\n", expected, actual)
+	}
+}
+
+func TestSnapshotWholeAddressSpace(t *testing.T) {
+	mem := Memory{}
+	s := NewSnapshot(&mem, 0x0000, 0xFFFF)
+
+	if len(s.Data) != int(memorySize) || !s.Contains(0xFFFF) {
+		t.Errorf("expected the whole address space, actual %d bytes\n", len(s.Data))
+	}
+}
+
+func TestDiffSnapshotOverlap(t *testing.T) {
+	mem := Memory{}
+	before := NewSnapshot(&mem, 0x0000, 0x00FF)
+	mem.Write(0x01, 0x0010)
+	mem.Write(0x02, 0x0080)
+	after := NewSnapshot(&mem, 0x0080, 0x017F)
+
+	expected := []Change{{Addr: 0x0080, Old: 0x00, New: 0x02}}
+	if actual := before.DiffSnapshot(after); !slices.Equal(actual, expected) {
+		t.Errorf("expected %v, actual %v\n", expected, actual)
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	c := Change{Addr: 0xD020, Old: 0x0E, New: 0x00}
+	if c.String() != "$D020: 0E -> 00" {
+		t.Errorf("unexpected format %q\n", c.String())
+	}
+}
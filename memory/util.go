@@ -0,0 +1,47 @@
+package memory
+
+// Fill writes val to every address from start to end, inclusive.
+//
+// Like the other helpers in this file it goes through Read and Write one
+// address at a time, so ROM and memory-mapped devices behind w see the same
+// accesses a program would make.
+func Fill(w Writer, start, end uint16, val byte) {
+	for addr := start; ; addr++ {
+		w.Write(val, addr)
+		if addr == end {
+			return
+		}
+	}
+}
+
+// Copy copies n bytes from src to dst. Overlapping ranges are handled as if
+// the source was read in full before writing. Addresses wrap at $FFFF.
+func Copy(rw ReadWriter, dst, src uint16, n int) {
+	if n <= 0 {
+		return
+	}
+	if dst-src < uint16(n) && dst != src {
+		// dst starts inside the source range: copy backwards so bytes are
+		// read before being overwritten.
+		for i := n - 1; i >= 0; i-- {
+			rw.Write(rw.Read(src+uint16(i)), dst+uint16(i))
+		}
+		return
+	}
+	for i := range n {
+		rw.Write(rw.Read(src+uint16(i)), dst+uint16(i))
+	}
+}
+
+// Compare compares n bytes starting at a with n bytes starting at b and
+// returns the addresses from a that differ: absolute addresses, wrapping
+// around the address space, not offsets.
+func Compare(r Reader, a, b uint16, n int) []uint16 {
+	var diff []uint16
+	for i := range n {
+		if r.Read(a+uint16(i)) != r.Read(b+uint16(i)) {
+			diff = append(diff, a+uint16(i))
+		}
+	}
+	return diff
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"slices"
+	"testing"
+)
+
+// rom ignores writes above its boundary, standing in for a bus with ROM
+// mapped at the top of the address space.
+type rom struct {
+	Memory
+	boundary uint16
+}
+
+func (r *rom) Write(val byte, addr uint16) {
+	if addr < r.boundary {
+		r.Memory.Write(val, addr)
+	}
+}
+
+func TestFill(t *testing.T) {
+	mem := Memory{}
+	Fill(&mem, 0x1000, 0x10FF, 0xEA)
+
+	if mem.Read(0x0FFF) != 0 || mem.Read(0x1000) != 0xEA || mem.Read(0x10FF) != 0xEA || mem.Read(0x1100) != 0 {
+		t.Errorf("fill did not cover exactly $1000-$10FF")
+	}
+}
+
+func TestFillWholeAddressSpace(t *testing.T) {
+	mem := Memory{}
+	Fill(&mem, 0x0000, 0xFFFF, 0x55)
+
+	if mem.Read(0x0000) != 0x55 || mem.Read(0xFFFF) != 0x55 {
+		t.Errorf("fill did not cover the whole address space")
+	}
+}
+
+func TestFillRespectsROM(t *testing.T) {
+	mem := rom{boundary: 0x8000}
+	Fill(&mem, 0x7FFE, 0x8001, 0xFF)
+
+	if mem.Read(0x7FFF) != 0xFF || mem.Read(0x8000) != 0x00 {
+		t.Errorf("fill wrote past ROM boundary")
+	}
+}
+
+func TestCopyOverlapping(t *testing.T) {
+	for _, tc := range []struct {
+		dst, src uint16
+		expected []byte
+	}{
+		{dst: 0x0101, src: 0x0100, expected: []byte{1, 1, 2, 3, 4}},
+		{dst: 0x0100, src: 0x0101, expected: []byte{2, 3, 4, 0, 0}},
+	} {
+		mem := Memory{}
+		for i, b := range []byte{1, 2, 3, 4} {
+			mem.Write(b, 0x0100+uint16(i))
+		}
+
+		Copy(&mem, tc.dst, tc.src, 4)
+
+		actual := mem[0x0100:0x0105]
+		if !slices.Equal(actual, tc.expected) {
+			t.Errorf("expected % X, actual % X\n", tc.expected, actual)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	mem := Memory{}
+	for i, b := range []byte{1, 2, 3, 4} {
+		mem.Write(b, 0x0100+uint16(i))
+		mem.Write(b, 0x0200+uint16(i))
+	}
+	mem.Write(9, 0x0202)
+
+	actual := Compare(&mem, 0x0100, 0x0200, 4)
+
+	expected := []uint16{0x0102}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("expected %04X, actual %04X\n", expected, actual)
+	}
+}
+
+func TestCompareAddresses(t *testing.T) {
+	mem := Memory{}
+	// The bytes from $FFFE wrap around to $0000 and $0001.
+	mem.Write(1, 0xFFFF)
+	mem.Write(1, 0x0001)
+
+	actual := Compare(&mem, 0xFFFE, 0x3000, 4)
+
+	expected := []uint16{0xFFFF, 0x0001}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("expected %04X, actual %04X\n", expected, actual)
+	}
+}
@@ -0,0 +1,25 @@
+package memory
+
+// ReadWord returns the little-endian word stored at addr and addr+1. The
+// second address wraps from $FFFF to $0000.
+func ReadWord(r Reader, addr uint16) uint16 {
+	lo := r.Read(addr)
+	hi := r.Read(addr + 1)
+	return uint16(hi)<<8 | uint16(lo)
+}
+
+// ReadWordPageWrap returns the little-endian word stored at addr and the
+// next address in the same page, reproducing how the 6502 fetches pointers
+// for JMP ($xxFF) and for zero page indirect addressing at $FF.
+func ReadWordPageWrap(r Reader, addr uint16) uint16 {
+	lo := r.Read(addr)
+	hi := r.Read(addr&0xFF00 | (addr+1)&0x00FF)
+	return uint16(hi)<<8 | uint16(lo)
+}
+
+// WriteWord stores val at addr and addr+1, low byte first. The second
+// address wraps from $FFFF to $0000.
+func WriteWord(w Writer, val uint16, addr uint16) {
+	w.Write(byte(val), addr)
+	w.Write(byte(val>>8), addr+1)
+}
@@ -0,0 +1,41 @@
+package memory
+
+import "testing"
+
+func TestReadWriteWord(t *testing.T) {
+	mem := Memory{}
+	WriteWord(&mem, 0xBEEF, 0x1000)
+
+	if mem.Read(0x1000) != 0xEF || mem.Read(0x1001) != 0xBE {
+		t.Errorf("expected little-endian layout")
+	}
+	if actual := ReadWord(&mem, 0x1000); actual != 0xBEEF {
+		t.Errorf("expected BEEF, actual %04X\n", actual)
+	}
+}
+
+func TestWordWrapsAddressSpace(t *testing.T) {
+	mem := Memory{}
+	WriteWord(&mem, 0x1234, 0xFFFF)
+
+	if mem.Read(0xFFFF) != 0x34 || mem.Read(0x0000) != 0x12 {
+		t.Errorf("expected high byte at $0000")
+	}
+	if actual := ReadWord(&mem, 0xFFFF); actual != 0x1234 {
+		t.Errorf("expected 1234, actual %04X\n", actual)
+	}
+}
+
+func TestReadWordPageWrap(t *testing.T) {
+	mem := Memory{}
+	mem.Write(0x34, 0x10FF)
+	mem.Write(0x12, 0x1000)
+	mem.Write(0x56, 0x1100)
+
+	if actual := ReadWordPageWrap(&mem, 0x10FF); actual != 0x1234 {
+		t.Errorf("expected 1234, actual %04X\n", actual)
+	}
+	if actual := ReadWordPageWrap(&mem, 0x00FF); actual != 0x0000 {
+		t.Errorf("expected 0000, actual %04X\n", actual)
+	}
+}
@@ -0,0 +1,242 @@
+// Package metrics counts what a CPU does at run time, the instructions it
+// retires, the cycles it runs, the interrupts it takes and the faults it
+// stops at, for long emulation jobs or fleets of instances to be watched.
+// The counters are published with expvar, or served in the text format of
+// Prometheus.
+//
+//	m := metrics.New(c, "main")
+//	m.Publish()
+//	http.Handle("/metrics", metrics.Handler(m))
+//	...
+//	_, err := c.RunFor(1_000_000)
+//	m.Observe(err)
+//
+// The counters are updated on the goroutine running the CPU, and can be read
+// from any other.
+package metrics
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// Collector counts what a CPU does from the time it is made.
+type Collector struct {
+	name string
+	cpu  *cpu.CPU
+	// last is the cycle count of the CPU when last synced.
+	last  uint
+	start time.Time
+	now   func() time.Time
+
+	instructions atomic.Uint64
+	cycles       atomic.Uint64
+	irqs         atomic.Uint64
+	nmis         atomic.Uint64
+	faults       atomic.Uint64
+	busFaults    atomic.Uint64
+
+	hook          int
+	interruptHook int
+}
+
+// Snapshot is the values of the counters of a Collector at a point in time.
+type Snapshot struct {
+	Name         string `json:"name"`
+	Instructions uint64 `json:"instructions"`
+	Cycles       uint64 `json:"cycles"`
+	IRQs         uint64 `json:"irqs"`
+	NMIs         uint64 `json:"nmis"`
+	// Faults is the number of errors observed, BusFaults the number of them
+	// that are *cpu.BusFault.
+	Faults    uint64 `json:"faults"`
+	BusFaults uint64 `json:"bus_faults"`
+	// Elapsed is the wall time since the Collector was made, and MHz the
+	// cycles run by second of it, in millions.
+	Elapsed time.Duration `json:"elapsed_ns"`
+	MHz     float64       `json:"effective_mhz"`
+}
+
+// New returns a Collector named name counting what c does from now on. The
+// name tells instances apart in the metrics.
+func New(c *cpu.CPU, name string) *Collector {
+	m := &Collector{name: name, cpu: c, last: c.Cycles(), now: time.Now}
+	m.start = m.now()
+	m.hook = c.AddInstructionHook(func(cpu.InstructionEvent) {
+		m.instructions.Add(1)
+		m.sync()
+	})
+	m.interruptHook = c.AddInterruptHook(func(e cpu.InterruptEvent) {
+		if e.Kind == cpu.InterruptNMI {
+			m.nmis.Add(1)
+		} else {
+			m.irqs.Add(1)
+		}
+		m.sync()
+	})
+	return m
+}
+
+// Name returns the name of m.
+func (m *Collector) Name() string {
+	return m.name
+}
+
+// sync adds the cycles run since the last sync.
+func (m *Collector) sync() {
+	now := m.cpu.Cycles()
+	if now < m.last {
+		// The CPU was reset.
+		m.last = now
+	}
+	m.cycles.Add(uint64(now - m.last))
+	m.last = now
+}
+
+// Observe counts err, as returned by Step, Run or RunFor, if it is not nil.
+// It also adds the cycles of stalls, which no hook reports, so it is called
+// on the goroutine running the CPU.
+func (m *Collector) Observe(err error) {
+	m.sync()
+	if err == nil {
+		return
+	}
+	m.faults.Add(1)
+	var fault *cpu.BusFault
+	if errors.As(err, &fault) {
+		m.busFaults.Add(1)
+	}
+}
+
+// Stop stops counting.
+func (m *Collector) Stop() {
+	m.cpu.RemoveInstructionHook(m.hook)
+	m.cpu.RemoveInterruptHook(m.interruptHook)
+}
+
+// Snapshot returns the current values of the counters.
+func (m *Collector) Snapshot() Snapshot {
+	s := Snapshot{
+		Name:         m.name,
+		Instructions: m.instructions.Load(),
+		Cycles:       m.cycles.Load(),
+		IRQs:         m.irqs.Load(),
+		NMIs:         m.nmis.Load(),
+		Faults:       m.faults.Load(),
+		BusFaults:    m.busFaults.Load(),
+		Elapsed:      m.now().Sub(m.start),
+	}
+	if s.Elapsed > 0 {
+		s.MHz = float64(s.Cycles) / s.Elapsed.Seconds() / 1e6
+	}
+	return s
+}
+
+// Publish publishes the snapshots of m with expvar under "mos6502." and its
+// name, served at /debug/vars. Like expvar.Publish, it panics if the name is
+// already published.
+func (m *Collector) Publish() {
+	expvar.Publish("mos6502."+m.name, expvar.Func(func() any { return m.Snapshot() }))
+}
+
+// metric is a metric of the Prometheus exposition.
+type metric struct {
+	name, kind, help string
+	// labels are the labels of the samples other than the emulator, and
+	// value returns the value of the sample with each of them.
+	labels []string
+	value  func(s *Snapshot, label string) float64
+}
+
+var exposition = []metric{
+	{
+		name: "mos6502_instructions_total", kind: "counter",
+		help:  "Instructions retired.",
+		value: func(s *Snapshot, _ string) float64 { return float64(s.Instructions) },
+	},
+	{
+		name: "mos6502_cycles_total", kind: "counter",
+		help:  "Cycles run.",
+		value: func(s *Snapshot, _ string) float64 { return float64(s.Cycles) },
+	},
+	{
+		name: "mos6502_interrupts_total", kind: "counter",
+		help:   "Interrupts taken.",
+		labels: []string{`kind="irq"`, `kind="nmi"`},
+		value: func(s *Snapshot, label string) float64 {
+			if label == `kind="nmi"` {
+				return float64(s.NMIs)
+			}
+			return float64(s.IRQs)
+		},
+	},
+	{
+		name: "mos6502_faults_total", kind: "counter",
+		help:   "Faults the CPU stopped at.",
+		labels: []string{`kind="bus"`, `kind="other"`},
+		value: func(s *Snapshot, label string) float64 {
+			if label == `kind="bus"` {
+				return float64(s.BusFaults)
+			}
+			return float64(s.Faults - s.BusFaults)
+		},
+	},
+	{
+		name: "mos6502_effective_mhz", kind: "gauge",
+		help:  "Cycles run by second of wall time, in millions.",
+		value: func(s *Snapshot, _ string) float64 { return s.MHz },
+	},
+}
+
+// labelEscaper escapes the values of labels.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the metrics of the collectors to w in the text
+// format of Prometheus, labelled with their names as the emulator. The
+// label is not instance, which Prometheus sets to the target scraped.
+func WritePrometheus(w io.Writer, collectors ...*Collector) error {
+	snaps := make([]Snapshot, len(collectors))
+	for i, m := range collectors {
+		snaps[i] = m.Snapshot()
+	}
+	var sb strings.Builder
+	for _, mt := range exposition {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", mt.name, mt.help, mt.name, mt.kind)
+		labels := mt.labels
+		if labels == nil {
+			labels = []string{""}
+		}
+		for i := range snaps {
+			s := &snaps[i]
+			for _, l := range labels {
+				all := fmt.Sprintf(`emulator="%s"`, labelEscaper.Replace(s.Name))
+				if l != "" {
+					all += "," + l
+				}
+				fmt.Fprintf(&sb, "%s{%s} %s\n", mt.name, all, strconv.FormatFloat(mt.value(s, l), 'f', -1, 64))
+			}
+		}
+	}
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("metrics: %w", err)
+	}
+	return nil
+}
+
+// Handler returns an http.Handler serving the metrics of the collectors in
+// the text format of Prometheus, such as at /metrics.
+func Handler(collectors ...*Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WritePrometheus(w, collectors...)
+	})
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newTestCollector returns a collector of a CPU running LDA #$01 twice, then
+// BRK, with the IRQ handler at $0400, whose wall clock advances by a second
+// at every reading.
+func newTestCollector(t *testing.T, name string) (*Collector, *cpu.CPU) {
+	t.Helper()
+	mem := memory.Memory{}
+	for i, b := range []byte{0xA9, 0x01, 0xA9, 0x01} {
+		mem.Write(b, 0x0200+uint16(i))
+	}
+	memory.WriteWord(&mem, 0x0400, 0xFFFE)
+	c := cpu.New(&mem)
+	c.Reset()
+	m := New(c, name)
+	now := time.Unix(0, 0)
+	m.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	m.start = now
+	return m, c
+}
+
+func TestCollector(t *testing.T) {
+	m, c := newTestCollector(t, "test")
+
+	for range 2 {
+		m.Observe(c.Step())
+	}
+	c.SetIRQ(true)
+	m.Observe(c.Step())
+	c.NMI()
+	m.Observe(c.Step())
+	m.Observe(fmt.Errorf("wrapped: %w", &cpu.BusFault{Access: cpu.AccessWrite, Addr: 0x8000}))
+
+	expected := Snapshot{
+		Name:         "test",
+		Instructions: 2,
+		Cycles:       2 + 2 + 7 + 7,
+		IRQs:         1,
+		NMIs:         1,
+		Faults:       1,
+		BusFaults:    1,
+		Elapsed:      time.Second,
+		MHz:          18 / 1e6,
+	}
+	if actual := m.Snapshot(); actual != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+
+	m.Stop()
+	c.SetIRQ(false)
+	m.Observe(c.Step())
+	if actual := m.Snapshot(); actual.Instructions != expected.Instructions {
+		t.Errorf("expected %d instructions once stopped, actual %d\n", expected.Instructions, actual.Instructions)
+	}
+}
+
+func TestCollectorReset(t *testing.T) {
+	m, c := newTestCollector(t, "test")
+	for range 2 {
+		m.Observe(c.Step())
+	}
+
+	c.Reset()
+	m.Observe(c.Step())
+
+	if actual := m.Snapshot().Cycles; actual != 4 {
+		t.Errorf("expected the reset not to count, actual %d cycles\n", actual)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	m, c := newTestCollector(t, "publish")
+	m.Observe(c.Step())
+
+	m.Publish()
+
+	v := expvar.Get("mos6502.publish")
+	if v == nil {
+		t.Fatalf("expected mos6502.publish to be published")
+	}
+	if !strings.Contains(v.String(), `"instructions":1,"cycles":2`) {
+		t.Errorf("unexpected value %s\n", v.String())
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m, c := newTestCollector(t, `a"b`)
+	m.Observe(c.Step())
+	m.Observe(&cpu.InvalidOpcodeError{})
+
+	rec := httptest.NewRecorder()
+	Handler(m).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE mos6502_instructions_total counter\n",
+		`mos6502_instructions_total{emulator="a\"b"} 1` + "\n",
+		`mos6502_interrupts_total{emulator="a\"b",kind="nmi"} 0` + "\n",
+		`mos6502_faults_total{emulator="a\"b",kind="other"} 1` + "\n",
+		`mos6502_effective_mhz{emulator="a\"b"} 0.000002` + "\n",
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("expected %q in\n%s", line, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("unexpected content type %q\n", ct)
+	}
+}
@@ -0,0 +1,208 @@
+package monitor
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// memoryRowSize is the number of bytes on a row of m, and memoryLength
+	// the number shown without an end address.
+	memoryRowSize = 8
+	memoryLength  = 0x40
+	// foundPerRow is the number of addresses on a row of h and c.
+	foundPerRow = 8
+)
+
+// memory shows memory, as hex and text, from the address given or where it
+// last stopped.
+func (m *Monitor) memory(args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("%w: m takes a start and an end address", ErrSyntax)
+	}
+	start := m.next
+	if len(args) > 0 {
+		a, err := m.parseAddr(args[0])
+		if err != nil {
+			return err
+		}
+		start = a
+	}
+	end := int(start) + memoryLength - 1
+	if len(args) == 2 {
+		e, err := m.parseRangeEnd(start, args[1])
+		if err != nil {
+			return err
+		}
+		end = int(e)
+	}
+	end = min(end, 0xFFFF)
+
+	for addr := int(start); addr <= end; addr += memoryRowSize {
+		var hex, text strings.Builder
+		for a := addr; a < addr+memoryRowSize && a <= end; a++ {
+			v := m.mem.Read(uint16(a))
+			fmt.Fprintf(&hex, " %02X", v)
+			if v >= 0x20 && v < 0x7F {
+				text.WriteByte(v)
+			} else {
+				text.WriteByte('.')
+			}
+		}
+		m.printf("%04X %-*s  %s\n", addr, 3*memoryRowSize, hex.String(), text.String())
+	}
+	m.next = uint16(end + 1)
+	return nil
+}
+
+// fill fills a range with a pattern of bytes, repeated.
+func (m *Monitor) fill(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("%w: f takes a start and an end address and bytes", ErrSyntax)
+	}
+	start, end, err := m.parseRange(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	pattern, err := m.parsePattern(args[2:])
+	if err != nil {
+		return err
+	}
+	for addr := int(start); addr <= int(end); addr++ {
+		m.mem.Write(pattern[(addr-int(start))%len(pattern)], uint16(addr))
+	}
+	return nil
+}
+
+// hunt lists the addresses in a range where a pattern of bytes, or of text
+// following a quote, starts.
+func (m *Monitor) hunt(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("%w: h takes a start and an end address and bytes", ErrSyntax)
+	}
+	start, end, err := m.parseRange(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	pattern, err := m.parsePattern(args[2:])
+	if err != nil {
+		return err
+	}
+
+	data := m.read(start, end)
+	var found []uint16
+	for i := 0; i+len(pattern) <= len(data); i++ {
+		if bytes.HasPrefix(data[i:], pattern) {
+			found = append(found, start+uint16(i))
+		}
+	}
+	m.printAddresses(found)
+	return nil
+}
+
+// transfer copies a range to the address given. The ranges may overlap.
+func (m *Monitor) transfer(args []string) error {
+	start, end, dest, err := m.parseCopy("t", args)
+	if err != nil {
+		return err
+	}
+	for i, v := range m.read(start, end) {
+		m.mem.Write(v, dest+uint16(i))
+	}
+	return nil
+}
+
+// compare lists the addresses in a range whose content differs from that of
+// the range at the address given.
+func (m *Monitor) compare(args []string) error {
+	start, end, dest, err := m.parseCopy("c", args)
+	if err != nil {
+		return err
+	}
+	var differ []uint16
+	for i, v := range m.read(start, end) {
+		if m.mem.Read(dest+uint16(i)) != v {
+			differ = append(differ, start+uint16(i))
+		}
+	}
+	m.printAddresses(differ)
+	return nil
+}
+
+// read returns the content of memory from start to end.
+func (m *Monitor) read(start, end uint16) []byte {
+	data := make([]byte, int(end)-int(start)+1)
+	for i := range data {
+		data[i] = m.mem.Read(start + uint16(i))
+	}
+	return data
+}
+
+func (m *Monitor) printAddresses(addrs []uint16) {
+	for i, addr := range addrs {
+		sep := " "
+		if i%foundPerRow == foundPerRow-1 || i == len(addrs)-1 {
+			sep = "\n"
+		}
+		m.printf("%04X%s", addr, sep)
+	}
+}
+
+// parseRange parses the start and end addresses of a range.
+func (m *Monitor) parseRange(from, to string) (uint16, uint16, error) {
+	start, err := m.parseAddr(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := m.parseRangeEnd(start, to)
+	return start, end, err
+}
+
+func (m *Monitor) parseRangeEnd(start uint16, s string) (uint16, error) {
+	end, err := m.parseAddr(s)
+	if err != nil {
+		return 0, err
+	}
+	if end < start {
+		return 0, fmt.Errorf("%w: range ends at $%04X before its start $%04X", ErrSyntax, end, start)
+	}
+	return end, nil
+}
+
+// parseCopy parses the arguments of commands taking a range and a
+// destination, such as t.
+func (m *Monitor) parseCopy(cmd string, args []string) (start, end, dest uint16, err error) {
+	if len(args) != 3 {
+		return 0, 0, 0, fmt.Errorf("%w: %s takes a start and an end address and a destination", ErrSyntax, cmd)
+	}
+	if start, end, err = m.parseRange(args[0], args[1]); err != nil {
+		return 0, 0, 0, err
+	}
+	if dest, err = m.parseAddr(args[2]); err != nil {
+		return 0, 0, 0, err
+	}
+	if int(dest)+int(end-start) > 0xFFFF {
+		return 0, 0, 0, fmt.Errorf("%w: destination $%04X too close to the end of memory", ErrSyntax, dest)
+	}
+	return start, end, dest, nil
+}
+
+// parsePattern parses bytes in hex or, following a quote, text.
+func (m *Monitor) parsePattern(args []string) ([]byte, error) {
+	if text, ok := strings.CutPrefix(strings.Join(args, " "), "'"); ok {
+		if text == "" {
+			return nil, fmt.Errorf("%w: empty text", ErrSyntax)
+		}
+		return []byte(text), nil
+	}
+	pattern := make([]byte, len(args))
+	for i, arg := range args {
+		v, err := parseByte(arg)
+		if err != nil {
+			return nil, err
+		}
+		pattern[i] = v
+	}
+	return pattern, nil
+}
@@ -0,0 +1,779 @@
+// Package monitor implements a machine-language monitor in the style of the
+// Woz Monitor and of the monitors of Commodore machines: a prompt to examine
+// and change memory, disassemble, and run code.
+//
+// Addresses and values are hexadecimal, with an optional $ prefix:
+//
+//	0200                    examine $0200
+//	0200.020F               examine $0200 to $020F
+//	0200: A9 01             deposit bytes from $0200
+//	: 8D 00 10              deposit bytes after the last ones
+//	0200R                   run from $0200, like g 0200
+//	m [start [end]]         show memory as hex and text
+//	f start end xx ...      fill memory with bytes
+//	h start end xx ...      hunt for bytes, or for text after a quote
+//	t start end dest        transfer memory to dest
+//	c start end dest        compare memory with that at dest
+//	d [start [end]]         disassemble
+//	r [A=xx ...]            show or change the registers
+//	g [addr]                run until a breakpoint, an error or an interrupt
+//	z [count]               step through instructions
+//	break [addr [if cond]]  set a breakpoint, or list them
+//	break sp < xx           stop when SP drops below xx, or rises above with >
+//	catch kind [addr]       stop at an irq, nmi or brk, or one handled at addr
+//	delete id               remove a breakpoint
+//	print expr              evaluate an expression
+//	watch [expr]            show an expression at every stop, or list them
+//	unwatch id              remove a watch expression
+//	bt                      show the call stack
+//	source file             run the handlers of a debugging script
+//	x                       leave the monitor
+//
+// With a symbol table, addresses can also be given by name, with an optional
+// leading dot, and disassembly and traces show the names.
+//
+// Expressions are those of package expr, over the registers and flags of the
+// CPU, memory and symbols: mem[$10 + X] & $80. Unlike addresses, their
+// numbers are decimal unless prefixed with $.
+//
+// The monitor tracks the subroutines and interrupt handlers the program
+// enters, and stops it at returns that do not match them, such as an RTS
+// without a JSR or one leaving the stack unbalanced, and at misuses of the
+// stack, such as overflows.
+package monitor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/disasm"
+	"github.com/leakedmemory/mos6502/expr"
+	"github.com/leakedmemory/mos6502/memory"
+	"github.com/leakedmemory/mos6502/script"
+	"github.com/leakedmemory/mos6502/symbols"
+)
+
+const (
+	examineRowSize    = 8
+	disassemblyLength = 16
+	// Steps beyond this count are not listed instruction by instruction.
+	maxListedSteps = 64
+	// runSlice is the number of cycles g runs between checks for Interrupt.
+	runSlice = 100_000
+)
+
+// ErrSyntax is returned for command lines that can not be parsed.
+var ErrSyntax = errors.New("monitor: syntax error")
+
+// errQuit is returned by Exec for the command leaving the monitor.
+var errQuit = errors.New("monitor: quit")
+
+// Monitor runs commands against a CPU and the memory it is connected to.
+type Monitor struct {
+	cpu *cpu.CPU
+	mem memory.ReadWriter
+	out io.Writer
+
+	// next is where examining and depositing continue, and disasm where
+	// disassembling does.
+	next   uint16
+	disasm uint16
+
+	symbols *symbols.Table
+	printer disasm.Printer
+	// breakpoints maps the ids of the breakpoints set from the monitor to
+	// their address and condition.
+	breakpoints map[int]monitorBreakpoint
+	watches     []*expr.Expr
+	// anomaly is the bad return or stack access that stopped the program,
+	// if any: a cpu.CallAnomaly or a cpu.StackMisuse.
+	anomaly fmt.Stringer
+	onFault func(error)
+	scripts []*script.Script
+
+	interrupted atomic.Bool
+}
+
+// New returns a Monitor for c writing its output to out. mem is used to
+// examine and change memory: it should be free of side effects, such as the
+// DebugView of a bus, so that looking at a device does not disturb it. The
+// monitor turns on call tracking and stack checking in c.
+func New(c *cpu.CPU, mem memory.ReadWriter, out io.Writer) *Monitor {
+	pc := c.Registers().PC
+	m := &Monitor{cpu: c, mem: mem, out: out, next: pc, disasm: pc, breakpoints: make(map[int]monitorBreakpoint)}
+	c.TrackCalls(func(a cpu.CallAnomaly) { m.stop(a) })
+	c.CheckStack(func(sm cpu.StackMisuse) { m.stop(sm) })
+	return m
+}
+
+// SetSymbols makes the monitor accept the names in t as addresses and show
+// them in disassembly.
+func (m *Monitor) SetSymbols(t *symbols.Table) {
+	m.symbols = t
+	m.printer.Symbols = t
+}
+
+// ShowCycles makes disassembly show the cycles of the instructions, with
+// their page-crossing and branch penalties.
+func (m *Monitor) ShowCycles(show bool) {
+	m.printer.Cycles = show
+}
+
+// SetTraceFormat sets the format of the traces shown when an instruction
+// fails.
+func (m *Monitor) SetTraceFormat(f *disasm.TraceFormat) {
+	m.printer.TraceFormat = f
+}
+
+// SetFaultHandler makes the monitor call fn when the program stops because an
+// instruction failed, such as to write a crash report.
+func (m *Monitor) SetFaultHandler(fn func(err error)) {
+	m.onFault = fn
+}
+
+// Source loads the debugging script in the file at path, of package script,
+// and attaches its handlers to the CPU. The symbols of the monitor can be
+// used in it.
+func (m *Monitor) Source(path string) error {
+	s, err := script.Load(path, m.lookup)
+	if err != nil {
+		return err
+	}
+	if err := s.Attach(m.cpu, m.mem, m.out); err != nil {
+		return err
+	}
+	m.scripts = append(m.scripts, s)
+	return nil
+}
+
+// Run reads commands from in and executes them until in ends or the x
+// command is given. Errors of the commands are reported on the output.
+func (m *Monitor) Run(in io.Reader) error {
+	sc := bufio.NewScanner(in)
+	for {
+		m.printf("> ")
+		if !sc.Scan() {
+			break
+		}
+		err := m.Exec(sc.Text())
+		if errors.Is(err, errQuit) {
+			return nil
+		}
+		if err != nil {
+			m.printf("? %v\n", err)
+		}
+	}
+	m.printf("\n")
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("monitor: reading commands: %w", err)
+	}
+	return nil
+}
+
+// Interrupt stops the running g or z command after the current instruction.
+// It is safe to call from another goroutine, such as a signal handler.
+func (m *Monitor) Interrupt() {
+	m.interrupted.Store(true)
+}
+
+// Exec executes a single command line.
+func (m *Monitor) Exec(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+
+	fields := strings.Fields(line)
+	cmd, args := strings.ToLower(fields[0]), fields[1:]
+	switch cmd {
+	case "m":
+		return m.memory(args)
+	case "f":
+		return m.fill(args)
+	case "h":
+		return m.hunt(args)
+	case "t":
+		return m.transfer(args)
+	case "c":
+		return m.compare(args)
+	case "d":
+		return m.disassemble(args)
+	case "r":
+		return m.registers(args)
+	case "g":
+		return m.goCommand(args)
+	case "z":
+		return m.step(args)
+	case "break":
+		return m.breakCommand(args)
+	case "catch":
+		return m.catch(args)
+	case "delete":
+		return m.deleteCommand(args)
+	case "print":
+		return m.print(args)
+	case "watch":
+		return m.watch(args)
+	case "unwatch":
+		return m.unwatch(args)
+	case "bt":
+		m.backtrace()
+		return nil
+	case "source":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: source takes a file", ErrSyntax)
+		}
+		return m.Source(args[0])
+	case "x":
+		return errQuit
+	case "?", "help":
+		m.printf("%s", help)
+		return nil
+	}
+	return m.woz(line)
+}
+
+const help = `0200                    examine $0200
+0200.020F               examine $0200 to $020F
+0200: A9 01             deposit bytes from $0200
+: 8D 00 10              deposit bytes after the last ones
+0200R                   run from $0200
+m [start [end]]         show memory as hex and text
+f start end xx ...      fill memory with bytes
+h start end xx ...      hunt for bytes, or for text after a quote
+t start end dest        transfer memory to dest
+c start end dest        compare memory with that at dest
+d [start [end]]         disassemble
+r [A=xx ...]            show or change the registers
+g [addr]                run until a breakpoint, an error or an interrupt
+z [count]               step through instructions
+break [addr [if cond]]  set a breakpoint, or list them
+break sp < xx           stop when SP drops below xx, or rises above with >
+catch kind [addr]       stop at an irq, nmi or brk, or one handled at addr
+delete id               remove a breakpoint
+print expr              evaluate an expression
+watch [expr]            show an expression at every stop, or list them
+unwatch id              remove a watch expression
+bt                      show the call stack
+source file             run the handlers of a debugging script
+x                       leave the monitor
+`
+
+// woz executes the commands of the Woz Monitor, made of addresses.
+func (m *Monitor) woz(line string) error {
+	if addr, data, ok := strings.Cut(line, ":"); ok {
+		return m.deposit(strings.TrimSpace(addr), strings.Fields(data))
+	}
+	if strings.HasSuffix(strings.ToUpper(line), "R") {
+		run := line[:len(line)-1]
+		if _, err := m.parseAddr(run); err == nil {
+			return m.goCommand([]string{run})
+		}
+	}
+	from, to, isRange := cutRange(line)
+	start, err := m.parseAddr(from)
+	if err != nil {
+		return err
+	}
+	end := start
+	if isRange {
+		if end, err = m.parseAddr(to); err != nil {
+			return err
+		}
+	}
+	m.examine(start, end)
+	return nil
+}
+
+func (m *Monitor) examine(start, end uint16) {
+	for addr := int(start); addr <= int(end); addr += examineRowSize {
+		m.printf("%04X:", addr)
+		for a := addr; a < addr+examineRowSize && a <= int(end); a++ {
+			m.printf(" %02X", m.mem.Read(uint16(a)))
+		}
+		m.printf("\n")
+	}
+	m.next = end + 1
+}
+
+func (m *Monitor) deposit(addr string, data []string) error {
+	if addr != "" {
+		a, err := m.parseAddr(addr)
+		if err != nil {
+			return err
+		}
+		m.next = a
+	}
+	vals := make([]byte, len(data))
+	for i, d := range data {
+		v, err := parseByte(d)
+		if err != nil {
+			return err
+		}
+		vals[i] = v
+	}
+	for _, v := range vals {
+		m.mem.Write(v, m.next)
+		m.next++
+	}
+	return nil
+}
+
+func (m *Monitor) disassemble(args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("%w: d takes a start and an end address", ErrSyntax)
+	}
+	start, end := m.disasm, -1
+	if len(args) > 0 {
+		a, err := m.parseAddr(args[0])
+		if err != nil {
+			return err
+		}
+		start = a
+	}
+	if len(args) == 2 {
+		e, err := m.parseAddr(args[1])
+		if err != nil {
+			return err
+		}
+		end = int(e)
+	}
+
+	addr := int(start)
+	for n := 0; end >= 0 && addr <= end || end < 0 && n < disassemblyLength; n++ {
+		inst := disasm.Decode(m.mem, uint16(addr))
+		m.printLabel(inst.Addr)
+		m.printf("%s\n", m.printer.Line(inst))
+		addr += int(inst.Len())
+	}
+	m.disasm = uint16(addr)
+	return nil
+}
+
+func (m *Monitor) registers(args []string) error {
+	if len(args) == 0 {
+		m.printRegisters()
+		return nil
+	}
+	r := m.cpu.Registers()
+	for _, arg := range args {
+		name, val, ok := strings.Cut(strings.ToUpper(arg), "=")
+		if !ok {
+			return fmt.Errorf("%w: expected register=value, got %q", ErrSyntax, arg)
+		}
+		var err error
+		switch name {
+		case "PC":
+			r.PC, err = parseAddr(val)
+		case "A":
+			r.A, err = parseByte(val)
+		case "X":
+			r.X, err = parseByte(val)
+		case "Y":
+			r.Y, err = parseByte(val)
+		case "SP":
+			r.SP, err = parseByte(val)
+		case "SR":
+			r.SR, err = parseByte(val)
+		default:
+			return fmt.Errorf("%w: unknown register %s", ErrSyntax, name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	m.cpu.SetRegisters(r)
+	return nil
+}
+
+func (m *Monitor) printRegisters() {
+	r := m.cpu.Registers()
+	m.printf("  PC  SR AC XR YR SP  NV-BDIZC\n")
+	m.printf(" %04X %02X %02X %02X %02X %02X  %08b\n", r.PC, r.SR, r.A, r.X, r.Y, r.SP, r.SR)
+}
+
+// goCommand runs the CPU, from addr if given, until it reaches a breakpoint,
+// an instruction fails or Interrupt is called.
+func (m *Monitor) goCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("%w: g takes an address", ErrSyntax)
+	}
+	if len(args) == 1 {
+		addr, err := m.parseAddr(args[0])
+		if err != nil {
+			return err
+		}
+		r := m.cpu.Registers()
+		r.PC = addr
+		m.cpu.SetRegisters(r)
+	}
+
+	m.interrupted.Store(false)
+	for {
+		reason, err := m.cpu.RunFor(runSlice)
+		switch reason {
+		case cpu.StopBreakpoint:
+			m.printf("stopped: breakpoint\n")
+		case cpu.StopStackLimit:
+			m.printf("stopped: stack limit, SP $%02X\n", m.cpu.Registers().SP)
+		case cpu.StopInterrupt:
+			m.printf("stopped: interrupt at %s\n", m.describe(m.cpu.Registers().PC))
+		}
+		if reason != cpu.StopCycles || m.interrupted.Load() {
+			m.stopped(err)
+			return nil
+		}
+	}
+}
+
+func (m *Monitor) step(args []string) error {
+	count := 1
+	if len(args) > 1 {
+		return fmt.Errorf("%w: z takes a count", ErrSyntax)
+	}
+	if len(args) == 1 {
+		n, err := strconv.ParseUint(strings.TrimPrefix(args[0], "$"), 16, 32)
+		if err != nil {
+			return fmt.Errorf("%w: invalid count %q", ErrSyntax, args[0])
+		}
+		count = int(n)
+	}
+
+	m.interrupted.Store(false)
+	var err error
+	for n := 0; n < count && !m.interrupted.Load() && m.anomaly == nil; n++ {
+		if count <= maxListedSteps {
+			m.printf("%s\n", m.printer.Line(disasm.Decode(m.mem, m.cpu.Registers().PC)))
+		}
+		if err = m.cpu.Step(); err != nil {
+			break
+		}
+	}
+	m.stopped(err)
+	return nil
+}
+
+// stopped reports why the CPU stopped running, and where. When an
+// instruction failed, the instructions leading to it are shown if the CPU
+// keeps a trace.
+func (m *Monitor) stopped(err error) {
+	switch {
+	case err != nil:
+		m.printf("stopped: %v\n", err)
+		if trace := m.cpu.Trace(); len(trace) != 0 {
+			// Output errors are ignored, as in printf.
+			_ = m.printer.Trace(m.out, trace)
+		}
+		if m.onFault != nil {
+			m.onFault(err)
+		}
+	case m.anomaly != nil:
+		m.printf("stopped: %v\n", m.anomaly)
+	case m.interrupted.Load():
+		m.printf("interrupted\n")
+	}
+	m.anomaly = nil
+	for _, s := range m.scripts {
+		if err := s.Err(); err != nil {
+			m.printf("stopped: %v\n", err)
+		}
+	}
+	m.printRegisters()
+	for _, e := range m.watches {
+		m.printValue(e)
+	}
+	pc := m.cpu.Registers().PC
+	m.next, m.disasm = pc, pc
+}
+
+// stop stops the program at an anomaly reported by the CPU, keeping the
+// first of an instruction.
+func (m *Monitor) stop(anomaly fmt.Stringer) {
+	if m.anomaly == nil {
+		m.anomaly = anomaly
+	}
+	m.cpu.Stop()
+}
+
+// backtrace shows the call stack, the innermost frame first.
+func (m *Monitor) backtrace() {
+	frames := m.cpu.CallStack()
+	for i := len(frames) - 1; i >= 0; i-- {
+		f := frames[i]
+		m.printf("%d  %v %s from %s\n", len(frames)-1-i, f.Kind, m.describe(f.Target), m.describe(f.Site))
+	}
+}
+
+type monitorBreakpoint struct {
+	addr uint16
+	cond string
+	// event is set for breakpoints that are not at an address, such as
+	// "SP < $80".
+	event string
+}
+
+// breakCommand sets a breakpoint at the address given, under a condition
+// following if, or a stack breakpoint, or lists the breakpoints without an
+// address.
+func (m *Monitor) breakCommand(args []string) error {
+	if len(args) == 0 {
+		for _, id := range slices.Sorted(maps.Keys(m.breakpoints)) {
+			b := m.breakpoints[id]
+			if b.event != "" {
+				m.printf("%d  %s\n", id, b.event)
+				continue
+			}
+			m.printf("%d  %s", id, m.describe(b.addr))
+			if b.cond != "" {
+				m.printf(" if %s", b.cond)
+			}
+			m.printf("\n")
+		}
+		return nil
+	}
+	if limit, ok := strings.CutPrefix(strings.ToUpper(strings.Join(args, "")), "SP"); ok && limit != "" {
+		return m.stackBreakpoint(limit)
+	}
+	if len(args) == 2 || len(args) > 2 && !strings.EqualFold(args[1], "if") {
+		return fmt.Errorf("%w: break takes an address and a condition after if", ErrSyntax)
+	}
+
+	addr, err := m.parseAddr(args[0])
+	if err != nil {
+		return err
+	}
+	b := monitorBreakpoint{addr: addr}
+	var id int
+	if len(args) == 1 {
+		id = m.cpu.AddBreakpoint(addr)
+	} else {
+		b.cond = strings.Join(args[2:], " ")
+		cond, err := m.parseExpr(b.cond)
+		if err != nil {
+			return err
+		}
+		if id, err = m.cpu.AddExprBreakpoint(addr, cond); err != nil {
+			return fmt.Errorf("%w: %w", ErrSyntax, err)
+		}
+	}
+	m.breakpoints[id] = b
+	m.printf("breakpoint %d at %s\n", id, m.describe(addr))
+	return nil
+}
+
+// stackBreakpoint sets a stack breakpoint from its limit: "<80" stops when
+// SP drops below $80, and ">F0" when it rises above $F0.
+func (m *Monitor) stackBreakpoint(limit string) error {
+	v, err := parseByte(limit[1:])
+	if err != nil {
+		return err
+	}
+	var id int
+	switch limit[0] {
+	case '<':
+		id = m.cpu.AddStackBreakpoint(v, 0xFF)
+	case '>':
+		id = m.cpu.AddStackBreakpoint(0x00, v)
+	default:
+		return fmt.Errorf("%w: break sp takes < or > and a limit", ErrSyntax)
+	}
+	b := monitorBreakpoint{event: fmt.Sprintf("SP %c $%02X", limit[0], v)}
+	m.breakpoints[id] = b
+	m.printf("breakpoint %d when %s\n", id, b.event)
+	return nil
+}
+
+// interruptKinds maps the interrupts catch takes to their kinds.
+var interruptKinds = map[string]cpu.InterruptKind{
+	"irq": cpu.InterruptIRQ,
+	"nmi": cpu.InterruptNMI,
+	"brk": cpu.InterruptBRK,
+}
+
+// catch sets a breakpoint on the interrupts of a kind, optionally only those
+// whose vector points to the address given.
+func (m *Monitor) catch(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("%w: catch takes irq, nmi or brk and a handler address", ErrSyntax)
+	}
+	kind, ok := interruptKinds[strings.ToLower(args[0])]
+	if !ok {
+		return fmt.Errorf("%w: unknown interrupt %q", ErrSyntax, args[0])
+	}
+	b := monitorBreakpoint{event: strings.ToUpper(args[0])}
+	var id int
+	if len(args) == 1 {
+		id = m.cpu.AddInterruptBreakpoint(kind)
+	} else {
+		handler, err := m.parseAddr(args[1])
+		if err != nil {
+			return err
+		}
+		id = m.cpu.AddHandlerBreakpoint(kind, handler)
+		b.event += " to " + m.describe(handler)
+	}
+	m.breakpoints[id] = b
+	m.printf("breakpoint %d on %s\n", id, b.event)
+	return nil
+}
+
+func (m *Monitor) deleteCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: delete takes a breakpoint id", ErrSyntax)
+	}
+	id, err := strconv.Atoi(args[0])
+	if _, ok := m.breakpoints[id]; err != nil || !ok {
+		return fmt.Errorf("%w: no breakpoint %s", ErrSyntax, args[0])
+	}
+	m.cpu.RemoveBreakpoint(id)
+	delete(m.breakpoints, id)
+	return nil
+}
+
+func (m *Monitor) print(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: print takes an expression", ErrSyntax)
+	}
+	e, err := m.parseExpr(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	m.printValue(e)
+	return nil
+}
+
+// watch adds an expression shown whenever the program stops, or lists them.
+func (m *Monitor) watch(args []string) error {
+	if len(args) == 0 {
+		for i, e := range m.watches {
+			m.printf("%d  %s\n", i, e)
+		}
+		return nil
+	}
+	e, err := m.parseExpr(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	m.watches = append(m.watches, e)
+	m.printValue(e)
+	return nil
+}
+
+func (m *Monitor) unwatch(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: unwatch takes a watch id", ErrSyntax)
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id < 0 || id >= len(m.watches) {
+		return fmt.Errorf("%w: no watch %s", ErrSyntax, args[0])
+	}
+	m.watches = slices.Delete(m.watches, id, id+1)
+	return nil
+}
+
+// printValue shows the value of e in hex, decimal and binary, or why it
+// could not be evaluated.
+func (m *Monitor) printValue(e *expr.Expr) {
+	v, err := e.Eval(m.cpu.Env())
+	if err != nil {
+		m.printf("%s = ? %v\n", e, err)
+		return
+	}
+	m.printf("%s = $%04X  %d  %%%08b\n", e, uint16(v), v, byte(v))
+}
+
+// parseExpr parses an expression, resolving the names of the symbol table.
+func (m *Monitor) parseExpr(s string) (*expr.Expr, error) {
+	e, err := expr.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSyntax, err)
+	}
+	return e.Bind(m.lookup), nil
+}
+
+// lookup returns the address of a symbol, as expressions bind it.
+func (m *Monitor) lookup(name string) (int, bool) {
+	if m.symbols == nil {
+		return 0, false
+	}
+	addr, ok := m.symbols.Lookup(name)
+	return int(addr), ok
+}
+
+// describe formats addr with its name, if it has one.
+func (m *Monitor) describe(addr uint16) string {
+	if m.symbols != nil {
+		if name, ok := m.symbols.Label(addr); ok {
+			return fmt.Sprintf("$%04X (%s)", addr, name)
+		}
+	}
+	return fmt.Sprintf("$%04X", addr)
+}
+
+func (m *Monitor) printLabel(addr uint16) {
+	if m.symbols == nil {
+		return
+	}
+	if name, ok := m.symbols.Label(addr); ok {
+		m.printf("%s:\n", name)
+	}
+}
+
+// parseAddr parses an address in hex or, with a symbol table, by name. A
+// leading dot marks names that would otherwise be taken for hex numbers,
+// such as .add.
+func (m *Monitor) parseAddr(s string) (uint16, error) {
+	s = strings.TrimSpace(s)
+	if m.symbols != nil {
+		name, isName := strings.CutPrefix(s, ".")
+		if _, err := parseAddr(s); err != nil || isName {
+			if addr, ok := m.symbols.Lookup(name); ok {
+				return addr, nil
+			}
+			if isName {
+				return 0, fmt.Errorf("%w: unknown symbol %q", ErrSyntax, name)
+			}
+		}
+	}
+	return parseAddr(s)
+}
+
+// cutRange splits the start and end addresses of a range. The dot
+// separating them is the last one, so that names can start with a dot.
+func cutRange(s string) (string, string, bool) {
+	i := strings.LastIndexByte(s, '.')
+	if i <= 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+1:], true
+}
+
+func (m *Monitor) printf(format string, args ...any) {
+	// The monitor is interactive: a failing output has no one to be
+	// reported to.
+	_, _ = fmt.Fprintf(m.out, format, args...)
+}
+
+func parseAddr(s string) (uint16, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(s), "$"), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid address %q", ErrSyntax, s)
+	}
+	return uint16(v), nil
+}
+
+func parseByte(s string) (byte, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "$"), 16, 8)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid byte %q", ErrSyntax, s)
+	}
+	return byte(v), nil
+}
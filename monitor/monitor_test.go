@@ -0,0 +1,364 @@
+package monitor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+	"github.com/leakedmemory/mos6502/symbols"
+)
+
+func newMonitorTest() (*Monitor, *cpu.CPU, *memory.Memory, *strings.Builder) {
+	mem := &memory.Memory{}
+	c := cpu.New(mem)
+	c.Reset()
+	out := &strings.Builder{}
+	return New(c, mem, out), c, mem, out
+}
+
+func execTestHelper(t *testing.T, m *Monitor, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if err := m.Exec(line); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestMonitorDepositAndExamine(t *testing.T) {
+	m, _, mem, out := newMonitorTest()
+
+	execTestHelper(t, m, "0300: A9 01 02", ": 03 04 05 06 07 08", "0300.0308", "$0301")
+
+	if mem.Read(0x0308) != 0x08 {
+		t.Errorf("expected $08 at $0308, actual $%02X\n", mem.Read(0x0308))
+	}
+	expected := "0300: A9 01 02 03 04 05 06 07\n0308: 08\n0301: 01\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+}
+
+func TestMonitorMemoryCommands(t *testing.T) {
+	m, _, mem, out := newMonitorTest()
+
+	execTestHelper(t, m,
+		"f 0300 030B 48 49 00",
+		"m 0300 030B",
+		"h 0300 030F 49 00",
+		"h 0300 030F 'HI",
+		"t 0300 0305 0301",
+		"c 0300 0305 0400",
+	)
+
+	if v := mem.Read(0x0306); v != 0x00 {
+		t.Errorf("expected the copy to end at $0306, actual $%02X\n", v)
+	}
+	expected := "" +
+		"0300  48 49 00 48 49 00 48 49  HI.HI.HI\n" +
+		"0308  00 48 49 00              .HI.\n" +
+		"0301 0304 0307 030A\n" +
+		"0300 0303 0306 0309\n" +
+		"0300 0301 0302 0304 0305\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+
+	for _, line := range []string{"f 0300 0200 00", "h 0300 0310", "t 0300 0310", "c 0300 0310 FFF8", "m 1 2 3", "f 0 1 '"} {
+		if err := m.Exec(line); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: expected %v, actual %v\n", line, ErrSyntax, err)
+		}
+	}
+}
+
+func TestMonitorDisassemble(t *testing.T) {
+	m, _, _, out := newMonitorTest()
+
+	execTestHelper(t, m, "0200: A9 42 EA", "d 0200 0202")
+
+	expected := "0200  A9 42     LDA #$42\n0202  EA        NOP\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+}
+
+func TestMonitorRegisters(t *testing.T) {
+	m, c, _, out := newMonitorTest()
+
+	execTestHelper(t, m, "r a=3F pc=1234 sp=$F0", "r")
+
+	expected := cpu.Registers{A: 0x3F, SP: 0xF0, PC: 0x1234, SR: 0x20}
+	if actual := c.Registers(); actual != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+	if !strings.Contains(out.String(), " 1234 20 3F 00 00 F0  00100000\n") {
+		t.Errorf("unexpected register display %q\n", out.String())
+	}
+}
+
+func TestMonitorStepAndGo(t *testing.T) {
+	m, c, _, out := newMonitorTest()
+
+	execTestHelper(t, m, "0200: A9 01 A9 80 02", "z")
+	if pc := c.Registers().PC; pc != 0x0202 {
+		t.Errorf("expected PC $0202 after a step, actual $%04X\n", pc)
+	}
+	if !strings.HasPrefix(out.String(), "0200  A9 01     LDA #$01\n") {
+		t.Errorf("expected the stepped instruction to be listed, actual %q\n", out.String())
+	}
+
+	out.Reset()
+	execTestHelper(t, m, "0200R")
+	r := c.Registers()
+	if r.PC != 0x0204 || r.A != 0x80 {
+		t.Errorf("expected to stop at $0204 with A=$80, actual %+v\n", r)
+	}
+	if !strings.HasPrefix(out.String(), "stopped: invalid opcode $02 at $0204\n") {
+		t.Errorf("expected the reason to stop, actual %q\n", out.String())
+	}
+}
+
+func TestMonitorRun(t *testing.T) {
+	m, _, _, out := newMonitorTest()
+
+	if err := m.Run(strings.NewReader("0200: EA\nbogus\nx\n0200: 00\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "> > ? monitor: syntax error: invalid address \"bogus\"\n> "
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+}
+
+func TestMonitorErrors(t *testing.T) {
+	m, _, _, _ := newMonitorTest()
+	for _, line := range []string{"0200: 1FF", "r Q=1", "r a", "d 1 2 3", "z zz"} {
+		if err := m.Exec(line); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: expected %v, actual %v\n", line, ErrSyntax, err)
+		}
+	}
+}
+
+func TestMonitorGoStopsAtBreakpoint(t *testing.T) {
+	m, c, _, out := newMonitorTest()
+	c.AddBreakpoint(0x0202)
+
+	execTestHelper(t, m, "0200: A9 01 A9 80 02", "g")
+
+	if pc := c.Registers().PC; pc != 0x0202 {
+		t.Errorf("expected to stop at $0202, actual $%04X\n", pc)
+	}
+	if !strings.HasPrefix(out.String(), "stopped: breakpoint\n") {
+		t.Errorf("expected the reason to stop, actual %q\n", out.String())
+	}
+}
+
+func TestMonitorShowsTraceOnFault(t *testing.T) {
+	m, c, _, out := newMonitorTest()
+	c.SetTraceSize(2)
+
+	execTestHelper(t, m, "0200: A9 01 A9 80 02", "g")
+
+	expected := "" +
+		"stopped: invalid opcode $02 at $0204\n" +
+		"0202  A9 80     LDA #$80         A=01 X=00 Y=00 SP=FF SR=00100000\n" +
+		"0204  02        .byte $02        A=80 X=00 Y=00 SP=FF SR=10100000\n"
+	if !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+}
+
+func TestMonitorSymbols(t *testing.T) {
+	m, c, _, out := newMonitorTest()
+	syms := symbols.New()
+	syms.Add("main", 0x0200)
+	syms.Add("sub", 0x0210)
+	syms.Add("add", 0x0300)
+	m.SetSymbols(syms)
+
+	execTestHelper(t, m, "0200: 20 10 02 02", "sub: A9 01 60", "d main main", "break sub", "break .add")
+	expected := "" +
+		"main:\n" +
+		"0200  20 10 02  JSR sub\n" +
+		"breakpoint 0 at $0210 (sub)\n" +
+		"breakpoint 1 at $0300 (add)\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+
+	execTestHelper(t, m, "delete 1", "g main")
+	if pc := c.Registers().PC; pc != 0x0210 {
+		t.Errorf("expected to stop at sub, actual $%04X\n", pc)
+	}
+	out.Reset()
+	execTestHelper(t, m, "break")
+	if expected := "0  $0210 (sub)\n"; out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+
+	for _, line := range []string{".nope", "delete 7"} {
+		if err := m.Exec(line); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: expected %v, actual %v\n", line, ErrSyntax, err)
+		}
+	}
+}
+
+func TestMonitorExpressions(t *testing.T) {
+	m, c, mem, out := newMonitorTest()
+	syms := symbols.New()
+	syms.Add("ptr", 0x0010)
+	m.SetSymbols(syms)
+	mem.Write(0x80, 0x0012)
+
+	execTestHelper(t, m, "r X=02", "print mem[ptr + x] & $80")
+	if expected := "mem[ptr + x] & $80 = $0080  128  %10000000\n"; !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+
+	out.Reset()
+	execTestHelper(t, m, "0200: A9 01 A9 80 02", "watch A", "break 0202 if A == 1", "break", "g 0200")
+	expected := "" +
+		"A = $0000  0  %00000000\n" +
+		"breakpoint 0 at $0202\n" +
+		"0  $0202 if A == 1\n" +
+		"stopped: breakpoint\n"
+	if !strings.HasPrefix(out.String(), expected) || !strings.HasSuffix(out.String(), "A = $0001  1  %00000001\n") {
+		t.Errorf("expected %q and the watch, actual %q\n", expected, out.String())
+	}
+	if pc := c.Registers().PC; pc != 0x0202 {
+		t.Errorf("expected to stop at $0202, actual $%04X\n", pc)
+	}
+
+	for _, line := range []string{"print", "print 1 +", "break 0202 when A", "break 0202 if Q", "unwatch 3"} {
+		if err := m.Exec(line); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: expected %v, actual %v\n", line, ErrSyntax, err)
+		}
+	}
+}
+
+func TestMonitorStackBreakpoint(t *testing.T) {
+	m, c, _, out := newMonitorTest()
+
+	execTestHelper(t, m, "0200: 20 00 02", "break sp < F0", "break SP>FE", "break", "delete 1", "g 0200")
+	expected := "" +
+		"breakpoint 0 when SP < $F0\n" +
+		"breakpoint 1 when SP > $FE\n" +
+		"0  SP < $F0\n" +
+		"1  SP > $FE\n" +
+		"stopped: stack limit, SP $EF\n"
+	if !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+	if sp := c.Registers().SP; sp != 0xEF {
+		t.Errorf("expected to stop with SP $EF, actual $%02X\n", sp)
+	}
+
+	for _, line := range []string{"break sp = 10", "break sp < 100"} {
+		if err := m.Exec(line); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: expected %v, actual %v\n", line, ErrSyntax, err)
+		}
+	}
+}
+
+func TestMonitorCatch(t *testing.T) {
+	m, c, _, out := newMonitorTest()
+	syms := symbols.New()
+	syms.Add("handler", 0x0300)
+	m.SetSymbols(syms)
+
+	execTestHelper(t, m, "FFFE: 00 03", "0200: A9 01 A9 02", "catch irq handler", "catch NMI", "break")
+	expected := "" +
+		"breakpoint 0 on IRQ to $0300 (handler)\n" +
+		"breakpoint 1 on NMI\n" +
+		"0  IRQ to $0300 (handler)\n" +
+		"1  NMI\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+
+	out.Reset()
+	c.SetIRQ(true)
+	execTestHelper(t, m, "g 0200")
+	if expected := "stopped: interrupt at $0300 (handler)\n"; !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+
+	for _, line := range []string{"catch", "catch reset", "catch irq 0300 0400"} {
+		if err := m.Exec(line); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: expected %v, actual %v\n", line, ErrSyntax, err)
+		}
+	}
+}
+
+func TestMonitorBacktrace(t *testing.T) {
+	m, _, _, out := newMonitorTest()
+
+	execTestHelper(t, m, "0200: 20 00 03", "0300: 20 00 04", "z 2")
+	out.Reset()
+	execTestHelper(t, m, "bt")
+
+	expected := "0  JSR $0400 from $0300\n1  JSR $0300 from $0200\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+}
+
+func TestMonitorStopsAtUnmatchedReturn(t *testing.T) {
+	m, c, _, out := newMonitorTest()
+
+	execTestHelper(t, m, "r sp=FD", "0500: 60 A9 01")
+	out.Reset()
+	execTestHelper(t, m, "g 0500")
+
+	expected := "stopped: return without call at $0500\n"
+	if !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("expected %q first, actual %q\n", expected, out.String())
+	}
+	if pc := c.Registers().PC; pc != 0x0001 {
+		t.Errorf("expected PC $0001, actual $%04X\n", pc)
+	}
+}
+
+func TestMonitorStopsAtStackUnderflow(t *testing.T) {
+	m, _, _, out := newMonitorTest()
+
+	execTestHelper(t, m, "0500: 60", "g 0500")
+
+	expected := "stopped: stack underflow at $0500, SP $FF\n"
+	if !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("expected %q first, actual %q\n", expected, out.String())
+	}
+}
+
+func TestMonitorFaultHandler(t *testing.T) {
+	m, _, _, _ := newMonitorTest()
+	var faults []error
+	m.SetFaultHandler(func(err error) { faults = append(faults, err) })
+
+	execTestHelper(t, m, "0200: A9 01 02", "g 0200")
+
+	var invalid *cpu.InvalidOpcodeError
+	if len(faults) != 1 || !errors.As(faults[0], &invalid) {
+		t.Errorf("expected an invalid opcode error, actual %v\n", faults)
+	}
+}
+
+func TestMonitorSource(t *testing.T) {
+	m, _, _, out := newMonitorTest()
+	path := filepath.Join(t.TempDir(), "test.script")
+	src := "on exec $0202\n log \"A is\", A\n log 1 / 0\nend\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	execTestHelper(t, m, "source "+path, "0200: A9 01 A9 02", "g 0200")
+
+	expected := "A is $01\nstopped: script: line 3: expr: division by zero\n"
+	if !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("expected %q first, actual %q\n", expected, out.String())
+	}
+}
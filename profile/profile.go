@@ -0,0 +1,120 @@
+// Package profile measures where a 6502 program spends its time: it counts
+// the cycles taken and the instructions executed at every address, and
+// reports the hot spots.
+//
+//	p := profile.New()
+//	c.AddInstructionHook(p.Record)
+//	c.Run()
+//	p.WriteReport(os.Stdout, profile.ByAddress, 20)
+package profile
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// Granularity is how finely a report groups addresses.
+type Granularity byte
+
+const (
+	// ByAddress reports every instruction address.
+	ByAddress Granularity = iota
+	// ByPage reports every 256-byte page.
+	ByPage
+)
+
+const pageSize = 0x100
+
+// Profiler accumulates the cycles and hits of the instructions executed by a
+// CPU.
+type Profiler struct {
+	cycles [0x10000]uint64
+	hits   [0x10000]uint64
+}
+
+// New returns an empty Profiler.
+func New() *Profiler {
+	return &Profiler{}
+}
+
+// Record accounts for an executed instruction. It is meant to be passed to
+// the AddInstructionHook method of a CPU.
+func (p *Profiler) Record(e cpu.InstructionEvent) {
+	p.cycles[e.PC] += uint64(e.Cycles)
+	p.hits[e.PC]++
+}
+
+// Reset forgets everything recorded so far.
+func (p *Profiler) Reset() {
+	*p = Profiler{}
+}
+
+// Hotspot is an address, or the start of a page, and what was spent there.
+type Hotspot struct {
+	Addr   uint16
+	Cycles uint64
+	// Hits is the number of instructions executed.
+	Hits uint64
+}
+
+// Hotspots returns the addresses or pages where instructions were executed,
+// those that took the most cycles first.
+func (p *Profiler) Hotspots(g Granularity) []Hotspot {
+	var spots []Hotspot
+	for addr := range len(p.hits) {
+		if p.hits[addr] == 0 {
+			continue
+		}
+		start := uint16(addr)
+		if g == ByPage {
+			start &^= pageSize - 1
+		}
+		if n := len(spots); n != 0 && spots[n-1].Addr == start {
+			spots[n-1].Cycles += p.cycles[addr]
+			spots[n-1].Hits += p.hits[addr]
+			continue
+		}
+		spots = append(spots, Hotspot{Addr: start, Cycles: p.cycles[addr], Hits: p.hits[addr]})
+	}
+	slices.SortStableFunc(spots, func(a, b Hotspot) int {
+		return cmp.Compare(b.Cycles, a.Cycles)
+	})
+	return spots
+}
+
+// TotalCycles returns the cycles taken by all the instructions recorded.
+func (p *Profiler) TotalCycles() uint64 {
+	var total uint64
+	for _, c := range p.cycles {
+		total += c
+	}
+	return total
+}
+
+// WriteReport writes the limit hottest spots to w, or all of them if limit is
+// zero, with their share of the total cycles:
+//
+//	ADDR      CYCLES       %        HITS
+//	0204         750   62.50         250
+func (p *Profiler) WriteReport(w io.Writer, g Granularity, limit int) error {
+	spots := p.Hotspots(g)
+	if limit > 0 && len(spots) > limit {
+		spots = spots[:limit]
+	}
+	total := p.TotalCycles()
+
+	if _, err := fmt.Fprintf(w, "%-4s %11s %7s %11s\n", "ADDR", "CYCLES", "%", "HITS"); err != nil {
+		return fmt.Errorf("profile: writing report: %w", err)
+	}
+	for _, s := range spots {
+		share := 100 * float64(s.Cycles) / float64(total)
+		if _, err := fmt.Fprintf(w, "%04X %11d %7.2f %11d\n", s.Addr, s.Cycles, share, s.Hits); err != nil {
+			return fmt.Errorf("profile: writing report: %w", err)
+		}
+	}
+	return nil
+}
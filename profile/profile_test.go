@@ -0,0 +1,78 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func newProfileTest() *Profiler {
+	p := New()
+	for range 3 {
+		p.Record(cpu.InstructionEvent{PC: 0x0200, Opcode: 0xA9, Cycles: 2})
+	}
+	p.Record(cpu.InstructionEvent{PC: 0x0202, Opcode: 0x20, Cycles: 6})
+	p.Record(cpu.InstructionEvent{PC: 0x0300, Opcode: 0x60, Cycles: 6})
+	return p
+}
+
+func TestHotspots(t *testing.T) {
+	p := newProfileTest()
+
+	for _, tc := range []struct {
+		granularity Granularity
+		expected    []Hotspot
+	}{
+		{ByAddress, []Hotspot{{0x0200, 6, 3}, {0x0202, 6, 1}, {0x0300, 6, 1}}},
+		{ByPage, []Hotspot{{0x0200, 12, 4}, {0x0300, 6, 1}}},
+	} {
+		actual := p.Hotspots(tc.granularity)
+		if len(actual) != len(tc.expected) {
+			t.Fatalf("expected %+v, actual %+v\n", tc.expected, actual)
+		}
+		for i := range actual {
+			if actual[i] != tc.expected[i] {
+				t.Errorf("expected %+v, actual %+v\n", tc.expected[i], actual[i])
+			}
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	p := newProfileTest()
+
+	var out strings.Builder
+	if err := p.WriteReport(&out, ByPage, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "" +
+		"ADDR      CYCLES       %        HITS\n" +
+		"0200          12   66.67           4\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\nactual\n%s\n", expected, out.String())
+	}
+}
+
+func TestProfileRun(t *testing.T) {
+	mem := &memory.Memory{}
+	mem.Write(0xA9, 0x0200)
+	mem.Write(0x01, 0x0201)
+	mem.Write(0x02, 0x0202)
+	c := cpu.New(mem)
+	c.Reset()
+	p := New()
+	c.AddInstructionHook(p.Record)
+
+	if _, err := c.Run(); err == nil {
+		t.Fatalf("expected invalid opcode error")
+	}
+
+	expected := []Hotspot{{0x0200, 2, 1}}
+	actual := p.Hotspots(ByAddress)
+	if len(actual) != 1 || actual[0] != expected[0] {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+}
@@ -0,0 +1,99 @@
+// Command mos6502grpc serves an emulated 6502 over gRPC, with the Emulator
+// service of proto/mos6502/v1, for test infrastructure driving it from other
+// languages. It is in the module of the proto directory, so that the
+// emulator does not depend on gRPC.
+//
+// Usage:
+//
+//	mos6502grpc [-listen localhost:6502] [-config bus.json] [-addr 0200]
+//		[-pc 0200] [file]
+//
+// Without -config, the whole address space is RAM. The file is read as a PRG
+// file if its name ends with .prg, as Intel HEX if it ends with .hex or .ihx,
+// as a paper tape of the Woz Monitor if it ends with .woz, as an iNES image
+// if it ends with .nes, and as raw bytes placed at -addr otherwise. The CPU
+// is left at -pc, or at the start of the loaded file, for the clients to
+// run it; without a file, they load the program themselves.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/memory"
+	mos6502v1 "github.com/leakedmemory/mos6502/proto/mos6502/v1"
+	"github.com/leakedmemory/mos6502/proto/server"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "mos6502grpc:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	listen := flag.String("listen", "localhost:6502", "TCP `address` to serve on")
+	config := flag.String("config", "", "bus configuration `file`")
+	addr := flag.String("addr", "0200", "load `address` of raw binaries, in hex")
+	pc := flag.String("pc", "", "start `address`, in hex")
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	b, closer, err := buildBus(*config)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closer.Close() }()
+
+	c := cpu.New(b)
+	c.Reset()
+	start := c.Registers().PC
+	if flag.NArg() == 1 {
+		a, err := loader.ParseAddr(*addr)
+		if err != nil {
+			return err
+		}
+		if start, err = loader.LoadFile(b.DebugView(), flag.Arg(0), a); err != nil {
+			return fmt.Errorf("loading %s: %w", flag.Arg(0), err)
+		}
+	}
+	if *pc != "" {
+		if start, err = loader.ParseAddr(*pc); err != nil {
+			return err
+		}
+	}
+	r := c.Registers()
+	r.PC = start
+	c.SetRegisters(r)
+
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	s := grpc.NewServer()
+	mos6502v1.RegisterEmulatorServer(s, server.New(c, b.DebugView()))
+	return s.Serve(lis)
+}
+
+func buildBus(config string) (*bus.Bus, io.Closer, error) {
+	if config == "" {
+		return bus.NewWithBackend(&memory.Memory{}), io.NopCloser(nil), nil
+	}
+	cfg, err := bus.LoadConfigFile(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg.Build(nil)
+}
@@ -0,0 +1,1112 @@
+// The remote control of an emulated 6502, for test infrastructure driving
+// the emulator from other languages.
+//
+// The service mirrors the commands of package web, served over WebSocket and
+// HTTP by the debugger: its messages hold the same fields as the JSON of
+// web.State, web.Event and web.Memory, and those of trace streams the fields
+// of jsontrace.Instruction. Addresses and bytes are carried in uint32, the
+// smallest integer type of protobuf, and must fit 16 and 8 bits.
+//
+// The Go code of this directory is generated from this file with
+// protoc-gen-go and protoc-gen-go-grpc, with paths=source_relative, and the
+// service is implemented by package server of the same module.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: mos6502/v1/emulator.proto
+
+package mos6502v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Registers struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	A     uint32                 `protobuf:"varint,1,opt,name=a,proto3" json:"a,omitempty"`
+	X     uint32                 `protobuf:"varint,2,opt,name=x,proto3" json:"x,omitempty"`
+	Y     uint32                 `protobuf:"varint,3,opt,name=y,proto3" json:"y,omitempty"`
+	Sp    uint32                 `protobuf:"varint,4,opt,name=sp,proto3" json:"sp,omitempty"`
+	Pc    uint32                 `protobuf:"varint,5,opt,name=pc,proto3" json:"pc,omitempty"`
+	// sr holds the flags N, V, 1, B, D, I, Z and C, from bit 7 to bit 0.
+	Sr            uint32 `protobuf:"varint,6,opt,name=sr,proto3" json:"sr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Registers) Reset() {
+	*x = Registers{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Registers) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Registers) ProtoMessage() {}
+
+func (x *Registers) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Registers.ProtoReflect.Descriptor instead.
+func (*Registers) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Registers) GetA() uint32 {
+	if x != nil {
+		return x.A
+	}
+	return 0
+}
+
+func (x *Registers) GetX() uint32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Registers) GetY() uint32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *Registers) GetSp() uint32 {
+	if x != nil {
+		return x.Sp
+	}
+	return 0
+}
+
+func (x *Registers) GetPc() uint32 {
+	if x != nil {
+		return x.Pc
+	}
+	return 0
+}
+
+func (x *Registers) GetSr() uint32 {
+	if x != nil {
+		return x.Sr
+	}
+	return 0
+}
+
+type State struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Registers     *Registers             `protobuf:"bytes,1,opt,name=registers,proto3" json:"registers,omitempty"`
+	Cycles        uint64                 `protobuf:"varint,2,opt,name=cycles,proto3" json:"cycles,omitempty"`
+	Running       bool                   `protobuf:"varint,3,opt,name=running,proto3" json:"running,omitempty"`
+	Breakpoints   []uint32               `protobuf:"varint,4,rep,packed,name=breakpoints,proto3" json:"breakpoints,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *State) Reset() {
+	*x = State{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *State) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State) ProtoMessage() {}
+
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use State.ProtoReflect.Descriptor instead.
+func (*State) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *State) GetRegisters() *Registers {
+	if x != nil {
+		return x.Registers
+	}
+	return nil
+}
+
+func (x *State) GetCycles() uint64 {
+	if x != nil {
+		return x.Cycles
+	}
+	return 0
+}
+
+func (x *State) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *State) GetBreakpoints() []uint32 {
+	if x != nil {
+		return x.Breakpoints
+	}
+	return nil
+}
+
+type StopEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// reason is why the CPU stopped, such as "breakpoint" or "fault".
+	Reason string `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	// error is the error of a fault.
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	State         *State `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopEvent) Reset() {
+	*x = StopEvent{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopEvent) ProtoMessage() {}
+
+func (x *StopEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopEvent.ProtoReflect.Descriptor instead.
+func (*StopEvent) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StopEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *StopEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *StopEvent) GetState() *State {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*Event_Changed
+	//	*Event_Stopped
+	Event         isEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Event) GetEvent() isEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *Event) GetChanged() *State {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Changed); ok {
+			return x.Changed
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetStopped() *StopEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Stopped); ok {
+			return x.Stopped
+		}
+	}
+	return nil
+}
+
+type isEvent_Event interface {
+	isEvent_Event()
+}
+
+type Event_Changed struct {
+	// changed is sent when the state is changed by a client.
+	Changed *State `protobuf:"bytes,1,opt,name=changed,proto3,oneof"`
+}
+
+type Event_Stopped struct {
+	// stopped is sent when the CPU stops.
+	Stopped *StopEvent `protobuf:"bytes,2,opt,name=stopped,proto3,oneof"`
+}
+
+func (*Event_Changed) isEvent_Event() {}
+
+func (*Event_Stopped) isEvent_Event() {}
+
+type Memory struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Addr          uint32                 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Memory) Reset() {
+	*x = Memory{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Memory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Memory) ProtoMessage() {}
+
+func (x *Memory) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Memory.ProtoReflect.Descriptor instead.
+func (*Memory) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Memory) GetAddr() uint32 {
+	if x != nil {
+		return x.Addr
+	}
+	return 0
+}
+
+func (x *Memory) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type TraceInstruction struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Pc     uint32                 `protobuf:"varint,1,opt,name=pc,proto3" json:"pc,omitempty"`
+	Opcode uint32                 `protobuf:"varint,2,opt,name=opcode,proto3" json:"opcode,omitempty"`
+	// bytes is the encoding of the instruction.
+	Bytes  []byte `protobuf:"bytes,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Disasm string `protobuf:"bytes,4,opt,name=disasm,proto3" json:"disasm,omitempty"`
+	// cycles is the number of cycles the instruction took, and total the
+	// cycle count of the CPU after it.
+	Cycles uint64 `protobuf:"varint,5,opt,name=cycles,proto3" json:"cycles,omitempty"`
+	Total  uint64 `protobuf:"varint,6,opt,name=total,proto3" json:"total,omitempty"`
+	// registers are those after the instruction ran.
+	Registers     *Registers `protobuf:"bytes,7,opt,name=registers,proto3" json:"registers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TraceInstruction) Reset() {
+	*x = TraceInstruction{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TraceInstruction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraceInstruction) ProtoMessage() {}
+
+func (x *TraceInstruction) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraceInstruction.ProtoReflect.Descriptor instead.
+func (*TraceInstruction) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TraceInstruction) GetPc() uint32 {
+	if x != nil {
+		return x.Pc
+	}
+	return 0
+}
+
+func (x *TraceInstruction) GetOpcode() uint32 {
+	if x != nil {
+		return x.Opcode
+	}
+	return 0
+}
+
+func (x *TraceInstruction) GetBytes() []byte {
+	if x != nil {
+		return x.Bytes
+	}
+	return nil
+}
+
+func (x *TraceInstruction) GetDisasm() string {
+	if x != nil {
+		return x.Disasm
+	}
+	return ""
+}
+
+func (x *TraceInstruction) GetCycles() uint64 {
+	if x != nil {
+		return x.Cycles
+	}
+	return 0
+}
+
+func (x *TraceInstruction) GetTotal() uint64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *TraceInstruction) GetRegisters() *Registers {
+	if x != nil {
+		return x.Registers
+	}
+	return nil
+}
+
+type GetStateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStateRequest) Reset() {
+	*x = GetStateRequest{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStateRequest) ProtoMessage() {}
+
+func (x *GetStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStateRequest.ProtoReflect.Descriptor instead.
+func (*GetStateRequest) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{6}
+}
+
+type StepRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StepRequest) Reset() {
+	*x = StepRequest{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StepRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StepRequest) ProtoMessage() {}
+
+func (x *StepRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StepRequest.ProtoReflect.Descriptor instead.
+func (*StepRequest) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{7}
+}
+
+type ContinueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContinueRequest) Reset() {
+	*x = ContinueRequest{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContinueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContinueRequest) ProtoMessage() {}
+
+func (x *ContinueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContinueRequest.ProtoReflect.Descriptor instead.
+func (*ContinueRequest) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{8}
+}
+
+type StopRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopRequest) Reset() {
+	*x = StopRequest{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopRequest) ProtoMessage() {}
+
+func (x *StopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
+func (*StopRequest) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{9}
+}
+
+type WatchEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{10}
+}
+
+type ReadMemoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Addr  uint32                 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	// len is the number of bytes, at most 4096.
+	Len           uint32 `protobuf:"varint,2,opt,name=len,proto3" json:"len,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadMemoryRequest) Reset() {
+	*x = ReadMemoryRequest{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadMemoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadMemoryRequest) ProtoMessage() {}
+
+func (x *ReadMemoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadMemoryRequest.ProtoReflect.Descriptor instead.
+func (*ReadMemoryRequest) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ReadMemoryRequest) GetAddr() uint32 {
+	if x != nil {
+		return x.Addr
+	}
+	return 0
+}
+
+func (x *ReadMemoryRequest) GetLen() uint32 {
+	if x != nil {
+		return x.Len
+	}
+	return 0
+}
+
+type WriteMemoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Addr          uint32                 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteMemoryRequest) Reset() {
+	*x = WriteMemoryRequest{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteMemoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteMemoryRequest) ProtoMessage() {}
+
+func (x *WriteMemoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteMemoryRequest.ProtoReflect.Descriptor instead.
+func (*WriteMemoryRequest) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WriteMemoryRequest) GetAddr() uint32 {
+	if x != nil {
+		return x.Addr
+	}
+	return 0
+}
+
+func (x *WriteMemoryRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type LoadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Addr          uint32                 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	Program       []byte                 `protobuf:"bytes,2,opt,name=program,proto3" json:"program,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoadRequest) Reset() {
+	*x = LoadRequest{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadRequest) ProtoMessage() {}
+
+func (x *LoadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadRequest.ProtoReflect.Descriptor instead.
+func (*LoadRequest) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *LoadRequest) GetAddr() uint32 {
+	if x != nil {
+		return x.Addr
+	}
+	return 0
+}
+
+func (x *LoadRequest) GetProgram() []byte {
+	if x != nil {
+		return x.Program
+	}
+	return nil
+}
+
+type SetRegistersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Registers     *Registers             `protobuf:"bytes,1,opt,name=registers,proto3" json:"registers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRegistersRequest) Reset() {
+	*x = SetRegistersRequest{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRegistersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRegistersRequest) ProtoMessage() {}
+
+func (x *SetRegistersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRegistersRequest.ProtoReflect.Descriptor instead.
+func (*SetRegistersRequest) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SetRegistersRequest) GetRegisters() *Registers {
+	if x != nil {
+		return x.Registers
+	}
+	return nil
+}
+
+type BreakpointRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Addr          uint32                 `protobuf:"varint,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BreakpointRequest) Reset() {
+	*x = BreakpointRequest{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BreakpointRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BreakpointRequest) ProtoMessage() {}
+
+func (x *BreakpointRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BreakpointRequest.ProtoReflect.Descriptor instead.
+func (*BreakpointRequest) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BreakpointRequest) GetAddr() uint32 {
+	if x != nil {
+		return x.Addr
+	}
+	return 0
+}
+
+type StreamTraceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamTraceRequest) Reset() {
+	*x = StreamTraceRequest{}
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamTraceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTraceRequest) ProtoMessage() {}
+
+func (x *StreamTraceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mos6502_v1_emulator_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTraceRequest.ProtoReflect.Descriptor instead.
+func (*StreamTraceRequest) Descriptor() ([]byte, []int) {
+	return file_mos6502_v1_emulator_proto_rawDescGZIP(), []int{16}
+}
+
+var File_mos6502_v1_emulator_proto protoreflect.FileDescriptor
+
+const file_mos6502_v1_emulator_proto_rawDesc = "" +
+	"\n" +
+	"\x19mos6502/v1/emulator.proto\x12\n" +
+	"mos6502.v1\"e\n" +
+	"\tRegisters\x12\f\n" +
+	"\x01a\x18\x01 \x01(\rR\x01a\x12\f\n" +
+	"\x01x\x18\x02 \x01(\rR\x01x\x12\f\n" +
+	"\x01y\x18\x03 \x01(\rR\x01y\x12\x0e\n" +
+	"\x02sp\x18\x04 \x01(\rR\x02sp\x12\x0e\n" +
+	"\x02pc\x18\x05 \x01(\rR\x02pc\x12\x0e\n" +
+	"\x02sr\x18\x06 \x01(\rR\x02sr\"\x90\x01\n" +
+	"\x05State\x123\n" +
+	"\tregisters\x18\x01 \x01(\v2\x15.mos6502.v1.RegistersR\tregisters\x12\x16\n" +
+	"\x06cycles\x18\x02 \x01(\x04R\x06cycles\x12\x18\n" +
+	"\arunning\x18\x03 \x01(\bR\arunning\x12 \n" +
+	"\vbreakpoints\x18\x04 \x03(\rR\vbreakpoints\"b\n" +
+	"\tStopEvent\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12'\n" +
+	"\x05state\x18\x03 \x01(\v2\x11.mos6502.v1.StateR\x05state\"r\n" +
+	"\x05Event\x12-\n" +
+	"\achanged\x18\x01 \x01(\v2\x11.mos6502.v1.StateH\x00R\achanged\x121\n" +
+	"\astopped\x18\x02 \x01(\v2\x15.mos6502.v1.StopEventH\x00R\astoppedB\a\n" +
+	"\x05event\"0\n" +
+	"\x06Memory\x12\x12\n" +
+	"\x04addr\x18\x01 \x01(\rR\x04addr\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"\xcb\x01\n" +
+	"\x10TraceInstruction\x12\x0e\n" +
+	"\x02pc\x18\x01 \x01(\rR\x02pc\x12\x16\n" +
+	"\x06opcode\x18\x02 \x01(\rR\x06opcode\x12\x14\n" +
+	"\x05bytes\x18\x03 \x01(\fR\x05bytes\x12\x16\n" +
+	"\x06disasm\x18\x04 \x01(\tR\x06disasm\x12\x16\n" +
+	"\x06cycles\x18\x05 \x01(\x04R\x06cycles\x12\x14\n" +
+	"\x05total\x18\x06 \x01(\x04R\x05total\x123\n" +
+	"\tregisters\x18\a \x01(\v2\x15.mos6502.v1.RegistersR\tregisters\"\x11\n" +
+	"\x0fGetStateRequest\"\r\n" +
+	"\vStepRequest\"\x11\n" +
+	"\x0fContinueRequest\"\r\n" +
+	"\vStopRequest\"\x14\n" +
+	"\x12WatchEventsRequest\"9\n" +
+	"\x11ReadMemoryRequest\x12\x12\n" +
+	"\x04addr\x18\x01 \x01(\rR\x04addr\x12\x10\n" +
+	"\x03len\x18\x02 \x01(\rR\x03len\"<\n" +
+	"\x12WriteMemoryRequest\x12\x12\n" +
+	"\x04addr\x18\x01 \x01(\rR\x04addr\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\";\n" +
+	"\vLoadRequest\x12\x12\n" +
+	"\x04addr\x18\x01 \x01(\rR\x04addr\x12\x18\n" +
+	"\aprogram\x18\x02 \x01(\fR\aprogram\"J\n" +
+	"\x13SetRegistersRequest\x123\n" +
+	"\tregisters\x18\x01 \x01(\v2\x15.mos6502.v1.RegistersR\tregisters\"'\n" +
+	"\x11BreakpointRequest\x12\x12\n" +
+	"\x04addr\x18\x01 \x01(\rR\x04addr\"\x14\n" +
+	"\x12StreamTraceRequest2\x89\x06\n" +
+	"\bEmulator\x12:\n" +
+	"\bGetState\x12\x1b.mos6502.v1.GetStateRequest\x1a\x11.mos6502.v1.State\x126\n" +
+	"\x04Step\x12\x17.mos6502.v1.StepRequest\x1a\x15.mos6502.v1.StopEvent\x12:\n" +
+	"\bContinue\x12\x1b.mos6502.v1.ContinueRequest\x1a\x11.mos6502.v1.State\x126\n" +
+	"\x04Stop\x12\x17.mos6502.v1.StopRequest\x1a\x15.mos6502.v1.StopEvent\x12B\n" +
+	"\vWatchEvents\x12\x1e.mos6502.v1.WatchEventsRequest\x1a\x11.mos6502.v1.Event0\x01\x12?\n" +
+	"\n" +
+	"ReadMemory\x12\x1d.mos6502.v1.ReadMemoryRequest\x1a\x12.mos6502.v1.Memory\x12@\n" +
+	"\vWriteMemory\x12\x1e.mos6502.v1.WriteMemoryRequest\x1a\x11.mos6502.v1.State\x122\n" +
+	"\x04Load\x12\x17.mos6502.v1.LoadRequest\x1a\x11.mos6502.v1.State\x12B\n" +
+	"\fSetRegisters\x12\x1f.mos6502.v1.SetRegistersRequest\x1a\x11.mos6502.v1.State\x12A\n" +
+	"\rAddBreakpoint\x12\x1d.mos6502.v1.BreakpointRequest\x1a\x11.mos6502.v1.State\x12D\n" +
+	"\x10RemoveBreakpoint\x12\x1d.mos6502.v1.BreakpointRequest\x1a\x11.mos6502.v1.State\x12M\n" +
+	"\vStreamTrace\x12\x1e.mos6502.v1.StreamTraceRequest\x1a\x1c.mos6502.v1.TraceInstruction0\x01B<Z:github.com/leakedmemory/mos6502/proto/mos6502/v1;mos6502v1b\x06proto3"
+
+var (
+	file_mos6502_v1_emulator_proto_rawDescOnce sync.Once
+	file_mos6502_v1_emulator_proto_rawDescData []byte
+)
+
+func file_mos6502_v1_emulator_proto_rawDescGZIP() []byte {
+	file_mos6502_v1_emulator_proto_rawDescOnce.Do(func() {
+		file_mos6502_v1_emulator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_mos6502_v1_emulator_proto_rawDesc), len(file_mos6502_v1_emulator_proto_rawDesc)))
+	})
+	return file_mos6502_v1_emulator_proto_rawDescData
+}
+
+var file_mos6502_v1_emulator_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_mos6502_v1_emulator_proto_goTypes = []any{
+	(*Registers)(nil),           // 0: mos6502.v1.Registers
+	(*State)(nil),               // 1: mos6502.v1.State
+	(*StopEvent)(nil),           // 2: mos6502.v1.StopEvent
+	(*Event)(nil),               // 3: mos6502.v1.Event
+	(*Memory)(nil),              // 4: mos6502.v1.Memory
+	(*TraceInstruction)(nil),    // 5: mos6502.v1.TraceInstruction
+	(*GetStateRequest)(nil),     // 6: mos6502.v1.GetStateRequest
+	(*StepRequest)(nil),         // 7: mos6502.v1.StepRequest
+	(*ContinueRequest)(nil),     // 8: mos6502.v1.ContinueRequest
+	(*StopRequest)(nil),         // 9: mos6502.v1.StopRequest
+	(*WatchEventsRequest)(nil),  // 10: mos6502.v1.WatchEventsRequest
+	(*ReadMemoryRequest)(nil),   // 11: mos6502.v1.ReadMemoryRequest
+	(*WriteMemoryRequest)(nil),  // 12: mos6502.v1.WriteMemoryRequest
+	(*LoadRequest)(nil),         // 13: mos6502.v1.LoadRequest
+	(*SetRegistersRequest)(nil), // 14: mos6502.v1.SetRegistersRequest
+	(*BreakpointRequest)(nil),   // 15: mos6502.v1.BreakpointRequest
+	(*StreamTraceRequest)(nil),  // 16: mos6502.v1.StreamTraceRequest
+}
+var file_mos6502_v1_emulator_proto_depIdxs = []int32{
+	0,  // 0: mos6502.v1.State.registers:type_name -> mos6502.v1.Registers
+	1,  // 1: mos6502.v1.StopEvent.state:type_name -> mos6502.v1.State
+	1,  // 2: mos6502.v1.Event.changed:type_name -> mos6502.v1.State
+	2,  // 3: mos6502.v1.Event.stopped:type_name -> mos6502.v1.StopEvent
+	0,  // 4: mos6502.v1.TraceInstruction.registers:type_name -> mos6502.v1.Registers
+	0,  // 5: mos6502.v1.SetRegistersRequest.registers:type_name -> mos6502.v1.Registers
+	6,  // 6: mos6502.v1.Emulator.GetState:input_type -> mos6502.v1.GetStateRequest
+	7,  // 7: mos6502.v1.Emulator.Step:input_type -> mos6502.v1.StepRequest
+	8,  // 8: mos6502.v1.Emulator.Continue:input_type -> mos6502.v1.ContinueRequest
+	9,  // 9: mos6502.v1.Emulator.Stop:input_type -> mos6502.v1.StopRequest
+	10, // 10: mos6502.v1.Emulator.WatchEvents:input_type -> mos6502.v1.WatchEventsRequest
+	11, // 11: mos6502.v1.Emulator.ReadMemory:input_type -> mos6502.v1.ReadMemoryRequest
+	12, // 12: mos6502.v1.Emulator.WriteMemory:input_type -> mos6502.v1.WriteMemoryRequest
+	13, // 13: mos6502.v1.Emulator.Load:input_type -> mos6502.v1.LoadRequest
+	14, // 14: mos6502.v1.Emulator.SetRegisters:input_type -> mos6502.v1.SetRegistersRequest
+	15, // 15: mos6502.v1.Emulator.AddBreakpoint:input_type -> mos6502.v1.BreakpointRequest
+	15, // 16: mos6502.v1.Emulator.RemoveBreakpoint:input_type -> mos6502.v1.BreakpointRequest
+	16, // 17: mos6502.v1.Emulator.StreamTrace:input_type -> mos6502.v1.StreamTraceRequest
+	1,  // 18: mos6502.v1.Emulator.GetState:output_type -> mos6502.v1.State
+	2,  // 19: mos6502.v1.Emulator.Step:output_type -> mos6502.v1.StopEvent
+	1,  // 20: mos6502.v1.Emulator.Continue:output_type -> mos6502.v1.State
+	2,  // 21: mos6502.v1.Emulator.Stop:output_type -> mos6502.v1.StopEvent
+	3,  // 22: mos6502.v1.Emulator.WatchEvents:output_type -> mos6502.v1.Event
+	4,  // 23: mos6502.v1.Emulator.ReadMemory:output_type -> mos6502.v1.Memory
+	1,  // 24: mos6502.v1.Emulator.WriteMemory:output_type -> mos6502.v1.State
+	1,  // 25: mos6502.v1.Emulator.Load:output_type -> mos6502.v1.State
+	1,  // 26: mos6502.v1.Emulator.SetRegisters:output_type -> mos6502.v1.State
+	1,  // 27: mos6502.v1.Emulator.AddBreakpoint:output_type -> mos6502.v1.State
+	1,  // 28: mos6502.v1.Emulator.RemoveBreakpoint:output_type -> mos6502.v1.State
+	5,  // 29: mos6502.v1.Emulator.StreamTrace:output_type -> mos6502.v1.TraceInstruction
+	18, // [18:30] is the sub-list for method output_type
+	6,  // [6:18] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_mos6502_v1_emulator_proto_init() }
+func file_mos6502_v1_emulator_proto_init() {
+	if File_mos6502_v1_emulator_proto != nil {
+		return
+	}
+	file_mos6502_v1_emulator_proto_msgTypes[3].OneofWrappers = []any{
+		(*Event_Changed)(nil),
+		(*Event_Stopped)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_mos6502_v1_emulator_proto_rawDesc), len(file_mos6502_v1_emulator_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_mos6502_v1_emulator_proto_goTypes,
+		DependencyIndexes: file_mos6502_v1_emulator_proto_depIdxs,
+		MessageInfos:      file_mos6502_v1_emulator_proto_msgTypes,
+	}.Build()
+	File_mos6502_v1_emulator_proto = out.File
+	file_mos6502_v1_emulator_proto_goTypes = nil
+	file_mos6502_v1_emulator_proto_depIdxs = nil
+}
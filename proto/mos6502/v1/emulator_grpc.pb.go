@@ -0,0 +1,591 @@
+// The remote control of an emulated 6502, for test infrastructure driving
+// the emulator from other languages.
+//
+// The service mirrors the commands of package web, served over WebSocket and
+// HTTP by the debugger: its messages hold the same fields as the JSON of
+// web.State, web.Event and web.Memory, and those of trace streams the fields
+// of jsontrace.Instruction. Addresses and bytes are carried in uint32, the
+// smallest integer type of protobuf, and must fit 16 and 8 bits.
+//
+// The Go code of this directory is generated from this file with
+// protoc-gen-go and protoc-gen-go-grpc, with paths=source_relative, and the
+// service is implemented by package server of the same module.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: mos6502/v1/emulator.proto
+
+package mos6502v1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Emulator_GetState_FullMethodName         = "/mos6502.v1.Emulator/GetState"
+	Emulator_Step_FullMethodName             = "/mos6502.v1.Emulator/Step"
+	Emulator_Continue_FullMethodName         = "/mos6502.v1.Emulator/Continue"
+	Emulator_Stop_FullMethodName             = "/mos6502.v1.Emulator/Stop"
+	Emulator_WatchEvents_FullMethodName      = "/mos6502.v1.Emulator/WatchEvents"
+	Emulator_ReadMemory_FullMethodName       = "/mos6502.v1.Emulator/ReadMemory"
+	Emulator_WriteMemory_FullMethodName      = "/mos6502.v1.Emulator/WriteMemory"
+	Emulator_Load_FullMethodName             = "/mos6502.v1.Emulator/Load"
+	Emulator_SetRegisters_FullMethodName     = "/mos6502.v1.Emulator/SetRegisters"
+	Emulator_AddBreakpoint_FullMethodName    = "/mos6502.v1.Emulator/AddBreakpoint"
+	Emulator_RemoveBreakpoint_FullMethodName = "/mos6502.v1.Emulator/RemoveBreakpoint"
+	Emulator_StreamTrace_FullMethodName      = "/mos6502.v1.Emulator/StreamTrace"
+)
+
+// EmulatorClient is the client API for Emulator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EmulatorClient interface {
+	// GetState returns the state of the CPU.
+	GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*State, error)
+	// Step executes an instruction, or enters the handler of a pending
+	// interrupt. It fails with FAILED_PRECONDITION while the program runs.
+	Step(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (*StopEvent, error)
+	// Continue runs the program until a breakpoint, a fault or Stop.
+	Continue(ctx context.Context, in *ContinueRequest, opts ...grpc.CallOption) (*State, error)
+	// Stop stops the running program.
+	Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopEvent, error)
+	// WatchEvents streams the events of the CPU stopping or its state
+	// changing, until the client cancels it.
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// ReadMemory reads a block of memory, without side effects on devices.
+	ReadMemory(ctx context.Context, in *ReadMemoryRequest, opts ...grpc.CallOption) (*Memory, error)
+	// WriteMemory writes a block of memory.
+	WriteMemory(ctx context.Context, in *WriteMemoryRequest, opts ...grpc.CallOption) (*State, error)
+	// Load writes a program and starts the CPU at its address.
+	Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*State, error)
+	// SetRegisters changes the registers.
+	SetRegisters(ctx context.Context, in *SetRegistersRequest, opts ...grpc.CallOption) (*State, error)
+	// AddBreakpoint stops the program before it executes the instruction at
+	// an address.
+	AddBreakpoint(ctx context.Context, in *BreakpointRequest, opts ...grpc.CallOption) (*State, error)
+	// RemoveBreakpoint removes the breakpoint at an address.
+	RemoveBreakpoint(ctx context.Context, in *BreakpointRequest, opts ...grpc.CallOption) (*State, error)
+	// StreamTrace streams the instructions executed until the client cancels
+	// it. Instructions the client is too slow to receive are dropped.
+	StreamTrace(ctx context.Context, in *StreamTraceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TraceInstruction], error)
+}
+
+type emulatorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEmulatorClient(cc grpc.ClientConnInterface) EmulatorClient {
+	return &emulatorClient{cc}
+}
+
+func (c *emulatorClient) GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Emulator_GetState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *emulatorClient) Step(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (*StopEvent, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StopEvent)
+	err := c.cc.Invoke(ctx, Emulator_Step_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *emulatorClient) Continue(ctx context.Context, in *ContinueRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Emulator_Continue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *emulatorClient) Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopEvent, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StopEvent)
+	err := c.cc.Invoke(ctx, Emulator_Stop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *emulatorClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Emulator_ServiceDesc.Streams[0], Emulator_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Emulator_WatchEventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *emulatorClient) ReadMemory(ctx context.Context, in *ReadMemoryRequest, opts ...grpc.CallOption) (*Memory, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Memory)
+	err := c.cc.Invoke(ctx, Emulator_ReadMemory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *emulatorClient) WriteMemory(ctx context.Context, in *WriteMemoryRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Emulator_WriteMemory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *emulatorClient) Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Emulator_Load_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *emulatorClient) SetRegisters(ctx context.Context, in *SetRegistersRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Emulator_SetRegisters_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *emulatorClient) AddBreakpoint(ctx context.Context, in *BreakpointRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Emulator_AddBreakpoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *emulatorClient) RemoveBreakpoint(ctx context.Context, in *BreakpointRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Emulator_RemoveBreakpoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *emulatorClient) StreamTrace(ctx context.Context, in *StreamTraceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TraceInstruction], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Emulator_ServiceDesc.Streams[1], Emulator_StreamTrace_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamTraceRequest, TraceInstruction]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Emulator_StreamTraceClient = grpc.ServerStreamingClient[TraceInstruction]
+
+// EmulatorServer is the server API for Emulator service.
+// All implementations must embed UnimplementedEmulatorServer
+// for forward compatibility.
+type EmulatorServer interface {
+	// GetState returns the state of the CPU.
+	GetState(context.Context, *GetStateRequest) (*State, error)
+	// Step executes an instruction, or enters the handler of a pending
+	// interrupt. It fails with FAILED_PRECONDITION while the program runs.
+	Step(context.Context, *StepRequest) (*StopEvent, error)
+	// Continue runs the program until a breakpoint, a fault or Stop.
+	Continue(context.Context, *ContinueRequest) (*State, error)
+	// Stop stops the running program.
+	Stop(context.Context, *StopRequest) (*StopEvent, error)
+	// WatchEvents streams the events of the CPU stopping or its state
+	// changing, until the client cancels it.
+	WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[Event]) error
+	// ReadMemory reads a block of memory, without side effects on devices.
+	ReadMemory(context.Context, *ReadMemoryRequest) (*Memory, error)
+	// WriteMemory writes a block of memory.
+	WriteMemory(context.Context, *WriteMemoryRequest) (*State, error)
+	// Load writes a program and starts the CPU at its address.
+	Load(context.Context, *LoadRequest) (*State, error)
+	// SetRegisters changes the registers.
+	SetRegisters(context.Context, *SetRegistersRequest) (*State, error)
+	// AddBreakpoint stops the program before it executes the instruction at
+	// an address.
+	AddBreakpoint(context.Context, *BreakpointRequest) (*State, error)
+	// RemoveBreakpoint removes the breakpoint at an address.
+	RemoveBreakpoint(context.Context, *BreakpointRequest) (*State, error)
+	// StreamTrace streams the instructions executed until the client cancels
+	// it. Instructions the client is too slow to receive are dropped.
+	StreamTrace(*StreamTraceRequest, grpc.ServerStreamingServer[TraceInstruction]) error
+	mustEmbedUnimplementedEmulatorServer()
+}
+
+// UnimplementedEmulatorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEmulatorServer struct{}
+
+func (UnimplementedEmulatorServer) GetState(context.Context, *GetStateRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedEmulatorServer) Step(context.Context, *StepRequest) (*StopEvent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Step not implemented")
+}
+func (UnimplementedEmulatorServer) Continue(context.Context, *ContinueRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Continue not implemented")
+}
+func (UnimplementedEmulatorServer) Stop(context.Context, *StopRequest) (*StopEvent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
+}
+func (UnimplementedEmulatorServer) WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedEmulatorServer) ReadMemory(context.Context, *ReadMemoryRequest) (*Memory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadMemory not implemented")
+}
+func (UnimplementedEmulatorServer) WriteMemory(context.Context, *WriteMemoryRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WriteMemory not implemented")
+}
+func (UnimplementedEmulatorServer) Load(context.Context, *LoadRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Load not implemented")
+}
+func (UnimplementedEmulatorServer) SetRegisters(context.Context, *SetRegistersRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRegisters not implemented")
+}
+func (UnimplementedEmulatorServer) AddBreakpoint(context.Context, *BreakpointRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBreakpoint not implemented")
+}
+func (UnimplementedEmulatorServer) RemoveBreakpoint(context.Context, *BreakpointRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveBreakpoint not implemented")
+}
+func (UnimplementedEmulatorServer) StreamTrace(*StreamTraceRequest, grpc.ServerStreamingServer[TraceInstruction]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTrace not implemented")
+}
+func (UnimplementedEmulatorServer) mustEmbedUnimplementedEmulatorServer() {}
+func (UnimplementedEmulatorServer) testEmbeddedByValue()                  {}
+
+// UnsafeEmulatorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EmulatorServer will
+// result in compilation errors.
+type UnsafeEmulatorServer interface {
+	mustEmbedUnimplementedEmulatorServer()
+}
+
+func RegisterEmulatorServer(s grpc.ServiceRegistrar, srv EmulatorServer) {
+	// If the following call pancis, it indicates UnimplementedEmulatorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Emulator_ServiceDesc, srv)
+}
+
+func _Emulator_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmulatorServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Emulator_GetState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmulatorServer).GetState(ctx, req.(*GetStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Emulator_Step_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmulatorServer).Step(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Emulator_Step_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmulatorServer).Step(ctx, req.(*StepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Emulator_Continue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContinueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmulatorServer).Continue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Emulator_Continue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmulatorServer).Continue(ctx, req.(*ContinueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Emulator_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmulatorServer).Stop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Emulator_Stop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmulatorServer).Stop(ctx, req.(*StopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Emulator_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EmulatorServer).WatchEvents(m, &grpc.GenericServerStream[WatchEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Emulator_WatchEventsServer = grpc.ServerStreamingServer[Event]
+
+func _Emulator_ReadMemory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadMemoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmulatorServer).ReadMemory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Emulator_ReadMemory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmulatorServer).ReadMemory(ctx, req.(*ReadMemoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Emulator_WriteMemory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteMemoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmulatorServer).WriteMemory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Emulator_WriteMemory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmulatorServer).WriteMemory(ctx, req.(*WriteMemoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Emulator_Load_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmulatorServer).Load(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Emulator_Load_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmulatorServer).Load(ctx, req.(*LoadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Emulator_SetRegisters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRegistersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmulatorServer).SetRegisters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Emulator_SetRegisters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmulatorServer).SetRegisters(ctx, req.(*SetRegistersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Emulator_AddBreakpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BreakpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmulatorServer).AddBreakpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Emulator_AddBreakpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmulatorServer).AddBreakpoint(ctx, req.(*BreakpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Emulator_RemoveBreakpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BreakpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmulatorServer).RemoveBreakpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Emulator_RemoveBreakpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmulatorServer).RemoveBreakpoint(ctx, req.(*BreakpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Emulator_StreamTrace_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTraceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EmulatorServer).StreamTrace(m, &grpc.GenericServerStream[StreamTraceRequest, TraceInstruction]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Emulator_StreamTraceServer = grpc.ServerStreamingServer[TraceInstruction]
+
+// Emulator_ServiceDesc is the grpc.ServiceDesc for Emulator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Emulator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mos6502.v1.Emulator",
+	HandlerType: (*EmulatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetState",
+			Handler:    _Emulator_GetState_Handler,
+		},
+		{
+			MethodName: "Step",
+			Handler:    _Emulator_Step_Handler,
+		},
+		{
+			MethodName: "Continue",
+			Handler:    _Emulator_Continue_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _Emulator_Stop_Handler,
+		},
+		{
+			MethodName: "ReadMemory",
+			Handler:    _Emulator_ReadMemory_Handler,
+		},
+		{
+			MethodName: "WriteMemory",
+			Handler:    _Emulator_WriteMemory_Handler,
+		},
+		{
+			MethodName: "Load",
+			Handler:    _Emulator_Load_Handler,
+		},
+		{
+			MethodName: "SetRegisters",
+			Handler:    _Emulator_SetRegisters_Handler,
+		},
+		{
+			MethodName: "AddBreakpoint",
+			Handler:    _Emulator_AddBreakpoint_Handler,
+		},
+		{
+			MethodName: "RemoveBreakpoint",
+			Handler:    _Emulator_RemoveBreakpoint_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _Emulator_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamTrace",
+			Handler:       _Emulator_StreamTrace_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "mos6502/v1/emulator.proto",
+}
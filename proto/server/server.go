@@ -0,0 +1,279 @@
+// Package server serves the Emulator service of package mos6502v1, the
+// remote control of a CPU over gRPC, for test infrastructure written in
+// other languages:
+//
+//	s := grpc.NewServer()
+//	mos6502v1.RegisterEmulatorServer(s, server.New(c, b.DebugView()))
+//	s.Serve(lis)
+//
+// The CPU is controlled as by package web, the server translating between
+// the messages of the service and the session both share: a command the
+// running program prevents fails with FAILED_PRECONDITION, every change of
+// the state is sent to the clients watching events, and traces drop the
+// instructions the client is too slow to receive.
+//
+// It lives in a module of its own, for the dependencies on gRPC and protobuf
+// to stay out of the module of the emulator.
+package server
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/disasm"
+	"github.com/leakedmemory/mos6502/internal/session"
+	"github.com/leakedmemory/mos6502/memory"
+	mos6502v1 "github.com/leakedmemory/mos6502/proto/mos6502/v1"
+)
+
+const (
+	// maxMemory bounds the size of a single read.
+	maxMemory = 0x1000
+	// eventBuffer and traceBuffer are the number of events and instructions
+	// kept for a slow client before dropping them.
+	eventBuffer = 64
+	traceBuffer = 1024
+)
+
+// Server serves a CPU. It implements mos6502v1.EmulatorServer.
+type Server struct {
+	mos6502v1.UnimplementedEmulatorServer
+
+	session *session.Session
+}
+
+// New returns a Server controlling c. mem is used to read and write memory
+// for the clients: it should be free of side effects, such as the DebugView
+// of a bus.
+func New(c *cpu.CPU, mem memory.ReadWriter) *Server {
+	return &Server{session: session.New(c, mem)}
+}
+
+// GetState returns the state of the CPU.
+func (s *Server) GetState(context.Context, *mos6502v1.GetStateRequest) (*mos6502v1.State, error) {
+	return state(s.session.State()), nil
+}
+
+// Step executes an instruction. A fault is reported by the event, not as an
+// error.
+func (s *Server) Step(context.Context, *mos6502v1.StepRequest) (*mos6502v1.StopEvent, error) {
+	ev, err := s.session.Step()
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return stopEvent(ev), nil
+}
+
+// Continue runs the program until a breakpoint, a fault or Stop.
+func (s *Server) Continue(context.Context, *mos6502v1.ContinueRequest) (*mos6502v1.State, error) {
+	return changed(s.session.Continue())
+}
+
+// Stop stops the running program. If it is not running, the event has no
+// reason.
+func (s *Server) Stop(context.Context, *mos6502v1.StopRequest) (*mos6502v1.StopEvent, error) {
+	return stopEvent(s.session.Stop()), nil
+}
+
+// WatchEvents sends the current state, then the events of the CPU until the
+// client cancels. Events the client is too slow to receive are dropped.
+func (s *Server) WatchEvents(_ *mos6502v1.WatchEventsRequest, stream mos6502v1.Emulator_WatchEventsServer) error {
+	events := make(chan *mos6502v1.Event, eventBuffer)
+	st, stop := s.session.Watch(func(ev session.Event) {
+		select {
+		case events <- event(ev):
+		default:
+		}
+	})
+	defer stop()
+
+	if err := stream.Send(&mos6502v1.Event{Event: &mos6502v1.Event_Changed{Changed: state(st)}}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev := <-events:
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ReadMemory reads at most 4096 bytes of memory.
+func (s *Server) ReadMemory(_ context.Context, req *mos6502v1.ReadMemoryRequest) (*mos6502v1.Memory, error) {
+	addr, err := address(req.GetAddr())
+	if err != nil {
+		return nil, err
+	}
+	data := s.session.Read(addr, int(min(req.GetLen(), maxMemory)))
+	return &mos6502v1.Memory{Addr: req.GetAddr(), Data: data}, nil
+}
+
+// WriteMemory writes a block of memory.
+func (s *Server) WriteMemory(_ context.Context, req *mos6502v1.WriteMemoryRequest) (*mos6502v1.State, error) {
+	addr, err := address(req.GetAddr())
+	if err != nil {
+		return nil, err
+	}
+	return changed(s.session.Write(addr, req.GetData()))
+}
+
+// Load writes a program and starts the CPU at its address.
+func (s *Server) Load(_ context.Context, req *mos6502v1.LoadRequest) (*mos6502v1.State, error) {
+	addr, err := address(req.GetAddr())
+	if err != nil {
+		return nil, err
+	}
+	return changed(s.session.Load(addr, req.GetProgram()))
+}
+
+// SetRegisters changes the registers.
+func (s *Server) SetRegisters(_ context.Context, req *mos6502v1.SetRegistersRequest) (*mos6502v1.State, error) {
+	r := req.GetRegisters()
+	if r == nil {
+		return nil, status.Error(codes.InvalidArgument, "server: registers expected")
+	}
+	if max(r.GetA(), r.GetX(), r.GetY(), r.GetSp(), r.GetSr()) > 0xFF || r.GetPc() > 0xFFFF {
+		return nil, status.Error(codes.InvalidArgument, "server: register out of range")
+	}
+	return changed(s.session.SetRegisters(cpu.Registers{
+		A:  byte(r.GetA()),
+		X:  byte(r.GetX()),
+		Y:  byte(r.GetY()),
+		SP: byte(r.GetSp()),
+		PC: uint16(r.GetPc()),
+		SR: byte(r.GetSr()),
+	}))
+}
+
+// AddBreakpoint stops the program before the instruction at an address.
+func (s *Server) AddBreakpoint(_ context.Context, req *mos6502v1.BreakpointRequest) (*mos6502v1.State, error) {
+	addr, err := address(req.GetAddr())
+	if err != nil {
+		return nil, err
+	}
+	return changed(s.session.AddBreakpoint(addr))
+}
+
+// RemoveBreakpoint removes the breakpoint at an address.
+func (s *Server) RemoveBreakpoint(_ context.Context, req *mos6502v1.BreakpointRequest) (*mos6502v1.State, error) {
+	addr, err := address(req.GetAddr())
+	if err != nil {
+		return nil, err
+	}
+	return changed(s.session.RemoveBreakpoint(addr))
+}
+
+// StreamTrace streams the instructions executed until the client cancels,
+// dropping those it is too slow to receive so that the CPU does not wait.
+// The headers are sent once tracing starts, for clients to wait for them
+// before running the program.
+func (s *Server) StreamTrace(_ *mos6502v1.StreamTraceRequest, stream mos6502v1.Emulator_StreamTraceServer) error {
+	insts := make(chan *mos6502v1.TraceInstruction, traceBuffer)
+	var hook int
+	s.session.Do(func(c *cpu.CPU, mem memory.ReadWriter) {
+		hook = c.AddInstructionHook(func(e cpu.InstructionEvent) {
+			inst := disasm.Decode(mem, e.PC)
+			select {
+			case insts <- &mos6502v1.TraceInstruction{
+				Pc:        uint32(e.PC),
+				Opcode:    uint32(e.Opcode),
+				Bytes:     inst.Bytes(),
+				Disasm:    inst.String(),
+				Cycles:    uint64(e.Cycles),
+				Total:     uint64(c.Cycles()),
+				Registers: registers(c.Registers()),
+			}:
+			default:
+			}
+		})
+	})
+	defer s.session.Do(func(c *cpu.CPU, _ memory.ReadWriter) {
+		c.RemoveInstructionHook(hook)
+	})
+
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case inst := <-insts:
+			if err := stream.Send(inst); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// changed translates the state returned by a command changing it.
+func changed(st session.State, err error) (*mos6502v1.State, error) {
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return state(st), nil
+}
+
+// statusError returns the status of the error of a command.
+func statusError(err error) error {
+	if errors.Is(err, session.ErrRunning) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func state(st session.State) *mos6502v1.State {
+	s := &mos6502v1.State{
+		Registers: registers(st.Registers),
+		Cycles:    uint64(st.Cycles),
+		Running:   st.Running,
+	}
+	for _, addr := range st.Breakpoints {
+		s.Breakpoints = append(s.Breakpoints, uint32(addr))
+	}
+	return s
+}
+
+// stopEvent returns the StopEvent of ev. The error of a fault is part of the
+// event, not of the response.
+func stopEvent(ev session.Event) *mos6502v1.StopEvent {
+	se := &mos6502v1.StopEvent{Reason: ev.Reason, State: state(ev.State)}
+	if ev.Err != nil {
+		se.Error = ev.Err.Error()
+	}
+	return se
+}
+
+func event(ev session.Event) *mos6502v1.Event {
+	if ev.Stopped {
+		return &mos6502v1.Event{Event: &mos6502v1.Event_Stopped{Stopped: stopEvent(ev)}}
+	}
+	return &mos6502v1.Event{Event: &mos6502v1.Event_Changed{Changed: state(ev.State)}}
+}
+
+func registers(r cpu.Registers) *mos6502v1.Registers {
+	return &mos6502v1.Registers{
+		A:  uint32(r.A),
+		X:  uint32(r.X),
+		Y:  uint32(r.Y),
+		Sp: uint32(r.SP),
+		Pc: uint32(r.PC),
+		Sr: uint32(r.SR),
+	}
+}
+
+// address checks that addr fits 16 bits.
+func address(addr uint32) (uint16, error) {
+	if addr > 0xFFFF {
+		return 0, status.Errorf(codes.InvalidArgument, "server: address %#x out of range", addr)
+	}
+	return uint16(addr), nil
+}
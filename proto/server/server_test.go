@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+	mos6502v1 "github.com/leakedmemory/mos6502/proto/mos6502/v1"
+)
+
+// newServerTest serves a CPU about to run
+//
+//	0200  JSR $0210
+//	0203  .byte $02
+//	0210  LDA #$07
+//	0212  RTS
+//
+// and returns a client connected to it.
+func newServerTest(t *testing.T) mos6502v1.EmulatorClient {
+	t.Helper()
+	mem := &memory.Memory{}
+	for addr, b := range map[uint16][]byte{
+		0x0200: {0x20, 0x10, 0x02, 0x02},
+		0x0210: {0xA9, 0x07, 0x60},
+	} {
+		for i, v := range b {
+			mem.Write(v, addr+uint16(i))
+		}
+	}
+	c := cpu.New(mem)
+	c.Reset()
+	r := c.Registers()
+	r.PC = 0x0200
+	c.SetRegisters(r)
+
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	mos6502v1.RegisterEmulatorServer(srv, New(c, mem))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return mos6502v1.NewEmulatorClient(conn)
+}
+
+func TestStepAndContinue(t *testing.T) {
+	client := newServerTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := client.WatchEvents(ctx, &mos6502v1.WatchEventsRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ev, err := events.Recv(); err != nil || ev.GetChanged().GetRegisters().GetPc() != 0x0200 {
+		t.Fatalf("expected the state at $0200, actual %v %v", ev, err)
+	}
+
+	ev, err := client.Step(ctx, &mos6502v1.StepRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ev.GetReason() != "stepped" || ev.GetState().GetRegisters().GetPc() != 0x0210 {
+		t.Errorf("expected to stop at $0210, actual %v\n", ev)
+	}
+	if ev, err := events.Recv(); err != nil || ev.GetStopped().GetState().GetRegisters().GetPc() != 0x0210 {
+		t.Errorf("expected the watcher to see $0210, actual %v %v\n", ev, err)
+	}
+
+	st, err := client.AddBreakpoint(ctx, &mos6502v1.BreakpointRequest{Addr: 0x0212})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bps := st.GetBreakpoints(); len(bps) != 1 || bps[0] != 0x0212 {
+		t.Errorf("expected a breakpoint at $0212, actual %v\n", bps)
+	}
+	events.Recv()
+
+	if _, err := client.Continue(ctx, &mos6502v1.ContinueRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for {
+		ev, err := events.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stopped := ev.GetStopped(); stopped != nil {
+			if stopped.GetReason() != "breakpoint" || stopped.GetState().GetRegisters().GetA() != 0x07 {
+				t.Errorf("expected to stop at the breakpoint with A $07, actual %v\n", stopped)
+			}
+			break
+		}
+	}
+
+	if _, err := client.RemoveBreakpoint(ctx, &mos6502v1.BreakpointRequest{Addr: 0x0212}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client.Step(ctx, &mos6502v1.StepRequest{})
+	// 0203  .byte $02
+	ev, err = client.Step(ctx, &mos6502v1.StepRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ev.GetReason() != "fault" || ev.GetError() == "" {
+		t.Errorf("expected a fault, actual %v\n", ev)
+	}
+}
+
+func TestMemoryAndRegisters(t *testing.T) {
+	client := newServerTest(t)
+	ctx := context.Background()
+
+	// 0300  LDA #$2A
+	st, err := client.Load(ctx, &mos6502v1.LoadRequest{Addr: 0x0300, Program: []byte{0xA9, 0x2A}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pc := st.GetRegisters().GetPc(); pc != 0x0300 {
+		t.Errorf("expected PC $0300, actual $%04X\n", pc)
+	}
+	if _, err := client.WriteMemory(ctx, &mos6502v1.WriteMemoryRequest{Addr: 0x0400, Data: []byte{1, 2}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m, err := client.ReadMemory(ctx, &mos6502v1.ReadMemoryRequest{Addr: 0x0300, Len: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.GetAddr() != 0x0300 || string(m.GetData()) != "\xA9\x2A" {
+		t.Errorf("expected the program loaded, actual %v\n", m)
+	}
+	m, _ = client.ReadMemory(ctx, &mos6502v1.ReadMemoryRequest{Addr: 0x0400, Len: 1 << 20})
+	if len(m.GetData()) != maxMemory || m.GetData()[1] != 2 {
+		t.Errorf("expected %d bytes from $0400, actual %d\n", maxMemory, len(m.GetData()))
+	}
+
+	st, err = client.SetRegisters(ctx, &mos6502v1.SetRegistersRequest{
+		Registers: &mos6502v1.Registers{A: 0x11, X: 0x22, Y: 0x33, Sp: 0xF0, Pc: 0x0300, Sr: 0x24},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r := st.GetRegisters(); r.GetX() != 0x22 || r.GetSp() != 0xF0 {
+		t.Errorf("expected the registers set, actual %v\n", r)
+	}
+
+	for _, tc := range []struct {
+		name string
+		call func() error
+	}{
+		{"address", func() error {
+			_, err := client.ReadMemory(ctx, &mos6502v1.ReadMemoryRequest{Addr: 0x10000})
+			return err
+		}},
+		{"register", func() error {
+			_, err := client.SetRegisters(ctx, &mos6502v1.SetRegistersRequest{Registers: &mos6502v1.Registers{A: 0x100}})
+			return err
+		}},
+		{"no registers", func() error {
+			_, err := client.SetRegisters(ctx, &mos6502v1.SetRegistersRequest{})
+			return err
+		}},
+	} {
+		if code := status.Code(tc.call()); code != codes.InvalidArgument {
+			t.Errorf("%s: expected %v, actual %v\n", tc.name, codes.InvalidArgument, code)
+		}
+	}
+}
+
+func TestRunning(t *testing.T) {
+	client := newServerTest(t)
+	ctx := context.Background()
+
+	// 0300  JSR $0300
+	if _, err := client.Load(ctx, &mos6502v1.LoadRequest{Addr: 0x0300, Program: []byte{0x20, 0x00, 0x03}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	st, err := client.Continue(ctx, &mos6502v1.ContinueRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !st.GetRunning() {
+		t.Errorf("expected the program to run, actual %v\n", st)
+	}
+
+	if _, err := client.Step(ctx, &mos6502v1.StepRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected %v while running, actual %v\n", codes.FailedPrecondition, err)
+	}
+	if _, err := client.WriteMemory(ctx, &mos6502v1.WriteMemoryRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected %v while running, actual %v\n", codes.FailedPrecondition, err)
+	}
+
+	ev, err := client.Stop(ctx, &mos6502v1.StopRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ev.GetReason() != "interrupted" || ev.GetState().GetRunning() {
+		t.Errorf("expected to be interrupted, actual %v\n", ev)
+	}
+}
+
+func TestStreamTrace(t *testing.T) {
+	client := newServerTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	trace, err := client.StreamTrace(ctx, &mos6502v1.StreamTraceRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The headers are sent once tracing starts.
+	if _, err := trace.Header(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client.Step(ctx, &mos6502v1.StepRequest{})
+	client.Step(ctx, &mos6502v1.StepRequest{})
+
+	for _, expected := range []string{"JSR $0210", "LDA #$07"} {
+		inst, err := trace.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if inst.GetDisasm() != expected {
+			t.Errorf("expected %s, actual %v\n", expected, inst)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Package replay records the nondeterministic inputs of a session, such as
+// the reads of a keyboard or a random number generator and the moments
+// interrupts are raised, stamped with the cycle they happened at, and
+// replays them exactly, so that a bug found in a long interactive session
+// becomes a reproducible test case.
+//
+// Input devices are wrapped before being mapped on the bus, by a Recorder
+// while recording and by a Player while replaying:
+//
+//	rec := replay.NewRecorder(c.Cycles)
+//	b.Map(0xD010, 0xD013, rec.Device("keyboard", kbd))
+//	...
+//	rec.Log().WriteTo(f)
+//
+// Inputs that do not come through the bus, such as the decision of the host
+// to raise an interrupt, go through Input.
+package replay
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/bus"
+)
+
+var (
+	// ErrDiverged is returned by Player.Err when the session replayed asked
+	// for an input the recorded one did not.
+	ErrDiverged = errors.New("replay: session diverged from recording")
+	// ErrSyntax is returned by ReadLog for malformed logs.
+	ErrSyntax = errors.New("replay: syntax error")
+)
+
+// Event is an input fed to the machine: the value read from Addr of the
+// device named Source, or given to Input, at the given cycle.
+type Event struct {
+	Cycle  uint
+	Source string
+	Addr   uint16
+	Value  byte
+}
+
+// Log is the sequence of inputs of a session.
+type Log struct {
+	Events []Event
+}
+
+// WriteTo writes the log to w, an event per line:
+//
+//	1234 keyboard C010 41
+func (l *Log) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, e := range l.Events {
+		n, err := fmt.Fprintf(w, "%d %s %04X %02X\n", e.Cycle, e.Source, e.Addr, e.Value)
+		total += int64(n)
+		if err != nil {
+			return total, fmt.Errorf("replay: writing log: %w", err)
+		}
+	}
+	return total, nil
+}
+
+// ReadLog reads a log written by WriteTo.
+func ReadLog(r io.Reader) (*Log, error) {
+	l := &Log{}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("%w: line %d", ErrSyntax, n)
+		}
+		cycle, err1 := strconv.ParseUint(fields[0], 10, 64)
+		addr, err2 := strconv.ParseUint(fields[2], 16, 16)
+		val, err3 := strconv.ParseUint(fields[3], 16, 8)
+		if err := errors.Join(err1, err2, err3); err != nil {
+			return nil, fmt.Errorf("%w: line %d", ErrSyntax, n)
+		}
+		l.Events = append(l.Events, Event{
+			Cycle:  uint(cycle),
+			Source: fields[1],
+			Addr:   uint16(addr),
+			Value:  byte(val),
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("replay: reading log: %w", err)
+	}
+	return l, nil
+}
+
+// Recorder records the inputs of a session.
+//
+// A Recorder can also go back in its recording with Seek, as when the
+// machine is restored to an earlier state: the inputs are then served from
+// the recording again, until it runs out or the session takes another path.
+type Recorder struct {
+	clock func() uint
+	log   Log
+	// pos is the index of the next event served by Seek, len(log.Events)
+	// while recording live.
+	pos int
+}
+
+// NewRecorder returns a Recorder stamping events with the cycle returned by
+// clock, usually the Cycles method of the CPU.
+func NewRecorder(clock func() uint) *Recorder {
+	return &Recorder{clock: clock}
+}
+
+// Log returns the inputs recorded so far.
+func (r *Recorder) Log() *Log {
+	return &r.log
+}
+
+// Pos returns the number of events recorded, or served again since Seek,
+// so far.
+func (r *Recorder) Pos() int {
+	return r.pos
+}
+
+// Seek makes the inputs be served from the recording again, starting with
+// the event at index pos, a position returned by Pos. An input that does not
+// match the next recorded event drops the rest of the recording and is
+// recorded live.
+func (r *Recorder) Seek(pos int) {
+	r.pos = min(pos, len(r.log.Events))
+}
+
+// Device returns d wrapped so that its reads are recorded under name, which
+// must not contain spaces. Peeks, pokes and writes are not recorded.
+func (r *Recorder) Device(name string, d bus.Device) bus.Device {
+	return &device{Device: d, read: func(addr uint16) byte {
+		if val, ok := r.recorded(name, addr); ok {
+			return val
+		}
+		val := d.Read(addr)
+		r.record(name, addr, val)
+		return val
+	}}
+}
+
+// Input records val as an input named name and returns it.
+func (r *Recorder) Input(name string, val byte) byte {
+	if v, ok := r.recorded(name, 0); ok {
+		return v
+	}
+	r.record(name, 0, val)
+	return val
+}
+
+// recorded returns the value recorded for an input when going through the
+// recording after Seek.
+func (r *Recorder) recorded(name string, addr uint16) (byte, bool) {
+	if r.pos == len(r.log.Events) {
+		return 0, false
+	}
+	e := r.log.Events[r.pos]
+	if e.Source != name || e.Addr != addr || e.Cycle != r.clock() {
+		r.log.Events = r.log.Events[:r.pos]
+		return 0, false
+	}
+	r.pos++
+	return e.Value, true
+}
+
+func (r *Recorder) record(name string, addr uint16, val byte) {
+	r.log.Events = append(r.log.Events, Event{Cycle: r.clock(), Source: name, Addr: addr, Value: val})
+	r.pos++
+}
+
+// Player replays the inputs of a recorded session.
+type Player struct {
+	clock  func() uint
+	events []Event
+	err    error
+}
+
+// NewPlayer returns a Player replaying log, checking the events against the
+// cycle returned by clock.
+func NewPlayer(log *Log, clock func() uint) *Player {
+	return &Player{clock: clock, events: log.Events}
+}
+
+// Device returns d wrapped so that its reads return the values recorded under
+// name instead. Writes still go to d. Once the session diverged from the
+// recording, reads go to d as well.
+func (p *Player) Device(name string, d bus.Device) bus.Device {
+	return &device{Device: d, read: func(addr uint16) byte {
+		if val, ok := p.next(name, addr); ok {
+			return val
+		}
+		return d.Read(addr)
+	}}
+}
+
+// Input returns the value recorded for the input named name, or val once the
+// session diverged from the recording.
+func (p *Player) Input(name string, val byte) byte {
+	if v, ok := p.next(name, 0); ok {
+		return v
+	}
+	return val
+}
+
+// Done reports whether every recorded event was replayed.
+func (p *Player) Done() bool {
+	return len(p.events) == 0
+}
+
+// Err returns the ErrDiverged error describing where the session first
+// diverged from the recording, if it did.
+func (p *Player) Err() error {
+	return p.err
+}
+
+// next returns the recorded value of the input asked for, if it is the next
+// event recorded.
+func (p *Player) next(name string, addr uint16) (byte, bool) {
+	if p.err != nil {
+		return 0, false
+	}
+	cycle := p.clock()
+	if len(p.events) == 0 {
+		p.err = fmt.Errorf("%w: %s $%04X read at cycle %d after the end of the recording",
+			ErrDiverged, name, addr, cycle)
+		return 0, false
+	}
+	e := p.events[0]
+	if e.Source != name || e.Addr != addr || e.Cycle != cycle {
+		p.err = fmt.Errorf("%w: %s $%04X read at cycle %d, expected %s $%04X at cycle %d",
+			ErrDiverged, name, addr, cycle, e.Source, e.Addr, e.Cycle)
+		return 0, false
+	}
+	p.events = p.events[1:]
+	return e.Value, true
+}
+
+// device wraps a bus.Device, replacing its reads.
+type device struct {
+	bus.Device
+	read func(addr uint16) byte
+}
+
+func (d *device) Read(addr uint16) byte {
+	return d.read(addr)
+}
+
+// Peek returns what the wrapped device holds, without recording or
+// replaying anything.
+func (d *device) Peek(addr uint16) byte {
+	if p, ok := d.Device.(bus.Peeker); ok {
+		return p.Peek(addr)
+	}
+	return d.Device.Read(addr)
+}
+
+// Poke forwards to the wrapped device.
+func (d *device) Poke(val byte, addr uint16) {
+	if p, ok := d.Device.(bus.Poker); ok {
+		p.Poke(val, addr)
+		return
+	}
+	d.Device.Write(val, addr)
+}
@@ -0,0 +1,126 @@
+package replay
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/bus"
+)
+
+// counter is an input device returning a different value on every read.
+type counter struct {
+	n byte
+}
+
+func (c *counter) Read(uint16) byte {
+	c.n += 7
+	return c.n
+}
+
+func (c *counter) Write(byte, uint16) {}
+
+func newReplayTestBus(t *testing.T, d bus.Device) *bus.Bus {
+	t.Helper()
+	b := bus.New()
+	if err := b.Map(0xD000, 0xD00F, d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return b
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var cycle uint
+	clock := func() uint { return cycle }
+	rec := NewRecorder(clock)
+	b := newReplayTestBus(t, rec.Device("rng", &counter{}))
+
+	var recorded []byte
+	for _, addr := range []uint16{0xD000, 0xD001, 0xD000} {
+		cycle += 4
+		recorded = append(recorded, b.Read(addr))
+	}
+	cycle++
+	irq := rec.Input("irq", 1)
+
+	var out strings.Builder
+	if _, err := rec.Log().WriteTo(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "4 rng 0000 07\n8 rng 0001 0E\n12 rng 0000 15\n13 irq 0000 01\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+
+	log, err := ReadLog(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cycle = 0
+	p := NewPlayer(log, clock)
+	// The device replayed against starts from another state.
+	b = newReplayTestBus(t, p.Device("rng", &counter{n: 100}))
+	for i, addr := range []uint16{0xD000, 0xD001, 0xD000} {
+		cycle += 4
+		if actual := b.Read(addr); actual != recorded[i] {
+			t.Errorf("expected $%02X, actual $%02X\n", recorded[i], actual)
+		}
+	}
+	cycle++
+	if actual := p.Input("irq", 0); actual != irq {
+		t.Errorf("expected %d, actual %d\n", irq, actual)
+	}
+	if !p.Done() || p.Err() != nil {
+		t.Errorf("expected replay to be done without error, actual %v\n", p.Err())
+	}
+}
+
+func TestReplayDiverges(t *testing.T) {
+	var cycle uint
+	log := &Log{Events: []Event{{Cycle: 4, Source: "rng", Addr: 0, Value: 0x42}}}
+	p := NewPlayer(log, func() uint { return cycle })
+	b := newReplayTestBus(t, p.Device("rng", &counter{}))
+
+	cycle = 5
+	if actual := b.Read(0xD000); actual != 0x07 {
+		t.Errorf("expected the device to be read, actual $%02X\n", actual)
+	}
+
+	if err := p.Err(); !errors.Is(err, ErrDiverged) {
+		t.Errorf("expected %v, actual %v\n", ErrDiverged, err)
+	}
+}
+
+func TestReadLogErrors(t *testing.T) {
+	for _, s := range []string{"1 rng 0000", "x rng 0000 01", "1 rng 0000 100"} {
+		if _, err := ReadLog(strings.NewReader(s)); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: expected %v, actual %v\n", s, ErrSyntax, err)
+		}
+	}
+}
+
+func TestRecorderSeek(t *testing.T) {
+	var cycle uint
+	rec := NewRecorder(func() uint { return cycle })
+	b := newReplayTestBus(t, rec.Device("rng", &counter{}))
+	for range 3 {
+		cycle++
+		b.Read(0xD000)
+	}
+
+	cycle = 1
+	rec.Seek(1)
+	cycle++
+	if actual := b.Read(0xD000); actual != 0x0E {
+		t.Errorf("expected the recorded $0E, actual $%02X\n", actual)
+	}
+	// Another address than recorded: the rest of the recording is dropped.
+	cycle++
+	if actual := b.Read(0xD001); actual != 0x1C {
+		t.Errorf("expected the device to be read, actual $%02X\n", actual)
+	}
+
+	if n := len(rec.Log().Events); n != 3 || rec.Pos() != 3 {
+		t.Errorf("expected 3 events at position 3, actual %d at %d\n", n, rec.Pos())
+	}
+}
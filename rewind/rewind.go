@@ -0,0 +1,124 @@
+// Package rewind lets debuggers step a CPU backwards.
+//
+// A Rewinder takes a savestate of the machine every so many instructions.
+// Stepping back restores the last savestate before the instruction to go
+// back to and executes the instructions from there again, replaying the
+// inputs recorded in the meantime so that devices such as keyboards feed the
+// program the same values as the first time.
+package rewind
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/replay"
+	"github.com/leakedmemory/mos6502/savestate"
+)
+
+// DefaultInterval is the default number of instructions between savestates.
+const DefaultInterval = 1000
+
+// DefaultHistory is the default number of savestates kept.
+const DefaultHistory = 256
+
+// ErrHistory is returned when stepping back further than the history kept.
+var ErrHistory = errors.New("rewind: not enough history")
+
+// Rewinder records the history of a machine to step it back.
+type Rewinder struct {
+	m        *savestate.Machine
+	rec      *replay.Recorder
+	interval uint64
+	history  int
+
+	// count is the number of instructions executed since the Rewinder was
+	// created.
+	count     uint64
+	snapshots []snapshot
+}
+
+type snapshot struct {
+	count uint64
+	// pos is the position of the input recorder.
+	pos   int
+	state []byte
+}
+
+// New returns a Rewinder for m, taking a savestate every interval
+// instructions and keeping the last history ones. rec records the inputs of
+// the devices of m, or is nil if the machine has none. It adds an
+// instruction hook to m.CPU, and must be created before running it.
+func New(m *savestate.Machine, rec *replay.Recorder, interval uint, history int) (*Rewinder, error) {
+	r := &Rewinder{m: m, rec: rec, interval: uint64(max(interval, 1)), history: max(history, 1)}
+	if err := r.snapshot(); err != nil {
+		return nil, err
+	}
+	m.CPU.AddInstructionHook(r.executed)
+	return r, nil
+}
+
+// Instructions returns the number of instructions executed since the
+// Rewinder was created, less those stepped back.
+func (r *Rewinder) Instructions() uint64 {
+	return r.count
+}
+
+// StepBack brings the machine back to its state n instructions ago.
+func (r *Rewinder) StepBack(n uint64) error {
+	if n > r.count {
+		return fmt.Errorf("%w: %d instructions executed", ErrHistory, r.count)
+	}
+	target := r.count - n
+	i := len(r.snapshots) - 1
+	for i >= 0 && r.snapshots[i].count > target {
+		i--
+	}
+	if i < 0 {
+		return fmt.Errorf("%w: oldest state is %d instructions back", ErrHistory, r.count-r.snapshots[0].count)
+	}
+
+	s := r.snapshots[i]
+	r.snapshots = r.snapshots[:i+1]
+	if err := savestate.Load(bytes.NewReader(s.state), r.m); err != nil {
+		return fmt.Errorf("rewind: %w", err)
+	}
+	if r.rec != nil {
+		r.rec.Seek(s.pos)
+	}
+	r.count = s.count
+	for r.count < target {
+		if err := r.m.CPU.Step(); err != nil {
+			return fmt.Errorf("rewind: replaying: %w", err)
+		}
+	}
+	return nil
+}
+
+// executed is the instruction hook counting instructions and taking the
+// savestates.
+func (r *Rewinder) executed(cpu.InstructionEvent) {
+	r.count++
+	if r.count%r.interval == 0 {
+		// A missing savestate only makes stepping back replay more
+		// instructions, from the previous one.
+		_ = r.snapshot()
+	}
+}
+
+func (r *Rewinder) snapshot() error {
+	var buf bytes.Buffer
+	if err := savestate.Save(&buf, r.m); err != nil {
+		return fmt.Errorf("rewind: %w", err)
+	}
+	s := snapshot{count: r.count, state: buf.Bytes()}
+	if r.rec != nil {
+		s.pos = r.rec.Pos()
+	}
+	if len(r.snapshots) == r.history {
+		r.snapshots = append(r.snapshots[:0], r.snapshots[1:]...)
+	}
+	r.snapshots = append(r.snapshots, s)
+	return nil
+}
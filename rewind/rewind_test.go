@@ -0,0 +1,105 @@
+package rewind
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+	"github.com/leakedmemory/mos6502/replay"
+	"github.com/leakedmemory/mos6502/savestate"
+)
+
+// randomSub is a subroutine loading A with a different value on every call:
+// LDA #n; RTS.
+type randomSub struct {
+	n byte
+}
+
+func (d *randomSub) Read(addr uint16) byte {
+	if addr == 1 {
+		d.n += 7
+	}
+	return d.Peek(addr)
+}
+
+func (d *randomSub) Peek(addr uint16) byte {
+	return []byte{0xA9, d.n, 0x60}[addr]
+}
+
+func (d *randomSub) Write(byte, uint16) {}
+
+func newRewindTest(t *testing.T, interval uint, history int) (*Rewinder, *cpu.CPU) {
+	t.Helper()
+	b := bus.NewWithBackend(&memory.Memory{})
+	c := cpu.New(b)
+	c.Reset()
+	rec := replay.NewRecorder(c.Cycles)
+	if err := b.Map(0x0300, 0x0302, rec.Device("rng", &randomSub{})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// JSR $0300, ten times.
+	for i := range uint16(10) {
+		b.Write(0x20, 0x0200+3*i)
+		b.Write(0x00, 0x0201+3*i)
+		b.Write(0x03, 0x0202+3*i)
+	}
+
+	m := &savestate.Machine{CPU: c, Memory: b.DebugView()}
+	r, err := New(m, rec, interval, history)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return r, c
+}
+
+func TestStepBack(t *testing.T) {
+	r, c := newRewindTest(t, 4, DefaultHistory)
+	var states []cpu.State
+	for range 20 {
+		states = append(states, c.State())
+		if err := c.Step(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	states = append(states, c.State())
+
+	for _, n := range []uint64{1, 5, 7} {
+		if err := r.StepBack(n); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		at := int(r.Instructions())
+		if actual := c.State(); actual != states[at] {
+			t.Errorf("expected %+v, actual %+v\n", states[at], actual)
+		}
+	}
+
+	// Running forward again replays the values the device gave.
+	for at := int(r.Instructions()); at < len(states)-1; at++ {
+		if err := c.Step(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if actual := c.State(); actual != states[at+1] {
+			t.Errorf("expected %+v, actual %+v\n", states[at+1], actual)
+		}
+	}
+}
+
+func TestStepBackBeyondHistory(t *testing.T) {
+	r, c := newRewindTest(t, 2, 2)
+	for range 10 {
+		if err := c.Step(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	for _, n := range []uint64{3, 11} {
+		if err := r.StepBack(n); !errors.Is(err, ErrHistory) {
+			t.Errorf("%d: expected %v, actual %v\n", n, ErrHistory, err)
+		}
+	}
+	if err := r.StepBack(2); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+// Package savestate saves the state of a whole machine, the CPU, its memory
+// and the devices on the bus, to a file it can later be restored from.
+//
+// A savestate starts with an 8-byte magic number and a 16-bit format
+// version, followed by chunks made of a 4-byte id, a 32-bit length and the
+// chunk data, and ends with the CRC-32 of everything before it. Integers are
+// little-endian. The chunks are:
+//
+//	"CPU " registers A, X, Y, SP, PC (2 bytes), SR, a reserved byte,
+//	       then the cycle count and the pending stall cycles (8 bytes each)
+//	"MEM " the 65536 bytes of the address space
+//	"DEV " the length of the device name (1 byte), the name and the state
+//	       of the device
+//
+// Unknown chunks are skipped, so that newer versions of the format can add
+// chunks older readers ignore.
+//
+// ImportVSF and ExportVSF exchange the CPU and memory with the snapshots of
+// the VICE emulator instead.
+package savestate
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// Version is the version of the format written by Save.
+const Version = 1
+
+const (
+	addressSpaceSize = 0x10000
+	versionSize      = 2
+	chunkHeaderSize  = 8
+	checksumSize     = 4
+	cpuChunkSize     = 24
+	maxNameLength    = 0xFF
+)
+
+var magic = []byte{'6', '5', '0', '2', 'S', 'A', 'V', 0x1A}
+
+// Chunk ids.
+var (
+	cpuChunk    = [4]byte{'C', 'P', 'U', ' '}
+	memoryChunk = [4]byte{'M', 'E', 'M', ' '}
+	deviceChunk = [4]byte{'D', 'E', 'V', ' '}
+)
+
+var (
+	// ErrFormat is returned when loading data that is not a valid
+	// savestate.
+	ErrFormat = errors.New("savestate: invalid format")
+	// ErrChecksum is returned when a savestate was corrupted.
+	ErrChecksum = errors.New("savestate: checksum mismatch")
+	// ErrVersion is returned when loading a savestate written by a newer
+	// version of the format.
+	ErrVersion = errors.New("savestate: unsupported version")
+	// ErrDevice is returned when the devices of a savestate do not match
+	// those of the machine it is loaded into.
+	ErrDevice = errors.New("savestate: device mismatch")
+)
+
+// Device is a device whose state is saved, beyond what it shows in the
+// address space, such as the registers and timers of an I/O chip.
+type Device interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// Machine is what a savestate saves and restores.
+type Machine struct {
+	CPU *cpu.CPU
+	// Memory is the address space. It should be free of side effects, such
+	// as the DebugView of a bus: saving peeks at every address and loading
+	// pokes them, before the devices are restored.
+	Memory memory.ReadWriter
+	// Devices are saved and restored by name.
+	Devices map[string]Device
+}
+
+// Save writes the state of m to w.
+func Save(w io.Writer, m *Machine) error {
+	var buf bytes.Buffer
+	buf.Write(magic)
+	buf.Write(binary.LittleEndian.AppendUint16(nil, Version))
+
+	s := m.CPU.State()
+	data := []byte{s.A, s.X, s.Y, s.SP, byte(s.PC), byte(s.PC >> 8), s.SR, 0}
+	data = binary.LittleEndian.AppendUint64(data, uint64(s.Cycles))
+	data = binary.LittleEndian.AppendUint64(data, uint64(s.Stall))
+	writeChunk(&buf, cpuChunk, data)
+
+	mem := make([]byte, addressSpaceSize)
+	for addr := range mem {
+		mem[addr] = m.Memory.Read(uint16(addr))
+	}
+	writeChunk(&buf, memoryChunk, mem)
+
+	for _, name := range slices.Sorted(maps.Keys(m.Devices)) {
+		d := m.Devices[name]
+		if len(name) > maxNameLength {
+			return fmt.Errorf("savestate: device name %q too long", name)
+		}
+		state, err := d.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("savestate: saving device %q: %w", name, err)
+		}
+		data := append([]byte{byte(len(name))}, name...)
+		writeChunk(&buf, deviceChunk, append(data, state...))
+	}
+
+	buf.Write(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(buf.Bytes())))
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("savestate: %w", err)
+	}
+	return nil
+}
+
+func writeChunk(buf *bytes.Buffer, id [4]byte, data []byte) {
+	buf.Write(id[:])
+	buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(data))))
+	buf.Write(data)
+}
+
+// Load restores the state of m from r. The savestate is checked in full
+// before anything is restored, so m is left untouched on errors other than
+// those of its devices.
+func Load(r io.Reader, m *Machine) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("savestate: %w", err)
+	}
+	st, err := decode(b)
+	if err != nil {
+		return err
+	}
+	for name := range st.devices {
+		if _, ok := m.Devices[name]; !ok {
+			return fmt.Errorf("%w: unknown device %q", ErrDevice, name)
+		}
+	}
+	for name := range m.Devices {
+		if _, ok := st.devices[name]; !ok {
+			return fmt.Errorf("%w: missing device %q", ErrDevice, name)
+		}
+	}
+
+	m.CPU.SetState(*st.cpu)
+	for addr, val := range st.memory {
+		m.Memory.Write(val, uint16(addr))
+	}
+	for name, data := range st.devices {
+		if err := m.Devices[name].UnmarshalBinary(data); err != nil {
+			return fmt.Errorf("savestate: loading device %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+type state struct {
+	cpu     *cpu.State
+	memory  []byte
+	devices map[string][]byte
+}
+
+func decode(b []byte) (*state, error) {
+	if len(b) < len(magic)+versionSize+checksumSize || !bytes.Equal(b[:len(magic)], magic) {
+		return nil, ErrFormat
+	}
+	body, sum := b[:len(b)-checksumSize], b[len(b)-checksumSize:]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(sum) {
+		return nil, ErrChecksum
+	}
+	if v := binary.LittleEndian.Uint16(body[len(magic):]); v > Version {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, v)
+	}
+
+	st := &state{devices: make(map[string][]byte)}
+	rest := body[len(magic)+versionSize:]
+	for len(rest) != 0 {
+		if len(rest) < chunkHeaderSize {
+			return nil, fmt.Errorf("%w: truncated chunk", ErrFormat)
+		}
+		id := [4]byte(rest[:4])
+		n := binary.LittleEndian.Uint32(rest[4:chunkHeaderSize])
+		rest = rest[chunkHeaderSize:]
+		if uint64(n) > uint64(len(rest)) {
+			return nil, fmt.Errorf("%w: truncated chunk", ErrFormat)
+		}
+		data := rest[:n]
+		rest = rest[n:]
+
+		if err := st.chunk(id, data); err != nil {
+			return nil, err
+		}
+	}
+	if st.cpu == nil || st.memory == nil {
+		return nil, fmt.Errorf("%w: missing CPU or memory", ErrFormat)
+	}
+	return st, nil
+}
+
+func (st *state) chunk(id [4]byte, data []byte) error {
+	switch id {
+	case cpuChunk:
+		if len(data) != cpuChunkSize {
+			return fmt.Errorf("%w: invalid CPU chunk", ErrFormat)
+		}
+		st.cpu = &cpu.State{
+			Registers: cpu.Registers{
+				A:  data[0],
+				X:  data[1],
+				Y:  data[2],
+				SP: data[3],
+				PC: binary.LittleEndian.Uint16(data[4:]),
+				SR: data[6],
+			},
+			Cycles: uint(binary.LittleEndian.Uint64(data[8:])),
+			Stall:  uint(binary.LittleEndian.Uint64(data[16:])),
+		}
+	case memoryChunk:
+		if len(data) != addressSpaceSize {
+			return fmt.Errorf("%w: invalid memory chunk", ErrFormat)
+		}
+		st.memory = data
+	case deviceChunk:
+		if len(data) == 0 || len(data) < 1+int(data[0]) {
+			return fmt.Errorf("%w: invalid device chunk", ErrFormat)
+		}
+		name := string(data[1 : 1+data[0]])
+		st.devices[name] = data[1+data[0]:]
+	}
+	return nil
+}
+
+// SaveToFile saves the state of m to the file at path, replacing it.
+func SaveToFile(path string, m *Machine) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("savestate: %w", err)
+	}
+	if err := Save(f, m); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("savestate: %w", err)
+	}
+	return nil
+}
+
+// LoadFromFile restores the state of m from the file at path.
+func LoadFromFile(path string, m *Machine) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("savestate: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return Load(f, m)
+}
@@ -0,0 +1,101 @@
+package savestate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"path/filepath"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+type testDevice struct {
+	state []byte
+}
+
+func (d *testDevice) MarshalBinary() ([]byte, error) {
+	return d.state, nil
+}
+
+func (d *testDevice) UnmarshalBinary(data []byte) error {
+	d.state = bytes.Clone(data)
+	return nil
+}
+
+func newSavestateTestMachine() *Machine {
+	mem := &memory.Memory{}
+	c := cpu.New(mem)
+	c.Reset()
+	return &Machine{CPU: c, Memory: mem, Devices: map[string]Device{"via": &testDevice{}}}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	m := newSavestateTestMachine()
+	m.CPU.SetRegisters(cpu.Registers{A: 1, X: 2, Y: 3, SP: 0xF0, PC: 0x1234, SR: 0xA5})
+	m.CPU.Stall(5)
+	m.Memory.Write(0x42, 0x0000)
+	m.Memory.Write(0x99, 0xFFFF)
+	m.Devices["via"] = &testDevice{state: []byte{7, 8, 9}}
+
+	path := filepath.Join(t.TempDir(), "state.sav")
+	if err := SaveToFile(path, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restored := newSavestateTestMachine()
+	if err := LoadFromFile(path, restored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected, actual := m.CPU.State(), restored.CPU.State(); actual != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+	for _, addr := range []uint16{0x0000, 0xFFFF} {
+		if expected, actual := m.Memory.Read(addr), restored.Memory.Read(addr); actual != expected {
+			t.Errorf("expected $%02X at $%04X, actual $%02X\n", expected, addr, actual)
+		}
+	}
+	if actual := restored.Devices["via"].(*testDevice).state; !bytes.Equal(actual, []byte{7, 8, 9}) {
+		t.Errorf("expected device state [7 8 9], actual %v\n", actual)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Save(&buf, newSavestateTestMachine()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	saved := buf.Bytes()
+
+	corrupted := bytes.Clone(saved)
+	corrupted[len(magic)+versionSize+chunkHeaderSize] ^= 0xFF
+	noDevices := newSavestateTestMachine()
+	noDevices.Devices = nil
+
+	for _, tc := range []struct {
+		name     string
+		data     []byte
+		machine  *Machine
+		expected error
+	}{
+		{"magic", []byte("not a savestate file"), newSavestateTestMachine(), ErrFormat},
+		{"checksum", corrupted, newSavestateTestMachine(), ErrChecksum},
+		{"version", resealed(saved, func(b []byte) { b[len(magic)] = Version + 1 }), newSavestateTestMachine(), ErrVersion},
+		{"device", saved, noDevices, ErrDevice},
+	} {
+		if err := Load(bytes.NewReader(tc.data), tc.machine); !errors.Is(err, tc.expected) {
+			t.Errorf("%s: expected %v, actual %v\n", tc.name, tc.expected, err)
+		}
+	}
+}
+
+// resealed returns a copy of a savestate changed by fn, with its checksum
+// updated.
+func resealed(saved []byte, fn func([]byte)) []byte {
+	b := bytes.Clone(saved)
+	fn(b)
+	body := b[:len(b)-checksumSize]
+	return binary.LittleEndian.AppendUint32(body, crc32.ChecksumIEEE(body))
+}
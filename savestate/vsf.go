@@ -0,0 +1,166 @@
+package savestate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// VICE snapshot files (VSF) start with a header naming the machine, followed
+// by modules made of a 16-byte name, a major and a minor version, and a
+// 32-bit size counting the module header. Only the MAINCPU and C64MEM
+// modules are read and written: the state of the other chips is not
+// exchanged.
+const (
+	vsfMagic         = "VICE Snapshot File\x1a"
+	vsfVersionMagic  = "VICE Version\x1a"
+	vsfNameSize      = 16
+	vsfModuleHdrSize = vsfNameSize + 2 + 4
+	vsfMajor         = 2
+	vsfMinor         = 0
+	vsfMachine       = "C64"
+)
+
+// Versions of the modules written, those of VICE 3.
+const (
+	vsfCPUModule    = "MAINCPU"
+	vsfCPUMajor     = 1
+	vsfCPUMinor     = 1
+	vsfMemoryModule = "C64MEM"
+	vsfMemoryMajor  = 0
+	vsfMemoryMinor  = 0
+)
+
+const (
+	// vsfCPUSize is the size of the MAINCPU fields read: the clock,
+	// the registers, and the last opcode.
+	vsfCPUSize = 4 + 7 + 4
+	// vsfInterruptSize is the size of the interrupt state following
+	// them, written as no interrupt pending.
+	vsfInterruptSize = 4 * 4
+	// vsfPortSize is the size of the fields before the RAM in C64MEM: the
+	// data and direction registers of the CPU port and the EXROM and GAME
+	// lines of the expansion port.
+	vsfPortSize = 4
+)
+
+// ErrVSFModule is returned when importing a VICE snapshot missing the
+// MAINCPU or C64MEM module.
+var ErrVSFModule = errors.New("savestate: missing VSF module")
+
+// ExportVSF writes the CPU and the memory of m to w as a VICE snapshot of a
+// C64. The RAM is read through m.Memory: where ROM or I/O is mapped, their
+// content is saved as RAM.
+func ExportVSF(w io.Writer, m *Machine) error {
+	var buf bytes.Buffer
+	buf.WriteString(vsfMagic)
+	buf.Write([]byte{vsfMajor, vsfMinor})
+	buf.Write(vsfName(vsfMachine))
+	buf.WriteString(vsfVersionMagic)
+	// VICE version 3.5.0.0, revision 0.
+	buf.Write([]byte{3, 5, 0, 0, 0, 0, 0, 0})
+
+	s := m.CPU.State()
+	data := binary.LittleEndian.AppendUint32(nil, uint32(s.Cycles))
+	data = append(data, s.A, s.X, s.Y, s.SP, byte(s.PC), byte(s.PC>>8), s.SR)
+	// The last opcode and the interrupt state.
+	data = append(data, make([]byte, 4+vsfInterruptSize)...)
+	writeVSFModule(&buf, vsfCPUModule, vsfCPUMajor, vsfCPUMinor, data)
+
+	ram := make([]byte, addressSpaceSize)
+	for addr := range ram {
+		ram[addr] = m.Memory.Read(uint16(addr))
+	}
+	// The CPU port is followed by the EXROM and GAME lines before the RAM,
+	// and as driven and read after it.
+	dir, port := ram[0], ram[1]
+	data = append([]byte{port, dir, 0, 0}, ram...)
+	data = append(data, port, port, dir)
+	writeVSFModule(&buf, vsfMemoryModule, vsfMemoryMajor, vsfMemoryMinor, data)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("savestate: %w", err)
+	}
+	return nil
+}
+
+func vsfName(s string) []byte {
+	name := make([]byte, vsfNameSize)
+	copy(name, s)
+	return name
+}
+
+func writeVSFModule(buf *bytes.Buffer, name string, major, minor byte, data []byte) {
+	buf.Write(vsfName(name))
+	buf.Write([]byte{major, minor})
+	buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(vsfModuleHdrSize+len(data))))
+	buf.Write(data)
+}
+
+// ImportVSF restores the CPU and the memory of m from the VICE snapshot in r.
+// The cycle count is restored from the 32-bit clock of VICE, and the RAM is
+// written through m.Memory. The devices of m are left untouched.
+func ImportVSF(r io.Reader, m *Machine) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("savestate: %w", err)
+	}
+	headerSize := len(vsfMagic) + 2 + vsfNameSize
+	if len(b) < headerSize || string(b[:len(vsfMagic)]) != vsfMagic {
+		return ErrFormat
+	}
+	rest := b[headerSize:]
+	if bytes.HasPrefix(rest, []byte(vsfVersionMagic)) {
+		if len(rest) < len(vsfVersionMagic)+8 {
+			return fmt.Errorf("%w: truncated header", ErrFormat)
+		}
+		rest = rest[len(vsfVersionMagic)+8:]
+	}
+
+	var cpuData, memData []byte
+	for len(rest) != 0 {
+		if len(rest) < vsfModuleHdrSize {
+			return fmt.Errorf("%w: truncated module", ErrFormat)
+		}
+		name := string(bytes.TrimRight(rest[:vsfNameSize], "\x00"))
+		size := binary.LittleEndian.Uint32(rest[vsfNameSize+2:])
+		if size < vsfModuleHdrSize || uint64(size) > uint64(len(rest)) {
+			return fmt.Errorf("%w: truncated module %s", ErrFormat, name)
+		}
+		data := rest[vsfModuleHdrSize:size]
+		rest = rest[size:]
+
+		switch name {
+		case vsfCPUModule:
+			cpuData = data
+		case vsfMemoryModule:
+			memData = data
+		}
+	}
+	if cpuData == nil || memData == nil {
+		return ErrVSFModule
+	}
+	if len(cpuData) < vsfCPUSize || len(memData) < vsfPortSize+addressSpaceSize {
+		return fmt.Errorf("%w: truncated module", ErrFormat)
+	}
+
+	m.CPU.SetState(cpu.State{
+		Registers: cpu.Registers{
+			A:  cpuData[4],
+			X:  cpuData[5],
+			Y:  cpuData[6],
+			SP: cpuData[7],
+			PC: binary.LittleEndian.Uint16(cpuData[8:]),
+			SR: cpuData[10],
+		},
+		Cycles: uint(binary.LittleEndian.Uint32(cpuData)),
+	})
+	for addr, val := range memData[vsfPortSize : vsfPortSize+addressSpaceSize] {
+		m.Memory.Write(val, uint16(addr))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package savestate
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+func TestVSFRoundTrip(t *testing.T) {
+	m := newSavestateTestMachine()
+	m.CPU.SetState(cpu.State{
+		Registers: cpu.Registers{A: 1, X: 2, Y: 3, SP: 0xF0, PC: 0xE5CD, SR: 0x24},
+		Cycles:    123456,
+	})
+	m.Memory.Write(0x2F, 0x0000)
+	m.Memory.Write(0x37, 0x0001)
+	m.Memory.Write(0x99, 0xFFFF)
+
+	var buf bytes.Buffer
+	if err := ExportVSF(&buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("VICE Snapshot File\x1a\x02\x00C64\x00")) {
+		t.Errorf("unexpected header %q\n", buf.Bytes()[:40])
+	}
+	restored := newSavestateTestMachine()
+	if err := ImportVSF(&buf, restored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected, actual := m.CPU.State(), restored.CPU.State(); actual != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+	for _, addr := range []uint16{0x0000, 0x0001, 0xFFFF} {
+		if expected, actual := m.Memory.Read(addr), restored.Memory.Read(addr); actual != expected {
+			t.Errorf("expected $%02X at $%04X, actual $%02X\n", expected, addr, actual)
+		}
+	}
+}
+
+func TestImportVSFWithoutVersionHeader(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString(vsfMagic)
+	buf.Write([]byte{1, 1})
+	buf.Write(vsfName("C64"))
+	writeVSFModule(&buf, "MAINCPU", 1, 1, []byte{0x10, 0, 0, 0, 0xAA, 0, 0, 0xFF, 0x00, 0xC0, 0x20, 0, 0, 0, 0})
+	writeVSFModule(&buf, "C64MEM", 0, 0, make([]byte, vsfPortSize+addressSpaceSize))
+	m := newSavestateTestMachine()
+
+	if err := ImportVSF(&buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := cpu.State{Registers: cpu.Registers{A: 0xAA, SP: 0xFF, PC: 0xC000, SR: 0x20}, Cycles: 0x10}
+	if actual := m.CPU.State(); actual != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+}
+
+func TestImportVSFErrors(t *testing.T) {
+	var missing bytes.Buffer
+	missing.WriteString(vsfMagic)
+	missing.Write([]byte{vsfMajor, vsfMinor})
+	missing.Write(vsfName("C64"))
+	writeVSFModule(&missing, "MAINCPU", 1, 1, make([]byte, vsfCPUSize))
+
+	for _, tc := range []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"magic", []byte("VICE Snapshot"), ErrFormat},
+		{"module", missing.Bytes(), ErrVSFModule},
+	} {
+		if err := ImportVSF(bytes.NewReader(tc.data), newSavestateTestMachine()); !errors.Is(err, tc.expected) {
+			t.Errorf("%s: expected %v, actual %v\n", tc.name, tc.expected, err)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+// Package script runs debugging scripts: handlers reacting to the execution
+// of an address or to accesses to memory, which log data, change memory or
+// stop the program, such as
+//
+//	# Dump the sprite table every frame.
+//	on exec vblank
+//	    log "frame", CYCLES
+//	    dump $0200, $023F
+//	end
+//
+//	on write $D020 if NEW > 15
+//	    log "bad border color", NEW, "at", PC
+//	    stop
+//	end
+//
+// A handler starts with on, the event, an address or a range of addresses
+// separated by a comma, and an optional condition after if. The events are
+// exec, the fetch of an opcode, and read, write and access, the data
+// accesses. The statements of the handler are:
+//
+//	log item, ...       print strings and the values of expressions
+//	poke addr, value    change memory
+//	dump start, end     print a hex dump of memory
+//	stop                stop the program once the instruction completes
+//
+// Addresses, conditions and values are expressions of package expr, over the
+// registers and flags of the CPU and memory. In handlers of memory accesses,
+// ADDR is the address accessed, and OLD and NEW the byte before and after
+// it. Lines starting with # are comments.
+package script
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/expr"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// ErrSyntax is returned for scripts that can not be parsed.
+var ErrSyntax = errors.New("script: syntax error")
+
+// Events handlers react to.
+var events = map[string]cpu.Access{
+	"exec":   cpu.AccessExecute,
+	"read":   cpu.AccessRead,
+	"write":  cpu.AccessWrite,
+	"access": cpu.AccessReadWrite,
+}
+
+// Script is a parsed script.
+type Script struct {
+	handlers []*handler
+
+	cpu *cpu.CPU
+	mem memory.ReadWriter
+	out io.Writer
+	ids []int
+	err error
+}
+
+type handler struct {
+	line       int
+	access     cpu.Access
+	start, end *expr.Expr
+	cond       *expr.Expr
+	stmts      []stmt
+}
+
+type stmt struct {
+	line int
+	op   string
+	args []arg
+}
+
+// arg is a string or an expression.
+type arg struct {
+	s string
+	e *expr.Expr
+}
+
+// Parse parses the script read from r. Names lookup knows, such as the
+// symbols of the program, are replaced by their value; lookup may be nil.
+func Parse(r io.Reader, lookup func(name string) (int, bool)) (*Script, error) {
+	p := &parser{lookup: lookup}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		p.line++
+		if err := p.parseLine(strings.TrimSpace(sc.Text())); err != nil {
+			return nil, err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("script: %w", err)
+	}
+	if p.cur != nil {
+		return nil, fmt.Errorf("%w: line %d: handler without end", ErrSyntax, p.cur.line)
+	}
+	return &Script{handlers: p.handlers}, nil
+}
+
+// Load parses the script in the file at path, like Parse.
+func Load(path string, lookup func(name string) (int, bool)) (*Script, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("script: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return Parse(f, lookup)
+}
+
+// Attach installs the handlers of the script on c as watchpoints. mem is used
+// to read and change memory: it should be free of side effects, such as the
+// DebugView of a bus. The output of the script goes to out.
+//
+// A handler that fails, such as on an expression dividing by zero, stops the
+// program, and its error is kept for Err.
+func (s *Script) Attach(c *cpu.CPU, mem memory.ReadWriter, out io.Writer) error {
+	s.cpu, s.mem, s.out = c, mem, out
+	env := c.Env()
+	for _, h := range s.handlers {
+		start, err := h.start.Eval(env)
+		if err != nil {
+			s.Detach()
+			return fmt.Errorf("script: line %d: %w", h.line, err)
+		}
+		end := start
+		if h.end != nil {
+			if end, err = h.end.Eval(env); err != nil {
+				s.Detach()
+				return fmt.Errorf("script: line %d: %w", h.line, err)
+			}
+		}
+		s.ids = append(s.ids, c.AddWatchpoint(uint16(start), uint16(end), h.access, func(e cpu.WatchEvent) {
+			s.run(h, e)
+		}))
+	}
+	return nil
+}
+
+// Detach removes the handlers of the script from the CPU.
+func (s *Script) Detach() {
+	for _, id := range s.ids {
+		s.cpu.RemoveWatchpoint(id)
+	}
+	s.ids = nil
+}
+
+// Err returns the error of the first handler that failed since Err was last
+// called, if any.
+func (s *Script) Err() error {
+	err := s.err
+	s.err = nil
+	return err
+}
+
+func (s *Script) run(h *handler, e cpu.WatchEvent) {
+	env := &eventEnv{Env: s.cpu.Env(), values: map[string]int{"PC": int(e.PC)}}
+	if e.Access != cpu.AccessExecute {
+		env.values["ADDR"] = int(e.Addr)
+		env.values["OLD"] = int(e.Old)
+		env.values["NEW"] = int(e.New)
+	}
+	if h.cond != nil {
+		v, err := h.cond.Eval(env)
+		if err != nil {
+			s.fail(h.line, err)
+			return
+		}
+		if v == 0 {
+			return
+		}
+	}
+	for _, st := range h.stmts {
+		if err := s.exec(st, env); err != nil {
+			s.fail(st.line, err)
+			return
+		}
+	}
+}
+
+func (s *Script) exec(st stmt, env expr.Env) error {
+	vals := make([]int, len(st.args))
+	for i, a := range st.args {
+		if a.e == nil {
+			continue
+		}
+		v, err := a.e.Eval(env)
+		if err != nil {
+			return err
+		}
+		vals[i] = v
+	}
+
+	switch st.op {
+	case "log":
+		items := make([]string, len(st.args))
+		for i, a := range st.args {
+			if a.e == nil {
+				items[i] = a.s
+			} else {
+				items[i] = formatValue(vals[i])
+			}
+		}
+		return s.printf("%s\n", strings.Join(items, " "))
+	case "poke":
+		s.mem.Write(byte(vals[1]), uint16(vals[0]))
+	case "dump":
+		if err := memory.DumpHex(s.out, s.mem, uint16(vals[0]), uint16(vals[1]), nil); err != nil {
+			return fmt.Errorf("script: %w", err)
+		}
+	case "stop":
+		s.cpu.Stop()
+	}
+	return nil
+}
+
+func (s *Script) fail(line int, err error) {
+	if s.err == nil {
+		s.err = fmt.Errorf("script: line %d: %w", line, err)
+	}
+	s.cpu.Stop()
+}
+
+func (s *Script) printf(format string, args ...any) error {
+	if _, err := fmt.Fprintf(s.out, format, args...); err != nil {
+		return fmt.Errorf("script: %w", err)
+	}
+	return nil
+}
+
+// formatValue shows bytes with two hex digits and other values with four.
+func formatValue(v int) string {
+	if v >= 0 && v <= 0xFF {
+		return fmt.Sprintf("$%02X", v)
+	}
+	return fmt.Sprintf("$%04X", uint16(v))
+}
+
+// eventEnv adds the values of an event to the names of the CPU.
+type eventEnv struct {
+	expr.Env
+	values map[string]int
+}
+
+func (e *eventEnv) Value(name string) (int, bool) {
+	if v, ok := e.values[strings.ToUpper(name)]; ok {
+		return v, true
+	}
+	return e.Env.Value(name)
+}
+
+type parser struct {
+	lookup   func(string) (int, bool)
+	line     int
+	handlers []*handler
+	cur      *handler
+}
+
+// argCounts is the number of arguments of the statements, -1 for any.
+var argCounts = map[string]int{
+	"log":  -1,
+	"poke": 2,
+	"dump": 2,
+	"stop": 0,
+}
+
+func (p *parser) parseLine(line string) error {
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	word, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+	switch {
+	case word == "on":
+		if p.cur != nil {
+			return p.errorf("handler inside a handler")
+		}
+		return p.parseHandler(rest)
+	case p.cur == nil:
+		return p.errorf("statement outside a handler")
+	case word == "end":
+		p.handlers = append(p.handlers, p.cur)
+		p.cur = nil
+		return nil
+	}
+
+	n, ok := argCounts[word]
+	if !ok {
+		return p.errorf("unknown statement %q", word)
+	}
+	args, err := p.parseArgs(rest)
+	if err != nil {
+		return err
+	}
+	if n >= 0 && len(args) != n || n < 0 && len(args) == 0 {
+		return p.errorf("wrong number of arguments to %s", word)
+	}
+	for _, a := range args {
+		if a.e == nil && word != "log" {
+			return p.errorf("%s takes expressions", word)
+		}
+	}
+	p.cur.stmts = append(p.cur.stmts, stmt{line: p.line, op: word, args: args})
+	return nil
+}
+
+// parseHandler parses what follows on: the event, the addresses and the
+// condition.
+func (p *parser) parseHandler(s string) error {
+	event, rest, _ := strings.Cut(s, " ")
+	access, ok := events[event]
+	if !ok {
+		return p.errorf("unknown event %q", event)
+	}
+	h := &handler{line: p.line, access: access}
+	addrs, cond, hasCond := strings.Cut(rest, " if ")
+	if hasCond {
+		e, err := p.parseExpr(cond)
+		if err != nil {
+			return err
+		}
+		h.cond = e
+	}
+	args, err := p.parseArgs(addrs)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 || len(args) > 2 || args[0].e == nil || len(args) == 2 && args[1].e == nil {
+		return p.errorf("on takes an address or a range")
+	}
+	h.start = args[0].e
+	if len(args) == 2 {
+		h.end = args[1].e
+	}
+	p.cur = h
+	return nil
+}
+
+// parseArgs parses arguments separated by commas: quoted strings or
+// expressions.
+func (p *parser) parseArgs(s string) ([]arg, error) {
+	var args []arg
+	for s = strings.TrimSpace(s); s != ""; {
+		var a arg
+		var rest string
+		if s[0] == '"' {
+			prefix, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, p.errorf("invalid string %s", s)
+			}
+			a.s, _ = strconv.Unquote(prefix)
+			rest = strings.TrimSpace(s[len(prefix):])
+			if rest != "" && rest[0] != ',' {
+				return nil, p.errorf("expected a comma after %s", prefix)
+			}
+			rest = strings.TrimPrefix(rest, ",")
+		} else {
+			var item string
+			item, rest, _ = strings.Cut(s, ",")
+			e, err := p.parseExpr(item)
+			if err != nil {
+				return nil, err
+			}
+			a.e = e
+		}
+		args = append(args, a)
+		s = strings.TrimSpace(rest)
+	}
+	return args, nil
+}
+
+func (p *parser) parseExpr(s string) (*expr.Expr, error) {
+	e, err := expr.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: line %d: %w", ErrSyntax, p.line, err)
+	}
+	if p.lookup != nil {
+		e = e.Bind(p.lookup)
+	}
+	return e, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: line %d: %s", ErrSyntax, p.line, fmt.Sprintf(format, args...))
+}
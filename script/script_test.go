@@ -0,0 +1,135 @@
+package script
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/expr"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newScriptTest returns a CPU about to run
+//
+//	0200  JSR $0210
+//	0210  LDA #$07
+//	0212  RTS
+//
+// with the script src attached, and its output.
+func newScriptTest(t *testing.T, src string) (*Script, *cpu.CPU, *memory.Memory, *strings.Builder) {
+	t.Helper()
+	mem := &memory.Memory{}
+	for addr, b := range map[uint16][]byte{
+		0x0200: {0x20, 0x10, 0x02},
+		0x0210: {0xA9, 0x07, 0x60},
+	} {
+		for i, v := range b {
+			mem.Write(v, addr+uint16(i))
+		}
+	}
+	c := cpu.New(mem)
+	c.Reset()
+
+	lookup := func(name string) (int, bool) {
+		if name == "sub" {
+			return 0x0210, true
+		}
+		return 0, false
+	}
+	s, err := Parse(strings.NewReader(src), lookup)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := &strings.Builder{}
+	if err := s.Attach(c, mem, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s, c, mem, out
+}
+
+func TestScript(t *testing.T) {
+	src := `# Log the calls.
+on exec sub
+    log "in sub", PC, "A", A
+    poke $10, mem[$10] + 1
+end
+
+on write $01FE, $01FF if NEW == 2
+    log "pushed", NEW, "at", ADDR
+    dump $01F8, $01FF
+end
+`
+	_, c, mem, out := newScriptTest(t, src)
+
+	for range 3 {
+		if err := c.Step(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The return address pushed is $0202.
+	expected := "pushed $02 at $01FF\n" +
+		"01F8                          00 00 00 00 00 00 00 02  |        ........|\n" +
+		"pushed $02 at $01FE\n" +
+		"01F8                          00 00 00 00 00 00 02 02  |        ........|\n" +
+		"in sub $0210 A $00\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, out.String())
+	}
+	if mem.Read(0x10) != 1 {
+		t.Errorf("expected $01 at $0010, actual $%02X\n", mem.Read(0x10))
+	}
+}
+
+func TestScriptStop(t *testing.T) {
+	s, c, _, _ := newScriptTest(t, "on exec $0212\n stop\nend\n")
+
+	reason, err := c.Run()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reason != cpu.StopRequested || c.Registers().PC != 0x0203 {
+		t.Errorf("expected to stop after the RTS, actual %v at $%04X\n", reason, c.Registers().PC)
+	}
+
+	s.Detach()
+	c.SetRegisters(cpu.Registers{PC: 0x0200, SP: 0xFF})
+	if _, err := c.Run(); err == nil {
+		t.Errorf("expected the program to run to the invalid opcode")
+	}
+}
+
+func TestScriptRuntimeError(t *testing.T) {
+	s, c, _, _ := newScriptTest(t, "on exec $0210\n log 1 / X\nend\n")
+
+	reason, _ := c.Run()
+
+	if reason != cpu.StopRequested {
+		t.Errorf("expected %v, actual %v\n", cpu.StopRequested, reason)
+	}
+	if !errors.Is(s.Err(), expr.ErrDivisionByZero) {
+		t.Errorf("expected %v, actual %v\n", expr.ErrDivisionByZero, s.Err())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"log 1",
+		"on exec $0200\n",
+		"on jump $0200\nend",
+		"on exec\nend",
+		"on exec $0200\n on exec $0300\nend",
+		"on exec $0200\n poke 1\nend",
+		"on exec $0200\n dump \"a\", 2\nend",
+		"on exec $0200\n jump 1\nend",
+		"on exec $0200\n log \"a\" 1\nend",
+		"on exec $0200 if (\nend",
+	}
+
+	for _, src := range tests {
+		if _, err := Parse(strings.NewReader(src), nil); !errors.Is(err, ErrSyntax) {
+			t.Errorf("expected %v for %q, actual %v\n", ErrSyntax, src, err)
+		}
+	}
+}
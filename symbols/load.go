@@ -0,0 +1,121 @@
+package symbols
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrSyntax is returned for malformed symbol files.
+var ErrSyntax = errors.New("symbols: syntax error")
+
+// LoadVICE adds the labels of a VICE label file, as written by VICE and by
+// ld65 with -Ln, to t. Each line holds a label command:
+//
+//	al C:E5CD .reset_handler
+//
+// The C: memory space prefix and the leading dot of labels are optional.
+// Other commands are ignored.
+func (t *Table) LoadVICE(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || !strings.EqualFold(fields[0], "al") {
+			continue
+		}
+		if len(fields) != 3 {
+			return fmt.Errorf("%w: line %d", ErrSyntax, n)
+		}
+		hex := fields[1]
+		if _, after, ok := strings.Cut(hex, ":"); ok {
+			hex = after
+		}
+		addr, err := strconv.ParseUint(hex, 16, 16)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: invalid address %q", ErrSyntax, n, fields[1])
+		}
+		t.Add(strings.TrimPrefix(fields[2], "."), uint16(addr))
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("symbols: reading labels: %w", err)
+	}
+	return nil
+}
+
+// LoadDebugInfo adds the symbols of an ld65 debug info file, as written with
+// --dbgfile, to t. The labels and the equates fitting in 16 bits are added;
+// imports, which are resolved elsewhere, are not.
+func (t *Table) LoadDebugInfo(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		kind, rest, _ := strings.Cut(sc.Text(), "\t")
+		if kind != "sym" {
+			continue
+		}
+		attrs, err := parseAttributes(rest)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: %w", ErrSyntax, n, err)
+		}
+		name, val := attrs["name"], attrs["val"]
+		if attrs["type"] == "imp" || name == "" || val == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(val, 0, 32)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: invalid value %q", ErrSyntax, n, val)
+		}
+		if v <= 0xFFFF {
+			t.Add(name, uint16(v))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("symbols: reading debug info: %w", err)
+	}
+	return nil
+}
+
+// parseAttributes parses the comma separated key=value attributes of a line
+// of debug info. Values may be quoted.
+func parseAttributes(s string) (map[string]string, error) {
+	attrs := make(map[string]string)
+	for s != "" {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("missing value for %q", key)
+		}
+		var val string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, errors.New("unterminated string")
+			}
+			val, rest = rest[1:end+1], rest[end+2:]
+			rest = strings.TrimPrefix(rest, ",")
+		} else {
+			val, rest, _ = strings.Cut(rest, ",")
+		}
+		attrs[key] = val
+		s = rest
+	}
+	return attrs, nil
+}
+
+// LoadFile adds the symbols of the file at path to t: ld65 debug info if its
+// name ends with .dbg, and VICE labels otherwise, such as .lbl and .vs
+// files.
+func (t *Table) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("symbols: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if strings.EqualFold(filepath.Ext(path), ".dbg") {
+		return t.LoadDebugInfo(f)
+	}
+	return t.LoadVICE(f)
+}
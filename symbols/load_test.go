@@ -0,0 +1,58 @@
+package symbols
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadVICE(t *testing.T) {
+	tab := New()
+	err := tab.LoadVICE(strings.NewReader("" +
+		"al C:E5CD .reset_handler\n" +
+		"al 00FB .ptr\n" +
+		"break E5CD\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, expected := range map[string]uint16{"reset_handler": 0xE5CD, "ptr": 0x00FB} {
+		if addr, ok := tab.Lookup(name); !ok || addr != expected {
+			t.Errorf("expected %s at $%04X, actual $%04X\n", name, expected, addr)
+		}
+	}
+	if tab.Len() != 2 {
+		t.Errorf("expected 2 symbols, actual %d\n", tab.Len())
+	}
+}
+
+func TestLoadDebugInfo(t *testing.T) {
+	tab := New()
+	err := tab.LoadDebugInfo(strings.NewReader("" +
+		"version\tmajor=2,minor=0\n" +
+		"file\tid=0,name=\"main, with comma.s\",size=120,mtime=0x5F000000,mod=0\n" +
+		"sym\tid=0,name=\"reset\",addrsize=absolute,size=3,scope=0,def=1,ref=4,val=0x8000,seg=0,type=lab\n" +
+		"sym\tid=1,name=\"SCREEN\",addrsize=absolute,scope=0,def=2,val=1024,type=equ\n" +
+		"sym\tid=2,name=\"chrout\",addrsize=absolute,scope=0,def=3,type=imp,exp=5\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, expected := range map[string]uint16{"reset": 0x8000, "SCREEN": 0x0400} {
+		if addr, ok := tab.Lookup(name); !ok || addr != expected {
+			t.Errorf("expected %s at $%04X, actual $%04X\n", name, expected, addr)
+		}
+	}
+	if _, ok := tab.Lookup("chrout"); ok {
+		t.Errorf("expected imports to be skipped\n")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	if err := New().LoadVICE(strings.NewReader("al C:XYZ .bad\n")); !errors.Is(err, ErrSyntax) {
+		t.Errorf("expected %v, actual %v\n", ErrSyntax, err)
+	}
+	if err := New().LoadDebugInfo(strings.NewReader("sym\tid=0,name=\"bad\n")); !errors.Is(err, ErrSyntax) {
+		t.Errorf("expected %v, actual %v\n", ErrSyntax, err)
+	}
+}
@@ -0,0 +1,75 @@
+// Package symbols maps between addresses and the names programs give them.
+package symbols
+
+import (
+	"cmp"
+	"iter"
+	"maps"
+	"slices"
+)
+
+// Table is a set of named addresses. An address can have several names.
+type Table struct {
+	byAddr map[uint16][]string
+	byName map[string]uint16
+}
+
+// New returns an empty Table.
+func New() *Table {
+	return &Table{
+		byAddr: make(map[uint16][]string),
+		byName: make(map[string]uint16),
+	}
+}
+
+// Add names addr. Adding a name again moves it to the new address.
+func (t *Table) Add(name string, addr uint16) {
+	if old, ok := t.byName[name]; ok {
+		t.byAddr[old] = slices.DeleteFunc(t.byAddr[old], func(n string) bool { return n == name })
+		if len(t.byAddr[old]) == 0 {
+			delete(t.byAddr, old)
+		}
+	}
+	t.byName[name] = addr
+	t.byAddr[addr] = append(t.byAddr[addr], name)
+}
+
+// Lookup returns the address named name.
+func (t *Table) Lookup(name string) (uint16, bool) {
+	addr, ok := t.byName[name]
+	return addr, ok
+}
+
+// Label returns the first name given to addr.
+func (t *Table) Label(addr uint16) (string, bool) {
+	names := t.byAddr[addr]
+	if len(names) == 0 {
+		return "", false
+	}
+	return names[0], true
+}
+
+// Names returns every name given to addr, in the order they were added.
+func (t *Table) Names(addr uint16) []string {
+	return slices.Clone(t.byAddr[addr])
+}
+
+// Len returns the number of names in the table.
+func (t *Table) Len() int {
+	return len(t.byName)
+}
+
+// All returns every name with its address, ordered by address and then by
+// name.
+func (t *Table) All() iter.Seq2[string, uint16] {
+	names := slices.SortedFunc(maps.Keys(t.byName), func(a, b string) int {
+		return cmp.Or(cmp.Compare(t.byName[a], t.byName[b]), cmp.Compare(a, b))
+	})
+	return func(yield func(string, uint16) bool) {
+		for _, name := range names {
+			if !yield(name, t.byName[name]) {
+				return
+			}
+		}
+	}
+}
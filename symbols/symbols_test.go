@@ -0,0 +1,56 @@
+package symbols
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	tab := New()
+	tab.Add("reset", 0xFF00)
+	tab.Add("start", 0xFF00)
+	tab.Add("nmi", 0xFF10)
+
+	if addr, ok := tab.Lookup("nmi"); !ok || addr != 0xFF10 {
+		t.Errorf("expected nmi at $FF10, actual $%04X\n", addr)
+	}
+	if label, ok := tab.Label(0xFF00); !ok || label != "reset" {
+		t.Errorf("expected reset, actual %q\n", label)
+	}
+	if names := tab.Names(0xFF00); !slices.Equal(names, []string{"reset", "start"}) {
+		t.Errorf("unexpected names %v\n", names)
+	}
+	if _, ok := tab.Label(0x1234); ok {
+		t.Errorf("expected no label at $1234")
+	}
+}
+
+func TestTableRedefine(t *testing.T) {
+	tab := New()
+	tab.Add("loop", 0x0200)
+	tab.Add("loop", 0x0210)
+
+	if _, ok := tab.Label(0x0200); ok {
+		t.Errorf("expected old address to lose its name")
+	}
+	if addr, _ := tab.Lookup("loop"); addr != 0x0210 || tab.Len() != 1 {
+		t.Errorf("expected loop at $0210, actual $%04X\n", addr)
+	}
+}
+
+func TestTableAll(t *testing.T) {
+	tab := New()
+	tab.Add("start", 0x0200)
+	tab.Add("irq", 0xFF10)
+	tab.Add("reset", 0x0200)
+
+	var actual []string
+	for name, addr := range tab.All() {
+		actual = append(actual, fmt.Sprintf("%s=$%04X", name, addr))
+	}
+	expected := []string{"reset=$0200", "start=$0200", "irq=$FF10"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("expected %v, actual %v\n", expected, actual)
+	}
+}
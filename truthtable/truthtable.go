@@ -0,0 +1,178 @@
+// Package truthtable runs the ALU instructions of the CPU over their inputs
+// and writes the results and flags they produce, one line per input, so that
+// the flag math can be diffed against published tables and other emulators.
+//
+// The ALU instructions are ADC, SBC, AND, ORA, EOR, the comparisons, BIT,
+// the shifts and rotates and the increments and decrements, in their
+// immediate, accumulator, implied and zero page forms. Only those the CPU
+// implements are tabulated: none yet, the CPU executing few instructions so
+// far.
+//
+// A table starts with a comment naming the opcode, followed by lines such as
+//
+//	80 80 80 80 20 -> 80 80 80 A0
+//
+// giving in hexadecimal the operand, then A, X, Y and the status register
+// before the instruction, and A, X, Y and the status register after it. X
+// and Y start with the value of A, so that the instructions working on them,
+// such as CPX, see the same inputs. The status register starts with C and D
+// set or clear, the flags ADC and SBC read, and the other flags clear. The
+// operand of a zero page instruction is the byte in memory it reads, and its
+// lines end with that byte after the instruction, for those modifying it.
+package truthtable
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"math/rand"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// codeStart is where the instruction is run, and operandAddr the address of
+// the operand of zero page instructions.
+const (
+	codeStart   uint16 = 0x0200
+	operandAddr byte   = 0x10
+)
+
+// aluMnemonics are the mnemonics of the ALU instructions.
+var aluMnemonics = map[string]bool{
+	"ADC": true, "SBC": true, "AND": true, "ORA": true, "EOR": true,
+	"CMP": true, "CPX": true, "CPY": true, "BIT": true,
+	"ASL": true, "LSR": true, "ROL": true, "ROR": true,
+	"INC": true, "DEC": true, "INX": true, "INY": true, "DEX": true, "DEY": true,
+}
+
+// Flags of the status register.
+const (
+	flagC      byte = 0x01
+	flagD      byte = 0x08
+	flagUnused byte = 0x20
+)
+
+// inputFlags are the status registers the instructions start with.
+var inputFlags = []byte{flagUnused, flagUnused | flagC, flagUnused | flagD, flagUnused | flagD | flagC}
+
+// Options tells which inputs to run.
+type Options struct {
+	// Sample, if not zero, is the number of inputs drawn at random instead of
+	// running them all.
+	Sample int
+	// Seed seeds the draw of the sampled inputs.
+	Seed int64
+}
+
+// input is the operand and the registers an instruction starts with.
+type input struct {
+	operand, a, sr byte
+}
+
+// ALU reports whether the table of op can be written: op is an implemented
+// ALU instruction, in a mode whose operand the table holds.
+func ALU(op byte) bool {
+	info := cpu.Opcode(op)
+	if !info.Implemented() || !aluMnemonics[info.Mnemonic] {
+		return false
+	}
+	switch info.Mode {
+	case cpu.Immediate, cpu.Accumulator, cpu.Implied, cpu.ZeroPage:
+		return true
+	}
+	return false
+}
+
+// Opcodes returns the opcodes whose table can be written, in order.
+func Opcodes() []byte {
+	var ops []byte
+	for op := range 256 {
+		if ALU(byte(op)) {
+			ops = append(ops, byte(op))
+		}
+	}
+	return ops
+}
+
+// Write writes the table of op to w.
+func Write(w io.Writer, op byte, opts Options) error {
+	if !ALU(op) {
+		return fmt.Errorf("truthtable: opcode $%02X is not an implemented ALU instruction", op)
+	}
+	return write(w, op, opts)
+}
+
+// write writes the table of op, which is not checked to be an ALU
+// instruction.
+func write(w io.Writer, op byte, opts Options) error {
+	info := cpu.Opcode(op)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %02X %s %v\n", op, info.Mnemonic, info.Mode)
+
+	mem := &memory.Memory{}
+	mem.Write(op, codeStart)
+	c := cpu.New(mem)
+	c.Reset()
+	zeroPage := info.Mode == cpu.ZeroPage
+	if zeroPage {
+		mem.Write(operandAddr, codeStart+1)
+	}
+	for in := range inputs(info.Mode, opts) {
+		if zeroPage {
+			mem.Write(in.operand, uint16(operandAddr))
+		} else {
+			mem.Write(in.operand, codeStart+1)
+		}
+		c.SetRegisters(cpu.Registers{A: in.a, X: in.a, Y: in.a, SP: 0xFF, PC: codeStart, SR: in.sr})
+		if err := c.Step(); err != nil {
+			return fmt.Errorf("truthtable: %w", err)
+		}
+		r := c.Registers()
+		fmt.Fprintf(bw, "%02X %02X %02X %02X %02X -> %02X %02X %02X %02X", in.operand, in.a, in.a, in.a, in.sr, r.A, r.X, r.Y, r.SR)
+		if zeroPage {
+			fmt.Fprintf(bw, " %02X", mem.Read(uint16(operandAddr)))
+		}
+		bw.WriteString("\n")
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("truthtable: %w", err)
+	}
+	return nil
+}
+
+// inputs returns the inputs of an instruction using mode: every operand, A
+// and flags, or a sample of them. Instructions working on the accumulator
+// or implied registers have no operand, which is left at zero.
+func inputs(mode cpu.AddressingMode, opts Options) iter.Seq[input] {
+	operands := 256
+	if mode == cpu.Accumulator || mode == cpu.Implied {
+		operands = 1
+	}
+	return func(yield func(input) bool) {
+		if opts.Sample != 0 {
+			rng := rand.New(rand.NewSource(opts.Seed))
+			for range opts.Sample {
+				in := input{
+					operand: byte(rng.Intn(operands)),
+					a:       byte(rng.Intn(256)),
+					sr:      inputFlags[rng.Intn(len(inputFlags))],
+				}
+				if !yield(in) {
+					return
+				}
+			}
+			return
+		}
+		for operand := range operands {
+			for a := range 256 {
+				for _, sr := range inputFlags {
+					if !yield(input{operand: byte(operand), a: byte(a), sr: sr}) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
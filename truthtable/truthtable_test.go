@@ -0,0 +1,81 @@
+package truthtable
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// ldaImmediate is the opcode of LDA #.
+const ldaImmediate byte = 0xA9
+
+func TestOpcodes(t *testing.T) {
+	ops := Opcodes()
+	for _, op := range ops {
+		if info := cpu.Opcode(op); !info.Implemented() || !aluMnemonics[info.Mnemonic] {
+			t.Errorf("expected implemented ALU instructions, actual $%02X %s\n", op, info.Mnemonic)
+		}
+	}
+	for op := range 256 {
+		info := cpu.Opcode(byte(op))
+		if info.Implemented() && aluMnemonics[info.Mnemonic] && info.Mode == cpu.Immediate && !slices.Contains(ops, byte(op)) {
+			t.Errorf("expected $%02X %s immediate\n", op, info.Mnemonic)
+		}
+	}
+	if slices.Contains(ops, ldaImmediate) {
+		t.Errorf("expected LDA # not to be an ALU instruction\n")
+	}
+}
+
+func TestWriteExhaustive(t *testing.T) {
+	var b bytes.Buffer
+	if err := write(&b, ldaImmediate, Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+
+	if expected := "# A9 LDA immediate"; lines[0] != expected {
+		t.Errorf("expected %q, actual %q\n", expected, lines[0])
+	}
+	if n := len(lines) - 1; n != 256*256*len(inputFlags) {
+		t.Errorf("expected %d lines, actual %d\n", 256*256*len(inputFlags), n)
+	}
+	for _, expected := range []string{
+		"00 42 42 42 21 -> 00 42 42 23",
+		"80 00 00 00 28 -> 80 00 00 A8",
+		"42 FF FF FF 20 -> 42 FF FF 20",
+	} {
+		if !strings.Contains(b.String(), "\n"+expected+"\n") {
+			t.Errorf("expected line %q\n", expected)
+		}
+	}
+}
+
+func TestWriteSample(t *testing.T) {
+	var a, b bytes.Buffer
+	if err := write(&a, ldaImmediate, Options{Sample: 100, Seed: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := write(&b, ldaImmediate, Options{Sample: 100, Seed: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := strings.Count(a.String(), "\n"); n != 101 {
+		t.Errorf("expected 101 lines, actual %d\n", n)
+	}
+	if a.String() != b.String() {
+		t.Errorf("expected the same sample for the same seed\n")
+	}
+}
+
+func TestWriteNotALU(t *testing.T) {
+	// SEI and LDA #
+	for _, op := range []byte{0x78, ldaImmediate} {
+		if err := Write(&bytes.Buffer{}, op, Options{}); err == nil {
+			t.Errorf("$%02X: expected an error, actual nil\n", op)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+// Package tui implements a full-screen terminal debugger: panes showing the
+// registers and flags, the disassembly around PC, the stack and a memory
+// dump, driven by single key presses.
+//
+// The package draws frames with ANSI escape codes and handles keys; putting
+// the terminal in raw mode and reading the keys is left to the caller, such
+// as cmd/debug.
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/disasm"
+	"github.com/leakedmemory/mos6502/memory"
+	"github.com/leakedmemory/mos6502/rewind"
+	"github.com/leakedmemory/mos6502/symbols"
+)
+
+// Layout of the screen.
+const (
+	screenWidth   = 80
+	disasmLines   = 14
+	disasmWidth   = 48
+	stackLines    = 6
+	memoryRows    = 8
+	memoryRowSize = 16
+)
+
+const (
+	stackPage = 0x0100
+	stackTop  = 0x01FF
+	// runSlice is the number of cycles run by Tick.
+	runSlice         = 100_000
+	maxAddressDigits = 4
+)
+
+// Escape sequences drawing the screen.
+const (
+	cursorHome   = "\x1b[H"
+	clearScreen  = "\x1b[2J"
+	reverseVideo = "\x1b[7m"
+	normalVideo  = "\x1b[0m"
+)
+
+// Keys with a special meaning.
+const (
+	keyCtrlC     = 0x03
+	keyEscape    = 0x1B
+	keyEnter     = '\r'
+	keyBackspace = 0x7F
+)
+
+// Keys understood by the debugger.
+const help = "s step  p back  n over  o out  c continue  b break  m memory  k calls  q quit"
+
+// Debugger is the state of the terminal debugger.
+type Debugger struct {
+	cpu     *cpu.CPU
+	mem     memory.ReadWriter
+	rewind  *rewind.Rewinder
+	printer disasm.Printer
+
+	// breakpoints maps the addresses of the breakpoints set from the
+	// debugger to their CPU ids.
+	breakpoints map[uint16]int
+	// disasmStart is the first address of the disassembly pane, kept while
+	// PC stays in it.
+	disasmStart uint16
+	memStart    uint16
+	// showCalls replaces the stack pane with the call stack.
+	showCalls bool
+	// anomaly is the bad return or stack access that stopped the program,
+	// if any.
+	anomaly fmt.Stringer
+	running bool
+	status  string
+	// prompt holds what is typed after m, while an address is entered.
+	prompt    string
+	prompting bool
+}
+
+// New returns a Debugger for c. mem is used to show memory: it should be free
+// of side effects, such as the DebugView of a bus. The debugger turns on call
+// tracking and stack checking in c, and stops the program at returns that do
+// not match the call stack and at misuses of the stack.
+func New(c *cpu.CPU, mem memory.ReadWriter) *Debugger {
+	pc := c.Registers().PC
+	d := &Debugger{
+		cpu:         c,
+		mem:         mem,
+		breakpoints: make(map[uint16]int),
+		disasmStart: pc,
+		memStart:    pc &^ (memoryRowSize - 1),
+	}
+	c.TrackCalls(func(a cpu.CallAnomaly) { d.stop(a) })
+	c.CheckStack(func(m cpu.StackMisuse) { d.stop(m) })
+	return d
+}
+
+// SetSymbols names the addresses in the disassembly with the symbols of t.
+func (d *Debugger) SetSymbols(t *symbols.Table) {
+	d.printer.Symbols = t
+}
+
+// SetRewinder enables stepping back with r.
+func (d *Debugger) SetRewinder(r *rewind.Rewinder) {
+	d.rewind = r
+}
+
+// Running reports whether the program was continued and has not stopped
+// yet. The caller runs it by calling Tick until it stops.
+func (d *Debugger) Running() bool {
+	return d.running
+}
+
+// Tick runs the continued program for a slice of time. A key press while the
+// program runs should be given to Key, which stops it.
+func (d *Debugger) Tick() {
+	if !d.running {
+		return
+	}
+	reason, err := d.cpu.RunFor(runSlice)
+	if reason == cpu.StopCycles {
+		return
+	}
+	d.running = false
+	d.stopped(reason, err)
+}
+
+// Key handles a key press and reports whether the debugger should quit.
+func (d *Debugger) Key(k byte) (quit bool) {
+	if d.running {
+		d.running = false
+		d.status = "interrupted"
+		return false
+	}
+	if d.prompting {
+		d.promptKey(k)
+		return false
+	}
+
+	d.status = ""
+	switch k {
+	case 's':
+		d.stopped(cpu.StopStepped, d.cpu.Step())
+	case 'p':
+		d.stepBack()
+	case 'n':
+		d.stopped(d.cpu.StepOver())
+	case 'o':
+		d.stopped(d.cpu.StepOut())
+	case 'c':
+		d.running = true
+		d.status = "running, press any key to interrupt"
+	case 'b':
+		d.toggleBreakpoint(d.cpu.Registers().PC)
+	case 'm':
+		d.prompting = true
+		d.prompt = ""
+	case 'k':
+		d.showCalls = !d.showCalls
+	case 'q', keyCtrlC:
+		return true
+	}
+	return false
+}
+
+func (d *Debugger) promptKey(k byte) {
+	switch {
+	case k == keyEscape || k == keyCtrlC:
+		d.prompting = false
+	case k == keyEnter || k == '\n':
+		d.prompting = false
+		addr, err := strconv.ParseUint(d.prompt, 16, 16)
+		if err != nil {
+			d.status = fmt.Sprintf("invalid address %q", d.prompt)
+			return
+		}
+		d.memStart = uint16(addr)
+	case k == keyBackspace || k == '\b':
+		if d.prompt != "" {
+			d.prompt = d.prompt[:len(d.prompt)-1]
+		}
+	case len(d.prompt) < maxAddressDigits && strings.ContainsRune("0123456789abcdefABCDEF", rune(k)):
+		d.prompt += string(k)
+	}
+}
+
+func (d *Debugger) stepBack() {
+	if d.rewind == nil {
+		d.status = "stepping back is not enabled"
+		return
+	}
+	if err := d.rewind.StepBack(1); err != nil {
+		d.status = err.Error()
+	}
+}
+
+func (d *Debugger) toggleBreakpoint(addr uint16) {
+	if id, ok := d.breakpoints[addr]; ok {
+		d.cpu.RemoveBreakpoint(id)
+		delete(d.breakpoints, addr)
+		d.status = fmt.Sprintf("breakpoint at $%04X removed", addr)
+		return
+	}
+	d.breakpoints[addr] = d.cpu.AddBreakpoint(addr)
+	d.status = fmt.Sprintf("breakpoint at $%04X set", addr)
+}
+
+// stop stops the program at an anomaly reported by the CPU, keeping the
+// first of an instruction.
+func (d *Debugger) stop(anomaly fmt.Stringer) {
+	if d.anomaly == nil {
+		d.anomaly = anomaly
+	}
+	d.cpu.Stop()
+}
+
+func (d *Debugger) stopped(reason cpu.StopReason, err error) {
+	switch {
+	case err != nil:
+		d.status = err.Error()
+	case d.anomaly != nil:
+		d.status = d.anomaly.String()
+	case reason == cpu.StopBreakpoint:
+		d.status = fmt.Sprintf("breakpoint at $%04X", d.cpu.Registers().PC)
+	case reason == cpu.StopInterrupt:
+		d.status = fmt.Sprintf("interrupt at $%04X", d.cpu.Registers().PC)
+	case reason == cpu.StopStackLimit:
+		d.status = fmt.Sprintf("stack limit, SP $%02X", d.cpu.Registers().SP)
+	}
+	d.anomaly = nil
+}
+
+// Render draws the screen to w.
+func (d *Debugger) Render(w io.Writer) error {
+	left := d.disassemblyPane()
+	right := append(d.registersPane(), "")
+	if d.showCalls {
+		right = append(right, d.callsPane()...)
+	} else {
+		right = append(right, d.stackPane()...)
+	}
+
+	var b strings.Builder
+	b.WriteString(cursorHome + clearScreen)
+	writeLine(&b, reverseVideo+pad(" mos6502 debugger", screenWidth)+normalVideo)
+	for i := range max(len(left), len(right)) {
+		writeLine(&b, pad(line(left, i), disasmWidth)+line(right, i))
+	}
+	writeLine(&b, "")
+	for _, l := range d.memoryPane() {
+		writeLine(&b, l)
+	}
+	switch {
+	case d.prompting:
+		b.WriteString("memory address: $" + d.prompt)
+	case d.status != "":
+		b.WriteString(d.status)
+	default:
+		b.WriteString(help)
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("tui: drawing screen: %w", err)
+	}
+	return nil
+}
+
+// writeLine ends lines with CR LF, as terminals in raw mode need.
+func writeLine(b *strings.Builder, s string) {
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
+
+func line(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return ""
+}
+
+func pad(s string, width int) string {
+	if len(s) >= width {
+		return s[:width]
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
+
+func (d *Debugger) disassemblyPane() []string {
+	pc := d.cpu.Registers().PC
+	insts := d.disassembleFrom(d.disasmStart)
+	if !containsInstruction(insts, pc) {
+		d.disasmStart = pc
+		insts = d.disassembleFrom(pc)
+	}
+
+	lines := make([]string, len(insts))
+	for i, inst := range insts {
+		marker := "  "
+		if inst.Addr == pc {
+			marker = "> "
+		}
+		if _, ok := d.breakpoints[inst.Addr]; ok {
+			marker = marker[:1] + "*"
+		}
+		lines[i] = marker + d.printer.Line(inst)
+	}
+	return lines
+}
+
+func (d *Debugger) disassembleFrom(addr uint16) []disasm.Instruction {
+	insts := make([]disasm.Instruction, 0, disasmLines)
+	for range disasmLines {
+		inst := disasm.Decode(d.mem, addr)
+		insts = append(insts, inst)
+		addr += inst.Len()
+	}
+	return insts
+}
+
+func containsInstruction(insts []disasm.Instruction, addr uint16) bool {
+	for _, inst := range insts {
+		if inst.Addr == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Debugger) registersPane() []string {
+	r := d.cpu.Registers()
+	return []string{
+		fmt.Sprintf("PC $%04X   SP $%02X", r.PC, r.SP),
+		fmt.Sprintf("A  $%02X     X  $%02X", r.A, r.X),
+		fmt.Sprintf("Y  $%02X     SR $%02X", r.Y, r.SR),
+		"NV-BDIZC",
+		fmt.Sprintf("%08b", r.SR),
+		fmt.Sprintf("cycles %d", d.cpu.Cycles()),
+	}
+}
+
+// stackPane shows the bytes on top of the stack, the most recently pushed
+// first.
+func (d *Debugger) stackPane() []string {
+	sp := d.cpu.Registers().SP
+	lines := []string{"stack"}
+	for addr := stackPage + int(sp) + 1; addr <= stackTop && len(lines) <= stackLines; addr++ {
+		lines = append(lines, fmt.Sprintf("$%04X  $%02X", addr, d.mem.Read(uint16(addr))))
+	}
+	if len(lines) == 1 {
+		lines = append(lines, "(empty)")
+	}
+	return lines
+}
+
+// callsPane shows the frames of the call stack, the innermost first.
+func (d *Debugger) callsPane() []string {
+	frames := d.cpu.CallStack()
+	lines := []string{"calls"}
+	for i := len(frames) - 1; i >= 0 && len(lines) <= stackLines; i-- {
+		f := frames[i]
+		lines = append(lines, fmt.Sprintf("%v $%04X  from $%04X", f.Kind, f.Target, f.Site))
+	}
+	if len(lines) == 1 {
+		lines = append(lines, "(empty)")
+	}
+	return lines
+}
+
+func (d *Debugger) memoryPane() []string {
+	lines := make([]string, memoryRows)
+	for row := range memoryRows {
+		start := d.memStart + uint16(row*memoryRowSize)
+		var hex, ascii strings.Builder
+		for i := range uint16(memoryRowSize) {
+			v := d.mem.Read(start + i)
+			fmt.Fprintf(&hex, " %02X", v)
+			if v >= 0x20 && v < 0x7F {
+				ascii.WriteByte(v)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		lines[row] = fmt.Sprintf("%04X %s  %s", start, hex.String(), ascii.String())
+	}
+	return lines
+}
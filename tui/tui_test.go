@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+	"github.com/leakedmemory/mos6502/rewind"
+	"github.com/leakedmemory/mos6502/savestate"
+)
+
+// newDebuggerTest returns a Debugger for a CPU about to run:
+//
+//	0200  JSR $0210
+//	0203  .byte $02
+//	0210  LDA #$07
+//	0212  RTS
+func newDebuggerTest() (*Debugger, *cpu.CPU) {
+	mem := &memory.Memory{}
+	for addr, b := range map[uint16][]byte{
+		0x0200: {0x20, 0x10, 0x02, 0x02},
+		0x0210: {0xA9, 0x07, 0x60},
+	} {
+		for i, v := range b {
+			mem.Write(v, addr+uint16(i))
+		}
+	}
+	c := cpu.New(mem)
+	c.Reset()
+	return New(c, mem), c
+}
+
+func renderTestHelper(t *testing.T, d *Debugger) string {
+	t.Helper()
+	var b strings.Builder
+	if err := d.Render(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return b.String()
+}
+
+func TestRender(t *testing.T) {
+	d, _ := newDebuggerTest()
+
+	screen := renderTestHelper(t, d)
+
+	for _, expected := range []string{
+		"> 0200  20 10 02  JSR $0210",
+		"PC $0200   SP $FF",
+		"00100000",
+		"(empty)",
+		"0200  20 10 02 02 00",
+		help,
+	} {
+		if !strings.Contains(screen, expected) {
+			t.Errorf("expected %q on the screen\n%s\n", expected, screen)
+		}
+	}
+}
+
+func TestKeysStepAndBreakpoint(t *testing.T) {
+	d, c := newDebuggerTest()
+
+	d.Key('s')
+	screen := renderTestHelper(t, d)
+	if !strings.Contains(screen, "> 0210  A9 07     LDA #$07") || !strings.Contains(screen, "$01FE  $02") {
+		t.Errorf("expected to be in the subroutine with a stack\n%s\n", screen)
+	}
+
+	d.Key('b')
+	d.Key('o')
+	if pc := c.Registers().PC; pc != 0x0203 {
+		t.Errorf("expected to step out to $0203, actual $%04X\n", pc)
+	}
+
+	c.SetRegisters(cpu.Registers{PC: 0x0200, SP: 0xFF})
+	d.Key('c')
+	for d.Running() {
+		d.Tick()
+	}
+	screen = renderTestHelper(t, d)
+	if !strings.Contains(screen, ">*0210") || !strings.Contains(screen, "breakpoint at $0210") {
+		t.Errorf("expected to stop at the breakpoint\n%s\n", screen)
+	}
+}
+
+func TestKeysMemoryPrompt(t *testing.T) {
+	d, _ := newDebuggerTest()
+
+	for _, k := range []byte("m0211\x7f0\r") {
+		d.Key(k)
+	}
+
+	if d.memStart != 0x0210 {
+		t.Errorf("expected memory at $0210, actual $%04X\n", d.memStart)
+	}
+	if quit := d.Key('q'); !quit {
+		t.Errorf("expected q to quit")
+	}
+}
+
+func TestKeysStepBack(t *testing.T) {
+	d, c := newDebuggerTest()
+	d.Key('p')
+	if screen := renderTestHelper(t, d); !strings.Contains(screen, "stepping back is not enabled") {
+		t.Errorf("expected stepping back to be refused\n%s\n", screen)
+	}
+
+	r, err := rewind.New(&savestate.Machine{CPU: c, Memory: d.mem}, nil, 1, 8)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d.SetRewinder(r)
+	d.Key('s')
+	d.Key('s')
+	d.Key('p')
+
+	expected := cpu.Registers{SP: 0xFD, PC: 0x0210, SR: 0x20}
+	if actual := c.Registers(); actual != expected {
+		t.Errorf("expected %+v, actual %+v\n", expected, actual)
+	}
+}
+
+func TestCallsPane(t *testing.T) {
+	d, _ := newDebuggerTest()
+
+	d.Key('s')
+	d.Key('k')
+	screen := renderTestHelper(t, d)
+	if !strings.Contains(screen, "JSR $0210  from $0200") {
+		t.Errorf("expected the call on the screen\n%s\n", screen)
+	}
+
+	d.Key('k')
+	screen = renderTestHelper(t, d)
+	if !strings.Contains(screen, "$01FE  $02") {
+		t.Errorf("expected the stack on the screen\n%s\n", screen)
+	}
+}
+
+func TestStopsAtUnmatchedReturn(t *testing.T) {
+	d, c := newDebuggerTest()
+
+	c.SetRegisters(cpu.Registers{PC: 0x0212, SP: 0xFD})
+	d.Key('c')
+	for d.Running() {
+		d.Tick()
+	}
+
+	if expected := "return without call at $0212"; d.status != expected {
+		t.Errorf("expected status %q, actual %q\n", expected, d.status)
+	}
+}
@@ -0,0 +1,159 @@
+// Package vcd dumps the bus activity of a 6502 to Value Change Dump files,
+// as viewed by GTKWave and other waveform viewers, to compare the emulator
+// with captures of real hardware.
+//
+// The dump has the address and data buses, R/W (high for reads), SYNC (high
+// on opcode fetches) and the active-low IRQ and NMI lines, with a time unit
+// per CPU cycle:
+//
+//	w := vcd.NewWriter(f, c)
+//	c.AddWatchpoint(0x0000, 0xFFFF, cpu.AccessAny, w.Record)
+//	c.Run()
+//	w.Flush()
+//
+// The emulator does not model the bus cycle by cycle: accesses are dumped at
+// the cycle count of the CPU when they are made, and the buses hold their
+// values through the internal cycles of instructions.
+package vcd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+// Identifiers of the signals in the dump.
+const (
+	addrID = "!"
+	dataID = "\""
+	rwID   = "#"
+	syncID = "$"
+	irqID  = "%"
+	nmiID  = "&"
+)
+
+const header = `$version mos6502 $end
+$timescale 1 us $end
+$scope module cpu $end
+$var wire 16 ! addr $end
+$var wire 8 " data $end
+$var wire 1 # rw $end
+$var wire 1 $ sync $end
+$var wire 1 % irq_n $end
+$var wire 1 & nmi_n $end
+$upscope $end
+$enddefinitions $end
+`
+
+// signals are the values dumped.
+type signals struct {
+	addr uint16
+	data byte
+	rw   bool
+	sync bool
+	irq  bool
+	nmi  bool
+}
+
+// Writer writes the accesses of a CPU as a Value Change Dump.
+type Writer struct {
+	w   *bufio.Writer
+	cpu *cpu.CPU
+	// last holds the values dumped so far, and time the last time written.
+	last    signals
+	time    uint
+	started bool
+	err     error
+}
+
+// NewWriter returns a Writer dumping the accesses of c to w.
+func NewWriter(w io.Writer, c *cpu.CPU) *Writer {
+	return &Writer{w: bufio.NewWriter(w), cpu: c}
+}
+
+// Record dumps an access. It is meant to be passed to the AddWatchpoint
+// method of the CPU, for the whole address space and every kind of access.
+func (v *Writer) Record(e cpu.WatchEvent) {
+	s := signals{
+		addr: e.Addr,
+		data: e.New,
+		rw:   e.Access != cpu.AccessWrite,
+		sync: e.Access == cpu.AccessExecute,
+		irq:  v.cpu.IRQ(),
+		nmi:  v.cpu.NMIPending(),
+	}
+	now := v.cpu.Cycles()
+	if !v.started {
+		v.printf("%s#%d\n$dumpvars\n", header, now)
+		v.dump(s, true)
+		v.printf("$end\n")
+		v.started, v.last, v.time = true, s, now
+		return
+	}
+	if s == v.last {
+		return
+	}
+	if now != v.time {
+		v.printf("#%d\n", now)
+		v.time = now
+	}
+	v.dump(s, false)
+	v.last = s
+}
+
+// dump writes the signals that changed since the last ones, or all of them.
+func (v *Writer) dump(s signals, all bool) {
+	if all || s.addr != v.last.addr {
+		v.printf("b%016b %s\n", s.addr, addrID)
+	}
+	if all || s.data != v.last.data {
+		v.printf("b%08b %s\n", s.data, dataID)
+	}
+	if all || s.rw != v.last.rw {
+		v.printf("%s%s\n", bit(s.rw), rwID)
+	}
+	if all || s.sync != v.last.sync {
+		v.printf("%s%s\n", bit(s.sync), syncID)
+	}
+	// The interrupt lines are active low.
+	if all || s.irq != v.last.irq {
+		v.printf("%s%s\n", bit(!s.irq), irqID)
+	}
+	if all || s.nmi != v.last.nmi {
+		v.printf("%s%s\n", bit(!s.nmi), nmiID)
+	}
+}
+
+func bit(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func (v *Writer) printf(format string, args ...any) {
+	if v.err == nil {
+		_, v.err = fmt.Fprintf(v.w, format, args...)
+	}
+}
+
+// Flush writes what is buffered, ending the dump at the current cycle of the
+// CPU, and returns the first error met writing the dump. Nothing is written
+// before the CPU makes an access.
+func (v *Writer) Flush() error {
+	if v.started {
+		if now := v.cpu.Cycles(); now != v.time {
+			v.printf("#%d\n", now)
+			v.time = now
+		}
+	}
+	if v.err == nil {
+		v.err = v.w.Flush()
+	}
+	if v.err != nil {
+		return fmt.Errorf("vcd: %w", v.err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package vcd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+func TestWriter(t *testing.T) {
+	mem := &memory.Memory{}
+	mem.Write(0xA9, 0x0200)
+	mem.Write(0x07, 0x0201)
+	c := cpu.New(mem)
+	c.Reset()
+	var b strings.Builder
+	w := NewWriter(&b, c)
+	c.AddWatchpoint(0x0000, 0xFFFF, cpu.AccessAny, w.Record)
+
+	c.SetIRQ(true)
+	c.SetRegisters(cpu.Registers{PC: 0x0200, SP: 0xFF, SR: 0x04})
+	if err := c.Step(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := header + `#7
+$dumpvars
+b0000001000000000 !
+b10101001 "
+1#
+1$
+0%
+1&
+$end
+#8
+b0000001000000001 !
+b00000111 "
+0$
+#9
+`
+	if b.String() != expected {
+		t.Errorf("expected %q, actual %q\n", expected, b.String())
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	c := cpu.New(&memory.Memory{})
+	var b strings.Builder
+
+	if err := NewWriter(&b, c).Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("expected nothing, actual %q\n", b.String())
+	}
+}
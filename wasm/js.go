@@ -0,0 +1,76 @@
+//go:build js && wasm
+
+package wasm
+
+import "syscall/js"
+
+// Register publishes e as the global object mos6502. The functions must be
+// kept, so the program registering them must not return.
+func Register(e *Emulator) {
+	api := map[string]any{
+		"load": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			if len(args) < 2 {
+				return failure(e, "load takes bytes and an address")
+			}
+			return result(e, e.Load(bytesOf(args[0]), uint16(args[1].Int())))
+		}),
+		"reset": js.FuncOf(func(js.Value, []js.Value) any {
+			e.Reset()
+			return result(e, nil)
+		}),
+		"step": js.FuncOf(func(js.Value, []js.Value) any {
+			return result(e, e.Step())
+		}),
+		"runFrame": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			if len(args) < 1 || args[0].Int() < 0 {
+				return failure(e, "runFrame takes a number of cycles")
+			}
+			return result(e, e.RunFrame(uint(args[0].Int())))
+		}),
+		"state": js.FuncOf(func(js.Value, []js.Value) any {
+			return result(e, nil)
+		}),
+		"read": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			if len(args) < 2 || args[1].Int() < 0 {
+				return failure(e, "read takes an address and a length")
+			}
+			data := e.Read(uint16(args[0].Int()), args[1].Int())
+			arr := js.Global().Get("Uint8Array").New(len(data))
+			js.CopyBytesToJS(arr, data)
+			return arr
+		}),
+		"write": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			if len(args) < 2 {
+				return failure(e, "write takes an address and bytes")
+			}
+			e.Write(uint16(args[0].Int()), bytesOf(args[1]))
+			return result(e, nil)
+		}),
+	}
+	js.Global().Set("mos6502", js.ValueOf(api))
+}
+
+func bytesOf(v js.Value) []byte {
+	data := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(data, v)
+	return data
+}
+
+// result returns the state of e, with err if not nil.
+func result(e *Emulator, err error) any {
+	s := e.State()
+	obj := map[string]any{
+		"a": int(s.A), "x": int(s.X), "y": int(s.Y), "sp": int(s.SP), "sr": int(s.SR),
+		"pc": int(s.PC), "cycles": int(s.Cycles), "stop": s.Stop,
+	}
+	if err != nil {
+		obj["error"] = err.Error()
+	}
+	return js.ValueOf(obj)
+}
+
+func failure(e *Emulator, msg string) any {
+	obj := result(e, nil).(js.Value)
+	obj.Set("error", msg)
+	return obj
+}
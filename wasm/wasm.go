@@ -0,0 +1,109 @@
+// Package wasm exposes an emulated 6502 to JavaScript, once compiled to
+// WebAssembly, for playgrounds and teaching material running in browsers.
+//
+// Emulator is what the API drives, and Register, built only for js/wasm,
+// publishes it as the global object mos6502:
+//
+//	mos6502.load(bytes, addr)     load a Uint8Array at addr, starting there
+//	mos6502.reset()               reset the CPU, keeping the memory
+//	mos6502.step()                execute an instruction
+//	mos6502.runFrame(cycles)      run for cycles cycles, or until a fault
+//	mos6502.state()               the registers and the cycle count
+//	mos6502.read(addr, len)       len bytes from addr, as a Uint8Array
+//	mos6502.write(addr, bytes)    write a Uint8Array at addr
+//
+// Every function returns an object, with an error field if it failed, and
+// the state after it ran. The command wasm builds the module:
+//
+//	GOOS=js GOARCH=wasm go build -o mos6502.wasm ./cmd/wasm
+//
+// to be run with the wasm_exec.js support file of the Go distribution.
+package wasm
+
+import (
+	"fmt"
+
+	"github.com/leakedmemory/mos6502/bus"
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/loader"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// State is the state of the CPU returned to JavaScript.
+type State struct {
+	A, X, Y, SP, SR byte
+	PC              uint16
+	Cycles          uint
+	// Stop is why the last run stopped, if any.
+	Stop string
+}
+
+// Emulator is a 6502 with 64 KiB of RAM.
+type Emulator struct {
+	CPU *cpu.CPU
+	Bus *bus.Bus
+
+	stop string
+}
+
+// New returns an Emulator with its memory cleared and its CPU reset.
+func New() *Emulator {
+	e := &Emulator{Bus: bus.NewWithBackend(&memory.Memory{})}
+	e.CPU = cpu.New(e.Bus)
+	e.CPU.Reset()
+	return e
+}
+
+// Load writes program at addr and starts the CPU there.
+func (e *Emulator) Load(program []byte, addr uint16) error {
+	seg := loader.Segment{Addr: addr, Data: program}
+	if err := seg.Load(e.Bus.DebugView()); err != nil {
+		return fmt.Errorf("wasm: %w", err)
+	}
+	r := e.CPU.Registers()
+	r.PC = addr
+	e.CPU.SetRegisters(r)
+	return nil
+}
+
+// Reset resets the CPU, keeping the memory.
+func (e *Emulator) Reset() {
+	e.CPU.Reset()
+	e.stop = ""
+}
+
+// Step executes an instruction.
+func (e *Emulator) Step() error {
+	return e.CPU.Step()
+}
+
+// RunFrame runs the CPU for cycles cycles, or until it stops on a
+// breakpoint or a fault, such as for a frame of an animation.
+func (e *Emulator) RunFrame(cycles uint) error {
+	reason, err := e.CPU.RunFor(cycles)
+	e.stop = reason.String()
+	return err
+}
+
+// State returns the state of the CPU.
+func (e *Emulator) State() State {
+	r := e.CPU.Registers()
+	return State{A: r.A, X: r.X, Y: r.Y, SP: r.SP, SR: r.SR, PC: r.PC, Cycles: e.CPU.Cycles(), Stop: e.stop}
+}
+
+// Read returns n bytes from addr, wrapping around the address space,
+// without side effects on devices.
+func (e *Emulator) Read(addr uint16, n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = e.Bus.Peek(addr + uint16(i))
+	}
+	return data
+}
+
+// Write writes data at addr, wrapping around the address space.
+func (e *Emulator) Write(addr uint16, data []byte) {
+	for i, b := range data {
+		e.Bus.Poke(b, addr+uint16(i))
+	}
+}
@@ -0,0 +1,41 @@
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+)
+
+func TestEmulatorRunFrame(t *testing.T) {
+	e := New()
+	// 0600  LDA #$42
+	// 0602  BRK
+	if err := e.Load([]byte{0xA9, 0x42, 0x00}, 0x0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := e.RunFrame(1000)
+
+	var invalid *cpu.InvalidOpcodeError
+	if !errors.As(err, &invalid) || invalid.PC != 0x0602 {
+		t.Errorf("expected a fault at the BRK, actual %v\n", err)
+	}
+	if s := e.State(); s.A != 0x42 || s.PC != 0x0602 || s.Stop != cpu.StopFault.String() {
+		t.Errorf("unexpected state %+v\n", s)
+	}
+}
+
+func TestEmulatorMemory(t *testing.T) {
+	e := New()
+
+	e.Write(0xFFFF, []byte{1, 2, 3})
+
+	if actual := e.Read(0xFFFF, 3); !bytes.Equal(actual, []byte{1, 2, 3}) {
+		t.Errorf("expected the writes to wrap around, actual % X\n", actual)
+	}
+	if err := e.Load(make([]byte, 2), 0xFFFF); err == nil {
+		t.Errorf("expected loading past the address space to fail\n")
+	}
+}
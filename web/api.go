@@ -0,0 +1,197 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/internal/session"
+	"github.com/leakedmemory/mos6502/jsontrace"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+const (
+	// maxBody is the size limit of the bodies of requests, beyond the
+	// address space.
+	maxBody = 0x10000
+	// traceBuffer is the number of lines of a trace buffered for a client.
+	traceBuffer = 4096
+)
+
+// Media types of the bodies of requests.
+const (
+	typeOctetStream = "application/octet-stream"
+	typeJSON        = "application/json"
+)
+
+// handleAPI registers the handlers of the HTTP API.
+func (s *Server) handleAPI() {
+	s.mux.HandleFunc("GET /api/state", s.command("state"))
+	s.mux.HandleFunc("POST /api/step", s.command("step"))
+	s.mux.HandleFunc("POST /api/continue", s.command("continue"))
+	s.mux.HandleFunc("POST /api/stop", s.command("stop"))
+	s.mux.HandleFunc("GET /api/memory", s.command("memory"))
+	s.mux.HandleFunc("PUT /api/memory", s.command("poke"))
+	s.mux.HandleFunc("POST /api/load", s.command("load"))
+	s.mux.HandleFunc("PUT /api/registers", s.command("registers"))
+	s.mux.HandleFunc("POST /api/breakpoints", s.command("break"))
+	s.mux.HandleFunc("DELETE /api/breakpoints", s.command("delete"))
+	s.mux.HandleFunc("GET /api/disasm", s.command("disasm"))
+	s.mux.HandleFunc("GET /api/trace", s.serveTrace)
+}
+
+// command returns the handler executing the command cmd, with the arguments
+// of the query and the body of the request.
+func (s *Server) command(cmd string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			if err := checkContentType(cmd, r); err != nil {
+				http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+		req, err := parseRequest(cmd, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, ev, err := s.exec(req)
+		switch {
+		case errors.Is(err, session.ErrRunning):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case ev != nil:
+			result = ev
+		}
+		w.Header().Set("Content-Type", typeJSON)
+		_ = json.NewEncoder(w).Encode(result)
+	}
+}
+
+// checkContentType returns an error unless r, a request changing the state
+// of the command cmd, has a media type browsers do not send across origins
+// without asking the server first: raw bytes for poke and load, JSON for
+// registers, and either for the commands without a body. Pages of other
+// origins are thus unable to drive the debugger.
+func checkContentType(cmd string, r *http.Request) error {
+	ct := r.Header.Get("Content-Type")
+	if mt, _, err := mime.ParseMediaType(ct); err == nil {
+		switch cmd {
+		case "poke", "load":
+			if mt == typeOctetStream {
+				return nil
+			}
+		case "registers":
+			if mt == typeJSON {
+				return nil
+			}
+		default:
+			if mt == typeOctetStream || mt == typeJSON {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unsupported content type %q", ct)
+}
+
+// parseRequest returns the Request of the command cmd made by r.
+func parseRequest(cmd string, r *http.Request) (Request, error) {
+	req := Request{Cmd: cmd}
+	q := r.URL.Query()
+	var err error
+	if v := q.Get("addr"); v != "" {
+		if req.Addr, err = parseAddr(v); err != nil {
+			return req, err
+		}
+	}
+	for name, n := range map[string]*int{"len": &req.Len, "count": &req.Count} {
+		if v := q.Get(name); v != "" {
+			if *n, err = strconv.Atoi(v); err != nil {
+				return req, fmt.Errorf("invalid %s %q", name, v)
+			}
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBody))
+	if err != nil {
+		return req, fmt.Errorf("reading the body: %w", err)
+	}
+	switch cmd {
+	case "poke", "load":
+		req.Data = make([]int, len(body))
+		for i, b := range body {
+			req.Data[i] = int(b)
+		}
+	case "registers":
+		req.Registers = &cpu.Registers{}
+		if err := json.Unmarshal(body, req.Registers); err != nil {
+			return req, fmt.Errorf("invalid registers: %w", err)
+		}
+	}
+	return req, nil
+}
+
+func parseAddr(s string) (uint16, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "$"), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address %q", s)
+	}
+	return uint16(v), nil
+}
+
+// serveTrace streams the instructions executed until the client goes away.
+func (s *Server) serveTrace(w http.ResponseWriter, r *http.Request) {
+	lines := make(chan []byte, traceBuffer)
+	var hook int
+	s.session.Do(func(c *cpu.CPU, mem memory.ReadWriter) {
+		tw := jsontrace.NewWriter(lineWriter(lines), c, mem)
+		hook = c.AddInstructionHook(func(e cpu.InstructionEvent) {
+			tw.Instruction(e)
+			_ = tw.Flush()
+		})
+	})
+	defer s.session.Do(func(c *cpu.CPU, _ memory.ReadWriter) {
+		c.RemoveInstructionHook(hook)
+	})
+
+	w.Header().Set("Content-Type", "application/jsonl")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line := <-lines:
+			if _, err := w.Write(line); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// lineWriter sends what is written to it, the lines of a trace, dropping
+// them when the channel is full so that the CPU does not wait for clients.
+type lineWriter chan []byte
+
+func (lw lineWriter) Write(p []byte) (int, error) {
+	select {
+	case lw <- append([]byte(nil), p...):
+	default:
+	}
+	return len(p), nil
+}
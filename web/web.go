@@ -0,0 +1,332 @@
+// Package web serves a debugger to browsers: the state of a CPU and commands
+// to control it, over a WebSocket speaking JSON, and a minimal web page
+// using them.
+//
+// Clients connect to /ws and send requests, each answered by a response with
+// the same id:
+//
+//	{"id": 1, "cmd": "step"}
+//	{"id": 1, "result": {"registers": {...}, "cycles": 9, ...}}
+//
+// The commands are
+//
+//	state                          the State of the CPU
+//	step                           execute an instruction
+//	continue                       run until a breakpoint or a fault
+//	stop                           stop running
+//	break, delete {addr}           set or remove a breakpoint
+//	memory {addr, len}             read len bytes from addr
+//	poke {addr, data}              write the bytes of data from addr
+//	load {addr, data}              poke, and start the program at addr
+//	disasm {addr, count}           disassemble count instructions
+//	registers {registers}          change the registers
+//
+// A command that fails gets a response with an error instead of a result.
+// Whenever the CPU stops or its state is changed, every client receives an
+// Event with the new State.
+//
+// The same commands are served over plain HTTP under /api, for tools and
+// scripts, answered with the JSON of their result:
+//
+//	GET    /api/state
+//	POST   /api/step, /api/continue, /api/stop
+//	GET    /api/memory?addr=0200&len=16
+//	PUT    /api/memory?addr=0200        the body is the bytes to write
+//	POST   /api/load?addr=0200          the body is the program
+//	PUT    /api/registers               the body is the JSON of cpu.Registers
+//	POST   /api/breakpoints?addr=0200
+//	DELETE /api/breakpoints?addr=0200
+//	GET    /api/disasm?addr=0200&count=16
+//	GET    /api/trace
+//
+// Addresses are in hex. Stepping and stopping answer with the Event of the
+// CPU stopping, and a command the running program prevents fails with 409
+// Conflict. /api/trace streams the instructions executed while it is
+// connected, as the JSON lines of package jsontrace, dropping those the
+// client is too slow to receive.
+//
+// For pages of other origins not to drive the debugger through the browser
+// of its user, WebSocket handshakes with the Origin of another host are
+// refused, and the requests other than GET must have the Content-Type
+// application/octet-stream or application/json, the bodies of memory being
+// raw bytes and those of registers JSON.
+package web
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/disasm"
+	"github.com/leakedmemory/mos6502/internal/session"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+const readHeaderTimeout = 10 * time.Second
+
+// maxDisasm and maxMemory bound the size of a single request.
+const (
+	maxDisasm = 256
+	maxMemory = 0x1000
+)
+
+//go:embed static
+var static embed.FS
+
+var errCommand = errors.New("unknown command")
+
+// Request is a command sent by a client.
+type Request struct {
+	ID        int            `json:"id"`
+	Cmd       string         `json:"cmd"`
+	Addr      uint16         `json:"addr,omitempty"`
+	Len       int            `json:"len,omitempty"`
+	Count     int            `json:"count,omitempty"`
+	Data      []int          `json:"data,omitempty"`
+	Registers *cpu.Registers `json:"registers,omitempty"`
+}
+
+// Response answers the Request with the same ID.
+type Response struct {
+	ID     int    `json:"id"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Event is sent to every client when the state of the CPU changes.
+type Event struct {
+	Event string `json:"event"`
+	// Reason tells why the CPU stopped, for stopped events.
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+	State  State  `json:"state"`
+}
+
+// State is the state of the CPU shown to clients.
+type State struct {
+	Registers   cpu.Registers `json:"registers"`
+	Cycles      uint          `json:"cycles"`
+	Running     bool          `json:"running"`
+	Breakpoints []uint16      `json:"breakpoints"`
+}
+
+// Line is a disassembled instruction.
+type Line struct {
+	Addr  uint16 `json:"addr"`
+	Bytes []int  `json:"bytes"`
+	Text  string `json:"text"`
+}
+
+// Memory is a block of memory.
+type Memory struct {
+	Addr uint16 `json:"addr"`
+	Data []int  `json:"data"`
+}
+
+// Server serves a CPU to browsers. It is an http.Handler.
+type Server struct {
+	mux     *http.ServeMux
+	session *session.Session
+}
+
+// NewServer returns a Server debugging c. mem is used to read and write
+// memory for the clients: it should be free of side effects, such as the
+// DebugView of a bus.
+func NewServer(c *cpu.CPU, mem memory.ReadWriter) *Server {
+	s := &Server{
+		mux:     http.NewServeMux(),
+		session: session.New(c, mem),
+	}
+	root, _ := fs.Sub(static, "static")
+	s.mux.Handle("/", http.FileServerFS(root))
+	s.mux.HandleFunc("/ws", s.serveWebSocket)
+	s.handleAPI()
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	// Clients failing are dropped when their connection ends.
+	st, stop := s.session.Watch(func(ev session.Event) { _ = send(conn, event(ev)) })
+	defer stop()
+
+	if err := send(conn, Event{Event: "state", State: state(st)}); err != nil {
+		return
+	}
+	for {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(msg, &req); err != nil {
+			resp.Error = fmt.Sprintf("invalid request: %v", err)
+		} else {
+			resp = s.handle(req)
+		}
+		if err := send(conn, resp); err != nil {
+			return
+		}
+	}
+}
+
+func send(conn *wsConn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("web: %w", err)
+	}
+	return conn.WriteMessage(data)
+}
+
+// handle executes a request. The events it causes are sent to the clients
+// before it returns.
+func (s *Server) handle(req Request) Response {
+	result, _, err := s.exec(req)
+	resp := Response{ID: req.ID, Result: result}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+// exec executes a request, returning its result and, for the commands
+// stopping the CPU, the event of it stopping.
+func (s *Server) exec(req Request) (any, *Event, error) {
+	var st session.State
+	var err error
+	switch req.Cmd {
+	case "state":
+		return state(s.session.State()), nil, nil
+	case "memory":
+		return s.readMemory(req.Addr, req.Len), nil, nil
+	case "disasm":
+		return s.disassemble(req.Addr, req.Count), nil, nil
+	case "step", "stop":
+		var ev session.Event
+		if req.Cmd == "step" {
+			ev, err = s.session.Step()
+		} else {
+			ev = s.session.Stop()
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ev.Stopped {
+			return state(ev.State), nil, nil
+		}
+		wev := event(ev)
+		return wev.State, &wev, nil
+	case "continue":
+		st, err = s.session.Continue()
+	case "break":
+		st, err = s.session.AddBreakpoint(req.Addr)
+	case "delete":
+		st, err = s.session.RemoveBreakpoint(req.Addr)
+	case "poke", "load":
+		data, derr := toBytes(req.Data)
+		if derr != nil {
+			return nil, nil, derr
+		}
+		if req.Cmd == "poke" {
+			st, err = s.session.Write(req.Addr, data)
+		} else {
+			st, err = s.session.Load(req.Addr, data)
+		}
+	case "registers":
+		if req.Registers == nil {
+			return nil, nil, errors.New("registers expected")
+		}
+		st, err = s.session.SetRegisters(*req.Registers)
+	default:
+		return nil, nil, fmt.Errorf("%w %q", errCommand, req.Cmd)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return state(st), nil, nil
+}
+
+// toBytes returns the bytes of data, rejecting the values that are not
+// bytes rather than truncating them.
+func toBytes(data []int) ([]byte, error) {
+	b := make([]byte, len(data))
+	for i, v := range data {
+		if v < 0 || v > 0xFF {
+			return nil, fmt.Errorf("data[%d]: %d is not a byte", i, v)
+		}
+		b[i] = byte(v)
+	}
+	return b, nil
+}
+
+func state(st session.State) State {
+	return State{
+		Registers:   st.Registers,
+		Cycles:      st.Cycles,
+		Running:     st.Running,
+		Breakpoints: st.Breakpoints,
+	}
+}
+
+// event returns the Event of ev. The error of a fault is part of the event,
+// not of the response.
+func event(ev session.Event) Event {
+	e := Event{Event: "state", State: state(ev.State)}
+	if ev.Stopped {
+		e.Event, e.Reason = "stopped", ev.Reason
+	}
+	if ev.Err != nil {
+		e.Error = ev.Err.Error()
+	}
+	return e
+}
+
+func (s *Server) readMemory(addr uint16, n int) Memory {
+	data := s.session.Read(addr, min(n, maxMemory))
+	m := Memory{Addr: addr, Data: make([]int, len(data))}
+	for i, b := range data {
+		m.Data[i] = int(b)
+	}
+	return m
+}
+
+func (s *Server) disassemble(addr uint16, count int) []Line {
+	count = min(max(count, 0), maxDisasm)
+	lines := make([]Line, 0, count)
+	s.session.Do(func(_ *cpu.CPU, mem memory.ReadWriter) {
+		for range count {
+			inst := disasm.Decode(mem, addr)
+			l := Line{Addr: addr, Text: inst.String()}
+			for _, b := range inst.Bytes() {
+				l.Bytes = append(l.Bytes, int(b))
+			}
+			lines = append(lines, l)
+			addr += inst.Len()
+		}
+	})
+	return lines
+}
+
+// ListenAndServe serves s on the TCP address addr.
+func (s *Server) ListenAndServe(addr string) error {
+	srv := &http.Server{Addr: addr, Handler: s, ReadHeaderTimeout: readHeaderTimeout}
+	if err := srv.ListenAndServe(); err != nil {
+		return fmt.Errorf("web: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,416 @@
+package web
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leakedmemory/mos6502/cpu"
+	"github.com/leakedmemory/mos6502/jsontrace"
+	"github.com/leakedmemory/mos6502/memory"
+)
+
+// newWebTest serves a CPU about to run
+//
+//	0200  JSR $0210
+//	0203  .byte $02
+//	0210  LDA #$07
+//	0212  RTS
+func newWebTest(t *testing.T) *httptest.Server {
+	t.Helper()
+	mem := &memory.Memory{}
+	for addr, b := range map[uint16][]byte{
+		0x0200: {0x20, 0x10, 0x02, 0x02},
+		0x0210: {0xA9, 0x07, 0x60},
+	} {
+		for i, v := range b {
+			mem.Write(v, addr+uint16(i))
+		}
+	}
+	c := cpu.New(mem)
+	c.Reset()
+	srv := httptest.NewServer(NewServer(c, mem))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialTestHelper connects to the WebSocket of srv and reads the first state
+// event.
+func dialTestHelper(t *testing.T, srv *httptest.Server) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_, err = io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\n"+
+		"Connection: keep-alive, Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+		"Sec-WebSocket-Version: 13\r\n\r\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The accept value of the example handshake of RFC 6455.
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); resp.StatusCode != http.StatusSwitchingProtocols ||
+		accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("expected a handshake, actual %s with accept %q", resp.Status, accept)
+	}
+	c := &testClient{t: t, conn: conn, r: r}
+	if ev := c.event(); ev.Event != "state" {
+		t.Fatalf("expected a state event, actual %+v", ev)
+	}
+	return c
+}
+
+// writeFrame sends a masked frame, as clients must.
+func (c *testClient) writeFrame(op byte, payload []byte) {
+	c.t.Helper()
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{finBit | op}
+	if len(payload) < 126 {
+		frame = append(frame, maskBit|byte(len(payload)))
+	} else {
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		c.t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func (c *testClient) readFrame() (byte, []byte) {
+	c.t.Helper()
+	var h [2]byte
+	if _, err := io.ReadFull(c.r, h[:]); err != nil {
+		c.t.Fatalf("unexpected error: %v", err)
+	}
+	n := int(h[1])
+	if n == 126 {
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			c.t.Fatalf("unexpected error: %v", err)
+		}
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		c.t.Fatalf("unexpected error: %v", err)
+	}
+	return h[0] & 0x0F, payload
+}
+
+func (c *testClient) send(req Request) {
+	c.t.Helper()
+	data, err := json.Marshal(req)
+	if err != nil {
+		c.t.Fatalf("unexpected error: %v", err)
+	}
+	c.writeFrame(opText, data)
+}
+
+func (c *testClient) event() Event {
+	c.t.Helper()
+	_, data := c.readFrame()
+	var ev Event
+	if err := json.Unmarshal(data, &ev); err != nil || ev.Event == "" {
+		c.t.Fatalf("expected an event, actual %s", data)
+	}
+	return ev
+}
+
+// response reads the response to a request, decoding its result into v.
+func (c *testClient) response(v any) Response {
+	c.t.Helper()
+	_, data := c.readFrame()
+	var resp struct {
+		Response
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		c.t.Fatalf("unexpected error: %v", err)
+	}
+	if v != nil && resp.Result != nil {
+		if err := json.Unmarshal(resp.Result, v); err != nil {
+			c.t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return resp.Response
+}
+
+func TestStepAndContinue(t *testing.T) {
+	srv := newWebTest(t)
+	c := dialTestHelper(t, srv)
+	other := dialTestHelper(t, srv)
+
+	c.send(Request{ID: 1, Cmd: "step"})
+	ev := c.event()
+	if ev.Event != "stopped" || ev.State.Registers.PC != 0x0210 {
+		t.Errorf("expected to stop at $0210, actual %+v\n", ev)
+	}
+	if ev := other.event(); ev.State.Registers.PC != 0x0210 {
+		t.Errorf("expected the other client to see $0210, actual %+v\n", ev)
+	}
+	var st State
+	if resp := c.response(&st); resp.ID != 1 || st.Registers.PC != 0x0210 {
+		t.Errorf("expected the state at $0210, actual %+v %+v\n", resp, st)
+	}
+
+	c.send(Request{ID: 2, Cmd: "break", Addr: 0x0212})
+	c.event()
+	if resp := c.response(&st); len(st.Breakpoints) != 1 || st.Breakpoints[0] != 0x0212 {
+		t.Errorf("expected a breakpoint at $0212, actual %+v %+v\n", resp, st)
+	}
+
+	c.send(Request{ID: 3, Cmd: "continue"})
+	// The response and the events of continuing come in any order.
+	for stopped := false; !stopped; {
+		_, data := c.readFrame()
+		var ev Event
+		if err := json.Unmarshal(data, &ev); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ev.Event == "stopped" {
+			stopped = true
+			if ev.Reason != "breakpoint" || ev.State.Registers.PC != 0x0212 {
+				t.Errorf("expected a breakpoint at $0212, actual %+v\n", ev)
+			}
+		}
+	}
+}
+
+func TestMemoryAndDisasm(t *testing.T) {
+	c := dialTestHelper(t, newWebTest(t))
+
+	c.send(Request{ID: 1, Cmd: "poke", Addr: 0x0300, Data: []int{0xA9, 0x42}})
+	c.event()
+	c.response(nil)
+
+	var m Memory
+	c.send(Request{ID: 2, Cmd: "memory", Addr: 0x0300, Len: 3})
+	c.response(&m)
+	if m.Addr != 0x0300 || len(m.Data) != 3 || m.Data[0] != 0xA9 || m.Data[1] != 0x42 {
+		t.Errorf("expected the bytes poked, actual %+v\n", m)
+	}
+
+	var lines []Line
+	c.send(Request{ID: 3, Cmd: "disasm", Addr: 0x0300, Count: 1})
+	c.response(&lines)
+	if len(lines) != 1 || lines[0].Text != "LDA #$42" || len(lines[0].Bytes) != 2 {
+		t.Errorf("expected LDA #$42, actual %+v\n", lines)
+	}
+
+	for _, data := range [][]int{{0x100}, {-1}} {
+		c.send(Request{ID: 4, Cmd: "poke", Addr: 0x0300, Data: data})
+		if resp := c.response(nil); resp.Error == "" {
+			t.Errorf("expected an error for %v, actual %+v\n", data, resp)
+		}
+	}
+	c.send(Request{ID: 5, Cmd: "memory", Addr: 0x0300, Len: 1})
+	c.response(&m)
+	if m.Data[0] != 0xA9 {
+		t.Errorf("expected $A9 left at $0300, actual $%02X\n", m.Data[0])
+	}
+
+	c.send(Request{ID: 6, Cmd: "jump"})
+	if resp := c.response(nil); resp.ID != 6 || resp.Error == "" {
+		t.Errorf("expected an error, actual %+v\n", resp)
+	}
+}
+
+func TestControlFrames(t *testing.T) {
+	c := dialTestHelper(t, newWebTest(t))
+
+	c.writeFrame(opPing, []byte("hi"))
+	if op, payload := c.readFrame(); op != opPong || string(payload) != "hi" {
+		t.Errorf("expected a pong, actual %#x %q\n", op, payload)
+	}
+
+	c.writeFrame(opClose, []byte{0x03, 0xE8})
+	if op, payload := c.readFrame(); op != opClose || len(payload) != 2 {
+		t.Errorf("expected a close, actual %#x %q\n", op, payload)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	srv := newWebTest(t)
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "mos6502 debugger") {
+		t.Errorf("expected the page, actual %s\n", resp.Status)
+	}
+
+	resp, err = http.Get(srv.URL + "/ws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected %d without a handshake, actual %d\n", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func apiRequest(t *testing.T, method, url, body string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if method != http.MethodGet {
+		// The bodies of registers are JSON, those of memory raw bytes.
+		if strings.HasPrefix(body, "{") {
+			req.Header.Set("Content-Type", typeJSON)
+		} else {
+			req.Header.Set("Content-Type", typeOctetStream)
+		}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAPI(t *testing.T) {
+	srv := newWebTest(t)
+
+	var st State
+	// 0300  LDA #$2A
+	if code := apiRequest(t, http.MethodPost, srv.URL+"/api/load?addr=0300", "\xA9\x2A", &st); code != http.StatusOK {
+		t.Fatalf("expected %d, actual %d", http.StatusOK, code)
+	}
+	if st.Registers.PC != 0x0300 {
+		t.Errorf("expected PC $0300, actual $%04X\n", st.Registers.PC)
+	}
+
+	var ev Event
+	apiRequest(t, http.MethodPost, srv.URL+"/api/step", "", &ev)
+	if ev.Event != "stopped" || ev.State.Registers.A != 0x2A {
+		t.Errorf("unexpected event %+v\n", ev)
+	}
+
+	var m Memory
+	apiRequest(t, http.MethodGet, srv.URL+"/api/memory?addr=$02FF&len=3", "", &m)
+	if m.Addr != 0x02FF || len(m.Data) != 3 || m.Data[1] != 0xA9 || m.Data[2] != 0x2A {
+		t.Errorf("unexpected memory %+v\n", m)
+	}
+
+	apiRequest(t, http.MethodPut, srv.URL+"/api/registers", `{"A":1,"X":2,"Y":3,"SP":253,"PC":528,"SR":0}`, &st)
+	if st.Registers.PC != 0x0210 || st.Registers.SP != 0xFD {
+		t.Errorf("unexpected registers %+v\n", st.Registers)
+	}
+	apiRequest(t, http.MethodPost, srv.URL+"/api/breakpoints?addr=0212", "", &st)
+	if len(st.Breakpoints) != 1 || st.Breakpoints[0] != 0x0212 {
+		t.Errorf("expected a breakpoint at $0212, actual %v\n", st.Breakpoints)
+	}
+
+	if code := apiRequest(t, http.MethodGet, srv.URL+"/api/memory?addr=zz", "", nil); code != http.StatusBadRequest {
+		t.Errorf("expected %d, actual %d\n", http.StatusBadRequest, code)
+	}
+}
+
+func TestAPIContentType(t *testing.T) {
+	srv := newWebTest(t)
+
+	for _, tc := range []struct {
+		url, contentType string
+		expected         int
+	}{
+		{"/api/load?addr=0300", "text/plain", http.StatusUnsupportedMediaType},
+		{"/api/load?addr=0300", "", http.StatusUnsupportedMediaType},
+		{"/api/load?addr=0300", typeJSON, http.StatusUnsupportedMediaType},
+		{"/api/step", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"/api/load?addr=0300", typeOctetStream, http.StatusOK},
+		{"/api/step", typeJSON + "; charset=utf-8", http.StatusOK},
+	} {
+		resp, err := http.Post(srv.URL+tc.url, tc.contentType, strings.NewReader("\xEA"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != tc.expected {
+			t.Errorf("%s with %q: expected %d, actual %d\n", tc.url, tc.contentType, tc.expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	srv := newWebTest(t)
+
+	for origin, expected := range map[string]int{
+		"http://evil.example": http.StatusForbidden,
+		srv.URL:               http.StatusSwitchingProtocols,
+	} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/ws", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Errorf("%s: expected %d, actual %d\n", origin, expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestAPITrace(t *testing.T) {
+	srv := newWebTest(t)
+
+	resp, err := http.Get(srv.URL + "/api/trace")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	apiRequest(t, http.MethodPost, srv.URL+"/api/step", "", nil)
+
+	line, err := bufio.NewReader(resp.Body).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var inst jsontrace.Instruction
+	if err := json.Unmarshal(line, &inst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inst.PC != 0x0200 || inst.Disasm != "JSR $0210" {
+		t.Errorf("unexpected instruction %+v\n", inst)
+	}
+}
@@ -0,0 +1,233 @@
+package web
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// websocketGUID is appended to the key of the client to compute the accept
+// header of the handshake, as RFC 6455 specifies.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxMessageSize bounds the messages read from clients.
+const maxMessageSize = 1 << 20
+
+// Frame opcodes.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+const (
+	finBit  = 0x80
+	maskBit = 0x80
+)
+
+var (
+	errHandshake = errors.New("web: invalid websocket handshake")
+	errProtocol  = errors.New("web: websocket protocol error")
+)
+
+// wsConn is the server side of a WebSocket connection. Reads must come from
+// a single goroutine; writes may come from any.
+type wsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	mu   sync.Mutex
+	w    *bufio.Writer
+}
+
+// upgrade answers the WebSocket handshake of r and takes over its
+// connection. Browsers open WebSockets across origins, so the handshakes of
+// pages other than those of the server are refused.
+func upgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin websocket refused", http.StatusForbidden)
+		return nil, errHandshake
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		http.Error(w, "websocket handshake expected", http.StatusBadRequest)
+		return nil, errHandshake
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errHandshake
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("web: %w", err)
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	_, _ = fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("web: %w", err)
+	}
+	return &wsConn{conn: conn, r: rw.Reader, w: rw.Writer}, nil
+}
+
+// sameOrigin reports whether r comes from a page of the server, or from a
+// client other than a browser, which sends no Origin header.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadMessage returns the next text or binary message, answering the
+// control frames received before it. It returns io.EOF once the client
+// closes the connection.
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	var msg []byte
+	started := false
+	for {
+		op, fin, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			// Echo the status code, as the closing handshake asks.
+			_ = c.writeFrame(opClose, payload[:min(len(payload), 2)])
+			return nil, io.EOF
+		case opText, opBinary:
+			if started {
+				return nil, fmt.Errorf("%w: unfinished message", errProtocol)
+			}
+			started = true
+		case opContinuation:
+			if !started {
+				return nil, fmt.Errorf("%w: continuation without a message", errProtocol)
+			}
+		default:
+			return nil, fmt.Errorf("%w: opcode %#x", errProtocol, op)
+		}
+		if len(msg)+len(payload) > maxMessageSize {
+			return nil, fmt.Errorf("%w: message too large", errProtocol)
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (op byte, fin bool, payload []byte, err error) {
+	var h [2]byte
+	if _, err := io.ReadFull(c.r, h[:]); err != nil {
+		return 0, false, nil, err
+	}
+	fin, op = h[0]&finBit != 0, h[0]&0x0F
+	if h[1]&maskBit == 0 {
+		return 0, false, nil, fmt.Errorf("%w: unmasked frame", errProtocol)
+	}
+
+	n := uint64(h[1] &^ maskBit)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return 0, false, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return 0, false, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxMessageSize {
+		return 0, false, nil, fmt.Errorf("%w: frame too large", errProtocol)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+		return 0, false, nil, err
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return 0, false, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, fin, payload, nil
+}
+
+// WriteMessage sends data as a text message.
+func (c *wsConn) WriteMessage(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	header := []byte{finBit | op}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	// Errors of the buffered writes are returned by Flush.
+	_, _ = c.w.Write(header)
+	_, _ = c.w.Write(payload)
+	if err := c.w.Flush(); err != nil {
+		return fmt.Errorf("web: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}